@@ -7,6 +7,24 @@ import (
 
 // AgentConfigSpec defines the desired state of AgentConfig.
 type AgentConfigSpec struct {
+	// Extends names an AgentConfig in the same namespace that this one
+	// builds on. The base's effective configuration is merged first, as if
+	// it were listed before this AgentConfig in agentConfigRefs. A base may
+	// extend another AgentConfig; cycles are rejected when the configuration
+	// is resolved.
+	// +optional
+	Extends *AgentConfigReference `json:"extends,omitempty"`
+
+	// Overrides holds configuration that only applies to one agent type,
+	// keyed by agent type (claude-code, codex, gemini, opencode, cursor).
+	// The override for the consuming agent's type is merged right after this
+	// AgentConfig's own fields, with the same rules as agentConfigRefs:
+	// agentsMD is appended, plugins and skills are added, and mcpServers
+	// replace same-named servers.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k in ['claude-code', 'codex', 'gemini', 'opencode', 'cursor'])",message="overrides keys must be agent types: claude-code, codex, gemini, opencode or cursor"
+	Overrides map[string]AgentConfigOverride `json:"overrides,omitempty"`
+
 	// AgentsMD is written to the agent's instruction file
 	// (e.g., ~/.claude/CLAUDE.md for Claude Code).
 	// This is additive and does not overwrite the repo's own instruction files.
@@ -32,6 +50,67 @@ type AgentConfigSpec struct {
 	// MCP configuration (e.g., ~/.claude.json for Claude Code).
 	// +optional
 	MCPServers []MCPServerSpec `json:"mcpServers,omitempty"`
+
+	// Permissions restricts the tools, shell commands and files the agent
+	// may use. The policy is provider-neutral and is translated into the
+	// agent's native permission configuration (e.g., permission rules in
+	// ~/.claude/settings.json for Claude Code). Agent types that cannot
+	// enforce a rule fail the Task rather than run without it.
+	// +optional
+	Permissions *AgentPermissions `json:"permissions,omitempty"`
+}
+
+// AgentPermissions is a provider-neutral tool permission policy. Tools are
+// named "shell", "read", "edit", "web-fetch" and "web-search" for built-in
+// tools, and "mcp:<server>" or "mcp:<server>/<tool>" for MCP tools, where
+// <tool> may contain * wildcards. Denied entries take precedence over
+// allowed ones.
+type AgentPermissions struct {
+	// AllowedTools limits the agent to the listed tools. Built-in tools and
+	// the tools of each MCP server are limited separately: listing a
+	// built-in tool denies the built-in tools that are not listed, and
+	// listing "mcp:<server>/<tool>" denies the other tools of that server,
+	// e.g. to leave only the read-only tools of a server. Tools of a group
+	// with no entry are not limited.
+	// +optional
+	AllowedTools []string `json:"allowedTools,omitempty"`
+
+	// DeniedTools lists tools the agent may not use.
+	// +optional
+	DeniedTools []string `json:"deniedTools,omitempty"`
+
+	// DeniedCommands lists shell command patterns the agent may not run,
+	// e.g. "rm -rf *" or "kubectl *". A * matches any text, and a trailing
+	// " *" also matches the command without arguments. Each command of a
+	// pipeline or command list is matched separately.
+	// +optional
+	DeniedCommands []string `json:"deniedCommands,omitempty"`
+
+	// DeniedPaths lists globs of files the agent may not edit, relative to
+	// the working directory, e.g. ".github/workflows/**". A * matches
+	// within a path segment and ** matches any number of segments.
+	// +optional
+	DeniedPaths []string `json:"deniedPaths,omitempty"`
+}
+
+// AgentConfigOverride is AgentConfig configuration for one agent type.
+type AgentConfigOverride struct {
+	// AgentsMD is appended to the AgentConfig's agentsMD.
+	// +optional
+	AgentsMD string `json:"agentsMD,omitempty"`
+
+	// Plugins are added to the AgentConfig's plugins.
+	// +optional
+	Plugins []PluginSpec `json:"plugins,omitempty"`
+
+	// Skills are added to the AgentConfig's skills.
+	// +optional
+	Skills []SkillsShSpec `json:"skills,omitempty"`
+
+	// MCPServers are added to the AgentConfig's mcpServers, replacing
+	// servers with the same name.
+	// +optional
+	MCPServers []MCPServerSpec `json:"mcpServers,omitempty"`
 }
 
 // PluginSpec defines a plugin bundle containing skills and agents.
@@ -166,17 +245,96 @@ type AgentConfigReference struct {
 	Name string `json:"name"`
 }
 
+const (
+	// AgentConfigConditionReady indicates whether Tasks, Sessions and
+	// WorkerPools can use the AgentConfig. It is False when any other
+	// condition is False.
+	AgentConfigConditionReady = "Ready"
+	// AgentConfigConditionValid indicates whether the configuration can be
+	// rendered for an agent: plugin, skill and MCP server names are valid,
+	// skills.sh sources are well-formed, env entries use supported
+	// EnvVarSource variants, permissions are well-formed, the plugin content
+	// fits in a ConfigMap and the extends chain has no cycle.
+	AgentConfigConditionValid = "Valid"
+	// AgentConfigConditionReferencesResolved indicates whether the Secrets,
+	// ConfigMaps and base AgentConfig referenced by the configuration exist
+	// and contain the referenced keys.
+	AgentConfigConditionReferencesResolved = "ReferencesResolved"
+)
+
+// AgentConfigUser identifies a resource that references an AgentConfig.
+type AgentConfigUser struct {
+	// Kind is the kind of the resource: Task, Session, WorkerPool,
+	// TaskSpawner or AgentConfig.
+	Kind string `json:"kind"`
+
+	// Name is the name of the resource.
+	Name string `json:"name"`
+}
+
+// AgentConfigUserCount is the number of resources of one kind that
+// reference an AgentConfig.
+type AgentConfigUserCount struct {
+	// Kind is the kind of the resources.
+	Kind string `json:"kind"`
+
+	// Count is the number of resources of the kind.
+	Count int32 `json:"count"`
+}
+
+// AgentConfigStatus defines the observed state of AgentConfig.
+type AgentConfigStatus struct {
+	// ObservedGeneration is the most recent generation validated by the
+	// controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// PluginBytes is the size of the plugin content rendered into the
+	// plugin ConfigMap of a Task using this AgentConfig, for the agent type
+	// with the largest content.
+	// +optional
+	PluginBytes int64 `json:"pluginBytes,omitempty"`
+
+	// UsedBy lists the resources in the namespace that reference this
+	// AgentConfig, directly or through the extends field of another
+	// AgentConfig. Tasks are listed until they finish. Only the first 20
+	// resources by kind and name are listed; UsedByCounts counts all of
+	// them.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	UsedBy []AgentConfigUser `json:"usedBy,omitempty"`
+
+	// UsedByCounts is the number of resources of each kind that reference
+	// this AgentConfig, including those not listed in UsedBy.
+	// +optional
+	// +listType=map
+	// +listMapKey=kind
+	UsedByCounts []AgentConfigUserCount `json:"usedByCounts,omitempty"`
+
+	// Conditions provides detailed status information.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
 // +genclient
-// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // AgentConfig is the Schema for the agentconfigs API.
 type AgentConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec AgentConfigSpec `json:"spec,omitempty"`
+	Spec   AgentConfigSpec   `json:"spec,omitempty"`
+	Status AgentConfigStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
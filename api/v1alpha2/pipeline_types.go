@@ -0,0 +1,390 @@
+package v1alpha2
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PipelinePhase represents the current phase of a Pipeline.
+type PipelinePhase string
+
+const (
+	// PipelinePhasePending means no step has started yet.
+	PipelinePhasePending PipelinePhase = "Pending"
+	// PipelinePhaseRunning means at least one step has created its Tasks.
+	PipelinePhaseRunning PipelinePhase = "Running"
+	// PipelinePhaseSucceeded means every step succeeded or was skipped.
+	PipelinePhaseSucceeded PipelinePhase = "Succeeded"
+	// PipelinePhaseFailed means at least one step failed or the pipeline
+	// definition is invalid.
+	PipelinePhaseFailed PipelinePhase = "Failed"
+)
+
+// PipelineStepPhase represents the current phase of a Pipeline step.
+type PipelineStepPhase string
+
+const (
+	// PipelineStepPhasePending means the step is waiting for its dependencies.
+	PipelineStepPhasePending PipelineStepPhase = "Pending"
+	// PipelineStepPhaseRunning means the step's Tasks have been created and
+	// at least one has not finished.
+	PipelineStepPhaseRunning PipelineStepPhase = "Running"
+	// PipelineStepPhaseSucceeded means all of the step's Tasks succeeded.
+	PipelineStepPhaseSucceeded PipelineStepPhase = "Succeeded"
+	// PipelineStepPhaseFailed means at least one of the step's Tasks failed
+	// or the step's Tasks could not be created.
+	PipelineStepPhaseFailed PipelineStepPhase = "Failed"
+	// PipelineStepPhaseSkipped means the step's runIf condition was not met.
+	PipelineStepPhaseSkipped PipelineStepPhase = "Skipped"
+)
+
+// PipelineRunIfWhen selects which dependency outcomes allow a step to run.
+type PipelineRunIfWhen string
+
+const (
+	// PipelineRunIfSucceeded runs the step when every dependency succeeded.
+	PipelineRunIfSucceeded PipelineRunIfWhen = "Succeeded"
+	// PipelineRunIfFailed runs the step when at least one dependency failed.
+	PipelineRunIfFailed PipelineRunIfWhen = "Failed"
+	// PipelineRunIfAlways runs the step once every dependency has finished,
+	// regardless of the outcome.
+	PipelineRunIfAlways PipelineRunIfWhen = "Always"
+)
+
+// PipelineResultOperator is the operator of a result condition.
+type PipelineResultOperator string
+
+const (
+	// PipelineResultOpIn matches when the result value is one of values.
+	PipelineResultOpIn PipelineResultOperator = "In"
+	// PipelineResultOpNotIn matches when the result is missing or its value
+	// is not one of values.
+	PipelineResultOpNotIn PipelineResultOperator = "NotIn"
+	// PipelineResultOpExists matches when the result key is present.
+	PipelineResultOpExists PipelineResultOperator = "Exists"
+	// PipelineResultOpDoesNotExist matches when the result key is absent.
+	PipelineResultOpDoesNotExist PipelineResultOperator = "DoesNotExist"
+)
+
+const (
+	// PipelineLabel is set on Tasks created by a Pipeline and names the
+	// owning Pipeline.
+	PipelineLabel = "kelos.dev/pipeline"
+	// PipelineStepLabel is set on Tasks created by a Pipeline and names the
+	// step that created them.
+	PipelineStepLabel = "kelos.dev/pipeline-step"
+)
+
+// PipelineResultCondition matches a structured result of a dependency step.
+//
+// +kubebuilder:validation:XValidation:rule="(self.operator == 'In' || self.operator == 'NotIn') == (has(self.values) && size(self.values) > 0)",message="values must be set for In and NotIn and must be empty for Exists and DoesNotExist"
+type PipelineResultCondition struct {
+	// Step is the name of the step whose results are matched. It must be
+	// listed in the step's dependsOn.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Step string `json:"step"`
+
+	// Key is the result key to match (e.g. "branch" or "pr").
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Operator is the relationship between the result and values.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist
+	Operator PipelineResultOperator `json:"operator"`
+
+	// Values is the set of values matched by In and NotIn.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// PipelineRunIf controls whether a step runs once its dependencies finish.
+type PipelineRunIf struct {
+	// When selects which dependency outcomes allow the step to run.
+	// Succeeded runs the step only when every dependency succeeded, Failed
+	// runs it when at least one dependency failed, and Always runs it once
+	// every dependency has finished. Skipped dependencies count as neither
+	// succeeded nor failed. Defaults to Succeeded.
+	// +optional
+	// +kubebuilder:validation:Enum=Succeeded;Failed;Always
+	// +kubebuilder:default=Succeeded
+	When PipelineRunIfWhen `json:"when,omitempty"`
+
+	// Results are conditions on the structured results of dependency steps.
+	// All conditions must match for the step to run.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Results []PipelineResultCondition `json:"results,omitempty"`
+}
+
+// PipelineForEach fans a step out into one Task per item of a list result.
+type PipelineForEach struct {
+	// Step is the name of the step whose result holds the list. It must be
+	// listed in the step's dependsOn.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Step string `json:"step"`
+
+	// Result is the result key holding the list. The value is parsed as a
+	// JSON array when it starts with "[", otherwise it is split on commas
+	// and newlines. Empty items are ignored.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Result string `json:"result"`
+
+	// MaxItems is the maximum number of items the step fans out to. The step
+	// fails when the list is longer.
+	// +optional
+	// +kubebuilder:default=20
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxItems *int32 `json:"maxItems,omitempty"`
+}
+
+// PipelineTaskTemplate defines the Tasks of a Pipeline step. Exactly one of
+// worker and workerPoolRef is required.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.worker) != has(self.workerPoolRef)",message="exactly one of worker and workerPoolRef is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.worker) || (has(self.worker.type) && size(self.worker.type) > 0)",message="worker.type is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.branch) || size(self.branch) == 0",message="branch is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.podFailurePolicy)",message="podFailurePolicy is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.retryPolicy)",message="retryPolicy is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.resultsSchema) || size(self.resultsSchema) == 0",message="resultsSchema is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.approval)",message="approval is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.approval) || !has(self.approval.mode) || self.approval.mode != 'BeforePush' || (has(self.worker) && has(self.worker.workspaceRef))",message="approval mode BeforePush requires a workspaceRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.spendLimit)",message="spendLimit is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || (!has(self.waitingTimeoutSeconds) && !has(self.pendingTimeoutSeconds))",message="waitingTimeoutSeconds and pendingTimeoutSeconds are not supported with workerPoolRef"
+type PipelineTaskTemplate struct {
+	// Worker defines the execution environment for the step's Tasks.
+	// +optional
+	Worker *WorkerSpec `json:"worker,omitempty"`
+
+	// WorkerPoolRef runs the step's Tasks on a WorkerPool instead of
+	// creating a Job per Task.
+	// +optional
+	WorkerPoolRef *WorkerPoolReference `json:"workerPoolRef,omitempty"`
+
+	// Branch is the git branch the step's Tasks work on. It is a Go
+	// text/template with the same variables as promptTemplate.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// PromptTemplate is a Go text/template for rendering the prompt of the
+	// step's Tasks. Available variables: {{.Pipeline}} is the Pipeline name;
+	// {{.Steps}} maps each finished dependency step to its Name, Phase,
+	// Tasks, Outputs, Results and Items (per-item results of a forEach
+	// step), accessed with the index function because step names may
+	// contain dashes; forEach steps additionally expose {{.Item}} and
+	// {{.Index}}.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	PromptTemplate string `json:"promptTemplate"`
+
+	// PodFailurePolicy specifies how failed pods affect the Job retry
+	// accounting of the step's Tasks. See Task.spec.podFailurePolicy.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.rules.all(r, r.action != 'FailIndex')",message="podFailurePolicy.rules[].action FailIndex is not supported for Task Jobs"
+	PodFailurePolicy *batchv1.PodFailurePolicy `json:"podFailurePolicy,omitempty"`
+
+	// RetryPolicy configures automatic retries for the step's Tasks. See
+	// Task.spec.retryPolicy.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// ResultsSchema is a JSON Schema, written as JSON or YAML, that the
+	// results of the step's Tasks must match. See Task.spec.resultsSchema.
+	// The schema is also available to promptTemplate as {{.ResultsSchema}},
+	// and {{.ResultsInstructions}} renders instructions for reporting
+	// matching results.
+	// +optional
+	ResultsSchema string `json:"resultsSchema,omitempty"`
+
+	// Approval holds the step's Tasks until a human approves them. See
+	// Task.spec.approval.
+	// +optional
+	Approval *ApprovalPolicy `json:"approval,omitempty"`
+
+	// SpendLimit caps the usage of each of the step's Tasks. See
+	// Task.spec.spendLimit.
+	// +optional
+	SpendLimit *SpendLimit `json:"spendLimit,omitempty"`
+
+	// Priority of the step's Tasks. See Task.spec.priority.
+	// +optional
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	Priority *int32 `json:"priority,omitempty"`
+
+	// WaitingTimeoutSeconds is the maximum time in seconds a step Task may
+	// wait to start. See Task.spec.waitingTimeoutSeconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	WaitingTimeoutSeconds *int64 `json:"waitingTimeoutSeconds,omitempty"`
+
+	// PendingTimeoutSeconds is the maximum time in seconds between creating
+	// a step Task's Job and its agent Pod running. See
+	// Task.spec.pendingTimeoutSeconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PendingTimeoutSeconds *int64 `json:"pendingTimeoutSeconds,omitempty"`
+
+	// Metadata holds optional labels and annotations for the step's Tasks.
+	// Values are Go text/templates with the same variables as
+	// promptTemplate.
+	// +optional
+	Metadata *TaskTemplateMetadata `json:"metadata,omitempty"`
+
+	// UpstreamRepo is the upstream repository in "owner/repo" format,
+	// passed to the step's Tasks. See Task.spec.upstreamRepo.
+	// +optional
+	UpstreamRepo string `json:"upstreamRepo,omitempty"`
+}
+
+// PipelineStep declares one step of a Pipeline.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.forEach) || (has(self.dependsOn) && self.forEach.step in self.dependsOn)",message="forEach.step must be listed in dependsOn"
+// +kubebuilder:validation:XValidation:rule="!has(self.runIf) || !has(self.runIf.results) || self.runIf.results.all(c, has(self.dependsOn) && c.step in self.dependsOn)",message="runIf.results[].step must be listed in dependsOn"
+type PipelineStep struct {
+	// Name identifies the step. Step Tasks are named <pipeline>-<step>, with
+	// an -<index> suffix for forEach steps.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// DependsOn lists the names of steps that must finish before this step
+	// runs. Steps without dependencies start immediately.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// RunIf controls whether the step runs once its dependencies finish.
+	// When unset, the step runs only if every dependency succeeded.
+	// +optional
+	RunIf *PipelineRunIf `json:"runIf,omitempty"`
+
+	// ForEach fans the step out into one Task per item of a list result
+	// produced by a dependency step.
+	// +optional
+	ForEach *PipelineForEach `json:"forEach,omitempty"`
+
+	// TaskTemplate is the template for the step's Tasks.
+	// +kubebuilder:validation:Required
+	TaskTemplate PipelineTaskTemplate `json:"taskTemplate"`
+}
+
+// PipelineSpec defines the desired state of Pipeline.
+type PipelineSpec struct {
+	// Steps declares the steps of the pipeline. Steps form a directed
+	// acyclic graph through dependsOn; a Pipeline with unknown or circular
+	// dependencies fails without creating any Task.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=50
+	// +listType=map
+	// +listMapKey=name
+	Steps []PipelineStep `json:"steps"`
+}
+
+// PipelineStepStatus reports the state of one Pipeline step.
+type PipelineStepStatus struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Phase is the current phase of the step.
+	// +optional
+	Phase PipelineStepPhase `json:"phase,omitempty"`
+
+	// Message provides additional information about the step.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Tasks lists the names of the Tasks created for the step, in item order
+	// for forEach steps.
+	// +optional
+	Tasks []string `json:"tasks,omitempty"`
+
+	// Outputs contains the outputs of the step's Tasks.
+	// +optional
+	Outputs []string `json:"outputs,omitempty"`
+
+	// Results contains the structured results of the step's Task. It is not
+	// set for forEach steps, see itemResults.
+	// +optional
+	Results map[string]string `json:"results,omitempty"`
+
+	// ItemResults contains the structured results of each Task of a forEach
+	// step, in item order.
+	// +optional
+	ItemResults []map[string]string `json:"itemResults,omitempty"`
+
+	// Usage is the usage summed over the step's Tasks.
+	// +optional
+	Usage *TaskUsage `json:"usage,omitempty"`
+}
+
+// PipelineStatus defines the observed state of Pipeline.
+type PipelineStatus struct {
+	// Phase represents the current phase of the Pipeline.
+	// +optional
+	Phase PipelinePhase `json:"phase,omitempty"`
+
+	// Message provides additional information about the current status.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the Pipeline was first reconciled.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when every step of the Pipeline finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Steps reports the state of each step, in spec order.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Steps []PipelineStepStatus `json:"steps,omitempty"`
+
+	// Usage is the usage summed over all steps.
+	// +optional
+	Usage *TaskUsage `json:"usage,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=pl
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+// +kubebuilder:printcolumn:name="Cost",type=string,JSONPath=`.status.usage.costUSD`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Pipeline runs a directed acyclic graph of agent Tasks. Each step creates
+// its Tasks from a TaskTemplate once its dependencies finish, passing their
+// outputs and results to the step's templates.
+type Pipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Pipeline spec is immutable after creation"
+	Spec   PipelineSpec   `json:"spec,omitempty"`
+	Status PipelineStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PipelineList contains a list of Pipeline.
+type PipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Pipeline `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Pipeline{}, &PipelineList{})
+}
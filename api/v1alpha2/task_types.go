@@ -5,6 +5,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // CredentialType defines the type of credentials used for authentication.
@@ -55,6 +56,80 @@ const (
 	// TaskPhaseWaiting means the Task is waiting for dependencies, branch lock,
 	// or budget admission.
 	TaskPhaseWaiting TaskPhase = "Waiting"
+	// TaskPhaseAwaitingApproval means the Task has an approval policy and is
+	// held until a human approves it.
+	TaskPhaseAwaitingApproval TaskPhase = "AwaitingApproval"
+)
+
+const (
+	// AnnotationTaskCancel requests cancellation of a Task. The controller
+	// stops the agent gracefully, records the outputs it produced so far, and
+	// marks the Task Failed with a Cancelled condition. The value is an
+	// optional human-readable reason.
+	AnnotationTaskCancel = "kelos.dev/cancel"
+
+	// TaskConditionCancelled is set on a Task once a cancellation request has
+	// been observed.
+	TaskConditionCancelled = "Cancelled"
+
+	// TaskReasonCancelRequested is the Cancelled condition reason while the
+	// agent is being stopped.
+	TaskReasonCancelRequested = "CancelRequested"
+
+	// TaskReasonCancelled is the Cancelled condition reason once the Task has
+	// stopped.
+	TaskReasonCancelled = "Cancelled"
+
+	// TaskConditionResultsInvalid is set on a Task whose results did not
+	// match its resultsSchema.
+	TaskConditionResultsInvalid = "ResultsInvalid"
+
+	// TaskReasonSchemaMismatch is the ResultsInvalid condition reason.
+	TaskReasonSchemaMismatch = "SchemaMismatch"
+
+	// AnnotationTaskApprove approves a Task that has an approval policy. The
+	// value records who approved the Task, e.g. "alice" or "github:octocat".
+	AnnotationTaskApprove = "kelos.dev/approve"
+
+	// TaskConditionApproved is set on a Task that has an approval policy. It
+	// is False while the Task waits for approval and True once approved.
+	TaskConditionApproved = "Approved"
+
+	// TaskReasonAwaitingApproval is the Approved condition reason while the
+	// Task waits for approval.
+	TaskReasonAwaitingApproval = "AwaitingApproval"
+
+	// TaskReasonApproved is the Approved condition reason once the Task has
+	// been approved.
+	TaskReasonApproved = "Approved"
+
+	// TaskConditionSpendLimitExceeded is set on a Task whose agent was
+	// stopped because its usage exceeded spec.spendLimit.
+	TaskConditionSpendLimitExceeded = "SpendLimitExceeded"
+
+	// TaskReasonSpendLimitExceeded is the SpendLimitExceeded condition
+	// reason.
+	TaskReasonSpendLimitExceeded = "SpendLimitExceeded"
+
+	// TaskConditionQuotaBlocked is set on a Waiting Task that is held back
+	// because a matching TaskBudget's maxRunning quota is in use.
+	TaskConditionQuotaBlocked = "QuotaBlocked"
+
+	// TaskReasonQuotaExceeded is the QuotaBlocked condition reason.
+	TaskReasonQuotaExceeded = "QuotaExceeded"
+
+	// TaskConditionTimedOut is set on a Task that failed because it exceeded
+	// spec.waitingTimeoutSeconds or spec.pendingTimeoutSeconds.
+	TaskConditionTimedOut = "TimedOut"
+
+	// TaskReasonWaitingTimeout is the TimedOut condition reason of a Task
+	// that waited to start for longer than spec.waitingTimeoutSeconds.
+	TaskReasonWaitingTimeout = "WaitingTimeout"
+
+	// TaskReasonPendingTimeout is the TimedOut condition reason of a Task
+	// whose agent Pod did not start running within
+	// spec.pendingTimeoutSeconds.
+	TaskReasonPendingTimeout = "PendingTimeout"
 )
 
 // SecretReference refers to a Secret containing credentials.
@@ -268,6 +343,17 @@ type WorkerSpec struct {
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.ttlSecondsAfterFinished)",message="ttlSecondsAfterFinished is not supported with workerPoolRef"
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.podFailurePolicy)",message="podFailurePolicy is not supported with workerPoolRef"
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.podOverrides)",message="podOverrides is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.retryPolicy)",message="retryPolicy is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.resultsSchema) || size(self.resultsSchema) == 0",message="resultsSchema is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.artifacts)",message="artifacts is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.artifacts) || has(self.workspaceRef) || (has(self.worker) && has(self.worker.workspaceRef))",message="artifacts requires a workspaceRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.approval)",message="approval is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.approval) || !has(self.approval.mode) || self.approval.mode != 'BeforePush' || has(self.workspaceRef) || (has(self.worker) && has(self.worker.workspaceRef))",message="approval mode BeforePush requires a workspaceRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.spendLimit)",message="spendLimit is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || (!has(self.waitingTimeoutSeconds) && !has(self.pendingTimeoutSeconds))",message="waitingTimeoutSeconds and pendingTimeoutSeconds are not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || (!has(self.conversation) && (!has(self.continueFrom) || size(self.continueFrom) == 0))",message="conversation and continueFrom are not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.conversation) || !has(self.continueFrom) || size(self.continueFrom) == 0",message="conversation cannot be set with continueFrom, which keeps the conversation of the continued Task"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.resolved)",message="resolved is not supported with workerPoolRef"
 type TaskSpec struct {
 	// Worker defines the execution environment for this Task.
 	// Mutually exclusive with workerPoolRef.
@@ -278,7 +364,9 @@ type TaskSpec struct {
 	// When set, the Task is dispatched to a pre-warmed worker pod instead of
 	// creating a one-shot Job. Mutually exclusive with worker, type/credentials,
 	// image, workspaceRef, agentConfigRefs, branch, dependsOn,
-	// ttlSecondsAfterFinished, podFailurePolicy, and podOverrides.
+	// ttlSecondsAfterFinished, podFailurePolicy, podOverrides, retryPolicy,
+	// resultsSchema, artifacts, approval, spendLimit, waitingTimeoutSeconds,
+	// pendingTimeoutSeconds, conversation, and continueFrom.
 	// +optional
 	WorkerPoolRef *WorkerPoolReference `json:"workerPoolRef,omitempty"`
 
@@ -378,6 +466,369 @@ type TaskSpec struct {
 	// Deprecated: use spec.worker.podOverrides instead.
 	// +optional
 	PodOverrides *PodOverrides `json:"podOverrides,omitempty"`
+
+	// RetryPolicy configures automatic retries when the Task's Job fails.
+	// Each retry runs a fresh Job whose prompt includes context from the
+	// failed attempt. If unset, a failed Job fails the Task.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// ResultsSchema is a JSON Schema, written as JSON or YAML, that the
+	// Task's results must match. The agent is told to write its results as a
+	// JSON object to the file named by KELOS_RESULTS_FILE. When the agent
+	// succeeds, the controller validates status.results against the schema
+	// and fails the Task with a ResultsInvalid condition if they do not
+	// match. Result values are always strings, and the built-in results
+	// (e.g. branch, commit, pr) are validated together with the agent's own.
+	// +optional
+	ResultsSchema string `json:"resultsSchema,omitempty"`
+
+	// Artifacts configures files that are uploaded from the workspace to
+	// object storage after the agent exits, such as reports, screenshots or
+	// benchmark output. The object keys are recorded in status.artifacts.
+	// Requires a workspaceRef.
+	// +optional
+	Artifacts *TaskArtifacts `json:"artifacts,omitempty"`
+
+	// Approval requires a human to approve the Task, either before its agent
+	// starts or before the agent pushes changes. A Task is approved by
+	// setting the kelos.dev/approve annotation, e.g. with
+	// "kelos approve task".
+	// +optional
+	Approval *ApprovalPolicy `json:"approval,omitempty"`
+
+	// SpendLimit caps the usage of the Task while its agent runs. The
+	// controller follows the running usage reported in the agent's log and
+	// stops the agent once a limit is exceeded, failing the Task with a
+	// SpendLimitExceeded condition. Usage of earlier retry attempts counts
+	// towards the limit.
+	// +optional
+	SpendLimit *SpendLimit `json:"spendLimit,omitempty"`
+
+	// Priority orders the Task among Tasks that wait for the same branch,
+	// TaskBudget maxRunning quota or worker of a WorkerPool. Tasks with a
+	// higher priority are released first; Tasks of equal priority are shared
+	// fairly between TaskSpawners and then released in creation order. Tasks
+	// blocked by a TaskBudget spend limit are not ordered and are admitted
+	// as soon as the budget allows. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	Priority *int32 `json:"priority,omitempty"`
+
+	// WaitingTimeoutSeconds is the maximum time in seconds the Task may wait
+	// to start, for example for a dependency, a branch lock, or a TaskBudget.
+	// The clock starts when the Task is created, approved, or done with a
+	// retry backoff, whichever is latest. When exceeded, the Task fails with
+	// a TimedOut condition.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	WaitingTimeoutSeconds *int64 `json:"waitingTimeoutSeconds,omitempty"`
+
+	// PendingTimeoutSeconds is the maximum time in seconds between creating
+	// the Task's Job and its agent Pod running, for example while the Pod is
+	// scheduled or its image is pulled. When exceeded, the agent Pod is
+	// stopped and the Task fails with a TimedOut condition.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PendingTimeoutSeconds *int64 `json:"pendingTimeoutSeconds,omitempty"`
+
+	// Conversation persists the agent's conversation state on a
+	// PersistentVolumeClaim named "<task>-conversation", so that a later
+	// Task can continue the conversation with continueFrom. Supported for
+	// the claude-code, codex and opencode agent types.
+	// +optional
+	Conversation *TaskConversation `json:"conversation,omitempty"`
+
+	// ContinueFrom is the name of a Task in the same namespace whose agent
+	// conversation this Task continues, with its prompt as the follow-up.
+	// The Task waits for that Task to finish, then runs with its restored
+	// conversation state on the same branch; an empty branch is set to the
+	// branch of that Task. The continued Task must have persisted its
+	// conversation and use the same agent type.
+	// +optional
+	ContinueFrom string `json:"continueFrom,omitempty"`
+
+	// Resolved runs the Task with this snapshot, typically copied from the
+	// status of a previous Task, instead of the current specs of its
+	// Workspace and AgentConfigs, which must be the ones the snapshot names.
+	// The agent type, image, model and effort of the snapshot take
+	// precedence over the worker settings.
+	// +optional
+	Resolved *TaskResolvedSnapshot `json:"resolved,omitempty"`
+}
+
+const (
+	// ResolvedSnapshotWorkspaceKey is the key of the JSON-encoded Workspace
+	// spec in the ConfigMap of a TaskResolvedSnapshot.
+	ResolvedSnapshotWorkspaceKey = "workspace.json"
+	// ResolvedSnapshotAgentConfigKey is the key of the JSON-encoded merged
+	// AgentConfig spec in the ConfigMap of a TaskResolvedSnapshot.
+	ResolvedSnapshotAgentConfigKey = "agentConfig.json"
+)
+
+// TaskResolvedSnapshot identifies the effective configuration a Task runs
+// with, resolved when its Job is created. The Workspace spec and merged
+// AgentConfig are kept in a ConfigMap so that the snapshot stays small.
+type TaskResolvedSnapshot struct {
+	// Hash is the hex-encoded SHA-256 of the resolved configuration: the
+	// agent type, image, model and effort and the contents of the ConfigMap.
+	// It does not cover the Workspace and AgentConfig names, so Tasks that
+	// ran with the same configuration have the same hash.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// Type is the agent type.
+	// +kubebuilder:validation:Enum=claude-code;codex;gemini;opencode;cursor
+	Type string `json:"type"`
+
+	// Image is the agent container image.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Model is the model passed to the agent, if any.
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Effort is the reasoning effort passed to the agent, if any.
+	// +optional
+	Effort string `json:"effort,omitempty"`
+
+	// WorkspaceName is the name of the Workspace the snapshot was taken from.
+	// +optional
+	WorkspaceName string `json:"workspaceName,omitempty"`
+
+	// AgentConfigRefs lists the AgentConfigs the snapshot was merged from.
+	// +optional
+	AgentConfigRefs []AgentConfigReference `json:"agentConfigRefs,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap in the namespace of the
+	// Task that holds the Workspace spec and the merged AgentConfig, with
+	// extends and the overrides for the agent type applied. Secret and
+	// ConfigMap references in them are kept as references. The ConfigMap is
+	// owned by the Task and by its TaskRecord.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// TaskConversation configures the PersistentVolumeClaim that holds the
+// conversation state of a Task's agent.
+type TaskConversation struct {
+	// Size is the requested storage of the claim. Defaults to 1Gi.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName is the StorageClass of the claim. Defaults to the
+	// cluster's default StorageClass.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// SpendLimit caps the cost and token usage of a single Task.
+// +kubebuilder:validation:XValidation:rule="has(self.maxCostUSD) || has(self.maxTokens)",message="at least one of maxCostUSD or maxTokens must be set"
+type SpendLimit struct {
+	// MaxCostUSD is the maximum cost in USD. Only opencode reports its cost
+	// while running; a Task of another agent type that sets it fails.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="type(self) == int ? self > 0 : quantity(self).isGreaterThan(quantity('0'))",message="maxCostUSD must be positive"
+	MaxCostUSD *resource.Quantity `json:"maxCostUSD,omitempty"`
+
+	// MaxTokens is the maximum number of input and output tokens combined.
+	// Supported for claude-code, codex and opencode.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxTokens *int64 `json:"maxTokens,omitempty"`
+}
+
+// ApprovalMode selects what a Task's approval gates.
+// +kubebuilder:validation:Enum=BeforeRun;BeforePush
+type ApprovalMode string
+
+const (
+	// ApprovalModeBeforeRun holds the Task in the AwaitingApproval phase and
+	// creates its Job only once the Task is approved.
+	ApprovalModeBeforeRun ApprovalMode = "BeforeRun"
+	// ApprovalModeBeforePush runs the agent right away but blocks git push
+	// from the workspace until the Task is approved.
+	ApprovalModeBeforePush ApprovalMode = "BeforePush"
+)
+
+// ApprovalPolicy configures human approval of a Task.
+type ApprovalPolicy struct {
+	// Mode selects what the approval gates. Defaults to BeforeRun.
+	// BeforePush requires a workspaceRef; it relies on a git pre-push hook
+	// that the agent could bypass, so protect the target branches as well.
+	// +optional
+	Mode ApprovalMode `json:"mode,omitempty"`
+
+	// GitHubComment is a command, e.g. "/kelos approve", that approves Tasks
+	// spawned from a githubIssues or githubPullRequests source when posted
+	// on the originating issue or pull request after the Task was created.
+	// Commenters are authorized with the source's commentPolicy; when it
+	// does not restrict commenters, write permission on the repository is
+	// required.
+	// +optional
+	GitHubComment string `json:"githubComment,omitempty"`
+
+	// SlackApprovers lists the Slack user IDs allowed to approve Tasks
+	// created from Slack. When set, an Approve button is posted in the
+	// originating thread while the Task waits for approval.
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:Pattern=`^[UW][A-Z0-9]{2,}$`
+	SlackApprovers []string `json:"slackApprovers,omitempty"`
+}
+
+// TaskArtifacts configures the collection of files from a Task's workspace.
+type TaskArtifacts struct {
+	// Paths lists glob patterns of files to upload, relative to the
+	// repository root (/workspace/repo). Patterns use doublestar syntax
+	// (e.g., "report.md", "screenshots/*.png", "bench/**"). At most 100
+	// files are uploaded.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:items:MinLength=1
+	Paths []string `json:"paths"`
+
+	// Storage is where the artifacts are stored.
+	Storage ArtifactStorage `json:"storage"`
+}
+
+// ArtifactStorage defines the object storage that Task artifacts are
+// uploaded to.
+type ArtifactStorage struct {
+	// S3 stores artifacts in an S3-compatible object store such as AWS S3
+	// or MinIO.
+	// +kubebuilder:validation:Required
+	S3 *S3ArtifactStorage `json:"s3"`
+}
+
+// S3ArtifactStorage configures an S3-compatible object store.
+type S3ArtifactStorage struct {
+	// Endpoint is the base URL of the object store, for example
+	// "https://s3.us-east-1.amazonaws.com" or "http://minio.minio:9000".
+	// Requests use path-style addressing.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+
+	// Bucket is the name of the bucket to upload to.
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// Region is the region used to sign requests. Defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Prefix is prepended to object keys. Keys have the form
+	// "<prefix>/<namespace>/<task>/<path>".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// SecretRef references a Secret with the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY keys, and optionally AWS_SESSION_TOKEN.
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// TaskFailureReason classifies why a Task attempt failed.
+// +kubebuilder:validation:Enum=AgentError;DeadlineExceeded;PodFailurePolicy;ResultsInvalid
+type TaskFailureReason string
+
+const (
+	// TaskFailureReasonAgentError means the agent container exited with an
+	// error and the Job exhausted its pod retries.
+	TaskFailureReasonAgentError TaskFailureReason = "AgentError"
+	// TaskFailureReasonDeadlineExceeded means the Job exceeded its active
+	// deadline.
+	TaskFailureReasonDeadlineExceeded TaskFailureReason = "DeadlineExceeded"
+	// TaskFailureReasonPodFailurePolicy means a podFailurePolicy rule failed
+	// the Job.
+	TaskFailureReasonPodFailurePolicy TaskFailureReason = "PodFailurePolicy"
+	// TaskFailureReasonResultsInvalid means the agent succeeded but its
+	// results did not match the Task's resultsSchema.
+	TaskFailureReasonResultsInvalid TaskFailureReason = "ResultsInvalid"
+)
+
+// RetryPolicy configures how a failed Task is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	MaxAttempts int32 `json:"maxAttempts"`
+
+	// BackoffSeconds is the delay before the first retry. The delay doubles
+	// for each subsequent retry, capped at maxBackoffSeconds. Defaults to 30.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BackoffSeconds *int32 `json:"backoffSeconds,omitempty"`
+
+	// MaxBackoffSeconds caps the delay between retries. Defaults to 600.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxBackoffSeconds *int32 `json:"maxBackoffSeconds,omitempty"`
+
+	// RetryOn lists the failure reasons that trigger a retry. When empty,
+	// every failure reason is retried.
+	// +optional
+	// +listType=set
+	RetryOn []TaskFailureReason `json:"retryOn,omitempty"`
+
+	// IncludeFailureContext controls whether the retry prompt includes the
+	// previous attempt's failure message, last agent response and outputs.
+	// Defaults to true.
+	// +optional
+	IncludeFailureContext *bool `json:"includeFailureContext,omitempty"`
+}
+
+// TaskAttempt records a failed execution attempt of a Task.
+type TaskAttempt struct {
+	// Attempt is the 1-based attempt number.
+	Attempt int32 `json:"attempt"`
+
+	// JobName is the name of the Job that ran the attempt.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// JobUID is the UID of the Job that ran the attempt. Every attempt
+	// reuses the Job name, so the UID tells the Jobs apart.
+	// +optional
+	JobUID types.UID `json:"jobUID,omitempty"`
+
+	// PodName is the name of the Pod that ran the attempt.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// StartTime is when the attempt started running.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the attempt failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Reason classifies the failure.
+	// +optional
+	Reason TaskFailureReason `json:"reason,omitempty"`
+
+	// Message describes the failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastAgentMessage is the last assistant response of the attempt,
+	// truncated.
+	// +optional
+	LastAgentMessage string `json:"lastAgentMessage,omitempty"`
+
+	// Outputs contains the outputs captured from the attempt.
+	// +optional
+	Outputs []string `json:"outputs,omitempty"`
+
+	// Results contains the structured results captured from the attempt.
+	// +optional
+	Results map[string]string `json:"results,omitempty"`
+
+	// Usage contains the cost and token usage of the attempt.
+	// +optional
+	Usage *TaskUsage `json:"usage,omitempty"`
 }
 
 // TaskStatus defines the observed state of Task.
@@ -415,11 +866,58 @@ type TaskStatus struct {
 	// +optional
 	Results map[string]string `json:"results,omitempty"`
 
+	// Artifacts lists the object keys of the files uploaded from the
+	// workspace as configured by spec.artifacts.
+	// +optional
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// ConversationClaimName is the PersistentVolumeClaim holding the agent's
+	// conversation state, set for Tasks that persist or continue a
+	// conversation.
+	// +optional
+	ConversationClaimName string `json:"conversationClaimName,omitempty"`
+
+	// InheritedBranch is the branch of the continued Task that a Task
+	// without spec.branch works on. It takes the place of spec.branch for
+	// checkout, pushing and branch locking.
+	// +optional
+	InheritedBranch string `json:"inheritedBranch,omitempty"`
+
 	// Usage contains structured cost and token usage populated from results
 	// when the Task reaches a terminal phase.
 	// +optional
 	Usage *TaskUsage `json:"usage,omitempty"`
 
+	// Attempt is the 1-based number of the current attempt. Only set for
+	// Tasks with a retryPolicy.
+	// +optional
+	Attempt int32 `json:"attempt,omitempty"`
+
+	// Attempts records the previous failed attempts of a Task with a
+	// retryPolicy, oldest first.
+	// +optional
+	Attempts []TaskAttempt `json:"attempts,omitempty"`
+
+	// NextRetryTime is when the next attempt may start. Set while the Task
+	// waits out the retry backoff.
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// QueuePosition is the 1-based position of the Task in the queue for a
+	// branch or TaskBudget maxRunning quota while it waits in that queue.
+	// +optional
+	QueuePosition *int32 `json:"queuePosition,omitempty"`
+
+	// BranchLockHolder is the name of the Task holding the branch lock this
+	// Task is waiting for.
+	// +optional
+	BranchLockHolder string `json:"branchLockHolder,omitempty"`
+
+	// Resolved identifies the effective Workspace, AgentConfig, image and
+	// model the Task runs with, recorded when its Job is created.
+	// +optional
+	Resolved *TaskResolvedSnapshot `json:"resolved,omitempty"`
+
 	// Conditions provides detailed status information.
 	// +optional
 	// +listType=map
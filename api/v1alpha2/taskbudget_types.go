@@ -11,15 +11,41 @@ type BudgetPeriodType string
 const (
 	// BudgetPeriodDaily resets at midnight in the configured timezone.
 	BudgetPeriodDaily BudgetPeriodType = "Daily"
+	// BudgetPeriodWeekly resets at midnight on the configured week start day
+	// in the configured timezone.
+	BudgetPeriodWeekly BudgetPeriodType = "Weekly"
+	// BudgetPeriodMonthly resets at midnight on the first day of each month
+	// in the configured timezone.
+	BudgetPeriodMonthly BudgetPeriodType = "Monthly"
+	// BudgetPeriodRolling never resets; it covers the configured window
+	// ending now, so usage ages out of the budget continuously.
+	BudgetPeriodRolling BudgetPeriodType = "Rolling"
 )
 
 // BudgetPeriod defines the accounting window for a TaskBudget.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Rolling' ? has(self.window) : !has(self.window)",message="window is required for Rolling periods and not supported for other period types"
+// +kubebuilder:validation:XValidation:rule="!has(self.weekStart) || self.type == 'Weekly'",message="weekStart is only supported for Weekly periods"
 type BudgetPeriod struct {
 	// Type is the period boundary used for budget accounting.
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=Daily
+	// +kubebuilder:validation:Enum=Daily;Weekly;Monthly;Rolling
 	Type BudgetPeriodType `json:"type"`
 
+	// WeekStart is the day on which Weekly periods start. Defaults to
+	// Monday.
+	// +optional
+	// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+	WeekStart string `json:"weekStart,omitempty"`
+
+	// Window is the length of a Rolling period as a number followed by m
+	// (minutes), h (hours) or d (days), e.g. "24h" or "7d". At most 31d,
+	// because TaskRecords are garbage-collected after 32 days.
+	// +optional
+	// +kubebuilder:validation:MaxLength=7
+	// +kubebuilder:validation:Pattern=`^[1-9][0-9]{0,5}[mhd]$`
+	// +kubebuilder:validation:XValidation:rule="int(self.substring(0, size(self) - 1)) * (self.endsWith('d') ? 1440 : (self.endsWith('h') ? 60 : 1)) <= 44640",message="window must not be longer than 31d"
+	Window string `json:"window,omitempty"`
+
 	// Timezone is the IANA timezone used to compute period boundaries.
 	// Defaults to UTC. The XValidation rule rejects names that the controller
 	// cannot load (getHours errors on an unknown IANA zone), so an invalid
@@ -34,7 +60,7 @@ type BudgetPeriod struct {
 
 // TaskBudgetSpec defines observed-spend admission limits for Tasks.
 //
-// +kubebuilder:validation:XValidation:rule="has(self.maxCostUSD) || has(self.maxInputTokens) || has(self.maxOutputTokens)",message="at least one of maxCostUSD, maxInputTokens, or maxOutputTokens must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.maxCostUSD) || has(self.maxInputTokens) || has(self.maxOutputTokens) || has(self.maxRunning)",message="at least one of maxCostUSD, maxInputTokens, maxOutputTokens, or maxRunning must be set"
 type TaskBudgetSpec struct {
 	// TaskSelector selects Tasks and TaskRecords in the same namespace.
 	// An empty selector ({}) selects all Tasks in the namespace.
@@ -59,8 +85,32 @@ type TaskBudgetSpec struct {
 	// +optional
 	// +kubebuilder:validation:Minimum=0
 	MaxOutputTokens *int64 `json:"maxOutputTokens,omitempty"`
+
+	// SoftLimitPercent is a warning threshold as a percentage of each limit.
+	// Once usage in the period reaches it, the TaskBudget gets a
+	// SoftLimitReached condition and a Warning event, while Tasks are still
+	// admitted until a limit is reached.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	SoftLimitPercent *int32 `json:"softLimitPercent,omitempty"`
+
+	// MaxRunning caps how many matching Tasks may be admitted and not yet
+	// finished at the same time, including Tasks that run in a WorkerPool.
+	// Tasks over the quota stay in Waiting phase with a QuotaBlocked
+	// condition and are admitted in creation order as running Tasks finish.
+	// The quota does not depend on the period.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRunning *int32 `json:"maxRunning,omitempty"`
 }
 
+const (
+	// TaskBudgetConditionSoftLimitReached is set on a TaskBudget whose usage
+	// in the current period reached spec.softLimitPercent of a limit.
+	TaskBudgetConditionSoftLimitReached = "SoftLimitReached"
+)
+
 // TaskBudgetStatus tracks the current accounting period and usage.
 type TaskBudgetStatus struct {
 	// ObservedGeneration is the most recent generation observed by the controller.
@@ -96,12 +146,14 @@ type TaskBudgetStatus struct {
 // +kubebuilder:printcolumn:name="Period",type=string,JSONPath=`.spec.period.type`
 // +kubebuilder:printcolumn:name="Max Cost",type=string,JSONPath=`.spec.maxCostUSD`,priority=1
 // +kubebuilder:printcolumn:name="Used Cost",type=string,JSONPath=`.status.used.costUSD`,priority=1
+// +kubebuilder:printcolumn:name="Max Running",type=integer,JSONPath=`.spec.maxRunning`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // TaskBudget defines observed-spend admission limits for Tasks.
 // When a Task's labels match a TaskBudget's taskSelector and the budget
 // is exceeded, the Task stays in Waiting phase with a BudgetBlocked
-// condition until the period resets.
+// condition until the period resets. A TaskBudget can also cap how many
+// matching Tasks run at once with maxRunning.
 type TaskBudget struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -49,6 +49,11 @@ type TaskRecordSpec struct {
 	// +optional
 	Usage *TaskUsage `json:"usage,omitempty"`
 
+	// Resolved is the effective configuration the Task ran with, copied
+	// from its status.
+	// +optional
+	Resolved *TaskResolvedSnapshot `json:"resolved,omitempty"`
+
 	// TTLSecondsAfterCompletion is the number of seconds after CompletionTime
 	// before the TaskRecord is eligible for automatic deletion. If unset,
 	// the record is retained indefinitely. The controller garbage-collects
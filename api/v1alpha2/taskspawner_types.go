@@ -38,6 +38,10 @@ type When struct {
 	// +optional
 	Jira *Jira `json:"jira,omitempty"`
 
+	// GitLab discovers issues and merge requests from a GitLab project.
+	// +optional
+	GitLab *GitLab `json:"gitlab,omitempty"`
+
 	// GitHubWebhook triggers task spawning on GitHub webhook events.
 	// +optional
 	GitHubWebhook *GitHubWebhook `json:"githubWebhook,omitempty"`
@@ -379,6 +383,71 @@ type Jira struct {
 	PollInterval string `json:"pollInterval,omitempty"`
 }
 
+// GitLab discovers issues and merge requests from a GitLab project.
+// The GitLab instance is derived from the workspace repo URL in
+// taskTemplate.workspaceRef, and the GITLAB_TOKEN key of the workspace's
+// secretRef is used for API authentication.
+type GitLab struct {
+	// Project optionally overrides the project to poll, as a path with
+	// namespace (e.g., "group/subgroup/project"). When empty, the project is
+	// derived from the workspace repo URL.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// Types specifies which item types to discover: "issues",
+	// "merge_requests", or both.
+	// +kubebuilder:validation:items:Enum=issues;merge_requests
+	// +kubebuilder:default={"issues"}
+	// +optional
+	Types []string `json:"types,omitempty"`
+
+	// Labels filters items by labels. Items must have all of the labels.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// ExcludeLabels filters out items that have any of these labels (client-side).
+	// +optional
+	ExcludeLabels []string `json:"excludeLabels,omitempty"`
+
+	// State filters items by state (opened, closed, all). Defaults to opened.
+	// +kubebuilder:validation:Enum=opened;closed;all
+	// +kubebuilder:default=opened
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Reporting configures status reporting back to the originating GitLab
+	// issue or merge request.
+	// +optional
+	Reporting *GitLabReporting `json:"reporting,omitempty"`
+
+	// PollInterval is how often this source is polled (e.g., "30s", "5m").
+	// When empty, a default of 5m is used.
+	// +optional
+	PollInterval string `json:"pollInterval,omitempty"`
+}
+
+// GitLabReporting configures status reporting back to GitLab.
+type GitLabReporting struct {
+	// Notes posts a status note on the originating issue or merge request
+	// and updates it as the Task's phase changes.
+	// +optional
+	Notes bool `json:"notes,omitempty"`
+
+	// CommitStatus sets a commit status on the head commit of the
+	// originating merge request, enabling pipelines-must-succeed style
+	// merge checks. Issues have no head commit and are not reported.
+	// +optional
+	CommitStatus *GitLabCommitStatusReporting `json:"commitStatus,omitempty"`
+}
+
+// GitLabCommitStatusReporting configures GitLab commit status reporting.
+type GitLabCommitStatusReporting struct {
+	// Name overrides the default commit status name ("kelos/<taskspawner-name>").
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	Name string `json:"name,omitempty"`
+}
+
 // GitHubWebhook configures matching for GitHub webhook events.
 // +kubebuilder:validation:XValidation:rule="!has(self.reporting) || !has(self.reporting.checks) || self.events.exists(e, e in ['pull_request', 'pull_request_review', 'pull_request_review_comment', 'pull_request_target'])",message="checks reporting requires at least one pull-request event type"
 type GitHubWebhook struct {
@@ -907,6 +976,12 @@ type TaskTemplateMetadata struct {
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.ttlSecondsAfterFinished)",message="ttlSecondsAfterFinished is not supported with workerPoolRef"
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.podOverrides)",message="podOverrides is not supported with workerPoolRef"
 // +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.podFailurePolicy)",message="podFailurePolicy is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.retryPolicy)",message="retryPolicy is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.resultsSchema) || size(self.resultsSchema) == 0",message="resultsSchema is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.approval)",message="approval is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.approval) || !has(self.approval.mode) || self.approval.mode != 'BeforePush' || has(self.workspaceRef) || (has(self.worker) && has(self.worker.workspaceRef))",message="approval mode BeforePush requires a workspaceRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || !has(self.spendLimit)",message="spendLimit is not supported with workerPoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.workerPoolRef) || (!has(self.waitingTimeoutSeconds) && !has(self.pendingTimeoutSeconds))",message="waitingTimeoutSeconds and pendingTimeoutSeconds are not supported with workerPoolRef"
 type TaskTemplate struct {
 	// Worker defines the execution environment for spawned Tasks.
 	// Mutually exclusive with workerPoolRef.
@@ -917,7 +992,9 @@ type TaskTemplate struct {
 	// When set, spawned Tasks execute on pre-warmed workers instead of
 	// creating per-task Jobs. Mutually exclusive with inline type/credentials,
 	// image, workspaceRef, agentConfigRefs, branch, dependsOn,
-	// ttlSecondsAfterFinished, podOverrides, and podFailurePolicy.
+	// ttlSecondsAfterFinished, podOverrides, podFailurePolicy, retryPolicy,
+	// resultsSchema, approval, spendLimit, waitingTimeoutSeconds, and
+	// pendingTimeoutSeconds.
 	// +optional
 	WorkerPoolRef *WorkerPoolReference `json:"workerPoolRef,omitempty"`
 
@@ -1060,6 +1137,51 @@ type TaskTemplate struct {
 	// +kubebuilder:validation:XValidation:rule="self.rules.all(r, r.action != 'FailIndex')",message="podFailurePolicy.rules[].action FailIndex is not supported for Task Jobs"
 	PodFailurePolicy *batchv1.PodFailurePolicy `json:"podFailurePolicy,omitempty"`
 
+	// RetryPolicy configures automatic retries for spawned Tasks whose Job
+	// fails. If unset, a failed Job fails the spawned Task.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// ResultsSchema is a JSON Schema, written as JSON or YAML, that the
+	// results of spawned Tasks must match. See Task.spec.resultsSchema.
+	// The schema is also available to promptTemplate as {{.ResultsSchema}},
+	// and {{.ResultsInstructions}} renders instructions for reporting
+	// matching results.
+	// +optional
+	ResultsSchema string `json:"resultsSchema,omitempty"`
+
+	// Approval holds spawned Tasks until a human approves them, either
+	// before the agent starts or before it pushes changes. See
+	// Task.spec.approval.
+	// +optional
+	Approval *ApprovalPolicy `json:"approval,omitempty"`
+
+	// SpendLimit caps the usage of each spawned Task while its agent runs.
+	// See Task.spec.spendLimit.
+	// +optional
+	SpendLimit *SpendLimit `json:"spendLimit,omitempty"`
+
+	// Priority of spawned Tasks among Tasks waiting for the same branch,
+	// TaskBudget maxRunning quota or WorkerPool worker. See
+	// Task.spec.priority.
+	// +optional
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	Priority *int32 `json:"priority,omitempty"`
+
+	// WaitingTimeoutSeconds is the maximum time in seconds a spawned Task may
+	// wait to start. See Task.spec.waitingTimeoutSeconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	WaitingTimeoutSeconds *int64 `json:"waitingTimeoutSeconds,omitempty"`
+
+	// PendingTimeoutSeconds is the maximum time in seconds between creating
+	// a spawned Task's Job and its agent Pod running. See
+	// Task.spec.pendingTimeoutSeconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PendingTimeoutSeconds *int64 `json:"pendingTimeoutSeconds,omitempty"`
+
 	// Metadata holds optional labels and annotations for spawned Tasks.
 	// +optional
 	Metadata *TaskTemplateMetadata `json:"metadata,omitempty"`
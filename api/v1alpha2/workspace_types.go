@@ -1,6 +1,8 @@
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,10 +30,141 @@ type WorkspaceFile struct {
 	Content string `json:"content"`
 }
 
+// WorkspaceRepository defines an additional git repository cloned into the
+// workspace next to the primary repository.
+type WorkspaceRepository struct {
+	// Name identifies the repository. It names the init containers that
+	// prepare the repository and prefixes its Task outputs
+	// ("repository/<name>/branch").
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// Repo is the git repository URL to clone.
+	// +kubebuilder:validation:Pattern="^(https?://|git://|git@).*"
+	Repo string `json:"repo"`
+
+	// Path is the directory under /workspace the repository is cloned into.
+	// Defaults to Name. The primary repository is always cloned into
+	// /workspace/repo.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9][A-Za-z0-9._-]*$"
+	Path string `json:"path,omitempty"`
+
+	// Ref is the git reference to checkout (branch, tag, or commit SHA).
+	// Defaults to the repository's default branch if not specified.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// SecretRef references a Secret containing a GITHUB_TOKEN key (a
+	// GITLAB_TOKEN key for GitLab Workspaces) for git authentication to this
+	// repository. Defaults to the Workspace's SecretRef. GitHub App secrets
+	// are only supported on the Workspace's SecretRef.
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// Remotes are additional git remotes to configure after cloning.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(r, r.name != 'origin')",message="remote name 'origin' is reserved for the clone source"
+	// +kubebuilder:validation:XValidation:rule="self.map(r, r.name).size() == self.size()",message="remote names must be unique"
+	Remotes []GitRemote `json:"remotes,omitempty"`
+}
+
+// WorkspaceCloneOptions tunes how the primary repository is cloned.
+type WorkspaceCloneOptions struct {
+	// Depth is the number of commits of history to fetch. 0 fetches the
+	// full history. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Depth *int32 `json:"depth,omitempty"`
+
+	// SparseCheckout lists the directories to check out in cone mode
+	// (for example, "services/api"). Files in the repository root are
+	// always checked out. When empty, the whole tree is checked out.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self.all(p, !p.startsWith('/') && !p.split('/').exists(s, s == '..'))",message="sparse checkout paths must be relative and must not contain '..'"
+	SparseCheckout []string `json:"sparseCheckout,omitempty"`
+
+	// Submodules recursively initializes and updates the repository's
+	// submodules after checkout, with the same Depth.
+	// +optional
+	Submodules bool `json:"submodules,omitempty"`
+
+	// LFS fetches Git LFS objects after checkout when set. The git-lfs
+	// filters are installed in the repository so later checkouts by the
+	// agent fetch LFS objects too.
+	// +optional
+	LFS *WorkspaceCloneLFS `json:"lfs,omitempty"`
+
+	// Filter is a partial clone filter such as "blob:none",
+	// "blob:limit=1m" or "tree:0". Objects excluded by the filter are
+	// fetched on demand.
+	// +optional
+	// +kubebuilder:validation:Pattern="^(blob:none|blob:limit=[0-9]+[kmg]?|tree:[0-9]+)$"
+	Filter string `json:"filter,omitempty"`
+}
+
+// WorkspaceCloneLFS selects the Git LFS objects fetched after checkout.
+type WorkspaceCloneLFS struct {
+	// Include lists the path patterns whose LFS objects are fetched.
+	// When empty, all LFS objects not matched by Exclude are fetched.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude lists the path patterns whose LFS objects are not fetched.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// WorkspaceCache configures a shared git mirror of the primary repository.
+// The mirror lives on a PersistentVolumeClaim that a CronJob refreshes
+// from the remote, and Task pods clone with it as a reference so only the
+// delta is fetched from the remote.
+type WorkspaceCache struct {
+	// Storage is the requested size of the mirror PersistentVolumeClaim.
+	// Defaults to 10Gi.
+	// +optional
+	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// StorageClassName is the StorageClass of the mirror
+	// PersistentVolumeClaim. Defaults to the cluster default StorageClass.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// AccessModes of the mirror PersistentVolumeClaim. The refresh CronJob
+	// mounts it read-write and Task pods mount it read-only, possibly on
+	// different nodes at the same time. Defaults to ReadWriteMany.
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// RefreshSchedule is the cron schedule on which the mirror is fetched
+	// from the remote. Defaults to "*/15 * * * *".
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	RefreshSchedule string `json:"refreshSchedule,omitempty"`
+}
+
+// ForgeType identifies the git hosting service of a Workspace.
+// +kubebuilder:validation:Enum=github;gitlab
+type ForgeType string
+
+const (
+	// ForgeGitHub is GitHub or GitHub Enterprise Server.
+	ForgeGitHub ForgeType = "github"
+
+	// ForgeGitLab is GitLab.com or a self-managed GitLab instance.
+	ForgeGitLab ForgeType = "gitlab"
+)
+
 // WorkspaceGHProxy configures the workspace-scoped ghproxy.
 type WorkspaceGHProxy struct{}
 
 // WorkspaceSpec defines the desired state of Workspace.
+// +kubebuilder:validation:XValidation:rule="!has(self.ghproxy) || !has(self.forge) || self.forge != 'gitlab'",message="ghproxy is only supported for GitHub workspaces"
 type WorkspaceSpec struct {
 	// Repo is the git repository URL to clone.
 	// +kubebuilder:validation:Required
@@ -43,8 +176,24 @@ type WorkspaceSpec struct {
 	// +optional
 	Ref string `json:"ref,omitempty"`
 
+	// Clone tunes how the primary repository is cloned: history depth,
+	// sparse checkout, submodules, Git LFS and partial clone filters.
+	// Defaults to a shallow clone of depth 1 without submodules or LFS
+	// objects.
+	// +optional
+	Clone *WorkspaceCloneOptions `json:"clone,omitempty"`
+
+	// Forge is the git hosting service of the repositories: "github" or
+	// "gitlab". It selects the credentials injected into Task pods and
+	// where kelos-capture looks up pull or merge requests. Defaults to
+	// "github".
+	// +optional
+	// +kubebuilder:default=github
+	Forge ForgeType `json:"forge,omitempty"`
+
 	// SecretRef references a Secret containing a GITHUB_TOKEN key for git
-	// authentication and GitHub CLI (gh) operations.
+	// authentication and GitHub CLI (gh) operations, or a GITLAB_TOKEN key
+	// when Forge is "gitlab".
 	// +optional
 	SecretRef *SecretReference `json:"secretRef,omitempty"`
 
@@ -52,6 +201,13 @@ type WorkspaceSpec struct {
 	// +optional
 	GHProxy *WorkspaceGHProxy `json:"ghproxy,omitempty"`
 
+	// Cache configures and enables a shared git mirror of the primary
+	// repository when set. Tasks that run in their own Pod and Sessions
+	// clone with the mirror as a reference; a Task started before the
+	// mirror is populated clones from the remote as usual.
+	// +optional
+	Cache *WorkspaceCache `json:"cache,omitempty"`
+
 	// Remotes are additional git remotes to configure after cloning.
 	// The credential from SecretRef applies to all remotes.
 	// +optional
@@ -59,6 +215,20 @@ type WorkspaceSpec struct {
 	// +kubebuilder:validation:XValidation:rule="self.map(r, r.name).size() == self.size()",message="remote names must be unique"
 	Remotes []GitRemote `json:"remotes,omitempty"`
 
+	// Repositories are additional git repositories cloned next to the
+	// primary repository, each into /workspace/<path>. A Task's branch is
+	// checked out in every repository, and the outputs of each repository
+	// are reported under "repository/<name>/" keys. Only Tasks that run in
+	// their own Pod clone these repositories; WorkerPool workers and
+	// Sessions clone only the primary repository.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=10
+	// +kubebuilder:validation:XValidation:rule="self.all(r, (has(r.path) ? r.path : r.name) != 'repo')",message="path 'repo' is reserved for the primary repository"
+	// +kubebuilder:validation:XValidation:rule="self.all(r, self.exists_one(o, (has(o.path) ? o.path : o.name) == (has(r.path) ? r.path : r.name)))",message="repository paths must be unique"
+	Repositories []WorkspaceRepository `json:"repositories,omitempty"`
+
 	// Files are written into the cloned repository before the agent starts.
 	// This can be used to inject plugin-like assets such as skills
 	// (for example, ".claude/skills/<name>/SKILL.md") and instruction files
@@ -86,17 +256,77 @@ type WorkspaceSpec struct {
 	SetupCommand []string `json:"setupCommand,omitempty"`
 }
 
+const (
+	// WorkspaceConditionReady indicates whether Tasks can clone the
+	// Workspace. It is False when any other condition is False.
+	WorkspaceConditionReady = "Ready"
+	// WorkspaceConditionRepoReachable indicates whether the repository
+	// answered a git ls-remote.
+	WorkspaceConditionRepoReachable = "RepoReachable"
+	// WorkspaceConditionCredentialsValid indicates whether the credentials
+	// in SecretRef were accepted by the repository host.
+	WorkspaceConditionCredentialsValid = "CredentialsValid"
+	// WorkspaceConditionRefResolved indicates whether Ref exists in the
+	// repository.
+	WorkspaceConditionRefResolved = "RefResolved"
+	// WorkspaceConditionDefaultBranchResolved indicates whether the
+	// repository's default branch could be determined.
+	WorkspaceConditionDefaultBranchResolved = "DefaultBranchResolved"
+)
+
+// WorkspaceStatus defines the observed state of Workspace.
+type WorkspaceStatus struct {
+	// ObservedGeneration is the most recent generation validated by the
+	// controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DefaultBranch is the default branch of the repository, which Tasks
+	// check out when Ref is empty.
+	// +optional
+	DefaultBranch string `json:"defaultBranch,omitempty"`
+
+	// ResolvedCommit is the commit Ref (or the default branch) pointed to
+	// at the last validation.
+	// +optional
+	ResolvedCommit string `json:"resolvedCommit,omitempty"`
+
+	// CredentialScopes lists the scopes or permissions granted to the token
+	// in SecretRef, when the repository host reports them: OAuth scopes for
+	// GitHub personal access tokens (classic), permissions for GitHub App
+	// installation tokens, and token scopes for GitLab.
+	// +optional
+	CredentialScopes []string `json:"credentialScopes,omitempty"`
+
+	// LastValidationTime is when the repository was last validated.
+	// +optional
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+
+	// Conditions provides detailed status information.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
 // +genclient
-// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repo",type=string,JSONPath=`.spec.repo`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Workspace is the Schema for the workspaces API.
 type Workspace struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec WorkspaceSpec `json:"spec,omitempty"`
+	Spec   WorkspaceSpec   `json:"spec,omitempty"`
+	Status WorkspaceStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
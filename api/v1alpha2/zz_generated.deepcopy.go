@@ -33,6 +33,7 @@ func (in *AgentConfig) DeepCopyInto(out *AgentConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfig.
@@ -85,6 +86,42 @@ func (in *AgentConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentConfigOverride) DeepCopyInto(out *AgentConfigOverride) {
+	*out = *in
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]PluginSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Skills != nil {
+		in, out := &in.Skills, &out.Skills
+		*out = make([]SkillsShSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MCPServers != nil {
+		in, out := &in.MCPServers, &out.MCPServers
+		*out = make([]MCPServerSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfigOverride.
+func (in *AgentConfigOverride) DeepCopy() *AgentConfigOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentConfigOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AgentConfigReference) DeepCopyInto(out *AgentConfigReference) {
 	*out = *in
@@ -103,6 +140,18 @@ func (in *AgentConfigReference) DeepCopy() *AgentConfigReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AgentConfigSpec) DeepCopyInto(out *AgentConfigSpec) {
 	*out = *in
+	if in.Extends != nil {
+		in, out := &in.Extends, &out.Extends
+		*out = new(AgentConfigReference)
+		**out = **in
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make(map[string]AgentConfigOverride, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.Plugins != nil {
 		in, out := &in.Plugins, &out.Plugins
 		*out = make([]PluginSpec, len(*in))
@@ -124,6 +173,11 @@ func (in *AgentConfigSpec) DeepCopyInto(out *AgentConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = new(AgentPermissions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfigSpec.
@@ -136,6 +190,68 @@ func (in *AgentConfigSpec) DeepCopy() *AgentConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentConfigStatus) DeepCopyInto(out *AgentConfigStatus) {
+	*out = *in
+	if in.UsedBy != nil {
+		in, out := &in.UsedBy, &out.UsedBy
+		*out = make([]AgentConfigUser, len(*in))
+		copy(*out, *in)
+	}
+	if in.UsedByCounts != nil {
+		in, out := &in.UsedByCounts, &out.UsedByCounts
+		*out = make([]AgentConfigUserCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfigStatus.
+func (in *AgentConfigStatus) DeepCopy() *AgentConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentConfigUser) DeepCopyInto(out *AgentConfigUser) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfigUser.
+func (in *AgentConfigUser) DeepCopy() *AgentConfigUser {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentConfigUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentConfigUserCount) DeepCopyInto(out *AgentConfigUserCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentConfigUserCount.
+func (in *AgentConfigUserCount) DeepCopy() *AgentConfigUserCount {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentConfigUserCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AgentDefinition) DeepCopyInto(out *AgentDefinition) {
 	*out = *in
@@ -151,6 +267,81 @@ func (in *AgentDefinition) DeepCopy() *AgentDefinition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentPermissions) DeepCopyInto(out *AgentPermissions) {
+	*out = *in
+	if in.AllowedTools != nil {
+		in, out := &in.AllowedTools, &out.AllowedTools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedTools != nil {
+		in, out := &in.DeniedTools, &out.DeniedTools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedCommands != nil {
+		in, out := &in.DeniedCommands, &out.DeniedCommands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedPaths != nil {
+		in, out := &in.DeniedPaths, &out.DeniedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentPermissions.
+func (in *AgentPermissions) DeepCopy() *AgentPermissions {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentPermissions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalPolicy) DeepCopyInto(out *ApprovalPolicy) {
+	*out = *in
+	if in.SlackApprovers != nil {
+		in, out := &in.SlackApprovers, &out.SlackApprovers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalPolicy.
+func (in *ApprovalPolicy) DeepCopy() *ApprovalPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactStorage) DeepCopyInto(out *ArtifactStorage) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3ArtifactStorage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactStorage.
+func (in *ArtifactStorage) DeepCopy() *ArtifactStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BudgetPeriod) DeepCopyInto(out *BudgetPeriod) {
 	*out = *in
@@ -590,6 +781,76 @@ func (in *GitHubWebhookFilter) DeepCopy() *GitHubWebhookFilter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitLab) DeepCopyInto(out *GitLab) {
+	*out = *in
+	if in.Types != nil {
+		in, out := &in.Types, &out.Types
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeLabels != nil {
+		in, out := &in.ExcludeLabels, &out.ExcludeLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Reporting != nil {
+		in, out := &in.Reporting, &out.Reporting
+		*out = new(GitLabReporting)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitLab.
+func (in *GitLab) DeepCopy() *GitLab {
+	if in == nil {
+		return nil
+	}
+	out := new(GitLab)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitLabCommitStatusReporting) DeepCopyInto(out *GitLabCommitStatusReporting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitLabCommitStatusReporting.
+func (in *GitLabCommitStatusReporting) DeepCopy() *GitLabCommitStatusReporting {
+	if in == nil {
+		return nil
+	}
+	out := new(GitLabCommitStatusReporting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitLabReporting) DeepCopyInto(out *GitLabReporting) {
+	*out = *in
+	if in.CommitStatus != nil {
+		in, out := &in.CommitStatus, &out.CommitStatus
+		*out = new(GitLabCommitStatusReporting)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitLabReporting.
+func (in *GitLabReporting) DeepCopy() *GitLabReporting {
+	if in == nil {
+		return nil
+	}
+	out := new(GitLabReporting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRemote) DeepCopyInto(out *GitRemote) {
 	*out = *in
@@ -784,6 +1045,330 @@ func (in *MCPServerSpec) DeepCopy() *MCPServerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Pipeline) DeepCopyInto(out *Pipeline) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pipeline.
+func (in *Pipeline) DeepCopy() *Pipeline {
+	if in == nil {
+		return nil
+	}
+	out := new(Pipeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Pipeline) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineForEach) DeepCopyInto(out *PipelineForEach) {
+	*out = *in
+	if in.MaxItems != nil {
+		in, out := &in.MaxItems, &out.MaxItems
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineForEach.
+func (in *PipelineForEach) DeepCopy() *PipelineForEach {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineForEach)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineList) DeepCopyInto(out *PipelineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Pipeline, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineList.
+func (in *PipelineList) DeepCopy() *PipelineList {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineResultCondition) DeepCopyInto(out *PipelineResultCondition) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineResultCondition.
+func (in *PipelineResultCondition) DeepCopy() *PipelineResultCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineResultCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineRunIf) DeepCopyInto(out *PipelineRunIf) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]PipelineResultCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineRunIf.
+func (in *PipelineRunIf) DeepCopy() *PipelineRunIf {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineRunIf)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineSpec) DeepCopyInto(out *PipelineSpec) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]PipelineStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineSpec.
+func (in *PipelineSpec) DeepCopy() *PipelineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]PipelineStepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(TaskUsage)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
+func (in *PipelineStatus) DeepCopy() *PipelineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStep) DeepCopyInto(out *PipelineStep) {
+	*out = *in
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RunIf != nil {
+		in, out := &in.RunIf, &out.RunIf
+		*out = new(PipelineRunIf)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForEach != nil {
+		in, out := &in.ForEach, &out.ForEach
+		*out = new(PipelineForEach)
+		(*in).DeepCopyInto(*out)
+	}
+	in.TaskTemplate.DeepCopyInto(&out.TaskTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStep.
+func (in *PipelineStep) DeepCopy() *PipelineStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStepStatus) DeepCopyInto(out *PipelineStepStatus) {
+	*out = *in
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ItemResults != nil {
+		in, out := &in.ItemResults, &out.ItemResults
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(TaskUsage)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStepStatus.
+func (in *PipelineStepStatus) DeepCopy() *PipelineStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTaskTemplate) DeepCopyInto(out *PipelineTaskTemplate) {
+	*out = *in
+	if in.Worker != nil {
+		in, out := &in.Worker, &out.Worker
+		*out = new(WorkerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkerPoolRef != nil {
+		in, out := &in.WorkerPoolRef, &out.WorkerPoolRef
+		*out = new(WorkerPoolReference)
+		**out = **in
+	}
+	if in.PodFailurePolicy != nil {
+		in, out := &in.PodFailurePolicy, &out.PodFailurePolicy
+		*out = new(batchv1.PodFailurePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpendLimit != nil {
+		in, out := &in.SpendLimit, &out.SpendLimit
+		*out = new(SpendLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WaitingTimeoutSeconds != nil {
+		in, out := &in.WaitingTimeoutSeconds, &out.WaitingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PendingTimeoutSeconds != nil {
+		in, out := &in.PendingTimeoutSeconds, &out.PendingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = new(TaskTemplateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTaskTemplate.
+func (in *PipelineTaskTemplate) DeepCopy() *PipelineTaskTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTaskTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PluginSpec) DeepCopyInto(out *PluginSpec) {
 	*out = *in
@@ -925,6 +1510,57 @@ func (in *ResponseFilter) DeepCopy() *ResponseFilter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.BackoffSeconds != nil {
+		in, out := &in.BackoffSeconds, &out.BackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBackoffSeconds != nil {
+		in, out := &in.MaxBackoffSeconds, &out.MaxBackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]TaskFailureReason, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeFailureContext != nil {
+		in, out := &in.IncludeFailureContext, &out.IncludeFailureContext
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3ArtifactStorage) DeepCopyInto(out *S3ArtifactStorage) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3ArtifactStorage.
+func (in *S3ArtifactStorage) DeepCopy() *S3ArtifactStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(S3ArtifactStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
@@ -1378,7 +2014,32 @@ func (in *SpawnerCredential) DeepCopy() *SpawnerCredential {
 	if in == nil {
 		return nil
 	}
-	out := new(SpawnerCredential)
+	out := new(SpawnerCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendLimit) DeepCopyInto(out *SpendLimit) {
+	*out = *in
+	if in.MaxCostUSD != nil {
+		in, out := &in.MaxCostUSD, &out.MaxCostUSD
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendLimit.
+func (in *SpendLimit) DeepCopy() *SpendLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendLimit)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1410,6 +2071,67 @@ func (in *Task) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskArtifacts) DeepCopyInto(out *TaskArtifacts) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Storage.DeepCopyInto(&out.Storage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskArtifacts.
+func (in *TaskArtifacts) DeepCopy() *TaskArtifacts {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskArtifacts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskAttempt) DeepCopyInto(out *TaskAttempt) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(TaskUsage)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskAttempt.
+func (in *TaskAttempt) DeepCopy() *TaskAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskBudget) DeepCopyInto(out *TaskBudget) {
 	*out = *in
@@ -1489,6 +2211,16 @@ func (in *TaskBudgetSpec) DeepCopyInto(out *TaskBudgetSpec) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.SoftLimitPercent != nil {
+		in, out := &in.SoftLimitPercent, &out.SoftLimitPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRunning != nil {
+		in, out := &in.MaxRunning, &out.MaxRunning
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskBudgetSpec.
@@ -1536,6 +2268,31 @@ func (in *TaskBudgetStatus) DeepCopy() *TaskBudgetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskConversation) DeepCopyInto(out *TaskConversation) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskConversation.
+func (in *TaskConversation) DeepCopy() *TaskConversation {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskConversation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskList) DeepCopyInto(out *TaskList) {
 	*out = *in
@@ -1643,6 +2400,11 @@ func (in *TaskRecordSpec) DeepCopyInto(out *TaskRecordSpec) {
 		*out = new(TaskUsage)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Resolved != nil {
+		in, out := &in.Resolved, &out.Resolved
+		*out = new(TaskResolvedSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TTLSecondsAfterCompletion != nil {
 		in, out := &in.TTLSecondsAfterCompletion, &out.TTLSecondsAfterCompletion
 		*out = new(int32)
@@ -1675,6 +2437,26 @@ func (in *TaskReference) DeepCopy() *TaskReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskResolvedSnapshot) DeepCopyInto(out *TaskResolvedSnapshot) {
+	*out = *in
+	if in.AgentConfigRefs != nil {
+		in, out := &in.AgentConfigRefs, &out.AgentConfigRefs
+		*out = make([]AgentConfigReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskResolvedSnapshot.
+func (in *TaskResolvedSnapshot) DeepCopy() *TaskResolvedSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskResolvedSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskSpawner) DeepCopyInto(out *TaskSpawner) {
 	*out = *in
@@ -1845,6 +2627,51 @@ func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 		*out = new(PodOverrides)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = new(TaskArtifacts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpendLimit != nil {
+		in, out := &in.SpendLimit, &out.SpendLimit
+		*out = new(SpendLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WaitingTimeoutSeconds != nil {
+		in, out := &in.WaitingTimeoutSeconds, &out.WaitingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PendingTimeoutSeconds != nil {
+		in, out := &in.PendingTimeoutSeconds, &out.PendingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Conversation != nil {
+		in, out := &in.Conversation, &out.Conversation
+		*out = new(TaskConversation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resolved != nil {
+		in, out := &in.Resolved, &out.Resolved
+		*out = new(TaskResolvedSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSpec.
@@ -1880,11 +2707,37 @@ func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Usage != nil {
 		in, out := &in.Usage, &out.Usage
 		*out = new(TaskUsage)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]TaskAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.QueuePosition != nil {
+		in, out := &in.QueuePosition, &out.QueuePosition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Resolved != nil {
+		in, out := &in.Resolved, &out.Resolved
+		*out = new(TaskResolvedSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -1952,6 +2805,36 @@ func (in *TaskTemplate) DeepCopyInto(out *TaskTemplate) {
 		*out = new(batchv1.PodFailurePolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpendLimit != nil {
+		in, out := &in.SpendLimit, &out.SpendLimit
+		*out = new(SpendLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WaitingTimeoutSeconds != nil {
+		in, out := &in.WaitingTimeoutSeconds, &out.WaitingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PendingTimeoutSeconds != nil {
+		in, out := &in.PendingTimeoutSeconds, &out.PendingTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	if in.Metadata != nil {
 		in, out := &in.Metadata, &out.Metadata
 		*out = new(TaskTemplateMetadata)
@@ -2058,6 +2941,11 @@ func (in *When) DeepCopyInto(out *When) {
 		*out = new(Jira)
 		**out = **in
 	}
+	if in.GitLab != nil {
+		in, out := &in.GitLab, &out.GitLab
+		*out = new(GitLab)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.GitHubWebhook != nil {
 		in, out := &in.GitHubWebhook, &out.GitHubWebhook
 		*out = new(GitHubWebhook)
@@ -2249,6 +3137,7 @@ func (in *Workspace) DeepCopyInto(out *Workspace) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workspace.
@@ -2269,6 +3158,91 @@ func (in *Workspace) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCache) DeepCopyInto(out *WorkspaceCache) {
+	*out = *in
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCache.
+func (in *WorkspaceCache) DeepCopy() *WorkspaceCache {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneLFS) DeepCopyInto(out *WorkspaceCloneLFS) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneLFS.
+func (in *WorkspaceCloneLFS) DeepCopy() *WorkspaceCloneLFS {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneLFS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneOptions) DeepCopyInto(out *WorkspaceCloneOptions) {
+	*out = *in
+	if in.Depth != nil {
+		in, out := &in.Depth, &out.Depth
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SparseCheckout != nil {
+		in, out := &in.SparseCheckout, &out.SparseCheckout
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LFS != nil {
+		in, out := &in.LFS, &out.LFS
+		*out = new(WorkspaceCloneLFS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneOptions.
+func (in *WorkspaceCloneOptions) DeepCopy() *WorkspaceCloneOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceFile) DeepCopyInto(out *WorkspaceFile) {
 	*out = *in
@@ -2346,9 +3320,39 @@ func (in *WorkspaceReference) DeepCopy() *WorkspaceReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRepository) DeepCopyInto(out *WorkspaceRepository) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Remotes != nil {
+		in, out := &in.Remotes, &out.Remotes
+		*out = make([]GitRemote, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRepository.
+func (in *WorkspaceRepository) DeepCopy() *WorkspaceRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceSpec) DeepCopyInto(out *WorkspaceSpec) {
 	*out = *in
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(WorkspaceCloneOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SecretRef != nil {
 		in, out := &in.SecretRef, &out.SecretRef
 		*out = new(SecretReference)
@@ -2359,11 +3363,23 @@ func (in *WorkspaceSpec) DeepCopyInto(out *WorkspaceSpec) {
 		*out = new(WorkspaceGHProxy)
 		**out = **in
 	}
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(WorkspaceCache)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Remotes != nil {
 		in, out := &in.Remotes, &out.Remotes
 		*out = make([]GitRemote, len(*in))
 		copy(*out, *in)
 	}
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]WorkspaceRepository, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Files != nil {
 		in, out := &in.Files, &out.Files
 		*out = make([]WorkspaceFile, len(*in))
@@ -2385,3 +3401,34 @@ func (in *WorkspaceSpec) DeepCopy() *WorkspaceSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceStatus) DeepCopyInto(out *WorkspaceStatus) {
+	*out = *in
+	if in.CredentialScopes != nil {
+		in, out := &in.CredentialScopes, &out.CredentialScopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastValidationTime != nil {
+		in, out := &in.LastValidationTime, &out.LastValidationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceStatus.
+func (in *WorkspaceStatus) DeepCopy() *WorkspaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -6,6 +6,8 @@
 #   - First argument ($1): the task prompt
 #   - KELOS_SESSION_SETUP_ONLY=1: prepare configuration and exit without a prompt
 #   - KELOS_MODEL env var: model name (optional)
+#   - KELOS_PERMISSIONS env var: JSON tool permission policy (optional)
+#   - KELOS_CONTINUE=1: continue the most recent conversation with the prompt
 #   - UID 61100: shared between git-clone init container and agent
 #   - Working directory: /workspace/repo when a workspace is configured
 
@@ -39,6 +41,100 @@ fs.writeFileSync(cfgPath, JSON.stringify(existing, null, 2));
 '
 fi
 
+# Translate the tool permission policy into deny rules of the user-scoped
+# Claude Code settings. Deny rules also apply with
+# --dangerously-skip-permissions. MCP tool wildcards and allowlists have no
+# rule syntax, so a PreToolUse hook enforces them.
+if [ -n "${KELOS_PERMISSIONS:-}" ]; then
+  node -e '
+const fs = require("fs");
+const path = require("path");
+const configDir = process.argv[1];
+const policy = JSON.parse(process.env.KELOS_PERMISSIONS);
+const settingsPath = path.join(configDir, "settings.json");
+fs.mkdirSync(configDir, { recursive: true });
+let settings = {};
+try { settings = JSON.parse(fs.readFileSync(settingsPath, "utf8")); } catch {}
+
+const builtins = {
+  "shell": ["Bash"],
+  "read": ["Read", "Glob", "Grep"],
+  "edit": ["Edit", "Write", "NotebookEdit"],
+  "web-fetch": ["WebFetch"],
+  "web-search": ["WebSearch"],
+};
+const allowed = policy.allowedTools || [];
+const denied = policy.deniedTools || [];
+const limited = allowed.some((tool) => tool in builtins);
+const rules = [];
+for (const [tool, names] of Object.entries(builtins)) {
+  if (denied.includes(tool) || (limited && !allowed.includes(tool))) rules.push(...names);
+}
+let needsHook = allowed.some((tool) => tool.startsWith("mcp:") && tool.includes("/"));
+for (const tool of denied.filter((tool) => tool.startsWith("mcp:"))) {
+  const [server, name] = tool.slice(4).split("/");
+  if (name === undefined) rules.push(`mcp__${server}`);
+  else if (name.includes("*")) needsHook = true;
+  else rules.push(`mcp__${server}__${name}`);
+}
+for (const command of policy.deniedCommands || []) rules.push(`Bash(${command})`);
+for (const glob of policy.deniedPaths || []) rules.push(`Edit(${glob})`);
+
+settings.permissions = settings.permissions || {};
+const deny = settings.permissions.deny || [];
+settings.permissions.deny = deny.concat(rules.filter((rule) => !deny.includes(rule)));
+
+const hookPath = path.join(configDir, "kelos-permissions-hook.js");
+const policyPath = path.join(configDir, "kelos-permissions.json");
+const hookCommand = `node ${JSON.stringify(hookPath)} ${JSON.stringify(policyPath)}`;
+settings.hooks = settings.hooks || {};
+const preToolUse = (settings.hooks.PreToolUse || []).filter(
+  (entry) => !(entry.hooks || []).some((hook) => hook.command === hookCommand));
+if (needsHook) {
+  fs.writeFileSync(policyPath, JSON.stringify(policy));
+  fs.writeFileSync(hookPath, String.raw`const fs = require("fs");
+const policy = JSON.parse(fs.readFileSync(process.argv[2], "utf8"));
+const wildcard = (pattern, value) => new RegExp("^" + pattern.split("*")
+  .map((part) => part.replace(/[.+?^$(){}|[\]\\]/g, "\\$&")).join(".*") + "$").test(value);
+const entries = (list) => (list || []).filter((entry) => entry.startsWith("mcp:"))
+  .map((entry) => entry.slice(4).split("/"));
+let input = "";
+process.stdin.on("data", (chunk) => { input += chunk; });
+process.stdin.on("end", () => {
+  const match = /^mcp__(.+?)__(.+)$/.exec(JSON.parse(input).tool_name || "");
+  if (!match) return;
+  const [, server, tool] = match;
+  let reason = "";
+  if (entries(policy.deniedTools).some(([s, t]) => s === server && (t === undefined || wildcard(t, tool)))) {
+    reason = "is denied";
+  }
+  const allowed = entries(policy.allowedTools).filter(([s]) => s === server);
+  if (!reason && allowed.length > 0 && !allowed.some(([, t]) => t === undefined || wildcard(t, tool))) {
+    reason = "is not in allowedTools";
+  }
+  if (!reason) return;
+  process.stdout.write(JSON.stringify({
+    hookSpecificOutput: {
+      hookEventName: "PreToolUse",
+      permissionDecision: "deny",
+      permissionDecisionReason: "Kelos permission policy: tool mcp:" + server + "/" + tool + " " + reason,
+    },
+  }));
+});
+`);
+  preToolUse.push({ matcher: "mcp__.*", hooks: [{ type: "command", command: hookCommand }] });
+} else {
+  fs.rmSync(hookPath, { force: true });
+  fs.rmSync(policyPath, { force: true });
+}
+if (preToolUse.length > 0) settings.hooks.PreToolUse = preToolUse;
+else delete settings.hooks.PreToolUse;
+if (Object.keys(settings.hooks).length === 0) delete settings.hooks;
+
+fs.writeFileSync(settingsPath, JSON.stringify(settings, null, 2));
+' "$claude_config_dir"
+fi
+
 # Run pre-agent setup command if configured. KELOS_SETUP_COMMAND is the
 # JSON-encoded exec-form array from Workspace.spec.setupCommand. A non-zero
 # exit aborts the task before the agent starts.
@@ -83,6 +179,11 @@ if [ -n "${KELOS_EFFORT:-}" ]; then
   ARGS+=("--effort" "$KELOS_EFFORT")
 fi
 
+# Resume the conversation restored from the continued Task.
+if [ "${KELOS_CONTINUE:-}" = "1" ]; then
+  ARGS+=("--continue")
+fi
+
 # Pass each plugin directory via --plugin-dir
 if [ -n "${KELOS_PLUGIN_DIR:-}" ] && [ -d "${KELOS_PLUGIN_DIR}" ]; then
   for dir in "${KELOS_PLUGIN_DIR}"/*/; do
@@ -90,6 +191,10 @@ if [ -n "${KELOS_PLUGIN_DIR:-}" ] && [ -d "${KELOS_PLUGIN_DIR}" ]; then
   done
 fi
 
+# Keep the shell alive when the agent is stopped with SIGTERM (for example,
+# when the Task is cancelled) so kelos-capture can still report outputs.
+trap 'true' TERM
+
 claude "${ARGS[@]}" | /kelos/kelos-capture
 PIPE_EXIT_CODES=("${PIPESTATUS[@]}")
 AGENT_EXIT_CODE=${PIPE_EXIT_CODES[0]}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/distribution/reference"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -95,6 +96,7 @@ func main() {
 	var ghProxyResourceRequests string
 	var ghProxyResourceLimits string
 	var ghProxyCacheTTL time.Duration
+	var workspaceValidationInterval time.Duration
 	var telemetryReport bool
 	var telemetryEndpoint string
 	var telemetryEnvironment string
@@ -129,6 +131,7 @@ func main() {
 	flag.StringVar(&ghProxyResourceRequests, "ghproxy-resource-requests", "", "Resource requests for workspace ghproxy containers as comma-separated name=value pairs (e.g., cpu=50m,memory=64Mi).")
 	flag.StringVar(&ghProxyResourceLimits, "ghproxy-resource-limits", "", "Resource limits for workspace ghproxy containers as comma-separated name=value pairs (e.g., cpu=200m,memory=128Mi).")
 	flag.DurationVar(&ghProxyCacheTTL, "ghproxy-cache-ttl", 0, "Cache TTL for workspace ghproxy instances (e.g., 30s, 1m). When set, passed as --cache-ttl to ghproxy containers. Zero means use the ghproxy default (15s).")
+	flag.DurationVar(&workspaceValidationInterval, "workspace-validation-interval", controller.DefaultWorkspaceValidationInterval, "How often ready Workspaces are revalidated with a git ls-remote of their repository. Zero disables Workspace validation.")
 	flag.BoolVar(&telemetryReport, "telemetry-report", false, "Run a one-shot telemetry report and exit.")
 	flag.StringVar(&telemetryEndpoint, "telemetry-endpoint", telemetry.DefaultPostHogEndpoint, "The PostHog endpoint for sending telemetry reports.")
 	flag.StringVar(&telemetryEnvironment, "telemetry-environment", "production", "The environment label for telemetry reports (e.g., production, development).")
@@ -245,6 +248,13 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "kelos-controller-leader-election",
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// Branch lock Leases are read from the API server so that
+				// ownership never depends on a stale cache.
+				DisableFor: []client.Object{&coordinationv1.Lease{}},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -309,15 +319,31 @@ func main() {
 	workspaceProxyBuilder.GHProxyImagePullPolicy = corev1.PullPolicy(ghProxyImagePullPolicy)
 	workspaceProxyBuilder.GHProxyResources = ghProxyResources
 	workspaceProxyBuilder.GHProxyCacheTTL = ghProxyCacheTTL
+	var workspaceValidator *controller.WorkspaceValidator
+	if workspaceValidationInterval > 0 {
+		workspaceValidator = &controller.WorkspaceValidator{
+			TokenClient: githubapp.NewTokenClient(),
+			Interval:    workspaceValidationInterval,
+		}
+	}
 	if err = (&controller.WorkspaceReconciler{
 		Client:       mgr.GetClient(),
 		Scheme:       mgr.GetScheme(),
 		ProxyBuilder: workspaceProxyBuilder,
 		Recorder:     mgr.GetEventRecorderFor("kelos-controller"),
+		Validator:    workspaceValidator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Workspace")
 		os.Exit(1)
 	}
+	if err = (&controller.AgentConfigReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("kelos-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AgentConfig")
+		os.Exit(1)
+	}
 	if err = (&controller.TaskSpawnerReconciler{
 		Client:            mgr.GetClient(),
 		Scheme:            mgr.GetScheme(),
@@ -340,8 +366,9 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controller.TaskBudgetReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("kelos-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TaskBudget")
 		os.Exit(1)
@@ -353,6 +380,14 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "TaskRecord")
 		os.Exit(1)
 	}
+	if err = (&controller.PipelineReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("kelos-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Pipeline")
+		os.Exit(1)
+	}
 	if err = (&controller.WorkerPoolReconciler{
 		Client:                      mgr.GetClient(),
 		Scheme:                      mgr.GetScheme(),
@@ -14,6 +14,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
 	"github.com/kelos-dev/kelos/internal/sessionruntime"
 	clientset "github.com/kelos-dev/kelos/pkg/generated/clientset/versioned"
 	clientv1alpha2 "github.com/kelos-dev/kelos/pkg/generated/clientset/versioned/typed/api/v1alpha2"
@@ -134,6 +136,14 @@ func runServe() {
 		fmt.Fprintln(os.Stderr, "Invalid configuration: KELOS_AGENT_TYPE must be set")
 		os.Exit(1)
 	}
+	var permissions *kelos.AgentPermissions
+	if value := os.Getenv(agentpermissions.EnvVar); value != "" {
+		permissions = &kelos.AgentPermissions{}
+		if err := json.Unmarshal([]byte(value), permissions); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration: parsing %s: %v\n", agentpermissions.EnvVar, err)
+			os.Exit(1)
+		}
+	}
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 	sessionClient, sessionName, podUID, err := sessionClientFromEnvironment()
@@ -161,6 +171,7 @@ func runServe() {
 		PluginDir:            os.Getenv("KELOS_PLUGIN_DIR"),
 		InitialPrompt:        session.Spec.InitialPrompt,
 		Environment:          os.Environ(),
+		Permissions:          permissions,
 		PublishSessionStatus: publisher,
 		SessionName:          sessionName,
 		PodUID:               podUID,
@@ -54,6 +54,8 @@ func main() {
 	var jiraBaseURL string
 	var jiraProject string
 	var jiraJQL string
+	var gitlabAPIBaseURL string
+	var gitlabProject string
 	var oneShot bool
 
 	flag.StringVar(&name, "taskspawner-name", "", "Name of the TaskSpawner to manage")
@@ -69,6 +71,8 @@ func main() {
 	flag.StringVar(&jiraBaseURL, "jira-base-url", "", "Jira instance base URL (e.g. https://mycompany.atlassian.net)")
 	flag.StringVar(&jiraProject, "jira-project", "", "Jira project key")
 	flag.StringVar(&jiraJQL, "jira-jql", "", "Optional JQL filter for Jira issues")
+	flag.StringVar(&gitlabAPIBaseURL, "gitlab-api-base-url", "", "GitLab API base URL (e.g. https://gitlab.com/api/v4)")
+	flag.StringVar(&gitlabProject, "gitlab-project", "", "GitLab project path with namespace or numeric ID")
 	flag.BoolVar(&oneShot, "one-shot", false, "Run a single discovery cycle and exit (used by CronJob)")
 
 	opts, applyVerbosity := logging.SetupZapOptions(flag.CommandLine)
@@ -137,6 +141,8 @@ func main() {
 		JiraBaseURL:      jiraBaseURL,
 		JiraProject:      jiraProject,
 		JiraJQL:          jiraJQL,
+		GitLabAPIBaseURL: gitlabAPIBaseURL,
+		GitLabProject:    gitlabProject,
 		HTTPClient:       httpClient,
 	}
 
@@ -178,11 +184,16 @@ func main() {
 	}
 }
 
+// taskStatusReporter reports the status of a Task back to its source.
+type taskStatusReporter interface {
+	ReportTaskStatus(ctx context.Context, task *kelos.Task) error
+}
+
 // runReportingCycle lists all Tasks owned by the given TaskSpawner and runs
-// reporting for each one that has GitHub reporting enabled. Running this
-// in the same goroutine as the discovery loop avoids races between Task
+// reporting for each one that has reporting enabled. Running this in the
+// same goroutine as the discovery loop avoids races between Task
 // creation/deletion and annotation patching.
-func runReportingCycle(ctx context.Context, cl client.Client, key types.NamespacedName, reporter *reporting.TaskReporter) error {
+func runReportingCycle(ctx context.Context, cl client.Client, key types.NamespacedName, reporter taskStatusReporter) error {
 	var taskList kelos.TaskList
 	if err := cl.List(ctx, &taskList,
 		client.InNamespace(key.Namespace),
@@ -204,13 +215,13 @@ func taskNameForWorkItem(taskSpawnerName, workItemID string) string {
 	return strings.ToLower(taskSpawnerName + "-" + workItemID)
 }
 
-func runCycle(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL string, httpClient *http.Client) error {
-	return runCycleWithProxy(ctx, cl, key, githubOwner, githubRepo, "", githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, httpClient)
+func runCycle(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject string, httpClient *http.Client) error {
+	return runCycleWithProxy(ctx, cl, key, githubOwner, githubRepo, "", githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject, httpClient)
 }
 
-func runCycleWithProxy(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL string, httpClient *http.Client) error {
+func runCycleWithProxy(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject string, httpClient *http.Client) error {
 	start := time.Now()
-	err := runCycleCore(ctx, cl, key, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, httpClient)
+	err := runCycleCore(ctx, cl, key, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject, httpClient)
 	discoveryDurationSeconds.Observe(time.Since(start).Seconds())
 	if err != nil {
 		discoveryErrorsTotal.Inc()
@@ -218,13 +229,13 @@ func runCycleWithProxy(ctx context.Context, cl client.Client, key types.Namespac
 	return err
 }
 
-func runCycleCore(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL string, httpClient *http.Client) error {
+func runCycleCore(ctx context.Context, cl client.Client, key types.NamespacedName, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject string, httpClient *http.Client) error {
 	var ts kelos.TaskSpawner
 	if err := cl.Get(ctx, key, &ts); err != nil {
 		return fmt.Errorf("fetching TaskSpawner: %w", err)
 	}
 
-	src, err := buildSourceWithProxy(ctx, &ts, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, httpClient)
+	src, err := buildSourceWithProxy(ctx, &ts, githubOwner, githubRepo, ghProxyURL, githubAPIBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject, httpClient)
 	if err != nil {
 		return fmt.Errorf("building source: %w", err)
 	}
@@ -337,6 +348,10 @@ func runCycleWithSourceCore(ctx context.Context, cl client.Client, key types.Nam
 			continue
 		}
 
+		if err := approveTaskFromWorkItem(ctx, cl, existing, item); err != nil {
+			log.Error(err, "Approving task from comment", "task", taskName)
+		}
+
 		// Retrigger: when the source provides a trigger time and the existing
 		// task is completed, check whether a new trigger arrived after the task
 		// finished. If so, delete the completed task so a new one can be created.
@@ -613,10 +628,14 @@ func recordCycleFailure(ctx context.Context, cl client.Client, key types.Namespa
 	return cycleErr
 }
 
-// sourceAnnotations returns annotations that stamp GitHub source metadata
-// onto a spawned Task. These annotations enable downstream consumers (such
-// as the reporting watcher) to identify the originating issue or PR.
+// sourceAnnotations returns annotations that stamp GitHub or GitLab source
+// metadata onto a spawned Task. These annotations enable downstream
+// consumers (such as the reporting watcher) to identify the originating
+// issue or PR.
 func sourceAnnotations(ts *kelos.TaskSpawner, item source.WorkItem) map[string]string {
+	if ts.Spec.When.GitLab != nil {
+		return gitLabSourceAnnotations(ts, item)
+	}
 	if ts.Spec.When.GitHubIssues == nil && ts.Spec.When.GitHubPullRequests == nil {
 		return nil
 	}
@@ -649,6 +668,47 @@ func sourceAnnotations(ts *kelos.TaskSpawner, item source.WorkItem) map[string]s
 	return annotations
 }
 
+// gitLabSourceAnnotations returns the source annotations of a Task spawned
+// from a GitLab issue or merge request.
+func gitLabSourceAnnotations(ts *kelos.TaskSpawner, item source.WorkItem) map[string]string {
+	kind := "issue"
+	if item.Kind == "PR" {
+		kind = "pull-request"
+	}
+
+	annotations := map[string]string{
+		reporting.AnnotationSourceKind:   kind,
+		reporting.AnnotationSourceNumber: strconv.Itoa(item.Number),
+	}
+
+	if gitLabNotesEnabled(ts) {
+		annotations[reporting.AnnotationForgeNotes] = "enabled"
+	}
+
+	// Issues have no head commit to set a status on.
+	if gitLabCommitStatusEnabled(ts) && item.HeadSHA != "" {
+		annotations[reporting.AnnotationForgeCommitStatus] = "enabled"
+		annotations[reporting.AnnotationSourceSHA] = item.HeadSHA
+		if name := ts.Spec.When.GitLab.Reporting.CommitStatus.Name; name != "" {
+			annotations[reporting.AnnotationForgeCommitStatusName] = name
+		}
+	}
+
+	return annotations
+}
+
+// gitLabNotesEnabled returns true when GitLab note reporting is configured
+// on the TaskSpawner.
+func gitLabNotesEnabled(ts *kelos.TaskSpawner) bool {
+	return ts.Spec.When.GitLab != nil && ts.Spec.When.GitLab.Reporting != nil && ts.Spec.When.GitLab.Reporting.Notes
+}
+
+// gitLabCommitStatusEnabled returns true when GitLab commit status
+// reporting is configured on the TaskSpawner.
+func gitLabCommitStatusEnabled(ts *kelos.TaskSpawner) bool {
+	return ts.Spec.When.GitLab != nil && ts.Spec.When.GitLab.Reporting != nil && ts.Spec.When.GitLab.Reporting.CommitStatus != nil
+}
+
 // reportingEnabled returns true when GitHub comment reporting is configured
 // and enabled on the TaskSpawner. This only covers polling-based sources
 // (Issues, PRs); webhook-based reporting is handled by the webhook server
@@ -732,11 +792,50 @@ func resolveGitHubCommentPolicy(policy *kelos.GitHubCommentPolicy) resolvedGitHu
 	}
 }
 
-func buildSource(ctx context.Context, ts *kelos.TaskSpawner, owner, repo, apiBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL string, httpClient *http.Client) (source.Source, error) {
-	return buildSourceWithProxy(ctx, ts, owner, repo, "", apiBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, httpClient)
+// approvalCommentForTaskSpawner returns the GitHub comment that approves the
+// TaskSpawner's Tasks, or an empty string when none is configured.
+func approvalCommentForTaskSpawner(ts *kelos.TaskSpawner) string {
+	if ts.Spec.TaskTemplate.Approval == nil {
+		return ""
+	}
+	return ts.Spec.TaskTemplate.Approval.GitHubComment
 }
 
-func buildSourceWithProxy(ctx context.Context, ts *kelos.TaskSpawner, owner, repo, ghProxyURL, apiBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL string, httpClient *http.Client) (source.Source, error) {
+// approveTaskFromWorkItem approves a Task that is waiting for approval when
+// the work item reports an authorized approval comment posted after the Task
+// was created. Older approvals belong to a previous Task for the same item
+// and are ignored.
+func approveTaskFromWorkItem(ctx context.Context, cl client.Client, task *kelos.Task, item source.WorkItem) error {
+	if task.Spec.Approval == nil || item.ApprovedBy == "" {
+		return nil
+	}
+	if _, ok := task.Annotations[kelos.AnnotationTaskApprove]; ok {
+		return nil
+	}
+	if task.Status.Phase == kelos.TaskPhaseSucceeded || task.Status.Phase == kelos.TaskPhaseFailed {
+		return nil
+	}
+	if !item.ApprovalTime.After(task.CreationTimestamp.Time) {
+		return nil
+	}
+
+	patch := client.MergeFrom(task.DeepCopy())
+	if task.Annotations == nil {
+		task.Annotations = make(map[string]string)
+	}
+	task.Annotations[kelos.AnnotationTaskApprove] = "github:" + item.ApprovedBy
+	if err := cl.Patch(ctx, task, patch); err != nil {
+		return err
+	}
+	ctrl.Log.WithName("spawner").Info("Approved task from GitHub comment", "task", task.Name, "approver", item.ApprovedBy)
+	return nil
+}
+
+func buildSource(ctx context.Context, ts *kelos.TaskSpawner, owner, repo, apiBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject string, httpClient *http.Client) (source.Source, error) {
+	return buildSourceWithProxy(ctx, ts, owner, repo, "", apiBaseURL, tokenResolver, jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject, httpClient)
+}
+
+func buildSourceWithProxy(ctx context.Context, ts *kelos.TaskSpawner, owner, repo, ghProxyURL, apiBaseURL string, tokenResolver func(context.Context) (string, error), jiraBaseURL, jiraProject, jiraJQL, gitlabAPIBaseURL, gitlabProject string, httpClient *http.Client) (source.Source, error) {
 	if ts.Spec.When.GitHubIssues != nil {
 		gh := ts.Spec.When.GitHubIssues
 		commentPolicy := resolveGitHubCommentPolicy(gh.CommentPolicy)
@@ -770,6 +869,7 @@ func buildSourceWithProxy(ctx context.Context, ts *kelos.TaskSpawner, owner, rep
 			AllowedTeams:      commentPolicy.AllowedTeams,
 			MinimumPermission: commentPolicy.MinimumPermission,
 			PriorityLabels:    gh.PriorityLabels,
+			ApprovalComment:   approvalCommentForTaskSpawner(ts),
 		}, nil
 	}
 
@@ -807,6 +907,7 @@ func buildSourceWithProxy(ctx context.Context, ts *kelos.TaskSpawner, owner, rep
 			MinimumPermission: commentPolicy.MinimumPermission,
 			Draft:             gh.Draft,
 			PriorityLabels:    gh.PriorityLabels,
+			ApprovalComment:   approvalCommentForTaskSpawner(ts),
 		}
 		if gh.FilePatterns != nil {
 			src.FileInclude = gh.FilePatterns.Include
@@ -815,6 +916,20 @@ func buildSourceWithProxy(ctx context.Context, ts *kelos.TaskSpawner, owner, rep
 		return src, nil
 	}
 
+	if ts.Spec.When.GitLab != nil {
+		gl := ts.Spec.When.GitLab
+		return &source.GitLabSource{
+			BaseURL:       gitlabAPIBaseURL,
+			Project:       gitlabProject,
+			Types:         gl.Types,
+			Labels:        gl.Labels,
+			ExcludeLabels: gl.ExcludeLabels,
+			State:         gl.State,
+			Token:         os.Getenv("GITLAB_TOKEN"),
+			Client:        httpClient,
+		}, nil
+	}
+
 	if ts.Spec.When.Jira != nil {
 		user := os.Getenv("JIRA_USER")
 		token := os.Getenv("JIRA_TOKEN")
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -252,7 +253,7 @@ func TestRunCycleWithSource_AssignsTaskSpawnerCredentials(t *testing.T) {
 func TestBuildSource_GitHubIssuesWithBaseURL(t *testing.T) {
 	ts := newTaskSpawner("spawner", "default", nil)
 
-	src, err := buildSource(context.Background(), ts, "my-org", "my-repo", "https://github.example.com/api/v3", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "my-org", "my-repo", "https://github.example.com/api/v3", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -275,7 +276,7 @@ func TestBuildSource_GitHubIssuesWithBaseURL(t *testing.T) {
 func TestBuildSource_GitHubIssuesDefaultBaseURL(t *testing.T) {
 	ts := newTaskSpawner("spawner", "default", nil)
 
-	src, err := buildSource(context.Background(), ts, "kelos-dev", "kelos", "", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "kelos-dev", "kelos", "", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -302,8 +303,9 @@ func TestBuildSource_GitHubPullRequests(t *testing.T) {
 			Draft: boolPtr(false),
 		},
 	}
+	ts.Spec.TaskTemplate.Approval = &kelos.ApprovalPolicy{GitHubComment: "/kelos approve"}
 
-	src, err := buildSource(context.Background(), ts, "kelos-dev", "kelos", "https://github.example.com/api/v3", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "kelos-dev", "kelos", "https://github.example.com/api/v3", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -333,6 +335,9 @@ func TestBuildSource_GitHubPullRequests(t *testing.T) {
 	if ghSrc.Draft == nil || *ghSrc.Draft {
 		t.Errorf("Draft = %v, want false", ghSrc.Draft)
 	}
+	if ghSrc.ApprovalComment != "/kelos approve" {
+		t.Errorf("ApprovalComment = %q, want %q", ghSrc.ApprovalComment, "/kelos approve")
+	}
 }
 
 func TestBuildSource_Jira(t *testing.T) {
@@ -363,7 +368,7 @@ func TestBuildSource_Jira(t *testing.T) {
 	t.Setenv("JIRA_USER", "user@example.com")
 	t.Setenv("JIRA_TOKEN", "jira-api-token")
 
-	src, err := buildSource(context.Background(), ts, "", "", "", noToken, "https://mycompany.atlassian.net", "PROJ", "status = Open", nil)
+	src, err := buildSource(context.Background(), ts, "", "", "", noToken, "https://mycompany.atlassian.net", "PROJ", "status = Open", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -695,7 +700,7 @@ func TestRunCycle_BuildSourceFailureCountsDiscoveryErrorAndDuration(t *testing.T
 	beforeErrors := testutil.ToFloat64(discoveryErrorsTotal)
 	beforeDurationCount := histogramSampleCount(t, discoveryDurationSeconds)
 
-	err := runCycle(context.Background(), cl, key, "owner", "repo", "", noToken, "", "", "", nil)
+	err := runCycle(context.Background(), cl, key, "owner", "repo", "", noToken, "", "", "", "", "", nil)
 	if err == nil {
 		t.Fatal("Expected buildSource error")
 	}
@@ -1373,6 +1378,48 @@ func TestRunCycleWithSource_PriorityLabelsNotConfigured(t *testing.T) {
 	}
 }
 
+func TestBuildSource_GitLab(t *testing.T) {
+	ts := &kelos.TaskSpawner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spawner",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpawnerSpec{
+			When: kelos.When{
+				GitLab: &kelos.GitLab{
+					Types:  []string{"issues", "merge_requests"},
+					Labels: []string{"kelos"},
+					State:  "opened",
+				},
+			},
+		},
+	}
+
+	t.Setenv("GITLAB_TOKEN", "glpat-test")
+
+	src, err := buildSource(context.Background(), ts, "", "", "", noToken, "", "", "", "https://gitlab.example.com/api/v4", "group/service", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	glSrc, ok := src.(*source.GitLabSource)
+	if !ok {
+		t.Fatalf("Expected *source.GitLabSource, got %T", src)
+	}
+	if glSrc.BaseURL != "https://gitlab.example.com/api/v4" {
+		t.Errorf("BaseURL = %q, want %q", glSrc.BaseURL, "https://gitlab.example.com/api/v4")
+	}
+	if glSrc.Project != "group/service" {
+		t.Errorf("Project = %q, want %q", glSrc.Project, "group/service")
+	}
+	if glSrc.Token != "glpat-test" {
+		t.Errorf("Token = %q, want %q", glSrc.Token, "glpat-test")
+	}
+	if !reflect.DeepEqual(glSrc.Types, []string{"issues", "merge_requests"}) {
+		t.Errorf("Types = %v", glSrc.Types)
+	}
+}
+
 func TestBuildSource_PriorityLabelsPassedToSource(t *testing.T) {
 	ts := newTaskSpawner("spawner", "default", nil)
 	ts.Spec.When.GitHubIssues.PriorityLabels = []string{
@@ -1380,7 +1427,7 @@ func TestBuildSource_PriorityLabelsPassedToSource(t *testing.T) {
 		"priority/imporant-soon",
 	}
 
-	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -1409,7 +1456,7 @@ func TestRunCycleWithSource_CommentFieldsPassedToSource(t *testing.T) {
 		},
 	}
 
-	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -1438,7 +1485,7 @@ func TestBuildSource_CommentPolicyPassedToIssueSource(t *testing.T) {
 		},
 	}
 
-	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -1478,7 +1525,7 @@ func TestBuildSource_CommentPolicyPassedToPullRequestSource(t *testing.T) {
 		},
 	}
 
-	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", nil)
+	src, err := buildSource(context.Background(), ts, "owner", "repo", "", noToken, "", "", "", "", "", nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -1976,6 +2023,43 @@ func TestSourceAnnotations_GitHubPR(t *testing.T) {
 	}
 }
 
+func TestSourceAnnotations_GitLab(t *testing.T) {
+	ts := &kelos.TaskSpawner{
+		Spec: kelos.TaskSpawnerSpec{
+			When: kelos.When{
+				GitLab: &kelos.GitLab{
+					Reporting: &kelos.GitLabReporting{
+						Notes:        true,
+						CommitStatus: &kelos.GitLabCommitStatusReporting{Name: "kelos/review"},
+					},
+				},
+			},
+		},
+	}
+
+	annotations := sourceAnnotations(ts, source.WorkItem{ID: "mr-7", Number: 7, Kind: "PR", HeadSHA: "abc123"})
+	want := map[string]string{
+		reporting.AnnotationSourceKind:            "pull-request",
+		reporting.AnnotationSourceNumber:          "7",
+		reporting.AnnotationForgeNotes:            "enabled",
+		reporting.AnnotationForgeCommitStatus:     "enabled",
+		reporting.AnnotationSourceSHA:             "abc123",
+		reporting.AnnotationForgeCommitStatusName: "kelos/review",
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("sourceAnnotations() = %v, want %v", annotations, want)
+	}
+
+	// Issues have no head commit, so no commit status is reported.
+	annotations = sourceAnnotations(ts, source.WorkItem{ID: "3", Number: 3, Kind: "Issue"})
+	if _, ok := annotations[reporting.AnnotationForgeCommitStatus]; ok {
+		t.Errorf("Expected no commit status annotation for an issue, got %v", annotations)
+	}
+	if annotations[reporting.AnnotationSourceKind] != "issue" {
+		t.Errorf("Expected source-kind 'issue', got %q", annotations[reporting.AnnotationSourceKind])
+	}
+}
+
 func TestSourceAnnotations_ReportingEnabled(t *testing.T) {
 	ts := &kelos.TaskSpawner{
 		Spec: kelos.TaskSpawnerSpec{
@@ -3280,3 +3364,52 @@ func TestRunCycleWithSource_NameTemplateDedup(t *testing.T) {
 		t.Errorf("Expected tasks custom-1 and custom-2, got %v", names)
 	}
 }
+
+func TestRunCycleWithSource_ApprovesTaskFromComment(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		approvalTime time.Time
+		want         string
+	}{
+		{
+			name:         "approval after task creation",
+			approvalTime: createdAt.Add(time.Hour),
+			want:         "github:alice",
+		},
+		{
+			name:         "approval before task creation is ignored",
+			approvalTime: createdAt.Add(-time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTaskSpawner("spawner", "default", nil)
+			ts.Spec.TaskTemplate.Approval = &kelos.ApprovalPolicy{GitHubComment: "/kelos approve"}
+
+			task := newTask("spawner-1", "default", "spawner", kelos.TaskPhaseAwaitingApproval)
+			task.CreationTimestamp = metav1.NewTime(createdAt)
+			task.Spec.Approval = &kelos.ApprovalPolicy{GitHubComment: "/kelos approve"}
+			cl, key := setupTest(t, ts, task)
+
+			src := &fakeSource{
+				items: []source.WorkItem{
+					{ID: "1", Title: "Needs approval", ApprovedBy: "alice", ApprovalTime: tt.approvalTime},
+				},
+			}
+			if err := runCycleWithSource(context.Background(), cl, key, src); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got kelos.Task
+			if err := cl.Get(context.Background(), types.NamespacedName{Name: "spawner-1", Namespace: "default"}, &got); err != nil {
+				t.Fatalf("Getting task: %v", err)
+			}
+			if approver := got.Annotations[kelos.AnnotationTaskApprove]; approver != tt.want {
+				t.Errorf("approve annotation = %q, want %q", approver, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,6 +19,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
 	"github.com/kelos-dev/kelos/internal/reporting"
 )
 
@@ -31,6 +33,8 @@ type spawnerRuntimeConfig struct {
 	JiraBaseURL      string
 	JiraProject      string
 	JiraJQL          string
+	GitLabAPIBaseURL string
+	GitLabProject    string
 	HTTPClient       *http.Client
 }
 
@@ -70,7 +74,7 @@ func (r *spawnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 func runOnce(ctx context.Context, cl client.Client, key types.NamespacedName, cfg spawnerRuntimeConfig) (time.Duration, error) {
-	if err := runCycleWithProxy(ctx, cl, key, cfg.GitHubOwner, cfg.GitHubRepo, cfg.GHProxyURL, cfg.GitHubAPIBaseURL, cfg.TokenResolver, cfg.JiraBaseURL, cfg.JiraProject, cfg.JiraJQL, cfg.HTTPClient); err != nil {
+	if err := runCycleWithProxy(ctx, cl, key, cfg.GitHubOwner, cfg.GitHubRepo, cfg.GHProxyURL, cfg.GitHubAPIBaseURL, cfg.TokenResolver, cfg.JiraBaseURL, cfg.JiraProject, cfg.JiraJQL, cfg.GitLabAPIBaseURL, cfg.GitLabProject, cfg.HTTPClient); err != nil {
 		return 0, err
 	}
 
@@ -118,6 +122,21 @@ func runOnce(ctx context.Context, cl client.Client, key types.NamespacedName, cf
 		}
 	}
 
+	if gitLabNotesEnabled(&ts) || gitLabCommitStatusEnabled(&ts) {
+		reporter := &reporting.ForgeTaskReporter{
+			Client: cl,
+			Forge: &forge.GitLab{
+				BaseURL: cfg.GitLabAPIBaseURL,
+				Project: cfg.GitLabProject,
+				Token:   os.Getenv("GITLAB_TOKEN"),
+				Client:  cfg.HTTPClient,
+			},
+		}
+		if err := runReportingCycle(ctx, cl, key, reporter); err != nil {
+			return 0, err
+		}
+	}
+
 	return resolvedPollInterval(&ts), nil
 }
 
@@ -130,6 +149,8 @@ func resolvedPollInterval(ts *kelos.TaskSpawner) time.Duration {
 		sourceInterval = ts.Spec.When.GitHubIssues.PollInterval
 	case ts.Spec.When.GitHubPullRequests != nil:
 		sourceInterval = ts.Spec.When.GitHubPullRequests.PollInterval
+	case ts.Spec.When.GitLab != nil:
+		sourceInterval = ts.Spec.When.GitLab.PollInterval
 	case ts.Spec.When.Jira != nil:
 		sourceInterval = ts.Spec.When.Jira.PollInterval
 	}
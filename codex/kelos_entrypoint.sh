@@ -6,6 +6,9 @@
 #   - First argument ($1): the task prompt
 #   - KELOS_SESSION_SETUP_ONLY=1: prepare configuration and exit without a prompt
 #   - KELOS_MODEL env var: model name (optional)
+#   - KELOS_PERMISSIONS env var: JSON tool permission policy (optional)
+#   - KELOS_CONVERSATION=1: CODEX_HOME persists the conversation across Tasks
+#   - KELOS_CONTINUE=1: continue the most recent conversation with the prompt
 #   - UID 61100: shared between git-clone init container and agent
 #   - Working directory: /workspace/repo when a workspace is configured
 
@@ -13,7 +16,9 @@ set -uo pipefail
 
 codex_home="${CODEX_HOME:-$HOME/.codex}"
 mkdir -p "$codex_home"
-if [ "${KELOS_SESSION_SETUP_ONLY:-}" = "1" ]; then
+# A persisted Codex home keeps the config of an earlier run; start over so
+# the settings below are not appended twice.
+if [ "${KELOS_SESSION_SETUP_ONLY:-}" = "1" ] || [ "${KELOS_CONVERSATION:-}" = "1" ]; then
   : >"$codex_home/config.toml"
 fi
 
@@ -67,6 +72,34 @@ if [ -n "${KELOS_PLUGIN_DIR:-}" ] && [ -d "${KELOS_PLUGIN_DIR}" ]; then
   done
 fi
 
+# Translate the tool permission policy. Denied commands become forbidden
+# prefix rules of the Codex exec policy, which also apply when approvals
+# are bypassed; MCP tool limits are applied to the servers below.
+rm -f "$codex_home/rules/kelos.rules"
+if [ -n "${KELOS_PERMISSIONS:-}" ]; then
+  node -e '
+const fs = require("fs");
+const path = require("path");
+const codexHome = process.argv[1];
+const policy = JSON.parse(process.env.KELOS_PERMISSIONS);
+const rules = (policy.deniedCommands || []).map((command) => {
+  const words = command.trim().split(/\s+/).slice(0, -1);
+  return `prefix_rule(pattern = ${JSON.stringify(words)}, decision = "forbidden", ` +
+    `justification = ${JSON.stringify(`denied by the Kelos permission policy: ${command}`)})\n`;
+});
+if (rules.length > 0) {
+  fs.mkdirSync(path.join(codexHome, "rules"), { recursive: true });
+  fs.writeFileSync(path.join(codexHome, "rules", "kelos.rules"), rules.join(""));
+}
+const allowed = policy.allowedTools || [];
+const builtins = ["shell", "read", "edit", "web-fetch", "web-search"];
+if ((policy.deniedTools || []).includes("web-search") ||
+    (allowed.some((tool) => builtins.includes(tool)) && !allowed.includes("web-search"))) {
+  fs.appendFileSync(path.join(codexHome, "config.toml"), "web_search = \"disabled\"\n");
+}
+' "$codex_home"
+fi
+
 # Write MCP server configuration to project-scoped config.
 # KELOS_MCP_SERVERS contains JSON in .mcp.json format; convert to
 # Codex TOML via a small Node.js helper that is available in the image.
@@ -74,9 +107,20 @@ if [ -n "${KELOS_MCP_SERVERS:-}" ]; then
   node -e '
 const cfg = JSON.parse(process.env.KELOS_MCP_SERVERS);
 const servers = cfg.mcpServers || {};
+const policy = JSON.parse(process.env.KELOS_PERMISSIONS || "{}");
+const mcpTools = (list) => (list || []).filter((tool) => tool.startsWith("mcp:")).map((tool) => tool.slice(4).split("/"));
 let toml = "";
 for (const [name, s] of Object.entries(servers)) {
   toml += `[mcp_servers.${JSON.stringify(name)}]\n`;
+  const denied = mcpTools(policy.deniedTools).filter(([server]) => server === name);
+  const allowed = mcpTools(policy.allowedTools).filter(([server]) => server === name);
+  if (denied.some(([, tool]) => tool === undefined)) toml += "enabled = false\n";
+  if (allowed.length > 0 && allowed.every(([, tool]) => tool !== undefined)) {
+    toml += `enabled_tools = ${JSON.stringify(allowed.map(([, tool]) => tool))}\n`;
+  }
+  if (denied.some(([, tool]) => tool !== undefined)) {
+    toml += `disabled_tools = ${JSON.stringify(denied.filter(([, tool]) => tool !== undefined).map(([, tool]) => tool))}\n`;
+  }
   if (s.command) toml += `command = ${JSON.stringify(s.command)}\n`;
   if (s.args && s.args.length) toml += `args = ${JSON.stringify(s.args)}\n`;
   if (s.url) toml += `url = ${JSON.stringify(s.url)}\n`;
@@ -127,7 +171,6 @@ ARGS=(
   "exec"
   "--dangerously-bypass-approvals-and-sandbox"
   "--json"
-  "$PROMPT"
 )
 
 if [ -n "${KELOS_MODEL:-}" ]; then
@@ -139,6 +182,16 @@ if [ -n "${KELOS_EFFORT:-}" ]; then
   ARGS+=("--config" "model_reasoning_effort=\"$SAFE_EFFORT\"")
 fi
 
+# Resume the conversation restored from the continued Task.
+if [ "${KELOS_CONTINUE:-}" = "1" ]; then
+  ARGS+=("resume" "--last")
+fi
+ARGS+=("$PROMPT")
+
+# Keep the shell alive when the agent is stopped with SIGTERM (for example,
+# when the Task is cancelled) so kelos-capture can still report outputs.
+trap 'true' TERM
+
 codex "${ARGS[@]}" | /kelos/kelos-capture
 AGENT_EXIT_CODE=${PIPESTATUS[0]}
 
@@ -128,6 +128,10 @@ process.exit(r.status ?? 1);
   printf '\n---KELOS_SETUP_COMMAND_DONE---\n' >&2
 fi
 
+# Keep the shell alive when the agent is stopped with SIGTERM (for example,
+# when the Task is cancelled) so kelos-capture can still report outputs.
+trap 'true' TERM
+
 agent "${ARGS[@]}" | /kelos/kelos-capture
 AGENT_EXIT_CODE=${PIPESTATUS[0]}
 
@@ -285,6 +285,7 @@ generate_chart_crd_templates() {
   mkdir -p "${CHART_CRD_DIR}"
 
   write_chart_crd_template "${source}" "CustomResourceDefinition" "agentconfigs.kelos.dev" "${CHART_CRD_DIR}/agentconfig-crd.yaml"
+  write_chart_crd_template "${source}" "CustomResourceDefinition" "pipelines.kelos.dev" "${CHART_CRD_DIR}/pipeline-crd.yaml"
   write_chart_crd_template "${source}" "CustomResourceDefinition" "sessions.kelos.dev" "${CHART_CRD_DIR}/session-crd.yaml"
   write_chart_crd_template "${source}" "CustomResourceDefinition" "sessionspawners.kelos.dev" "${CHART_CRD_DIR}/sessionspawner-crd.yaml"
   write_chart_crd_template "${source}" "CustomResourceDefinition" "taskbudgets.kelos.dev" "${CHART_CRD_DIR}/taskbudget-crd.yaml"
@@ -18,6 +18,7 @@ cd "${REPO_ROOT}"
 GENERATED_FILES=(
   internal/manifests/install-crd.yaml
   internal/manifests/charts/kelos/charts/kelos-crds/templates/agentconfig-crd.yaml
+  internal/manifests/charts/kelos/charts/kelos-crds/templates/pipeline-crd.yaml
   internal/manifests/charts/kelos/charts/kelos-crds/templates/session-crd.yaml
   internal/manifests/charts/kelos/charts/kelos-crds/templates/sessionspawner-crd.yaml
   internal/manifests/charts/kelos/charts/kelos-crds/templates/taskbudget-crd.yaml
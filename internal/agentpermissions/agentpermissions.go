@@ -0,0 +1,413 @@
+// Package agentpermissions evaluates the provider-neutral tool permission
+// policy of an AgentConfig and checks which agent types can enforce it.
+// The policy reaches the agent as JSON in the KELOS_PERMISSIONS environment
+// variable; the agent entrypoints and the Session runtime translate it into
+// each agent's native permission configuration.
+package agentpermissions
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// EnvVar is the environment variable that carries the JSON-encoded policy
+// to the agent container.
+const EnvVar = "KELOS_PERMISSIONS"
+
+// Built-in tool names.
+const (
+	ToolShell     = "shell"
+	ToolRead      = "read"
+	ToolEdit      = "edit"
+	ToolWebFetch  = "web-fetch"
+	ToolWebSearch = "web-search"
+)
+
+// BuiltinTools lists the built-in tool names in a stable order.
+var BuiltinTools = []string{ToolShell, ToolRead, ToolEdit, ToolWebFetch, ToolWebSearch}
+
+const mcpPrefix = "mcp:"
+
+// Empty reports whether p has no rules.
+func Empty(p *kelos.AgentPermissions) bool {
+	return p == nil || (len(p.AllowedTools) == 0 && len(p.DeniedTools) == 0 &&
+		len(p.DeniedCommands) == 0 && len(p.DeniedPaths) == 0)
+}
+
+// Merge combines policies in order. The lists are concatenated without
+// duplicates, so a later policy can add rules but not remove them. Returns
+// nil if no policy has rules.
+func Merge(policies ...*kelos.AgentPermissions) *kelos.AgentPermissions {
+	merged := &kelos.AgentPermissions{}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		merged.AllowedTools = appendUnique(merged.AllowedTools, p.AllowedTools)
+		merged.DeniedTools = appendUnique(merged.DeniedTools, p.DeniedTools)
+		merged.DeniedCommands = appendUnique(merged.DeniedCommands, p.DeniedCommands)
+		merged.DeniedPaths = appendUnique(merged.DeniedPaths, p.DeniedPaths)
+	}
+	if Empty(merged) {
+		return nil
+	}
+	return merged
+}
+
+func appendUnique(list, values []string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Validate returns an error describing the first malformed entry of p.
+func Validate(p *kelos.AgentPermissions) error {
+	if p == nil {
+		return nil
+	}
+	for _, tool := range p.AllowedTools {
+		if err := validateTool(tool); err != nil {
+			return fmt.Errorf("permissions.allowedTools: %w", err)
+		}
+	}
+	for _, tool := range p.DeniedTools {
+		if err := validateTool(tool); err != nil {
+			return fmt.Errorf("permissions.deniedTools: %w", err)
+		}
+	}
+	for _, command := range p.DeniedCommands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("permissions.deniedCommands: pattern must not be empty")
+		}
+	}
+	for _, glob := range p.DeniedPaths {
+		if err := validatePath(glob); err != nil {
+			return fmt.Errorf("permissions.deniedPaths: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateTool(tool string) error {
+	if slices.Contains(BuiltinTools, tool) {
+		return nil
+	}
+	server, name, ok := ParseMCPTool(tool)
+	if !ok {
+		return fmt.Errorf("unknown tool %q: expected one of %s, mcp:<server> or mcp:<server>/<tool>", tool, strings.Join(BuiltinTools, ", "))
+	}
+	if server == "" || strings.Contains(server, "*") {
+		return fmt.Errorf("tool %q must name an MCP server without wildcards", tool)
+	}
+	if name != nil && (*name == "" || strings.Contains(*name, "/")) {
+		return fmt.Errorf("tool %q must name a single MCP tool", tool)
+	}
+	return nil
+}
+
+func validatePath(glob string) error {
+	if strings.TrimSpace(glob) == "" {
+		return fmt.Errorf("glob must not be empty")
+	}
+	if path.IsAbs(glob) {
+		return fmt.Errorf("glob %q must be relative to the working directory", glob)
+	}
+	for _, segment := range strings.Split(path.Clean(glob), "/") {
+		if segment == ".." {
+			return fmt.Errorf("glob %q must not leave the working directory", glob)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("glob %q is malformed: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// ParseMCPTool splits "mcp:<server>" or "mcp:<server>/<tool>" into its
+// server and tool; tool is nil when the entry names the whole server.
+func ParseMCPTool(tool string) (server string, name *string, ok bool) {
+	rest, ok := strings.CutPrefix(tool, mcpPrefix)
+	if !ok {
+		return "", nil, false
+	}
+	server, toolName, hasTool := strings.Cut(rest, "/")
+	if !hasTool {
+		return server, nil, true
+	}
+	return server, &toolName, true
+}
+
+// CheckAgentType returns an error when agentType cannot enforce every rule
+// of p. A policy is never silently dropped: a Task whose agent cannot
+// enforce it fails instead.
+func CheckAgentType(p *kelos.AgentPermissions, agentType string) error {
+	if Empty(p) {
+		return nil
+	}
+	switch agentType {
+	case "claude-code", "opencode":
+		return nil
+	case "codex":
+		return checkCodex(p)
+	default:
+		return fmt.Errorf("agent type %s does not support permissions", agentType)
+	}
+}
+
+// checkCodex reports the rules Codex cannot enforce. Codex forbids command
+// prefixes through its exec policy and limits web search and MCP tools
+// through config.toml, but cannot deny its other built-in tools or single
+// paths.
+func checkCodex(p *kelos.AgentPermissions) error {
+	if len(p.DeniedPaths) > 0 {
+		return fmt.Errorf("codex cannot enforce permissions.deniedPaths")
+	}
+	for _, tool := range p.DeniedTools {
+		if err := checkCodexTool(tool); err != nil {
+			return fmt.Errorf("codex cannot enforce permissions.deniedTools: %w", err)
+		}
+	}
+	var allowedBuiltins []string
+	for _, tool := range p.AllowedTools {
+		if slices.Contains(BuiltinTools, tool) {
+			allowedBuiltins = append(allowedBuiltins, tool)
+			continue
+		}
+		if err := checkCodexTool(tool); err != nil {
+			return fmt.Errorf("codex cannot enforce permissions.allowedTools: %w", err)
+		}
+	}
+	if len(allowedBuiltins) > 0 {
+		for _, tool := range BuiltinTools {
+			if tool != ToolWebSearch && !slices.Contains(allowedBuiltins, tool) {
+				return fmt.Errorf("codex cannot enforce permissions.allowedTools: tool %q cannot be denied", tool)
+			}
+		}
+	}
+	for _, command := range p.DeniedCommands {
+		if _, ok := commandPrefix(command); !ok {
+			return fmt.Errorf("codex cannot enforce permissions.deniedCommands: pattern %q is not a command prefix followed by \" *\"", command)
+		}
+	}
+	return nil
+}
+
+func checkCodexTool(tool string) error {
+	if tool == ToolWebSearch {
+		return nil
+	}
+	if slices.Contains(BuiltinTools, tool) {
+		return fmt.Errorf("tool %q cannot be denied", tool)
+	}
+	if _, name, _ := ParseMCPTool(tool); name != nil && strings.Contains(*name, "*") {
+		return fmt.Errorf("tool %q uses a wildcard", tool)
+	}
+	return nil
+}
+
+// commandPrefix returns the words of a command pattern of the form
+// "<words> *", which matches every command that starts with the words.
+func commandPrefix(pattern string) ([]string, bool) {
+	fields := strings.Fields(pattern)
+	if len(fields) < 2 || fields[len(fields)-1] != "*" {
+		return nil, false
+	}
+	fields = fields[:len(fields)-1]
+	for _, field := range fields {
+		if strings.Contains(field, "*") {
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// Action is a tool call to check against a policy.
+type Action struct {
+	// Tool is a built-in tool name or "mcp:<server>/<tool>".
+	Tool string
+	// Command is the command line of a shell call.
+	Command string
+	// Path is the file of an edit call, relative to the working directory.
+	Path string
+}
+
+// Denied returns why p denies action, or false when p allows it.
+func Denied(p *kelos.AgentPermissions, action Action) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	for _, entry := range p.DeniedTools {
+		if toolMatches(entry, action.Tool) {
+			return fmt.Sprintf("tool %q is denied", action.Tool), true
+		}
+	}
+	if !toolAllowed(p.AllowedTools, action.Tool) {
+		return fmt.Sprintf("tool %q is not in allowedTools", action.Tool), true
+	}
+	if action.Tool == ToolShell {
+		for _, command := range SplitCommands(action.Command) {
+			for _, pattern := range p.DeniedCommands {
+				if CommandMatches(pattern, command) {
+					return fmt.Sprintf("command %q matches denied pattern %q", command, pattern), true
+				}
+			}
+		}
+	}
+	if action.Tool == ToolEdit && action.Path != "" {
+		for _, glob := range p.DeniedPaths {
+			if PathMatches(glob, action.Path) {
+				return fmt.Sprintf("path %q matches denied glob %q", action.Path, glob), true
+			}
+		}
+	}
+	return "", false
+}
+
+// toolMatches reports whether the tool entry of a policy covers tool.
+func toolMatches(entry, tool string) bool {
+	if entry == tool {
+		return true
+	}
+	entryServer, entryName, ok := ParseMCPTool(entry)
+	if !ok {
+		return false
+	}
+	server, name, ok := ParseMCPTool(tool)
+	if !ok || server != entryServer {
+		return false
+	}
+	if entryName == nil {
+		return true
+	}
+	return name != nil && wildcardMatch(*entryName, *name)
+}
+
+// toolAllowed reports whether allowed leaves tool usable: built-in tools are
+// limited when a built-in tool is listed, and the tools of an MCP server
+// when one of its tools is listed.
+func toolAllowed(allowed []string, tool string) bool {
+	if slices.Contains(BuiltinTools, tool) {
+		limited := false
+		for _, entry := range allowed {
+			if slices.Contains(BuiltinTools, entry) {
+				limited = true
+				if entry == tool {
+					return true
+				}
+			}
+		}
+		return !limited
+	}
+	server, _, ok := ParseMCPTool(tool)
+	if !ok {
+		return true
+	}
+	limited := false
+	for _, entry := range allowed {
+		entryServer, entryName, ok := ParseMCPTool(entry)
+		if !ok || entryServer != server {
+			continue
+		}
+		if entryName == nil {
+			return true
+		}
+		limited = true
+		if toolMatches(entry, tool) {
+			return true
+		}
+	}
+	return !limited
+}
+
+// LimitedMCPServers returns the MCP servers whose tools allowed limits,
+// with the allowed tool patterns of each.
+func LimitedMCPServers(allowed []string) map[string][]string {
+	limited := map[string][]string{}
+	whole := map[string]bool{}
+	for _, entry := range allowed {
+		server, name, ok := ParseMCPTool(entry)
+		if !ok {
+			continue
+		}
+		if name == nil {
+			whole[server] = true
+			continue
+		}
+		limited[server] = append(limited[server], *name)
+	}
+	for server := range whole {
+		delete(limited, server)
+	}
+	return limited
+}
+
+var commandSeparatorRe = regexp.MustCompile(`\s*(?:&&|\|\||;|\||\n)\s*`)
+
+// SplitCommands splits a command line into the commands of its pipelines
+// and command lists. Quoting is not taken into account.
+func SplitCommands(commandLine string) []string {
+	var commands []string
+	for _, command := range commandSeparatorRe.Split(commandLine, -1) {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// CommandMatches reports whether command matches pattern. Whitespace is
+// normalized, * matches any text and a trailing " *" also matches the
+// command without arguments.
+func CommandMatches(pattern, command string) bool {
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	command = strings.Join(strings.Fields(command), " ")
+	if prefix, ok := strings.CutSuffix(pattern, " *"); ok && command == prefix {
+		return true
+	}
+	return wildcardMatch(pattern, command)
+}
+
+// wildcardMatch reports whether value matches pattern, in which * matches
+// any text.
+func wildcardMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(value)
+}
+
+// PathMatches reports whether file, relative to the working directory,
+// matches glob. A * matches within a path segment and ** matches any
+// number of segments.
+func PathMatches(glob, file string) bool {
+	file = strings.TrimPrefix(path.Clean(file), "./")
+	return matchSegments(strings.Split(path.Clean(glob), "/"), strings.Split(file, "/"))
+}
+
+func matchSegments(glob, file []string) bool {
+	if len(glob) == 0 {
+		return len(file) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(file); i++ {
+			if matchSegments(glob[1:], file[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(file) == 0 {
+		return false
+	}
+	ok, err := path.Match(glob[0], file[0])
+	return err == nil && ok && matchSegments(glob[1:], file[1:])
+}
@@ -0,0 +1,148 @@
+package agentpermissions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestDenied(t *testing.T) {
+	policy := &kelos.AgentPermissions{
+		AllowedTools:   []string{"shell", "read", "edit", "mcp:github/get_*", "mcp:github/list_issues"},
+		DeniedTools:    []string{"mcp:slack"},
+		DeniedCommands: []string{"rm -rf *", "kubectl *"},
+		DeniedPaths:    []string{".github/workflows/**"},
+	}
+	tests := []struct {
+		name   string
+		action Action
+		denied bool
+	}{
+		{"allowed command", Action{Tool: ToolShell, Command: "go test ./..."}, false},
+		{"denied command", Action{Tool: ToolShell, Command: "rm  -rf /tmp/build"}, true},
+		{"bare command of a trailing wildcard", Action{Tool: ToolShell, Command: "kubectl"}, true},
+		{"denied command in a list", Action{Tool: ToolShell, Command: "cd deploy && kubectl apply -f ."}, true},
+		{"denied command in a pipeline", Action{Tool: ToolShell, Command: "echo y | kubectl delete ns prod"}, true},
+		{"similar command", Action{Tool: ToolShell, Command: "kubectl-lint config.yaml"}, false},
+		{"allowed path", Action{Tool: ToolEdit, Path: "main.go"}, false},
+		{"denied path", Action{Tool: ToolEdit, Path: ".github/workflows/ci.yaml"}, true},
+		{"denied nested path", Action{Tool: ToolEdit, Path: "./.github/workflows/release/publish.yaml"}, true},
+		{"read of a denied path", Action{Tool: ToolRead, Path: ".github/workflows/ci.yaml"}, false},
+		{"built-in tool not allowed", Action{Tool: ToolWebFetch}, true},
+		{"allowed MCP tool", Action{Tool: "mcp:github/get_issue"}, false},
+		{"MCP tool not allowed", Action{Tool: "mcp:github/create_issue"}, true},
+		{"denied MCP server", Action{Tool: "mcp:slack/post_message"}, true},
+		{"unlimited MCP server", Action{Tool: "mcp:docs/search"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, denied := Denied(policy, tt.action)
+			if denied != tt.denied {
+				t.Errorf("Denied(%+v) = %q, %v, want denied %v", tt.action, reason, denied, tt.denied)
+			}
+			if denied && reason == "" {
+				t.Error("Expected a reason for a denied action")
+			}
+		})
+	}
+
+	if _, denied := Denied(nil, Action{Tool: ToolShell, Command: "rm -rf /"}); denied {
+		t.Error("Expected a nil policy to allow every action")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := &kelos.AgentPermissions{
+		AllowedTools:   []string{"shell", "mcp:github", "mcp:github/get_*"},
+		DeniedTools:    []string{"web-search"},
+		DeniedCommands: []string{"rm -rf *"},
+		DeniedPaths:    []string{".github/workflows/**", "*.pem"},
+	}
+	if err := Validate(valid); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		policy kelos.AgentPermissions
+		want   string
+	}{
+		{"unknown tool", kelos.AgentPermissions{DeniedTools: []string{"Bash"}}, `unknown tool "Bash"`},
+		{"wildcard server", kelos.AgentPermissions{AllowedTools: []string{"mcp:*/get"}}, "without wildcards"},
+		{"empty MCP tool", kelos.AgentPermissions{DeniedTools: []string{"mcp:github/"}}, "single MCP tool"},
+		{"empty command", kelos.AgentPermissions{DeniedCommands: []string{" "}}, "must not be empty"},
+		{"absolute path", kelos.AgentPermissions{DeniedPaths: []string{"/etc/passwd"}}, "relative to the working directory"},
+		{"parent path", kelos.AgentPermissions{DeniedPaths: []string{"../secrets/**"}}, "must not leave"},
+		{"malformed glob", kelos.AgentPermissions{DeniedPaths: []string{"src/[a"}}, "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.policy)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAgentType(t *testing.T) {
+	tests := []struct {
+		name      string
+		agentType string
+		policy    *kelos.AgentPermissions
+		wantErr   bool
+	}{
+		{"no policy", "gemini", nil, false},
+		{"empty policy", "cursor", &kelos.AgentPermissions{}, false},
+		{"claude-code", "claude-code", &kelos.AgentPermissions{DeniedPaths: []string{"*.pem"}}, false},
+		{"opencode", "opencode", &kelos.AgentPermissions{DeniedTools: []string{"mcp:github/create_*"}}, false},
+		{"gemini", "gemini", &kelos.AgentPermissions{DeniedCommands: []string{"rm -rf *"}}, true},
+		{"codex command prefix", "codex", &kelos.AgentPermissions{DeniedCommands: []string{"rm -rf *", "kubectl *"}}, false},
+		{"codex exact command", "codex", &kelos.AgentPermissions{DeniedCommands: []string{"kubectl"}}, true},
+		{"codex wildcard command", "codex", &kelos.AgentPermissions{DeniedCommands: []string{"git push * --force"}}, true},
+		{"codex path", "codex", &kelos.AgentPermissions{DeniedPaths: []string{".github/**"}}, true},
+		{"codex web search", "codex", &kelos.AgentPermissions{DeniedTools: []string{"web-search", "mcp:slack"}}, false},
+		{"codex shell", "codex", &kelos.AgentPermissions{DeniedTools: []string{"shell"}}, true},
+		{"codex MCP wildcard", "codex", &kelos.AgentPermissions{AllowedTools: []string{"mcp:github/get_*"}}, true},
+		{"codex MCP allowlist", "codex", &kelos.AgentPermissions{AllowedTools: []string{"mcp:github/get_issue"}}, false},
+		{"codex built-in allowlist", "codex", &kelos.AgentPermissions{AllowedTools: []string{"shell", "read", "edit", "web-fetch"}}, false},
+		{"codex partial built-in allowlist", "codex", &kelos.AgentPermissions{AllowedTools: []string{"shell", "read"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAgentType(tt.policy, tt.agentType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckAgentType() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge(
+		&kelos.AgentPermissions{DeniedCommands: []string{"rm -rf *"}, AllowedTools: []string{"shell"}},
+		nil,
+		&kelos.AgentPermissions{DeniedCommands: []string{"kubectl *", "rm -rf *"}, DeniedPaths: []string{".github/**"}},
+	)
+	want := &kelos.AgentPermissions{
+		AllowedTools:   []string{"shell"},
+		DeniedCommands: []string{"rm -rf *", "kubectl *"},
+		DeniedPaths:    []string{".github/**"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+	if got := Merge(nil, &kelos.AgentPermissions{}); got != nil {
+		t.Errorf("Merge() = %+v, want nil", got)
+	}
+}
+
+func TestLimitedMCPServers(t *testing.T) {
+	got := LimitedMCPServers([]string{"shell", "mcp:github/get_*", "mcp:github/list_*", "mcp:docs/search", "mcp:docs"})
+	want := map[string][]string{"github": {"get_*", "list_*"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LimitedMCPServers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Package artifacts collects files from a Task's workspace and stores them
+// in S3-compatible object storage.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// MaxFiles caps the number of files uploaded for a Task so that the object
+// keys recorded in the Task status stay small.
+const MaxFiles = 100
+
+// Keys of the storage credentials Secret.
+const (
+	SecretKeyAccessKeyID     = "AWS_ACCESS_KEY_ID"
+	SecretKeySecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	SecretKeySessionToken    = "AWS_SESSION_TOKEN"
+)
+
+// Environment variables through which the controller configures the
+// upload done by kelos-capture.
+const (
+	// EnvPaths holds the artifact glob patterns as a JSON array.
+	EnvPaths = "KELOS_ARTIFACTS_PATHS"
+	// EnvDir is the directory the patterns are relative to.
+	EnvDir             = "KELOS_ARTIFACTS_DIR"
+	EnvEndpoint        = "KELOS_ARTIFACTS_ENDPOINT"
+	EnvBucket          = "KELOS_ARTIFACTS_BUCKET"
+	EnvRegion          = "KELOS_ARTIFACTS_REGION"
+	EnvKeyPrefix       = "KELOS_ARTIFACTS_KEY_PREFIX"
+	EnvAccessKeyID     = "KELOS_ARTIFACTS_ACCESS_KEY_ID"
+	EnvSecretAccessKey = "KELOS_ARTIFACTS_SECRET_ACCESS_KEY"
+	EnvSessionToken    = "KELOS_ARTIFACTS_SESSION_TOKEN"
+)
+
+// KeyPrefix returns the object key prefix under which the artifacts of a
+// Task are stored: "<prefix>/<namespace>/<task>/". The leading prefix is
+// omitted when empty.
+func KeyPrefix(prefix, namespace, task string) string {
+	p := namespace + "/" + task + "/"
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		p = prefix + "/" + p
+	}
+	return p
+}
+
+// Collect returns the slash-separated paths, relative to root, of the
+// regular files matching any of patterns. Symbolic links are skipped, and
+// every match is resolved so that a symlinked directory on its path cannot
+// lead outside root. The result is sorted, deduplicated and capped at
+// MaxFiles; truncated reports whether files were dropped by the cap.
+func Collect(root string, patterns []string) (files []string, truncated bool, err error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, false, fmt.Errorf("resolving %s: %w", root, err)
+	}
+	fsys := os.DirFS(root)
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(path.Clean(pattern), "./")
+		if !doublestar.ValidatePattern(pattern) || !fs.ValidPath(pattern) {
+			return nil, false, fmt.Errorf("invalid artifact path %q", pattern)
+		}
+		matches, err := doublestar.Glob(fsys, pattern, doublestar.WithFilesOnly(), doublestar.WithNoFollow())
+		if err != nil {
+			return nil, false, fmt.Errorf("matching %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+			info, err := fs.Lstat(fsys, m)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if _, err := resolveInRoot(realRoot, root, m); err != nil {
+				continue
+			}
+			seen[m] = true
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+	if len(files) > MaxFiles {
+		return files[:MaxFiles], true, nil
+	}
+	return files, false, nil
+}
+
+// Upload uploads files, given relative to root, to bucket under keyPrefix
+// and returns the object keys of the files that were stored. It keeps
+// going after a failed upload and returns the first error.
+func Upload(ctx context.Context, c *Client, bucket, keyPrefix, root string, files []string) ([]string, error) {
+	var keys []string
+	var firstErr error
+	for _, name := range files {
+		key := keyPrefix + name
+		if err := uploadFile(ctx, c, bucket, key, root, name); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("uploading %s: %w", name, err)
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, firstErr
+}
+
+func uploadFile(ctx context.Context, c *Client, bucket, key, root, name string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveInRoot(realRoot, root, name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return c.PutObject(ctx, bucket, key, f, info.Size())
+}
+
+// resolveInRoot resolves the symbolic links on the path of name, relative
+// to root, and returns the resolved path. It fails when the path leads
+// outside realRoot, the resolved root.
+func resolveInRoot(realRoot, root, name string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(realRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s resolves to %s outside %s", name, resolved, root)
+	}
+	return resolved, nil
+}
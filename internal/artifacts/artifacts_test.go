@@ -0,0 +1,164 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{prefix: "", want: "default/task-1/"},
+		{prefix: "kelos", want: "kelos/default/task-1/"},
+		{prefix: "/runs/kelos/", want: "runs/kelos/default/task-1/"},
+	}
+	for _, tt := range tests {
+		if got := KeyPrefix(tt.prefix, "default", "task-1"); got != tt.want {
+			t.Errorf("KeyPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"report.md",
+		"screenshots/home.png",
+		"screenshots/nested/settings.png",
+		"screenshots/notes.txt",
+		"main.go",
+	)
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "screenshots", "link.png")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(root, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("screenshots", filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "single file",
+			patterns: []string{"report.md"},
+			want:     []string{"report.md"},
+		},
+		{
+			name:     "doublestar",
+			patterns: []string{"screenshots/**/*.png"},
+			want:     []string{"screenshots/home.png", "screenshots/nested/settings.png"},
+		},
+		{
+			name:     "overlapping patterns are deduplicated",
+			patterns: []string{"./report.md", "*.md", "screenshots/*"},
+			want:     []string{"report.md", "screenshots/home.png", "screenshots/notes.txt"},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"*.log"},
+		},
+		{
+			name:     "symlinked directory leading outside the root",
+			patterns: []string{"outside/*.txt", "outside/secret.txt"},
+		},
+		{
+			name:     "symlinked directory inside the root",
+			patterns: []string{"alias/home.png"},
+			want:     []string{"alias/home.png"},
+		},
+		{
+			name:     "escaping the root",
+			patterns: []string{"../*.txt"},
+			wantErr:  true,
+		},
+		{
+			name:     "absolute path",
+			patterns: []string{"/etc/passwd"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated, err := Collect(root, tt.patterns)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Collect() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Collect() error: %v", err)
+			}
+			if truncated {
+				t.Error("Collect() truncated = true, want false")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Collect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectCapsFiles(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < MaxFiles+5; i++ {
+		writeFiles(t, root, fmt.Sprintf("out/%03d.txt", i))
+	}
+	got, truncated, err := Collect(root, []string{"out/*.txt"})
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if len(got) != MaxFiles || !truncated {
+		t.Errorf("Collect() returned %d files, truncated = %v; want %d, true", len(got), truncated, MaxFiles)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	root := t.TempDir()
+	writeFiles(t, root, "report.md", "out/bench.txt")
+
+	keys, err := Upload(context.Background(), newTestClient(srv.URL), "artifacts", "default/task-1/", root,
+		[]string{"out/bench.txt", "missing.txt", "report.md"})
+	if err == nil || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("Upload() error = %v, want an error for missing.txt", err)
+	}
+	want := []string{"default/task-1/out/bench.txt", "default/task-1/report.md"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Upload() keys = %v, want %v", keys, want)
+	}
+	if got := string(fake.objects["/artifacts/default/task-1/report.md"]); got != "report.md" {
+		t.Errorf("stored report.md = %q", got)
+	}
+}
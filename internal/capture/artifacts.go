@@ -0,0 +1,65 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kelos-dev/kelos/internal/artifacts"
+)
+
+const artifactsTimeout = 5 * time.Minute
+
+// uploadArtifacts uploads the workspace files configured through the
+// KELOS_ARTIFACTS_* environment and returns one "artifact: <key>" output
+// line per stored object. Upload problems are reported on stderr and do not
+// fail the run.
+func uploadArtifacts(getenv func(string) string, stderr io.Writer) []string {
+	rawPaths := getenv(artifacts.EnvPaths)
+	if rawPaths == "" {
+		return nil
+	}
+	var patterns []string
+	if err := json.Unmarshal([]byte(rawPaths), &patterns); err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: parsing %s: %v\n", artifacts.EnvPaths, err)
+		return nil
+	}
+
+	root := getenv(artifacts.EnvDir)
+	if root == "" {
+		root = "."
+	}
+	files, truncated, err := artifacts.Collect(root, patterns)
+	if err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: collecting artifacts: %v\n", err)
+		return nil
+	}
+	if truncated {
+		fmt.Fprintf(stderr, "kelos-capture: more than %d artifacts matched, uploading the first %d\n", artifacts.MaxFiles, artifacts.MaxFiles)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	c := &artifacts.Client{
+		Endpoint:        getenv(artifacts.EnvEndpoint),
+		Region:          getenv(artifacts.EnvRegion),
+		AccessKeyID:     getenv(artifacts.EnvAccessKeyID),
+		SecretAccessKey: getenv(artifacts.EnvSecretAccessKey),
+		SessionToken:    getenv(artifacts.EnvSessionToken),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), artifactsTimeout)
+	defer cancel()
+	keys, err := artifacts.Upload(ctx, c, getenv(artifacts.EnvBucket), getenv(artifacts.EnvKeyPrefix), root, files)
+	if err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: %v\n", err)
+	}
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, "artifact: "+key)
+	}
+	return lines
+}
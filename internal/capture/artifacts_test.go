@@ -0,0 +1,101 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kelos-dev/kelos/internal/artifacts"
+)
+
+func TestUploadArtifacts(t *testing.T) {
+	var mu sync.Mutex
+	stored := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		stored[r.URL.Path] = string(data)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"report.md": "# Report", "out/bench.txt": "42 ns/op", "main.go": "package main"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := map[string]string{
+		artifacts.EnvPaths:           `["report.md", "out/**"]`,
+		artifacts.EnvDir:             root,
+		artifacts.EnvEndpoint:        srv.URL,
+		artifacts.EnvBucket:          "kelos",
+		artifacts.EnvKeyPrefix:       "default/task-1/",
+		artifacts.EnvAccessKeyID:     "minio",
+		artifacts.EnvSecretAccessKey: "minio123",
+	}
+	var stderr bytes.Buffer
+	got := uploadArtifacts(func(k string) string { return env[k] }, &stderr)
+
+	want := []string{"artifact: default/task-1/out/bench.txt", "artifact: default/task-1/report.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("uploadArtifacts() = %v, want %v (stderr: %s)", got, want, stderr.String())
+	}
+	if stored["/kelos/default/task-1/report.md"] != "# Report" {
+		t.Errorf("stored objects = %v", stored)
+	}
+	if _, ok := stored["/kelos/default/task-1/main.go"]; ok {
+		t.Error("main.go was uploaded but does not match any pattern")
+	}
+}
+
+func TestUploadArtifactsReportsFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`)
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "report.md"), []byte("# Report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		artifacts.EnvPaths:    `["report.md"]`,
+		artifacts.EnvDir:      root,
+		artifacts.EnvEndpoint: srv.URL,
+		artifacts.EnvBucket:   "kelos",
+	}
+	var stderr bytes.Buffer
+	if got := uploadArtifacts(func(k string) string { return env[k] }, &stderr); len(got) != 0 {
+		t.Errorf("uploadArtifacts() = %v, want no outputs", got)
+	}
+	if !strings.Contains(stderr.String(), "AccessDenied") {
+		t.Errorf("stderr = %q, want the upload error", stderr.String())
+	}
+}
+
+func TestUploadArtifactsDisabled(t *testing.T) {
+	var stderr bytes.Buffer
+	if got := uploadArtifacts(func(string) string { return "" }, &stderr); got != nil {
+		t.Errorf("uploadArtifacts() = %v, want nil", got)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want empty", stderr.String())
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/kelos-dev/kelos/internal/forge"
 )
 
 const (
@@ -19,32 +26,55 @@ const (
 )
 
 // Run streams the agent's JSON output from stdin to stdout, accumulating
-// per-agent token usage in memory, then emits deterministic outputs
-// (branch, commit, PRs, token usage) between markers on stdout. It is
+// per-agent token usage in memory, then emits the results the agent wrote to
+// KELOS_RESULTS_FILE, deterministic outputs (branch, commit, PRs, token
+// usage, and the same git outputs for each additional repository of the
+// workspace) and the object keys of uploaded artifacts between markers on
+// stdout. The same block is written to the durable results channel (see
+// package results) so the controller does not depend on the logs. It is
 // intended to be the right-hand side of a pipe from the agent process so
 // that no on-disk copy of the stream is required. It returns non-zero when
 // the stream cannot be processed or Claude Code reports an incomplete result.
+//
+// SIGTERM and SIGINT are ignored: when a Task is cancelled the whole process
+// group is signalled, and kelos-capture must outlive the agent so it can
+// still report the outputs of the partial run once its input closes.
 func Run() int {
+	signal.Ignore(syscall.SIGTERM, syscall.SIGINT)
 	return run(os.Getenv("KELOS_AGENT_TYPE"), os.Stdin, os.Stdout, os.Stderr, realRunner{})
 }
 
 func run(agentType string, input io.Reader, stdout, stderr io.Writer, commandRunner runner) int {
-	usage, err := StreamUsage(agentType, input, stdout)
+	usage, err := StreamUsage(agentType, input, stdout, os.Getenv("KELOS_REPORT_USAGE") == "true")
 	exitCode := 0
 	if err != nil {
 		fmt.Fprintf(stderr, "kelos-capture: %v\n", err)
 		exitCode = 1
 	}
-	outputs := captureOutputs(commandRunner, usage)
+	// Agent results come first so that the deterministic outputs win when a
+	// key is reported twice.
+	outputs := readResultsFile(os.Getenv("KELOS_RESULTS_FILE"), stderr)
+	outputs = append(outputs, captureOutputs(commandRunner, usage)...)
+	outputs = append(outputs, captureRepositoryOutputs(commandRunner, parseRepositories(os.Getenv(EnvRepositories), stderr))...)
+	outputs = append(outputs, uploadArtifacts(os.Getenv, stderr)...)
 	if len(outputs) == 0 {
 		return exitCode
 	}
-	fmt.Fprintln(stdout, markerStart)
+	block := outputBlock(outputs)
+	fmt.Fprint(stdout, block)
+	writeResults(os.Getenv, block, stderr, inClusterResultsClient)
+	return exitCode
+}
+
+// outputBlock returns the output lines between markers.
+func outputBlock(outputs []string) string {
+	var b strings.Builder
+	b.WriteString(markerStart + "\n")
 	for _, line := range outputs {
-		fmt.Fprintln(stdout, line)
+		b.WriteString(line + "\n")
 	}
-	fmt.Fprintln(stdout, markerEnd)
-	return exitCode
+	b.WriteString(markerEnd + "\n")
+	return b.String()
 }
 
 // runner abstracts command execution for testing.
@@ -104,12 +134,71 @@ func captureOutputs(r runner, usage map[string]string) []string {
 	return outputs
 }
 
+// readResultsFile returns the results the agent wrote to path as a JSON
+// object, as "key: value" lines sorted by key. String values are reported
+// as-is and other values as compact JSON. Entries that do not fit on a single
+// output line are skipped.
+func readResultsFile(path string, stderr io.Writer) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(stderr, "kelos-capture: reading results file: %v\n", err)
+		}
+		return nil
+	}
+	var results map[string]json.RawMessage
+	if err := json.Unmarshal(data, &results); err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: results file %s is not a JSON object: %v\n", path, err)
+		return nil
+	}
+
+	keys := make([]string, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		var value string
+		if json.Unmarshal(results[key], &value) != nil {
+			var compact bytes.Buffer
+			if json.Compact(&compact, results[key]) != nil {
+				continue
+			}
+			value = compact.String()
+		}
+		value = strings.TrimSpace(value)
+		if key == "" || strings.Contains(key, ": ") || strings.ContainsAny(key+value, "\r\n") {
+			fmt.Fprintf(stderr, "kelos-capture: skipping result %q: keys and values must fit on a single line\n", key)
+			continue
+		}
+		lines = append(lines, key+": "+value)
+	}
+	return lines
+}
+
 func isGitRepo(r runner) bool {
 	_, err := r.run("git", "rev-parse", "--is-inside-work-tree")
 	return err == nil
 }
 
+// capturePRs returns the pull requests opened from branch as "pr: <url>"
+// lines. When the workspace is hosted on a forge other than GitHub (see
+// forge.FromEnv), its change requests are listed through the forge API
+// instead of gh.
 func capturePRs(r runner, branch string) []string {
+	f, err := forge.FromEnv(os.Getenv)
+	if err != nil {
+		return nil
+	}
+	if f != nil {
+		return queryChangeRequests(f, branch)
+	}
+
 	// Check origin repo (current behavior)
 	lines := queryPRs(r, branch, "")
 
@@ -121,6 +210,21 @@ func capturePRs(r runner, branch string) []string {
 	return lines
 }
 
+// queryChangeRequests lists the change requests opened from branch on f.
+func queryChangeRequests(f forge.Forge, branch string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	urls, err := f.ChangeRequestURLs(ctx, branch)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, u := range urls {
+		lines = append(lines, "pr: "+u)
+	}
+	return lines
+}
+
 func queryPRs(r runner, branch, repo string) []string {
 	args := []string{"pr", "list", "--head", branch, "--json", "url"}
 	if repo != "" {
@@ -2,10 +2,16 @@ package capture
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/kelos-dev/kelos/internal/forge"
 )
 
 // mockRunner returns predefined outputs for specific commands.
@@ -209,6 +215,24 @@ func TestCapturePRsInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestCapturePRsFromGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("source_branch"); got != "fix-branch" {
+			t.Errorf("source_branch = %q, want fix-branch", got)
+		}
+		json.NewEncoder(w).Encode([]forge.GitLabMergeRequest{{IID: 3, WebURL: "https://gitlab.example.com/group/repo/-/merge_requests/3"}})
+	}))
+	defer server.Close()
+
+	t.Setenv(forge.EnvForge, forge.TypeGitLab)
+	t.Setenv(forge.EnvAPIURL, server.URL)
+	t.Setenv(forge.EnvProject, "group/repo")
+
+	// gh is not mocked, so any PR must come from the GitLab API.
+	prs := capturePRs(mockRunner{}, "fix-branch")
+	assertOutputLines(t, []string{"pr: https://gitlab.example.com/group/repo/-/merge_requests/3"}, prs)
+}
+
 func assertOutputLines(t *testing.T, expected, got []string) {
 	t.Helper()
 	if len(expected) != len(got) {
@@ -326,10 +350,97 @@ func TestRunClaudeCodeResultStatus(t *testing.T) {
 	}
 }
 
+func TestReadResultsFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		want       []string
+		wantStderr string
+	}{
+		{
+			name:    "string and structured values",
+			content: `{"summary": "Fixed the flaky test", "risk": "low", "files": 3, "labels": ["bug", "ci"]}`,
+			want: []string{
+				"files: 3",
+				`labels: ["bug","ci"]`,
+				"risk: low",
+				"summary: Fixed the flaky test",
+			},
+		},
+		{
+			name:       "multi-line value",
+			content:    `{"summary": "line one\nline two", "risk": "low"}`,
+			want:       []string{"risk: low"},
+			wantStderr: "skipping result \"summary\"",
+		},
+		{
+			name:       "not an object",
+			content:    `["low"]`,
+			wantStderr: "is not a JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var stderr bytes.Buffer
+			got := readResultsFile(path, &stderr)
+			assertOutputLines(t, tt.want, got)
+			if tt.wantStderr == "" && stderr.Len() > 0 {
+				t.Errorf("unexpected stderr: %q", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestReadResultsFileMissing(t *testing.T) {
+	var stderr bytes.Buffer
+	if got := readResultsFile(filepath.Join(t.TempDir(), "results.json"), &stderr); got != nil {
+		t.Errorf("readResultsFile() = %v, want nil", got)
+	}
+	if got := readResultsFile("", &stderr); got != nil {
+		t.Errorf("readResultsFile() = %v, want nil", got)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunReportsResultsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, []byte(`{"risk": "low", "cost-usd": "0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KELOS_RESULTS_FILE", path)
+	t.Setenv("KELOS_BASE_BRANCH", "")
+	commandRunner := mockRunner{commands: map[string]mockResult{
+		"git rev-parse --is-inside-work-tree": {err: fmt.Errorf("not a git repo")},
+	}}
+
+	input := `{"type":"result","subtype":"success","is_error":false,"stop_reason":"end_turn","terminal_reason":"completed","total_cost_usd":0.01}` + "\n"
+	var stdout, stderr bytes.Buffer
+	if code := run("claude-code", strings.NewReader(input), &stdout, &stderr, commandRunner); code != 0 {
+		t.Fatalf("run() exit code = %d, stderr = %q", code, stderr.String())
+	}
+	want := markerStart + "\ncost-usd: 0\nrisk: low\ncost-usd: 0.01\n"
+	if !strings.Contains(stdout.String(), want) {
+		t.Fatalf("stdout = %q, want it to contain %q", stdout.String(), want)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Ensure env vars don't leak between tests by clearing them.
 	os.Unsetenv("KELOS_BASE_BRANCH")
 	os.Unsetenv("KELOS_AGENT_TYPE")
 	os.Unsetenv("KELOS_UPSTREAM_REPO")
+	os.Unsetenv("KELOS_RESULTS_FILE")
+	os.Unsetenv("KELOS_ARTIFACTS_PATHS")
+	os.Unsetenv(forge.EnvForge)
 	os.Exit(m.Run())
 }
@@ -0,0 +1,88 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EnvRepositories is the environment variable through which the controller
+// passes the additional repositories of a multi-repository Workspace, as a
+// JSON array of Repository.
+const EnvRepositories = "KELOS_REPOSITORIES"
+
+// Repository describes an additional repository cloned into the workspace.
+type Repository struct {
+	// Name identifies the repository in the output keys.
+	Name string `json:"name"`
+	// Path is the absolute path of the clone.
+	Path string `json:"path"`
+	// BaseBranch is the configured ref of the repository, if any.
+	BaseBranch string `json:"baseBranch,omitempty"`
+	// GitHubRepo is the [HOST/]OWNER/REPO the repository's pull requests are
+	// looked up in, if it is hosted on GitHub.
+	GitHubRepo string `json:"githubRepo,omitempty"`
+}
+
+// RepositoryOutputKey returns the output key under which key is reported for
+// the named additional repository, for example "repository/proto/branch".
+func RepositoryOutputKey(name, key string) string {
+	return "repository/" + name + "/" + key
+}
+
+// parseRepositories decodes the value of EnvRepositories. An invalid value is
+// reported on stderr and ignored.
+func parseRepositories(value string, stderr io.Writer) []Repository {
+	if value == "" {
+		return nil
+	}
+	var repos []Repository
+	if err := json.Unmarshal([]byte(value), &repos); err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: ignoring invalid %s: %v\n", EnvRepositories, err)
+		return nil
+	}
+	return repos
+}
+
+// captureRepositoryOutputs returns the branch, pull requests, commit and base
+// branch of each additional repository, keyed by RepositoryOutputKey.
+// Repositories that are not git work trees are skipped.
+func captureRepositoryOutputs(r runner, repos []Repository) []string {
+	var outputs []string
+	for _, repo := range repos {
+		if _, err := r.run("git", "-C", repo.Path, "rev-parse", "--is-inside-work-tree"); err != nil {
+			continue
+		}
+		line := func(key, value string) string {
+			return RepositoryOutputKey(repo.Name, key) + ": " + value
+		}
+
+		branch, err := r.run("git", "-C", repo.Path, "branch", "--show-current")
+		if err == nil && branch != "" {
+			outputs = append(outputs, line("branch", branch))
+			if repo.GitHubRepo != "" {
+				for _, pr := range queryPRs(r, branch, repo.GitHubRepo) {
+					outputs = append(outputs, line("pr", strings.TrimPrefix(pr, "pr: ")))
+				}
+			}
+		}
+
+		commit, err := r.run("git", "-C", repo.Path, "rev-parse", "HEAD")
+		if err == nil && commit != "" {
+			outputs = append(outputs, line("commit", commit))
+		}
+
+		base := repo.BaseBranch
+		if base == "" {
+			ref, err := r.run("git", "-C", repo.Path, "symbolic-ref", "refs/remotes/origin/HEAD")
+			if err == nil {
+				base = strings.TrimPrefix(ref, "refs/remotes/origin/")
+			}
+		}
+		if base != "" {
+			outputs = append(outputs, line("base-branch", base))
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,54 @@
+package capture
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCaptureRepositoryOutputs(t *testing.T) {
+	r := mockRunner{commands: map[string]mockResult{
+		"git -C /workspace/proto rev-parse --is-inside-work-tree": {output: "true"},
+		"git -C /workspace/proto branch --show-current":           {output: "kelos/fix"},
+		"gh pr list --head kelos/fix --json url --repo org/proto": {
+			output: `[{"url":"https://github.com/org/proto/pull/7"}]`,
+		},
+		"git -C /workspace/proto rev-parse HEAD":                         {output: "abc123"},
+		"git -C /workspace/deploy rev-parse --is-inside-work-tree":       {output: "true"},
+		"git -C /workspace/deploy branch --show-current":                 {output: "kelos/fix"},
+		"git -C /workspace/deploy rev-parse HEAD":                        {output: "def456"},
+		"git -C /workspace/deploy symbolic-ref refs/remotes/origin/HEAD": {output: "refs/remotes/origin/main"},
+	}}
+
+	outputs := captureRepositoryOutputs(r, []Repository{
+		{Name: "proto", Path: "/workspace/proto", BaseBranch: "develop", GitHubRepo: "org/proto"},
+		{Name: "deploy", Path: "/workspace/deploy"},
+		{Name: "missing", Path: "/workspace/missing"},
+	})
+
+	expected := []string{
+		"repository/proto/branch: kelos/fix",
+		"repository/proto/pr: https://github.com/org/proto/pull/7",
+		"repository/proto/commit: abc123",
+		"repository/proto/base-branch: develop",
+		"repository/deploy/branch: kelos/fix",
+		"repository/deploy/commit: def456",
+		"repository/deploy/base-branch: main",
+	}
+	assertOutputLines(t, expected, outputs)
+}
+
+func TestParseRepositories(t *testing.T) {
+	var stderr bytes.Buffer
+	repos := parseRepositories(`[{"name":"proto","path":"/workspace/proto","githubRepo":"org/proto"}]`, &stderr)
+	if len(repos) != 1 || repos[0].Name != "proto" || repos[0].GitHubRepo != "org/proto" {
+		t.Errorf("repos = %+v, want the proto repository", repos)
+	}
+
+	if repos := parseRepositories("not json", &stderr); repos != nil {
+		t.Errorf("repos = %+v, want nil for an invalid value", repos)
+	}
+	if !strings.Contains(stderr.String(), EnvRepositories) {
+		t.Errorf("stderr = %q, want a warning about %s", stderr.String(), EnvRepositories)
+	}
+}
@@ -0,0 +1,93 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/kelos-dev/kelos/internal/results"
+)
+
+const (
+	resultsTimeout = 30 * time.Second
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// resultsClientFunc returns a Kubernetes client and the namespace of the
+// pod for writing the results ConfigMap.
+type resultsClientFunc func() (kubernetes.Interface, string, error)
+
+func inClusterResultsClient() (kubernetes.Interface, string, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("building in-cluster config: %w", err)
+	}
+	c, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating kubernetes client: %w", err)
+	}
+	namespace := os.Getenv("KELOS_POD_NAMESPACE")
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	return c, namespace, nil
+}
+
+// writeResults hands the output block to the controller through the
+// durable channel configured by the KELOS_TERMINATION_MESSAGE_PATH and
+// KELOS_RESULTS_CONFIGMAP environment: the termination message when the
+// block fits, the results ConfigMap otherwise. Problems are reported on
+// stderr and do not fail the run, since the controller falls back to the
+// block on stdout.
+func writeResults(getenv func(string) string, block string, stderr io.Writer, newClient resultsClientFunc) {
+	if path := getenv(results.EnvTerminationMessagePath); path != "" && len(block) <= results.MaxTerminationMessageBytes {
+		err := os.WriteFile(path, []byte(block), 0o644)
+		if err == nil {
+			return
+		}
+		fmt.Fprintf(stderr, "kelos-capture: writing termination message: %v\n", err)
+	}
+
+	name := getenv(results.EnvConfigMap)
+	if name == "" {
+		return
+	}
+	c, namespace, err := newClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: writing results ConfigMap: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resultsTimeout)
+	defer cancel()
+	// The agent may only get and update its own ConfigMap, which the
+	// controller creates before the agent starts.
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := c.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[results.ConfigMapKey] = block
+		_, err = c.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "kelos-capture: writing results ConfigMap %s: %v\n", name, err)
+	}
+}
@@ -0,0 +1,152 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kelos-dev/kelos/internal/results"
+)
+
+func newResultsTestClient(objs ...runtime.Object) (*fake.Clientset, resultsClientFunc) {
+	c := fake.NewSimpleClientset(objs...)
+	return c, func() (kubernetes.Interface, string, error) { return c, "default", nil }
+}
+
+func newResultsConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: results.ConfigMapName("uid-1"), Namespace: "default"},
+	}
+}
+
+func resultsEnv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestWriteResultsTerminationMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	c, newClient := newResultsTestClient(newResultsConfigMap())
+	block := outputBlock([]string{"branch: feature-1", "commit: abc123"})
+
+	var stderr bytes.Buffer
+	writeResults(resultsEnv(map[string]string{
+		results.EnvTerminationMessagePath: path,
+		results.EnvConfigMap:              results.ConfigMapName("uid-1"),
+	}), block, &stderr, newClient)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading termination message: %v", err)
+	}
+	if string(data) != block {
+		t.Errorf("Termination message = %q, want %q", data, block)
+	}
+
+	cm, err := c.CoreV1().ConfigMaps("default").Get(context.Background(), results.ConfigMapName("uid-1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting ConfigMap: %v", err)
+	}
+	if len(cm.Data) != 0 {
+		t.Errorf("Expected the ConfigMap to be left empty, got %v", cm.Data)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Unexpected stderr: %s", stderr.String())
+	}
+}
+
+func TestWriteResultsLargeBlockToConfigMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	c, newClient := newResultsTestClient(newResultsConfigMap())
+	block := outputBlock([]string{"response: " + strings.Repeat("x", results.MaxTerminationMessageBytes)})
+
+	var stderr bytes.Buffer
+	writeResults(resultsEnv(map[string]string{
+		results.EnvTerminationMessagePath: path,
+		results.EnvConfigMap:              results.ConfigMapName("uid-1"),
+	}), block, &stderr, newClient)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no termination message for a large block, got err=%v", err)
+	}
+	cm, err := c.CoreV1().ConfigMaps("default").Get(context.Background(), results.ConfigMapName("uid-1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting ConfigMap: %v", err)
+	}
+	if cm.Data[results.ConfigMapKey] != block {
+		t.Errorf("ConfigMap outputs = %q, want the output block", cm.Data[results.ConfigMapKey])
+	}
+}
+
+func TestWriteResultsWorkerUsesConfigMap(t *testing.T) {
+	c, newClient := newResultsTestClient(newResultsConfigMap())
+	block := outputBlock([]string{"branch: feature-1"})
+
+	var stderr bytes.Buffer
+	writeResults(resultsEnv(map[string]string{
+		results.EnvConfigMap: results.ConfigMapName("uid-1"),
+	}), block, &stderr, newClient)
+
+	cm, err := c.CoreV1().ConfigMaps("default").Get(context.Background(), results.ConfigMapName("uid-1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting ConfigMap: %v", err)
+	}
+	if cm.Data[results.ConfigMapKey] != block {
+		t.Errorf("ConfigMap outputs = %q, want the output block", cm.Data[results.ConfigMapKey])
+	}
+}
+
+func TestWriteResultsReportsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		newClient resultsClientFunc
+		want      string
+	}{
+		{
+			name: "missing ConfigMap",
+			newClient: func() (kubernetes.Interface, string, error) {
+				return fake.NewSimpleClientset(), "default", nil
+			},
+			want: "writing results ConfigMap kelos-results-uid-1",
+		},
+		{
+			name: "no client",
+			newClient: func() (kubernetes.Interface, string, error) {
+				return nil, "", errors.New("not in a cluster")
+			},
+			want: "not in a cluster",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			writeResults(resultsEnv(map[string]string{
+				results.EnvConfigMap: results.ConfigMapName("uid-1"),
+			}), outputBlock([]string{"branch: feature-1"}), &stderr, tt.newClient)
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteResultsDisabled(t *testing.T) {
+	var stderr bytes.Buffer
+	writeResults(resultsEnv(nil), outputBlock([]string{"branch: feature-1"}), &stderr, func() (kubernetes.Interface, string, error) {
+		t.Fatal("Expected no client to be created without a results channel")
+		return nil, "", nil
+	})
+	if stderr.Len() != 0 {
+		t.Errorf("Unexpected stderr: %s", stderr.String())
+	}
+}
@@ -7,11 +7,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"strings"
 
 	"github.com/kelos-dev/kelos/internal/claudecode"
 )
 
+// UsageReportMarker prefixes the running usage lines that StreamUsage
+// writes when usage reporting is enabled. The rest of the line is a JSON
+// object with the same cost-usd, input-tokens and output-tokens keys as the
+// final outputs.
+const UsageReportMarker = "---KELOS_USAGE---"
+
+// usageReportInterval is the number of forwarded lines after which an
+// unchanged running usage is reported again, so that a bounded tail of the
+// log always contains the latest report.
+const usageReportInterval = 100
+
 // usageAccumulator consumes the agent's JSON-lines output one line at a
 // time and emits a final token usage map. Per-agent implementations keep
 // only the state they need (last "result" line or running counters), so
@@ -19,6 +31,9 @@ import (
 type usageAccumulator interface {
 	addLine(line []byte)
 	result() map[string]string
+	// running returns the usage accumulated so far while the agent is
+	// still producing output, or nil if none is known yet.
+	running() map[string]string
 }
 
 // newUsageAccumulator returns the accumulator for the given agent type, or
@@ -26,13 +41,13 @@ type usageAccumulator interface {
 func newUsageAccumulator(agentType string) usageAccumulator {
 	switch agentType {
 	case "claude-code":
-		return &lastResultAccumulator{extract: extractClaudeCode}
+		return &lastResultAccumulator{extract: extractClaudeCode, extractMessage: extractClaudeCodeMessageUsage}
 	case "codex":
 		return &sumAccumulator{event: "turn.completed", extract: extractCodexUsage, extractResponse: extractCodexResponse}
 	case "gemini":
 		return &lastResultAccumulator{extract: extractGemini, extractResponse: extractGeminiResponse}
 	case "opencode":
-		return &sumAccumulator{event: "step_finish", extract: extractOpencodeUsage, extractResponse: extractOpencodeResponse}
+		return &sumAccumulator{event: "step_finish", extract: extractOpencodeUsage, extractCost: extractOpencodeCost, extractResponse: extractOpencodeResponse}
 	case "cursor":
 		return &lastResultAccumulator{extract: extractCursor}
 	default:
@@ -51,8 +66,15 @@ func newUsageAccumulator(agentType string) usageAccumulator {
 // scanner buffer between us and r, so an arbitrarily long line is still
 // forwarded faithfully (memory cost is bounded by the longest line, which
 // the agent producer is already holding).
-func StreamUsage(agentType string, r io.Reader, w io.Writer) (usage map[string]string, err error) {
+//
+// When report is true, the running usage is also written to w on a line
+// starting with UsageReportMarker whenever it changes, and again every
+// usageReportInterval lines, so that a controller tailing the log can
+// observe the spend of a Task while its agent is still running.
+func StreamUsage(agentType string, r io.Reader, w io.Writer, report bool) (usage map[string]string, err error) {
 	acc := newUsageAccumulator(agentType)
+	var lastReport string
+	sinceReport := 0
 	bw := bufio.NewWriter(w)
 	defer func() {
 		if ferr := bw.Flush(); err == nil {
@@ -83,6 +105,12 @@ func StreamUsage(agentType string, r io.Reader, w io.Writer) (usage map[string]s
 				if len(body) > 0 {
 					acc.addLine(body)
 				}
+				sinceReport++
+				if report {
+					if werr := reportUsage(bw, acc.running(), &lastReport, &sinceReport); werr != nil {
+						return nil, werr
+					}
+				}
 			}
 		}
 		if readErr != nil {
@@ -108,14 +136,46 @@ func StreamUsage(agentType string, r io.Reader, w io.Writer) (usage map[string]s
 	return usage, nil
 }
 
+// reportUsage writes a usage report line to bw and flushes it when usage
+// differs from the last report or usageReportInterval lines have been
+// forwarded since.
+func reportUsage(bw *bufio.Writer, usage map[string]string, lastReport *string, sinceReport *int) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	if string(data) == *lastReport && *sinceReport < usageReportInterval {
+		return nil
+	}
+	*lastReport = string(data)
+	*sinceReport = 0
+	if _, err := fmt.Fprintf(bw, "%s %s\n", UsageReportMarker, data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
 // lastResultAccumulator keeps only the most recent line whose "type" is
 // "result" and runs extract on it at EOF. Used by claude-code, gemini, and
-// cursor whose totals live in the final result line.
+// cursor whose totals live in the final result line. When extractMessage
+// is set, per-message token counts are summed as well so that the usage of
+// a run is known before, or without, its final result line.
 type lastResultAccumulator struct {
 	last            map[string]any
 	extract         func(map[string]any) map[string]string
 	extractResponse func(map[string]any) string
 	response        string
+
+	extractMessage func(map[string]any) (id string, input, output int64, ok bool)
+	// in and out sum the token counts of all messages before lastID, whose
+	// counts are kept separately because a message is repeated once per
+	// content block.
+	in, out         int64
+	lastID          string
+	lastIn, lastOut int64
 }
 
 func (a *lastResultAccumulator) addLine(line []byte) {
@@ -126,6 +186,15 @@ func (a *lastResultAccumulator) addLine(line []byte) {
 	if m["type"] == "result" {
 		a.last = m
 	}
+	if a.extractMessage != nil {
+		if id, i, o, ok := a.extractMessage(m); ok {
+			if id == "" || id != a.lastID {
+				a.in += a.lastIn
+				a.out += a.lastOut
+			}
+			a.lastID, a.lastIn, a.lastOut = id, i, o
+		}
+	}
 	if a.extractResponse != nil {
 		if r := a.extractResponse(m); r != "" {
 			a.response = r
@@ -134,12 +203,17 @@ func (a *lastResultAccumulator) addLine(line []byte) {
 }
 
 func (a *lastResultAccumulator) result() map[string]string {
-	if a.last == nil && a.response == "" {
+	// Without a result line, e.g. when the agent was stopped, fall back
+	// to the token counts of the messages streamed so far.
+	partial := a.last == nil && a.extractMessage != nil
+	if a.last == nil && a.response == "" && !partial {
 		return nil
 	}
 	var r map[string]string
 	if a.last != nil {
 		r = a.extract(a.last)
+	} else if partial {
+		r = tokenResult(a.in+a.lastIn, a.out+a.lastOut)
 	}
 	if a.response != "" {
 		if r == nil {
@@ -152,14 +226,28 @@ func (a *lastResultAccumulator) result() map[string]string {
 	return r
 }
 
-// sumAccumulator adds per-event input/output token counts as the stream is
-// read. Used by codex and opencode whose totals are spread across many
-// per-turn or per-step events.
+func (a *lastResultAccumulator) running() map[string]string {
+	if a.last != nil {
+		r := a.extract(a.last)
+		delete(r, "response")
+		return r
+	}
+	if a.extractMessage == nil {
+		return nil
+	}
+	return tokenResult(a.in+a.lastIn, a.out+a.lastOut)
+}
+
+// sumAccumulator adds per-event input/output token counts, and the cost
+// when extractCost is set, as the stream is read. Used by codex and
+// opencode whose totals are spread across many per-turn or per-step events.
 type sumAccumulator struct {
 	event           string
 	extract         func(map[string]any) (int64, int64)
+	extractCost     func(map[string]any) *big.Rat
 	extractResponse func(map[string]any) string
 	in, out         int64
+	cost            *big.Rat
 	response        string
 }
 
@@ -172,6 +260,14 @@ func (a *sumAccumulator) addLine(line []byte) {
 		i, o := a.extract(m)
 		a.in += i
 		a.out += o
+		if a.extractCost != nil {
+			if c := a.extractCost(m); c != nil {
+				if a.cost == nil {
+					a.cost = new(big.Rat)
+				}
+				a.cost.Add(a.cost, c)
+			}
+		}
 	}
 	if a.extractResponse != nil {
 		if r := a.extractResponse(m); r != "" {
@@ -181,7 +277,7 @@ func (a *sumAccumulator) addLine(line []byte) {
 }
 
 func (a *sumAccumulator) result() map[string]string {
-	r := tokenResult(a.in, a.out)
+	r := a.running()
 	if a.response != "" {
 		if r == nil {
 			r = make(map[string]string)
@@ -191,6 +287,17 @@ func (a *sumAccumulator) result() map[string]string {
 	return r
 }
 
+func (a *sumAccumulator) running() map[string]string {
+	r := tokenResult(a.in, a.out)
+	if a.cost != nil {
+		if r == nil {
+			r = make(map[string]string)
+		}
+		r["cost-usd"] = formatCost(a.cost)
+	}
+	return r
+}
+
 // extractClaudeCode reads cost, token counts, and the agent response from a claude-code
 // {"type":"result","total_cost_usd":N,"usage":{"input_tokens":N,"output_tokens":N},"result":"..."} line.
 func extractClaudeCode(m map[string]any) map[string]string {
@@ -215,6 +322,26 @@ func extractClaudeCode(m map[string]any) map[string]string {
 	return result
 }
 
+// extractClaudeCodeMessageUsage reads the message ID and token counts from a
+// claude-code {"type":"assistant","message":{"id":"...","usage":{"input_tokens":N,"output_tokens":N}}}
+// event. Claude Code repeats the event for every content block of a
+// message, so callers must count each message ID once.
+func extractClaudeCodeMessageUsage(m map[string]any) (string, int64, int64, bool) {
+	if m["type"] != "assistant" {
+		return "", 0, 0, false
+	}
+	message, ok := m["message"].(map[string]any)
+	if !ok {
+		return "", 0, 0, false
+	}
+	usage, ok := message["usage"].(map[string]any)
+	if !ok {
+		return "", 0, 0, false
+	}
+	id, _ := message["id"].(string)
+	return id, toInt64(usage["input_tokens"]), toInt64(usage["output_tokens"]), true
+}
+
 func extractClaudeCodeCompletion(m map[string]any) claudecode.Result {
 	subtype, _ := m["subtype"].(string)
 	isError, _ := m["is_error"].(bool)
@@ -294,6 +421,24 @@ func extractOpencodeUsage(m map[string]any) (int64, int64) {
 	return toInt64(tokens["input"]), toInt64(tokens["output"])
 }
 
+// extractOpencodeCost pulls the cost in USD from an opencode
+// {"type":"step_finish","part":{"cost":N}} event, or nil if it is missing.
+func extractOpencodeCost(m map[string]any) *big.Rat {
+	part, ok := m["part"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	n, ok := part["cost"].(json.Number)
+	if !ok {
+		return nil
+	}
+	c, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return nil
+	}
+	return c
+}
+
 // extractGeminiResponse returns the assistant message content from a gemini
 // {"type":"message","role":"assistant","delta":false,"content":"..."} event.
 // Returns "" for non-matching events so the caller keeps the last non-empty value.
@@ -354,6 +499,14 @@ func tokenResult(input, output int64) map[string]string {
 	return result
 }
 
+// formatCost formats a summed cost in USD as a decimal string, rounded to
+// 1e-9 and without trailing zeros.
+func formatCost(c *big.Rat) string {
+	s := c.FloatString(9)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
 // parseLine unmarshals a JSON line using json.Number to preserve number format.
 func parseLine(line []byte) map[string]any {
 	d := json.NewDecoder(strings.NewReader(string(line)))
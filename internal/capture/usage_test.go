@@ -140,6 +140,18 @@ func TestStreamUsage(t *testing.T) {
 				"response":      "Rml4ZWQgdGhlIGJ1Zy4=", // base64("Fixed the bug.")
 			},
 		},
+		{
+			name:      "opencode sums step costs",
+			agentType: "opencode",
+			content: `{"type":"step_finish","part":{"cost":0.1,"tokens":{"input":500,"output":200}}}
+{"type":"step_finish","part":{"cost":0.2,"tokens":{"input":300,"output":100}}}
+`,
+			want: map[string]string{
+				"cost-usd":      "0.3",
+				"input-tokens":  "800",
+				"output-tokens": "300",
+			},
+		},
 		{
 			name:      "cursor result with camelCase usage",
 			agentType: "cursor",
@@ -188,12 +200,25 @@ func TestStreamUsage(t *testing.T) {
 			content:   `{"type":"assistant","message":"done"}` + "\n",
 			want:      nil,
 		},
+		{
+			name:      "claude-code without result sums message usage",
+			agentType: "claude-code",
+			content: `{"type":"assistant","message":{"id":"msg_1","usage":{"input_tokens":100,"output_tokens":20}}}
+{"type":"assistant","message":{"id":"msg_1","usage":{"input_tokens":100,"output_tokens":20}}}
+{"type":"user","message":{"content":"tool result"}}
+{"type":"assistant","message":{"id":"msg_2","usage":{"input_tokens":300,"output_tokens":50}}}
+`,
+			want: map[string]string{
+				"input-tokens":  "400",
+				"output-tokens": "70",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var out bytes.Buffer
-			got, err := StreamUsage(tt.agentType, strings.NewReader(tt.content), &out)
+			got, err := StreamUsage(tt.agentType, strings.NewReader(tt.content), &out, false)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -207,7 +232,7 @@ func TestStreamUsage(t *testing.T) {
 func TestStreamUsageRejectsIncompleteClaudeCodeResult(t *testing.T) {
 	in := `{"type":"result","subtype":"success","is_error":false,"stop_reason":"tool_use","result":"Starting the next tool","total_cost_usd":0.01,"usage":{"input_tokens":1,"output_tokens":2}}` + "\n"
 	var out bytes.Buffer
-	usage, err := StreamUsage("claude-code", strings.NewReader(in), &out)
+	usage, err := StreamUsage("claude-code", strings.NewReader(in), &out, false)
 	if err == nil || err.Error() != "Claude Code run incomplete (stop_reason=tool_use)" {
 		t.Fatalf("StreamUsage() error = %v, want incomplete tool use", err)
 	}
@@ -219,13 +244,56 @@ func TestStreamUsageRejectsIncompleteClaudeCodeResult(t *testing.T) {
 	}
 }
 
+func TestStreamUsageReportsRunningUsage(t *testing.T) {
+	in := `{"type":"turn.completed","usage":{"input_tokens":100,"output_tokens":50}}
+{"type":"item.completed","item":{"type":"agent_message","text":"Working on it"}}
+{"type":"turn.completed","usage":{"input_tokens":200,"output_tokens":150}}
+`
+	var out bytes.Buffer
+	usage, err := StreamUsage("codex", strings.NewReader(in), &out, true)
+	if err != nil {
+		t.Fatalf("StreamUsage() error = %v", err)
+	}
+	if usage["input-tokens"] != "300" {
+		t.Errorf("input-tokens = %q, want %q", usage["input-tokens"], "300")
+	}
+
+	lines := strings.Split(in, "\n")
+	want := strings.Join([]string{
+		lines[0],
+		UsageReportMarker + ` {"input-tokens":"100","output-tokens":"50"}`,
+		lines[1],
+		lines[2],
+		UsageReportMarker + ` {"input-tokens":"300","output-tokens":"200"}`,
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("forwarded output mismatch:\n  want: %q\n  got:  %q", want, out.String())
+	}
+}
+
+func TestStreamUsageRepeatsUnchangedReport(t *testing.T) {
+	var in strings.Builder
+	in.WriteString(`{"type":"step_finish","part":{"tokens":{"input":10,"output":5}}}` + "\n")
+	for range usageReportInterval {
+		in.WriteString(`{"type":"text","text":"..."}` + "\n")
+	}
+	var out bytes.Buffer
+	if _, err := StreamUsage("opencode", strings.NewReader(in.String()), &out, true); err != nil {
+		t.Fatalf("StreamUsage() error = %v", err)
+	}
+	if got := strings.Count(out.String(), UsageReportMarker); got != 2 {
+		t.Errorf("Expected the unchanged usage to be reported twice, got %d reports", got)
+	}
+}
+
 // TestStreamUsageForwardsUnterminatedLine verifies that an input that does
 // not end with a newline still has its final line forwarded (terminated
 // with a newline, matching what `tee` produced previously).
 func TestStreamUsageForwardsUnterminatedLine(t *testing.T) {
 	in := `{"type":"result","subtype":"success","is_error":false,"total_cost_usd":0.01,"usage":{"input_tokens":1,"output_tokens":2}}`
 	var out bytes.Buffer
-	got, err := StreamUsage("claude-code", strings.NewReader(in), &out)
+	got, err := StreamUsage("claude-code", strings.NewReader(in), &out, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -249,7 +317,7 @@ func TestStreamUsageForwardsLargeLineByteForByte(t *testing.T) {
 	tail := `{"type":"result","subtype":"success","is_error":false,"total_cost_usd":0.01,"usage":{"input_tokens":1,"output_tokens":2}}`
 	in := huge + "\n" + tail + "\n"
 	var out bytes.Buffer
-	got, err := StreamUsage("claude-code", strings.NewReader(in), &out)
+	got, err := StreamUsage("claude-code", strings.NewReader(in), &out, false)
 	if err != nil {
 		t.Fatalf("unexpected error on large line: %v", err)
 	}
@@ -266,7 +334,7 @@ func TestStreamUsageForwardsLargeLineByteForByte(t *testing.T) {
 // silently swallowed.
 func TestStreamUsagePropagatesFlushError(t *testing.T) {
 	in := `{"type":"result","subtype":"success","is_error":false,"total_cost_usd":0.01,"usage":{"input_tokens":1,"output_tokens":2}}` + "\n"
-	_, err := StreamUsage("claude-code", strings.NewReader(in), errWriter{})
+	_, err := StreamUsage("claude-code", strings.NewReader(in), errWriter{}, false)
 	if err == nil {
 		t.Fatalf("expected flush error, got nil")
 	}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newApproveCommand(cfg *ClientConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve",
+		Short: "Approve resources waiting for approval",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Help()
+			return fmt.Errorf("must specify a resource type")
+		},
+	}
+
+	cmd.AddCommand(newApproveTaskCommand(cfg))
+
+	return cmd
+}
+
+func newApproveTaskCommand(cfg *ClientConfig) *cobra.Command {
+	var approver string
+
+	cmd := &cobra.Command{
+		Use:     "task [name]",
+		Aliases: []string{"tasks"},
+		Short:   "Approve a task that is waiting for approval",
+		Long: `Approve a task that is waiting for approval.
+
+A task with a BeforeRun approval policy starts its agent once approved. A task
+with a BeforePush approval policy is allowed to push its changes. The approver
+is recorded in the task's Approved condition.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("task name is required\nUsage: %s", cmd.Use)
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("too many arguments: expected 1 task name, got %d\nUsage: %s", len(args), cmd.Use)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, ns, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
+
+			if approver == "" {
+				approver = defaultApprover()
+			}
+
+			ctx := context.Background()
+			key := client.ObjectKey{Name: args[0], Namespace: ns}
+
+			message, err := approveTask(ctx, cl, key, approver)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, message)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&approver, "approver", "", "name recorded as the approver (default: the local user name)")
+
+	cmd.ValidArgsFunction = completeTaskNames(cfg)
+
+	return cmd
+}
+
+// defaultApprover returns the local user name, or an empty string when it
+// cannot be determined.
+func defaultApprover() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// approveTask sets the approve annotation on a Task and returns a message
+// describing the outcome. Tasks that are already approved are left
+// unchanged.
+func approveTask(ctx context.Context, cl client.Client, key client.ObjectKey, approver string) (string, error) {
+	task := &kelos.Task{}
+	if err := cl.Get(ctx, key, task); err != nil {
+		return "", fmt.Errorf("getting task: %w", err)
+	}
+
+	if task.Spec.Approval == nil {
+		return "", fmt.Errorf("task/%s does not require approval", key.Name)
+	}
+	if task.Status.Phase == kelos.TaskPhaseSucceeded || task.Status.Phase == kelos.TaskPhaseFailed {
+		return fmt.Sprintf("task/%s has already finished (%s)", key.Name, task.Status.Phase), nil
+	}
+	if _, ok := task.Annotations[kelos.AnnotationTaskApprove]; ok {
+		return fmt.Sprintf("task/%s is already approved", key.Name), nil
+	}
+
+	base := task.DeepCopy()
+	if task.Annotations == nil {
+		task.Annotations = make(map[string]string)
+	}
+	task.Annotations[kelos.AnnotationTaskApprove] = approver
+	if err := cl.Patch(ctx, task, client.MergeFrom(base)); err != nil {
+		return "", fmt.Errorf("approving task: %w", err)
+	}
+
+	return fmt.Sprintf("task/%s approved", key.Name), nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestApproveCommand_MissingName(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"approve", "task"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when name is missing")
+	}
+	if !strings.Contains(err.Error(), "task name is required") {
+		t.Errorf("Expected 'task name is required' error, got: %v", err)
+	}
+}
+
+func TestApproveCommand_NoResourceType(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"approve"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when no resource type specified")
+	}
+	if !strings.Contains(err.Error(), "must specify a resource type") {
+		t.Errorf("Expected 'must specify a resource type' error, got: %v", err)
+	}
+}
+
+func TestApproveTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		phase        kelos.TaskPhase
+		approval     *kelos.ApprovalPolicy
+		annotations  map[string]string
+		wantMessage  string
+		wantErr      string
+		wantApprover string
+	}{
+		{
+			name:         "task awaiting approval",
+			phase:        kelos.TaskPhaseAwaitingApproval,
+			approval:     &kelos.ApprovalPolicy{},
+			wantMessage:  "task/my-task approved",
+			wantApprover: "alice",
+		},
+		{
+			name:         "already approved",
+			phase:        kelos.TaskPhaseRunning,
+			approval:     &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforePush},
+			annotations:  map[string]string{kelos.AnnotationTaskApprove: "slack:U123"},
+			wantMessage:  "task/my-task is already approved",
+			wantApprover: "slack:U123",
+		},
+		{
+			name:        "finished task",
+			phase:       kelos.TaskPhaseFailed,
+			approval:    &kelos.ApprovalPolicy{},
+			wantMessage: "task/my-task has already finished (Failed)",
+		},
+		{
+			name:    "task without approval policy",
+			phase:   kelos.TaskPhaseRunning,
+			wantErr: "does not require approval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-task",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				Spec: kelos.TaskSpec{
+					Type:     "claude-code",
+					Prompt:   "Fix the bug",
+					Approval: tt.approval,
+				},
+				Status: kelos.TaskStatus{Phase: tt.phase},
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+			ctx := context.Background()
+			key := client.ObjectKey{Name: "my-task", Namespace: "default"}
+
+			message, err := approveTask(ctx, cl, key, "alice")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("approveTask() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("approveTask: %v", err)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", message, tt.wantMessage)
+			}
+
+			updated := &kelos.Task{}
+			if err := cl.Get(ctx, key, updated); err != nil {
+				t.Fatalf("Get after approve: %v", err)
+			}
+			if got := updated.Annotations[kelos.AnnotationTaskApprove]; got != tt.wantApprover {
+				t.Errorf("Approve annotation = %q, want %q", got, tt.wantApprover)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/artifacts"
+)
+
+func newArtifactsCommand(cfg *ClientConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "Work with files collected from task workspaces",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Help()
+			return fmt.Errorf("must specify a subcommand")
+		},
+	}
+
+	cmd.AddCommand(newArtifactsGetCommand(cfg))
+
+	return cmd
+}
+
+func newArtifactsGetCommand(cfg *ClientConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Download artifacts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Help()
+			return fmt.Errorf("must specify a resource type")
+		},
+	}
+
+	cmd.AddCommand(newArtifactsGetTaskCommand(cfg))
+
+	return cmd
+}
+
+func newArtifactsGetTaskCommand(cfg *ClientConfig) *cobra.Command {
+	var outputDir string
+	var endpoint string
+
+	cmd := &cobra.Command{
+		Use:     "task [name]",
+		Aliases: []string{"tasks"},
+		Short:   "Download the artifacts uploaded by a task",
+		Long: `Download the artifacts uploaded by a task.
+
+The files listed in the task's status.artifacts are downloaded from the
+object store configured in spec.artifacts, using the credentials from the
+storage Secret. Files keep their path relative to the repository root.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("task name is required\nUsage: %s", cmd.Use)
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("too many arguments: expected 1 task name, got %d\nUsage: %s", len(args), cmd.Use)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, ns, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
+
+			dir := outputDir
+			if dir == "" {
+				dir = args[0]
+			}
+			key := client.ObjectKey{Name: args[0], Namespace: ns}
+			return downloadTaskArtifacts(context.Background(), cl, key, dir, endpoint, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "directory to write the artifacts to (default: the task name)")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "object store URL to use instead of the one in the task spec, e.g. a port-forwarded in-cluster endpoint")
+
+	cmd.ValidArgsFunction = completeTaskNames(cfg)
+
+	return cmd
+}
+
+// downloadTaskArtifacts downloads the artifacts recorded in the status of
+// the Task into dir and reports each written file on out.
+func downloadTaskArtifacts(ctx context.Context, cl client.Client, key client.ObjectKey, dir, endpoint string, out io.Writer) error {
+	task := &kelos.Task{}
+	if err := cl.Get(ctx, key, task); err != nil {
+		return fmt.Errorf("getting task: %w", err)
+	}
+	if task.Spec.Artifacts == nil || task.Spec.Artifacts.Storage.S3 == nil {
+		return fmt.Errorf("task/%s does not configure artifacts", key.Name)
+	}
+	if len(task.Status.Artifacts) == 0 {
+		return fmt.Errorf("task/%s has no artifacts", key.Name)
+	}
+
+	s3 := task.Spec.Artifacts.Storage.S3
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: s3.SecretRef.Name, Namespace: key.Namespace}, secret); err != nil {
+		return fmt.Errorf("getting storage secret: %w", err)
+	}
+	if endpoint == "" {
+		endpoint = s3.Endpoint
+	}
+	c := &artifacts.Client{
+		Endpoint:        endpoint,
+		Region:          s3.Region,
+		AccessKeyID:     string(secret.Data[artifacts.SecretKeyAccessKeyID]),
+		SecretAccessKey: string(secret.Data[artifacts.SecretKeySecretAccessKey]),
+		SessionToken:    string(secret.Data[artifacts.SecretKeySessionToken]),
+	}
+
+	prefix := artifacts.KeyPrefix(s3.Prefix, task.Namespace, task.Name)
+	for _, objectKey := range task.Status.Artifacts {
+		rel, ok := strings.CutPrefix(objectKey, prefix)
+		if !ok || !fs.ValidPath(rel) {
+			return fmt.Errorf("unexpected artifact key %q", objectKey)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := downloadArtifact(ctx, c, s3.Bucket, objectKey, dest); err != nil {
+			return fmt.Errorf("downloading %s: %w", objectKey, err)
+		}
+		fmt.Fprintln(out, dest)
+	}
+	return nil
+}
+
+func downloadArtifact(ctx context.Context, c *artifacts.Client, bucket, key, dest string) error {
+	body, err := c.GetObject(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestArtifactsGetCommand_MissingName(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"artifacts", "get", "task"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when name is missing")
+	}
+	if !strings.Contains(err.Error(), "task name is required") {
+		t.Errorf("Expected 'task name is required' error, got: %v", err)
+	}
+}
+
+func TestArtifactsGetCommand_NoResourceType(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"artifacts", "get"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when no resource type specified")
+	}
+	if !strings.Contains(err.Error(), "must specify a resource type") {
+		t.Errorf("Expected 'must specify a resource type' error, got: %v", err)
+	}
+}
+
+func newArtifactsTestTask(endpoint string, keys ...string) *kelos.Task {
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-task", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:         "claude-code",
+			Prompt:       "Run the benchmarks",
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "my-workspace"},
+			Artifacts: &kelos.TaskArtifacts{
+				Paths: []string{"bench/**"},
+				Storage: kelos.ArtifactStorage{
+					S3: &kelos.S3ArtifactStorage{
+						Endpoint:  endpoint,
+						Bucket:    "kelos",
+						SecretRef: kelos.SecretReference{Name: "minio-creds"},
+					},
+				},
+			},
+		},
+		Status: kelos.TaskStatus{
+			Phase:     kelos.TaskPhaseSucceeded,
+			Artifacts: keys,
+		},
+	}
+}
+
+func TestDownloadTaskArtifacts(t *testing.T) {
+	objects := map[string]string{
+		"/kelos/default/my-task/bench/results.txt": "42 ns/op",
+		"/kelos/default/my-task/report.md":         "# Report",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=minio/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	task := newArtifactsTestTask("http://minio.minio:9000",
+		"default/my-task/bench/results.txt",
+		"default/my-task/report.md",
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "minio-creds", Namespace: "default"},
+		Data: map[string][]byte{
+			"AWS_ACCESS_KEY_ID":     []byte("minio"),
+			"AWS_SECRET_ACCESS_KEY": []byte("minio123"),
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task, secret).Build()
+	dir := t.TempDir()
+	var out bytes.Buffer
+
+	// The in-cluster endpoint is not reachable from the test, so override
+	// it the way a user would with a port-forward.
+	key := client.ObjectKey{Name: "my-task", Namespace: "default"}
+	if err := downloadTaskArtifacts(context.Background(), cl, key, dir, srv.URL, &out); err != nil {
+		t.Fatalf("downloadTaskArtifacts: %v", err)
+	}
+
+	for rel, want := range map[string]string{"bench/results.txt": "42 ns/op", "report.md": "# Report"} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("Reading %s: %v", rel, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 downloaded files to be reported, got %q", out.String())
+	}
+}
+
+func TestDownloadTaskArtifacts_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    *kelos.Task
+		wantErr string
+	}{
+		{
+			name: "artifacts not configured",
+			task: &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-task", Namespace: "default"},
+				Spec:       kelos.TaskSpec{Type: "claude-code", Prompt: "Fix the bug"},
+			},
+			wantErr: "does not configure artifacts",
+		},
+		{
+			name:    "no artifacts uploaded",
+			task:    newArtifactsTestTask("http://minio.minio:9000"),
+			wantErr: "has no artifacts",
+		},
+		{
+			name:    "key outside the task prefix",
+			task:    newArtifactsTestTask("http://minio.minio:9000", "default/other-task/report.md"),
+			wantErr: "unexpected artifact key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "minio-creds", Namespace: "default"}}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.task, secret).Build()
+			key := client.ObjectKey{Name: "my-task", Namespace: "default"}
+
+			err := downloadTaskArtifacts(context.Background(), cl, key, t.TempDir(), "", &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("downloadTaskArtifacts() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newCancelCommand(cfg *ClientConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel resources",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Help()
+			return fmt.Errorf("must specify a resource type")
+		},
+	}
+
+	cmd.AddCommand(newCancelTaskCommand(cfg))
+
+	return cmd
+}
+
+func newCancelTaskCommand(cfg *ClientConfig) *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:     "task [name]",
+		Aliases: []string{"tasks"},
+		Short:   "Cancel a running or waiting task",
+		Long: `Cancel a running or waiting task.
+
+The agent is stopped gracefully so the branch, commit and token usage of the
+partial run are still captured. The task keeps its status and outputs and
+ends in the Failed phase with a Cancelled condition.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("task name is required\nUsage: %s", cmd.Use)
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("too many arguments: expected 1 task name, got %d\nUsage: %s", len(args), cmd.Use)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, ns, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			key := client.ObjectKey{Name: args[0], Namespace: ns}
+
+			message, err := requestTaskCancellation(ctx, cl, key, reason)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, message)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "reason recorded in the task status")
+
+	cmd.ValidArgsFunction = completeTaskNames(cfg)
+
+	return cmd
+}
+
+// requestTaskCancellation sets the cancel annotation on a Task and returns a
+// message describing the outcome. Tasks that already finished are left
+// unchanged.
+func requestTaskCancellation(ctx context.Context, cl client.Client, key client.ObjectKey, reason string) (string, error) {
+	task := &kelos.Task{}
+	if err := cl.Get(ctx, key, task); err != nil {
+		return "", fmt.Errorf("getting task: %w", err)
+	}
+
+	if task.Status.Phase == kelos.TaskPhaseSucceeded || task.Status.Phase == kelos.TaskPhaseFailed {
+		return fmt.Sprintf("task/%s has already finished (%s)", key.Name, task.Status.Phase), nil
+	}
+	if _, ok := task.Annotations[kelos.AnnotationTaskCancel]; ok {
+		return fmt.Sprintf("task/%s cancellation is already requested", key.Name), nil
+	}
+
+	base := task.DeepCopy()
+	if task.Annotations == nil {
+		task.Annotations = make(map[string]string)
+	}
+	task.Annotations[kelos.AnnotationTaskCancel] = reason
+	if err := cl.Patch(ctx, task, client.MergeFrom(base)); err != nil {
+		return "", fmt.Errorf("cancelling task: %w", err)
+	}
+
+	return fmt.Sprintf("task/%s cancellation requested", key.Name), nil
+}
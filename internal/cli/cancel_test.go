@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestCancelCommand_MissingName(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"cancel", "task"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when name is missing")
+	}
+	if !strings.Contains(err.Error(), "task name is required") {
+		t.Errorf("Expected 'task name is required' error, got: %v", err)
+	}
+}
+
+func TestCancelCommand_TooManyArgs(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"cancel", "task", "a", "b"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error with too many arguments")
+	}
+	if !strings.Contains(err.Error(), "too many arguments") {
+		t.Errorf("Expected 'too many arguments' error, got: %v", err)
+	}
+}
+
+func TestCancelCommand_NoResourceType(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"cancel"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error when no resource type specified")
+	}
+	if !strings.Contains(err.Error(), "must specify a resource type") {
+		t.Errorf("Expected 'must specify a resource type' error, got: %v", err)
+	}
+}
+
+func TestRequestTaskCancellation(t *testing.T) {
+	tests := []struct {
+		name           string
+		phase          kelos.TaskPhase
+		annotations    map[string]string
+		wantMessage    string
+		wantAnnotation string
+		wantCancel     bool
+	}{
+		{
+			name:           "running task",
+			phase:          kelos.TaskPhaseRunning,
+			annotations:    map[string]string{"team": "platform"},
+			wantMessage:    "task/my-task cancellation requested",
+			wantAnnotation: "no longer needed",
+			wantCancel:     true,
+		},
+		{
+			name:           "already requested",
+			phase:          kelos.TaskPhaseRunning,
+			annotations:    map[string]string{kelos.AnnotationTaskCancel: "earlier"},
+			wantMessage:    "task/my-task cancellation is already requested",
+			wantAnnotation: "earlier",
+			wantCancel:     true,
+		},
+		{
+			name:        "finished task",
+			phase:       kelos.TaskPhaseSucceeded,
+			wantMessage: "task/my-task has already finished (Succeeded)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-task",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				Spec: kelos.TaskSpec{
+					Type:   "claude-code",
+					Prompt: "Fix the bug",
+				},
+				Status: kelos.TaskStatus{Phase: tt.phase},
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+			ctx := context.Background()
+			key := client.ObjectKey{Name: "my-task", Namespace: "default"}
+
+			message, err := requestTaskCancellation(ctx, cl, key, "no longer needed")
+			if err != nil {
+				t.Fatalf("requestTaskCancellation: %v", err)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", message, tt.wantMessage)
+			}
+
+			updated := &kelos.Task{}
+			if err := cl.Get(ctx, key, updated); err != nil {
+				t.Fatalf("Get after cancel: %v", err)
+			}
+			got, ok := updated.Annotations[kelos.AnnotationTaskCancel]
+			if ok != tt.wantCancel {
+				t.Fatalf("Cancel annotation present = %v, want %v", ok, tt.wantCancel)
+			}
+			if got != tt.wantAnnotation {
+				t.Errorf("Cancel annotation = %q, want %q", got, tt.wantAnnotation)
+			}
+			if v, ok := tt.annotations["team"]; ok && updated.Annotations["team"] != v {
+				t.Error("Existing annotations were not preserved")
+			}
+		})
+	}
+}
+
+func TestRequestTaskCancellation_NotFound(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	key := client.ObjectKey{Name: "missing", Namespace: "default"}
+
+	if _, err := requestTaskCancellation(context.Background(), cl, key, ""); err == nil {
+		t.Fatal("Expected error for a missing task")
+	}
+}
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -10,6 +12,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
 )
 
 func newDeleteCommand(cfg *ClientConfig) *cobra.Command {
@@ -102,11 +105,17 @@ func newDeleteTaskCommand(cfg *ClientConfig) *cobra.Command {
 
 func newDeleteWorkspaceCommand(cfg *ClientConfig) *cobra.Command {
 	var all bool
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:     "workspace [name]",
 		Aliases: []string{"workspaces", "ws"},
 		Short:   "Delete a workspace",
+		Long: `Delete a workspace.
+
+A workspace that Tasks, Sessions, WorkerPools, TaskSpawners, SessionSpawners
+or Pipelines still use is not deleted; the command lists them instead. Use
+--force to delete it anyway.`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if all && len(args) > 0 {
 				return fmt.Errorf("cannot specify workspace name with --all")
@@ -127,47 +136,59 @@ func newDeleteWorkspaceCommand(cfg *ClientConfig) *cobra.Command {
 				return err
 			}
 
-			ctx := context.Background()
-
-			if all {
-				wsList := &kelos.WorkspaceList{}
-				if err := cl.List(ctx, wsList, client.InNamespace(ns)); err != nil {
-					return fmt.Errorf("listing workspaces: %w", err)
-				}
-				if len(wsList.Items) == 0 {
-					fmt.Fprintln(os.Stdout, "No workspaces found")
-					return nil
-				}
-				for i := range wsList.Items {
-					if err := cl.Delete(ctx, &wsList.Items[i]); err != nil {
-						return fmt.Errorf("deleting workspace %s: %w", wsList.Items[i].Name, err)
-					}
-					fmt.Fprintf(os.Stdout, "workspace/%s deleted\n", wsList.Items[i].Name)
-				}
-				return nil
-			}
-
-			ws := &kelos.Workspace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      args[0],
-					Namespace: ns,
-				},
-			}
-
-			if err := cl.Delete(ctx, ws); err != nil {
-				return fmt.Errorf("deleting workspace: %w", err)
-			}
-			fmt.Fprintf(os.Stdout, "workspace/%s deleted\n", args[0])
-			return nil
+			return runDeleteWorkspace(context.Background(), cl, ns, args, all, force, os.Stdout)
 		},
 	}
 
 	cmd.Flags().BoolVar(&all, "all", false, "Delete all workspaces in the namespace")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete workspaces even if they are in use")
 	cmd.ValidArgsFunction = completeWorkspaceNames(cfg)
 
 	return cmd
 }
 
+func runDeleteWorkspace(ctx context.Context, cl client.Client, namespace string, args []string, all, force bool, out io.Writer) error {
+	names := args
+	if all {
+		wsList := &kelos.WorkspaceList{}
+		if err := cl.List(ctx, wsList, client.InNamespace(namespace)); err != nil {
+			return fmt.Errorf("listing workspaces: %w", err)
+		}
+		if len(wsList.Items) == 0 {
+			fmt.Fprintln(out, "No workspaces found")
+			return nil
+		}
+		names = nil
+		for _, ws := range wsList.Items {
+			names = append(names, ws.Name)
+		}
+	}
+
+	var errs []error
+	for _, name := range names {
+		if !force {
+			if err := checkNotInUse(ctx, cl, "workspace", namespace, name, inuse.WorkspaceUsers); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		ws := &kelos.Workspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+		if err := cl.Delete(ctx, ws); err != nil {
+			return fmt.Errorf("deleting workspace %s: %w", name, err)
+		}
+		if err := releaseInUseProtection(ctx, cl, ws, inuse.WorkspaceProtectionFinalizer); err != nil {
+			return fmt.Errorf("removing protection of workspace %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "workspace/%s deleted\n", name)
+	}
+	return errors.Join(errs...)
+}
+
 func newDeleteTaskSpawnerCommand(cfg *ClientConfig) *cobra.Command {
 	var all bool
 
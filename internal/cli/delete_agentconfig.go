@@ -2,20 +2,33 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
 )
 
 func newDeleteAgentConfigCommand(cfg *ClientConfig) *cobra.Command {
 	var all bool
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:     "agentconfig [name]",
 		Aliases: []string{"agentconfigs", "ac"},
 		Short:   "Delete an agent config",
+		Long: `Delete an agent config.
+
+An agent config that Tasks, Sessions, WorkerPools, TaskSpawners,
+SessionSpawners, Pipelines or other agent configs (through extends) still use
+is not deleted; the command lists them instead. Use --force to delete it
+anyway.`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if all && len(args) > 0 {
 				return fmt.Errorf("cannot specify agent config name with --all")
@@ -36,36 +49,69 @@ func newDeleteAgentConfigCommand(cfg *ClientConfig) *cobra.Command {
 				return err
 			}
 
-			ctx := context.Background()
-
-			if all {
-				items, _, err := listAgentConfigs(ctx, cl, client.InNamespace(ns))
-				if err != nil {
-					return fmt.Errorf("listing agent configs: %w", err)
-				}
-				if len(items) == 0 {
-					fmt.Fprintln(os.Stdout, "No agent configs found")
-					return nil
-				}
-				for i := range items {
-					if err := deleteAgentConfig(ctx, cl, items[i].Name, items[i].Namespace); err != nil {
-						return fmt.Errorf("deleting agent config %s: %w", items[i].Name, err)
-					}
-					fmt.Fprintf(os.Stdout, "agentconfig/%s deleted\n", items[i].Name)
-				}
-				return nil
-			}
-
-			if err := deleteAgentConfig(ctx, cl, args[0], ns); err != nil {
-				return fmt.Errorf("deleting agent config %s: %w", args[0], err)
-			}
-			fmt.Fprintf(os.Stdout, "agentconfig/%s deleted\n", args[0])
-			return nil
+			return runDeleteAgentConfig(context.Background(), cl, ns, args, all, force, os.Stdout)
 		},
 	}
 
 	cmd.Flags().BoolVar(&all, "all", false, "Delete all agent configs in the namespace")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete agent configs even if they are in use")
 	cmd.ValidArgsFunction = completeAgentConfigNames(cfg)
 
 	return cmd
 }
+
+func runDeleteAgentConfig(ctx context.Context, cl client.Client, namespace string, args []string, all, force bool, out io.Writer) error {
+	names := args
+	if all {
+		items, _, err := listAgentConfigs(ctx, cl, client.InNamespace(namespace))
+		if err != nil {
+			return fmt.Errorf("listing agent configs: %w", err)
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(out, "No agent configs found")
+			return nil
+		}
+		names = nil
+		for i := range items {
+			names = append(names, items[i].Name)
+		}
+	}
+
+	// Agent configs deleted together do not keep each other in use.
+	deleted := make(map[string]bool, len(names))
+	for _, name := range names {
+		deleted[name] = true
+	}
+	users := func(ctx context.Context, c client.Reader, namespace, name string) ([]inuse.User, error) {
+		found, err := inuse.AgentConfigUsers(ctx, c, namespace, name)
+		var remaining []inuse.User
+		for _, user := range found {
+			if user.Kind != "AgentConfig" || !deleted[user.Name] {
+				remaining = append(remaining, user)
+			}
+		}
+		return remaining, err
+	}
+
+	var errs []error
+	for _, name := range names {
+		if !force {
+			if err := checkNotInUse(ctx, cl, "agentconfig", namespace, name, users); err != nil {
+				errs = append(errs, err)
+				delete(deleted, name)
+				continue
+			}
+		}
+		if err := deleteAgentConfig(ctx, cl, name, namespace); err != nil {
+			return fmt.Errorf("deleting agent config %s: %w", name, err)
+		}
+		ac := &kelos.AgentConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		}
+		if err := releaseInUseProtection(ctx, cl, ac, inuse.AgentConfigProtectionFinalizer); err != nil {
+			return fmt.Errorf("removing protection of agent config %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "agentconfig/%s deleted\n", name)
+	}
+	return errors.Join(errs...)
+}
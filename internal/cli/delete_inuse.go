@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/kelos-dev/kelos/internal/inuse"
+)
+
+// usersFunc lists the objects that use the named object.
+type usersFunc func(ctx context.Context, c client.Reader, namespace, name string) ([]inuse.User, error)
+
+// checkNotInUse returns an error listing the users of the named object, or
+// nil if it is not in use. Clusters without the listed kinds have no users.
+func checkNotInUse(ctx context.Context, cl client.Client, kind, namespace, name string, users usersFunc) error {
+	blocking, err := users(ctx, cl, namespace, name)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("listing users of %s %s: %w", kind, name, err)
+	}
+	if len(blocking) == 0 {
+		return nil
+	}
+	names := make([]string, len(blocking))
+	for i, user := range blocking {
+		names[i] = user.String()
+	}
+	return fmt.Errorf("%s %s is in use by %s; delete or update them first, or use --force to delete it anyway", kind, name, strings.Join(names, ", "))
+}
+
+// releaseInUseProtection removes finalizer from a deleted obj, so that it is
+// removed without waiting for the controller to find it unused.
+func releaseInUseProtection(ctx context.Context, cl client.Client, obj client.Object, finalizer string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		if !controllerutil.RemoveFinalizer(obj, finalizer) {
+			return nil
+		}
+		return cl.Update(ctx, obj)
+	})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
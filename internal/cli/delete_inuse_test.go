@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
+)
+
+func TestRunDeleteWorkspaceInUse(t *testing.T) {
+	ctx := context.Background()
+	ws := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "repo",
+			Namespace:  "default",
+			Finalizers: []string{inuse.WorkspaceProtectionFinalizer},
+		},
+	}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "fix-bug", Namespace: "default"},
+		Spec:       kelos.TaskSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "repo"}},
+	}
+	session := &kelos.Session{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "default"},
+		Spec:       kelos.SessionSpec{Worker: kelos.WorkerSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "repo"}}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ws, task, session).Build()
+
+	var out strings.Builder
+	err := runDeleteWorkspace(ctx, cl, "default", []string{"repo"}, false, false, &out)
+	if err == nil {
+		t.Fatal("expected an error for a workspace in use")
+	}
+	for _, want := range []string{"session/chat", "task/fix-bug", "--force"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got %q", want, err.Error())
+		}
+	}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(ws), &kelos.Workspace{}); err != nil {
+		t.Fatalf("expected the workspace to remain: %v", err)
+	}
+	if out.String() != "" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	if err := runDeleteWorkspace(ctx, cl, "default", []string{"repo"}, false, true, &out); err != nil {
+		t.Fatalf("runDeleteWorkspace with force: %v", err)
+	}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(ws), &kelos.Workspace{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the workspace to be removed, got %v", err)
+	}
+	if out.String() != "workspace/repo deleted\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestRunDeleteWorkspaceNotInUseRemovesProtection(t *testing.T) {
+	ctx := context.Background()
+	ws := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "repo",
+			Namespace:  "default",
+			Finalizers: []string{inuse.WorkspaceProtectionFinalizer},
+		},
+	}
+	done := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "done", Namespace: "default"},
+		Spec:       kelos.TaskSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "repo"}},
+		Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseSucceeded},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ws, done).Build()
+
+	var out strings.Builder
+	if err := runDeleteWorkspace(ctx, cl, "default", []string{"repo"}, false, false, &out); err != nil {
+		t.Fatalf("runDeleteWorkspace: %v", err)
+	}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(ws), &kelos.Workspace{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the workspace to be removed, got %v", err)
+	}
+}
+
+func TestRunDeleteAgentConfigAllSkipsConfigsInUse(t *testing.T) {
+	ctx := context.Background()
+	protected := []string{inuse.AgentConfigProtectionFinalizer}
+	org := &kelos.AgentConfig{ObjectMeta: metav1.ObjectMeta{Name: "org", Namespace: "default", Finalizers: protected}}
+	team := &kelos.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default", Finalizers: protected},
+		Spec:       kelos.AgentConfigSpec{Extends: &kelos.AgentConfigReference{Name: "org"}},
+	}
+	used := &kelos.AgentConfig{ObjectMeta: metav1.ObjectMeta{Name: "used", Namespace: "default", Finalizers: protected}}
+	spawner := &kelos.TaskSpawner{
+		ObjectMeta: metav1.ObjectMeta{Name: "issues", Namespace: "default"},
+		Spec: kelos.TaskSpawnerSpec{TaskTemplate: kelos.TaskTemplate{
+			Worker: &kelos.WorkerSpec{AgentConfigRefs: []kelos.AgentConfigReference{{Name: "used"}}},
+		}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(org, team, used, spawner).Build()
+
+	var out strings.Builder
+	err := runDeleteAgentConfig(ctx, cl, "default", nil, true, false, &out)
+	if err == nil || !strings.Contains(err.Error(), "agentconfig used is in use by taskspawner/issues") {
+		t.Fatalf("expected an error for the agent config in use, got %v", err)
+	}
+
+	// An agent config extended only by another deleted agent config is
+	// deleted with it.
+	for _, name := range []string{"org", "team"} {
+		if err := cl.Get(ctx, client.ObjectKey{Name: name, Namespace: "default"}, &kelos.AgentConfig{}); !apierrors.IsNotFound(err) {
+			t.Errorf("expected agent config %s to be removed, got %v", name, err)
+		}
+	}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(used), &kelos.AgentConfig{}); err != nil {
+		t.Errorf("expected agent config used to remain: %v", err)
+	}
+	if out.String() != "agentconfig/org deleted\nagentconfig/team deleted\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
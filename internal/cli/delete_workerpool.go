@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -10,15 +11,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
 )
 
 func newDeleteWorkerPoolCommand(cfg *ClientConfig) *cobra.Command {
 	var all bool
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:     "workerpool [name]",
 		Aliases: []string{"workerpools", "wp"},
 		Short:   "Delete a worker pool",
+		Long: `Delete a worker pool.
+
+A worker pool that unfinished Tasks, TaskSpawners or Pipelines still use is
+not deleted; the command lists them instead. Use --force to delete it anyway.`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if all && len(args) > 0 {
 				return fmt.Errorf("cannot specify worker pool name with --all")
@@ -39,17 +46,19 @@ func newDeleteWorkerPoolCommand(cfg *ClientConfig) *cobra.Command {
 				return err
 			}
 
-			return runDeleteWorkerPool(context.Background(), cl, ns, args, all, cmd.OutOrStdout())
+			return runDeleteWorkerPool(context.Background(), cl, ns, args, all, force, cmd.OutOrStdout())
 		},
 	}
 
 	cmd.Flags().BoolVar(&all, "all", false, "Delete all worker pools in the namespace")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete worker pools even if they are in use")
 	cmd.ValidArgsFunction = completeWorkerPoolNames(cfg)
 
 	return cmd
 }
 
-func runDeleteWorkerPool(ctx context.Context, cl client.Client, namespace string, args []string, all bool, out io.Writer) error {
+func runDeleteWorkerPool(ctx context.Context, cl client.Client, namespace string, args []string, all, force bool, out io.Writer) error {
+	names := args
 	if all {
 		wpList := &kelos.WorkerPoolList{}
 		if err := cl.List(ctx, wpList, client.InNamespace(namespace)); err != nil {
@@ -59,26 +68,33 @@ func runDeleteWorkerPool(ctx context.Context, cl client.Client, namespace string
 			fmt.Fprintln(out, "No worker pools found")
 			return nil
 		}
-		for i := range wpList.Items {
-			if err := cl.Delete(ctx, &wpList.Items[i]); err != nil {
-				return fmt.Errorf("deleting worker pool %s: %w", wpList.Items[i].Name, err)
-			}
-			fmt.Fprintf(out, "workerpool/%s deleted\n", wpList.Items[i].Name)
+		names = nil
+		for _, wp := range wpList.Items {
+			names = append(names, wp.Name)
 		}
-		return nil
-	}
-
-	name := args[0]
-	wp := &kelos.WorkerPool{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-		},
 	}
 
-	if err := cl.Delete(ctx, wp); err != nil {
-		return fmt.Errorf("deleting worker pool %s: %w", name, err)
+	var errs []error
+	for _, name := range names {
+		if !force {
+			if err := checkNotInUse(ctx, cl, "workerpool", namespace, name, inuse.WorkerPoolUsers); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		wp := &kelos.WorkerPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+		if err := cl.Delete(ctx, wp); err != nil {
+			return fmt.Errorf("deleting worker pool %s: %w", name, err)
+		}
+		if err := releaseInUseProtection(ctx, cl, wp, inuse.WorkerPoolProtectionFinalizer); err != nil {
+			return fmt.Errorf("removing protection of worker pool %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "workerpool/%s deleted\n", name)
 	}
-	fmt.Fprintf(out, "workerpool/%s deleted\n", name)
-	return nil
+	return errors.Join(errs...)
 }
@@ -25,7 +25,7 @@ func TestRunDeleteWorkerPoolDeletesNamedPool(t *testing.T) {
 	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wp).Build()
 
 	var out strings.Builder
-	if err := runDeleteWorkerPool(ctx, cl, "default", []string{"pool-a"}, false, &out); err != nil {
+	if err := runDeleteWorkerPool(ctx, cl, "default", []string{"pool-a"}, false, false, &out); err != nil {
 		t.Fatalf("runDeleteWorkerPool: %v", err)
 	}
 
@@ -47,7 +47,7 @@ func TestRunDeleteWorkerPoolAllDeletesPoolsInNamespace(t *testing.T) {
 	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wpA, wpB, wpOtherNamespace).Build()
 
 	var out strings.Builder
-	if err := runDeleteWorkerPool(ctx, cl, "default", nil, true, &out); err != nil {
+	if err := runDeleteWorkerPool(ctx, cl, "default", nil, true, false, &out); err != nil {
 		t.Fatalf("runDeleteWorkerPool: %v", err)
 	}
 
@@ -80,7 +80,7 @@ func TestRunDeleteWorkerPoolAllNoPools(t *testing.T) {
 	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
 
 	var out strings.Builder
-	if err := runDeleteWorkerPool(ctx, cl, "default", nil, true, &out); err != nil {
+	if err := runDeleteWorkerPool(ctx, cl, "default", nil, true, false, &out); err != nil {
 		t.Fatalf("runDeleteWorkerPool: %v", err)
 	}
 
@@ -2,10 +2,14 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
@@ -116,6 +120,7 @@ func newGetTaskSpawnerCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Com
 func newGetTaskCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Command {
 	var output string
 	var detail bool
+	var resolved bool
 	var phases []string
 
 	cmd := &cobra.Command{
@@ -136,6 +141,10 @@ func newGetTaskCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Command {
 				return err
 			}
 
+			if resolved && len(args) != 1 {
+				return fmt.Errorf("--resolved requires a task name")
+			}
+
 			cl, ns, err := cfg.NewClient()
 			if err != nil {
 				return err
@@ -143,6 +152,21 @@ func newGetTaskCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Command {
 
 			ctx := context.Background()
 
+			if resolved {
+				snapshot, err := getTaskResolvedSnapshot(ctx, cl, ns, args[0])
+				if err != nil {
+					return err
+				}
+				view, err := getResolvedSnapshotView(ctx, cl, ns, snapshot, os.Stderr)
+				if err != nil {
+					return err
+				}
+				if output == "json" {
+					return printJSON(os.Stdout, view)
+				}
+				return printYAML(os.Stdout, view)
+			}
+
 			if len(args) == 1 {
 				task := &kelos.Task{}
 				if err := cl.Get(ctx, client.ObjectKey{Name: args[0], Namespace: ns}, task); err != nil {
@@ -199,18 +223,94 @@ func newGetTaskCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Command {
 
 	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format (yaml or json)")
 	cmd.Flags().BoolVarP(&detail, "detail", "d", false, "Show detailed information for a specific task")
-	cmd.Flags().StringSliceVar(&phases, "phase", nil, "Filter tasks by phase (Pending, Running, Waiting, Succeeded, Failed)")
+	cmd.Flags().BoolVar(&resolved, "resolved", false, "Show the resolved Workspace, AgentConfig, image and model a specific task ran with")
+	cmd.Flags().StringSliceVar(&phases, "phase", nil, "Filter tasks by phase (Pending, Running, Waiting, AwaitingApproval, Succeeded, Failed)")
 
 	cmd.ValidArgsFunction = completeTaskNames(cfg)
 	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"yaml", "json"}, cobra.ShellCompDirectiveNoFileComp))
 	_ = cmd.RegisterFlagCompletionFunc("phase", cobra.FixedCompletions(
-		[]string{"Pending", "Running", "Waiting", "Succeeded", "Failed"},
+		[]string{"Pending", "Running", "Waiting", "AwaitingApproval", "Succeeded", "Failed"},
 		cobra.ShellCompDirectiveNoFileComp,
 	))
 
 	return cmd
 }
 
+// getTaskResolvedSnapshot returns the resolved snapshot of the named Task, or
+// of its most recent TaskRecord once the Task has been deleted.
+func getTaskResolvedSnapshot(ctx context.Context, cl client.Client, namespace, name string) (*kelos.TaskResolvedSnapshot, error) {
+	var task kelos.Task
+	err := cl.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &task)
+	if err == nil {
+		if task.Status.Resolved == nil {
+			return nil, fmt.Errorf("task %s has no resolved snapshot yet; it is recorded when the Task's Job is created", name)
+		}
+		return task.Status.Resolved, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting task: %w", err)
+	}
+
+	var records kelos.TaskRecordList
+	if listErr := cl.List(ctx, &records, client.InNamespace(namespace)); listErr != nil {
+		return nil, fmt.Errorf("listing task records: %w", listErr)
+	}
+	var latest *kelos.TaskRecord
+	for i := range records.Items {
+		record := &records.Items[i]
+		if record.Spec.TaskRef.Name != name || record.Spec.Resolved == nil || record.Spec.CompletionTime == nil {
+			continue
+		}
+		if latest == nil || record.Spec.CompletionTime.After(latest.Spec.CompletionTime.Time) {
+			latest = record
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("getting task: %w", err)
+	}
+	return latest.Spec.Resolved, nil
+}
+
+// resolvedSnapshotView is a resolved snapshot together with the Workspace
+// and AgentConfig stored in its ConfigMap.
+type resolvedSnapshotView struct {
+	kelos.TaskResolvedSnapshot
+	Workspace   *kelos.WorkspaceSpec   `json:"workspace,omitempty"`
+	AgentConfig *kelos.AgentConfigSpec `json:"agentConfig,omitempty"`
+}
+
+// getResolvedSnapshotView loads the content of snapshot's ConfigMap. When the
+// ConfigMap is gone, a warning is written to errOut and only the snapshot
+// itself is returned.
+func getResolvedSnapshotView(ctx context.Context, cl client.Client, namespace string, snapshot *kelos.TaskResolvedSnapshot, errOut io.Writer) (*resolvedSnapshotView, error) {
+	view := &resolvedSnapshotView{TaskResolvedSnapshot: *snapshot}
+	if snapshot.ConfigMapName == "" {
+		return view, nil
+	}
+
+	var configMap corev1.ConfigMap
+	if err := cl.Get(ctx, client.ObjectKey{Name: snapshot.ConfigMapName, Namespace: namespace}, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(errOut, "Warning: resolved snapshot ConfigMap %s not found; showing the snapshot only\n", snapshot.ConfigMapName)
+			return view, nil
+		}
+		return nil, fmt.Errorf("getting resolved snapshot ConfigMap: %w", err)
+	}
+	if data, ok := configMap.Data[kelos.ResolvedSnapshotWorkspaceKey]; ok {
+		view.Workspace = &kelos.WorkspaceSpec{}
+		if err := json.Unmarshal([]byte(data), view.Workspace); err != nil {
+			return nil, fmt.Errorf("decoding resolved Workspace: %w", err)
+		}
+	}
+	if data, ok := configMap.Data[kelos.ResolvedSnapshotAgentConfigKey]; ok {
+		view.AgentConfig = &kelos.AgentConfigSpec{}
+		if err := json.Unmarshal([]byte(data), view.AgentConfig); err != nil {
+			return nil, fmt.Errorf("decoding resolved AgentConfig: %w", err)
+		}
+	}
+	return view, nil
+}
+
 func hydrateTaskWorkerPoolFields(ctx context.Context, cl client.Client, task *kelos.Task) {
 	if task.Spec.WorkerPoolRef == nil {
 		return
@@ -326,17 +426,18 @@ func newGetWorkspaceCommand(cfg *ClientConfig, allNamespaces *bool) *cobra.Comma
 }
 
 var validTaskPhases = map[kelos.TaskPhase]bool{
-	kelos.TaskPhasePending:   true,
-	kelos.TaskPhaseRunning:   true,
-	kelos.TaskPhaseWaiting:   true,
-	kelos.TaskPhaseSucceeded: true,
-	kelos.TaskPhaseFailed:    true,
+	kelos.TaskPhasePending:          true,
+	kelos.TaskPhaseRunning:          true,
+	kelos.TaskPhaseWaiting:          true,
+	kelos.TaskPhaseAwaitingApproval: true,
+	kelos.TaskPhaseSucceeded:        true,
+	kelos.TaskPhaseFailed:           true,
 }
 
 func validatePhases(phases []string) error {
 	for _, p := range phases {
 		if !validTaskPhases[kelos.TaskPhase(p)] {
-			return fmt.Errorf("unknown phase %q: must be one of Pending, Running, Waiting, Succeeded, Failed", p)
+			return fmt.Errorf("unknown phase %q: must be one of Pending, Running, Waiting, AwaitingApproval, Succeeded, Failed", p)
 		}
 	}
 	return nil
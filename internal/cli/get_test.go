@@ -58,7 +58,7 @@ func TestValidatePhases(t *testing.T) {
 	}{
 		{"valid single phase", []string{"Running"}, false},
 		{"valid multiple phases", []string{"Pending", "Running", "Waiting"}, false},
-		{"all valid phases", []string{"Pending", "Running", "Waiting", "Succeeded", "Failed"}, false},
+		{"all valid phases", []string{"Pending", "Running", "Waiting", "AwaitingApproval", "Succeeded", "Failed"}, false},
 		{"empty phases", nil, false},
 		{"invalid phase", []string{"Unknown"}, true},
 		{"mixed valid and invalid", []string{"Running", "Invalid"}, true},
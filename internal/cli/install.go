@@ -42,6 +42,7 @@ const installWaitTimeout = 2 * time.Minute
 
 var kelosCRDNames = []string{
 	"agentconfigs.kelos.dev",
+	"pipelines.kelos.dev",
 	"sessions.kelos.dev",
 	"sessionspawners.kelos.dev",
 	"tasks.kelos.dev",
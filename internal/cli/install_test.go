@@ -1097,6 +1097,7 @@ func TestKelosCRDsExist(t *testing.T) {
 
 func TestKelosCRDNameSets(t *testing.T) {
 	for _, name := range []string{
+		"pipelines.kelos.dev",
 		"sessions.kelos.dev",
 		"taskbudgets.kelos.dev",
 		"taskrecords.kelos.dev",
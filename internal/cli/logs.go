@@ -251,15 +251,21 @@ func streamWorkerPoolTaskAgentLogs(ctx context.Context, cl client.Client, cs *ku
 }
 
 func parseAgentLogs(agentType string, stream io.Reader) error {
+	return formatAgentLogs(agentType, stream, os.Stdout, os.Stderr)
+}
+
+// formatAgentLogs writes the agent text of stream to stdout and its status
+// and tool information to stderr, using the log format of agentType.
+func formatAgentLogs(agentType string, stream io.Reader, stdout, stderr io.Writer) error {
 	switch agentType {
 	case "codex":
-		return ParseAndFormatCodexLogs(stream, os.Stdout, os.Stderr)
+		return ParseAndFormatCodexLogs(stream, stdout, stderr)
 	case "gemini":
-		return ParseAndFormatGeminiLogs(stream, os.Stdout, os.Stderr)
+		return ParseAndFormatGeminiLogs(stream, stdout, stderr)
 	case "opencode":
-		return ParseAndFormatOpenCodeLogs(stream, os.Stdout, os.Stderr)
+		return ParseAndFormatOpenCodeLogs(stream, stdout, stderr)
 	default:
-		return ParseAndFormatLogs(stream, os.Stdout, os.Stderr)
+		return ParseAndFormatLogs(stream, stdout, stderr)
 	}
 }
 
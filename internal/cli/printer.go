@@ -88,15 +88,17 @@ func agentConfigRefNames(refs []kelos.AgentConfigReference) []string {
 func printTaskTable(w io.Writer, tasks []kelos.Task, allNamespaces bool) {
 	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
 	if allNamespaces {
-		fmt.Fprintln(tw, "NAMESPACE\tNAME\tTYPE\tPHASE\tBRANCH\tWORKSPACE\tAGENT CONFIG\tDURATION\tAGE")
+		fmt.Fprintln(tw, "NAMESPACE\tNAME\tTYPE\tPHASE\tQUEUE\tBRANCH\tWORKSPACE\tAGENT CONFIG\tDURATION\tAGE")
 	} else {
-		fmt.Fprintln(tw, "NAME\tTYPE\tPHASE\tBRANCH\tWORKSPACE\tAGENT CONFIG\tDURATION\tAGE")
+		fmt.Fprintln(tw, "NAME\tTYPE\tPHASE\tQUEUE\tBRANCH\tWORKSPACE\tAGENT CONFIG\tDURATION\tAGE")
 	}
 	for _, t := range tasks {
 		age := duration.HumanDuration(time.Since(t.CreationTimestamp.Time))
 		branch := "-"
 		if t.Spec.Branch != "" {
 			branch = t.Spec.Branch
+		} else if t.Status.InheritedBranch != "" {
+			branch = t.Status.InheritedBranch
 		}
 		workspace := "-"
 		if ref := taskDisplayWorkspaceRef(&t); ref != nil {
@@ -108,22 +110,44 @@ func printTaskTable(w io.Writer, tasks []kelos.Task, allNamespaces bool) {
 			agentConfig = strings.Join(names, ",")
 		}
 		dur := taskDuration(&t.Status)
+		queue := "-"
+		if pos := taskQueuePosition(&t); pos > 0 {
+			queue = fmt.Sprintf("%d", pos)
+		}
 		if allNamespaces {
-			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				t.Namespace, t.Name, taskDisplayType(&t), t.Status.Phase, branch, workspace, agentConfig, dur, age)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				t.Namespace, t.Name, taskDisplayType(&t), t.Status.Phase, queue, branch, workspace, agentConfig, dur, age)
 		} else {
-			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				t.Name, taskDisplayType(&t), t.Status.Phase, branch, workspace, agentConfig, dur, age)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				t.Name, taskDisplayType(&t), t.Status.Phase, queue, branch, workspace, agentConfig, dur, age)
 		}
 	}
 	tw.Flush()
 }
 
+// taskQueuePosition returns the position of a Waiting Task in the queue for
+// its branch or TaskBudget maxRunning quota, or 0 if it is not queued.
+func taskQueuePosition(t *kelos.Task) int32 {
+	if t.Status.Phase != kelos.TaskPhaseWaiting || t.Status.QueuePosition == nil {
+		return 0
+	}
+	return *t.Status.QueuePosition
+}
+
 func printTaskDetail(w io.Writer, t *kelos.Task) {
 	printField(w, "Name", t.Name)
 	printField(w, "Namespace", t.Namespace)
 	printField(w, "Type", taskDisplayType(t))
 	printField(w, "Phase", string(t.Status.Phase))
+	if pos := taskQueuePosition(t); pos > 0 {
+		printField(w, "Queue Position", fmt.Sprintf("%d", pos))
+	}
+	if t.Status.Phase == kelos.TaskPhaseWaiting && t.Status.BranchLockHolder != "" {
+		printField(w, "Branch Locked By", t.Status.BranchLockHolder)
+	}
+	if t.Spec.Priority != nil {
+		printField(w, "Priority", fmt.Sprintf("%d", *t.Spec.Priority))
+	}
 	printField(w, "Prompt", t.Spec.Prompt)
 	if creds := taskDisplayCredentials(t); creds != nil {
 		if creds.SecretRef != nil {
@@ -139,10 +163,18 @@ func printTaskDetail(w io.Writer, t *kelos.Task) {
 	}
 	if t.Spec.Branch != "" {
 		printField(w, "Branch", t.Spec.Branch)
+	} else if t.Status.InheritedBranch != "" {
+		printField(w, "Branch", t.Status.InheritedBranch+" (inherited)")
 	}
 	if len(t.Spec.DependsOn) > 0 {
 		printField(w, "Depends On", strings.Join(t.Spec.DependsOn, ", "))
 	}
+	if t.Spec.ContinueFrom != "" {
+		printField(w, "Continues", t.Spec.ContinueFrom)
+	}
+	if t.Status.ConversationClaimName != "" {
+		printField(w, "Conversation", t.Status.ConversationClaimName)
+	}
 	if ref := taskDisplayWorkspaceRef(t); ref != nil {
 		printField(w, "Workspace", ref.Name)
 	}
@@ -153,12 +185,36 @@ func printTaskDetail(w io.Writer, t *kelos.Task) {
 		names := agentConfigRefNames(refs)
 		printField(w, "Agent Configs", strings.Join(names, ", "))
 	}
+	if t.Status.Resolved != nil && t.Status.Resolved.Hash != "" {
+		printField(w, "Resolved Hash", t.Status.Resolved.Hash)
+	}
 	if t.Spec.TTLSecondsAfterFinished != nil {
 		printField(w, "TTL", fmt.Sprintf("%ds", *t.Spec.TTLSecondsAfterFinished))
 	}
 	if overrides := taskDisplayPodOverrides(t); overrides != nil && overrides.ActiveDeadlineSeconds != nil {
 		printField(w, "Timeout", fmt.Sprintf("%ds", *overrides.ActiveDeadlineSeconds))
 	}
+	if t.Spec.RetryPolicy != nil {
+		attempt := t.Status.Attempt
+		if attempt == 0 {
+			attempt = int32(len(t.Status.Attempts)) + 1
+		}
+		printField(w, "Attempt", fmt.Sprintf("%d/%d", attempt, t.Spec.RetryPolicy.MaxAttempts))
+	}
+	if t.Status.NextRetryTime != nil {
+		printField(w, "Next Retry", t.Status.NextRetryTime.Time.Format(time.RFC3339))
+	}
+	for i, a := range t.Status.Attempts {
+		entry := fmt.Sprintf("#%d %s", a.Attempt, a.Reason)
+		if a.Message != "" {
+			entry += ": " + a.Message
+		}
+		if i == 0 {
+			printField(w, "Previous Attempts", entry)
+		} else {
+			fmt.Fprintf(w, "%-20s%s\n", "", entry)
+		}
+	}
 	if t.Status.JobName != "" {
 		printField(w, "Job", t.Status.JobName)
 	}
@@ -217,6 +273,11 @@ func printTaskSpawnerTable(w io.Writer, spawners []kelos.TaskSpawner, allNamespa
 			source = "GitHub Pull Requests"
 		} else if s.Spec.When.Jira != nil {
 			source = s.Spec.When.Jira.Project
+		} else if s.Spec.When.GitLab != nil {
+			source = "GitLab"
+			if s.Spec.When.GitLab.Project != "" {
+				source += " (" + s.Spec.When.GitLab.Project + ")"
+			}
 		} else if s.Spec.When.Cron != nil {
 			source = "cron: " + s.Spec.When.Cron.Schedule
 		} else if s.Spec.When.GitHubWebhook != nil {
@@ -255,6 +316,8 @@ func effectivePollInterval(ts *kelos.TaskSpawner) string {
 		return ts.Spec.When.GitHubPullRequests.PollInterval
 	case ts.Spec.When.Jira != nil && ts.Spec.When.Jira.PollInterval != "":
 		return ts.Spec.When.Jira.PollInterval
+	case ts.Spec.When.GitLab != nil && ts.Spec.When.GitLab.PollInterval != "":
+		return ts.Spec.When.GitLab.PollInterval
 	}
 	return "5m"
 }
@@ -297,6 +360,21 @@ func printTaskSpawnerDetail(w io.Writer, ts *kelos.TaskSpawner) {
 		if jira.JQL != "" {
 			printField(w, "JQL", jira.JQL)
 		}
+	} else if ts.Spec.When.GitLab != nil {
+		gl := ts.Spec.When.GitLab
+		printField(w, "Source", "GitLab")
+		if gl.Project != "" {
+			printField(w, "Project", gl.Project)
+		}
+		if len(gl.Types) > 0 {
+			printField(w, "Types", fmt.Sprintf("%v", gl.Types))
+		}
+		if gl.State != "" {
+			printField(w, "State", gl.State)
+		}
+		if len(gl.Labels) > 0 {
+			printField(w, "Labels", fmt.Sprintf("%v", gl.Labels))
+		}
 	} else if ts.Spec.When.Cron != nil {
 		printField(w, "Source", "Cron")
 		printField(w, "Schedule", ts.Spec.When.Cron.Schedule)
@@ -487,9 +565,35 @@ func printWorkspaceDetail(w io.Writer, ws *kelos.Workspace) {
 	if ws.Spec.Ref != "" {
 		printField(w, "Ref", ws.Spec.Ref)
 	}
+	if ws.Spec.Forge != "" {
+		printField(w, "Forge", string(ws.Spec.Forge))
+	}
 	if ws.Spec.SecretRef != nil {
 		printField(w, "Secret", ws.Spec.SecretRef.Name)
 	}
+	if len(ws.Spec.Repositories) > 0 {
+		repos := make([]string, 0, len(ws.Spec.Repositories))
+		for _, repo := range ws.Spec.Repositories {
+			path := repo.Path
+			if path == "" {
+				path = repo.Name
+			}
+			repos = append(repos, fmt.Sprintf("%s=%s (%s)", repo.Name, repo.Repo, path))
+		}
+		printField(w, "Repositories", strings.Join(repos, ", "))
+	}
+	if ready := apiMeta.FindStatusCondition(ws.Status.Conditions, kelos.WorkspaceConditionReady); ready != nil {
+		printField(w, "Ready", fmt.Sprintf("%s (%s): %s", ready.Status, ready.Reason, ready.Message))
+	}
+	if ws.Status.DefaultBranch != "" {
+		printField(w, "Default Branch", ws.Status.DefaultBranch)
+	}
+	if len(ws.Status.CredentialScopes) > 0 {
+		printField(w, "Credential Scopes", strings.Join(ws.Status.CredentialScopes, ", "))
+	}
+	if ws.Status.LastValidationTime != nil {
+		printField(w, "Last Validated", ws.Status.LastValidationTime.Time.Format(time.RFC3339))
+	}
 }
 
 func printAgentConfigTable(w io.Writer, configs []kelos.AgentConfig, allNamespaces bool) {
@@ -516,6 +620,17 @@ func printAgentConfigTable(w io.Writer, configs []kelos.AgentConfig, allNamespac
 func printAgentConfigDetail(w io.Writer, ac *kelos.AgentConfig) {
 	printField(w, "Name", ac.Name)
 	printField(w, "Namespace", ac.Namespace)
+	if ac.Spec.Extends != nil {
+		printField(w, "Extends", ac.Spec.Extends.Name)
+	}
+	if len(ac.Spec.Overrides) > 0 {
+		agentTypes := make([]string, 0, len(ac.Spec.Overrides))
+		for agentType := range ac.Spec.Overrides {
+			agentTypes = append(agentTypes, agentType)
+		}
+		sort.Strings(agentTypes)
+		printField(w, "Overrides", strings.Join(agentTypes, ", "))
+	}
 	if ac.Spec.AgentsMD != "" {
 		// Truncate long agents-md content for display
 		md := ac.Spec.AgentsMD
@@ -575,6 +690,31 @@ func printAgentConfigDetail(w io.Writer, ac *kelos.AgentConfig) {
 			}
 		}
 	}
+	if ready := apiMeta.FindStatusCondition(ac.Status.Conditions, kelos.AgentConfigConditionReady); ready != nil {
+		printField(w, "Ready", fmt.Sprintf("%s (%s): %s", ready.Status, ready.Reason, ready.Message))
+	}
+	if ac.Status.PluginBytes > 0 {
+		printField(w, "Plugin Bytes", fmt.Sprintf("%d", ac.Status.PluginBytes))
+	}
+	for i, user := range ac.Status.UsedBy {
+		detail := user.Kind + "/" + user.Name
+		if i == 0 {
+			printField(w, "Used By", detail)
+		} else {
+			fmt.Fprintf(w, "%-20s%s\n", "", detail)
+		}
+	}
+	var total int32
+	for _, count := range ac.Status.UsedByCounts {
+		total += count.Count
+	}
+	if more := total - int32(len(ac.Status.UsedBy)); more > 0 {
+		var counts []string
+		for _, count := range ac.Status.UsedByCounts {
+			counts = append(counts, fmt.Sprintf("%d %s", count.Count, count.Kind))
+		}
+		fmt.Fprintf(w, "%-20s... and %d more (%s)\n", "", more, strings.Join(counts, ", "))
+	}
 }
 
 func printField(w io.Writer, label, value string) {
@@ -149,7 +149,7 @@ func TestPrintTaskTable(t *testing.T) {
 	printTaskTable(&buf, tasks, false)
 	output := buf.String()
 
-	for _, header := range []string{"NAME", "TYPE", "PHASE", "BRANCH", "WORKSPACE", "AGENT CONFIG", "DURATION", "AGE"} {
+	for _, header := range []string{"NAME", "TYPE", "PHASE", "QUEUE", "BRANCH", "WORKSPACE", "AGENT CONFIG", "DURATION", "AGE"} {
 		if !strings.Contains(output, header) {
 			t.Errorf("expected header %s in output, got %q", header, output)
 		}
@@ -164,6 +164,42 @@ func TestPrintTaskTable(t *testing.T) {
 	}
 }
 
+func TestPrintTaskTableQueuePosition(t *testing.T) {
+	position := int32(3)
+	stalePosition := int32(1)
+	tasks := []kelos.Task{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "queued-task", CreationTimestamp: metav1.Now()},
+			Spec:       kelos.TaskSpec{Type: "claude-code"},
+			Status: kelos.TaskStatus{
+				Phase:         kelos.TaskPhaseWaiting,
+				QueuePosition: &position,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "running-task", CreationTimestamp: metav1.Now()},
+			Spec:       kelos.TaskSpec{Type: "claude-code"},
+			Status: kelos.TaskStatus{
+				Phase:         kelos.TaskPhaseRunning,
+				QueuePosition: &stalePosition,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printTaskTable(&buf, tasks, false)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) < 4 || fields[3] != "3" {
+		t.Errorf("expected queue position 3 for the waiting task, got %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) < 4 || fields[3] != "-" {
+		t.Errorf("expected no queue position for the running task, got %q", lines[2])
+	}
+}
+
 func TestPrintTaskTableCanonicalWorkerFields(t *testing.T) {
 	now := time.Now()
 	tasks := []kelos.Task{
@@ -1051,6 +1087,59 @@ func TestPrintTaskSpawnerDetail(t *testing.T) {
 	}
 }
 
+func TestPrintWorkspaceDetailRepositories(t *testing.T) {
+	ws := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "multi-repo", Namespace: "default"},
+		Spec: kelos.WorkspaceSpec{
+			Repo: "https://github.com/org/service.git",
+			Repositories: []kelos.WorkspaceRepository{
+				{Name: "proto", Repo: "https://github.com/org/proto.git"},
+				{Name: "deploy", Repo: "https://github.com/org/deploy.git", Path: "ops"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printWorkspaceDetail(&buf, ws)
+	output := buf.String()
+
+	want := "proto=https://github.com/org/proto.git (proto), deploy=https://github.com/org/deploy.git (ops)"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected repositories %q in output, got %q", want, output)
+	}
+}
+
+func TestPrintWorkspaceDetailStatus(t *testing.T) {
+	ws := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-workspace", Namespace: "default"},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git", Ref: "release-9"},
+		Status: kelos.WorkspaceStatus{
+			DefaultBranch:    "main",
+			CredentialScopes: []string{"repo", "workflow"},
+			Conditions: []metav1.Condition{{
+				Type:    kelos.WorkspaceConditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "RefNotFound",
+				Message: `RefResolved: Ref "release-9" is not a branch or tag`,
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	printWorkspaceDetail(&buf, ws)
+	output := buf.String()
+
+	for _, want := range []string{
+		`False (RefNotFound): RefResolved: Ref "release-9" is not a branch or tag`,
+		"Default Branch",
+		"repo, workflow",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got %q", want, output)
+		}
+	}
+}
+
 func TestPrintWorkspaceDetailWithoutOptionalFields(t *testing.T) {
 	ws := &kelos.Workspace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1398,6 +1487,86 @@ func TestPrintAgentConfigDetail(t *testing.T) {
 	}
 }
 
+func TestPrintAgentConfigDetailComposition(t *testing.T) {
+	ac := &kelos.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: kelos.AgentConfigSpec{
+			Extends: &kelos.AgentConfigReference{Name: "org"},
+			Overrides: map[string]kelos.AgentConfigOverride{
+				"opencode": {AgentsMD: "# OpenCode"},
+				"codex":    {AgentsMD: "# Codex"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printAgentConfigDetail(&buf, ac)
+	output := buf.String()
+
+	for _, expected := range []string{"Extends:", "org", "Overrides:", "codex, opencode"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in detail output, got %q", expected, output)
+		}
+	}
+}
+
+func TestPrintAgentConfigDetailStatus(t *testing.T) {
+	ac := &kelos.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Status: kelos.AgentConfigStatus{
+			PluginBytes: 2048,
+			UsedBy: []kelos.AgentConfigUser{
+				{Kind: "Task", Name: "fix-bug"},
+				{Kind: "WorkerPool", Name: "pool"},
+			},
+			Conditions: []metav1.Condition{{
+				Type:    kelos.AgentConfigConditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "SecretNotFound",
+				Message: `ReferencesResolved: secret "skills-token" not found`,
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	printAgentConfigDetail(&buf, ac)
+	output := buf.String()
+
+	for _, expected := range []string{
+		"Ready:", "False (SecretNotFound)", `secret "skills-token" not found`,
+		"Plugin Bytes:", "2048",
+		"Used By:", "Task/fix-bug", "WorkerPool/pool",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in detail output, got %q", expected, output)
+		}
+	}
+}
+
+func TestPrintAgentConfigDetailTruncatedUsers(t *testing.T) {
+	ac := &kelos.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Status: kelos.AgentConfigStatus{
+			UsedBy: []kelos.AgentConfigUser{
+				{Kind: "Task", Name: "task-1"},
+				{Kind: "Task", Name: "task-2"},
+			},
+			UsedByCounts: []kelos.AgentConfigUserCount{
+				{Kind: "Task", Count: 30},
+				{Kind: "WorkerPool", Count: 1},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printAgentConfigDetail(&buf, ac)
+	output := buf.String()
+
+	if want := "... and 29 more (30 Task, 1 WorkerPool)"; !strings.Contains(output, want) {
+		t.Errorf("expected %q in detail output, got %q", want, output)
+	}
+}
+
 func TestPrintAgentConfigDetailMinimal(t *testing.T) {
 	ac := &kelos.AgentConfig{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1415,9 +1584,13 @@ func TestPrintAgentConfigDetailMinimal(t *testing.T) {
 		t.Errorf("expected config name in output, got %q", output)
 	}
 	for _, absent := range []string{
+		"Extends:",
+		"Overrides:",
 		"Agents MD:",
 		"Plugins:",
 		"MCP Servers:",
+		"Ready:",
+		"Used By:",
 	} {
 		if strings.Contains(output, absent) {
 			t.Errorf("expected no %s field for minimal config, got %q", absent, output)
@@ -1425,6 +1598,74 @@ func TestPrintAgentConfigDetailMinimal(t *testing.T) {
 	}
 }
 
+func TestPrintTaskDetailRetryAttempts(t *testing.T) {
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "retry-task",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   "claude-code",
+			Prompt: "Fix the bug",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "secret"},
+			},
+			RetryPolicy: &kelos.RetryPolicy{MaxAttempts: 3},
+		},
+		Status: kelos.TaskStatus{
+			Phase:   kelos.TaskPhaseRunning,
+			Attempt: 3,
+			Attempts: []kelos.TaskAttempt{
+				{Attempt: 1, Reason: kelos.TaskFailureReasonAgentError, Message: "Job has reached the specified backoff limit"},
+				{Attempt: 2, Reason: kelos.TaskFailureReasonDeadlineExceeded},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printTaskDetail(&buf, task)
+	output := buf.String()
+
+	for _, expected := range []string{
+		"Attempt:            3/3",
+		"Previous Attempts:  #1 AgentError: Job has reached the specified backoff limit",
+		"                    #2 DeadlineExceeded",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestPrintTaskDetailContinuation(t *testing.T) {
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "follow-up",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:         "claude-code",
+			Prompt:       "Also add a test",
+			ContinueFrom: "fix-bug",
+		},
+		Status: kelos.TaskStatus{
+			Phase:                 kelos.TaskPhaseRunning,
+			ConversationClaimName: "fix-bug-conversation",
+		},
+	}
+
+	var buf bytes.Buffer
+	printTaskDetail(&buf, task)
+	output := buf.String()
+
+	for _, want := range []string{"fix-bug-conversation", "Continues:", "Conversation:"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
 func TestPrintTaskDetailMinimal(t *testing.T) {
 	task := &kelos.Task{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1457,6 +1698,8 @@ func TestPrintTaskDetailMinimal(t *testing.T) {
 		"Image:",
 		"Branch:",
 		"Depends On:",
+		"Continues:",
+		"Conversation:",
 		"Workspace:",
 		"Agent Configs:",
 		"TTL:",
@@ -1591,3 +1834,41 @@ func TestPrintTaskSpawnerDetailShowsPerSourcePollInterval(t *testing.T) {
 		t.Errorf("expected deprecated top-level poll interval (5m) not to appear in line %q", pollLine)
 	}
 }
+
+func TestPrintTaskDetailBranchLockHolder(t *testing.T) {
+	position := int32(2)
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "queued-task",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   "claude-code",
+			Prompt: "Do something",
+			Branch: "feature/queue",
+		},
+		Status: kelos.TaskStatus{
+			Phase:            kelos.TaskPhaseWaiting,
+			Message:          `Waiting for branch "feature/queue" (locked by holder-task)`,
+			QueuePosition:    &position,
+			BranchLockHolder: "holder-task",
+		},
+	}
+
+	var buf bytes.Buffer
+	printTaskDetail(&buf, task)
+	output := buf.String()
+
+	for _, want := range []string{"Queue Position:", "Branch Locked By:", "holder-task"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+
+	task.Status.Phase = kelos.TaskPhaseRunning
+	buf.Reset()
+	printTaskDetail(&buf, task)
+	if output := buf.String(); strings.Contains(output, "Branch Locked By:") {
+		t.Errorf("expected no branch lock holder for a running task, got:\n%s", output)
+	}
+}
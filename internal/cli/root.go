@@ -47,6 +47,9 @@ func NewRootCommand() *cobra.Command {
 		newDeleteCommand(cfg),
 		newSuspendCommand(cfg),
 		newResumeCommand(cfg),
+		newCancelCommand(cfg),
+		newArtifactsCommand(cfg),
+		newApproveCommand(cfg),
 		newInitCommand(cfg),
 		newInstallCommand(cfg),
 		newUninstallCommand(cfg),
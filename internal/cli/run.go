@@ -55,6 +55,9 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 		branch          string
 		from            string
 		valuesFile      string
+		continueRef     string
+		rerunRef        string
+		persistConv     bool
 	)
 
 	cmd := &cobra.Command{
@@ -95,6 +98,78 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 				}
 				return nil
 			}
+			if continueRef != "" {
+				if err := validateContinueRunFlags(cmd); err != nil {
+					return err
+				}
+				sourceName, err := parseTaskReference(continueRef)
+				if err != nil {
+					return err
+				}
+				prompt, err = resolveRunPrompt(cmd, prompt, promptFile)
+				if err != nil {
+					return err
+				}
+				cl, ns, err := cfg.NewClient()
+				if err != nil {
+					return err
+				}
+				task, err := buildContinuationTask(cmd.Context(), cl, ns, sourceName, continueTaskOptions{
+					Name:   name,
+					Prompt: prompt,
+					Model:  model,
+					Effort: effort,
+				})
+				if err != nil {
+					return err
+				}
+				if dryRun {
+					return printYAML(os.Stdout, task)
+				}
+				if err := cl.Create(cmd.Context(), task); err != nil {
+					return fmt.Errorf("creating task: %w", err)
+				}
+				fmt.Fprintf(os.Stdout, "task/%s created\n", task.Name)
+				if watch {
+					return watchTask(cmd.Context(), cl, task.Name, ns, os.Stdout, os.Stderr)
+				}
+				return nil
+			}
+			if rerunRef != "" {
+				if err := validateRerunFlags(cmd); err != nil {
+					return err
+				}
+				sourceName, err := parseTaskReference(rerunRef)
+				if err != nil {
+					return err
+				}
+				opts := rerunTaskOptions{Name: name}
+				if cmd.Flags().Changed("prompt") || cmd.Flags().Changed("prompt-file") {
+					opts.Prompt, err = resolveRunPrompt(cmd, prompt, promptFile)
+					if err != nil {
+						return err
+					}
+				}
+				cl, ns, err := cfg.NewClient()
+				if err != nil {
+					return err
+				}
+				task, err := buildRerunTask(cmd.Context(), cl, ns, sourceName, opts)
+				if err != nil {
+					return err
+				}
+				if dryRun {
+					return printYAML(os.Stdout, task)
+				}
+				if err := cl.Create(cmd.Context(), task); err != nil {
+					return fmt.Errorf("creating task: %w", err)
+				}
+				fmt.Fprintf(os.Stdout, "task/%s created\n", task.Name)
+				if watch {
+					return watchTask(cmd.Context(), cl, task.Name, ns, os.Stdout, os.Stderr)
+				}
+				return nil
+			}
 			if cmd.Flags().Changed("values") {
 				return fmt.Errorf("--values requires --from")
 			}
@@ -123,26 +198,10 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 				}
 			}
 
-			if !cmd.Flags().Changed("prompt") && !cmd.Flags().Changed("prompt-file") {
-				return fmt.Errorf("either --prompt or --prompt-file is required")
-			}
-			if cmd.Flags().Changed("prompt-file") {
-				var err error
-				if promptFile == "-" {
-					data, readErr := io.ReadAll(os.Stdin)
-					if readErr != nil {
-						return fmt.Errorf("reading prompt from stdin: %w", readErr)
-					}
-					prompt = strings.TrimRight(string(data), "\n")
-				} else {
-					prompt, err = resolveContent("@" + promptFile)
-					if err != nil {
-						return fmt.Errorf("resolving prompt file: %w", err)
-					}
-				}
-				if prompt == "" {
-					return fmt.Errorf("prompt file is empty")
-				}
+			var err error
+			prompt, err = resolveRunPrompt(cmd, prompt, promptFile)
+			if err != nil {
+				return err
 			}
 
 			// Auto-create secret from token if no explicit secret is set.
@@ -296,6 +355,9 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 			if branch != "" {
 				task.Spec.Branch = branch
 			}
+			if persistConv {
+				task.Spec.Conversation = &kelos.TaskConversation{}
+			}
 
 			if workspace != "" {
 				task.Spec.WorkspaceRef = &kelos.WorkspaceReference{
@@ -397,7 +459,7 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&prompt, "prompt", "p", "", "task prompt (required unless --prompt-file or --from is set)")
+	cmd.Flags().StringVarP(&prompt, "prompt", "p", "", "task prompt (required unless --prompt-file, --from or --rerun is set)")
 	cmd.Flags().StringVar(&promptFile, "prompt-file", "", "read task prompt from a file (use - for stdin)")
 	cmd.Flags().StringVarP(&agentType, "type", "t", "claude-code", "agent type (claude-code, codex, gemini, opencode, cursor)")
 	cmd.Flags().StringVar(&secret, "secret", "", "secret name with credentials (overrides oauthToken/apiKey in config)")
@@ -417,6 +479,9 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 	cmd.Flags().StringVar(&branch, "branch", "", "Git branch to work on")
 	cmd.Flags().StringVar(&from, "from", "", "TaskSpawner reference in taskspawner/name form")
 	cmd.Flags().StringVarP(&valuesFile, "values", "f", "", "template values file in YAML or JSON format (use - for stdin)")
+	cmd.Flags().StringVar(&continueRef, "continue", "", "continue the conversation of a finished Task in task/name form")
+	cmd.Flags().StringVar(&rerunRef, "rerun", "", "run a Task again with its resolved Workspace, AgentConfig, image and model, in task/name form")
+	cmd.Flags().BoolVar(&persistConv, "persist-conversation", false, "persist the agent conversation so that it can be continued with --continue")
 
 	cmd.MarkFlagsMutuallyExclusive("prompt", "prompt-file")
 
@@ -426,6 +491,34 @@ func newRunCommand(cfg *ClientConfig) *cobra.Command {
 	return cmd
 }
 
+// resolveRunPrompt returns the prompt given with --prompt or read from
+// --prompt-file.
+func resolveRunPrompt(cmd *cobra.Command, prompt, promptFile string) (string, error) {
+	if !cmd.Flags().Changed("prompt") && !cmd.Flags().Changed("prompt-file") {
+		return "", fmt.Errorf("either --prompt or --prompt-file is required")
+	}
+	if !cmd.Flags().Changed("prompt-file") {
+		return prompt, nil
+	}
+	if promptFile == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading prompt from stdin: %w", err)
+		}
+		prompt = strings.TrimRight(string(data), "\n")
+	} else {
+		var err error
+		prompt, err = resolveContent("@" + promptFile)
+		if err != nil {
+			return "", fmt.Errorf("resolving prompt file: %w", err)
+		}
+	}
+	if prompt == "" {
+		return "", fmt.Errorf("prompt file is empty")
+	}
+	return prompt, nil
+}
+
 func watchTask(ctx context.Context, cl client.Client, name, namespace string, out, errOut io.Writer) error {
 	var lastPhase kelos.TaskPhase
 	for {
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+type continueTaskOptions struct {
+	Name   string
+	Prompt string
+	Model  string
+	Effort string
+}
+
+// validateContinueRunFlags rejects flags that would change what the
+// continued conversation runs on. The continuation inherits the agent,
+// credentials, workspace, branch and pod overrides of the continued Task.
+func validateContinueRunFlags(cmd *cobra.Command) error {
+	incompatible := []string{
+		"from",
+		"values",
+		"rerun",
+		"type",
+		"secret",
+		"credential-type",
+		"image",
+		"workspace",
+		"yes",
+		"timeout",
+		"env",
+		"agent-config",
+		"depends-on",
+		"branch",
+		"persist-conversation",
+	}
+	for _, flag := range incompatible {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s cannot be used with --continue", flag)
+		}
+	}
+	return nil
+}
+
+func parseTaskReference(ref string) (string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid Task reference %q: expected task/name", ref)
+	}
+	switch parts[0] {
+	case "task", "tasks":
+		return parts[1], nil
+	default:
+		return "", fmt.Errorf("invalid Task reference %q: expected task/name", ref)
+	}
+}
+
+// buildContinuationTask returns a Task that continues the agent conversation
+// of the named Task with a follow-up prompt. It copies the spec of the
+// continued Task so that the follow-up runs with the same agent, workspace
+// and branch.
+func buildContinuationTask(ctx context.Context, cl client.Client, namespace, sourceName string, opts continueTaskOptions) (*kelos.Task, error) {
+	var source kelos.Task
+	if err := cl.Get(ctx, client.ObjectKey{Name: sourceName, Namespace: namespace}, &source); err != nil {
+		return nil, fmt.Errorf("getting task %s: %w", sourceName, err)
+	}
+	if source.Spec.WorkerPoolRef != nil {
+		return nil, fmt.Errorf("task %s runs on a WorkerPool, which does not persist conversations", sourceName)
+	}
+	if source.Spec.Conversation == nil && source.Spec.ContinueFrom == "" {
+		return nil, fmt.Errorf("task %s did not persist its conversation; run it with --persist-conversation to continue it later", sourceName)
+	}
+
+	spec := source.Spec.DeepCopy()
+	spec.Prompt = opts.Prompt
+	spec.ContinueFrom = source.Name
+	spec.Conversation = nil
+	spec.DependsOn = nil
+	if spec.Branch == "" {
+		spec.Branch = source.Status.Results["branch"]
+	}
+	if opts.Model != "" {
+		spec.Model = opts.Model
+	}
+	if opts.Effort != "" {
+		spec.Effort = opts.Effort
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = suffixedTaskName(source.Name, "-continue-", rand.String(5))
+	}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: *spec,
+	}
+	task.SetGroupVersionKind(kelos.GroupVersion.WithKind("Task"))
+	return task, nil
+}
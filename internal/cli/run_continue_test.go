@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func testContinuedTask(name string) *kelos.Task {
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"kelos.dev/taskspawner": "spawner"},
+		},
+		Spec: kelos.TaskSpec{
+			Type:         "claude-code",
+			Prompt:       "Fix the bug",
+			Credentials:  &kelos.Credentials{Type: kelos.CredentialTypeOAuth, SecretRef: &kelos.SecretReference{Name: "creds"}},
+			Model:        "opus",
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+			DependsOn:    []string{"setup"},
+			Conversation: &kelos.TaskConversation{},
+		},
+		Status: kelos.TaskStatus{
+			Phase:   kelos.TaskPhaseSucceeded,
+			Results: map[string]string{"branch": "kelos/fix-bug"},
+		},
+	}
+}
+
+func TestBuildContinuationTask(t *testing.T) {
+	source := testContinuedTask("fix-bug")
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+
+	task, err := buildContinuationTask(context.Background(), cl, "default", source.Name, continueTaskOptions{
+		Prompt: "Also add a test",
+		Effort: "high",
+	})
+	if err != nil {
+		t.Fatalf("buildContinuationTask: %v", err)
+	}
+
+	if !strings.HasPrefix(task.Name, "fix-bug-continue-") {
+		t.Errorf("Name = %q, want a continue suffix", task.Name)
+	}
+	if len(task.Labels) != 0 {
+		t.Errorf("Labels = %v, want none copied from the continued Task", task.Labels)
+	}
+	if task.Spec.Prompt != "Also add a test" {
+		t.Errorf("Prompt = %q", task.Spec.Prompt)
+	}
+	if task.Spec.ContinueFrom != source.Name {
+		t.Errorf("ContinueFrom = %q, want %q", task.Spec.ContinueFrom, source.Name)
+	}
+	if task.Spec.Branch != "kelos/fix-bug" {
+		t.Errorf("Branch = %q, want the branch of the continued Task", task.Spec.Branch)
+	}
+	if task.Spec.Conversation != nil {
+		t.Errorf("Conversation = %+v, want nil", task.Spec.Conversation)
+	}
+	if task.Spec.DependsOn != nil {
+		t.Errorf("DependsOn = %v, want nil", task.Spec.DependsOn)
+	}
+	if task.Spec.Model != "opus" || task.Spec.Effort != "high" {
+		t.Errorf("Model = %q, Effort = %q; want the inherited model and the effort override", task.Spec.Model, task.Spec.Effort)
+	}
+	if task.Spec.WorkspaceRef == nil || task.Spec.WorkspaceRef.Name != "ws" {
+		t.Errorf("WorkspaceRef = %+v, want the workspace of the continued Task", task.Spec.WorkspaceRef)
+	}
+	if task.Spec.Credentials == nil || task.Spec.Credentials.SecretRef == nil || task.Spec.Credentials.SecretRef.Name != "creds" {
+		t.Errorf("Credentials = %+v, want the credentials of the continued Task", task.Spec.Credentials)
+	}
+}
+
+func TestBuildContinuationTaskOfContinuation(t *testing.T) {
+	source := testContinuedTask("fix-bug-continue-abcde")
+	source.Spec.Conversation = nil
+	source.Spec.ContinueFrom = "fix-bug"
+	source.Spec.Branch = "kelos/fix-bug"
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+
+	task, err := buildContinuationTask(context.Background(), cl, "default", source.Name, continueTaskOptions{
+		Name:   "follow-up",
+		Prompt: "One more thing",
+	})
+	if err != nil {
+		t.Fatalf("buildContinuationTask: %v", err)
+	}
+	if task.Name != "follow-up" {
+		t.Errorf("Name = %q, want follow-up", task.Name)
+	}
+	if task.Spec.ContinueFrom != source.Name {
+		t.Errorf("ContinueFrom = %q, want %q", task.Spec.ContinueFrom, source.Name)
+	}
+}
+
+func TestBuildContinuationTaskRequiresPersistedConversation(t *testing.T) {
+	source := testContinuedTask("fix-bug")
+	source.Spec.Conversation = nil
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+
+	_, err := buildContinuationTask(context.Background(), cl, "default", source.Name, continueTaskOptions{Prompt: "Continue"})
+	if err == nil || !strings.Contains(err.Error(), "did not persist its conversation") {
+		t.Fatalf("error = %v, want a missing conversation error", err)
+	}
+}
+
+func TestParseTaskReference(t *testing.T) {
+	for _, ref := range []string{"task/example", "tasks/example"} {
+		name, err := parseTaskReference(ref)
+		if err != nil {
+			t.Fatalf("parseTaskReference(%q): %v", ref, err)
+		}
+		if name != "example" {
+			t.Errorf("parseTaskReference(%q) = %q", ref, name)
+		}
+	}
+	for _, ref := range []string{"example", "taskspawner/example", "task/"} {
+		if _, err := parseTaskReference(ref); err == nil {
+			t.Errorf("parseTaskReference(%q): expected an error", ref)
+		}
+	}
+}
+
+func TestRunContinueRejectsTaskDefinitionFlags(t *testing.T) {
+	cmd := newRunCommand(&ClientConfig{})
+	cmd.SetArgs([]string{"--continue", "task/example", "--prompt", "more", "--workspace", "other"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--workspace cannot be used with --continue") {
+		t.Fatalf("error = %v, want incompatible flag error", err)
+	}
+}
+
+func TestRunContinueRequiresPrompt(t *testing.T) {
+	cmd := newRunCommand(&ClientConfig{})
+	cmd.SetArgs([]string{"--continue", "task/example"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "either --prompt or --prompt-file is required") {
+		t.Fatalf("error = %v, want prompt requirement", err)
+	}
+}
@@ -54,6 +54,9 @@ func validateTaskSpawnerRunFlags(cmd *cobra.Command) error {
 		"agent-config",
 		"depends-on",
 		"branch",
+		"continue",
+		"rerun",
+		"persist-conversation",
 	}
 	for _, flag := range incompatible {
 		if cmd.Flags().Changed(flag) {
@@ -233,9 +236,14 @@ func taskSpawnerUpstreamRepo(spawner *kelos.TaskSpawner) string {
 }
 
 func manualTaskName(spawnerName, suffix string) string {
-	const separator = "-manual-"
+	return suffixedTaskName(spawnerName, "-manual-", suffix)
+}
+
+// suffixedTaskName joins name, separator and suffix, trimming name so that
+// the result fits a DNS label.
+func suffixedTaskName(name, separator, suffix string) string {
 	maxPrefix := 63 - len(separator) - len(suffix)
-	prefix := spawnerName
+	prefix := name
 	if len(prefix) > maxPrefix {
 		prefix = strings.TrimRight(prefix[:maxPrefix], "-.")
 	}
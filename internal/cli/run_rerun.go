@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+type rerunTaskOptions struct {
+	Name   string
+	Prompt string
+}
+
+// validateRerunFlags rejects flags that would change what the rerun runs
+// with. The rerun inherits the spec of the rerun Task and runs with its
+// resolved snapshot.
+func validateRerunFlags(cmd *cobra.Command) error {
+	incompatible := []string{
+		"from",
+		"values",
+		"continue",
+		"type",
+		"secret",
+		"credential-type",
+		"model",
+		"effort",
+		"image",
+		"workspace",
+		"yes",
+		"timeout",
+		"env",
+		"agent-config",
+		"depends-on",
+		"branch",
+		"persist-conversation",
+	}
+	for _, flag := range incompatible {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s cannot be used with --rerun", flag)
+		}
+	}
+	return nil
+}
+
+// buildRerunTask returns a Task that runs the named Task again with the
+// Workspace, AgentConfig, image and model recorded in its resolved
+// snapshot, regardless of later changes to its Workspace and AgentConfigs.
+// The prompt of the rerun Task is kept unless opts.Prompt is set.
+func buildRerunTask(ctx context.Context, cl client.Client, namespace, sourceName string, opts rerunTaskOptions) (*kelos.Task, error) {
+	var source kelos.Task
+	if err := cl.Get(ctx, client.ObjectKey{Name: sourceName, Namespace: namespace}, &source); err != nil {
+		return nil, fmt.Errorf("getting task %s: %w", sourceName, err)
+	}
+	if source.Spec.WorkerPoolRef != nil {
+		return nil, fmt.Errorf("task %s runs on a WorkerPool, which does not record a resolved snapshot", sourceName)
+	}
+	if source.Status.Resolved == nil {
+		return nil, fmt.Errorf("task %s has no resolved snapshot yet; it is recorded when the Task's Job is created", sourceName)
+	}
+
+	spec := source.Spec.DeepCopy()
+	spec.Resolved = source.Status.Resolved.DeepCopy()
+	if opts.Prompt != "" {
+		spec.Prompt = opts.Prompt
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = suffixedTaskName(source.Name, "-rerun-", rand.String(5))
+	}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: *spec,
+	}
+	task.SetGroupVersionKind(kelos.GroupVersion.WithKind("Task"))
+	return task, nil
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func testResolvedSnapshot(hash string) *kelos.TaskResolvedSnapshot {
+	return &kelos.TaskResolvedSnapshot{
+		Hash:          hash,
+		Type:          "claude-code",
+		Image:         "ghcr.io/kelos-dev/claude-code:v1",
+		Model:         "opus",
+		WorkspaceName: "ws",
+		ConfigMapName: "kelos-resolved-task-uid",
+	}
+}
+
+func TestBuildRerunTask(t *testing.T) {
+	source := testContinuedTask("fix-bug")
+	source.Status.Resolved = testResolvedSnapshot("abc123")
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+
+	task, err := buildRerunTask(context.Background(), cl, "default", source.Name, rerunTaskOptions{})
+	if err != nil {
+		t.Fatalf("buildRerunTask: %v", err)
+	}
+	if !strings.HasPrefix(task.Name, "fix-bug-rerun-") {
+		t.Errorf("Name = %q, want a rerun suffix", task.Name)
+	}
+	if len(task.Labels) != 0 {
+		t.Errorf("Labels = %v, want none copied from the rerun Task", task.Labels)
+	}
+	if task.Spec.Prompt != source.Spec.Prompt {
+		t.Errorf("Prompt = %q, want %q", task.Spec.Prompt, source.Spec.Prompt)
+	}
+	if !reflect.DeepEqual(task.Spec.Resolved, source.Status.Resolved) {
+		t.Errorf("Resolved = %+v, want the snapshot of the rerun Task", task.Spec.Resolved)
+	}
+	if task.Spec.WorkspaceRef == nil || task.Spec.WorkspaceRef.Name != "ws" {
+		t.Errorf("WorkspaceRef = %+v, want the spec of the rerun Task", task.Spec.WorkspaceRef)
+	}
+
+	task, err = buildRerunTask(context.Background(), cl, "default", source.Name, rerunTaskOptions{Name: "again", Prompt: "Fix it again"})
+	if err != nil {
+		t.Fatalf("buildRerunTask: %v", err)
+	}
+	if task.Name != "again" || task.Spec.Prompt != "Fix it again" {
+		t.Errorf("Name, Prompt = %q, %q", task.Name, task.Spec.Prompt)
+	}
+}
+
+func TestBuildRerunTaskWithoutSnapshot(t *testing.T) {
+	source := testContinuedTask("fix-bug")
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+
+	_, err := buildRerunTask(context.Background(), cl, "default", source.Name, rerunTaskOptions{})
+	if err == nil || !strings.Contains(err.Error(), "has no resolved snapshot") {
+		t.Fatalf("Expected an error for a Task without a snapshot, got %v", err)
+	}
+}
+
+func TestGetTaskResolvedSnapshot(t *testing.T) {
+	task := testContinuedTask("fix-bug")
+	task.Status.Resolved = testResolvedSnapshot("current")
+	older := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	newer := metav1.NewTime(time.Now().Add(-time.Hour))
+	record := func(uid, hash string, completed metav1.Time) *kelos.TaskRecord {
+		return &kelos.TaskRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: uid, Namespace: "default"},
+			Spec: kelos.TaskRecordSpec{
+				TaskRef:        kelos.TaskReference{Name: "deleted"},
+				Phase:          kelos.TaskPhaseSucceeded,
+				CompletionTime: &completed,
+				Resolved:       testResolvedSnapshot(hash),
+			},
+		}
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		task,
+		record("uid-1", "older", older),
+		record("uid-2", "newer", newer),
+	).Build()
+	ctx := context.Background()
+
+	got, err := getTaskResolvedSnapshot(ctx, cl, "default", "fix-bug")
+	if err != nil || got.Hash != "current" {
+		t.Errorf("getTaskResolvedSnapshot(fix-bug) = %+v, %v, want the Task's snapshot", got, err)
+	}
+
+	// A deleted Task falls back to its most recent TaskRecord.
+	got, err = getTaskResolvedSnapshot(ctx, cl, "default", "deleted")
+	if err != nil || got.Hash != "newer" {
+		t.Errorf("getTaskResolvedSnapshot(deleted) = %+v, %v, want the newest record's snapshot", got, err)
+	}
+
+	if _, err := getTaskResolvedSnapshot(ctx, cl, "default", "missing"); err == nil {
+		t.Error("Expected an error for a missing Task")
+	}
+}
+
+func TestGetResolvedSnapshotView(t *testing.T) {
+	snapshot := testResolvedSnapshot("abc123")
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: snapshot.ConfigMapName, Namespace: "default"},
+		Data: map[string]string{
+			kelos.ResolvedSnapshotWorkspaceKey:   `{"repo":"https://github.com/org/repo.git"}`,
+			kelos.ResolvedSnapshotAgentConfigKey: `{"agentsMD":"Team rules"}`,
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+	ctx := context.Background()
+
+	var errOut bytes.Buffer
+	view, err := getResolvedSnapshotView(ctx, cl, "default", snapshot, &errOut)
+	if err != nil {
+		t.Fatalf("getResolvedSnapshotView() error = %v", err)
+	}
+	if view.Hash != "abc123" {
+		t.Errorf("Hash = %q, want %q", view.Hash, "abc123")
+	}
+	if view.Workspace == nil || view.Workspace.Repo != "https://github.com/org/repo.git" {
+		t.Errorf("Workspace = %+v, want the Workspace from the ConfigMap", view.Workspace)
+	}
+	if view.AgentConfig == nil || view.AgentConfig.AgentsMD != "Team rules" {
+		t.Errorf("AgentConfig = %+v, want the AgentConfig from the ConfigMap", view.AgentConfig)
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("Unexpected warning: %s", errOut.String())
+	}
+
+	// Without the ConfigMap only the snapshot is shown.
+	missing := testResolvedSnapshot("abc123")
+	missing.ConfigMapName = "kelos-resolved-gone"
+	view, err = getResolvedSnapshotView(ctx, cl, "default", missing, &errOut)
+	if err != nil {
+		t.Fatalf("getResolvedSnapshotView() error = %v", err)
+	}
+	if view.Workspace != nil || view.AgentConfig != nil {
+		t.Errorf("view = %+v, want only the snapshot", view)
+	}
+	if !strings.Contains(errOut.String(), "kelos-resolved-gone not found") {
+		t.Errorf("Warning = %q, want the missing ConfigMap reported", errOut.String())
+	}
+}
@@ -48,7 +48,7 @@ func newSessionCommand(cfg *ClientConfig) *cobra.Command {
 			return cmd.Help()
 		},
 	}
-	command.AddCommand(newSessionConnectCommand(cfg), newSessionResetCommand(cfg))
+	command.AddCommand(newSessionConnectCommand(cfg), newSessionResetCommand(cfg), newSessionFromTaskCommand(cfg))
 	return command
 }
 
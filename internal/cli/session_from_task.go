@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/sessionbuilder"
+)
+
+// taskTranscriptTailLines bounds the agent log lines read for the transcript
+// of a Job-backed Task.
+const taskTranscriptTailLines = int64(2000)
+
+func newSessionFromTaskCommand(cfg *ClientConfig) *cobra.Command {
+	var (
+		name         string
+		dryRun       bool
+		noTranscript bool
+	)
+	command := &cobra.Command{
+		Use:   "from-task NAME",
+		Short: "Open a Session that takes over from a finished Task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, namespace, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
+			var cs kubernetes.Interface
+			if !noTranscript {
+				clientset, _, err := cfg.NewClientset()
+				if err != nil {
+					return err
+				}
+				cs = clientset
+			}
+			session, err := buildSessionFromTask(cmd.Context(), cl, cs, namespace, args[0], name, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				return printYAML(cmd.OutOrStdout(), session)
+			}
+			if err := cl.Create(cmd.Context(), session); err != nil {
+				return fmt.Errorf("creating Session %q: %w", session.Name, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "session/%s created\n", session.Name)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Run 'kelos session connect %s' to take over.\n", session.Name)
+			return nil
+		},
+	}
+	command.Flags().StringVar(&name, "name", "", "Session name (defaults to NAME-session)")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "print the Session that would be created without submitting it")
+	command.Flags().BoolVar(&noTranscript, "no-transcript", false, "do not include the end of the Task's agent transcript in the initial prompt")
+	command.ValidArgsFunction = completeTaskNames(cfg)
+	return command
+}
+
+// buildSessionFromTask returns a Session that takes over from the named
+// Task. The end of the Task's agent transcript is read through cs; a nil
+// cs skips the transcript, and a transcript that cannot be read is reported
+// on warnings.
+func buildSessionFromTask(ctx context.Context, cl client.Client, cs kubernetes.Interface, namespace, taskName, sessionName string, warnings io.Writer) (*kelos.Session, error) {
+	var task kelos.Task
+	if err := cl.Get(ctx, client.ObjectKey{Name: taskName, Namespace: namespace}, &task); err != nil {
+		return nil, fmt.Errorf("getting task %s: %w", taskName, err)
+	}
+	if !isTerminalTaskPhase(task.Status.Phase) {
+		return nil, fmt.Errorf("task %s has not finished (phase: %s); cancel it or wait for it to finish", taskName, task.Status.Phase)
+	}
+
+	var pool *kelos.WorkerPool
+	if task.Spec.WorkerPoolRef != nil {
+		pool = &kelos.WorkerPool{}
+		if err := cl.Get(ctx, client.ObjectKey{Name: task.Spec.WorkerPoolRef.Name, Namespace: namespace}, pool); err != nil {
+			return nil, fmt.Errorf("getting WorkerPool %s of task %s: %w", task.Spec.WorkerPoolRef.Name, taskName, err)
+		}
+	}
+	worker := sessionbuilder.TaskWorker(&task, pool)
+
+	var transcript string
+	if cs != nil {
+		var err error
+		transcript, err = readTaskTranscript(ctx, cl, cs, namespace, &task, worker.Type)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: the Session starts without the transcript of task %s: %v\n", taskName, err)
+		}
+	}
+
+	if sessionName == "" {
+		sessionName = sessionbuilder.TaskSessionName(task.Name)
+	}
+	return sessionbuilder.FromTask(sessionName, namespace, &task, worker, transcript)
+}
+
+// readTaskTranscript returns the formatted agent output of a Task, as shown
+// by kelos logs.
+func readTaskTranscript(ctx context.Context, cl client.Client, cs kubernetes.Interface, namespace string, task *kelos.Task, agentType string) (string, error) {
+	podName, err := resolveTaskPodName(ctx, cl, namespace, task)
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", fmt.Errorf("task has no pod left to read logs from")
+	}
+
+	opts := &corev1.PodLogOptions{Container: kelos.AgentContainerName}
+	if task.Spec.WorkerPoolRef == nil {
+		tailLines := taskTranscriptTailLines
+		opts.TailLines = &tailLines
+	}
+	stream, err := cs.CoreV1().Pods(namespace).GetLogs(podName, opts).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading logs of pod %s: %w", podName, err)
+	}
+	defer stream.Close()
+
+	var logs io.Reader = stream
+	if task.Spec.WorkerPoolRef != nil {
+		var segment bytes.Buffer
+		if err := filterTaskLogSegment(stream, &segment, task.Name); err != nil {
+			return "", fmt.Errorf("reading logs of pod %s: %w", podName, err)
+		}
+		logs = &segment
+	}
+
+	var transcript bytes.Buffer
+	if err := formatAgentLogs(agentType, logs, &transcript, &transcript); err != nil {
+		return "", fmt.Errorf("formatting logs of pod %s: %w", podName, err)
+	}
+	return transcript.String(), nil
+}
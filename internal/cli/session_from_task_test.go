@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/sessionbuilder"
+)
+
+func testSessionSourceTask(phase kelos.TaskPhase) *kelos.Task {
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "fix-bug", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:         "codex",
+			Prompt:       "Fix the bug",
+			Credentials:  &kelos.Credentials{Type: kelos.CredentialTypeAPIKey, SecretRef: &kelos.SecretReference{Name: "creds"}},
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+			Branch:       "kelos/fix-bug",
+		},
+		Status: kelos.TaskStatus{Phase: phase},
+	}
+}
+
+func TestBuildSessionFromTask(t *testing.T) {
+	task := testSessionSourceTask(kelos.TaskPhaseFailed)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "fix-bug-abcde",
+		Namespace: "default",
+		Labels:    map[string]string{"kelos.dev/task": task.Name},
+	}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task, pod).Build()
+	// The fake clientset serves "fake logs" for every pod, which the Codex
+	// log parser passes through as plain text.
+	cs := kubefake.NewSimpleClientset()
+
+	var warnings bytes.Buffer
+	session, err := buildSessionFromTask(context.Background(), cl, cs, "default", task.Name, "", &warnings)
+	if err != nil {
+		t.Fatalf("buildSessionFromTask: %v", err)
+	}
+	if session.Name != "fix-bug-session" {
+		t.Errorf("Name = %q, want fix-bug-session", session.Name)
+	}
+	if session.Annotations[sessionbuilder.AnnotationFromTask] != task.Name {
+		t.Errorf("Annotations = %v, want the Task name", session.Annotations)
+	}
+	if session.Spec.Worker.Type != "codex" || session.Spec.Worker.WorkspaceRef == nil || session.Spec.Worker.WorkspaceRef.Name != "ws" {
+		t.Errorf("Worker = %+v, want the Task's agent and workspace", session.Spec.Worker)
+	}
+	if session.Spec.InitialBranch != "kelos/fix-bug" {
+		t.Errorf("InitialBranch = %q, want kelos/fix-bug", session.Spec.InitialBranch)
+	}
+	if !strings.Contains(session.Spec.InitialPrompt, "fake logs") {
+		t.Errorf("InitialPrompt does not include the transcript:\n%s", session.Spec.InitialPrompt)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Unexpected warnings: %s", warnings.String())
+	}
+}
+
+func TestBuildSessionFromTaskWarnsWithoutPod(t *testing.T) {
+	task := testSessionSourceTask(kelos.TaskPhaseSucceeded)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+
+	var warnings bytes.Buffer
+	session, err := buildSessionFromTask(context.Background(), cl, kubefake.NewSimpleClientset(), "default", task.Name, "takeover", &warnings)
+	if err != nil {
+		t.Fatalf("buildSessionFromTask: %v", err)
+	}
+	if session.Name != "takeover" {
+		t.Errorf("Name = %q, want takeover", session.Name)
+	}
+	if !strings.Contains(warnings.String(), "starts without the transcript") {
+		t.Errorf("warnings = %q, want a missing transcript warning", warnings.String())
+	}
+}
+
+func TestBuildSessionFromTaskRequiresFinishedTask(t *testing.T) {
+	task := testSessionSourceTask(kelos.TaskPhaseRunning)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+
+	_, err := buildSessionFromTask(context.Background(), cl, nil, "default", task.Name, "", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "has not finished") {
+		t.Fatalf("error = %v, want a not finished error", err)
+	}
+}
@@ -42,6 +42,7 @@ import (
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 	"github.com/kelos-dev/kelos/internal/sessionattachment"
+	"github.com/kelos-dev/kelos/internal/sessionbuilder"
 	"github.com/kelos-dev/kelos/internal/sessionreset"
 	"github.com/kelos-dev/kelos/internal/sessionruntime"
 	"github.com/kelos-dev/kelos/internal/sessionsuspend"
@@ -427,6 +428,10 @@ func (s *Server) api(writer http.ResponseWriter, request *http.Request) {
 		s.getTaskLogs(writer, request, parts[2], parts[3])
 		return
 	}
+	if len(parts) == 5 && parts[0] == "resources" && parts[1] == "tasks" && parts[4] == "session" && request.Method == http.MethodPost {
+		s.createSessionFromTask(writer, request, parts[2], parts[3])
+		return
+	}
 	if len(parts) < 3 || parts[0] != "sessions" {
 		writeError(writer, http.StatusNotFound, "not found")
 		return
@@ -1127,6 +1132,52 @@ func (s *Server) createSession(writer http.ResponseWriter, request *http.Request
 	writeJSON(writer, http.StatusCreated, summarize(session))
 }
 
+// createSessionFromTask opens a Session that takes over from a finished
+// Task, with the end of the Task's agent log as its transcript.
+func (s *Server) createSessionFromTask(writer http.ResponseWriter, request *http.Request, namespace, name string) {
+	var task kelos.Task
+	if err := s.client.Get(request.Context(), client.ObjectKey{Namespace: namespace, Name: name}, &task); err != nil {
+		writeKubernetesError(writer, fmt.Sprintf("getting Task %q", name), err)
+		return
+	}
+	if task.Status.Phase != kelos.TaskPhaseSucceeded && task.Status.Phase != kelos.TaskPhaseFailed {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("Task %q has not finished", name))
+		return
+	}
+
+	var pool *kelos.WorkerPool
+	if task.Spec.WorkerPoolRef != nil {
+		pool = &kelos.WorkerPool{}
+		if err := s.client.Get(request.Context(), client.ObjectKey{Namespace: namespace, Name: task.Spec.WorkerPoolRef.Name}, pool); err != nil {
+			writeKubernetesError(writer, fmt.Sprintf("getting WorkerPool %q", task.Spec.WorkerPoolRef.Name), err)
+			return
+		}
+	}
+
+	// The Session starts without a transcript when the logs are gone.
+	var transcript string
+	if task.Status.PodName != "" {
+		if logs, err := s.readTaskLogs(request.Context(), &task); err == nil {
+			transcript = logs
+		}
+	}
+
+	session, err := sessionbuilder.FromTask(sessionbuilder.TaskSessionName(task.Name), namespace, &task, sessionbuilder.TaskWorker(&task, pool), transcript)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.client.Create(request.Context(), session); err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsAlreadyExists(err) || apierrors.IsInvalid(err) {
+			status = http.StatusConflict
+		}
+		writeError(writer, status, fmt.Sprintf("creating Session %q: %v", session.Name, err))
+		return
+	}
+	writeJSON(writer, http.StatusCreated, summarize(session))
+}
+
 func (s *Server) applySession(writer http.ResponseWriter, request *http.Request) {
 	session, err := decodeSessionYAML(request.Body)
 	if err != nil {
@@ -411,6 +411,71 @@ func TestConsoleTaskLogs(t *testing.T) {
 	}
 }
 
+func TestConsoleCreatesSessionFromTask(t *testing.T) {
+	server := testServer(t)
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed-task", Namespace: "team-a"},
+		Spec: kelos.TaskSpec{
+			Type:         "claude-code",
+			Prompt:       "Fix the console",
+			Credentials:  &kelos.Credentials{Type: kelos.CredentialTypeNone},
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "workspace"},
+		},
+		Status: kelos.TaskStatus{
+			Phase:   kelos.TaskPhaseFailed,
+			PodName: "failed-task-pod",
+			Results: map[string]string{"branch": "kelos/fix-console"},
+		},
+	}
+	if err := server.client.Create(t.Context(), task); err != nil {
+		t.Fatal(err)
+	}
+	server.taskLogStream = func(_ context.Context, _ *kelos.Task, _ int64) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("agent gave up\n")), nil
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/resources/tasks/team-a/failed-task/session", nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, request)
+	if response.Code != http.StatusCreated {
+		t.Fatalf("create status = %d body = %s", response.Code, response.Body.String())
+	}
+
+	var session kelos.Session
+	if err := server.client.Get(t.Context(), client.ObjectKey{Namespace: "team-a", Name: "failed-task-session"}, &session); err != nil {
+		t.Fatal(err)
+	}
+	if session.Spec.Worker.Type != "claude-code" || session.Spec.InitialBranch != "kelos/fix-console" {
+		t.Errorf("Session spec = %+v, want the Task's agent and branch", session.Spec)
+	}
+	for _, want := range []string{"Fix the console", "agent gave up"} {
+		if !strings.Contains(session.Spec.InitialPrompt, want) {
+			t.Errorf("initialPrompt does not contain %q:\n%s", want, session.Spec.InitialPrompt)
+		}
+	}
+}
+
+func TestConsoleRejectsSessionFromRunningTask(t *testing.T) {
+	server := testServer(t)
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "running-task", Namespace: "team-a"},
+		Spec:       kelos.TaskSpec{Type: "claude-code", Prompt: "Fix the console"},
+		Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseRunning},
+	}
+	if err := server.client.Create(t.Context(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/resources/tasks/team-a/running-task/session", nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, request)
+	if response.Code != http.StatusConflict {
+		t.Fatalf("create status = %d body = %s, want %d", response.Code, response.Body.String(), http.StatusConflict)
+	}
+}
+
 func TestConsoleWorkerPoolTaskLogsOnlyIncludeSelectedTask(t *testing.T) {
 	server := testServer(t)
 	task := &kelos.Task{
@@ -127,6 +127,7 @@ async function testResourceDetailsIgnoreStaleResponses() {
     resourceDetailLogs: {textContent: ''},
     resourceDetailYAML: {textContent: ''},
     resourceDetailDialog: {showModal() {}},
+    openTaskSession: {hidden: true, disabled: false},
   };
   global.state = {
     resourceDetailGeneration: 0,
@@ -158,6 +159,7 @@ async function testResourceDetailsIgnoreStaleResponses() {
 
   assert.equal(elements.resourceDetailTitle.textContent, 'Task second');
   assert.equal(elements.resourceDetailLogsPanel.hidden, false);
+  assert.equal(elements.openTaskSession.hidden, false);
   assert.equal(logRequests[1].path, '/api/resources/tasks/default/second/logs');
   manifestRequests[1].resolve({yaml: 'name: second'});
   logRequests[1].resolve('second logs');
@@ -48,6 +48,7 @@ const elements = {
   refreshResourceLogs: document.querySelector('#refresh-resource-logs'),
   resourceDetailLogs: document.querySelector('#resource-detail-logs'),
   resourceDetailYAML: document.querySelector('#resource-detail-yaml'),
+  openTaskSession: document.querySelector('#open-task-session'),
   namespaceLabels: document.querySelectorAll('.console-namespace'),
   newSessionButton: document.querySelector('#new-session'),
   sectionSelect: document.querySelector('#session-section-select'),
@@ -765,6 +766,8 @@ async function openResourceDetail(collection, item) {
   elements.resourceDetailSubtitle.textContent = `${item.namespace}/${item.name}`;
   elements.resourceDetailTabs.hidden = !showTaskLogs;
   state.resourceDetailTask = showTaskLogs ? {namespace: item.namespace, name: item.name} : null;
+  elements.openTaskSession.hidden = !showTaskLogs;
+  elements.openTaskSession.disabled = false;
   if (!showTaskLogs) {
     state.resourceDetailLogGeneration++;
     elements.refreshResourceLogs.disabled = true;
@@ -789,6 +792,22 @@ async function openResourceDetail(collection, item) {
   await Promise.all([manifestRequest, logsRequest]);
 }
 
+async function openTaskSession(task) {
+  elements.openTaskSession.disabled = true;
+  try {
+    const created = await api(`/api/resources/tasks/${encodeURIComponent(task.namespace)}/${encodeURIComponent(task.name)}/session`, {method: 'POST'});
+    elements.resourceDetailDialog.close();
+    setConsoleView('sessions');
+    await loadSessions();
+    const selected = state.sessions.find(item => sessionKey(item) === sessionKey(created));
+    selectSession(selected || created);
+  } catch (error) {
+    showToast(error.message);
+  } finally {
+    elements.openTaskSession.disabled = false;
+  }
+}
+
 function sessionKey(session) {
   return `${session.namespace}/${session.name}`;
 }
@@ -3499,6 +3518,10 @@ function handleEvent(event) {
       endAssistantSegment(event.turnId);
       renderTurnEnd(event, recoveredCompletion);
       break;
+    case 'permission.denied':
+      endAssistantSegment(event.turnId);
+      renderPermissionDenied(event);
+      break;
     case 'error':
       endAssistantSegment(event.turnId);
       if (event.requestId && event.requestId === state.historyRequestID) cancelOlderHistoryPage();
@@ -4215,6 +4238,15 @@ function renderRecovery(event) {
   scrollToBottom();
 }
 
+function renderPermissionDenied(event) {
+  ensureConversation();
+  const card = document.createElement('div');
+  card.className = 'permission-card';
+  card.textContent = `Permission denied: ${event.text || event.toolName || 'tool call'}`;
+  elements.messages.append(card);
+  scrollToBottom();
+}
+
 function renderTurnEnd(event, recoveredCompletion = false) {
   const completedAt = Date.parse(event.timestamp || '');
   const matchingTurn = !event.turnId || !state.activeTurnID || event.turnId === state.activeTurnID;
@@ -4821,6 +4853,9 @@ elements.resourceDetailTabs.addEventListener('keydown', handleResourceDetailTabK
 elements.refreshResourceLogs.addEventListener('click', () => {
   if (state.resourceDetailTask) void loadResourceTaskLogs(state.resourceDetailTask, state.resourceDetailGeneration);
 });
+elements.openTaskSession.addEventListener('click', () => {
+  if (state.resourceDetailTask) void openTaskSession(state.resourceDetailTask);
+});
 document.querySelector('#refresh-console').addEventListener('click', () => {
   void refreshConsole();
 });
@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
+)
+
+// maxAgentConfigUsersListed is the number of users listed in the UsedBy
+// status of an AgentConfig, matching the MaxItems of the field.
+const maxAgentConfigUsersListed = 20
+
+// AgentConfigReconciler validates AgentConfigs, records the resources that
+// use them and protects them from removal while in use.
+type AgentConfigReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=kelos.dev,resources=agentconfigs,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=agentconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=agentconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kelos.dev,resources=tasks;sessions;workerpools;taskspawners;sessionspawners;pipelines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile validates an AgentConfig and updates its status, and keeps
+// deleted AgentConfigs while they are in use.
+func (r *AgentConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var ac kelos.AgentConfig
+	if err := r.Get(ctx, req.NamespacedName, &ac); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Unable to fetch AgentConfig")
+		reconcileErrorsTotal.WithLabelValues("agentconfig").Inc()
+		return ctrl.Result{}, err
+	}
+
+	removed, protection, err := reconcileInUseProtection(ctx, r.Client, r.Recorder, &ac, inuse.AgentConfigProtectionFinalizer, func() ([]inuse.User, error) {
+		return inuse.AgentConfigUsers(ctx, r.Client, ac.Namespace, ac.Name)
+	})
+	if removed || err != nil {
+		return protection, err
+	}
+
+	status, err := validateAgentConfig(ctx, r.Client, &ac)
+	if err != nil {
+		logger.Error(err, "Unable to validate AgentConfig", "agentConfig", ac.Name)
+		return ctrl.Result{}, err
+	}
+	status.UsedBy, status.UsedByCounts, err = agentConfigUsers(ctx, r.Client, &ac)
+	if err != nil {
+		logger.Error(err, "Unable to list AgentConfig users", "agentConfig", ac.Name)
+		return ctrl.Result{}, err
+	}
+	if equality.Semantic.DeepEqual(ac.Status, status) {
+		return protection, nil
+	}
+
+	previous := meta.FindStatusCondition(ac.Status.Conditions, kelos.AgentConfigConditionReady)
+	var previousStatus metav1.ConditionStatus
+	if previous != nil {
+		previousStatus = previous.Status
+	}
+	ac.Status = status
+	if err := r.Status().Update(ctx, &ac); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		logger.Error(err, "Unable to update AgentConfig status", "agentConfig", ac.Name)
+		return ctrl.Result{}, err
+	}
+
+	if ready := meta.FindStatusCondition(status.Conditions, kelos.AgentConfigConditionReady); ready != nil && ready.Status != previousStatus {
+		switch ready.Status {
+		case metav1.ConditionFalse:
+			r.recordEvent(&ac, corev1.EventTypeWarning, "AgentConfigNotReady", "AgentConfig is not ready: %s", ready.Message)
+		case metav1.ConditionTrue:
+			r.recordEvent(&ac, corev1.EventTypeNormal, "AgentConfigReady", "AgentConfig is ready")
+		}
+	}
+	return protection, nil
+}
+
+func (r *AgentConfigReconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AgentConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kelos.AgentConfig{}).
+		Watches(&kelos.AgentConfig{}, handler.EnqueueRequestsFromMapFunc(r.findBasesForAgentConfig)).
+		Watches(&kelos.Task{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser), builder.WithPredicates(agentConfigTaskPredicate{})).
+		Watches(&kelos.Session{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser)).
+		Watches(&kelos.WorkerPool{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser)).
+		Watches(&kelos.TaskSpawner{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser)).
+		Watches(&kelos.SessionSpawner{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser)).
+		Watches(&kelos.Pipeline{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForUser)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForReference)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.findAgentConfigsForReference)).
+		Complete(r)
+}
+
+// agentConfigTaskPredicate passes the Task events that can change the
+// UsedBy of an AgentConfig: creation, deletion, phase transitions and
+// reference changes. A running Task updates its status often, and those
+// updates do not change whether it uses an AgentConfig.
+type agentConfigTaskPredicate struct{}
+
+func (agentConfigTaskPredicate) Create(event.CreateEvent) bool { return true }
+
+func (agentConfigTaskPredicate) Delete(event.DeleteEvent) bool { return true }
+
+func (agentConfigTaskPredicate) Generic(event.GenericEvent) bool { return true }
+
+func (agentConfigTaskPredicate) Update(e event.UpdateEvent) bool {
+	oldTask, ok := e.ObjectOld.(*kelos.Task)
+	if !ok {
+		return true
+	}
+	newTask, ok := e.ObjectNew.(*kelos.Task)
+	if !ok {
+		return true
+	}
+	if oldTask.Status.Phase != newTask.Status.Phase {
+		return true
+	}
+	_, oldRefs := inuse.References(oldTask)
+	_, newRefs := inuse.References(newTask)
+	return !reflect.DeepEqual(oldRefs.AgentConfigs, newRefs.AgentConfigs)
+}
+
+// findBasesForAgentConfig enqueues the bases of an AgentConfig, whose UsedBy
+// lists it.
+func (r *AgentConfigReconciler) findBasesForAgentConfig(ctx context.Context, obj client.Object) []reconcile.Request {
+	ac, ok := obj.(*kelos.AgentConfig)
+	if !ok || ac.Spec.Extends == nil {
+		return nil
+	}
+	return r.agentConfigRequests(ctx, ac.Namespace, []string{ac.Spec.Extends.Name})
+}
+
+// findAgentConfigsForUser enqueues the AgentConfigs a Task, Session,
+// WorkerPool, TaskSpawner, SessionSpawner or Pipeline references, and their
+// bases.
+func (r *AgentConfigReconciler) findAgentConfigsForUser(ctx context.Context, obj client.Object) []reconcile.Request {
+	_, refs := inuse.References(obj)
+	if len(refs.AgentConfigs) == 0 {
+		return nil
+	}
+	return r.agentConfigRequests(ctx, obj.GetNamespace(), refs.AgentConfigs)
+}
+
+// findAgentConfigsForReference enqueues the AgentConfigs that reference a
+// Secret or ConfigMap.
+func (r *AgentConfigReconciler) findAgentConfigsForReference(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list kelos.AgentConfigList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	_, isConfigMap := obj.(*corev1.ConfigMap)
+
+	var requests []reconcile.Request
+	for i := range list.Items {
+		ac := &list.Items[i]
+		if agentConfigReferencesObject(ac, obj.GetName(), isConfigMap) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)})
+		}
+	}
+	return requests
+}
+
+// agentConfigRequests returns requests for the AgentConfigs names and every
+// AgentConfig they extend, directly or through other bases.
+func (r *AgentConfigReconciler) agentConfigRequests(ctx context.Context, namespace string, names []string) []reconcile.Request {
+	var list kelos.AgentConfigList
+	if err := r.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+	bases := make(map[string]string, len(list.Items))
+	for _, ac := range list.Items {
+		if ac.Spec.Extends != nil {
+			bases[ac.Name] = ac.Spec.Extends.Name
+		}
+	}
+
+	seen := map[string]bool{}
+	var requests []reconcile.Request
+	for _, start := range names {
+		for name := start; name != "" && !seen[name]; name = bases[name] {
+			seen[name] = true
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}})
+		}
+	}
+	return requests
+}
+
+// agentConfigReferencesObject reports whether the own fields or overrides of
+// ac reference the Secret, or the ConfigMap if isConfigMap, named name.
+func agentConfigReferencesObject(ac *kelos.AgentConfig, name string, isConfigMap bool) bool {
+	skills := append([]kelos.SkillsShSpec(nil), ac.Spec.Skills...)
+	servers := append([]kelos.MCPServerSpec(nil), ac.Spec.MCPServers...)
+	for _, override := range ac.Spec.Overrides {
+		skills = append(skills, override.Skills...)
+		servers = append(servers, override.MCPServers...)
+	}
+
+	if !isConfigMap {
+		for _, skill := range skills {
+			if skill.SecretRef != nil && skill.SecretRef.Name == name {
+				return true
+			}
+		}
+	}
+	for _, server := range servers {
+		if !isConfigMap {
+			if server.HeadersFrom != nil && server.HeadersFrom.SecretRef.Name == name {
+				return true
+			}
+			if server.EnvFrom != nil && server.EnvFrom.SecretRef.Name == name {
+				return true
+			}
+		}
+		for _, entry := range server.Env {
+			if entry.ValueFrom == nil {
+				continue
+			}
+			if !isConfigMap && entry.ValueFrom.SecretKeyRef != nil && entry.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+			if isConfigMap && entry.ValueFrom.ConfigMapKeyRef != nil && entry.ValueFrom.ConfigMapKeyRef.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// agentConfigUsers returns the first maxAgentConfigUsersListed resources in
+// the namespace of ac that use it, directly or through the extends field of
+// another AgentConfig, sorted by kind and name, and the number of users of
+// each kind.
+func agentConfigUsers(ctx context.Context, c client.Reader, ac *kelos.AgentConfig) ([]kelos.AgentConfigUser, []kelos.AgentConfigUserCount, error) {
+	users, err := inuse.AgentConfigUsers(ctx, c, ac.Namespace, ac.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var listed []kelos.AgentConfigUser
+	var counts []kelos.AgentConfigUserCount
+	for _, user := range users {
+		if len(listed) < maxAgentConfigUsersListed {
+			listed = append(listed, kelos.AgentConfigUser{Kind: user.Kind, Name: user.Name})
+		}
+		if n := len(counts); n > 0 && counts[n-1].Kind == user.Kind {
+			counts[n-1].Count++
+		} else {
+			counts = append(counts, kelos.AgentConfigUserCount{Kind: user.Kind, Count: 1})
+		}
+	}
+	return listed, counts, nil
+}
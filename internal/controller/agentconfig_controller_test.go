@@ -0,0 +1,353 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestAgentConfigReconciler_UpdatesStatus(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	refs := []kelos.AgentConfigReference{{Name: "team"}}
+	config := newAgentConfig("org", "", kelos.AgentConfigSpec{
+		AgentsMD: "# Org",
+		Plugins: []kelos.PluginSpec{{
+			Name:   "review",
+			Skills: []kelos.SkillDefinition{{Name: "review", Content: "12345"}},
+		}},
+		MCPServers: []kelos.MCPServerSpec{{
+			Name:    "tools",
+			Type:    "stdio",
+			Command: "tools",
+			EnvFrom: &kelos.SecretValuesSource{SecretRef: kelos.SecretReference{Name: "tools-env"}},
+		}},
+	})
+	config.Namespace = "default"
+	team := newAgentConfig("team", "org", kelos.AgentConfigSpec{})
+	team.Namespace = "default"
+	objects := []client.Object{
+		config,
+		team,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tools-env", Namespace: "default"}},
+		&kelos.Task{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+			Spec:       kelos.TaskSpec{Type: AgentTypeClaudeCode, Prompt: "test", AgentConfigRefs: refs},
+			Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseRunning},
+		},
+		&kelos.Task{
+			ObjectMeta: metav1.ObjectMeta{Name: "done", Namespace: "default"},
+			Spec:       kelos.TaskSpec{Type: AgentTypeClaudeCode, Prompt: "test", AgentConfigRefs: refs},
+			Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseSucceeded},
+		},
+		&kelos.Session{
+			ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "default"},
+			Spec:       kelos.SessionSpec{Worker: kelos.WorkerSpec{Type: AgentTypeCodex, AgentConfigRefs: refs}},
+		},
+		&kelos.WorkerPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+			Spec:       kelos.WorkerPoolSpec{Worker: kelos.WorkerSpec{Type: AgentTypeCodex, AgentConfigRefs: []kelos.AgentConfigReference{{Name: "org"}}}},
+		},
+		&kelos.TaskSpawner{
+			ObjectMeta: metav1.ObjectMeta{Name: "spawner", Namespace: "default"},
+			Spec: kelos.TaskSpawnerSpec{TaskTemplate: kelos.TaskTemplate{
+				Worker: &kelos.WorkerSpec{Type: AgentTypeClaudeCode, AgentConfigRefs: refs},
+			}},
+		},
+		&kelos.TaskSpawner{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(config, team).
+		WithObjects(objects...).
+		Build()
+	r := &AgentConfigReconciler{Client: cl, Scheme: scheme}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(config)}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	var updated kelos.AgentConfig
+	if err := cl.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("Getting AgentConfig: %v", err)
+	}
+	for _, conditionType := range []string{
+		kelos.AgentConfigConditionReady,
+		kelos.AgentConfigConditionValid,
+		kelos.AgentConfigConditionReferencesResolved,
+	} {
+		if !meta.IsStatusConditionTrue(updated.Status.Conditions, conditionType) {
+			t.Errorf("Condition %s = %+v, want True", conditionType, meta.FindStatusCondition(updated.Status.Conditions, conditionType))
+		}
+	}
+	if updated.Status.PluginBytes != 5 {
+		t.Errorf("PluginBytes = %d, want 5", updated.Status.PluginBytes)
+	}
+	wantUsers := []kelos.AgentConfigUser{
+		{Kind: "AgentConfig", Name: "team"},
+		{Kind: "Session", Name: "chat"},
+		{Kind: "Task", Name: "running"},
+		{Kind: "TaskSpawner", Name: "spawner"},
+		{Kind: "WorkerPool", Name: "pool"},
+	}
+	if !reflect.DeepEqual(updated.Status.UsedBy, wantUsers) {
+		t.Errorf("UsedBy = %+v, want %+v", updated.Status.UsedBy, wantUsers)
+	}
+	wantCounts := []kelos.AgentConfigUserCount{
+		{Kind: "AgentConfig", Count: 1},
+		{Kind: "Session", Count: 1},
+		{Kind: "Task", Count: 1},
+		{Kind: "TaskSpawner", Count: 1},
+		{Kind: "WorkerPool", Count: 1},
+	}
+	if !reflect.DeepEqual(updated.Status.UsedByCounts, wantCounts) {
+		t.Errorf("UsedByCounts = %+v, want %+v", updated.Status.UsedByCounts, wantCounts)
+	}
+
+	// Users of a config extending org enqueue org as well.
+	requests := r.findAgentConfigsForUser(context.Background(), objects[3])
+	if len(requests) != 2 || requests[0].Name != "team" || requests[1].Name != "org" {
+		t.Errorf("findAgentConfigsForUser() = %v, want team and org", requests)
+	}
+}
+
+func TestValidateAgentConfig_Problems(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          kelos.AgentConfigSpec
+		conditionType string
+		wantReason    string
+		wantMessage   string
+	}{
+		{
+			name: "missing envFrom secret",
+			spec: kelos.AgentConfigSpec{MCPServers: []kelos.MCPServerSpec{{
+				Name:    "tools",
+				Type:    "stdio",
+				Command: "tools",
+				EnvFrom: &kelos.SecretValuesSource{SecretRef: kelos.SecretReference{Name: "missing"}},
+			}}},
+			conditionType: kelos.AgentConfigConditionReferencesResolved,
+			wantReason:    "ReferenceNotFound",
+			wantMessage:   `envFrom secret "missing"`,
+		},
+		{
+			name: "missing configmap key",
+			spec: kelos.AgentConfigSpec{MCPServers: []kelos.MCPServerSpec{{
+				Name:    "tools",
+				Type:    "stdio",
+				Command: "tools",
+				Env: []corev1.EnvVar{{Name: "REGION", ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+						Key:                  "region",
+					},
+				}}},
+			}}},
+			conditionType: kelos.AgentConfigConditionReferencesResolved,
+			wantReason:    "ReferenceNotFound",
+			wantMessage:   `configmap "settings" has no key "region"`,
+		},
+		{
+			name: "missing skills secret in override",
+			spec: kelos.AgentConfigSpec{Overrides: map[string]kelos.AgentConfigOverride{
+				AgentTypeCodex: {Skills: []kelos.SkillsShSpec{{
+					Source:    "owner/private-skills",
+					SecretRef: &kelos.SecretReference{Name: "skills-token"},
+				}}},
+			}},
+			conditionType: kelos.AgentConfigConditionReferencesResolved,
+			wantReason:    "SecretNotFound",
+			wantMessage:   `secret "skills-token"`,
+		},
+		{
+			name:          "missing base",
+			spec:          kelos.AgentConfigSpec{Extends: &kelos.AgentConfigReference{Name: "org"}},
+			conditionType: kelos.AgentConfigConditionReferencesResolved,
+			wantReason:    "BaseNotFound",
+			wantMessage:   `AgentConfig "org" extended by "cfg" not found`,
+		},
+		{
+			name:          "extends cycle",
+			spec:          kelos.AgentConfigSpec{Extends: &kelos.AgentConfigReference{Name: "cfg"}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "ExtendsCycle",
+			wantMessage:   "cfg -> cfg",
+		},
+		{
+			name: "field ref env",
+			spec: kelos.AgentConfigSpec{MCPServers: []kelos.MCPServerSpec{{
+				Name:    "tools",
+				Type:    "stdio",
+				Command: "tools",
+				Env: []corev1.EnvVar{{Name: "POD", ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+				}}},
+			}}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "UnsupportedEnvVarSource",
+			wantMessage:   "only supports secretKeyRef and configMapKeyRef",
+		},
+		{
+			name:          "invalid skills source",
+			spec:          kelos.AgentConfigSpec{Skills: []kelos.SkillsShSpec{{Source: "not a repo"}}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "InvalidSkillsSource",
+			wantMessage:   `"not a repo"`,
+		},
+		{
+			name: "oversized plugins",
+			spec: kelos.AgentConfigSpec{Plugins: []kelos.PluginSpec{{
+				Name:   "big",
+				Skills: []kelos.SkillDefinition{{Name: "big", Content: strings.Repeat("x", pluginConfigMapMaxBytes+1)}},
+			}}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "PluginsTooLarge",
+			wantMessage:   "exceeding the",
+		},
+		{
+			name:          "invalid permissions",
+			spec:          kelos.AgentConfigSpec{Permissions: &kelos.AgentPermissions{DeniedTools: []string{"Bash"}}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "InvalidPermissions",
+			wantMessage:   `unknown tool "Bash"`,
+		},
+		{
+			name:          "invalid plugin name",
+			spec:          kelos.AgentConfigSpec{Plugins: []kelos.PluginSpec{{Name: "../escape"}}},
+			conditionType: kelos.AgentConfigConditionValid,
+			wantReason:    "InvalidPlugins",
+			wantMessage:   "path separators",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := newAgentConfig("cfg", "", tt.spec)
+			ac.Namespace = "default"
+			cl := fake.NewClientBuilder().
+				WithScheme(newWorkspaceControllerTestScheme()).
+				WithObjects(ac, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}).
+				Build()
+
+			status, err := validateAgentConfig(context.Background(), cl, ac)
+			if err != nil {
+				t.Fatalf("validateAgentConfig() error: %v", err)
+			}
+
+			c := meta.FindStatusCondition(status.Conditions, tt.conditionType)
+			if c == nil || c.Status != metav1.ConditionFalse || c.Reason != tt.wantReason || !strings.Contains(c.Message, tt.wantMessage) {
+				t.Fatalf("Condition %s = %+v, want False/%s containing %q", tt.conditionType, c, tt.wantReason, tt.wantMessage)
+			}
+			ready := meta.FindStatusCondition(status.Conditions, kelos.AgentConfigConditionReady)
+			if ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != tt.wantReason {
+				t.Errorf("Ready = %+v, want False/%s", ready, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAgentConfigReconciler_FindAgentConfigsForReference(t *testing.T) {
+	ac := newAgentConfig("cfg", "", kelos.AgentConfigSpec{
+		Overrides: map[string]kelos.AgentConfigOverride{
+			AgentTypeCodex: {MCPServers: []kelos.MCPServerSpec{{
+				Name:        "github",
+				Type:        "http",
+				URL:         "https://example.com",
+				HeadersFrom: &kelos.SecretValuesSource{SecretRef: kelos.SecretReference{Name: "github-headers"}},
+			}}},
+		},
+	})
+	cl := newAgentConfigResolveClient(t, ac)
+	r := &AgentConfigReconciler{Client: cl}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-headers", Namespace: "default"}}
+	if got := r.findAgentConfigsForReference(context.Background(), secret); len(got) != 1 || got[0].Name != "cfg" {
+		t.Errorf("findAgentConfigsForReference(Secret) = %v, want cfg", got)
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "github-headers", Namespace: "default"}}
+	if got := r.findAgentConfigsForReference(context.Background(), configMap); len(got) != 0 {
+		t.Errorf("findAgentConfigsForReference(ConfigMap) = %v, want none", got)
+	}
+}
+
+func TestAgentConfigReconciler_CapsUsedBy(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	config := newAgentConfig("team", "", kelos.AgentConfigSpec{AgentsMD: "# Team"})
+	config.Namespace = "default"
+	objects := []client.Object{config}
+	for i := 0; i < 30; i++ {
+		task := newTestTask(fmt.Sprintf("task-%02d", i), kelos.TaskPhaseRunning)
+		task.Spec.AgentConfigRefs = []kelos.AgentConfigReference{{Name: "team"}}
+		objects = append(objects, task)
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(config).
+		WithObjects(objects...).
+		Build()
+	r := &AgentConfigReconciler{Client: cl, Scheme: scheme}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(config)}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	var updated kelos.AgentConfig
+	if err := cl.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("Getting AgentConfig: %v", err)
+	}
+	if len(updated.Status.UsedBy) != maxAgentConfigUsersListed {
+		t.Fatalf("len(UsedBy) = %d, want %d", len(updated.Status.UsedBy), maxAgentConfigUsersListed)
+	}
+	if first, last := updated.Status.UsedBy[0].Name, updated.Status.UsedBy[maxAgentConfigUsersListed-1].Name; first != "task-00" || last != "task-19" {
+		t.Errorf("UsedBy = %s..%s, want task-00..task-19", first, last)
+	}
+	wantCounts := []kelos.AgentConfigUserCount{{Kind: "Task", Count: 30}}
+	if !reflect.DeepEqual(updated.Status.UsedByCounts, wantCounts) {
+		t.Errorf("UsedByCounts = %+v, want %+v", updated.Status.UsedByCounts, wantCounts)
+	}
+}
+
+func TestAgentConfigTaskPredicate(t *testing.T) {
+	running := newTestTask("task-1", kelos.TaskPhaseRunning)
+	running.Spec.AgentConfigRefs = []kelos.AgentConfigReference{{Name: "team"}}
+
+	progressed := running.DeepCopy()
+	progressed.Status.Message = "Still running"
+	succeeded := running.DeepCopy()
+	succeeded.Status.Phase = kelos.TaskPhaseSucceeded
+	retargeted := running.DeepCopy()
+	retargeted.Spec.AgentConfigRefs = []kelos.AgentConfigReference{{Name: "org"}}
+
+	tests := []struct {
+		name   string
+		newObj *kelos.Task
+		want   bool
+	}{
+		{name: "status update while running", newObj: progressed, want: false},
+		{name: "phase transition", newObj: succeeded, want: true},
+		{name: "reference change", newObj: retargeted, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := agentConfigTaskPredicate{}.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: tt.newObj})
+			if got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,23 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
 )
 
 // MergeAgentConfigs merges multiple AgentConfigSpecs in order.
 // agentsMD values are concatenated with "\n\n", plugins and skills are
-// appended, and mcpServers are appended with later entries winning on
-// name collision. Returns nil if the input slice is empty.
+// appended, mcpServers are appended with later entries winning on name
+// collision, and the permissions lists are combined so that a later
+// AgentConfig can add rules but not remove them. Returns nil if the input
+// slice is empty.
 func MergeAgentConfigs(configs []kelos.AgentConfigSpec) *kelos.AgentConfigSpec {
 	if len(configs) == 0 {
 		return nil
@@ -49,6 +57,12 @@ func MergeAgentConfigs(configs []kelos.AgentConfigSpec) *kelos.AgentConfigSpec {
 		}
 	}
 
+	policies := make([]*kelos.AgentPermissions, len(configs))
+	for i := range configs {
+		policies[i] = configs[i].Permissions
+	}
+	merged.Permissions = agentpermissions.Merge(policies...)
+
 	return &merged
 }
 
@@ -64,3 +78,150 @@ func ResolveAgentConfigRefs(spec *kelos.TaskSpec) []kelos.AgentConfigReference {
 	}
 	return nil
 }
+
+// AgentConfigNotFoundError reports that an AgentConfig named by a ref, or by
+// the extends field of another AgentConfig, does not exist.
+type AgentConfigNotFoundError struct {
+	// Name is the name of the missing AgentConfig.
+	Name string
+	// ExtendedBy is the name of the AgentConfig that extends it, or empty
+	// if it was named by a ref.
+	ExtendedBy string
+}
+
+func (e *AgentConfigNotFoundError) Error() string {
+	if e.ExtendedBy == "" {
+		return fmt.Sprintf("AgentConfig %q not found", e.Name)
+	}
+	return fmt.Sprintf("AgentConfig %q extended by %q not found", e.Name, e.ExtendedBy)
+}
+
+// AgentConfigCycleError reports that the extends chain of an AgentConfig
+// leads back to itself.
+type AgentConfigCycleError struct {
+	// Chain lists the AgentConfigs in the cycle, starting and ending with
+	// the same name.
+	Chain []string
+}
+
+func (e *AgentConfigCycleError) Error() string {
+	return "AgentConfig extends cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// ResolveAgentConfigs fetches the AgentConfigs named by refs and returns their
+// effective configuration for agentType. Each AgentConfig is expanded into
+// its extends chain, base first, followed by its own fields and its override
+// for agentType; the expanded list is merged with MergeAgentConfigs. An
+// AgentConfig reached more than once, e.g. a base shared by two refs, is
+// merged only at its first position. Returns nil if refs is empty.
+//
+// A missing AgentConfig is returned as an *AgentConfigNotFoundError and a
+// cycle as an *AgentConfigCycleError.
+func ResolveAgentConfigs(ctx context.Context, c client.Reader, namespace string, refs []kelos.AgentConfigReference, agentType string) (*kelos.AgentConfigSpec, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	r := agentConfigResolver{
+		client:    c,
+		namespace: namespace,
+		agentType: agentType,
+		merged:    make(map[string]bool),
+	}
+	for _, ref := range refs {
+		if err := r.expand(ctx, ref.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return MergeAgentConfigs(r.specs), nil
+}
+
+type agentConfigResolver struct {
+	client    client.Reader
+	namespace string
+	agentType string
+	merged    map[string]bool
+	specs     []kelos.AgentConfigSpec
+}
+
+// expand appends the effective configuration of the AgentConfig name to
+// r.specs. chain holds the AgentConfigs that extend name, outermost first.
+func (r *agentConfigResolver) expand(ctx context.Context, name string, chain []string) error {
+	for i, n := range chain {
+		if n == name {
+			cycle := append(append([]string{}, chain[i:]...), name)
+			return &AgentConfigCycleError{Chain: cycle}
+		}
+	}
+	if r.merged[name] {
+		return nil
+	}
+
+	var ac kelos.AgentConfig
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: r.namespace, Name: name}, &ac); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("fetching AgentConfig %q: %w", name, err)
+		}
+		notFound := &AgentConfigNotFoundError{Name: name}
+		if len(chain) > 0 {
+			notFound.ExtendedBy = chain[len(chain)-1]
+		}
+		return notFound
+	}
+
+	if ac.Spec.Extends != nil {
+		if err := r.expand(ctx, ac.Spec.Extends.Name, append(chain, name)); err != nil {
+			return err
+		}
+	}
+	// A base reached again through a later ref has already been merged.
+	if r.merged[name] {
+		return nil
+	}
+	r.merged[name] = true
+
+	r.specs = append(r.specs, kelos.AgentConfigSpec{
+		AgentsMD:    ac.Spec.AgentsMD,
+		Plugins:     ac.Spec.Plugins,
+		Skills:      ac.Spec.Skills,
+		MCPServers:  ac.Spec.MCPServers,
+		Permissions: ac.Spec.Permissions,
+	})
+	if override, ok := ac.Spec.Overrides[r.agentType]; ok {
+		r.specs = append(r.specs, kelos.AgentConfigSpec{
+			AgentsMD:   override.AgentsMD,
+			Plugins:    override.Plugins,
+			Skills:     override.Skills,
+			MCPServers: override.MCPServers,
+		})
+	}
+	return nil
+}
+
+// agentConfigDependents returns the names of the AgentConfigs in namespace
+// whose effective configuration includes name: name itself and every
+// AgentConfig that extends it, directly or through other bases.
+func agentConfigDependents(ctx context.Context, c client.Reader, namespace, name string) (map[string]bool, error) {
+	var list kelos.AgentConfigList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	bases := make(map[string]string, len(list.Items))
+	for _, ac := range list.Items {
+		if ac.Spec.Extends != nil {
+			bases[ac.Name] = ac.Spec.Extends.Name
+		}
+	}
+
+	dependents := map[string]bool{name: true}
+	for _, ac := range list.Items {
+		seen := map[string]bool{}
+		for n := ac.Name; n != "" && !seen[n]; n = bases[n] {
+			seen[n] = true
+			if n == name {
+				dependents[ac.Name] = true
+				break
+			}
+		}
+	}
+	return dependents, nil
+}
@@ -1,8 +1,16 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 )
 
@@ -221,6 +229,25 @@ func TestMergeAgentConfigs_ThreeConfigs(t *testing.T) {
 	}
 }
 
+func TestMergeAgentConfigs_PermissionsCombined(t *testing.T) {
+	configs := []kelos.AgentConfigSpec{
+		{Permissions: &kelos.AgentPermissions{DeniedCommands: []string{"rm -rf *"}}},
+		{AgentsMD: "# No permissions"},
+		{Permissions: &kelos.AgentPermissions{
+			DeniedCommands: []string{"kubectl *", "rm -rf *"},
+			DeniedPaths:    []string{".github/workflows/**"},
+		}},
+	}
+	got := MergeAgentConfigs(configs)
+	want := &kelos.AgentPermissions{
+		DeniedCommands: []string{"rm -rf *", "kubectl *"},
+		DeniedPaths:    []string{".github/workflows/**"},
+	}
+	if !reflect.DeepEqual(got.Permissions, want) {
+		t.Errorf("Permissions = %+v, want %+v", got.Permissions, want)
+	}
+}
+
 func TestResolveAgentConfigRefs_NeitherSet(t *testing.T) {
 	spec := &kelos.TaskSpec{}
 	if got := ResolveAgentConfigRefs(spec); got != nil {
@@ -284,3 +311,197 @@ func TestResolveAgentConfigRefs_WorkerNilFallsBackToLegacy(t *testing.T) {
 		t.Errorf("Expected [legacy-fallback], got %+v", got)
 	}
 }
+
+func newAgentConfigResolveClient(t *testing.T, configs ...*kelos.AgentConfig) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := kelos.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, ac := range configs {
+		ac.Namespace = "default"
+		builder = builder.WithObjects(ac)
+	}
+	return builder.Build()
+}
+
+func newAgentConfig(name, base string, spec kelos.AgentConfigSpec) *kelos.AgentConfig {
+	if base != "" {
+		spec.Extends = &kelos.AgentConfigReference{Name: base}
+	}
+	return &kelos.AgentConfig{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func TestResolveAgentConfigs_ExtendsAndOverrides(t *testing.T) {
+	cl := newAgentConfigResolveClient(t,
+		newAgentConfig("org", "", kelos.AgentConfigSpec{
+			AgentsMD:   "# Org",
+			MCPServers: []kelos.MCPServerSpec{{Name: "docs", Type: "http", URL: "https://org.example.com"}},
+		}),
+		newAgentConfig("team", "org", kelos.AgentConfigSpec{
+			AgentsMD: "# Team",
+			Overrides: map[string]kelos.AgentConfigOverride{
+				AgentTypeCodex: {
+					AgentsMD:   "# Codex",
+					MCPServers: []kelos.MCPServerSpec{{Name: "docs", Type: "http", URL: "https://codex.example.com"}},
+				},
+				AgentTypeClaudeCode: {AgentsMD: "# Claude"},
+			},
+		}),
+		newAgentConfig("extra", "", kelos.AgentConfigSpec{Plugins: []kelos.PluginSpec{{Name: "p1"}}}),
+	)
+	refs := []kelos.AgentConfigReference{{Name: "team"}, {Name: "extra"}}
+
+	got, err := ResolveAgentConfigs(context.Background(), cl, "default", refs, AgentTypeCodex)
+	if err != nil {
+		t.Fatalf("ResolveAgentConfigs() error: %v", err)
+	}
+	if want := "# Org\n\n# Team\n\n# Codex"; got.AgentsMD != want {
+		t.Errorf("AgentsMD = %q, want %q", got.AgentsMD, want)
+	}
+	if len(got.MCPServers) != 1 || got.MCPServers[0].URL != "https://codex.example.com" {
+		t.Errorf("MCPServers = %+v, want the codex override of docs", got.MCPServers)
+	}
+	if len(got.Plugins) != 1 || got.Plugins[0].Name != "p1" {
+		t.Errorf("Plugins = %+v, want [p1]", got.Plugins)
+	}
+	if got.Extends != nil || got.Overrides != nil {
+		t.Errorf("Expected extends and overrides to be resolved, got %+v, %+v", got.Extends, got.Overrides)
+	}
+
+	got, err = ResolveAgentConfigs(context.Background(), cl, "default", refs, AgentTypeOpenCode)
+	if err != nil {
+		t.Fatalf("ResolveAgentConfigs() error: %v", err)
+	}
+	if want := "# Org\n\n# Team"; got.AgentsMD != want {
+		t.Errorf("AgentsMD = %q, want %q", got.AgentsMD, want)
+	}
+}
+
+func TestResolveAgentConfigs_SharedBaseMergedOnce(t *testing.T) {
+	cl := newAgentConfigResolveClient(t,
+		newAgentConfig("org", "", kelos.AgentConfigSpec{AgentsMD: "# Org"}),
+		newAgentConfig("a", "org", kelos.AgentConfigSpec{AgentsMD: "# A"}),
+		newAgentConfig("b", "org", kelos.AgentConfigSpec{AgentsMD: "# B"}),
+	)
+	refs := []kelos.AgentConfigReference{{Name: "a"}, {Name: "b"}}
+
+	got, err := ResolveAgentConfigs(context.Background(), cl, "default", refs, AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatalf("ResolveAgentConfigs() error: %v", err)
+	}
+	if want := "# Org\n\n# A\n\n# B"; got.AgentsMD != want {
+		t.Errorf("AgentsMD = %q, want %q", got.AgentsMD, want)
+	}
+}
+
+func TestResolveAgentConfigs_Errors(t *testing.T) {
+	cl := newAgentConfigResolveClient(t,
+		newAgentConfig("a", "b", kelos.AgentConfigSpec{}),
+		newAgentConfig("b", "c", kelos.AgentConfigSpec{}),
+		newAgentConfig("c", "a", kelos.AgentConfigSpec{}),
+		newAgentConfig("orphan", "missing", kelos.AgentConfigSpec{}),
+	)
+
+	_, err := ResolveAgentConfigs(context.Background(), cl, "default", []kelos.AgentConfigReference{{Name: "a"}}, AgentTypeClaudeCode)
+	var cycle *AgentConfigCycleError
+	if !errors.As(err, &cycle) || err.Error() != "AgentConfig extends cycle: a -> b -> c -> a" {
+		t.Errorf("Expected cycle error, got %v", err)
+	}
+
+	_, err = ResolveAgentConfigs(context.Background(), cl, "default", []kelos.AgentConfigReference{{Name: "orphan"}}, AgentTypeClaudeCode)
+	var notFound *AgentConfigNotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "missing" || notFound.ExtendedBy != "orphan" {
+		t.Errorf("Expected missing base error, got %v", err)
+	}
+
+	_, err = ResolveAgentConfigs(context.Background(), cl, "default", []kelos.AgentConfigReference{{Name: "nope"}}, AgentTypeClaudeCode)
+	if !errors.As(err, &notFound) || notFound.Name != "nope" || notFound.ExtendedBy != "" {
+		t.Errorf("Expected missing ref error, got %v", err)
+	}
+}
+
+func TestAgentConfigDependents(t *testing.T) {
+	cl := newAgentConfigResolveClient(t,
+		newAgentConfig("org", "", kelos.AgentConfigSpec{}),
+		newAgentConfig("team", "org", kelos.AgentConfigSpec{}),
+		newAgentConfig("project", "team", kelos.AgentConfigSpec{}),
+		newAgentConfig("other", "", kelos.AgentConfigSpec{}),
+		newAgentConfig("loop", "loop", kelos.AgentConfigSpec{}),
+	)
+
+	got, err := agentConfigDependents(context.Background(), cl, "default", "org")
+	if err != nil {
+		t.Fatalf("agentConfigDependents() error: %v", err)
+	}
+	want := map[string]bool{"org": true, "team": true, "project": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("agentConfigDependents() = %v, want %v", got, want)
+	}
+}
+
+func TestCreateJob_AgentConfigExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   []*kelos.AgentConfig
+		wantPhase kelos.TaskPhase
+		wantMsg   string
+	}{
+		{
+			name: "missing base",
+			configs: []*kelos.AgentConfig{
+				newAgentConfig("team", "org", kelos.AgentConfigSpec{}),
+			},
+			wantPhase: kelos.TaskPhaseWaiting,
+			wantMsg:   `Waiting for AgentConfig "org" extended by "team"`,
+		},
+		{
+			name: "cycle",
+			configs: []*kelos.AgentConfig{
+				newAgentConfig("team", "org", kelos.AgentConfigSpec{}),
+				newAgentConfig("org", "team", kelos.AgentConfigSpec{}),
+			},
+			wantPhase: kelos.TaskPhaseFailed,
+			wantMsg:   "AgentConfig extends cycle: team -> org -> team",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newWorkspaceControllerTestScheme()
+			task := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-task", Namespace: "default"},
+				Spec: kelos.TaskSpec{
+					Type:            AgentTypeClaudeCode,
+					Prompt:          "test",
+					AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+				},
+			}
+			builder := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(task).
+				WithObjects(task)
+			for _, ac := range tt.configs {
+				ac.Namespace = "default"
+				builder = builder.WithObjects(ac)
+			}
+			cl := builder.Build()
+			r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+			if _, err := r.createJob(context.Background(), task); err != nil {
+				t.Fatalf("createJob error: %v", err)
+			}
+
+			var updated kelos.Task
+			if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+				t.Fatalf("Getting task: %v", err)
+			}
+			if updated.Status.Phase != tt.wantPhase || updated.Status.Message != tt.wantMsg {
+				t.Errorf("Phase, Message = %q, %q, want %q, %q", updated.Status.Phase, updated.Status.Message, tt.wantPhase, tt.wantMsg)
+			}
+			if updated.Status.JobName != "" {
+				t.Errorf("Expected no Job, got %q", updated.Status.JobName)
+			}
+		})
+	}
+}
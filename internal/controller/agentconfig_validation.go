@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
+)
+
+// agentConfigAgentTypes lists the agent types an AgentConfig is validated
+// for; overrides make the effective configuration differ per type.
+var agentConfigAgentTypes = []string{
+	AgentTypeClaudeCode,
+	AgentTypeCodex,
+	AgentTypeGemini,
+	AgentTypeOpenCode,
+	AgentTypeCursor,
+}
+
+// skillsShSourceRe matches the owner/repo form of a skills.sh source.
+var skillsShSourceRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// agentConfigProblem is a reason and message for a False condition.
+type agentConfigProblem struct {
+	reason  string
+	message string
+}
+
+// agentConfigProblems collects the problems found for one condition.
+type agentConfigProblems []agentConfigProblem
+
+func (p *agentConfigProblems) add(reason, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	for _, existing := range *p {
+		if existing.message == message {
+			return
+		}
+	}
+	*p = append(*p, agentConfigProblem{reason: reason, message: message})
+}
+
+// condition returns a condition of type conditionType that is True with
+// okMessage when there are no problems, and False with the first problem's
+// reason and all messages otherwise.
+func (p agentConfigProblems) condition(conditionType string, generation int64, okReason, okMessage string) metav1.Condition {
+	c := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             okReason,
+		Message:            okMessage,
+	}
+	if len(p) > 0 {
+		messages := make([]string, len(p))
+		for i, problem := range p {
+			messages[i] = problem.message
+		}
+		c.Status = metav1.ConditionFalse
+		c.Reason = p[0].reason
+		c.Message = strings.Join(messages, "; ")
+	}
+	return c
+}
+
+// validateAgentConfig validates ac and returns its status without UsedBy.
+// Conditions of the previous status are carried over so that transition
+// times only change when a condition does. Only API errors other than
+// NotFound are returned.
+func validateAgentConfig(ctx context.Context, c client.Client, ac *kelos.AgentConfig) (kelos.AgentConfigStatus, error) {
+	status := kelos.AgentConfigStatus{
+		ObservedGeneration: ac.Generation,
+		Conditions:         append([]metav1.Condition(nil), ac.Status.Conditions...),
+	}
+	var invalid, unresolved agentConfigProblems
+
+	// The own fields and each override are rendered independently of the
+	// base, which has its own status.
+	specs := []kelos.AgentConfigSpec{ac.Spec}
+	for _, agentType := range agentConfigAgentTypes {
+		if override, ok := ac.Spec.Overrides[agentType]; ok {
+			specs = append(specs, kelos.AgentConfigSpec{
+				AgentsMD:   override.AgentsMD,
+				Plugins:    override.Plugins,
+				Skills:     override.Skills,
+				MCPServers: override.MCPServers,
+			})
+		}
+	}
+	for _, spec := range specs {
+		for _, skill := range spec.Skills {
+			if !validSkillsShSource(skill.Source) {
+				invalid.add("InvalidSkillsSource", "skills.sh source %q is not in owner/repo format or an HTTPS git URL", skill.Source)
+			}
+		}
+		for _, server := range spec.MCPServers {
+			if reason, err := validateMCPServer(server); err != nil {
+				invalid.add(reason, "%v", err)
+			}
+		}
+	}
+
+	if err := agentpermissions.Validate(ac.Spec.Permissions); err != nil {
+		invalid.add("InvalidPermissions", "%v", err)
+	}
+
+	// The plugins, and the resolvable references, are checked on the
+	// effective configuration for each agent type.
+	for _, agentType := range agentConfigAgentTypes {
+		effective, err := ResolveAgentConfigs(ctx, c, ac.Namespace, []kelos.AgentConfigReference{{Name: ac.Name}}, agentType)
+		var notFound *AgentConfigNotFoundError
+		var cycle *AgentConfigCycleError
+		switch {
+		case errors.As(err, &notFound):
+			unresolved.add("BaseNotFound", "%v", err)
+			continue
+		case errors.As(err, &cycle):
+			invalid.add("ExtendsCycle", "%v", err)
+			continue
+		case err != nil:
+			return status, err
+		}
+
+		if len(effective.Skills) > 0 {
+			for _, plugin := range effective.Plugins {
+				if plugin.Name == SkillsShPluginName {
+					invalid.add("InvalidPlugins", "plugin name %q is reserved for skills.sh packages when skills are set", SkillsShPluginName)
+				}
+			}
+		}
+		if _, _, err := buildPluginConfigMapData(effective.Plugins); err != nil {
+			size := pluginContentBytes(effective.Plugins)
+			if size > pluginConfigMapMaxBytes {
+				invalid.add("PluginsTooLarge", "plugin content for %s totals %d bytes, exceeding the %d byte ConfigMap budget", agentType, size, pluginConfigMapMaxBytes)
+			} else {
+				invalid.add("InvalidPlugins", "%v", err)
+			}
+		}
+		if size := int64(pluginContentBytes(effective.Plugins)); size > status.PluginBytes {
+			status.PluginBytes = size
+		}
+	}
+
+	if len(invalid) == 0 {
+		for _, spec := range specs {
+			if err := (&TaskReconciler{Client: c}).validateSkillsAuthSecrets(ctx, ac.Namespace, spec.Skills); err != nil {
+				if isTransientAPIError(err) {
+					return status, err
+				}
+				unresolved.add("SecretNotFound", "%v", err)
+			}
+			if _, err := resolveMCPServerSecrets(ctx, c, ac.Namespace, spec.MCPServers); err != nil {
+				if isTransientAPIError(err) {
+					return status, err
+				}
+				unresolved.add("ReferenceNotFound", "%v", err)
+			}
+		}
+	}
+
+	valid := invalid.condition(kelos.AgentConfigConditionValid, ac.Generation, "Valid", "The configuration can be rendered for every agent type")
+	resolved := unresolved.condition(kelos.AgentConfigConditionReferencesResolved, ac.Generation, "Resolved", "All referenced Secrets, ConfigMaps and AgentConfigs exist")
+	ready := metav1.Condition{
+		Type:               kelos.AgentConfigConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ac.Generation,
+		Reason:             "Validated",
+		Message:            "The AgentConfig was validated",
+	}
+	for _, cond := range []metav1.Condition{valid, resolved} {
+		if cond.Status == metav1.ConditionFalse {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = cond.Reason
+			ready.Message = fmt.Sprintf("%s: %s", cond.Type, cond.Message)
+			break
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, valid)
+	meta.SetStatusCondition(&status.Conditions, resolved)
+	meta.SetStatusCondition(&status.Conditions, ready)
+	return status, nil
+}
+
+// validateMCPServer reports the first problem of server that does not
+// depend on other objects, with the reason for the Valid condition: an
+// invalid name, a malformed env entry or an unsupported EnvVarSource.
+func validateMCPServer(server kelos.MCPServerSpec) (string, error) {
+	if err := sanitizeComponentName(server.Name, "MCP server"); err != nil {
+		return "InvalidMCPServer", err
+	}
+	for _, entry := range server.Env {
+		if entry.Name == "" {
+			return "InvalidMCPServer", fmt.Errorf("MCP server %q has an env entry with an empty name", server.Name)
+		}
+		src := entry.ValueFrom
+		if src == nil {
+			continue
+		}
+		if entry.Value != "" {
+			return "InvalidMCPServer", fmt.Errorf("MCP server %q env %q: value and valueFrom are mutually exclusive", server.Name, entry.Name)
+		}
+		if src.FieldRef != nil || src.ResourceFieldRef != nil || src.FileKeyRef != nil {
+			return "UnsupportedEnvVarSource", fmt.Errorf("MCP server %q env %q: valueFrom only supports secretKeyRef and configMapKeyRef", server.Name, entry.Name)
+		}
+		if (src.SecretKeyRef == nil) == (src.ConfigMapKeyRef == nil) {
+			return "InvalidMCPServer", fmt.Errorf("MCP server %q env %q: valueFrom must set exactly one of secretKeyRef or configMapKeyRef", server.Name, entry.Name)
+		}
+	}
+	return "", nil
+}
+
+// validSkillsShSource reports whether source is in owner/repo format or an
+// HTTPS git URL with an owner and repository path.
+func validSkillsShSource(source string) bool {
+	if skillsShSourceRe.MatchString(source) {
+		return true
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return false
+	}
+	return len(strings.Split(strings.Trim(u.Path, "/"), "/")) >= 2
+}
+
+// pluginContentBytes returns the size of the skill and agent content that
+// buildPluginConfigMapData places in the plugin ConfigMap.
+func pluginContentBytes(plugins []kelos.PluginSpec) int {
+	total := 0
+	for _, plugin := range plugins {
+		for _, skill := range plugin.Skills {
+			total += len(skill.Content)
+		}
+		for _, agent := range plugin.Agents {
+			total += len(agent.Content)
+		}
+	}
+	return total
+}
+
+// isTransientAPIError reports whether err wraps an API error other than
+// NotFound, which validation reports as a condition instead.
+func isTransientAPIError(err error) bool {
+	var status apierrors.APIStatus
+	return errors.As(err, &status) && !apierrors.IsNotFound(err)
+}
@@ -1,48 +1,229 @@
 package controller
 
-import "sync"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
 
-// BranchLocker tracks which task owns each workspace+branch combination.
-// TryAcquire atomically checks availability and claims ownership, so the
-// hot path does not depend on the informer cache being up-to-date.
-// The status-based check (checkBranchLock) is kept as a fallback to
-// reconstruct ownership after a controller restart.
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// branchLeasePrefix prefixes the names of the Leases that hold branch
+	// locks.
+	branchLeasePrefix = "kelos-branch-"
+
+	// branchLeaseKeyAnnotation records the lock key of a branch lock Lease,
+	// since the Lease name only contains a hash of it.
+	branchLeaseKeyAnnotation = "kelos.dev/branch-lock-key"
+
+	// defaultBranchLeaseDuration is how long a branch lock stays valid
+	// without being renewed by its holder.
+	defaultBranchLeaseDuration = 5 * time.Minute
+
+	// branchLeaseRenewInterval is how often the lock of an active Task is
+	// renewed.
+	branchLeaseRenewInterval = time.Minute
+)
+
+// BranchLocker grants each workspace+branch combination to one task at a
+// time. Ownership is recorded in a coordination.k8s.io Lease per lock key in
+// the task's namespace, with the owning task as holder identity, so it
+// survives controller restarts and leader failover. Lease writes use
+// optimistic concurrency, so two controllers can never both acquire a lock.
+// A Lease that was not renewed within its duration, or whose holder has
+// finished or is gone, is taken over by the next task.
+//
+// Leases are expected to be read from the API server rather than an
+// informer cache, so ownership never depends on the cache being up-to-date.
+// The status-based check (checkBranchLock) is kept as a fallback for tasks
+// that hold a branch without a Lease.
 type BranchLocker struct {
-	mu     sync.Mutex
-	owners map[string]string // lock key -> owning task name
+	// LeaseDuration is how long a lock stays valid without renewal.
+	// Defaults to 5 minutes.
+	LeaseDuration time.Duration
+
+	// NowFunc returns the current time. Defaults to time.Now.
+	// Overridable in tests for deterministic behavior.
+	NowFunc func() time.Time
 }
 
 // NewBranchLocker creates a new BranchLocker.
 func NewBranchLocker() *BranchLocker {
-	return &BranchLocker{owners: make(map[string]string)}
+	return &BranchLocker{LeaseDuration: defaultBranchLeaseDuration}
+}
+
+func (bl *BranchLocker) now() time.Time {
+	if bl.NowFunc != nil {
+		return bl.NowFunc()
+	}
+	return time.Now()
+}
+
+func (bl *BranchLocker) leaseDuration() time.Duration {
+	if bl.LeaseDuration > 0 {
+		return bl.LeaseDuration
+	}
+	return defaultBranchLeaseDuration
+}
+
+// branchLeaseName returns the name of the Lease for the given lock key.
+func branchLeaseName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return branchLeasePrefix + hex.EncodeToString(sum[:])[:20]
 }
 
 // TryAcquire attempts to claim the branch lock for the given task.
-// Returns (true, "") if acquired, or (false, holder) if another task holds it.
-// Calling TryAcquire again with the same key and taskName is idempotent.
-func (bl *BranchLocker) TryAcquire(key, taskName string) (bool, string) {
-	bl.mu.Lock()
-	defer bl.mu.Unlock()
-	if holder, ok := bl.owners[key]; ok && holder != taskName {
-		return false, holder
+// Returns (true, "") if acquired, or (false, holder) if another task holds
+// it. Calling TryAcquire again for the holder renews its lock.
+func (bl *BranchLocker) TryAcquire(ctx context.Context, c client.Client, task *kelos.Task) (bool, string, error) {
+	var acquired bool
+	var holder string
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		var err error
+		acquired, holder, err = bl.tryAcquire(ctx, c, task)
+		return err
+	})
+	return acquired, holder, err
+}
+
+func (bl *BranchLocker) tryAcquire(ctx context.Context, c client.Client, task *kelos.Task) (bool, string, error) {
+	key := branchLockKey(task)
+	now := bl.now()
+
+	lease := &coordinationv1.Lease{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: branchLeaseName(key)}, lease)
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        branchLeaseName(key),
+				Namespace:   task.Namespace,
+				Annotations: map[string]string{branchLeaseKeyAnnotation: key},
+			},
+		}
+		bl.setHolder(lease, task, now)
+		if err := c.Create(ctx, lease); err != nil {
+			return false, "", err
+		}
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", err
 	}
-	bl.owners[key] = taskName
-	return true, ""
+
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	switch {
+	case holder == task.Name:
+		if !bl.needsRenewal(lease, now) {
+			return true, "", nil
+		}
+	case holder != "" && !bl.expired(lease, now):
+		gone, err := branchLockHolderGone(ctx, c, task.Namespace, holder)
+		if err != nil || !gone {
+			return false, holder, err
+		}
+		log.FromContext(ctx).Info("Taking over branch lock of finished task", "branch", taskBranch(task), "previousHolder", holder)
+	case holder != "":
+		log.FromContext(ctx).Info("Taking over expired branch lock", "branch", taskBranch(task), "previousHolder", holder)
+	}
+
+	bl.setHolder(lease, task, now)
+	if err := c.Update(ctx, lease); err != nil {
+		return false, "", err
+	}
+	return true, "", nil
 }
 
 // Release releases the branch lock if held by the given task.
 // It is safe to call Release even if the task does not hold the lock.
-func (bl *BranchLocker) Release(key, taskName string) {
-	bl.mu.Lock()
-	defer bl.mu.Unlock()
-	if bl.owners[key] == taskName {
-		delete(bl.owners, key)
+func (bl *BranchLocker) Release(ctx context.Context, c client.Client, task *kelos.Task) error {
+	lease := &coordinationv1.Lease{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: branchLeaseName(branchLockKey(task))}, lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != task.Name {
+		return nil
 	}
+	return client.IgnoreNotFound(c.Delete(ctx, lease, client.Preconditions{
+		UID:             &lease.UID,
+		ResourceVersion: &lease.ResourceVersion,
+	}))
 }
 
-// Holder returns the task name holding the given key, or "" if unheld.
-func (bl *BranchLocker) Holder(key string) string {
-	bl.mu.Lock()
-	defer bl.mu.Unlock()
-	return bl.owners[key]
+// Holder returns the name of the task holding the branch lock of the given
+// task, or "" if the lock is unheld or has expired.
+func (bl *BranchLocker) Holder(ctx context.Context, c client.Reader, task *kelos.Task) (string, error) {
+	lease := &coordinationv1.Lease{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: branchLeaseName(branchLockKey(task))}, lease); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if bl.expired(lease, bl.now()) {
+		return "", nil
+	}
+	return ptr.Deref(lease.Spec.HolderIdentity, ""), nil
+}
+
+// setHolder records the task as the holder of the Lease and renews it. The
+// task owns the Lease, so it is garbage collected with the task.
+func (bl *BranchLocker) setHolder(lease *coordinationv1.Lease, task *kelos.Task, now time.Time) {
+	renewTime := metav1.NewMicroTime(now)
+	if previous := lease.Spec.HolderIdentity; previous == nil || *previous != task.Name {
+		lease.Spec.AcquireTime = &renewTime
+		if previous != nil {
+			lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		}
+	}
+	lease.Spec.HolderIdentity = ptr.To(task.Name)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(bl.leaseDuration().Seconds()))
+	lease.Spec.RenewTime = &renewTime
+	lease.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: kelos.GroupVersion.String(),
+		Kind:       "Task",
+		Name:       task.Name,
+		UID:        task.UID,
+	}}
+}
+
+// expired reports whether the holder of the Lease failed to renew it within
+// its duration.
+func (bl *BranchLocker) expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return !now.Before(expiry)
+}
+
+// needsRenewal reports whether the holder should renew the Lease. Renewals
+// are spaced out to avoid a write on every reconcile.
+func (bl *BranchLocker) needsRenewal(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || ptr.Deref(lease.Spec.LeaseDurationSeconds, 0) != int32(bl.leaseDuration().Seconds()) {
+		return true
+	}
+	return now.Sub(lease.Spec.RenewTime.Time) >= branchLeaseRenewInterval/2
+}
+
+// branchLockHolderGone reports whether the task holding a branch lock no
+// longer exists or has finished, so its lock can be taken over before it
+// expires.
+func branchLockHolderGone(ctx context.Context, c client.Reader, namespace, name string) (bool, error) {
+	var holder kelos.Task
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &holder); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return isTerminalTaskPhase(holder.Status.Phase), nil
 }
@@ -1,23 +1,76 @@
 package controller
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newBranchLockTestTask(name, workspace string, phase kelos.TaskPhase) *kelos.Task {
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:         "claude-code",
+			Prompt:       "test",
+			Branch:       "feature-1",
+			WorkspaceRef: &kelos.WorkspaceReference{Name: workspace},
+		},
+		Status: kelos.TaskStatus{Phase: phase},
+	}
+}
+
+func newBranchLockTestClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(objs...).
+		Build()
+}
+
+func mustTryAcquire(t *testing.T, bl *BranchLocker, cl client.Client, task *kelos.Task) (bool, string) {
+	t.Helper()
+	ok, holder, err := bl.TryAcquire(context.Background(), cl, task)
+	if err != nil {
+		t.Fatalf("TryAcquire(%s) error: %v", task.Name, err)
+	}
+	return ok, holder
+}
+
+func mustHolder(t *testing.T, bl *BranchLocker, cl client.Client, task *kelos.Task) string {
+	t.Helper()
+	holder, err := bl.Holder(context.Background(), cl, task)
+	if err != nil {
+		t.Fatalf("Holder() error: %v", err)
+	}
+	return holder
+}
 
 func TestBranchLocker_TryAcquireAndRelease(t *testing.T) {
+	taskA := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseRunning)
+	taskB := newBranchLockTestTask("task-b", "ws", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(taskA, taskB)
 	bl := NewBranchLocker()
 
-	ok, holder := bl.TryAcquire("ws:feature-1", "task-a")
+	ok, holder := mustTryAcquire(t, bl, cl, taskA)
 	if !ok {
 		t.Fatalf("Expected TryAcquire to succeed, got held by %q", holder)
 	}
 
 	// Same task re-acquiring is idempotent.
-	ok, _ = bl.TryAcquire("ws:feature-1", "task-a")
+	ok, _ = mustTryAcquire(t, bl, cl, taskA)
 	if !ok {
 		t.Fatal("Expected idempotent TryAcquire to succeed")
 	}
 
 	// Different task should be rejected.
-	ok, holder = bl.TryAcquire("ws:feature-1", "task-b")
+	ok, holder = mustTryAcquire(t, bl, cl, taskB)
 	if ok {
 		t.Fatal("Expected TryAcquire to fail for different task")
 	}
@@ -26,57 +79,186 @@ func TestBranchLocker_TryAcquireAndRelease(t *testing.T) {
 	}
 
 	// Release and re-acquire by different task.
-	bl.Release("ws:feature-1", "task-a")
-	ok, _ = bl.TryAcquire("ws:feature-1", "task-b")
+	if err := bl.Release(context.Background(), cl, taskA); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	ok, _ = mustTryAcquire(t, bl, cl, taskB)
 	if !ok {
 		t.Fatal("Expected TryAcquire to succeed after release")
 	}
 }
 
+func TestBranchLocker_RecordsLease(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseWaiting)
+	task.UID = "uid-a"
+	cl := newBranchLockTestClient(task)
+	bl := &BranchLocker{LeaseDuration: 2 * time.Minute, NowFunc: func() time.Time { return now }}
+
+	if ok, holder := mustTryAcquire(t, bl, cl, task); !ok {
+		t.Fatalf("Expected TryAcquire to succeed, got held by %q", holder)
+	}
+
+	lease := &coordinationv1.Lease{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: branchLeaseName("ws:feature-1")}, lease); err != nil {
+		t.Fatalf("Getting Lease: %v", err)
+	}
+	if got := ptr.Deref(lease.Spec.HolderIdentity, ""); got != "task-a" {
+		t.Errorf("holderIdentity = %q, want %q", got, "task-a")
+	}
+	if got := ptr.Deref(lease.Spec.LeaseDurationSeconds, 0); got != 120 {
+		t.Errorf("leaseDurationSeconds = %d, want 120", got)
+	}
+	if lease.Spec.RenewTime == nil || !lease.Spec.RenewTime.Time.Equal(now) {
+		t.Errorf("renewTime = %v, want %v", lease.Spec.RenewTime, now)
+	}
+	if got := lease.Annotations[branchLeaseKeyAnnotation]; got != "ws:feature-1" {
+		t.Errorf("lock key annotation = %q, want %q", got, "ws:feature-1")
+	}
+	if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].Kind != "Task" || lease.OwnerReferences[0].UID != "uid-a" {
+		t.Errorf("ownerReferences = %+v, want the holding Task", lease.OwnerReferences)
+	}
+}
+
 func TestBranchLocker_DifferentKeysIndependent(t *testing.T) {
+	taskA := newBranchLockTestTask("task-a", "ws-a", kelos.TaskPhaseRunning)
+	taskB := newBranchLockTestTask("task-b", "ws-b", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(taskA, taskB)
 	bl := NewBranchLocker()
 
-	ok, _ := bl.TryAcquire("ws-a:feature-1", "task-a")
-	if !ok {
+	if ok, _ := mustTryAcquire(t, bl, cl, taskA); !ok {
 		t.Fatal("Expected TryAcquire to succeed")
 	}
 
 	// Different key should succeed independently.
-	ok, _ = bl.TryAcquire("ws-b:feature-1", "task-b")
-	if !ok {
+	if ok, _ := mustTryAcquire(t, bl, cl, taskB); !ok {
 		t.Fatal("Expected TryAcquire on different key to succeed")
 	}
 }
 
 func TestBranchLocker_ReleaseWrongTask(t *testing.T) {
+	taskA := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseRunning)
+	taskB := newBranchLockTestTask("task-b", "ws", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(taskA, taskB)
 	bl := NewBranchLocker()
 
-	bl.TryAcquire("ws:feature-1", "task-a")
+	mustTryAcquire(t, bl, cl, taskA)
 
 	// Releasing with wrong task name should be a no-op.
-	bl.Release("ws:feature-1", "task-b")
+	if err := bl.Release(context.Background(), cl, taskB); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
 
-	if h := bl.Holder("ws:feature-1"); h != "task-a" {
+	if h := mustHolder(t, bl, cl, taskA); h != "task-a" {
 		t.Errorf("Expected holder %q after wrong release, got %q", "task-a", h)
 	}
 }
 
 func TestBranchLocker_ReleaseUnheldKey(t *testing.T) {
-	bl := NewBranchLocker()
+	task := newBranchLockTestTask("task-a", "nonexistent", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(task)
 
-	// Should not panic.
-	bl.Release("ws:nonexistent", "task-a")
+	if err := NewBranchLocker().Release(context.Background(), cl, task); err != nil {
+		t.Errorf("Release() error: %v", err)
+	}
 }
 
 func TestBranchLocker_Holder(t *testing.T) {
+	task := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(task)
 	bl := NewBranchLocker()
 
-	if h := bl.Holder("ws:feature-1"); h != "" {
+	if h := mustHolder(t, bl, cl, task); h != "" {
 		t.Errorf("Expected empty holder, got %q", h)
 	}
 
-	bl.TryAcquire("ws:feature-1", "task-a")
-	if h := bl.Holder("ws:feature-1"); h != "task-a" {
+	mustTryAcquire(t, bl, cl, task)
+	if h := mustHolder(t, bl, cl, task); h != "task-a" {
 		t.Errorf("Expected holder %q, got %q", "task-a", h)
 	}
 }
+
+func TestBranchLocker_TakesOverExpiredLease(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	taskA := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseRunning)
+	taskB := newBranchLockTestTask("task-b", "ws", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(taskA, taskB)
+	bl := &BranchLocker{LeaseDuration: time.Minute, NowFunc: func() time.Time { return now }}
+
+	mustTryAcquire(t, bl, cl, taskA)
+
+	now = now.Add(59 * time.Second)
+	if ok, holder := mustTryAcquire(t, bl, cl, taskB); ok || holder != "task-a" {
+		t.Fatalf("TryAcquire() = %v, %q; want held by task-a before the lease expires", ok, holder)
+	}
+
+	now = now.Add(time.Second)
+	if h := mustHolder(t, bl, cl, taskB); h != "" {
+		t.Errorf("Expected no holder for an expired lease, got %q", h)
+	}
+	if ok, holder := mustTryAcquire(t, bl, cl, taskB); !ok {
+		t.Fatalf("Expected TryAcquire to take over the expired lease, got held by %q", holder)
+	}
+
+	lease := &coordinationv1.Lease{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: branchLeaseName("ws:feature-1")}, lease); err != nil {
+		t.Fatalf("Getting Lease: %v", err)
+	}
+	if got := ptr.Deref(lease.Spec.LeaseTransitions, 0); got != 1 {
+		t.Errorf("leaseTransitions = %d, want 1", got)
+	}
+}
+
+func TestBranchLocker_RenewKeepsLease(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	taskA := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseRunning)
+	taskB := newBranchLockTestTask("task-b", "ws", kelos.TaskPhaseWaiting)
+	cl := newBranchLockTestClient(taskA, taskB)
+	bl := &BranchLocker{LeaseDuration: time.Minute, NowFunc: func() time.Time { return now }}
+
+	mustTryAcquire(t, bl, cl, taskA)
+	now = now.Add(45 * time.Second)
+	if ok, _ := mustTryAcquire(t, bl, cl, taskA); !ok {
+		t.Fatal("Expected the holder to renew its lease")
+	}
+
+	now = now.Add(45 * time.Second)
+	if ok, holder := mustTryAcquire(t, bl, cl, taskB); ok || holder != "task-a" {
+		t.Errorf("TryAcquire() = %v, %q; want held by task-a after renewal", ok, holder)
+	}
+}
+
+func TestBranchLocker_TakesOverLeaseOfFinishedTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		holder *kelos.Task
+	}{
+		{name: "succeeded holder", holder: newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseSucceeded)},
+		{name: "failed holder", holder: newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseFailed)},
+		{name: "deleted holder"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskA := newBranchLockTestTask("task-a", "ws", kelos.TaskPhaseRunning)
+			taskB := newBranchLockTestTask("task-b", "ws", kelos.TaskPhaseWaiting)
+			objs := []client.Object{taskB}
+			if tt.holder != nil {
+				objs = append(objs, tt.holder)
+			}
+			cl := newBranchLockTestClient(objs...)
+			bl := NewBranchLocker()
+
+			// Acquire on behalf of task-a, which has since finished or
+			// been deleted without releasing its lock.
+			mustTryAcquire(t, bl, cl, taskA)
+
+			if ok, holder := mustTryAcquire(t, bl, cl, taskB); !ok {
+				t.Fatalf("Expected TryAcquire to take over the lock, got held by %q", holder)
+			}
+			if h := mustHolder(t, bl, cl, taskB); h != "task-b" {
+				t.Errorf("Expected holder %q, got %q", "task-b", h)
+			}
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -171,6 +173,79 @@ func TestTaskBudgetReconciler_RefreshesUsed(t *testing.T) {
 	}
 }
 
+func TestTaskBudgetReconciler_SoftLimitReached(t *testing.T) {
+	scheme := newWorkerPoolTestScheme()
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	softLimit := int32(80)
+
+	budget := &kelos.TaskBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "b1", Namespace: "default"},
+		Spec: kelos.TaskBudgetSpec{
+			Period:           kelos.BudgetPeriod{Type: kelos.BudgetPeriodRolling, Window: "24h"},
+			MaxCostUSD:       mustQuantity("100"),
+			SoftLimitPercent: &softLimit,
+		},
+	}
+	rec := &kelos.TaskRecord{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "default"},
+		Spec: kelos.TaskRecordSpec{
+			TaskRef:        kelos.TaskReference{Name: "t1", UID: "u1"},
+			Phase:          kelos.TaskPhaseSucceeded,
+			CompletionTime: nowTime(now.Add(-23 * time.Hour)),
+			Usage:          &kelos.TaskUsage{CostUSD: mustQuantity("85")},
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.TaskBudget{}).
+		WithObjects(budget, rec).
+		Build()
+
+	recorder := record.NewFakeRecorder(10)
+	r := &TaskBudgetReconciler{Client: cl, Scheme: scheme, Recorder: recorder, NowFunc: func() time.Time { return now }}
+	key := types.NamespacedName{Name: "b1", Namespace: "default"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile error: %v", err)
+		}
+	}
+
+	var got kelos.TaskBudget
+	if err := cl.Get(context.Background(), key, &got); err != nil {
+		t.Fatalf("Get budget: %v", err)
+	}
+	cond := meta.FindStatusCondition(got.Status.Conditions, kelos.TaskBudgetConditionSoftLimitReached)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Fatalf("SoftLimitReached condition = %+v, want True", cond)
+	}
+	if want := "cost 85 reached 80% of limit 100"; cond.Message != want {
+		t.Errorf("SoftLimitReached message = %q, want %q", cond.Message, want)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("recorded %d events, want exactly 1", len(recorder.Events))
+	}
+	if event := <-recorder.Events; event != `Warning SoftLimitReached Budget "b1" reached its soft limit: cost 85 reached 80% of limit 100` {
+		t.Errorf("event = %q", event)
+	}
+
+	// Once the record ages out of the rolling window, the condition clears.
+	now = now.Add(2 * time.Hour)
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if err := cl.Get(context.Background(), key, &got); err != nil {
+		t.Fatalf("Get budget: %v", err)
+	}
+	if meta.FindStatusCondition(got.Status.Conditions, kelos.TaskBudgetConditionSoftLimitReached) != nil {
+		t.Errorf("SoftLimitReached condition still set after usage aged out: %+v", got.Status.Conditions)
+	}
+	if got.Status.Used == nil || got.Status.Used.CostUSD != nil {
+		t.Errorf("status.used = %+v, want no cost", got.Status.Used)
+	}
+}
+
 func TestTaskBudgetReconciler_EnqueueBudgetsForRecord(t *testing.T) {
 	scheme := newWorkerPoolTestScheme()
 	// Empty selector matches all records in the namespace.
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"os/exec"
@@ -500,3 +501,217 @@ func assertPathMissing(t *testing.T, path string) {
 		t.Fatalf("checking %s: %v", path, err)
 	}
 }
+
+func TestAgentEntrypointsContinueConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		entrypoint string
+		binary     string
+		wantArgs   string
+	}{
+		{name: "claude-code", entrypoint: "../../claude-code/kelos_entrypoint.sh", binary: "claude", wantArgs: "--continue"},
+		{name: "codex", entrypoint: "../../codex/kelos_entrypoint.sh", binary: "codex", wantArgs: "resume --last follow-up prompt"},
+		{name: "opencode", entrypoint: "../../opencode/kelos_entrypoint.sh", binary: "opencode", wantArgs: "--continue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			home := filepath.Join(tmp, "home")
+			if err := os.MkdirAll(home, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			argsPath := filepath.Join(tmp, "args")
+			agentPath := filepath.Join(tmp, tt.binary)
+			writeFile(t, agentPath, "#!/bin/bash\nprintf '%s\\n' \"$*\" >\""+argsPath+"\"\n")
+			if err := os.Chmod(agentPath, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			capturePath := filepath.Join(tmp, "kelos-capture")
+			writeFile(t, capturePath, "#!/bin/bash\ncat\n")
+			if err := os.Chmod(capturePath, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			entrypointData, err := os.ReadFile(tt.entrypoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entrypointPath := filepath.Join(tmp, "kelos_entrypoint.sh")
+			writeFile(t, entrypointPath, strings.ReplaceAll(string(entrypointData), "/kelos/kelos-capture", capturePath))
+
+			command := exec.Command("bash", entrypointPath, "follow-up prompt")
+			command.Dir = tmp
+			command.Env = []string{
+				"HOME=" + home,
+				"PATH=" + tmp + ":/usr/bin:/bin",
+				"KELOS_CONTINUE=1",
+			}
+			if output, err := command.CombinedOutput(); err != nil {
+				t.Fatalf("running entrypoint: %v\n%s", err, output)
+			}
+			data, err := os.ReadFile(argsPath)
+			if err != nil {
+				t.Fatalf("reading agent arguments: %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantArgs) {
+				t.Errorf("agent arguments = %q, want them to contain %q", strings.TrimSpace(string(data)), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCodexEntrypointResetsPersistedConversationConfig(t *testing.T) {
+	tmp := t.TempDir()
+	home := filepath.Join(tmp, "home")
+	codexHome := filepath.Join(tmp, "kelos-conversation", "codex-home")
+	writeFile(t, filepath.Join(codexHome, "config.toml"), "[mcp_servers.tools]\ncommand = \"tools-server\"\n")
+	writeFile(t, filepath.Join(codexHome, "sessions", "rollout.jsonl"), "conversation\n")
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	agentPath := filepath.Join(tmp, "codex")
+	writeFile(t, agentPath, "#!/bin/bash\ntrue\n")
+	if err := os.Chmod(agentPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	entrypointData, err := os.ReadFile("../../codex/kelos_entrypoint.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entrypointPath := filepath.Join(tmp, "kelos_entrypoint.sh")
+	writeFile(t, entrypointPath, strings.ReplaceAll(string(entrypointData), "/kelos/kelos-capture", "cat"))
+
+	command := exec.Command("bash", entrypointPath, "test prompt")
+	command.Dir = tmp
+	command.Env = []string{
+		"HOME=" + home,
+		"PATH=" + tmp + ":/usr/bin:/bin",
+		"CODEX_HOME=" + codexHome,
+		"KELOS_CONVERSATION=1",
+	}
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("running Codex entrypoint: %v\n%s", err, output)
+	}
+	assertFileContent(t, filepath.Join(codexHome, "config.toml"), "")
+	assertFileContent(t, filepath.Join(codexHome, "sessions", "rollout.jsonl"), "conversation\n")
+}
+
+func TestAgentEntrypointsTranslatePermissions(t *testing.T) {
+	const permissions = `{"allowedTools":["shell","read","edit","mcp:github/get_issue"],` +
+		`"deniedTools":["mcp:slack"],"deniedCommands":["rm -rf *","kubectl *"],"deniedPaths":[".github/workflows/**"]}`
+	runSetup := func(t *testing.T, entrypoint string, env ...string) {
+		t.Helper()
+		tmp := t.TempDir()
+		path, err := filepath.Abs(entrypoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		command := exec.Command("bash", path)
+		command.Dir = tmp
+		command.Env = append(os.Environ(), append([]string{
+			"HOME=" + tmp,
+			"KELOS_SESSION_SETUP_ONLY=1",
+			"KELOS_PERMISSIONS=" + permissions,
+		}, env...)...)
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("running %s setup: %v\n%s", entrypoint, err, output)
+		}
+	}
+	readJSON := func(t *testing.T, path string, value any) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, value); err != nil {
+			t.Fatalf("decoding %s: %v", path, err)
+		}
+	}
+
+	t.Run("claude-code", func(t *testing.T) {
+		configDir := filepath.Join(t.TempDir(), "claude")
+		writeFile(t, filepath.Join(configDir, "settings.json"), `{"permissions":{"deny":["Bash(rm -rf *)"]}}`)
+		// Setup runs on every Session start and must not repeat rules.
+		for range 2 {
+			runSetup(t, "../../claude-code/kelos_entrypoint.sh", "CLAUDE_CONFIG_DIR="+configDir)
+		}
+		var settings struct {
+			Permissions struct {
+				Deny []string `json:"deny"`
+			} `json:"permissions"`
+			Hooks map[string][]struct {
+				Matcher string `json:"matcher"`
+			} `json:"hooks"`
+		}
+		readJSON(t, filepath.Join(configDir, "settings.json"), &settings)
+		want := []string{
+			"Bash(rm -rf *)", "WebFetch", "WebSearch", "mcp__slack", "Bash(kubectl *)", "Edit(.github/workflows/**)",
+		}
+		if strings.Join(settings.Permissions.Deny, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("Claude deny rules = %q, want %q", settings.Permissions.Deny, want)
+		}
+		if hooks := settings.Hooks["PreToolUse"]; len(hooks) != 1 || hooks[0].Matcher != "mcp__.*" {
+			t.Fatalf("Claude PreToolUse hooks = %#v, want one MCP hook", hooks)
+		}
+
+		hook := exec.Command("node", filepath.Join(configDir, "kelos-permissions-hook.js"), filepath.Join(configDir, "kelos-permissions.json"))
+		hook.Stdin = strings.NewReader(`{"tool_name":"mcp__github__create_issue"}`)
+		output, err := hook.Output()
+		if err != nil || !strings.Contains(string(output), `"permissionDecision":"deny"`) {
+			t.Fatalf("Claude permission hook = %s, %v, want a denial", output, err)
+		}
+		hook = exec.Command("node", filepath.Join(configDir, "kelos-permissions-hook.js"), filepath.Join(configDir, "kelos-permissions.json"))
+		hook.Stdin = strings.NewReader(`{"tool_name":"mcp__github__get_issue"}`)
+		if output, err := hook.Output(); err != nil || len(output) != 0 {
+			t.Fatalf("Claude permission hook = %s, %v, want no decision", output, err)
+		}
+	})
+
+	t.Run("codex", func(t *testing.T) {
+		codexHome := filepath.Join(t.TempDir(), "codex")
+		runSetup(t, "../../codex/kelos_entrypoint.sh",
+			"CODEX_HOME="+codexHome,
+			`KELOS_MCP_SERVERS={"mcpServers":{"github":{"url":"https://mcp.example.com"},"slack":{"command":"slack-mcp"}}}`,
+		)
+		assertFileContent(t, filepath.Join(codexHome, "rules", "kelos.rules"),
+			`prefix_rule(pattern = ["rm","-rf"], decision = "forbidden", justification = "denied by the Kelos permission policy: rm -rf *")`+"\n"+
+				`prefix_rule(pattern = ["kubectl"], decision = "forbidden", justification = "denied by the Kelos permission policy: kubectl *")`+"\n")
+		assertFileContent(t, filepath.Join(codexHome, "config.toml"), `web_search = "disabled"
+[mcp_servers."github"]
+enabled_tools = ["get_issue"]
+url = "https://mcp.example.com"
+
+[mcp_servers."slack"]
+enabled = false
+command = "slack-mcp"
+
+`)
+	})
+
+	t.Run("opencode", func(t *testing.T) {
+		configDir := filepath.Join(t.TempDir(), "opencode")
+		writeFile(t, filepath.Join(configDir, "opencode.json"), `{"permission":{"bash":"allow"}}`)
+		runSetup(t, "../../opencode/kelos_entrypoint.sh", "OPENCODE_CONFIG_DIR="+configDir)
+		data, err := os.ReadFile(filepath.Join(configDir, "opencode.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config struct {
+			Permission json.RawMessage `json:"permission"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatal(err)
+		}
+		// OpenCode applies the last matching rule, so the order is checked too.
+		want := `{"webfetch":"deny","websearch":"deny","github_*":"deny","github_get_issue":"allow","slack_*":"deny",` +
+			`"bash":{"*":"allow","rm -rf *":"deny","kubectl *":"deny"},"edit":{".github/workflows/**":"deny"}}`
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, config.Permission); err != nil {
+			t.Fatal(err)
+		}
+		if compact.String() != want {
+			t.Fatalf("OpenCode permissions = %s, want %s", compact.String(), want)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kelos-dev/kelos/internal/inuse"
+)
+
+// inUseProtectionRetryInterval is how often the users of a deleted object
+// that is still in use are checked again.
+const inUseProtectionRetryInterval = 10 * time.Second
+
+// reconcileInUseProtection adds finalizer to obj, or removes it from a
+// deleted obj once users returns no users. It returns true once the deleted
+// obj may be removed, in which case the caller must stop reconciling it.
+// While a deleted obj is still in use, the result requeues it and the caller
+// keeps reconciling it so that its users keep working.
+func reconcileInUseProtection(ctx context.Context, c client.Client, recorder record.EventRecorder, obj client.Object, finalizer string, users func() ([]inuse.User, error)) (bool, ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if obj.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(obj, finalizer) {
+			controllerutil.AddFinalizer(obj, finalizer)
+			if err := c.Update(ctx, obj); err != nil {
+				logger.Error(err, "Unable to add protection finalizer")
+				return false, ctrl.Result{}, err
+			}
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, finalizer) {
+		return true, ctrl.Result{}, nil
+	}
+	blocking, err := users()
+	if err != nil {
+		logger.Error(err, "Unable to list users of deleted object")
+		return false, ctrl.Result{}, err
+	}
+	if len(blocking) > 0 {
+		names := make([]string, len(blocking))
+		for i, user := range blocking {
+			names[i] = user.String()
+		}
+		if recorder != nil {
+			recorder.Eventf(obj, corev1.EventTypeWarning, "DeletionBlocked", "Deletion is blocked while in use by %s", strings.Join(names, ", "))
+		}
+		logger.Info("Deletion blocked while in use", "users", names)
+		return false, ctrl.Result{RequeueAfter: inUseProtectionRetryInterval}, nil
+	}
+
+	controllerutil.RemoveFinalizer(obj, finalizer)
+	if err := c.Update(ctx, obj); err != nil {
+		logger.Error(err, "Unable to remove protection finalizer")
+		return true, ctrl.Result{}, err
+	}
+	return true, ctrl.Result{}, nil
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/inuse"
+)
+
+func TestWorkspaceReconciler_InUseProtection(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git"},
+	}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "fix-bug", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Prompt: "Fix the bug",
+			Worker: &kelos.WorkerSpec{Type: AgentTypeClaudeCode, WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"}},
+		},
+		Status: kelos.TaskStatus{Phase: kelos.TaskPhaseRunning},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(workspace, task).
+		WithObjects(workspace, task).
+		Build()
+	r := &WorkspaceReconciler{Client: cl, Scheme: scheme, ProxyBuilder: NewWorkspaceGHProxyBuilder()}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(workspace)}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	var got kelos.Workspace
+	if err := cl.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("Getting Workspace: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(&got, inuse.WorkspaceProtectionFinalizer) {
+		t.Fatalf("Finalizers = %v, want %s", got.Finalizers, inuse.WorkspaceProtectionFinalizer)
+	}
+
+	// The running Task keeps the deleted Workspace.
+	if err := cl.Delete(ctx, &got); err != nil {
+		t.Fatalf("Deleting Workspace: %v", err)
+	}
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if result.RequeueAfter != inUseProtectionRetryInterval {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, inUseProtectionRetryInterval)
+	}
+	if err := cl.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("Expected the Workspace to remain while in use: %v", err)
+	}
+
+	// Once the Task finishes, the Workspace is removed.
+	task.Status.Phase = kelos.TaskPhaseSucceeded
+	if err := cl.Status().Update(ctx, task); err != nil {
+		t.Fatalf("Updating Task status: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if err := cl.Get(ctx, req.NamespacedName, &got); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the Workspace to be removed, got %v", err)
+	}
+}
+
+func TestAgentConfigReconciler_InUseProtection(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	org := newAgentConfig("org", "", kelos.AgentConfigSpec{})
+	org.Namespace = "default"
+	org.Finalizers = []string{inuse.AgentConfigProtectionFinalizer}
+	team := newAgentConfig("team", "org", kelos.AgentConfigSpec{})
+	team.Namespace = "default"
+	team.Finalizers = []string{inuse.AgentConfigProtectionFinalizer}
+	pool := &kelos.WorkerPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec: kelos.WorkerPoolSpec{Worker: kelos.WorkerSpec{
+			Type:            AgentTypeCodex,
+			AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+		}},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(org, team).
+		WithObjects(org, team, pool).
+		Build()
+	r := &AgentConfigReconciler{Client: cl, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(org)}
+
+	if err := cl.Delete(ctx, org); err != nil {
+		t.Fatalf("Deleting AgentConfig: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	var got kelos.AgentConfig
+	if err := cl.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("Expected the AgentConfig to remain while extended: %v", err)
+	}
+
+	// Deleting the extending AgentConfig does not release the base while
+	// the WorkerPool still uses it through that AgentConfig.
+	if err := cl.Delete(ctx, team); err != nil {
+		t.Fatalf("Deleting AgentConfig: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if err := cl.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("Expected the AgentConfig to remain while in use: %v", err)
+	}
+
+	if err := cl.Delete(ctx, pool); err != nil {
+		t.Fatalf("Deleting WorkerPool: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if err := cl.Get(ctx, req.NamespacedName, &got); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the AgentConfig to be removed, got %v", err)
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"k8s.io/utils/ptr"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
+	"github.com/kelos-dev/kelos/internal/artifacts"
+	"github.com/kelos-dev/kelos/internal/resultschema"
 )
 
 const (
@@ -324,6 +327,49 @@ func credentialEnvVars(creds kelos.Credentials, agentType string) []corev1.EnvVa
 	}
 }
 
+// artifactEnvVars returns the environment variables that configure the
+// artifact upload done by kelos-capture. Storage credentials are read from
+// the Secret under KELOS_ARTIFACTS_* names so that they do not clash with
+// AWS credentials the agent itself may use.
+func artifactEnvVars(task *kelos.Task) ([]corev1.EnvVar, error) {
+	spec := task.Spec.Artifacts
+	s3 := spec.Storage.S3
+	paths, err := json.Marshal(spec.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("encoding artifact paths: %w", err)
+	}
+
+	secretEnvRef := func(name, key string, optional bool) corev1.EnvVar {
+		sel := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: s3.SecretRef.Name},
+			Key:                  key,
+		}
+		if optional {
+			sel.Optional = ptr.To(true)
+		}
+		return corev1.EnvVar{
+			Name:      name,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: sel},
+		}
+	}
+
+	envVars := []corev1.EnvVar{
+		{Name: artifacts.EnvPaths, Value: string(paths)},
+		{Name: artifacts.EnvDir, Value: WorkspaceMountPath + "/repo"},
+		{Name: artifacts.EnvEndpoint, Value: s3.Endpoint},
+		{Name: artifacts.EnvBucket, Value: s3.Bucket},
+		{Name: artifacts.EnvKeyPrefix, Value: artifacts.KeyPrefix(s3.Prefix, task.Namespace, task.Name)},
+	}
+	if s3.Region != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: artifacts.EnvRegion, Value: s3.Region})
+	}
+	return append(envVars,
+		secretEnvRef(artifacts.EnvAccessKeyID, artifacts.SecretKeyAccessKeyID, false),
+		secretEnvRef(artifacts.EnvSecretAccessKey, artifacts.SecretKeySecretAccessKey, false),
+		secretEnvRef(artifacts.EnvSessionToken, artifacts.SecretKeySessionToken, true),
+	), nil
+}
+
 func effectiveWorkspaceRemotes(workspace *kelos.WorkspaceSpec) []kelos.GitRemote {
 	if workspace == nil {
 		return nil
@@ -370,10 +416,10 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 		})
 	}
 
-	if task.Spec.Branch != "" {
+	if taskBranch(task) != "" {
 		envVars = append(envVars, corev1.EnvVar{
 			Name:  "KELOS_BRANCH",
-			Value: task.Spec.Branch,
+			Value: taskBranch(task),
 		})
 	}
 
@@ -399,7 +445,7 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 	effectiveRemotes := effectiveWorkspaceRemotes(workspace)
 	if workspace != nil {
 		host, _, _ := parseGitHubRepo(workspace.Repo)
-		isEnterprise = host != "" && host != "github.com"
+		isEnterprise = !workspaceIsGitLab(workspace) && host != "" && host != "github.com"
 
 		if isEnterprise {
 			// Set GH_HOST for GitHub Enterprise so that gh CLI targets the correct host.
@@ -430,7 +476,11 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 		}
 	}
 
-	if workspace != nil && workspace.SecretRef != nil {
+	if workspaceIsGitLab(workspace) {
+		agentEnv, initEnv := gitLabWorkspaceEnvVars(workspace)
+		envVars = append(envVars, agentEnv...)
+		workspaceEnvVars = append(workspaceEnvVars, initEnv...)
+	} else if workspace != nil && workspace.SecretRef != nil {
 		secretKeyRef := &corev1.SecretKeySelector{
 			LocalObjectReference: corev1.LocalObjectReference{
 				Name: workspace.SecretRef.Name,
@@ -511,25 +561,13 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 		}
 
 		targetPath := WorkspaceMountPath + "/repo"
-		commitRef := isFullGitCommitSHA(workspace.Ref)
 
 		// Workspace volume mounts shared by every container that needs
 		// the cloned repo plus, when a token Secret is configured, the
 		// auto-syncing token file.
 		workspaceVolumeMounts := []corev1.VolumeMount{volumeMount}
 		if workspace.SecretRef != nil {
-			volumes = append(volumes, corev1.Volume{
-				Name: GitHubTokenVolumeName,
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: workspace.SecretRef.Name,
-						Items: []corev1.KeyToPath{
-							{Key: GitHubTokenSecretKey, Path: GitHubTokenSecretKey},
-						},
-						Optional: ptr.To(true),
-					},
-				},
-			})
+			volumes = append(volumes, workspaceTokenVolume(workspace))
 			workspaceVolumeMounts = append(workspaceVolumeMounts, corev1.VolumeMount{
 				Name:      GitHubTokenVolumeName,
 				MountPath: GitHubTokenMountPath,
@@ -537,68 +575,29 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 			})
 		}
 
-		cloneArgs := []string{"clone"}
-		if workspace.Ref != "" && !commitRef {
-			cloneArgs = append(cloneArgs, "--branch", workspace.Ref)
-		}
-		cloneArgs = append(cloneArgs, "--no-single-branch", "--depth", "1", "--", workspace.Repo, targetPath)
-
-		initContainer := corev1.Container{
-			Name:         "git-clone",
-			Image:        GitCloneImage,
-			Args:         cloneArgs,
-			Env:          workspaceEnvVars,
-			VolumeMounts: append([]corev1.VolumeMount(nil), workspaceVolumeMounts...),
-			SecurityContext: &corev1.SecurityContext{
-				RunAsUser: &agentUID,
-			},
-		}
-
-		if commitRef {
-			credentialHelper := ""
-			if workspace.SecretRef != nil {
-				credentialHelper = gitCredentialHelper()
-			}
-			initContainer.Command = []string{"sh", "-c", buildCommitRefCheckoutScript(credentialHelper)}
-			initContainer.Args = []string{"--", workspace.Repo, targetPath, workspace.Ref}
-		} else if workspace.SecretRef != nil {
-			credentialHelper := gitCredentialHelper()
-			credentialConfig := workspaceGitCredentialConfigScript(credentialHelper)
-			// Clear inherited credential helpers with an empty -c credential.helper=
-			// before setting the workspace helper, then persist the same
-			// configuration into the repo so the agent container is
-			// independent from global/system helpers.
-			initContainer.Command = []string{"sh", "-c",
-				fmt.Sprintf(
-					`git -c credential.helper= -c credential.helper='%s' -c credential.username=%s "$@" && { `+
-						`%s; }`,
-					credentialHelper, gitCredentialDefaultUsername, credentialConfig,
-				),
-			}
-			initContainer.Args = append([]string{"--"}, cloneArgs...)
+		credentialHelper := ""
+		if workspace.SecretRef != nil {
+			credentialHelper = workspaceCredentialHelper(workspace)
+		}
+		// Clone with the Workspace's git mirror as a reference, if it has one,
+		// so only the delta is fetched from the remote.
+		cacheReference := workspaceCacheReference(task, workspace)
+		gitClone := gitCloneContainer("git-clone", workspace.Repo, workspace.Ref, targetPath, credentialHelper, cacheReference, workspace.Clone, workspaceEnvVars, workspaceVolumeMounts, agentUID)
+		if cacheReference != "" {
+			volumes = append(volumes, workspaceCacheVolume(resolveTaskWorkspaceRef(task).Name))
+			gitClone.VolumeMounts = append(gitClone.VolumeMounts, corev1.VolumeMount{
+				Name:      WorkspaceCacheVolumeName,
+				MountPath: WorkspaceCacheMountPath,
+				ReadOnly:  true,
+			})
 		}
-
-		initContainers = append(initContainers, initContainer)
+		initContainers = append(initContainers, gitClone)
 
 		if len(effectiveRemotes) > 0 {
-			var parts []string
-			parts = append(parts, fmt.Sprintf("cd %s/repo", WorkspaceMountPath))
-			for _, r := range effectiveRemotes {
-				parts = append(parts,
-					fmt.Sprintf(
-						"if git remote get-url %s >/dev/null 2>&1; then git remote set-url %s %s; else git remote add %s %s; fi",
-						shellQuote(r.Name),
-						shellQuote(r.Name),
-						shellQuote(r.URL),
-						shellQuote(r.Name),
-						shellQuote(r.URL),
-					),
-				)
-			}
 			remoteSetupContainer := corev1.Container{
 				Name:         "remote-setup",
 				Image:        GitCloneImage,
-				Command:      []string{"sh", "-c", strings.Join(parts, " && ")},
+				Command:      []string{"sh", "-c", remoteSetupScript(targetPath, effectiveRemotes)},
 				VolumeMounts: []corev1.VolumeMount{volumeMount},
 				SecurityContext: &corev1.SecurityContext{
 					RunAsUser: &agentUID,
@@ -607,49 +606,17 @@ func (b *JobBuilder) buildAgentJob(task *kelos.Task, workspace *kelos.WorkspaceS
 			initContainers = append(initContainers, remoteSetupContainer)
 		}
 
-		if task.Spec.Branch != "" {
-			remoteGit := "git"
-			if workspace.SecretRef != nil {
-				credHelper := gitCredentialHelper()
-				remoteGit = fmt.Sprintf(
-					`git -c credential.helper= -c credential.helper='%s' -c credential.username=%s`,
-					credHelper, gitCredentialDefaultUsername,
-				)
-			}
-			branchSetupScript := fmt.Sprintf(
-				`set -e
-cd %s/repo
-remote_status=0
-%s ls-remote --exit-code --heads origin "refs/heads/$KELOS_BRANCH" >/dev/null || remote_status=$?
-if [ "$remote_status" -eq 0 ]; then
-  %s fetch origin "refs/heads/$KELOS_BRANCH"
-  if git show-ref --verify --quiet "refs/heads/$KELOS_BRANCH"; then
-    git checkout "$KELOS_BRANCH"
-    git merge --ff-only FETCH_HEAD
-  else
-    git checkout -b "$KELOS_BRANCH" FETCH_HEAD
-  fi
-elif [ "$remote_status" -eq 2 ]; then
-  if git show-ref --verify --quiet "refs/heads/$KELOS_BRANCH"; then
-    git checkout "$KELOS_BRANCH"
-  else
-    git checkout -b "$KELOS_BRANCH"
-  fi
-else
-  exit "$remote_status"
-fi`,
-				WorkspaceMountPath, remoteGit, remoteGit,
-			)
+		if taskBranch(task) != "" {
 			branchEnv := make([]corev1.EnvVar, len(workspaceEnvVars), len(workspaceEnvVars)+1)
 			copy(branchEnv, workspaceEnvVars)
 			branchEnv = append(branchEnv, corev1.EnvVar{
 				Name:  "KELOS_BRANCH",
-				Value: task.Spec.Branch,
+				Value: taskBranch(task),
 			})
 			branchSetupContainer := corev1.Container{
 				Name:         "branch-setup",
 				Image:        GitCloneImage,
-				Command:      []string{"sh", "-c", branchSetupScript},
+				Command:      []string{"sh", "-c", branchSetupScript(targetPath, credentialHelper, workspace.Clone)},
 				Env:          branchEnv,
 				VolumeMounts: append([]corev1.VolumeMount(nil), workspaceVolumeMounts...),
 				SecurityContext: &corev1.SecurityContext{
@@ -659,6 +626,18 @@ fi`,
 			initContainers = append(initContainers, branchSetupContainer)
 		}
 
+		var repositoryVolumeMounts []corev1.VolumeMount
+		if len(workspace.Repositories) > 0 {
+			repos, err := buildWorkspaceRepositories(task, workspace, workspaceEnvVars, workspaceVolumeMounts, agentUID)
+			if err != nil {
+				return nil, err
+			}
+			initContainers = append(initContainers, repos.initContainers...)
+			volumes = append(volumes, repos.volumes...)
+			repositoryVolumeMounts = repos.agentVolumeMounts
+			mainContainer.Env = append(mainContainer.Env, repos.agentEnv...)
+		}
+
 		if len(workspace.Files) > 0 {
 			injectionScript, err := buildWorkspaceFileInjectionScript(workspace.Files)
 			if err != nil {
@@ -689,18 +668,97 @@ fi`,
 		}
 
 		mainContainer.VolumeMounts = append([]corev1.VolumeMount(nil), workspaceVolumeMounts...)
+		mainContainer.VolumeMounts = append(mainContainer.VolumeMounts, repositoryVolumeMounts...)
 		mainContainer.WorkingDir = WorkspaceMountPath + "/repo"
 	}
 
-	// Inject AgentConfig: agentsMD env var and plugin volume/init container.
+	// Hold git push until the Task is approved. The pre-push hook waits for
+	// the controller to annotate the agent pod, which it observes through a
+	// downward API volume.
+	pushApproval := workspace != nil && taskApprovalMode(task) == kelos.ApprovalModeBeforePush
+	var podAnnotations map[string]string
+	if pushApproval {
+		var repoDirs []string
+		for _, repo := range workspace.Repositories {
+			repoDirs = append(repoDirs, workspaceRepositoryDir(repo))
+		}
+		initContainers = append(initContainers, pushApprovalHookContainer(agentUID, repoDirs...))
+		volumes = append(volumes, pushApprovalVolume())
+		mainContainer.VolumeMounts = append(mainContainer.VolumeMounts, corev1.VolumeMount{
+			Name:      PushApprovalVolumeName,
+			MountPath: PushApprovalMountPath,
+			ReadOnly:  true,
+		})
+		if _, approved := taskApprovedBy(task); approved {
+			podAnnotations = map[string]string{podAnnotationPushApproved: "true"}
+		}
+	}
+
+	var agentsMD string
 	if agentConfig != nil {
-		if agentConfig.AgentsMD != "" {
-			mainContainer.Env = append(mainContainer.Env, corev1.EnvVar{
-				Name:  "KELOS_AGENTS_MD",
-				Value: agentConfig.AgentsMD,
-			})
+		agentsMD = agentConfig.AgentsMD
+	}
+	if workspace != nil && len(workspace.Repositories) > 0 {
+		if agentsMD != "" {
+			agentsMD += "\n\n"
+		}
+		agentsMD += workspaceRepositoriesInstructions(workspace.Repositories)
+	}
+	if pushApproval {
+		if agentsMD != "" {
+			agentsMD += "\n\n"
+		}
+		agentsMD += pushApprovalInstructions
+	}
+
+	// Tell the agent where and in which shape to report structured results.
+	// The instructions are appended to the user-level agent instructions so
+	// that every agent type picks them up.
+	if task.Spec.ResultsSchema != "" {
+		schema, err := resultschema.Compile(task.Spec.ResultsSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resultsSchema: %w", err)
+		}
+		mainContainer.Env = append(mainContainer.Env,
+			corev1.EnvVar{Name: "KELOS_RESULTS_SCHEMA", Value: schema.JSON()},
+			corev1.EnvVar{Name: "KELOS_RESULTS_FILE", Value: resultschema.FilePath},
+		)
+		if agentsMD != "" {
+			agentsMD += "\n\n"
+		}
+		agentsMD += schema.Instructions()
+	}
+
+	if agentsMD != "" {
+		mainContainer.Env = append(mainContainer.Env, corev1.EnvVar{
+			Name:  "KELOS_AGENTS_MD",
+			Value: agentsMD,
+		})
+	}
+
+	// Configure kelos-capture to upload artifacts after the agent exits.
+	if task.Spec.Artifacts != nil && task.Spec.Artifacts.Storage.S3 != nil {
+		envVars, err := artifactEnvVars(task)
+		if err != nil {
+			return nil, err
 		}
+		mainContainer.Env = append(mainContainer.Env, envVars...)
+	}
 
+	// Have kelos-capture report the running usage so the controller can
+	// enforce the spend limit while the agent runs.
+	if task.Spec.SpendLimit != nil {
+		if err := checkSpendLimitAgentType(task.Spec.SpendLimit, agentType); err != nil {
+			return nil, fmt.Errorf("invalid spend limit: %w", err)
+		}
+		mainContainer.Env = append(mainContainer.Env, corev1.EnvVar{
+			Name:  "KELOS_REPORT_USAGE",
+			Value: "true",
+		})
+	}
+
+	// Inject AgentConfig: plugin volume/init container.
+	if agentConfig != nil {
 		needsPluginVolume := len(agentConfig.Plugins) > 0 || len(agentConfig.Skills) > 0
 		if needsPluginVolume {
 			volumes = append(volumes, corev1.Volume{
@@ -783,6 +841,14 @@ fi`,
 				Value: mcpJSON,
 			})
 		}
+
+		permissionsEnv, err := buildPermissionsEnvVar(agentConfig.Permissions, agentType)
+		if err != nil {
+			return nil, err
+		}
+		if permissionsEnv != nil {
+			mainContainer.Env = append(mainContainer.Env, *permissionsEnv)
+		}
 	}
 
 	// Apply PodOverrides before constructing the Job so all overrides
@@ -941,7 +1007,8 @@ fi`,
 			ActiveDeadlineSeconds: activeDeadlineSeconds,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: jobLabels,
+					Labels:      jobLabels,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					RestartPolicy:      corev1.RestartPolicyNever,
@@ -1034,12 +1101,19 @@ func isFullGitCommitSHA(ref string) bool {
 	return true
 }
 
-func buildCommitRefCheckoutScript(credentialHelper string) string {
-	fetchCmd := `git -C "$target" fetch --depth 1 origin "$ref"`
+func buildCommitRefCheckoutScript(credentialHelper, reference string, clone *kelos.WorkspaceCloneOptions) string {
+	fetchFlags := ""
+	if depth := workspaceCloneDepth(clone); depth > 0 {
+		fetchFlags += fmt.Sprintf(" --depth %d", depth)
+	}
+	if clone != nil && clone.Filter != "" {
+		fetchFlags += " --filter=" + clone.Filter
+	}
+	fetchCmd := `git -C "$target" fetch` + fetchFlags + ` origin "$ref"`
 	if credentialHelper != "" {
 		fetchCmd = fmt.Sprintf(
-			`git -C "$target" -c credential.helper= -c credential.helper='%s' -c credential.username=%s fetch --depth 1 origin "$ref"`,
-			credentialHelper, gitCredentialDefaultUsername,
+			`git -C "$target" -c credential.helper= -c credential.helper='%s' -c credential.username=%s fetch%s origin "$ref"`,
+			credentialHelper, gitCredentialDefaultUsername, fetchFlags,
 		)
 	}
 
@@ -1050,9 +1124,38 @@ func buildCommitRefCheckoutScript(credentialHelper string) string {
 		"ref=$3",
 		`git init "$target"`,
 		`git -C "$target" remote add origin "$repo"`,
+	}
+	if clone != nil && clone.Filter != "" {
+		// Mirror the configuration git clone --filter writes so later
+		// fetches lazily download the filtered objects.
+		lines = append(lines,
+			`git -C "$target" config core.repositoryformatversion 1`,
+			`git -C "$target" config extensions.partialClone origin`,
+			`git -C "$target" config remote.origin.promisor true`,
+			`git -C "$target" config remote.origin.partialclonefilter `+shellQuote(clone.Filter),
+		)
+	}
+	if sparse := sparseCheckoutCommand(`"$target"`, clone); sparse != "" {
+		lines = append(lines, sparse)
+	}
+	if reference != "" {
+		// Borrow objects from the reference like git clone --reference-if-able.
+		lines = append(lines, fmt.Sprintf(
+			`if [ -d %s ]; then echo %s >> "$target/.git/objects/info/alternates"; fi`,
+			shellQuote(reference+"/objects"), shellQuote(reference+"/objects"),
+		))
+	}
+	lines = append(lines,
 		fetchCmd,
 		`git -C "$target" checkout --detach FETCH_HEAD`,
+	)
+	if reference != "" {
+		// Copy the borrowed objects like git clone --dissociate.
+		lines = append(lines,
+			`if [ -f "$target/.git/objects/info/alternates" ]; then git -C "$target" repack -a -d -q && rm -f "$target/.git/objects/info/alternates"; fi`,
+		)
 	}
+	lines = append(lines, workspaceCheckoutCommands(`"$target"`, remoteGitCommand(credentialHelper), clone)...)
 
 	if credentialHelper != "" {
 		lines = append(lines,
@@ -1079,22 +1182,89 @@ func gitCredentialHelper() string {
 }
 
 func gitCredentialHelperForTokenFile(tokenFile string) string {
+	return gitCredentialHelperForToken(tokenFile, "GITHUB_TOKEN")
+}
+
+// gitCredentialHelperForToken returns the inline git credential helper that
+// reads the token from tokenFile, falling back to the env var envName.
+func gitCredentialHelperForToken(tokenFile, envName string) string {
 	return fmt.Sprintf(
-		`!f() { if [ -r %q ]; then echo "password=$(cat %q)"; else echo "password=$GITHUB_TOKEN"; fi; }; f`,
-		tokenFile, tokenFile,
+		`!f() { if [ -r %q ]; then echo "password=$(cat %q)"; else echo "password=$%s"; fi; }; f`,
+		tokenFile, tokenFile, envName,
 	)
 }
 
 func workspaceGitCredentialConfigScript(credentialHelper string) string {
+	return gitCredentialConfigScript(WorkspaceMountPath+"/repo", credentialHelper)
+}
+
+// gitCredentialConfigScript returns the shell commands that persist
+// credentialHelper as the only credential helper of the repository in dir.
+func gitCredentialConfigScript(dir, credentialHelper string) string {
 	return fmt.Sprintf(
-		`git -C %s/repo config --unset-all credential.helper 2>/dev/null || true; `+
-			`git -C %s/repo config --add credential.helper '%s' && `+
-			`git -C %s/repo config credential.username %s`,
-		WorkspaceMountPath, WorkspaceMountPath, credentialHelper,
-		WorkspaceMountPath, gitCredentialDefaultUsername,
+		`git -C %s config --unset-all credential.helper 2>/dev/null || true; `+
+			`git -C %s config --add credential.helper '%s' && `+
+			`git -C %s config credential.username %s`,
+		dir, dir, credentialHelper,
+		dir, gitCredentialDefaultUsername,
 	)
 }
 
+// remoteSetupScript returns the shell commands that add or update remotes in
+// the repository in dir.
+func remoteSetupScript(dir string, remotes []kelos.GitRemote) string {
+	parts := []string{"cd " + dir}
+	for _, r := range remotes {
+		parts = append(parts,
+			fmt.Sprintf(
+				"if git remote get-url %s >/dev/null 2>&1; then git remote set-url %s %s; else git remote add %s %s; fi",
+				shellQuote(r.Name),
+				shellQuote(r.Name),
+				shellQuote(r.URL),
+				shellQuote(r.Name),
+				shellQuote(r.URL),
+			),
+		)
+	}
+	return strings.Join(parts, " && ")
+}
+
+// branchSetupScript returns the script that checks out $KELOS_BRANCH in the
+// repository in dir, tracking the remote branch when it already exists, and
+// then updates submodules and LFS objects as configured by clone. Remote
+// operations authenticate with credentialHelper when it is set.
+func branchSetupScript(dir, credentialHelper string, clone *kelos.WorkspaceCloneOptions) string {
+	remoteGit := remoteGitCommand(credentialHelper)
+	script := fmt.Sprintf(
+		`set -e
+cd %s
+remote_status=0
+%s ls-remote --exit-code --heads origin "refs/heads/$KELOS_BRANCH" >/dev/null || remote_status=$?
+if [ "$remote_status" -eq 0 ]; then
+  %s fetch%s origin "refs/heads/$KELOS_BRANCH"
+  if git show-ref --verify --quiet "refs/heads/$KELOS_BRANCH"; then
+    git checkout "$KELOS_BRANCH"
+    git merge --ff-only FETCH_HEAD
+  else
+    git checkout -b "$KELOS_BRANCH" FETCH_HEAD
+  fi
+elif [ "$remote_status" -eq 2 ]; then
+  if git show-ref --verify --quiet "refs/heads/$KELOS_BRANCH"; then
+    git checkout "$KELOS_BRANCH"
+  else
+    git checkout -b "$KELOS_BRANCH"
+  fi
+else
+  exit "$remote_status"
+fi`,
+		dir, remoteGit, remoteGit, workspaceFetchDepthFlag(clone),
+	)
+	for _, cmd := range workspaceCheckoutCommands(dir, remoteGit, clone) {
+		script += "\n" + cmd
+	}
+	return script
+}
+
 // reservedVolumeNames is the set of non-prefixed volume names that Kelos
 // manages internally. PodOverrides.Volumes entries must not use these names
 // or the Kelos-reserved volume prefix.
@@ -1495,3 +1665,24 @@ func envVarsToMap(serverName string, env []corev1.EnvVar) (map[string]string, er
 	}
 	return out, nil
 }
+
+// buildPermissionsEnvVar returns the KELOS_PERMISSIONS env var that passes
+// the permission policy of an AgentConfig to the agent entrypoint, or nil
+// when there is no policy. A policy that agentType cannot enforce is an
+// error, so that the agent never runs without it.
+func buildPermissionsEnvVar(permissions *kelos.AgentPermissions, agentType string) (*corev1.EnvVar, error) {
+	if agentpermissions.Empty(permissions) {
+		return nil, nil
+	}
+	if err := agentpermissions.Validate(permissions); err != nil {
+		return nil, fmt.Errorf("invalid permissions configuration: %w", err)
+	}
+	if err := agentpermissions.CheckAgentType(permissions, agentType); err != nil {
+		return nil, fmt.Errorf("invalid permissions configuration: %w", err)
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling permissions: %w", err)
+	}
+	return &corev1.EnvVar{Name: agentpermissions.EnvVar, Value: string(data)}, nil
+}
@@ -7,10 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/artifacts"
+	"github.com/kelos-dev/kelos/internal/resultschema"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -4417,6 +4420,94 @@ func TestBuildJob_AgentConfigMCPServersCodex(t *testing.T) {
 	}
 }
 
+func TestBuildJob_AgentConfigPermissions(t *testing.T) {
+	builder := NewJobBuilder()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-permissions",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Fix issue",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+		},
+	}
+
+	agentConfig := &kelos.AgentConfigSpec{
+		Permissions: &kelos.AgentPermissions{
+			DeniedCommands: []string{"rm -rf *", "kubectl *"},
+			DeniedPaths:    []string{".github/workflows/**"},
+		},
+	}
+
+	job, err := builder.Build(task, nil, agentConfig, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	var permissionsJSON string
+	for _, env := range job.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "KELOS_PERMISSIONS" {
+			permissionsJSON = env.Value
+		}
+	}
+	if permissionsJSON == "" {
+		t.Fatal("Expected KELOS_PERMISSIONS env var to be set")
+	}
+	var parsed kelos.AgentPermissions
+	if err := json.Unmarshal([]byte(permissionsJSON), &parsed); err != nil {
+		t.Fatalf("Failed to parse KELOS_PERMISSIONS JSON: %v", err)
+	}
+	if !reflect.DeepEqual(&parsed, agentConfig.Permissions) {
+		t.Errorf("KELOS_PERMISSIONS = %+v, want %+v", parsed, agentConfig.Permissions)
+	}
+}
+
+func TestBuildJob_AgentConfigPermissionsUnsupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		agentType   string
+		permissions *kelos.AgentPermissions
+		wantErrStr  string
+	}{
+		{
+			name:        "gemini",
+			agentType:   AgentTypeGemini,
+			permissions: &kelos.AgentPermissions{DeniedCommands: []string{"rm -rf *"}},
+			wantErrStr:  "agent type gemini does not support permissions",
+		},
+		{
+			name:        "codex denied paths",
+			agentType:   AgentTypeCodex,
+			permissions: &kelos.AgentPermissions{DeniedPaths: []string{".github/**"}},
+			wantErrStr:  "codex cannot enforce permissions.deniedPaths",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-permissions", Namespace: "default"},
+				Spec: kelos.TaskSpec{
+					Type:   tt.agentType,
+					Prompt: "Fix issue",
+					Credentials: &kelos.Credentials{
+						Type:      kelos.CredentialTypeAPIKey,
+						SecretRef: &kelos.SecretReference{Name: "my-secret"},
+					},
+				},
+			}
+			_, err := NewJobBuilder().Build(task, nil, &kelos.AgentConfigSpec{Permissions: tt.permissions}, task.Spec.Prompt)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrStr) {
+				t.Errorf("Build() error = %v, want an error containing %q", err, tt.wantErrStr)
+			}
+		})
+	}
+}
+
 func TestBuildJob_AgentConfigMCPServersGemini(t *testing.T) {
 	builder := NewJobBuilder()
 	task := &kelos.Task{
@@ -6370,3 +6461,143 @@ func TestBuildJob_WorkerSpecOverridesLegacy(t *testing.T) {
 			"worker-secret", apiKeyEnv.ValueFrom.SecretKeyRef.LocalObjectReference.Name)
 	}
 }
+
+func TestBuildJob_ResultsSchema(t *testing.T) {
+	builder := NewJobBuilder()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-results-schema",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Triage the issue",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+			ResultsSchema: "type: object\nrequired: [severity]\n",
+		},
+	}
+
+	job, err := builder.Build(task, nil, &kelos.AgentConfigSpec{AgentsMD: "Follow TDD"}, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	envMap := map[string]string{}
+	for _, env := range job.Spec.Template.Spec.Containers[0].Env {
+		envMap[env.Name] = env.Value
+	}
+	if want := `{"required":["severity"],"type":"object"}`; envMap["KELOS_RESULTS_SCHEMA"] != want {
+		t.Errorf("Expected KELOS_RESULTS_SCHEMA=%q, got %q", want, envMap["KELOS_RESULTS_SCHEMA"])
+	}
+	if envMap["KELOS_RESULTS_FILE"] != resultschema.FilePath {
+		t.Errorf("Expected KELOS_RESULTS_FILE=%q, got %q", resultschema.FilePath, envMap["KELOS_RESULTS_FILE"])
+	}
+	agentsMD := envMap["KELOS_AGENTS_MD"]
+	if !strings.HasPrefix(agentsMD, "Follow TDD\n\n## Structured results") {
+		t.Errorf("Expected KELOS_AGENTS_MD to keep the AgentConfig instructions and add the results instructions, got %q", agentsMD)
+	}
+	if !strings.Contains(agentsMD, resultschema.FilePath) {
+		t.Errorf("Expected KELOS_AGENTS_MD to name the results file, got %q", agentsMD)
+	}
+}
+
+func TestBuildJob_InvalidResultsSchema(t *testing.T) {
+	builder := NewJobBuilder()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-invalid-results-schema",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Triage the issue",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+			ResultsSchema: `{"type": `,
+		},
+	}
+
+	_, err := builder.Build(task, nil, nil, task.Spec.Prompt)
+	if err == nil || !strings.Contains(err.Error(), "invalid resultsSchema") {
+		t.Fatalf("Expected invalid resultsSchema error, got %v", err)
+	}
+}
+
+func TestBuildJob_Artifacts(t *testing.T) {
+	builder := NewJobBuilder()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-artifacts",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Run the benchmarks",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "my-workspace"},
+			Artifacts: &kelos.TaskArtifacts{
+				Paths: []string{"bench/**", "report.md"},
+				Storage: kelos.ArtifactStorage{
+					S3: &kelos.S3ArtifactStorage{
+						Endpoint:  "http://minio.minio:9000",
+						Bucket:    "kelos-artifacts",
+						Prefix:    "runs",
+						SecretRef: kelos.SecretReference{Name: "minio-creds"},
+					},
+				},
+			},
+		},
+	}
+	workspace := &kelos.WorkspaceSpec{Repo: "https://github.com/example/repo.git"}
+
+	job, err := builder.Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	env := map[string]corev1.EnvVar{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	for name, want := range map[string]string{
+		artifacts.EnvPaths:     `["bench/**","report.md"]`,
+		artifacts.EnvDir:       WorkspaceMountPath + "/repo",
+		artifacts.EnvEndpoint:  "http://minio.minio:9000",
+		artifacts.EnvBucket:    "kelos-artifacts",
+		artifacts.EnvKeyPrefix: "runs/default/test-artifacts/",
+	} {
+		if got := env[name].Value; got != want {
+			t.Errorf("Expected %s=%q, got %q", name, want, got)
+		}
+	}
+	if _, ok := env[artifacts.EnvRegion]; ok {
+		t.Errorf("Expected %s to be unset when no region is configured", artifacts.EnvRegion)
+	}
+
+	for name, key := range map[string]string{
+		artifacts.EnvAccessKeyID:     artifacts.SecretKeyAccessKeyID,
+		artifacts.EnvSecretAccessKey: artifacts.SecretKeySecretAccessKey,
+		artifacts.EnvSessionToken:    artifacts.SecretKeySessionToken,
+	} {
+		e, ok := env[name]
+		if !ok || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil {
+			t.Fatalf("Expected %s to reference the storage Secret, got %+v", name, e)
+		}
+		ref := e.ValueFrom.SecretKeyRef
+		if ref.Name != "minio-creds" || ref.Key != key {
+			t.Errorf("Expected %s to reference minio-creds/%s, got %s/%s", name, key, ref.Name, ref.Key)
+		}
+		wantOptional := name == artifacts.EnvSessionToken
+		if got := ref.Optional != nil && *ref.Optional; got != wantOptional {
+			t.Errorf("Expected %s optional=%v, got %v", name, wantOptional, got)
+		}
+	}
+}
@@ -34,6 +34,28 @@ var (
 		[]string{"namespace", "type", "phase"},
 	)
 
+	// taskCloneDurationSeconds records how long the git-clone init container
+	// of a Task pod ran. The cached label reports whether the clone used the
+	// Workspace's git mirror.
+	taskCloneDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kelos_task_clone_duration_seconds",
+			Help:    "Duration of the workspace clone of Task pods",
+			Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+		},
+		[]string{"namespace", "type", "cached"},
+	)
+
+	// taskRetriesTotal counts the total number of failed Task attempts that
+	// were retried under a retryPolicy.
+	taskRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kelos_task_retries_total",
+			Help: "Total number of failed Task attempts that were retried",
+		},
+		[]string{"namespace", "type", "reason"},
+	)
+
 	// reconcileErrorsTotal counts the total number of reconciliation errors.
 	reconcileErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -76,6 +98,8 @@ func init() {
 		taskCreatedTotal,
 		taskCompletedTotal,
 		taskDurationSeconds,
+		taskCloneDurationSeconds,
+		taskRetriesTotal,
 		reconcileErrorsTotal,
 		taskCostUSD,
 		taskInputTokens,
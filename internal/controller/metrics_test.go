@@ -19,6 +19,7 @@ func TestMetricsRegistered(t *testing.T) {
 		{"taskCreatedTotal", taskCreatedTotal},
 		{"taskCompletedTotal", taskCompletedTotal},
 		{"taskDurationSeconds", taskDurationSeconds},
+		{"taskRetriesTotal", taskRetriesTotal},
 		{"reconcileErrorsTotal", reconcileErrorsTotal},
 		{"taskCostUSD", taskCostUSD},
 		{"taskInputTokens", taskInputTokens},
@@ -1,10 +1,14 @@
 package controller
 
-import "strings"
+import (
+	"encoding/json"
+	"strings"
+)
 
 const (
 	outputStartMarker = "---KELOS_OUTPUTS_START---"
 	outputEndMarker   = "---KELOS_OUTPUTS_END---"
+	usageReportMarker = "---KELOS_USAGE---"
 )
 
 // ParseOutputs extracts output lines from the last complete
@@ -61,3 +65,37 @@ func ResultsFromOutputs(outputs []string) map[string]string {
 	}
 	return result
 }
+
+// ArtifactsFromOutputs returns the object keys reported by "artifact: <key>"
+// output lines, in order.
+func ArtifactsFromOutputs(outputs []string) []string {
+	var keys []string
+	for _, line := range outputs {
+		key, value, ok := strings.Cut(line, ": ")
+		if ok && key == "artifact" && value != "" {
+			keys = append(keys, value)
+		}
+	}
+	return keys
+}
+
+// ParseUsageReport returns the usage from the last
+// ---KELOS_USAGE--- {"input-tokens":"N",...} line in logData, using the same
+// keys as the results. kelos-capture writes these lines while the agent runs
+// when usage reporting is enabled. Returns nil if logData has no valid
+// report.
+func ParseUsageReport(logData string) map[string]string {
+	lines := strings.Split(logData, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		data, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), usageReportMarker+" ")
+		if !ok {
+			continue
+		}
+		var usage map[string]string
+		if err := json.Unmarshal([]byte(data), &usage); err != nil || len(usage) == 0 {
+			continue
+		}
+		return usage
+	}
+	return nil
+}
@@ -229,3 +229,88 @@ func TestResultsFromOutputs(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactsFromOutputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		outputs  []string
+		expected []string
+	}{
+		{
+			name:     "nil outputs",
+			outputs:  nil,
+			expected: nil,
+		},
+		{
+			name:     "no artifacts",
+			outputs:  []string{"branch: main", "commit: abc123"},
+			expected: nil,
+		},
+		{
+			name: "artifacts keep their order",
+			outputs: []string{
+				"branch: main",
+				"artifact: default/task-1/report.md",
+				"artifact: default/task-1/screenshots/home.png",
+			},
+			expected: []string{"default/task-1/report.md", "default/task-1/screenshots/home.png"},
+		},
+		{
+			name:     "empty keys are skipped",
+			outputs:  []string{"artifact: ", "artifact:"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ArtifactsFromOutputs(tt.outputs)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseUsageReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		logData  string
+		expected map[string]string
+	}{
+		{
+			name:     "no report",
+			logData:  `{"type":"turn.completed","usage":{"input_tokens":100,"output_tokens":50}}`,
+			expected: nil,
+		},
+		{
+			name: "last report wins",
+			logData: `---KELOS_USAGE--- {"input-tokens":"100","output-tokens":"50"}
+{"type":"turn.started"}
+---KELOS_USAGE--- {"input-tokens":"300","output-tokens":"200"}
+{"type":"turn.started"}
+`,
+			expected: map[string]string{"input-tokens": "300", "output-tokens": "200"},
+		},
+		{
+			name: "malformed report falls back to the previous one",
+			logData: `---KELOS_USAGE--- {"cost-usd":"0.5"}
+---KELOS_USAGE--- {"cost-usd":`,
+			expected: map[string]string{"cost-usd": "0.5"},
+		},
+		{
+			name:     "marker inside an agent line is ignored",
+			logData:  `{"type":"text","text":"---KELOS_USAGE--- {\"input-tokens\":\"1\"}"}`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseUsageReport(tt.logData)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,650 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/taskbuilder"
+)
+
+// defaultPipelineForEachMaxItems is the fan-out limit applied when
+// forEach.maxItems is unset.
+const defaultPipelineForEachMaxItems = 20
+
+// PipelineReconciler reconciles a Pipeline object. Each reconcile walks the
+// steps in dependency order, creates the Tasks of steps whose dependencies
+// have finished and whose runIf condition holds, and aggregates the phases,
+// outputs, results and usage of the step Tasks into the Pipeline status.
+type PipelineReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=kelos.dev,resources=pipelines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kelos.dev,resources=pipelines/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=pipelines/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kelos.dev,resources=tasks,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile advances a Pipeline by creating the Tasks of ready steps and
+// recording the state of every step.
+func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var pipeline kelos.Pipeline
+	if err := r.Get(ctx, req.NamespacedName, &pipeline); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pipeline.DeletionTimestamp.IsZero() || isTerminalPipelinePhase(pipeline.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	order, err := pipelineStepOrder(pipeline.Spec.Steps)
+	if err != nil {
+		logger.Info("Invalid pipeline", "error", err)
+		r.recordEvent(&pipeline, corev1.EventTypeWarning, "InvalidPipeline", "%v", err)
+		return ctrl.Result{}, r.updatePipelineStatus(ctx, &pipeline, func(status *kelos.PipelineStatus) {
+			status.Phase = kelos.PipelinePhaseFailed
+			status.Message = fmt.Sprintf("Invalid pipeline: %v", err)
+			now := metav1.Now()
+			if status.StartTime == nil {
+				status.StartTime = &now
+			}
+			status.CompletionTime = &now
+		})
+	}
+
+	var taskList kelos.TaskList
+	if err := r.List(ctx, &taskList,
+		client.InNamespace(pipeline.Namespace),
+		client.MatchingLabels{kelos.PipelineLabel: pipeline.Name},
+	); err != nil {
+		logger.Error(err, "Unable to list Tasks of Pipeline")
+		return ctrl.Result{}, err
+	}
+	tasks := make(map[string]*kelos.Task, len(taskList.Items))
+	for i := range taskList.Items {
+		t := &taskList.Items[i]
+		if metav1.IsControlledBy(t, &pipeline) {
+			tasks[t.Name] = t
+		}
+	}
+
+	steps := make(map[string]*kelos.PipelineStepStatus, len(pipeline.Spec.Steps))
+	for i := range pipeline.Status.Steps {
+		steps[pipeline.Status.Steps[i].Name] = pipeline.Status.Steps[i].DeepCopy()
+	}
+	for _, step := range order {
+		st, ok := steps[step.Name]
+		if !ok {
+			st = &kelos.PipelineStepStatus{Name: step.Name, Phase: kelos.PipelineStepPhasePending}
+			steps[step.Name] = st
+		}
+		if err := r.reconcileStep(ctx, &pipeline, step, st, steps, tasks); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := aggregatePipelineStatus(&pipeline, steps)
+	if err := r.updatePipelineStatus(ctx, &pipeline, func(s *kelos.PipelineStatus) {
+		*s = status
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	switch pipeline.Status.Phase {
+	case kelos.PipelinePhaseSucceeded:
+		logger.Info("Pipeline succeeded")
+		r.recordEvent(&pipeline, corev1.EventTypeNormal, "PipelineSucceeded", "Pipeline succeeded")
+	case kelos.PipelinePhaseFailed:
+		logger.Info("Pipeline failed", "message", pipeline.Status.Message)
+		r.recordEvent(&pipeline, corev1.EventTypeWarning, "PipelineFailed", "%s", pipeline.Status.Message)
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileStep advances a single step. Steps are visited in dependency
+// order, so the status of every dependency in steps is already current.
+func (r *PipelineReconciler) reconcileStep(ctx context.Context, pipeline *kelos.Pipeline, step *kelos.PipelineStep, st *kelos.PipelineStepStatus, steps map[string]*kelos.PipelineStepStatus, tasks map[string]*kelos.Task) error {
+	logger := log.FromContext(ctx)
+
+	if isTerminalPipelineStepPhase(st.Phase) {
+		return nil
+	}
+	if len(st.Tasks) > 0 {
+		observePipelineStepTasks(step, st, tasks)
+		if st.Phase == kelos.PipelineStepPhaseFailed {
+			r.recordEvent(pipeline, corev1.EventTypeWarning, "StepFailed", "Step %q failed: %s", step.Name, st.Message)
+		}
+		return nil
+	}
+
+	for _, dep := range step.DependsOn {
+		if !isTerminalPipelineStepPhase(steps[dep].Phase) {
+			st.Phase = kelos.PipelineStepPhasePending
+			st.Message = fmt.Sprintf("Waiting for step %q", dep)
+			return nil
+		}
+	}
+
+	if ok, reason := evaluatePipelineRunIf(step, steps); !ok {
+		st.Phase = kelos.PipelineStepPhaseSkipped
+		st.Message = reason
+		r.recordEvent(pipeline, corev1.EventTypeNormal, "StepSkipped", "Skipped step %q: %s", step.Name, reason)
+		return nil
+	}
+
+	stepTasks, err := r.buildStepTasks(pipeline, step, steps, tasks)
+	if err != nil {
+		st.Phase = kelos.PipelineStepPhaseFailed
+		st.Message = err.Error()
+		r.recordEvent(pipeline, corev1.EventTypeWarning, "StepFailed", "Step %q failed: %v", step.Name, err)
+		return nil
+	}
+	if len(stepTasks) == 0 {
+		st.Phase = kelos.PipelineStepPhaseSucceeded
+		st.Message = "No items to process"
+		return nil
+	}
+
+	names := make([]string, 0, len(stepTasks))
+	created := make([]*kelos.Task, 0, len(stepTasks))
+	for _, task := range stepTasks {
+		if err := r.Create(ctx, task); err != nil {
+			if apierrors.IsInvalid(err) {
+				return r.failStepCreatingTasks(ctx, pipeline, step, st, created, fmt.Sprintf("Invalid Task %q: %v", task.Name, err))
+			}
+			if !apierrors.IsAlreadyExists(err) {
+				logger.Error(err, "Unable to create Task for Pipeline step", "step", step.Name, "task", task.Name)
+				return err
+			}
+			// The Task was created by an earlier reconcile, unless an
+			// unrelated Task has taken its name.
+			var existing kelos.Task
+			if err := r.Get(ctx, client.ObjectKeyFromObject(task), &existing); err != nil {
+				logger.Error(err, "Unable to get existing Task for Pipeline step", "step", step.Name, "task", task.Name)
+				return err
+			}
+			if !metav1.IsControlledBy(&existing, pipeline) {
+				return r.failStepCreatingTasks(ctx, pipeline, step, st, created, fmt.Sprintf("Task %q already exists and is not controlled by this Pipeline", task.Name))
+			}
+		}
+		names = append(names, task.Name)
+		created = append(created, task)
+	}
+
+	st.Tasks = names
+	st.Phase = kelos.PipelineStepPhaseRunning
+	st.Message = ""
+	logger.Info("Started Pipeline step", "step", step.Name, "tasks", len(names))
+	r.recordEvent(pipeline, corev1.EventTypeNormal, "StepStarted", "Created %d Task(s) for step %q", len(names), step.Name)
+	return nil
+}
+
+// failStepCreatingTasks fails a step whose Tasks could not all be created.
+// The Tasks already created for the step are deleted so that they do not run
+// without being tracked in the step status.
+func (r *PipelineReconciler) failStepCreatingTasks(ctx context.Context, pipeline *kelos.Pipeline, step *kelos.PipelineStep, st *kelos.PipelineStepStatus, created []*kelos.Task, message string) error {
+	for _, task := range created {
+		if err := r.Delete(ctx, task); client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).Error(err, "Unable to delete Task of failed Pipeline step", "step", step.Name, "task", task.Name)
+			return err
+		}
+	}
+	st.Phase = kelos.PipelineStepPhaseFailed
+	st.Message = message
+	r.recordEvent(pipeline, corev1.EventTypeWarning, "StepFailed", "Step %q failed: %s", step.Name, st.Message)
+	return nil
+}
+
+// buildStepTasks renders the Tasks of a step. forEach steps produce one Task
+// per item; other steps produce a single Task. Each Task lists the Tasks of
+// the succeeded dependency steps in dependsOn, so the Task controller's
+// dependency checks still apply before the agent starts.
+func (r *PipelineReconciler) buildStepTasks(pipeline *kelos.Pipeline, step *kelos.PipelineStep, steps map[string]*kelos.PipelineStepStatus, tasks map[string]*kelos.Task) ([]*kelos.Task, error) {
+	if step.TaskTemplate.PromptTemplate == "" {
+		return nil, fmt.Errorf("taskTemplate.promptTemplate is required")
+	}
+	builder, err := taskbuilder.NewTaskBuilder(r.Client)
+	if err != nil {
+		return nil, err
+	}
+	template := pipelineStepTaskTemplate(&step.TaskTemplate)
+
+	vars := pipelineTemplateVars(pipeline, step, steps)
+	baseName := pipeline.Name + "-" + step.Name
+
+	var dependsOn []string
+	if step.TaskTemplate.WorkerPoolRef == nil {
+		for _, dep := range step.DependsOn {
+			if steps[dep].Phase != kelos.PipelineStepPhaseSucceeded {
+				continue
+			}
+			for _, name := range steps[dep].Tasks {
+				if _, ok := tasks[name]; ok {
+					dependsOn = append(dependsOn, name)
+				}
+			}
+		}
+	}
+
+	type stepTask struct {
+		name string
+		vars map[string]interface{}
+	}
+	var pending []stepTask
+	if step.ForEach == nil {
+		pending = append(pending, stepTask{name: truncateResourceName(baseName), vars: vars})
+	} else {
+		items, err := pipelineForEachItems(step.ForEach, steps[step.ForEach.Step])
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			itemVars := make(map[string]interface{}, len(vars)+2)
+			for k, v := range vars {
+				itemVars[k] = v
+			}
+			itemVars["Item"] = item
+			itemVars["Index"] = i
+			pending = append(pending, stepTask{name: truncateResourceName(fmt.Sprintf("%s-%d", baseName, i)), vars: itemVars})
+		}
+	}
+
+	out := make([]*kelos.Task, 0, len(pending))
+	for _, p := range pending {
+		task, err := builder.BuildTask(p.name, pipeline.Namespace, template, p.vars, nil)
+		if err != nil {
+			return nil, fmt.Errorf("building Task %q: %w", p.name, err)
+		}
+		if task.Labels == nil {
+			task.Labels = make(map[string]string)
+		}
+		task.Labels[kelos.PipelineLabel] = pipeline.Name
+		task.Labels[kelos.PipelineStepLabel] = step.Name
+		if len(dependsOn) > 0 {
+			task.Spec.DependsOn = dependsOn
+		}
+		if err := controllerutil.SetControllerReference(pipeline, task, r.Scheme); err != nil {
+			return nil, fmt.Errorf("setting owner reference on Task %q: %w", p.name, err)
+		}
+		out = append(out, task)
+	}
+	return out, nil
+}
+
+// pipelineStepTaskTemplate returns the TaskTemplate that the Tasks of a step
+// with the given template are built from.
+func pipelineStepTaskTemplate(template *kelos.PipelineTaskTemplate) *kelos.TaskTemplate {
+	return &kelos.TaskTemplate{
+		Worker:                template.Worker,
+		WorkerPoolRef:         template.WorkerPoolRef,
+		Branch:                template.Branch,
+		PromptTemplate:        template.PromptTemplate,
+		PodFailurePolicy:      template.PodFailurePolicy,
+		RetryPolicy:           template.RetryPolicy,
+		ResultsSchema:         template.ResultsSchema,
+		Approval:              template.Approval,
+		SpendLimit:            template.SpendLimit,
+		Priority:              template.Priority,
+		WaitingTimeoutSeconds: template.WaitingTimeoutSeconds,
+		PendingTimeoutSeconds: template.PendingTimeoutSeconds,
+		Metadata:              template.Metadata,
+		UpstreamRepo:          template.UpstreamRepo,
+	}
+}
+
+// updatePipelineStatus applies mutate to the latest Pipeline status and
+// writes it when it changed.
+func (r *PipelineReconciler) updatePipelineStatus(ctx context.Context, pipeline *kelos.Pipeline, mutate func(*kelos.PipelineStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(pipeline), pipeline); getErr != nil {
+			return getErr
+		}
+		before := pipeline.Status.DeepCopy()
+		mutate(&pipeline.Status)
+		if reflect.DeepEqual(before, &pipeline.Status) {
+			return nil
+		}
+		return r.Status().Update(ctx, pipeline)
+	})
+}
+
+// recordEvent records a Kubernetes Event on the given object if a Recorder is configured.
+func (r *PipelineReconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kelos.Pipeline{}).
+		Owns(&kelos.Task{}).
+		Complete(r)
+}
+
+func isTerminalPipelinePhase(phase kelos.PipelinePhase) bool {
+	return phase == kelos.PipelinePhaseSucceeded || phase == kelos.PipelinePhaseFailed
+}
+
+func isTerminalPipelineStepPhase(phase kelos.PipelineStepPhase) bool {
+	return phase == kelos.PipelineStepPhaseSucceeded ||
+		phase == kelos.PipelineStepPhaseFailed ||
+		phase == kelos.PipelineStepPhaseSkipped
+}
+
+// pipelineStepOrder validates the step graph and returns the steps ordered so
+// that every step comes after its dependencies. Unknown dependencies and
+// cycles are reported as errors.
+func pipelineStepOrder(steps []kelos.PipelineStep) ([]*kelos.PipelineStep, error) {
+	byName := make(map[string]*kelos.PipelineStep, len(steps))
+	for i := range steps {
+		byName[steps[i].Name] = &steps[i]
+	}
+	dependsOn := func(name string) []string {
+		if step, ok := byName[name]; ok {
+			return step.DependsOn
+		}
+		return nil
+	}
+
+	for i := range steps {
+		for _, dep := range steps[i].DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", steps[i].Name, dep)
+			}
+		}
+		if err := detectDependencyCycle(steps[i].Name, dependsOn); err != nil {
+			return nil, fmt.Errorf("circular dependency detected: %w", err)
+		}
+	}
+
+	order := make([]*kelos.PipelineStep, 0, len(steps))
+	added := make(map[string]bool, len(steps))
+	var visit func(step *kelos.PipelineStep)
+	visit = func(step *kelos.PipelineStep) {
+		if added[step.Name] {
+			return
+		}
+		for _, dep := range step.DependsOn {
+			visit(byName[dep])
+		}
+		added[step.Name] = true
+		order = append(order, step)
+	}
+	for i := range steps {
+		visit(&steps[i])
+	}
+	return order, nil
+}
+
+// evaluatePipelineRunIf reports whether a step whose dependencies have all
+// finished should run, and the reason when it should not.
+func evaluatePipelineRunIf(step *kelos.PipelineStep, steps map[string]*kelos.PipelineStepStatus) (bool, string) {
+	when := kelos.PipelineRunIfSucceeded
+	var conditions []kelos.PipelineResultCondition
+	if step.RunIf != nil {
+		if step.RunIf.When != "" {
+			when = step.RunIf.When
+		}
+		conditions = step.RunIf.Results
+	}
+
+	switch when {
+	case kelos.PipelineRunIfSucceeded:
+		for _, dep := range step.DependsOn {
+			if phase := steps[dep].Phase; phase != kelos.PipelineStepPhaseSucceeded {
+				return false, fmt.Sprintf("Dependency step %q is %s", dep, phase)
+			}
+		}
+	case kelos.PipelineRunIfFailed:
+		failed := false
+		for _, dep := range step.DependsOn {
+			if steps[dep].Phase == kelos.PipelineStepPhaseFailed {
+				failed = true
+				break
+			}
+		}
+		if !failed {
+			return false, "No dependency step failed"
+		}
+	}
+
+	for _, c := range conditions {
+		var results map[string]string
+		if st, ok := steps[c.Step]; ok {
+			results = st.Results
+		}
+		if !matchPipelineResultCondition(c, results) {
+			return false, fmt.Sprintf("Result %q of step %q does not match %s %v", c.Key, c.Step, c.Operator, c.Values)
+		}
+	}
+	return true, ""
+}
+
+// matchPipelineResultCondition evaluates a result condition against the
+// structured results of a step.
+func matchPipelineResultCondition(c kelos.PipelineResultCondition, results map[string]string) bool {
+	value, ok := results[c.Key]
+	switch c.Operator {
+	case kelos.PipelineResultOpExists:
+		return ok
+	case kelos.PipelineResultOpDoesNotExist:
+		return !ok
+	case kelos.PipelineResultOpIn:
+		return ok && slices.Contains(c.Values, value)
+	case kelos.PipelineResultOpNotIn:
+		return !ok || !slices.Contains(c.Values, value)
+	}
+	return false
+}
+
+// pipelineTemplateVars returns the template variables of a step: the
+// Pipeline name and the state of each dependency step.
+func pipelineTemplateVars(pipeline *kelos.Pipeline, step *kelos.PipelineStep, steps map[string]*kelos.PipelineStepStatus) map[string]interface{} {
+	deps := make(map[string]interface{}, len(step.DependsOn))
+	for _, dep := range step.DependsOn {
+		st := steps[dep]
+		deps[dep] = map[string]interface{}{
+			"Name":    dep,
+			"Phase":   string(st.Phase),
+			"Tasks":   st.Tasks,
+			"Outputs": st.Outputs,
+			"Results": st.Results,
+			"Items":   st.ItemResults,
+		}
+	}
+	return map[string]interface{}{
+		"Pipeline": pipeline.Name,
+		"Steps":    deps,
+	}
+}
+
+// pipelineForEachItems returns the items a forEach step fans out to.
+func pipelineForEachItems(forEach *kelos.PipelineForEach, source *kelos.PipelineStepStatus) ([]string, error) {
+	value, ok := source.Results[forEach.Result]
+	if !ok {
+		return nil, fmt.Errorf("step %q did not produce result %q", forEach.Step, forEach.Result)
+	}
+	items, err := parsePipelineItems(value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing result %q of step %q: %w", forEach.Result, forEach.Step, err)
+	}
+	maxItems := defaultPipelineForEachMaxItems
+	if forEach.MaxItems != nil {
+		maxItems = int(*forEach.MaxItems)
+	}
+	if len(items) > maxItems {
+		return nil, fmt.Errorf("result %q of step %q has %d items, more than maxItems %d", forEach.Result, forEach.Step, len(items), maxItems)
+	}
+	return items, nil
+}
+
+// parsePipelineItems parses a list result. Values starting with "[" are
+// parsed as a JSON array, where string elements are used as-is and other
+// elements as their JSON encoding; other values are split on commas and
+// newlines. Empty items are dropped.
+func parsePipelineItems(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	var items []string
+	if strings.HasPrefix(value, "[") {
+		var raw []json.RawMessage
+		if err := json.Unmarshal([]byte(value), &raw); err != nil {
+			return nil, err
+		}
+		for _, element := range raw {
+			var s string
+			if err := json.Unmarshal(element, &s); err != nil {
+				s = string(element)
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items, nil
+	}
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items, nil
+}
+
+// observePipelineStepTasks updates a started step from its Tasks. The step
+// finishes once every Task finished and fails if any Task failed or was
+// deleted before the step finished.
+func observePipelineStepTasks(step *kelos.PipelineStep, st *kelos.PipelineStepStatus, tasks map[string]*kelos.Task) {
+	var outputs []string
+	var itemResults []map[string]string
+	var usage *kelos.TaskUsage
+	var results map[string]string
+	running := false
+	failedMessage := ""
+
+	for _, name := range st.Tasks {
+		t, ok := tasks[name]
+		if !ok {
+			st.Phase = kelos.PipelineStepPhaseFailed
+			st.Message = fmt.Sprintf("Task %q was deleted before the step finished", name)
+			return
+		}
+		outputs = append(outputs, t.Status.Outputs...)
+		usage = addTaskUsage(usage, t.Status.Usage)
+		if step.ForEach != nil {
+			item := t.Status.Results
+			if item == nil {
+				item = map[string]string{}
+			}
+			itemResults = append(itemResults, item)
+		} else {
+			results = t.Status.Results
+		}
+		switch t.Status.Phase {
+		case kelos.TaskPhaseSucceeded:
+		case kelos.TaskPhaseFailed:
+			if failedMessage == "" {
+				failedMessage = fmt.Sprintf("Task %q failed", name)
+				if t.Status.Message != "" {
+					failedMessage = fmt.Sprintf("%s: %s", failedMessage, t.Status.Message)
+				}
+			}
+		default:
+			running = true
+		}
+	}
+
+	st.Outputs = outputs
+	st.Results = results
+	st.ItemResults = itemResults
+	st.Usage = usage
+	switch {
+	case running:
+		st.Phase = kelos.PipelineStepPhaseRunning
+		st.Message = ""
+	case failedMessage != "":
+		st.Phase = kelos.PipelineStepPhaseFailed
+		st.Message = failedMessage
+	default:
+		st.Phase = kelos.PipelineStepPhaseSucceeded
+		st.Message = ""
+	}
+}
+
+// aggregatePipelineStatus combines the step statuses into the Pipeline
+// status. Steps are reported in spec order.
+func aggregatePipelineStatus(pipeline *kelos.Pipeline, steps map[string]*kelos.PipelineStepStatus) kelos.PipelineStatus {
+	status := kelos.PipelineStatus{
+		StartTime: pipeline.Status.StartTime,
+	}
+	if status.StartTime == nil {
+		now := metav1.Now()
+		status.StartTime = &now
+	}
+
+	allFinished := true
+	started := false
+	var running []string
+	var failed []string
+	for _, step := range pipeline.Spec.Steps {
+		st := steps[step.Name]
+		status.Steps = append(status.Steps, *st)
+		status.Usage = addTaskUsage(status.Usage, st.Usage)
+		if len(st.Tasks) > 0 {
+			started = true
+		}
+		switch st.Phase {
+		case kelos.PipelineStepPhaseFailed:
+			failed = append(failed, step.Name)
+		case kelos.PipelineStepPhaseRunning:
+			running = append(running, step.Name)
+		}
+		if !isTerminalPipelineStepPhase(st.Phase) {
+			allFinished = false
+		}
+	}
+
+	switch {
+	case allFinished && len(failed) > 0:
+		status.Phase = kelos.PipelinePhaseFailed
+		status.Message = fmt.Sprintf("Step %q failed: %s", failed[0], steps[failed[0]].Message)
+	case allFinished:
+		status.Phase = kelos.PipelinePhaseSucceeded
+	case started || len(failed) > 0:
+		status.Phase = kelos.PipelinePhaseRunning
+		if len(running) > 0 {
+			status.Message = fmt.Sprintf("Running steps: %s", strings.Join(running, ", "))
+		}
+	default:
+		status.Phase = kelos.PipelinePhasePending
+	}
+	if allFinished {
+		completion := pipeline.Status.CompletionTime
+		if completion == nil {
+			now := metav1.Now()
+			completion = &now
+		}
+		status.CompletionTime = completion
+	}
+	return status
+}
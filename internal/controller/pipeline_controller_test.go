@@ -0,0 +1,540 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newPipelineTestStep(name, prompt string, dependsOn ...string) kelos.PipelineStep {
+	return kelos.PipelineStep{
+		Name:      name,
+		DependsOn: dependsOn,
+		TaskTemplate: kelos.PipelineTaskTemplate{
+			Worker: &kelos.WorkerSpec{
+				Type: "claude-code",
+				Credentials: &kelos.Credentials{
+					Type:      kelos.CredentialTypeAPIKey,
+					SecretRef: &kelos.SecretReference{Name: "creds"},
+				},
+				WorkspaceRef: &kelos.WorkspaceReference{Name: "workspace-1"},
+			},
+			PromptTemplate: prompt,
+		},
+	}
+}
+
+func newPipelineTestPipeline(steps ...kelos.PipelineStep) *kelos.Pipeline {
+	return &kelos.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "feature",
+			Namespace: "default",
+			UID:       types.UID("pipeline-uid"),
+		},
+		Spec: kelos.PipelineSpec{Steps: steps},
+	}
+}
+
+func newPipelineTestReconciler(pipeline *kelos.Pipeline, objects ...client.Object) (*PipelineReconciler, client.Client) {
+	scheme := newTestScheme()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Pipeline{}, &kelos.Task{}).
+		WithObjects(append([]client.Object{pipeline}, objects...)...).
+		Build()
+	return &PipelineReconciler{Client: cl, Scheme: scheme}, cl
+}
+
+func reconcilePipeline(t *testing.T, r *PipelineReconciler, pipeline *kelos.Pipeline) *kelos.Pipeline {
+	t.Helper()
+
+	key := client.ObjectKeyFromObject(pipeline)
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	updated := &kelos.Pipeline{}
+	if err := r.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("getting pipeline: %v", err)
+	}
+	return updated
+}
+
+func finishPipelineTestTask(t *testing.T, cl client.Client, name string, phase kelos.TaskPhase, results map[string]string, usage *kelos.TaskUsage) {
+	t.Helper()
+
+	task := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, task); err != nil {
+		t.Fatalf("getting task %s: %v", name, err)
+	}
+	task.Status.Phase = phase
+	task.Status.Results = results
+	task.Status.Usage = usage
+	if err := cl.Status().Update(context.Background(), task); err != nil {
+		t.Fatalf("updating task %s: %v", name, err)
+	}
+}
+
+func pipelineStepStatus(pipeline *kelos.Pipeline, name string) kelos.PipelineStepStatus {
+	for _, st := range pipeline.Status.Steps {
+		if st.Name == name {
+			return st
+		}
+	}
+	return kelos.PipelineStepStatus{}
+}
+
+func TestPipelineStepOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []kelos.PipelineStep
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "dependencies first",
+			steps: []kelos.PipelineStep{
+				{Name: "pr", DependsOn: []string{"test", "implement"}},
+				{Name: "test", DependsOn: []string{"implement"}},
+				{Name: "implement", DependsOn: []string{"plan"}},
+				{Name: "plan"},
+			},
+			want: []string{"plan", "implement", "test", "pr"},
+		},
+		{
+			name: "unknown dependency",
+			steps: []kelos.PipelineStep{
+				{Name: "implement", DependsOn: []string{"plan"}},
+			},
+			wantErr: `step "implement" depends on unknown step "plan"`,
+		},
+		{
+			name: "cycle",
+			steps: []kelos.PipelineStep{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			wantErr: `circular dependency detected: cycle involves "a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := pipelineStepOrder(tt.steps)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pipelineStepOrder: %v", err)
+			}
+			var got []string
+			for _, step := range order {
+				got = append(got, step.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePipelineItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "comma separated", value: "a, b,,c ", want: []string{"a", "b", "c"}},
+		{name: "newline separated", value: "a\nb\n", want: []string{"a", "b"}},
+		{name: "JSON strings", value: `["pkg/a", "pkg/b", ""]`, want: []string{"pkg/a", "pkg/b"}},
+		{name: "JSON objects", value: `[{"id":1}, 2]`, want: []string{`{"id":1}`, "2"}},
+		{name: "invalid JSON", value: `["a"`, wantErr: true},
+		{name: "empty", value: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePipelineItems(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePipelineRunIf(t *testing.T) {
+	steps := map[string]*kelos.PipelineStepStatus{
+		"plan":   {Name: "plan", Phase: kelos.PipelineStepPhaseSucceeded, Results: map[string]string{"risk": "low"}},
+		"build":  {Name: "build", Phase: kelos.PipelineStepPhaseFailed},
+		"lint":   {Name: "lint", Phase: kelos.PipelineStepPhaseSkipped},
+		"review": {Name: "review", Phase: kelos.PipelineStepPhaseSucceeded},
+	}
+
+	tests := []struct {
+		name  string
+		step  kelos.PipelineStep
+		want  bool
+		wantR string
+	}{
+		{
+			name: "no dependencies",
+			step: kelos.PipelineStep{Name: "s"},
+			want: true,
+		},
+		{
+			name: "default requires success",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"plan", "build"}},
+			want: false, wantR: `Dependency step "build" is Failed`,
+		},
+		{
+			name: "skipped dependency is not a success",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"lint"}},
+			want: false, wantR: `Dependency step "lint" is Skipped`,
+		},
+		{
+			name: "on failure",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"plan", "build"}, RunIf: &kelos.PipelineRunIf{When: kelos.PipelineRunIfFailed}},
+			want: true,
+		},
+		{
+			name: "on failure without failed dependency",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"plan", "lint"}, RunIf: &kelos.PipelineRunIf{When: kelos.PipelineRunIfFailed}},
+			want: false, wantR: "No dependency step failed",
+		},
+		{
+			name: "always",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"build", "lint"}, RunIf: &kelos.PipelineRunIf{When: kelos.PipelineRunIfAlways}},
+			want: true,
+		},
+		{
+			name: "result in values",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"plan"}, RunIf: &kelos.PipelineRunIf{Results: []kelos.PipelineResultCondition{
+				{Step: "plan", Key: "risk", Operator: kelos.PipelineResultOpIn, Values: []string{"low", "medium"}},
+			}}},
+			want: true,
+		},
+		{
+			name: "result not in values",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"plan"}, RunIf: &kelos.PipelineRunIf{Results: []kelos.PipelineResultCondition{
+				{Step: "plan", Key: "risk", Operator: kelos.PipelineResultOpNotIn, Values: []string{"low"}},
+			}}},
+			want: false, wantR: `Result "risk" of step "plan" does not match NotIn [low]`,
+		},
+		{
+			name: "missing result",
+			step: kelos.PipelineStep{Name: "s", DependsOn: []string{"review"}, RunIf: &kelos.PipelineRunIf{Results: []kelos.PipelineResultCondition{
+				{Step: "review", Key: "pr", Operator: kelos.PipelineResultOpDoesNotExist},
+			}}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := evaluatePipelineRunIf(&tt.step, steps)
+			if got != tt.want || reason != tt.wantR {
+				t.Errorf("evaluatePipelineRunIf = (%v, %q), want (%v, %q)", got, reason, tt.want, tt.wantR)
+			}
+		})
+	}
+}
+
+func TestPipelineReconcileRunsStepsInOrder(t *testing.T) {
+	pipeline := newPipelineTestPipeline(
+		newPipelineTestStep("plan", "Plan the change"),
+		newPipelineTestStep("implement", `Implement on {{index .Steps "plan" "Results" "branch"}} for {{.Pipeline}}`, "plan"),
+	)
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	updated := reconcilePipeline(t, r, pipeline)
+	if updated.Status.Phase != kelos.PipelinePhaseRunning {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.PipelinePhaseRunning)
+	}
+	if st := pipelineStepStatus(updated, "plan"); st.Phase != kelos.PipelineStepPhaseRunning || !reflect.DeepEqual(st.Tasks, []string{"feature-plan"}) {
+		t.Errorf("plan step = %+v, want Running with task feature-plan", st)
+	}
+	if st := pipelineStepStatus(updated, "implement"); st.Phase != kelos.PipelineStepPhasePending || st.Message != `Waiting for step "plan"` {
+		t.Errorf("implement step = %+v, want Pending waiting for plan", st)
+	}
+
+	plan := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "feature-plan"}, plan); err != nil {
+		t.Fatalf("getting plan task: %v", err)
+	}
+	if plan.Spec.Prompt != "Plan the change" {
+		t.Errorf("plan prompt = %q", plan.Spec.Prompt)
+	}
+	if plan.Labels[kelos.PipelineLabel] != "feature" || plan.Labels[kelos.PipelineStepLabel] != "plan" {
+		t.Errorf("plan labels = %v", plan.Labels)
+	}
+	if !metav1.IsControlledBy(plan, pipeline) {
+		t.Error("expected plan task to be controlled by the pipeline")
+	}
+	if len(plan.Spec.DependsOn) != 0 {
+		t.Errorf("plan dependsOn = %v, want none", plan.Spec.DependsOn)
+	}
+
+	finishPipelineTestTask(t, cl, "feature-plan", kelos.TaskPhaseSucceeded, map[string]string{"branch": "kelos/plan"}, nil)
+	updated = reconcilePipeline(t, r, pipeline)
+
+	implement := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "feature-implement"}, implement); err != nil {
+		t.Fatalf("getting implement task: %v", err)
+	}
+	if implement.Spec.Prompt != "Implement on kelos/plan for feature" {
+		t.Errorf("implement prompt = %q", implement.Spec.Prompt)
+	}
+	if !reflect.DeepEqual(implement.Spec.DependsOn, []string{"feature-plan"}) {
+		t.Errorf("implement dependsOn = %v, want [feature-plan]", implement.Spec.DependsOn)
+	}
+	if st := pipelineStepStatus(updated, "plan"); st.Phase != kelos.PipelineStepPhaseSucceeded || st.Results["branch"] != "kelos/plan" {
+		t.Errorf("plan step = %+v, want Succeeded with branch result", st)
+	}
+
+	finishPipelineTestTask(t, cl, "feature-implement", kelos.TaskPhaseSucceeded, nil, nil)
+	updated = reconcilePipeline(t, r, pipeline)
+	if updated.Status.Phase != kelos.PipelinePhaseSucceeded {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.PipelinePhaseSucceeded)
+	}
+	if updated.Status.CompletionTime == nil {
+		t.Error("expected completionTime to be set")
+	}
+}
+
+func TestPipelineReconcileFansOutOverListResult(t *testing.T) {
+	review := newPipelineTestStep("review", "Review {{.Item}} ({{.Index}})", "plan")
+	review.ForEach = &kelos.PipelineForEach{Step: "plan", Result: "packages"}
+	summary := newPipelineTestStep("summary", `{{range (index .Steps "review" "Items")}}{{.verdict}} {{end}}`, "review")
+	pipeline := newPipelineTestPipeline(newPipelineTestStep("plan", "Plan"), review, summary)
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	reconcilePipeline(t, r, pipeline)
+	finishPipelineTestTask(t, cl, "feature-plan", kelos.TaskPhaseSucceeded, map[string]string{"packages": `["api", "cli"]`}, nil)
+	updated := reconcilePipeline(t, r, pipeline)
+
+	st := pipelineStepStatus(updated, "review")
+	if !reflect.DeepEqual(st.Tasks, []string{"feature-review-0", "feature-review-1"}) {
+		t.Fatalf("review tasks = %v", st.Tasks)
+	}
+	for i, want := range []string{"Review api (0)", "Review cli (1)"} {
+		task := &kelos.Task{}
+		if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: st.Tasks[i]}, task); err != nil {
+			t.Fatalf("getting %s: %v", st.Tasks[i], err)
+		}
+		if task.Spec.Prompt != want {
+			t.Errorf("%s prompt = %q, want %q", task.Name, task.Spec.Prompt, want)
+		}
+	}
+
+	finishPipelineTestTask(t, cl, "feature-review-0", kelos.TaskPhaseSucceeded, map[string]string{"verdict": "ok"}, nil)
+	updated = reconcilePipeline(t, r, pipeline)
+	if st := pipelineStepStatus(updated, "review"); st.Phase != kelos.PipelineStepPhaseRunning {
+		t.Errorf("review phase = %q, want Running while an item is unfinished", st.Phase)
+	}
+
+	finishPipelineTestTask(t, cl, "feature-review-1", kelos.TaskPhaseSucceeded, map[string]string{"verdict": "nit"}, nil)
+	updated = reconcilePipeline(t, r, pipeline)
+	st = pipelineStepStatus(updated, "review")
+	if st.Phase != kelos.PipelineStepPhaseSucceeded {
+		t.Errorf("review phase = %q, want Succeeded", st.Phase)
+	}
+	wantItems := []map[string]string{{"verdict": "ok"}, {"verdict": "nit"}}
+	if !reflect.DeepEqual(st.ItemResults, wantItems) {
+		t.Errorf("review itemResults = %v, want %v", st.ItemResults, wantItems)
+	}
+
+	task := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "feature-summary"}, task); err != nil {
+		t.Fatalf("getting summary task: %v", err)
+	}
+	if task.Spec.Prompt != "ok nit " {
+		t.Errorf("summary prompt = %q", task.Spec.Prompt)
+	}
+}
+
+func TestPipelineReconcileFailsForEachOverLimit(t *testing.T) {
+	review := newPipelineTestStep("review", "Review {{.Item}}", "plan")
+	review.ForEach = &kelos.PipelineForEach{Step: "plan", Result: "packages", MaxItems: int32Ptr(1)}
+	pipeline := newPipelineTestPipeline(newPipelineTestStep("plan", "Plan"), review)
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	reconcilePipeline(t, r, pipeline)
+	finishPipelineTestTask(t, cl, "feature-plan", kelos.TaskPhaseSucceeded, map[string]string{"packages": "api,cli"}, nil)
+	updated := reconcilePipeline(t, r, pipeline)
+
+	st := pipelineStepStatus(updated, "review")
+	if st.Phase != kelos.PipelineStepPhaseFailed || len(st.Tasks) != 0 {
+		t.Errorf("review step = %+v, want Failed without tasks", st)
+	}
+	if updated.Status.Phase != kelos.PipelinePhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.PipelinePhaseFailed)
+	}
+}
+
+func TestPipelineReconcileDeletesForEachTasksWhenCreationFails(t *testing.T) {
+	review := newPipelineTestStep("review", "Review {{.Item}}", "plan")
+	review.ForEach = &kelos.PipelineForEach{Step: "plan", Result: "packages"}
+	pipeline := newPipelineTestPipeline(newPipelineTestStep("plan", "Plan"), review)
+	unrelated := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "feature-review-1", Namespace: "default"},
+		Spec:       kelos.TaskSpec{Type: "claude-code", Prompt: "Something else"},
+	}
+	r, cl := newPipelineTestReconciler(pipeline, unrelated)
+
+	reconcilePipeline(t, r, pipeline)
+	finishPipelineTestTask(t, cl, "feature-plan", kelos.TaskPhaseSucceeded, map[string]string{"packages": "api,cli"}, nil)
+	updated := reconcilePipeline(t, r, pipeline)
+
+	st := pipelineStepStatus(updated, "review")
+	if st.Phase != kelos.PipelineStepPhaseFailed || !strings.Contains(st.Message, "feature-review-1") {
+		t.Errorf("review step = %+v, want Failed on the name conflict of feature-review-1", st)
+	}
+	var first kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "feature-review-0"}, &first); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the Task created before the conflict to be deleted, got err=%v", err)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(unrelated), &kelos.Task{}); err != nil {
+		t.Errorf("expected the unrelated Task to be kept: %v", err)
+	}
+}
+
+func TestPipelineReconcileFailsStepWithoutPrompt(t *testing.T) {
+	pipeline := newPipelineTestPipeline(newPipelineTestStep("plan", ""))
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	updated := reconcilePipeline(t, r, pipeline)
+	st := pipelineStepStatus(updated, "plan")
+	if st.Phase != kelos.PipelineStepPhaseFailed || len(st.Tasks) != 0 {
+		t.Errorf("plan step = %+v, want Failed without tasks", st)
+	}
+	if !strings.Contains(st.Message, "promptTemplate is required") {
+		t.Errorf("plan step message = %q, want the missing prompt reported", st.Message)
+	}
+
+	var tasks kelos.TaskList
+	if err := cl.List(context.Background(), &tasks); err != nil {
+		t.Fatalf("listing tasks: %v", err)
+	}
+	if len(tasks.Items) != 0 {
+		t.Errorf("tasks = %d, want none for a step without a prompt", len(tasks.Items))
+	}
+}
+
+func TestPipelineReconcileFailsStepOnTaskNameConflict(t *testing.T) {
+	pipeline := newPipelineTestPipeline(newPipelineTestStep("plan", "Plan the change"))
+	unrelated := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "feature-plan", Namespace: "default"},
+		Spec:       kelos.TaskSpec{Type: "claude-code", Prompt: "Something else"},
+	}
+	r, cl := newPipelineTestReconciler(pipeline, unrelated)
+
+	updated := reconcilePipeline(t, r, pipeline)
+	st := pipelineStepStatus(updated, "plan")
+	if st.Phase != kelos.PipelineStepPhaseFailed || len(st.Tasks) != 0 {
+		t.Errorf("plan step = %+v, want Failed without tasks", st)
+	}
+	if !strings.Contains(st.Message, "not controlled by this Pipeline") {
+		t.Errorf("plan step message = %q, want a name conflict", st.Message)
+	}
+
+	existing := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(unrelated), existing); err != nil {
+		t.Fatalf("getting unrelated task: %v", err)
+	}
+	if existing.Spec.Prompt != "Something else" || metav1.IsControlledBy(existing, pipeline) {
+		t.Errorf("unrelated task was modified: %+v", existing)
+	}
+}
+
+func TestPipelineReconcileRunsFailureStepsAndAggregatesUsage(t *testing.T) {
+	notify := newPipelineTestStep("notify", `Report: {{index .Steps "implement" "Phase"}}`, "implement")
+	notify.RunIf = &kelos.PipelineRunIf{When: kelos.PipelineRunIfFailed}
+	pipeline := newPipelineTestPipeline(
+		newPipelineTestStep("implement", "Implement"),
+		newPipelineTestStep("pr", "Open a PR", "implement"),
+		notify,
+	)
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	reconcilePipeline(t, r, pipeline)
+	cost := resource.MustParse("1.5")
+	finishPipelineTestTask(t, cl, "feature-implement", kelos.TaskPhaseFailed, nil, &kelos.TaskUsage{CostUSD: &cost, InputTokens: int64Ptr(100)})
+	updated := reconcilePipeline(t, r, pipeline)
+
+	if st := pipelineStepStatus(updated, "pr"); st.Phase != kelos.PipelineStepPhaseSkipped {
+		t.Errorf("pr phase = %q, want Skipped", st.Phase)
+	}
+	if st := pipelineStepStatus(updated, "notify"); st.Phase != kelos.PipelineStepPhaseRunning {
+		t.Errorf("notify phase = %q, want Running", st.Phase)
+	}
+	if updated.Status.Phase != kelos.PipelinePhaseRunning {
+		t.Errorf("phase = %q, want Running while notify runs", updated.Status.Phase)
+	}
+
+	task := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "feature-notify"}, task); err != nil {
+		t.Fatalf("getting notify task: %v", err)
+	}
+	if task.Spec.Prompt != "Report: Failed" {
+		t.Errorf("notify prompt = %q", task.Spec.Prompt)
+	}
+	if len(task.Spec.DependsOn) != 0 {
+		t.Errorf("notify dependsOn = %v, want none for a failed dependency", task.Spec.DependsOn)
+	}
+
+	notifyCost := resource.MustParse("0.5")
+	finishPipelineTestTask(t, cl, "feature-notify", kelos.TaskPhaseSucceeded, nil, &kelos.TaskUsage{CostUSD: &notifyCost, InputTokens: int64Ptr(20)})
+	updated = reconcilePipeline(t, r, pipeline)
+
+	if updated.Status.Phase != kelos.PipelinePhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.PipelinePhaseFailed)
+	}
+	if updated.Status.Message != `Step "implement" failed: Task "feature-implement" failed` {
+		t.Errorf("message = %q", updated.Status.Message)
+	}
+	usage := updated.Status.Usage
+	if usage == nil || usage.CostUSD == nil || usage.CostUSD.String() != "2" || usage.InputTokens == nil || *usage.InputTokens != 120 {
+		t.Errorf("usage = %+v, want cost 2 and 120 input tokens", usage)
+	}
+}
+
+func TestPipelineReconcileFailsInvalidPipeline(t *testing.T) {
+	pipeline := newPipelineTestPipeline(
+		newPipelineTestStep("a", "A", "b"),
+		newPipelineTestStep("b", "B", "a"),
+	)
+	r, cl := newPipelineTestReconciler(pipeline)
+
+	updated := reconcilePipeline(t, r, pipeline)
+	if updated.Status.Phase != kelos.PipelinePhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.PipelinePhaseFailed)
+	}
+	if updated.Status.Message != `Invalid pipeline: circular dependency detected: cycle involves "a"` {
+		t.Errorf("message = %q", updated.Status.Message)
+	}
+
+	var tasks kelos.TaskList
+	if err := cl.List(context.Background(), &tasks); err != nil {
+		t.Fatalf("listing tasks: %v", err)
+	}
+	if len(tasks.Items) != 0 {
+		t.Errorf("expected no tasks, got %d", len(tasks.Items))
+	}
+}
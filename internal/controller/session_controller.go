@@ -1363,19 +1363,19 @@ func (r *SessionReconciler) resolveSessionInputs(
 		return workspace, nil, "", nil
 	}
 
-	specs := make([]kelos.AgentConfigSpec, 0, len(refs))
-	for _, ref := range refs {
-		var value kelos.AgentConfig
-		if err := r.Get(ctx, client.ObjectKey{Namespace: session.Namespace, Name: ref.Name}, &value); err != nil {
-			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Sprintf("Waiting for AgentConfig %q", ref.Name), nil
-			}
-			return nil, nil, "", fmt.Errorf("fetching AgentConfig %q: %w", ref.Name, err)
-		}
-		specs = append(specs, value.Spec)
+	agentConfig, err := ResolveAgentConfigs(ctx, r.Client, session.Namespace, refs, session.Spec.Worker.Type)
+	var notFound *AgentConfigNotFoundError
+	var cycle *AgentConfigCycleError
+	switch {
+	case errors.As(err, &notFound) && notFound.ExtendedBy == "":
+		return nil, nil, fmt.Sprintf("Waiting for AgentConfig %q", notFound.Name), nil
+	case errors.As(err, &notFound):
+		return nil, nil, fmt.Sprintf("Waiting for AgentConfig %q extended by %q", notFound.Name, notFound.ExtendedBy), nil
+	case errors.As(err, &cycle):
+		return nil, nil, "", invalidSessionConfiguration(err)
+	case err != nil:
+		return nil, nil, "", err
 	}
-
-	agentConfig := MergeAgentConfigs(specs)
 	inputClient := sessionInputClient{Client: r.Client}
 	if len(agentConfig.Skills) > 0 {
 		taskReconciler := TaskReconciler{Client: inputClient}
@@ -1626,6 +1626,13 @@ func (r *SessionReconciler) buildSessionStatefulSet(session *kelos.Session, work
 		},
 	}
 
+	// Sessions clone only the primary repository: the init containers of
+	// additional repositories are not safe to rerun on a reused workspace.
+	if workspace != nil && len(workspace.Repositories) > 0 {
+		workspace = workspace.DeepCopy()
+		workspace.Repositories = nil
+	}
+
 	job, err := r.JobBuilder.Build(task, workspace, agentConfig, "session")
 	if err != nil {
 		return nil, nil, err
@@ -1745,7 +1752,7 @@ func (r *SessionReconciler) buildSessionStatefulSet(session *kelos.Session, work
 	}
 	credentialHelper := ""
 	if workspace != nil && workspace.SecretRef != nil {
-		credentialHelper = gitCredentialHelper()
+		credentialHelper = workspaceCredentialHelper(workspace)
 	}
 	if err := prepareSessionWorkspaceInit(podSpec.InitContainers, credentialHelper); err != nil {
 		return nil, nil, err
@@ -2177,6 +2184,10 @@ func (r *SessionReconciler) findSessionsForAgentConfig(ctx context.Context, obj
 	if !ok {
 		return nil
 	}
+	dependents, err := agentConfigDependents(ctx, r.Client, agentConfig.Namespace, agentConfig.Name)
+	if err != nil {
+		return nil
+	}
 	var sessions kelos.SessionList
 	if err := r.List(ctx, &sessions, client.InNamespace(agentConfig.Namespace)); err != nil {
 		return nil
@@ -2185,7 +2196,7 @@ func (r *SessionReconciler) findSessionsForAgentConfig(ctx context.Context, obj
 	for i := range sessions.Items {
 		session := &sessions.Items[i]
 		for _, ref := range session.Spec.Worker.AgentConfigRefs {
-			if ref.Name == agentConfig.Name {
+			if dependents[ref.Name] {
 				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(session)})
 				break
 			}
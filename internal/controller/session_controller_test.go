@@ -1527,6 +1527,29 @@ func TestSessionCodexPodUsesPersistentCodexHome(t *testing.T) {
 	t.Fatal("CODEX_HOME was not injected")
 }
 
+func TestSessionPodClonesOnlyPrimaryRepository(t *testing.T) {
+	t.Parallel()
+	session := testSession("multi-repo", "codex")
+	session.Spec.Worker.WorkspaceRef = &kelos.WorkspaceReference{Name: "workspace"}
+	workspace := &kelos.WorkspaceSpec{
+		Repo:         "https://github.com/kelos-dev/kelos.git",
+		Repositories: []kelos.WorkspaceRepository{{Name: "docs", Repo: "https://github.com/kelos-dev/docs.git"}},
+	}
+
+	statefulSet, _, err := testSessionReconciler(nil, nil).buildSessionStatefulSet(session, workspace, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range statefulSet.Spec.Template.Spec.InitContainers {
+		if strings.HasPrefix(c.Name, kelos.ReservedContainerNamePrefix+"git-clone-") {
+			t.Fatalf("Session Pod clones additional repository in %q", c.Name)
+		}
+	}
+	if len(workspace.Repositories) != 1 {
+		t.Fatal("buildSessionStatefulSet modified the resolved Workspace")
+	}
+}
+
 func TestSessionPodUsesInitialBranch(t *testing.T) {
 	t.Parallel()
 	session := testSession("issue-42", "codex")
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// PushApprovalVolumeName is the name of the downward API volume that
+	// exposes the agent pod's annotations to the pre-push hook.
+	PushApprovalVolumeName = "kelos-approval"
+
+	// PushApprovalMountPath is the mount path for the push approval volume.
+	// The pod annotations are available in the "annotations" file.
+	PushApprovalMountPath = "/kelos/approval"
+
+	// PushApprovalHooksDir is the git hooks directory configured in the
+	// workspace repository of a Task with a BeforePush approval policy.
+	PushApprovalHooksDir = WorkspaceMountPath + "/.kelos-hooks"
+
+	// podAnnotationPushApproved is set on the agent pod of a Task with a
+	// BeforePush approval policy once the Task is approved. The pre-push
+	// hook reads it through the push approval volume.
+	podAnnotationPushApproved = "kelos.dev/push-approved"
+
+	// pushApprovalWaitSeconds is how long a single git push waits for
+	// approval before the hook rejects it. It is kept below common agent
+	// tool timeouts so the agent sees the rejection and can retry.
+	pushApprovalWaitSeconds = 90
+)
+
+// pushApprovalHookScript is the git pre-push hook installed for Tasks with
+// a BeforePush approval policy. It waits for the push-approved annotation to
+// appear on the agent pod and rejects the push if it does not arrive in time.
+var pushApprovalHookScript = fmt.Sprintf(`#!/bin/sh
+# Installed by Kelos: pushing requires approval of this Task.
+annotations=%s/annotations
+waited=0
+until grep -q '^%s=' "$annotations" 2>/dev/null; do
+  if [ "$waited" -ge %d ]; then
+    echo "kelos: push rejected, the task has not been approved yet. Retry git push later." >&2
+    exit 1
+  fi
+  if [ "$waited" -eq 0 ]; then
+    echo "kelos: waiting for approval of the task before pushing..." >&2
+  fi
+  sleep 5
+  waited=$((waited + 5))
+done
+`, PushApprovalMountPath, podAnnotationPushApproved, pushApprovalWaitSeconds)
+
+// pushApprovalHookSetupScript installs pushApprovalHookScript, passed in
+// KELOS_PRE_PUSH_HOOK, as the pre-push hook of the workspace repository.
+var pushApprovalHookSetupScript = fmt.Sprintf(`set -e
+mkdir -p %[1]s
+printf '%%s' "$KELOS_PRE_PUSH_HOOK" > %[1]s/pre-push
+chmod +x %[1]s/pre-push
+git -C %[2]s/repo config core.hooksPath %[1]s`, PushApprovalHooksDir, WorkspaceMountPath)
+
+// pushApprovalInstructions is appended to the agent instructions of a Task
+// whose pushes require approval.
+const pushApprovalInstructions = `## Push approval
+
+Pushing from this workspace requires human approval of the task. A ` + "`git push`" + ` may
+wait for the approval and is rejected if it does not arrive in time. When that
+happens, finish any remaining local work and retry the push until it succeeds.
+Never bypass the pre-push hook (for example with --no-verify) or change
+core.hooksPath.`
+
+// taskApprovalMode returns the approval mode of the Task, or an empty mode
+// when the Task does not require approval.
+func taskApprovalMode(task *kelos.Task) kelos.ApprovalMode {
+	if task.Spec.Approval == nil {
+		return ""
+	}
+	if task.Spec.Approval.Mode == "" {
+		return kelos.ApprovalModeBeforeRun
+	}
+	return task.Spec.Approval.Mode
+}
+
+// taskApprovedBy reports whether the Task has been approved and returns the
+// approver recorded in the approve annotation.
+func taskApprovedBy(task *kelos.Task) (string, bool) {
+	approver, ok := task.Annotations[kelos.AnnotationTaskApprove]
+	return approver, ok
+}
+
+// taskApprovedMessage returns the Approved condition message of an approved
+// Task.
+func taskApprovedMessage(approver string) string {
+	if approver == "" {
+		return "Task was approved"
+	}
+	return fmt.Sprintf("Task was approved by %s", approver)
+}
+
+// taskAwaitingApprovalMessage returns the Approved condition message of a
+// Task that has not been approved yet.
+func taskAwaitingApprovalMessage(mode kelos.ApprovalMode) string {
+	if mode == kelos.ApprovalModeBeforePush {
+		return "Pushing changes is held until the Task is approved"
+	}
+	return "Waiting for approval before starting the agent"
+}
+
+// checkApproval gates Job creation for Tasks with an approval policy. A Task
+// with a BeforeRun policy is held in the AwaitingApproval phase until it is
+// approved. A Task with a BeforePush policy is admitted right away; only
+// its Approved condition is recorded.
+func (r *TaskReconciler) checkApproval(ctx context.Context, task *kelos.Task) (bool, error) {
+	mode := taskApprovalMode(task)
+	if mode == "" {
+		return true, nil
+	}
+	if approver, ok := taskApprovedBy(task); ok {
+		return true, r.recordApproval(ctx, task, approver)
+	}
+
+	changed := false
+	message := taskAwaitingApprovalMessage(mode)
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		changed = meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               kelos.TaskConditionApproved,
+			Status:             metav1.ConditionFalse,
+			Reason:             kelos.TaskReasonAwaitingApproval,
+			Message:            message,
+			ObservedGeneration: task.Generation,
+		})
+		if mode == kelos.ApprovalModeBeforeRun && (task.Status.Phase != kelos.TaskPhaseAwaitingApproval || task.Status.Message != message) {
+			task.Status.Phase = kelos.TaskPhaseAwaitingApproval
+			task.Status.Message = message
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		return false, err
+	}
+
+	if mode != kelos.ApprovalModeBeforeRun {
+		return true, nil
+	}
+	if changed {
+		log.FromContext(ctx).Info("Task is awaiting approval")
+		r.recordEvent(task, corev1.EventTypeNormal, "AwaitingApproval", "%s", message)
+	}
+	return false, nil
+}
+
+// recordApproval marks the Task as approved. It is a no-op when the approval
+// has already been recorded.
+func (r *TaskReconciler) recordApproval(ctx context.Context, task *kelos.Task, approver string) error {
+	if meta.IsStatusConditionTrue(task.Status.Conditions, kelos.TaskConditionApproved) {
+		return nil
+	}
+	message := taskApprovedMessage(approver)
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               kelos.TaskConditionApproved,
+			Status:             metav1.ConditionTrue,
+			Reason:             kelos.TaskReasonApproved,
+			Message:            message,
+			ObservedGeneration: task.Generation,
+		})
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Task approved", "approver", approver)
+	r.recordEvent(task, corev1.EventTypeNormal, "TaskApproved", "%s", message)
+	return nil
+}
+
+// releasePushApproval lets the running agent of an approved Task with a
+// BeforePush approval policy push its changes by annotating the agent pods
+// that the pre-push hook is waiting on.
+func (r *TaskReconciler) releasePushApproval(ctx context.Context, task *kelos.Task, job *batchv1.Job) error {
+	approver, ok := taskApprovedBy(task)
+	if !ok || taskApprovalMode(task) != kelos.ApprovalModeBeforePush || job.Status.Active == 0 {
+		return nil
+	}
+	if err := r.recordApproval(ctx, task, approver); err != nil {
+		return err
+	}
+
+	var pods corev1.PodList
+	podLabels := client.MatchingLabels{
+		"kelos.dev/task": task.Name,
+	}
+	if job.UID != "" {
+		podLabels[batchv1.ControllerUidLabel] = string(job.UID)
+	}
+	if err := r.List(ctx, &pods, client.InNamespace(task.Namespace), podLabels); err != nil {
+		return fmt.Errorf("listing pods of Job %s: %w", job.Name, err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if _, ok := pod.Annotations[podAnnotationPushApproved]; ok {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[podAnnotationPushApproved] = "true"
+		if err := r.Patch(ctx, pod, patch); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("approving push of pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// pushApprovalHookContainer returns the init container that installs the
+// pre-push hook in the workspace repository and in the additional
+// repositories cloned into repoDirs.
+func pushApprovalHookContainer(agentUID int64, repoDirs ...string) corev1.Container {
+	script := pushApprovalHookSetupScript
+	for _, dir := range repoDirs {
+		script += fmt.Sprintf("\ngit -C %s config core.hooksPath %s", shellQuote(dir), PushApprovalHooksDir)
+	}
+	return corev1.Container{
+		Name:    kelos.ReservedContainerNamePrefix + "push-approval",
+		Image:   GitCloneImage,
+		Command: []string{"sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "KELOS_PRE_PUSH_HOOK", Value: pushApprovalHookScript},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: WorkspaceVolumeName, MountPath: WorkspaceMountPath},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &agentUID,
+		},
+	}
+}
+
+// pushApprovalVolume returns the downward API volume that exposes the agent
+// pod's annotations to the pre-push hook.
+func pushApprovalVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: PushApprovalVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{
+						Path:     "annotations",
+						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations"},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestReconcileHoldsTaskAwaitingApproval(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", "")
+	task.Finalizers = []string{taskFinalizer}
+	task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforeRun}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	}); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseAwaitingApproval {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseAwaitingApproval)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionApproved)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != kelos.TaskReasonAwaitingApproval {
+		t.Errorf("Approved condition = %+v, want False with reason %s", cond, kelos.TaskReasonAwaitingApproval)
+	}
+
+	var job batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &job); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Job for a Task awaiting approval, got err=%v", err)
+	}
+}
+
+func TestCheckApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         kelos.ApprovalMode
+		approver     string
+		approved     bool
+		wantAdmitted bool
+		wantPhase    kelos.TaskPhase
+		wantStatus   metav1.ConditionStatus
+		wantMessage  string
+	}{
+		{
+			name:         "before run, not approved",
+			mode:         kelos.ApprovalModeBeforeRun,
+			wantAdmitted: false,
+			wantPhase:    kelos.TaskPhaseAwaitingApproval,
+			wantStatus:   metav1.ConditionFalse,
+			wantMessage:  "Waiting for approval before starting the agent",
+		},
+		{
+			name:         "before run, approved",
+			mode:         kelos.ApprovalModeBeforeRun,
+			approver:     "github:alice",
+			approved:     true,
+			wantAdmitted: true,
+			wantStatus:   metav1.ConditionTrue,
+			wantMessage:  "Task was approved by github:alice",
+		},
+		{
+			name:         "before push, not approved",
+			mode:         kelos.ApprovalModeBeforePush,
+			wantAdmitted: true,
+			wantStatus:   metav1.ConditionFalse,
+			wantMessage:  "Pushing changes is held until the Task is approved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme()
+			task := newTestTask("task-1", "")
+			task.Finalizers = []string{taskFinalizer}
+			task.Spec.Approval = &kelos.ApprovalPolicy{Mode: tt.mode}
+			if tt.approved {
+				task.Annotations = map[string]string{kelos.AnnotationTaskApprove: tt.approver}
+			}
+
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(task).
+				WithObjects(task).
+				Build()
+
+			r := &TaskReconciler{Client: cl, Scheme: scheme}
+			admitted, err := r.checkApproval(context.Background(), task)
+			if err != nil {
+				t.Fatalf("checkApproval() error: %v", err)
+			}
+			if admitted != tt.wantAdmitted {
+				t.Errorf("admitted = %v, want %v", admitted, tt.wantAdmitted)
+			}
+
+			updated := &kelos.Task{}
+			if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+				t.Fatalf("getting updated task: %v", err)
+			}
+			if updated.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %q, want %q", updated.Status.Phase, tt.wantPhase)
+			}
+			cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionApproved)
+			if cond == nil || cond.Status != tt.wantStatus || cond.Message != tt.wantMessage {
+				t.Errorf("Approved condition = %+v, want %s with message %q", cond, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestReleasePushApprovalAnnotatesRunningPods(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Finalizers = []string{taskFinalizer}
+	task.Annotations = map[string]string{kelos.AnnotationTaskApprove: "alice"}
+	task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforePush}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: task.Name, Namespace: task.Namespace, UID: "job-uid"},
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "task-1-abcde",
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task":           task.Name,
+				batchv1.ControllerUidLabel: "job-uid",
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	finished := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "task-1-fghij",
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task":           task.Name,
+				batchv1.ControllerUidLabel: "job-uid",
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job, running, finished).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+	if err := r.releasePushApproval(context.Background(), task, job); err != nil {
+		t.Fatalf("releasePushApproval() error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		pod  *corev1.Pod
+		want bool
+	}{
+		{pod: running, want: true},
+		{pod: finished, want: false},
+	} {
+		updated := &corev1.Pod{}
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(tc.pod), updated); err != nil {
+			t.Fatalf("getting pod %s: %v", tc.pod.Name, err)
+		}
+		if _, ok := updated.Annotations[podAnnotationPushApproved]; ok != tc.want {
+			t.Errorf("pod %s push-approved annotation present = %v, want %v", tc.pod.Name, ok, tc.want)
+		}
+	}
+
+	updatedTask := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updatedTask); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(updatedTask.Status.Conditions, kelos.TaskConditionApproved) {
+		t.Error("expected Approved condition to be True")
+	}
+}
+
+func TestBuildJob_PushApproval(t *testing.T) {
+	builder := NewJobBuilder()
+	task := newTestTask("task-1", "")
+	task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforePush}
+	workspace := &kelos.WorkspaceSpec{Repo: "https://github.com/example/repo.git"}
+
+	job, err := builder.Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	var hook *corev1.Container
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == "kelos-push-approval" {
+			hook = &podSpec.InitContainers[i]
+		}
+	}
+	if hook == nil {
+		t.Fatal("Expected the push approval init container")
+	}
+	if !strings.Contains(hook.Command[2], "core.hooksPath") {
+		t.Errorf("Expected the init container to configure core.hooksPath, got %q", hook.Command[2])
+	}
+
+	var hasVolume bool
+	for _, v := range podSpec.Volumes {
+		if v.Name == PushApprovalVolumeName && v.DownwardAPI != nil {
+			hasVolume = true
+		}
+	}
+	if !hasVolume {
+		t.Error("Expected the push approval downward API volume")
+	}
+
+	var agentsMD string
+	for _, e := range podSpec.Containers[0].Env {
+		if e.Name == "KELOS_AGENTS_MD" {
+			agentsMD = e.Value
+		}
+	}
+	if !strings.Contains(agentsMD, "## Push approval") {
+		t.Errorf("Expected push approval instructions in KELOS_AGENTS_MD, got %q", agentsMD)
+	}
+	if _, ok := job.Spec.Template.Annotations[podAnnotationPushApproved]; ok {
+		t.Error("Expected an unapproved Task's pod to not be pre-approved")
+	}
+
+	task.Annotations = map[string]string{kelos.AnnotationTaskApprove: "alice"}
+	job, err = builder.Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	if _, ok := job.Spec.Template.Annotations[podAnnotationPushApproved]; !ok {
+		t.Error("Expected an approved Task's pod to be pre-approved")
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,6 +26,16 @@ import (
 // budgetBlockedMaxRequeue is the maximum requeue delay for budget-blocked tasks.
 const budgetBlockedMaxRequeue = 5 * time.Minute
 
+// rollingPeriodGranularity is the step by which Rolling periods advance.
+// Rounding the end of a Rolling period up to it keeps the boundaries stable
+// between reconciles, so the status is refreshed once per step instead of on
+// every evaluation.
+const rollingPeriodGranularity = time.Minute
+
+// maxBudgetWindow is the longest supported Rolling period. It stays below
+// defaultTaskRecordTTL so no record in the window has been garbage-collected.
+const maxBudgetWindow = 31 * 24 * time.Hour
+
 const taskBudgetLabelSnapshotAnnotation = "kelos.dev/taskbudget-labels"
 
 // budgetEnforcer evaluates TaskBudgets and maintains TaskRecords. It is shared
@@ -32,9 +46,12 @@ type budgetEnforcer struct {
 	client.Client
 	// now returns the current time; overridable for deterministic tests.
 	now func() time.Time
+	// recorder emits events on TaskBudgets; may be nil.
+	recorder record.EventRecorder
 }
 
-// checkBudgetAdmission checks all matching TaskBudgets before job creation.
+// checkBudgetAdmission checks the spend limits and maxRunning quotas of all
+// matching TaskBudgets before job creation.
 // Returns (true, _, nil) if admitted, (false, result, nil) if blocked,
 // or (false, _, err) on error.
 func (e *budgetEnforcer) checkBudgetAdmission(ctx context.Context, task *kelos.Task) (bool, ctrl.Result, error) {
@@ -52,6 +69,9 @@ func (e *budgetEnforcer) checkBudgetAdmission(ctx context.Context, task *kelos.T
 	}
 
 	matchedBudget := false
+	// Tasks in the namespace, listed on first use by a maxRunning quota.
+	var tasks []kelos.Task
+	tasksListed := false
 	for i := range budgetList.Items {
 		budget := &budgetList.Items[i]
 
@@ -113,6 +133,23 @@ func (e *budgetEnforcer) checkBudgetAdmission(ctx context.Context, task *kelos.T
 
 		// Best-effort update TaskBudget status even when not exceeded
 		e.updateBudgetStatus(ctx, budget, periodStart, periodEnd, used)
+
+		if budget.Spec.MaxRunning != nil {
+			if !tasksListed {
+				var taskList kelos.TaskList
+				if err := e.List(ctx, &taskList, client.InNamespace(task.Namespace)); err != nil {
+					logger.Error(err, "Unable to list running Tasks, blocking task", "budget", budget.Name)
+					return false, ctrl.Result{}, fmt.Errorf("listing tasks for budget %s: %w", budget.Name, err)
+				}
+				tasks = taskList.Items
+				tasksListed = true
+			}
+			if blocked, position, reason := checkRunningQuota(budget, selector, task, tasks); blocked {
+				logger.Info("Running quota in use, blocking task", "budget", budget.Name, "task", task.Name, "reason", reason, "queuePosition", position)
+				e.setQuotaBlockedPhase(ctx, task, budget.Name, reason, position)
+				return false, ctrl.Result{RequeueAfter: quotaBlockedRequeue}, nil
+			}
+		}
 	}
 
 	// All matching budgets are within limits — clear any stale BudgetBlocked condition
@@ -140,16 +177,83 @@ func computePeriodBoundaries(period kelos.BudgetPeriod, now time.Time) (time.Tim
 
 	localNow := now.In(loc)
 
+	// Calendar periods are built with time.Date and AddDate so they start at
+	// local midnight even across DST transitions, when a day is not 24h long.
 	switch period.Type {
 	case kelos.BudgetPeriodDaily:
 		start := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, loc)
 		end := start.AddDate(0, 0, 1)
 		return start, end, nil
+	case kelos.BudgetPeriodWeekly:
+		weekStart, err := parseWeekStart(period.WeekStart)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		daysSinceStart := (int(localNow.Weekday()) - int(weekStart) + 7) % 7
+		start := time.Date(localNow.Year(), localNow.Month(), localNow.Day()-daysSinceStart, 0, 0, 0, 0, loc)
+		end := start.AddDate(0, 0, 7)
+		return start, end, nil
+	case kelos.BudgetPeriodMonthly:
+		start := time.Date(localNow.Year(), localNow.Month(), 1, 0, 0, 0, 0, loc)
+		end := start.AddDate(0, 1, 0)
+		return start, end, nil
+	case kelos.BudgetPeriodRolling:
+		window, err := parseBudgetWindow(period.Window)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end := now.Truncate(rollingPeriodGranularity)
+		if end.Before(now) {
+			end = end.Add(rollingPeriodGranularity)
+		}
+		end = end.In(loc)
+		return end.Add(-window), end, nil
 	default:
 		return time.Time{}, time.Time{}, fmt.Errorf("unsupported period type %q", period.Type)
 	}
 }
 
+// parseWeekStart returns the weekday on which Weekly periods start. An empty
+// value defaults to Monday.
+func parseWeekStart(day string) (time.Weekday, error) {
+	if day == "" {
+		return time.Monday, nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == day {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekStart %q", day)
+}
+
+// parseBudgetWindow parses the window of a Rolling period: a positive number
+// followed by m (minutes), h (hours) or d (days).
+func parseBudgetWindow(window string) (time.Duration, error) {
+	if len(window) < 2 {
+		return 0, fmt.Errorf("invalid window %q", window)
+	}
+	n, err := strconv.ParseInt(window[:len(window)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid window %q", window)
+	}
+	var unit time.Duration
+	switch window[len(window)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid window %q: unit must be m, h or d", window)
+	}
+	if n > int64(maxBudgetWindow/unit) {
+		return 0, fmt.Errorf("window %q is longer than 31d", window)
+	}
+	return time.Duration(n) * unit, nil
+}
+
 // sumPeriodUsage lists TaskRecords in the namespace matching the selector and
 // sums usage from records whose CompletionTime falls within [periodStart, periodEnd).
 // The label selector is passed server-side so the API server pre-filters records.
@@ -200,6 +304,33 @@ func (e *budgetEnforcer) sumPeriodUsage(ctx context.Context, namespace string, s
 	return usage, nil
 }
 
+// checkSoftLimitReached checks whether the accumulated usage reached
+// spec.softLimitPercent of any budget limit. Returns true and a human-readable
+// reason if it did.
+func checkSoftLimitReached(budget *kelos.TaskBudget, used *kelos.TaskUsage) (bool, string) {
+	if budget.Spec.SoftLimitPercent == nil {
+		return false, ""
+	}
+	pct := int64(*budget.Spec.SoftLimitPercent)
+	if budget.Spec.MaxCostUSD != nil && used.CostUSD != nil {
+		threshold := resource.NewMilliQuantity(budget.Spec.MaxCostUSD.MilliValue()*pct/100, resource.DecimalSI)
+		if used.CostUSD.Cmp(*threshold) >= 0 {
+			return true, fmt.Sprintf("cost %s reached %d%% of limit %s", used.CostUSD.String(), pct, budget.Spec.MaxCostUSD.String())
+		}
+	}
+	if budget.Spec.MaxInputTokens != nil && used.InputTokens != nil {
+		if *used.InputTokens*100 >= *budget.Spec.MaxInputTokens*pct {
+			return true, fmt.Sprintf("input tokens %d reached %d%% of limit %d", *used.InputTokens, pct, *budget.Spec.MaxInputTokens)
+		}
+	}
+	if budget.Spec.MaxOutputTokens != nil && used.OutputTokens != nil {
+		if *used.OutputTokens*100 >= *budget.Spec.MaxOutputTokens*pct {
+			return true, fmt.Sprintf("output tokens %d reached %d%% of limit %d", *used.OutputTokens, pct, *budget.Spec.MaxOutputTokens)
+		}
+	}
+	return false, ""
+}
+
 // checkLimitsExceeded checks whether the accumulated usage exceeds any budget limits.
 // Returns true and a human-readable reason if exceeded. Missing used values are
 // treated as zero when the corresponding limit is set, so a zero limit blocks admission
@@ -236,26 +367,59 @@ func checkLimitsExceeded(budget *kelos.TaskBudget, used *kelos.TaskUsage) (bool,
 	return false, ""
 }
 
+// admissionBlockedConditions are the Task conditions set when a TaskBudget
+// holds a Task back. A Task carries at most one of them at a time.
+var admissionBlockedConditions = []string{"BudgetBlocked", kelos.TaskConditionQuotaBlocked}
+
+// taskAdmissionBlocked reports whether a TaskBudget holds the Task back.
+func taskAdmissionBlocked(task *kelos.Task) bool {
+	if task.Status.Phase != kelos.TaskPhaseWaiting {
+		return false
+	}
+	for _, t := range admissionBlockedConditions {
+		if meta.IsStatusConditionTrue(task.Status.Conditions, t) {
+			return true
+		}
+	}
+	return false
+}
+
 // setBudgetBlockedPhase sets the task to Waiting phase with a BudgetBlocked condition.
 // It skips the status write when the task is already in the desired blocked state,
 // so that watch-triggered reconciles do not churn on a stable budget block.
 func (e *budgetEnforcer) setBudgetBlockedPhase(ctx context.Context, task *kelos.Task, budgetName, reason string) {
+	e.setAdmissionBlockedPhase(ctx, task, "BudgetBlocked", "BudgetExceeded",
+		fmt.Sprintf("Budget %q exceeded: %s", budgetName, reason),
+		fmt.Sprintf("Blocked by TaskBudget %q: %s", budgetName, reason), 0)
+}
+
+// setAdmissionBlockedPhase sets the task to Waiting phase with the given
+// blocked condition and queue position (0 when the Task is not queued) and
+// removes the other blocked condition, skipping the status write when the
+// task is already in the desired state.
+func (e *budgetEnforcer) setAdmissionBlockedPhase(ctx context.Context, task *kelos.Task, condType, condReason, wantMessage, wantCondMessage string, position int32) {
 	logger := log.FromContext(ctx)
-	wantMessage := fmt.Sprintf("Budget %q exceeded: %s", budgetName, reason)
-	wantCondMessage := fmt.Sprintf("Blocked by TaskBudget %q: %s", budgetName, reason)
 	updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if getErr := e.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
 			return getErr
 		}
-		if budgetBlockUnchanged(task, wantMessage, wantCondMessage) {
+		if admissionBlockUnchanged(task, condType, condReason, wantMessage, wantCondMessage) &&
+			queuePositionEqual(task.Status.QueuePosition, position) && task.Status.BranchLockHolder == "" {
 			return nil
 		}
 		task.Status.Phase = kelos.TaskPhaseWaiting
 		task.Status.Message = wantMessage
+		task.Status.QueuePosition = queuePosition(position)
+		task.Status.BranchLockHolder = ""
+		for _, t := range admissionBlockedConditions {
+			if t != condType {
+				meta.RemoveStatusCondition(&task.Status.Conditions, t)
+			}
+		}
 		meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
-			Type:               "BudgetBlocked",
+			Type:               condType,
 			Status:             metav1.ConditionTrue,
-			Reason:             "BudgetExceeded",
+			Reason:             condReason,
 			Message:            wantCondMessage,
 			ObservedGeneration: task.Generation,
 			LastTransitionTime: metav1.Now(),
@@ -263,29 +427,41 @@ func (e *budgetEnforcer) setBudgetBlockedPhase(ctx context.Context, task *kelos.
 		return e.Status().Update(ctx, task)
 	})
 	if updateErr != nil {
-		logger.Error(updateErr, "Unable to update Task status to budget-blocked")
+		logger.Error(updateErr, "Unable to update Task status to blocked", "condition", condType)
 	}
 }
 
 // budgetBlockUnchanged reports whether the task is already in the desired
 // budget-blocked state, so the status write can be skipped.
 func budgetBlockUnchanged(task *kelos.Task, wantMessage, wantCondMessage string) bool {
+	return admissionBlockUnchanged(task, "BudgetBlocked", "BudgetExceeded", wantMessage, wantCondMessage)
+}
+
+// admissionBlockUnchanged reports whether the task is already in the desired
+// blocked state for the given condition, so the status write can be skipped.
+func admissionBlockUnchanged(task *kelos.Task, condType, condReason, wantMessage, wantCondMessage string) bool {
 	if task.Status.Phase != kelos.TaskPhaseWaiting || task.Status.Message != wantMessage {
 		return false
 	}
-	cond := meta.FindStatusCondition(task.Status.Conditions, "BudgetBlocked")
+	for _, t := range admissionBlockedConditions {
+		if t != condType && meta.FindStatusCondition(task.Status.Conditions, t) != nil {
+			return false
+		}
+	}
+	cond := meta.FindStatusCondition(task.Status.Conditions, condType)
 	return cond != nil &&
 		cond.Status == metav1.ConditionTrue &&
-		cond.Reason == "BudgetExceeded" &&
+		cond.Reason == condReason &&
 		cond.Message == wantCondMessage &&
 		cond.ObservedGeneration == task.Generation
 }
 
-// clearBudgetBlockedCondition removes the BudgetBlocked condition if present.
+// clearBudgetBlockedCondition removes the BudgetBlocked and QuotaBlocked
+// conditions and the queue position if present.
 func (e *budgetEnforcer) clearBudgetBlockedCondition(ctx context.Context, task *kelos.Task) {
-	hasCond := false
-	for _, c := range task.Status.Conditions {
-		if c.Type == "BudgetBlocked" && c.Status == metav1.ConditionTrue {
+	hasCond := task.Status.QueuePosition != nil
+	for _, t := range admissionBlockedConditions {
+		if meta.FindStatusCondition(task.Status.Conditions, t) != nil {
 			hasCond = true
 			break
 		}
@@ -299,7 +475,10 @@ func (e *budgetEnforcer) clearBudgetBlockedCondition(ctx context.Context, task *
 		if getErr := e.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
 			return getErr
 		}
-		meta.RemoveStatusCondition(&task.Status.Conditions, "BudgetBlocked")
+		for _, t := range admissionBlockedConditions {
+			meta.RemoveStatusCondition(&task.Status.Conditions, t)
+		}
+		task.Status.QueuePosition = nil
 		return e.Status().Update(ctx, task)
 	})
 	if updateErr != nil {
@@ -389,15 +568,33 @@ func copyStringMap(src map[string]string) map[string]string {
 }
 
 // updateBudgetStatus best-effort updates the TaskBudget status with current
-// period boundaries and accumulated usage. It skips the write when the status is
-// already current, so watch-driven reconciles do not churn on stable state.
+// period boundaries, accumulated usage and the SoftLimitReached condition. It
+// skips the write when the status is already current, so watch-driven
+// reconciles do not churn on stable state. A Warning event is emitted when the
+// soft limit is first reached in a period.
 func (e *budgetEnforcer) updateBudgetStatus(ctx context.Context, budget *kelos.TaskBudget, periodStart, periodEnd time.Time, used *kelos.TaskUsage) {
 	logger := log.FromContext(ctx)
+	softReached, softReason := checkSoftLimitReached(budget, used)
+	softLimitRaised := false
 	updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if getErr := e.Get(ctx, client.ObjectKeyFromObject(budget), budget); getErr != nil {
 			return getErr
 		}
-		if budgetStatusCurrent(budget, periodStart, periodEnd, used) {
+		wasReached := meta.IsStatusConditionTrue(budget.Status.Conditions, kelos.TaskBudgetConditionSoftLimitReached)
+		var condChanged bool
+		if softReached {
+			condChanged = meta.SetStatusCondition(&budget.Status.Conditions, metav1.Condition{
+				Type:               kelos.TaskBudgetConditionSoftLimitReached,
+				Status:             metav1.ConditionTrue,
+				Reason:             "SoftLimitReached",
+				Message:            softReason,
+				ObservedGeneration: budget.Generation,
+			})
+		} else {
+			condChanged = meta.RemoveStatusCondition(&budget.Status.Conditions, kelos.TaskBudgetConditionSoftLimitReached)
+		}
+		softLimitRaised = softReached && !wasReached
+		if !condChanged && budgetStatusCurrent(budget, periodStart, periodEnd, used) {
 			return nil
 		}
 		budget.Status.ObservedGeneration = budget.Generation
@@ -410,6 +607,17 @@ func (e *budgetEnforcer) updateBudgetStatus(ctx context.Context, budget *kelos.T
 	})
 	if updateErr != nil {
 		logger.V(1).Info("Unable to update TaskBudget status", "budget", budget.Name, "error", updateErr)
+		return
+	}
+	if softLimitRaised {
+		logger.Info("TaskBudget reached its soft limit", "budget", budget.Name, "reason", softReason)
+		e.recordEvent(budget, corev1.EventTypeWarning, "SoftLimitReached", "Budget %q reached its soft limit: %s", budget.Name, softReason)
+	}
+}
+
+func (e *budgetEnforcer) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if e.recorder != nil {
+		e.recorder.Eventf(obj, eventType, reason, messageFmt, args...)
 	}
 }
 
@@ -542,18 +750,22 @@ func (e *budgetEnforcer) createTaskRecord(ctx context.Context, task *kelos.Task)
 			StartTime:                 task.Status.StartTime,
 			CompletionTime:            task.Status.CompletionTime,
 			Usage:                     task.Status.Usage.DeepCopy(),
+			Resolved:                  task.Status.Resolved.DeepCopy(),
 			TTLSecondsAfterCompletion: &ttl,
 		},
 	}
 
 	if err := e.Create(ctx, record); err != nil {
-		if apierrors.IsAlreadyExists(err) {
-			return nil
+		if !apierrors.IsAlreadyExists(err) {
+			logger.Error(err, "Unable to create TaskRecord", "task", task.Name)
+			return err
+		}
+		if err := e.Get(ctx, client.ObjectKeyFromObject(record), record); err != nil {
+			return err
 		}
-		logger.Error(err, "Unable to create TaskRecord", "task", task.Name)
-		return err
 	}
-	return nil
+	// The resolved snapshot of the Task outlives the Task with its record.
+	return addResolvedSnapshotOwner(ctx, e.Client, e.Scheme(), record)
 }
 
 func (e *budgetEnforcer) taskRecordWorkerMetadata(ctx context.Context, task *kelos.Task) (string, string, error) {
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -219,10 +221,108 @@ func TestComputePeriodBoundaries(t *testing.T) {
 			now:     time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
 			wantErr: true,
 		},
+		{
+			name: "weekly period defaults to Monday start",
+			period: kelos.BudgetPeriod{
+				Type: kelos.BudgetPeriodWeekly,
+			},
+			// 2024-06-15 is a Saturday
+			now:       time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly period starts on the configured day",
+			period: kelos.BudgetPeriod{
+				Type:      kelos.BudgetPeriodWeekly,
+				WeekStart: "Sunday",
+			},
+			now:       time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly period across a DST transition",
+			period: kelos.BudgetPeriod{
+				Type:      kelos.BudgetPeriodWeekly,
+				WeekStart: "Saturday",
+				Timezone:  "America/New_York",
+			},
+			// DST starts on Sunday 2024-03-10, so the week is 167h long
+			now:       time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 3, 9, 0, 0, 0, 0, mustLoadLocation("America/New_York")),
+			wantEnd:   time.Date(2024, 3, 16, 0, 0, 0, 0, mustLoadLocation("America/New_York")),
+		},
+		{
+			name: "invalid weekStart returns error",
+			period: kelos.BudgetPeriod{
+				Type:      kelos.BudgetPeriodWeekly,
+				WeekStart: "Funday",
+			},
+			now:     time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+			wantErr: true,
+		},
+		{
+			name: "monthly period in a leap year February",
+			period: kelos.BudgetPeriod{
+				Type: kelos.BudgetPeriodMonthly,
+			},
+			now:       time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+			wantStart: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "monthly period rolls over the year",
+			period: kelos.BudgetPeriod{
+				Type:     kelos.BudgetPeriodMonthly,
+				Timezone: "Asia/Tokyo",
+			},
+			// 2024-12-31 16:00 UTC = 2025-01-01 01:00 JST
+			now:       time.Date(2024, 12, 31, 16, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 1, 1, 0, 0, 0, 0, mustLoadLocation("Asia/Tokyo")),
+			wantEnd:   time.Date(2025, 2, 1, 0, 0, 0, 0, mustLoadLocation("Asia/Tokyo")),
+		},
+		{
+			name: "rolling period ends at the next minute",
+			period: kelos.BudgetPeriod{
+				Type:   kelos.BudgetPeriodRolling,
+				Window: "24h",
+			},
+			now:       time.Date(2024, 6, 15, 14, 30, 20, 0, time.UTC),
+			wantStart: time.Date(2024, 6, 14, 14, 31, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 6, 15, 14, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "rolling period in days on a minute boundary",
+			period: kelos.BudgetPeriod{
+				Type:   kelos.BudgetPeriodRolling,
+				Window: "7d",
+			},
+			now:       time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 6, 8, 14, 30, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "rolling period without window returns error",
+			period: kelos.BudgetPeriod{
+				Type: kelos.BudgetPeriodRolling,
+			},
+			now:     time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+			wantErr: true,
+		},
+		{
+			name: "rolling window longer than 31d returns error",
+			period: kelos.BudgetPeriod{
+				Type:   kelos.BudgetPeriodRolling,
+				Window: "745h",
+			},
+			now:     time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
+			wantErr: true,
+		},
 		{
 			name: "unsupported period type returns error",
 			period: kelos.BudgetPeriod{
-				Type:     "Weekly",
+				Type:     "Yearly",
 				Timezone: "UTC",
 			},
 			now:     time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC),
@@ -393,6 +493,103 @@ func TestCheckLimitsExceeded(t *testing.T) {
 	}
 }
 
+func TestCheckSoftLimitReached(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+	int32Ptr := func(v int32) *int32 { return &v }
+	quantityPtr := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	tests := []struct {
+		name        string
+		budget      *kelos.TaskBudget
+		used        *kelos.TaskUsage
+		wantReached bool
+		wantReason  string
+	}{
+		{
+			name: "no soft limit configured",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxCostUSD: quantityPtr("10"),
+				},
+			},
+			used: &kelos.TaskUsage{CostUSD: quantityPtr("9.99")},
+		},
+		{
+			name: "cost below threshold",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxCostUSD:       quantityPtr("10"),
+					SoftLimitPercent: int32Ptr(80),
+				},
+			},
+			used: &kelos.TaskUsage{CostUSD: quantityPtr("7.99")},
+		},
+		{
+			name: "cost at threshold",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxCostUSD:       quantityPtr("10"),
+					SoftLimitPercent: int32Ptr(80),
+				},
+			},
+			used:        &kelos.TaskUsage{CostUSD: quantityPtr("8")},
+			wantReached: true,
+			wantReason:  "cost 8 reached 80% of limit 10",
+		},
+		{
+			name: "input tokens over threshold",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxInputTokens:   int64Ptr(1000),
+					SoftLimitPercent: int32Ptr(90),
+				},
+			},
+			used:        &kelos.TaskUsage{InputTokens: int64Ptr(950)},
+			wantReached: true,
+			wantReason:  "input tokens 950 reached 90% of limit 1000",
+		},
+		{
+			name: "output tokens at threshold",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxInputTokens:   int64Ptr(1000),
+					MaxOutputTokens:  int64Ptr(200),
+					SoftLimitPercent: int32Ptr(50),
+				},
+			},
+			used:        &kelos.TaskUsage{InputTokens: int64Ptr(10), OutputTokens: int64Ptr(100)},
+			wantReached: true,
+			wantReason:  "output tokens 100 reached 50% of limit 200",
+		},
+		{
+			name: "no usage recorded",
+			budget: &kelos.TaskBudget{
+				Spec: kelos.TaskBudgetSpec{
+					MaxCostUSD:       quantityPtr("10"),
+					MaxInputTokens:   int64Ptr(1000),
+					SoftLimitPercent: int32Ptr(1),
+				},
+			},
+			used: &kelos.TaskUsage{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached, reason := checkSoftLimitReached(tt.budget, tt.used)
+			if reached != tt.wantReached {
+				t.Errorf("checkSoftLimitReached() reached = %v, want %v", reached, tt.wantReached)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("checkSoftLimitReached() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
 func TestCheckBudgetAdmission_InvalidStoredSelectorFailsClosed(t *testing.T) {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -582,6 +779,59 @@ func TestCreateTaskRecordUsesBudgetLabelSnapshot(t *testing.T) {
 	}
 }
 
+func TestCreateTaskRecordCopiesResolvedSnapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	costUSD := resource.MustParse("1")
+	completionTime := metav1.Now()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-1", Namespace: "default", UID: "task-uid"},
+		Spec:       kelos.TaskSpec{Type: "codex"},
+		Status: kelos.TaskStatus{
+			Phase:          kelos.TaskPhaseSucceeded,
+			CompletionTime: &completionTime,
+			Usage:          &kelos.TaskUsage{CostUSD: &costUSD},
+			Resolved: &kelos.TaskResolvedSnapshot{
+				Hash:          "abc123",
+				Type:          "codex",
+				Image:         CodexImage,
+				ConfigMapName: "kelos-resolved-task-uid",
+			},
+		},
+	}
+	snapshotConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kelos-resolved-task-uid", Namespace: "default"},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(task, snapshotConfigMap).
+		Build()
+
+	enforcer := &budgetEnforcer{Client: cl}
+	if err := enforcer.createTaskRecord(context.Background(), task); err != nil {
+		t.Fatalf("createTaskRecord() error = %v", err)
+	}
+
+	var record kelos.TaskRecord
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "task-uid"}, &record); err != nil {
+		t.Fatalf("getting TaskRecord: %v", err)
+	}
+	if !reflect.DeepEqual(record.Spec.Resolved, task.Status.Resolved) {
+		t.Fatalf("record resolved = %#v, want %#v", record.Spec.Resolved, task.Status.Resolved)
+	}
+
+	// The snapshot ConfigMap is kept for as long as the record.
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(snapshotConfigMap), snapshotConfigMap); err != nil {
+		t.Fatalf("getting snapshot ConfigMap: %v", err)
+	}
+	if owners := snapshotConfigMap.OwnerReferences; len(owners) != 1 || owners[0].Kind != "TaskRecord" || owners[0].UID != record.UID {
+		t.Errorf("snapshot ConfigMap owners = %+v, want the TaskRecord", owners)
+	}
+}
+
 func TestCreateTaskRecordUsesWorkerPoolWorkerMetadata(t *testing.T) {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// quotaBlockedRequeue is how often a Task held back by a maxRunning quota is
+// re-evaluated. Job-backed Tasks are also re-enqueued as soon as another Task
+// finishes.
+const quotaBlockedRequeue = 10 * time.Second
+
+// taskHoldsQuota reports whether the Task occupies a slot of a maxRunning
+// quota: it has been admitted and has not finished yet.
+func taskHoldsQuota(task *kelos.Task) bool {
+	return task.Status.Phase == kelos.TaskPhasePending || task.Status.Phase == kelos.TaskPhaseRunning
+}
+
+// taskQuotaBlocked reports whether the Task is waiting for a maxRunning quota.
+func taskQuotaBlocked(task *kelos.Task) bool {
+	return task.Status.Phase == kelos.TaskPhaseWaiting &&
+		meta.IsStatusConditionTrue(task.Status.Conditions, kelos.TaskConditionQuotaBlocked)
+}
+
+// checkRunningQuota checks whether admitting the Task would exceed the
+// maxRunning quota of the budget. Matching Tasks that are already running
+// take up slots, and matching Tasks queued ahead of this one in a taskQueue
+// get the free slots first. Returns true, the Task's 1-based queue position
+// and a human-readable reason if the Task must wait.
+func checkRunningQuota(budget *kelos.TaskBudget, selector labels.Selector, task *kelos.Task, tasks []kelos.Task) (bool, int32, string) {
+	if budget.Spec.MaxRunning == nil {
+		return false, 0, ""
+	}
+	maxRunning := int(*budget.Spec.MaxRunning)
+
+	var running, queued []*kelos.Task
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Name == task.Name || !selector.Matches(labels.Set(t.Labels)) {
+			continue
+		}
+		switch {
+		case taskHoldsQuota(t):
+			running = append(running, t)
+		case taskQuotaBlocked(t):
+			queued = append(queued, t)
+		}
+	}
+
+	queue := newTaskQueue(running)
+	var queuedAhead int
+	for _, t := range queued {
+		if queue.before(t, task) {
+			queuedAhead++
+		}
+	}
+
+	if len(running)+queuedAhead < maxRunning {
+		return false, 0, ""
+	}
+	position := int32(queuedAhead + 1)
+	if queuedAhead == 0 {
+		return true, position, fmt.Sprintf("%d of %d Tasks running", len(running), maxRunning)
+	}
+	return true, position, fmt.Sprintf("%d of %d Tasks running, %d queued ahead", len(running), maxRunning, queuedAhead)
+}
+
+// setQuotaBlockedPhase sets the task to Waiting phase with a QuotaBlocked
+// condition and its queue position. Like setBudgetBlockedPhase, it skips the
+// status write when the task is already in the desired state.
+func (e *budgetEnforcer) setQuotaBlockedPhase(ctx context.Context, task *kelos.Task, budgetName, reason string, position int32) {
+	e.setAdmissionBlockedPhase(ctx, task, kelos.TaskConditionQuotaBlocked, kelos.TaskReasonQuotaExceeded,
+		fmt.Sprintf("Waiting for maxRunning quota of budget %q: %s", budgetName, reason),
+		fmt.Sprintf("Held by maxRunning quota of TaskBudget %q: %s", budgetName, reason), position)
+}
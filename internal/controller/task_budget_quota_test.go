@@ -0,0 +1,309 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func quotaTestTask(name string, created time.Time, phase kelos.TaskPhase, quotaBlocked bool) kelos.Task {
+	task := kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{"team": "platform"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: kelos.TaskStatus{Phase: phase},
+	}
+	if quotaBlocked {
+		task.Status.Conditions = []metav1.Condition{{
+			Type:   kelos.TaskConditionQuotaBlocked,
+			Status: metav1.ConditionTrue,
+			Reason: kelos.TaskReasonQuotaExceeded,
+		}}
+	}
+	return task
+}
+
+func withPriority(task kelos.Task, priority int32) kelos.Task {
+	task.Spec.Priority = &priority
+	return task
+}
+
+func fromSpawner(task kelos.Task, spawner string) kelos.Task {
+	task.Labels["kelos.dev/taskspawner"] = spawner
+	return task
+}
+
+func TestCheckRunningQuota(t *testing.T) {
+	base := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	maxRunning := int32(2)
+	budget := &kelos.TaskBudget{
+		Spec: kelos.TaskBudgetSpec{
+			TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+			MaxRunning:   &maxRunning,
+		},
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&budget.Spec.TaskSelector)
+	if err != nil {
+		t.Fatalf("LabelSelectorAsSelector() error = %v", err)
+	}
+
+	other := quotaTestTask("other-team", base, kelos.TaskPhaseRunning, false)
+	other.Labels = map[string]string{"team": "other"}
+
+	tests := []struct {
+		name         string
+		task         kelos.Task
+		tasks        []kelos.Task
+		wantBlocked  bool
+		wantPosition int32
+		wantReason   string
+	}{
+		{
+			name: "free slot",
+			task: quotaTestTask("new", base.Add(time.Minute), "", false),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("done", base, kelos.TaskPhaseSucceeded, false),
+				other,
+			},
+		},
+		{
+			name: "all slots in use",
+			task: quotaTestTask("new", base.Add(time.Minute), "", false),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("pending", base, kelos.TaskPhasePending, false),
+			},
+			wantBlocked:  true,
+			wantPosition: 1,
+			wantReason:   "2 of 2 Tasks running",
+		},
+		{
+			name: "task does not count against itself",
+			task: quotaTestTask("self", base, kelos.TaskPhasePending, false),
+			tasks: []kelos.Task{
+				quotaTestTask("self", base, kelos.TaskPhasePending, false),
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+			},
+		},
+		{
+			name: "earlier queued task gets the free slot",
+			task: quotaTestTask("new", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, true),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("queued", base.Add(time.Minute), kelos.TaskPhaseWaiting, true),
+			},
+			wantBlocked:  true,
+			wantPosition: 2,
+			wantReason:   "1 of 2 Tasks running, 1 queued ahead",
+		},
+		{
+			name: "later queued task does not hold back",
+			task: quotaTestTask("new", base.Add(time.Minute), kelos.TaskPhaseWaiting, true),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("queued", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, true),
+			},
+		},
+		{
+			name: "creation time tie is broken by name",
+			task: quotaTestTask("b", base, kelos.TaskPhaseWaiting, true),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("a", base, kelos.TaskPhaseWaiting, true),
+			},
+			wantBlocked:  true,
+			wantPosition: 2,
+			wantReason:   "1 of 2 Tasks running, 1 queued ahead",
+		},
+		{
+			name: "higher priority task overtakes earlier queued task",
+			task: withPriority(quotaTestTask("urgent", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, true), 10),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("queued", base.Add(time.Minute), kelos.TaskPhaseWaiting, true),
+			},
+		},
+		{
+			name: "lower priority task waits for later queued task",
+			task: withPriority(quotaTestTask("low", base.Add(time.Minute), kelos.TaskPhaseWaiting, true), -1),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("queued", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, true),
+			},
+			wantBlocked:  true,
+			wantPosition: 2,
+			wantReason:   "1 of 2 Tasks running, 1 queued ahead",
+		},
+		{
+			name: "spawner with fewer running tasks goes first",
+			task: fromSpawner(quotaTestTask("quiet-1", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, true), "quiet"),
+			tasks: []kelos.Task{
+				fromSpawner(quotaTestTask("busy-running", base, kelos.TaskPhaseRunning, false), "busy"),
+				fromSpawner(quotaTestTask("busy-queued", base.Add(time.Minute), kelos.TaskPhaseWaiting, true), "busy"),
+			},
+		},
+		{
+			name: "waiting task without quota block does not hold back",
+			task: quotaTestTask("new", base.Add(time.Minute), "", false),
+			tasks: []kelos.Task{
+				quotaTestTask("running", base, kelos.TaskPhaseRunning, false),
+				quotaTestTask("dependent", base, kelos.TaskPhaseWaiting, false),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocked, position, reason := checkRunningQuota(budget, selector, &tt.task, tt.tasks)
+			if blocked != tt.wantBlocked {
+				t.Errorf("checkRunningQuota() blocked = %v, want %v", blocked, tt.wantBlocked)
+			}
+			if position != tt.wantPosition {
+				t.Errorf("checkRunningQuota() position = %d, want %d", position, tt.wantPosition)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("checkRunningQuota() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckBudgetAdmission_MaxRunningQuota(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	base := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	maxRunning := int32(1)
+	budget := &kelos.TaskBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "default"},
+		Spec: kelos.TaskBudgetSpec{
+			TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+			Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily},
+			MaxRunning:   &maxRunning,
+		},
+	}
+	running := quotaTestTask("running", base, kelos.TaskPhaseRunning, false)
+	pooled := quotaTestTask("pooled", base.Add(time.Minute), "", false)
+	pooled.Spec.WorkerPoolRef = &kelos.WorkerPoolReference{Name: "pool"}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.TaskBudget{}, &kelos.Task{}).
+		WithObjects(budget, &running, &pooled).
+		Build()
+
+	enforcer := &budgetEnforcer{
+		Client: cl,
+		now:    func() time.Time { return base.Add(time.Hour) },
+	}
+	ctx := context.Background()
+
+	admitted, result, err := enforcer.checkBudgetAdmission(ctx, &pooled)
+	if err != nil {
+		t.Fatalf("checkBudgetAdmission() error = %v", err)
+	}
+	if admitted {
+		t.Fatal("checkBudgetAdmission() admitted = true, want false")
+	}
+	if result.RequeueAfter != quotaBlockedRequeue {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, quotaBlockedRequeue)
+	}
+
+	var got kelos.Task
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pooled"}, &got); err != nil {
+		t.Fatalf("getting Task: %v", err)
+	}
+	if got.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("phase = %q, want Waiting", got.Status.Phase)
+	}
+	cond := meta.FindStatusCondition(got.Status.Conditions, kelos.TaskConditionQuotaBlocked)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != kelos.TaskReasonQuotaExceeded {
+		t.Fatalf("QuotaBlocked condition = %+v, want True/QuotaExceeded", cond)
+	}
+	if want := `Held by maxRunning quota of TaskBudget "quota": 1 of 1 Tasks running`; cond.Message != want {
+		t.Errorf("QuotaBlocked message = %q, want %q", cond.Message, want)
+	}
+	if got.Status.QueuePosition == nil || *got.Status.QueuePosition != 1 {
+		t.Errorf("queuePosition = %v, want 1", got.Status.QueuePosition)
+	}
+
+	// Once the running Task finishes, the queued Task is admitted and the
+	// condition is cleared.
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(&running), &running); err != nil {
+		t.Fatalf("getting Task: %v", err)
+	}
+	running.Status.Phase = kelos.TaskPhaseSucceeded
+	if err := cl.Status().Update(ctx, &running); err != nil {
+		t.Fatalf("updating Task status: %v", err)
+	}
+	admitted, _, err = enforcer.checkBudgetAdmission(ctx, &got)
+	if err != nil {
+		t.Fatalf("checkBudgetAdmission() error = %v", err)
+	}
+	if !admitted {
+		t.Fatal("checkBudgetAdmission() admitted = false, want true")
+	}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pooled"}, &got); err != nil {
+		t.Fatalf("getting Task: %v", err)
+	}
+	if meta.FindStatusCondition(got.Status.Conditions, kelos.TaskConditionQuotaBlocked) != nil {
+		t.Errorf("QuotaBlocked condition still set after admission: %+v", got.Status.Conditions)
+	}
+	if got.Status.QueuePosition != nil {
+		t.Errorf("queuePosition = %d after admission, want unset", *got.Status.QueuePosition)
+	}
+}
+
+func TestSetQuotaBlockedPhaseReplacesBudgetBlocked(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	task := quotaTestTask("task", time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC), kelos.TaskPhaseWaiting, false)
+	task.Status.Conditions = []metav1.Condition{{
+		Type:   "BudgetBlocked",
+		Status: metav1.ConditionTrue,
+		Reason: "BudgetExceeded",
+	}}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Task{}).
+		WithObjects(&task).
+		Build()
+
+	enforcer := &budgetEnforcer{Client: cl}
+	enforcer.setQuotaBlockedPhase(context.Background(), &task, "quota", "1 of 1 Tasks running", 3)
+
+	var got kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(&task), &got); err != nil {
+		t.Fatalf("getting Task: %v", err)
+	}
+	if meta.FindStatusCondition(got.Status.Conditions, "BudgetBlocked") != nil {
+		t.Errorf("BudgetBlocked condition still set: %+v", got.Status.Conditions)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, kelos.TaskConditionQuotaBlocked) {
+		t.Errorf("QuotaBlocked condition not set: %+v", got.Status.Conditions)
+	}
+	if want := `Waiting for maxRunning quota of budget "quota": 1 of 1 Tasks running`; got.Status.Message != want {
+		t.Errorf("message = %q, want %q", got.Status.Message, want)
+	}
+	if got.Status.QueuePosition == nil || *got.Status.QueuePosition != 3 {
+		t.Errorf("queuePosition = %v, want 3", got.Status.QueuePosition)
+	}
+}
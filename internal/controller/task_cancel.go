@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// taskCancelPollInterval is how often a cancelling Task is re-checked
+	// while its agent shuts down.
+	taskCancelPollInterval = 2 * time.Second
+
+	// taskCancelActiveDeadlineSeconds is written to the spec of a running
+	// agent pod to cancel it. The deadline is already exceeded, so the
+	// kubelet stops the containers with the pod's termination grace period
+	// while keeping the pod and its logs for output capture.
+	taskCancelActiveDeadlineSeconds int64 = 1
+)
+
+// taskCancelRequested reports whether cancellation was requested for the Task
+// and returns the optional reason from the cancel annotation.
+func taskCancelRequested(task *kelos.Task) (string, bool) {
+	reason, ok := task.Annotations[kelos.AnnotationTaskCancel]
+	return reason, ok
+}
+
+// taskCancelMessage returns the status message of a cancelled Task.
+func taskCancelMessage(reason string) string {
+	if reason == "" {
+		return "Task was cancelled"
+	}
+	return fmt.Sprintf("Task was cancelled: %s", reason)
+}
+
+// setTaskCancelledCondition sets the Cancelled condition on the Task status.
+func setTaskCancelledCondition(task *kelos.Task, reason, message string) {
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               kelos.TaskConditionCancelled,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: task.Generation,
+	})
+}
+
+// markTaskCancelRequested records that the agent of a Task is being stopped.
+// It returns false when the request had already been recorded.
+func markTaskCancelRequested(ctx context.Context, cl client.Client, task *kelos.Task, reason string) (bool, error) {
+	if meta.IsStatusConditionTrue(task.Status.Conditions, kelos.TaskConditionCancelled) {
+		return false, nil
+	}
+	message := "Cancellation requested, waiting for the agent to stop"
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	return true, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := cl.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		task.Status.Message = message
+		setTaskCancelledCondition(task, kelos.TaskReasonCancelRequested, message)
+		return cl.Status().Update(ctx, task)
+	})
+}
+
+// failTaskCancelled moves a Task that has no running agent straight to the
+// Failed phase with a Cancelled condition. Usage of earlier retry attempts
+// is kept so budget accounting still covers them.
+func failTaskCancelled(ctx context.Context, cl client.Client, task *kelos.Task, reason string) error {
+	message := taskCancelMessage(reason)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := cl.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		task.Status.Phase = kelos.TaskPhaseFailed
+		task.Status.Message = message
+		if task.Status.CompletionTime == nil {
+			now := metav1.Now()
+			task.Status.CompletionTime = &now
+		}
+		task.Status.NextRetryTime = nil
+		task.Status.Usage = usageWithAttempts(task.Status.Attempts, task.Status.Usage)
+		meta.RemoveStatusCondition(&task.Status.Conditions, taskConditionRetryScheduled)
+		setTaskCancelledCondition(task, kelos.TaskReasonCancelled, message)
+		return cl.Status().Update(ctx, task)
+	})
+}
+
+// cancelTask handles a cancellation request for a Job-backed Task. A Task
+// whose agent has not started is failed immediately. Otherwise the agent pod
+// is stopped gracefully so kelos-capture still reports the branch, commit and
+// usage, and updateStatus records the Task as cancelled once the Job fails.
+func (r *TaskReconciler) cancelTask(ctx context.Context, task *kelos.Task, job *batchv1.Job, reason string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if job == nil || !job.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finishCancelledTask(ctx, task, reason)
+	}
+
+	if job.Status.Active == 0 && job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+		// No agent pod has started yet, so there is nothing to capture.
+		propagationPolicy := metav1.DeletePropagationBackground
+		if err := r.Delete(ctx, job, &client.DeleteOptions{
+			PropagationPolicy: &propagationPolicy,
+		}); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "Unable to delete Job of cancelled Task", "job", job.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.finishCancelledTask(ctx, task, reason)
+	}
+
+	if job.Status.Succeeded == 0 && !isJobFailed(job) {
+		if err := r.stopJobPods(ctx, task, job); err != nil {
+			logger.Error(err, "Unable to stop agent of cancelled Task", "job", job.Name)
+			return ctrl.Result{}, err
+		}
+		requested, err := markTaskCancelRequested(ctx, r.Client, task, reason)
+		if err != nil {
+			logger.Error(err, "Unable to update Task status")
+			return ctrl.Result{}, err
+		}
+		if requested {
+			logger.Info("Cancelling Task", "job", job.Name)
+			r.recordEvent(task, corev1.EventTypeNormal, "TaskCancelRequested", "Stopping the agent of cancelled Task")
+		}
+	}
+
+	result, err := r.updateStatus(ctx, task, job)
+	if err != nil || isTerminalTaskPhase(task.Status.Phase) {
+		return result, err
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > taskCancelPollInterval {
+		result.RequeueAfter = taskCancelPollInterval
+	}
+	return result, nil
+}
+
+// stopJobPods keeps the Job from starting a replacement pod and asks the
+// kubelet to stop its running agent pods. Pods that are not yet bound to a
+// node have no output to capture and are deleted instead.
+func (r *TaskReconciler) stopJobPods(ctx context.Context, task *kelos.Task, job *batchv1.Job) error {
+	if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 0 {
+		patch := client.MergeFrom(job.DeepCopy())
+		backoffLimit := int32(0)
+		job.Spec.BackoffLimit = &backoffLimit
+		if err := r.Patch(ctx, job, patch); err != nil {
+			return fmt.Errorf("disabling retries of Job %s: %w", job.Name, err)
+		}
+	}
+
+	var pods corev1.PodList
+	podLabels := client.MatchingLabels{
+		"kelos.dev/task": task.Name,
+	}
+	if job.UID != "" {
+		podLabels[batchv1.ControllerUidLabel] = string(job.UID)
+	}
+	if err := r.List(ctx, &pods, client.InNamespace(task.Namespace), podLabels); err != nil {
+		return fmt.Errorf("listing pods of Job %s: %w", job.Name, err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			if err := r.Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("deleting pod %s: %w", pod.Name, err)
+			}
+			continue
+		}
+		if pod.Spec.ActiveDeadlineSeconds != nil && *pod.Spec.ActiveDeadlineSeconds <= taskCancelActiveDeadlineSeconds {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		deadline := taskCancelActiveDeadlineSeconds
+		pod.Spec.ActiveDeadlineSeconds = &deadline
+		if err := r.Patch(ctx, pod, patch); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("stopping pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// finishCancelledTask fails a Task whose agent is no longer running, releases
+// its branch lock, and records the usage of earlier attempts.
+func (r *TaskReconciler) finishCancelledTask(ctx context.Context, task *kelos.Task, reason string) error {
+	logger := log.FromContext(ctx)
+
+	if err := failTaskCancelled(ctx, r.Client, task, reason); err != nil {
+		logger.Error(err, "Unable to update Task status")
+		reconcileErrorsTotal.WithLabelValues("task").Inc()
+		return err
+	}
+
+	r.releaseBranchLock(ctx, task)
+
+	logger.Info("Cancelled Task")
+	r.recordEvent(task, corev1.EventTypeWarning, "TaskCancelled", "%s", task.Status.Message)
+	taskCompletedTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(kelos.TaskPhaseFailed)).Inc()
+
+	if task.Status.Usage != nil {
+		return r.createTaskRecord(ctx, task)
+	}
+	return nil
+}
+
+// cancelTask handles a cancellation request for a Task that runs on a worker
+// pool. The runner stops the agent when the worker pod carries the cancel
+// annotation and reports the Task as failed; monitorTaskCompletion then
+// records it as cancelled.
+func (r *WorkerPoolReconciler) cancelTask(ctx context.Context, task *kelos.Task, reason string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if task.Status.PodName == "" {
+		if err := failTaskCancelled(ctx, r.Client, task, reason); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.recordEvent(task, corev1.EventTypeWarning, "TaskCancelled", "%s", task.Status.Message)
+		return ctrl.Result{}, r.createWorkerTaskRecord(ctx, task)
+	}
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: task.Status.PodName}, &pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.monitorTaskCompletion(ctx, task)
+		}
+		return ctrl.Result{}, fmt.Errorf("workerpool: fetching worker pod %s for task %s: %w", task.Status.PodName, task.Name, err)
+	}
+
+	if pod.Annotations[kelos.AnnotationWorkerAssignedTask] != task.Name {
+		if err := failTaskCancelled(ctx, r.Client, task, reason); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.recordEvent(task, corev1.EventTypeWarning, "TaskCancelled", "%s", task.Status.Message)
+		return ctrl.Result{}, r.createWorkerTaskRecord(ctx, task)
+	}
+
+	switch pod.Annotations[kelos.AnnotationWorkerTaskStatus] {
+	case "succeeded", "failed":
+		return r.monitorTaskCompletion(ctx, task)
+	}
+
+	if _, err := requestWorkerPodTaskCancellation(ctx, r.Client, &pod, task.Name); err != nil {
+		logger.Error(err, "Failed to request worker task cancellation", "pod", pod.Name, "task", task.Name)
+		return ctrl.Result{}, err
+	}
+	requested, err := markTaskCancelRequested(ctx, r.Client, task, reason)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if requested {
+		logger.Info("Cancelling Task", "pod", pod.Name, "task", task.Name)
+		r.recordEvent(task, corev1.EventTypeNormal, "TaskCancelRequested", "Stopping the agent on worker pod %s", pod.Name)
+	}
+	return ctrl.Result{RequeueAfter: taskCancelPollInterval}, nil
+}
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func assertTaskCancelled(t *testing.T, cl client.Client, task *kelos.Task, wantMessage string) *kelos.Task {
+	t.Helper()
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+	}
+	if updated.Status.Message != wantMessage {
+		t.Errorf("message = %q, want %q", updated.Status.Message, wantMessage)
+	}
+	if updated.Status.CompletionTime == nil {
+		t.Error("expected completionTime to be set")
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionCancelled)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != kelos.TaskReasonCancelled {
+		t.Errorf("Cancelled condition = %+v, want True with reason %s", cond, kelos.TaskReasonCancelled)
+	}
+	return updated
+}
+
+func TestReconcileCancelsTaskWithoutJob(t *testing.T) {
+	scheme := newTestScheme()
+
+	nextRetry := metav1.NewTime(time.Now().Add(time.Minute))
+	task := newTestTask("task-1", kelos.TaskPhaseWaiting)
+	task.Finalizers = []string{taskFinalizer}
+	task.Annotations = map[string]string{kelos.AnnotationTaskCancel: ""}
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Status.NextRetryTime = &nextRetry
+	task.Status.Attempts = []kelos.TaskAttempt{{
+		Attempt: 1,
+		Reason:  kelos.TaskFailureReasonAgentError,
+		Usage:   &kelos.TaskUsage{InputTokens: int64Ptr(100)},
+	}}
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:   taskConditionRetryScheduled,
+		Status: metav1.ConditionTrue,
+		Reason: string(kelos.TaskFailureReasonAgentError),
+	})
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task).
+		Build()
+
+	locker := NewBranchLocker()
+	if ok, holder, err := locker.TryAcquire(context.Background(), cl, task); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %q, %v; want acquired", ok, holder, err)
+	}
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: locker}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	}); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	updated := assertTaskCancelled(t, cl, task, "Task was cancelled")
+	if updated.Status.NextRetryTime != nil {
+		t.Errorf("nextRetryTime = %v, want nil", updated.Status.NextRetryTime)
+	}
+	if meta.FindStatusCondition(updated.Status.Conditions, taskConditionRetryScheduled) != nil {
+		t.Errorf("expected %s condition to be removed", taskConditionRetryScheduled)
+	}
+	if updated.Status.Usage == nil || updated.Status.Usage.InputTokens == nil || *updated.Status.Usage.InputTokens != 100 {
+		t.Errorf("usage = %+v, want usage of previous attempts", updated.Status.Usage)
+	}
+	if holder, err := locker.Holder(context.Background(), cl, task); err != nil || holder != "" {
+		t.Errorf("branch lock holder = %q, %v; want released", holder, err)
+	}
+
+	var job batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &job); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Job for a cancelled Task, got err=%v", err)
+	}
+}
+
+func TestReconcileCancelDeletesUnstartedJob(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhasePending)
+	task.Finalizers = []string{taskFinalizer}
+	task.Annotations = map[string]string{kelos.AnnotationTaskCancel: "superseded"}
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	}); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	assertTaskCancelled(t, cl, task, "Task was cancelled: superseded")
+
+	var deleted batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &deleted); !apierrors.IsNotFound(err) {
+		t.Errorf("expected unstarted Job to be deleted, got err=%v", err)
+	}
+}
+
+func TestReconcileCancelStopsRunningAgent(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Finalizers = []string{taskFinalizer}
+	task.Annotations = map[string]string{kelos.AnnotationTaskCancel: ""}
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	backoffLimit := int32(1)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+			UID:       types.UID("job-uid"),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+		},
+		Status: batchv1.JobStatus{
+			Active: 1,
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "task-1-abcde",
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task":           task.Name,
+				batchv1.ControllerUidLabel: "job-uid",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job, pod).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	})
+	if err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > taskCancelPollInterval {
+		t.Errorf("RequeueAfter = %v, want at most %v", result.RequeueAfter, taskCancelPollInterval)
+	}
+
+	var updatedJob batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &updatedJob); err != nil {
+		t.Fatalf("getting Job: %v", err)
+	}
+	if updatedJob.Spec.BackoffLimit == nil || *updatedJob.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %v, want 0", updatedJob.Spec.BackoffLimit)
+	}
+
+	var updatedPod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &updatedPod); err != nil {
+		t.Fatalf("expected running pod to be kept for output capture: %v", err)
+	}
+	if d := updatedPod.Spec.ActiveDeadlineSeconds; d == nil || *d != taskCancelActiveDeadlineSeconds {
+		t.Errorf("activeDeadlineSeconds = %v, want %d", d, taskCancelActiveDeadlineSeconds)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if isTerminalTaskPhase(updated.Status.Phase) {
+		t.Errorf("phase = %q, want the Task to stay active until the agent stops", updated.Status.Phase)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionCancelled)
+	if cond == nil || cond.Reason != kelos.TaskReasonCancelRequested {
+		t.Errorf("Cancelled condition = %+v, want reason %s", cond, kelos.TaskReasonCancelRequested)
+	}
+}
+
+func TestUpdateStatusRecordsCancelledJobWithoutRetry(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Finalizers = []string{taskFinalizer}
+	task.Annotations = map[string]string{kelos.AnnotationTaskCancel: "no longer needed"}
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	job := newFailedJob("task-1")
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	locker := NewBranchLocker()
+	if ok, holder, err := locker.TryAcquire(context.Background(), cl, task); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %q, %v; want acquired", ok, holder, err)
+	}
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: locker}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	updated := assertTaskCancelled(t, cl, task, "Task was cancelled: no longer needed")
+	if len(updated.Status.Attempts) != 0 {
+		t.Errorf("attempts = %d, want a cancelled Task not to be retried", len(updated.Status.Attempts))
+	}
+	if holder, err := locker.Holder(context.Background(), cl, task); err != nil || holder != "" {
+		t.Errorf("branch lock holder = %q, %v; want released", holder, err)
+	}
+}
+
+func TestWorkerPoolReconcilerCancelsAssignedTask(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-task",
+			Namespace:   "default",
+			Annotations: map[string]string{kelos.AnnotationTaskCancel: ""},
+		},
+		Spec: kelos.TaskSpec{
+			Type:          AgentTypeClaudeCode,
+			Prompt:        "Do something",
+			WorkerPoolRef: &kelos.WorkerPoolReference{Name: "my-pool"},
+		},
+		Status: kelos.TaskStatus{
+			Phase:   kelos.TaskPhaseRunning,
+			PodName: "wp-my-pool-0",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wp-my-pool-0",
+			Namespace: "default",
+			Annotations: map[string]string{
+				kelos.AnnotationWorkerAssignedTask: "test-task",
+				kelos.AnnotationWorkerTaskStatus:   "running",
+			},
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, pod).
+		Build()
+
+	r := newWorkerPoolReconciler(cl, scheme)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	var updatedPod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &updatedPod); err != nil {
+		t.Fatalf("getting pod: %v", err)
+	}
+	if updatedPod.Annotations[kelos.AnnotationWorkerCancelTask] != "test-task" {
+		t.Fatalf("cancel-task annotation = %q, want %q", updatedPod.Annotations[kelos.AnnotationWorkerCancelTask], "test-task")
+	}
+
+	// The runner stops the agent and reports the Task as failed.
+	podPatch := client.MergeFrom(updatedPod.DeepCopy())
+	updatedPod.Annotations[kelos.AnnotationWorkerTaskStatus] = "failed"
+	updatedPod.Annotations[kelos.AnnotationWorkerTaskFailReason] = "task was cancelled"
+	if err := cl.Patch(context.Background(), &updatedPod, podPatch); err != nil {
+		t.Fatalf("patching pod: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	assertTaskCancelled(t, cl, task, "Task was cancelled")
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &updatedPod); err != nil {
+		t.Fatalf("getting pod: %v", err)
+	}
+	if _, ok := updatedPod.Annotations[kelos.AnnotationWorkerAssignedTask]; ok {
+		t.Error("expected the worker pod assignment to be released")
+	}
+}
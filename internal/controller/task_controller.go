@@ -3,6 +3,7 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -13,6 +14,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -89,11 +91,15 @@ type TaskReconciler struct {
 // +kubebuilder:rbac:groups=kelos.dev,resources=taskbudgets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kelos.dev,resources=taskbudgets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update
 
 // Reconcile handles Task reconciliation.
 func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -142,6 +148,20 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	if reason, ok := taskCancelRequested(&task); ok && !isTerminalTaskPhase(task.Status.Phase) {
+		var current *batchv1.Job
+		if jobExists {
+			current = &job
+		}
+		return r.cancelTask(ctx, &task, current, reason)
+	}
+
+	// The Job of a failed attempt is being deleted before its retry; wait
+	// for it to disappear so the retry can reuse the Job name.
+	if jobExists && !job.DeletionTimestamp.IsZero() && task.Status.NextRetryTime != nil {
+		return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+	}
+
 	if !jobExists && isTerminalTaskPhase(task.Status.Phase) {
 		// The Job may have been deleted before its TaskRecord was created (e.g. a
 		// transient create failure). Retry here so budget usage is not undercounted.
@@ -156,46 +176,23 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	// Create Job if it doesn't exist
 	if !jobExists {
-		if len(task.Spec.DependsOn) > 0 {
-			ready, result, err := r.checkDependencies(ctx, &task)
-			if err != nil || !ready {
-				return result, err
-			}
+		if wait := retryWaitRemaining(&task, r.now()); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
 		}
 
-		if task.Spec.Branch != "" {
-			if resolveTaskWorkspaceRef(&task) == nil {
-				logger.Info("Branch is set without workspaceRef, branch checkout will not happen", "task", task.Name, "branch", task.Spec.Branch)
-				r.recordEvent(&task, corev1.EventTypeWarning, "BranchWithoutWorkspace", "Branch %q is set but workspaceRef is not configured, branch checkout will be skipped", task.Spec.Branch)
-			}
-			lockKey := branchLockKey(&task)
-			acquired, holder := r.BranchLocker.TryAcquire(lockKey, task.Name)
-			if !acquired {
-				// In-memory lock is held by another task.
-				logger.Info("Branch locked by another task", "branch", task.Spec.Branch, "lockedBy", holder)
-				r.setWaitingPhase(ctx, &task, fmt.Sprintf("Waiting for branch %q (locked by %s)", task.Spec.Branch, holder))
-				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
-			}
-			// Fallback: check status-based lock for restart recovery.
-			// After a restart the in-memory map is empty, so TryAcquire
-			// always succeeds. The status check catches Running/Pending
-			// tasks whose lock was lost.
-			locked, result, err := r.checkBranchLock(ctx, &task)
-			if err != nil || locked {
-				r.BranchLocker.Release(lockKey, task.Name)
-				return result, err
-			}
+		remaining, waiting := waitingTimeoutRemaining(&task, r.now())
+		if waiting && remaining <= 0 {
+			return ctrl.Result{}, r.failTaskTimedOut(ctx, &task, kelos.TaskReasonWaitingTimeout, waitingTimeoutMessage(&task))
 		}
 
-		admitted, result, err := r.checkBudgetAdmission(ctx, &task)
-		if err != nil || !admitted {
-			if task.Spec.Branch != "" {
-				r.BranchLocker.Release(branchLockKey(&task), task.Name)
+		result, err := r.startTask(ctx, &task)
+		if err == nil && waiting && task.Status.JobName == "" && !isTerminalTaskPhase(task.Status.Phase) {
+			// Check the waiting timeout again when it expires.
+			if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+				result.RequeueAfter = remaining
 			}
-			return result, err
 		}
-
-		return r.createJob(ctx, &task)
+		return result, err
 	}
 
 	// Update status based on Job status
@@ -204,6 +201,40 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return result, err
 	}
 
+	if task.Spec.PendingTimeoutSeconds != nil && !isTerminalTaskPhase(task.Status.Phase) {
+		next, err := r.enforcePendingTimeout(ctx, &task, &job)
+		if err != nil {
+			logger.Error(err, "Unable to enforce pending timeout")
+			return ctrl.Result{}, err
+		}
+		if next > 0 && (result.RequeueAfter == 0 || next < result.RequeueAfter) {
+			result.RequeueAfter = next
+		}
+	}
+
+	if taskBranch(&task) != "" && (task.Status.Phase == kelos.TaskPhasePending || task.Status.Phase == kelos.TaskPhaseRunning) {
+		r.renewBranchLock(ctx, &task)
+		if result.RequeueAfter == 0 || branchLeaseRenewInterval < result.RequeueAfter {
+			result.RequeueAfter = branchLeaseRenewInterval
+		}
+	}
+
+	if err := r.releasePushApproval(ctx, &task, &job); err != nil {
+		logger.Error(err, "Unable to release push approval")
+		return ctrl.Result{}, err
+	}
+
+	if job.Status.Active > 0 && task.Spec.SpendLimit != nil {
+		next, err := r.enforceSpendLimit(ctx, &task, &job)
+		if err != nil {
+			logger.Error(err, "Unable to enforce spend limit")
+			return ctrl.Result{}, err
+		}
+		if result.RequeueAfter == 0 || next < result.RequeueAfter {
+			result.RequeueAfter = next
+		}
+	}
+
 	// Refresh the per-task GitHub App installation token when the job
 	// is still running, so long-running agent pods keep working past
 	// the 1h installation-token TTL. Errors are logged but do not
@@ -252,15 +283,87 @@ func (r *TaskReconciler) applyTaskTTL(ctx context.Context, task *kelos.Task, res
 	return result, nil
 }
 
+// startTask takes a Task without a Job through approval, dependency, branch
+// lock, and budget admission, and creates its Job once all of them pass.
+func (r *TaskReconciler) startTask(ctx context.Context, task *kelos.Task) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if err := validateTaskConversation(task); err != nil {
+		message := fmt.Sprintf("Invalid conversation: %v", err)
+		r.recordEvent(task, corev1.EventTypeWarning, "ConversationInvalid", "%s", message)
+		return ctrl.Result{}, r.failTaskBeforeJob(ctx, task, message)
+	}
+
+	approved, err := r.checkApproval(ctx, task)
+	if err != nil {
+		logger.Error(err, "Unable to update Task status")
+		return ctrl.Result{}, err
+	}
+	if !approved {
+		return ctrl.Result{}, nil
+	}
+
+	if len(task.Spec.DependsOn) > 0 {
+		ready, result, err := r.checkDependencies(ctx, task)
+		if err != nil || !ready {
+			return result, err
+		}
+	}
+
+	if task.Spec.ContinueFrom != "" && task.Status.ConversationClaimName == "" {
+		ready, result, err := r.checkContinuation(ctx, task)
+		if err != nil || !ready {
+			return result, err
+		}
+	}
+
+	if taskBranch(task) != "" {
+		if resolveTaskWorkspaceRef(task) == nil {
+			logger.Info("Branch is set without workspaceRef, branch checkout will not happen", "task", task.Name, "branch", taskBranch(task))
+			r.recordEvent(task, corev1.EventTypeWarning, "BranchWithoutWorkspace", "Branch %q is set but workspaceRef is not configured, branch checkout will be skipped", taskBranch(task))
+		}
+		acquired, holder, err := r.BranchLocker.TryAcquire(ctx, r.Client, task)
+		if err != nil {
+			logger.Error(err, "Unable to acquire branch lock", "branch", taskBranch(task))
+			return ctrl.Result{}, err
+		}
+		if !acquired {
+			// The branch Lease is held by another task.
+			logger.Info("Branch locked by another task", "branch", taskBranch(task), "lockedBy", holder)
+			position, err := r.branchQueuePosition(ctx, task)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			r.setQueuedPhase(ctx, task, fmt.Sprintf("Waiting for branch %q (locked by %s)", taskBranch(task), holder), position, holder)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		// Fallback: check the status-based lock. It catches
+		// Running/Pending tasks that hold the branch without a Lease,
+		// such as tasks started by an older controller, and keeps
+		// Waiting tasks in queue order.
+		locked, result, err := r.checkBranchLock(ctx, task)
+		if err != nil || locked {
+			r.releaseBranchLock(ctx, task)
+			return result, err
+		}
+	}
+
+	admitted, result, err := r.checkBudgetAdmission(ctx, task)
+	if err != nil || !admitted {
+		r.releaseBranchLock(ctx, task)
+		return result, err
+	}
+
+	return r.createJob(ctx, task)
+}
+
 // handleDeletion handles Task deletion.
 func (r *TaskReconciler) handleDeletion(ctx context.Context, task *kelos.Task) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	if controllerutil.ContainsFinalizer(task, taskFinalizer) {
 		// Release branch lock if held.
-		if task.Spec.Branch != "" {
-			r.BranchLocker.Release(branchLockKey(task), task.Name)
-		}
+		r.releaseBranchLock(ctx, task)
 
 		if task.Spec.WorkerPoolRef != nil {
 			if task.Status.PodName != "" {
@@ -315,7 +418,25 @@ func (r *TaskReconciler) clearWorkerPodAssignment(ctx context.Context, pod *core
 func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
+	var snapshot *resolvedSnapshotContent
+	if task.Spec.Resolved != nil {
+		content, err := loadResolvedSnapshot(ctx, r.Client, task)
+		var invalid *resolvedSnapshotError
+		if errors.As(err, &invalid) {
+			r.recordEvent(task, corev1.EventTypeWarning, "ResolvedSnapshotInvalid", "%v", err)
+			if updateErr := r.failTaskBeforeJob(ctx, task, err.Error()); updateErr != nil {
+				logger.Error(updateErr, "Unable to update Task status")
+			}
+			return ctrl.Result{}, nil
+		} else if err != nil {
+			logger.Error(err, "Unable to load resolved snapshot")
+			return ctrl.Result{}, err
+		}
+		snapshot = content
+	}
+
 	var workspace *kelos.WorkspaceSpec
+	var workspaceName string
 	if resolveTaskWorkspaceRef(task) != nil {
 		var ws kelos.Workspace
 		if err := r.Get(ctx, client.ObjectKey{
@@ -329,45 +450,98 @@ func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.
 			logger.Error(err, "Unable to fetch Workspace", "workspace", resolveTaskWorkspaceRef(task).Name)
 			return ctrl.Result{}, err
 		}
-		workspace = &ws.Spec
-
-		// Handle GitHub App authentication
-		if workspace.SecretRef != nil {
-			resolvedWorkspace, err := r.resolveGitHubAppToken(ctx, task, workspace)
-			if err != nil {
-				logger.Error(err, "Unable to resolve GitHub App token")
-				message := fmt.Sprintf("Failed to resolve GitHub token: %v", err)
-				r.recordEvent(task, corev1.EventTypeWarning, "GitHubTokenFailed", "%s", message)
-				if updateErr := r.failTaskBeforeJob(ctx, task, message); updateErr != nil {
-					logger.Error(updateErr, "Unable to update Task status")
-				}
-				return ctrl.Result{}, nil
+		// Hold the Task while validation reports the Workspace as broken
+		// instead of failing in git-clone. The Workspace watch requeues the
+		// Task once the Workspace becomes ready.
+		if ready := workspaceReadyCondition(&ws); ready != nil && ready.Status == metav1.ConditionFalse {
+			logger.Info("Workspace not ready, waiting", "workspace", ws.Name, "reason", ready.Reason)
+			message := fmt.Sprintf("Waiting for Workspace %q to become ready: %s", ws.Name, ready.Message)
+			if task.Status.Message != message {
+				r.recordEvent(task, corev1.EventTypeWarning, "WorkspaceNotReady", "Workspace %q is not ready: %s", ws.Name, ready.Message)
 			}
-			workspace = resolvedWorkspace
+			r.releaseBranchLock(ctx, task)
+			r.setWaitingPhase(ctx, task, message)
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 		}
+		workspace = ws.Spec.DeepCopy()
+		workspaceName = ws.Name
+	}
+
+	// A Task with a snapshot runs with the agent type, image and model of
+	// the snapshot.
+	buildTask := task
+	if task.Spec.Resolved != nil {
+		buildTask = r.JobBuilder.taskForSnapshot(task)
 	}
 
 	var agentConfig *kelos.AgentConfigSpec
-	if refs := ResolveAgentConfigRefs(&task.Spec); len(refs) > 0 {
-		var specs []kelos.AgentConfigSpec
-		for _, ref := range refs {
-			ac, err := r.getAgentConfig(ctx, client.ObjectKey{
-				Namespace: task.Namespace,
-				Name:      ref.Name,
-			})
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					logger.Info("AgentConfig not found yet, requeuing", "agentConfig", ref.Name)
-					return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
-				}
-				logger.Error(err, "Unable to fetch AgentConfig", "agentConfig", ref.Name)
-				return ctrl.Result{}, err
+	agentConfigRefs := ResolveAgentConfigRefs(&task.Spec)
+	if len(agentConfigRefs) > 0 {
+		resolved, err := ResolveAgentConfigs(ctx, r.Client, task.Namespace, agentConfigRefs, resolveTaskType(buildTask))
+		var notFound *AgentConfigNotFoundError
+		var cycle *AgentConfigCycleError
+		switch {
+		case errors.As(err, &notFound) && notFound.ExtendedBy == "":
+			logger.Info("AgentConfig not found yet, requeuing", "agentConfig", notFound.Name)
+			return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+		case errors.As(err, &notFound):
+			message := fmt.Sprintf("Waiting for AgentConfig %q extended by %q", notFound.Name, notFound.ExtendedBy)
+			if task.Status.Message != message {
+				r.recordEvent(task, corev1.EventTypeWarning, "AgentConfigNotFound", "%v", err)
+			}
+			r.releaseBranchLock(ctx, task)
+			r.setWaitingPhase(ctx, task, message)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		case errors.As(err, &cycle):
+			r.recordEvent(task, corev1.EventTypeWarning, "AgentConfigInvalid", "%v", err)
+			if updateErr := r.failTaskBeforeJob(ctx, task, err.Error()); updateErr != nil {
+				logger.Error(updateErr, "Unable to update Task status")
 			}
-			specs = append(specs, ac.Spec)
+			return ctrl.Result{}, nil
+		case err != nil:
+			logger.Error(err, "Unable to resolve AgentConfigs")
+			return ctrl.Result{}, err
+		}
+		agentConfig = resolved
+	}
+
+	if snapshot != nil {
+		if err := checkResolvedSnapshotRefs(task, snapshot, workspaceName, workspace, agentConfigRefs, agentConfig); err != nil {
+			r.recordEvent(task, corev1.EventTypeWarning, "ResolvedSnapshotInvalid", "%v", err)
+			if updateErr := r.failTaskBeforeJob(ctx, task, err.Error()); updateErr != nil {
+				logger.Error(updateErr, "Unable to update Task status")
+			}
+			return ctrl.Result{}, nil
 		}
+		workspace = snapshot.Workspace.DeepCopy()
+		agentConfig = snapshot.AgentConfig.DeepCopy()
+	}
+	snapshotContent := &resolvedSnapshotContent{
+		Workspace:   workspace.DeepCopy(),
+		AgentConfig: agentConfig.DeepCopy(),
+	}
+	if snapshot != nil && workspace != nil {
+		// The git mirror of the Workspace may be gone by now. It only speeds
+		// up the clone, so clone from the remote instead.
+		workspace.Cache = nil
+	}
 
-		agentConfig = MergeAgentConfigs(specs)
+	// Handle GitHub App authentication
+	if workspace != nil && workspace.SecretRef != nil {
+		resolvedWorkspace, err := r.resolveGitHubAppToken(ctx, task, workspace)
+		if err != nil {
+			logger.Error(err, "Unable to resolve GitHub App token")
+			message := fmt.Sprintf("Failed to resolve GitHub token: %v", err)
+			r.recordEvent(task, corev1.EventTypeWarning, "GitHubTokenFailed", "%s", message)
+			if updateErr := r.failTaskBeforeJob(ctx, task, message); updateErr != nil {
+				logger.Error(updateErr, "Unable to update Task status")
+			}
+			return ctrl.Result{}, nil
+		}
+		workspace = resolvedWorkspace
+	}
 
+	if agentConfig != nil {
 		if len(agentConfig.Skills) > 0 {
 			if err := r.validateSkillsAuthSecrets(ctx, task.Namespace, agentConfig.Skills); err != nil {
 				logger.Error(err, "Unable to validate skills auth secrets")
@@ -396,8 +570,9 @@ func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.
 	}
 
 	resolvedPrompt := r.resolvePromptTemplate(ctx, task)
+	resolvedPrompt = appendRetryContext(task, resolvedPrompt)
 
-	job, err := r.JobBuilder.Build(task, workspace, agentConfig, resolvedPrompt)
+	job, err := r.JobBuilder.Build(buildTask, workspace, agentConfig, resolvedPrompt)
 	if err != nil {
 		logger.Error(err, "unable to build Job")
 		message := fmt.Sprintf("Failed to build Job: %v", err)
@@ -407,6 +582,15 @@ func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.
 		}
 		return ctrl.Result{}, err
 	}
+	resolvedSnapshot, err := buildResolvedSnapshot(buildTask, job, workspaceName, agentConfigRefs, snapshotContent)
+	if err != nil {
+		logger.Error(err, "Unable to build resolved snapshot")
+		return ctrl.Result{}, err
+	}
+	if err := ensureResolvedSnapshotConfigMap(ctx, r.Client, r.Scheme, task, snapshotContent); err != nil {
+		logger.Error(err, "Unable to ensure resolved snapshot ConfigMap")
+		return ctrl.Result{}, err
+	}
 
 	// Plugin content is delivered via a per-task ConfigMap that the Job's
 	// plugin-setup init container mounts, so it must exist before the Job.
@@ -438,6 +622,27 @@ func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.
 		}
 	}
 
+	// kelos-capture hands the outputs to the controller through the Task's
+	// results channel, which must be writable before the agent starts.
+	configureResultsChannel(task, job)
+	if err := ensureTaskResultsAccess(ctx, r.Client, r.Scheme, task, job.Spec.Template.Spec.ServiceAccountName); err != nil {
+		logger.Error(err, "unable to ensure results channel")
+		r.recordEvent(task, corev1.EventTypeWarning, "ResultsChannelFailed", "Failed to set up results channel: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// The conversation state lives on a PersistentVolumeClaim that outlives
+	// the Job, so that a later Task can continue it.
+	conversationClaim, err := r.ensureConversationClaim(ctx, task)
+	if err != nil {
+		logger.Error(err, "unable to ensure conversation PersistentVolumeClaim")
+		r.recordEvent(task, corev1.EventTypeWarning, "ConversationClaimFailed", "Failed to set up conversation storage: %v", err)
+		return ctrl.Result{}, err
+	}
+	if conversationClaim != "" {
+		configureConversation(task, job, conversationClaim)
+	}
+
 	// Set owner reference
 	if err := controllerutil.SetControllerReference(task, job, r.Scheme); err != nil {
 		logger.Error(err, "unable to set owner reference")
@@ -466,6 +671,17 @@ func (r *TaskReconciler) createJob(ctx context.Context, task *kelos.Task) (ctrl.
 		// Clear any stale message from a prior Waiting state (e.g. a budget-blocked
 		// or branch-lock wait) now that the Job has been created.
 		task.Status.Message = ""
+		task.Status.QueuePosition = nil
+		task.Status.BranchLockHolder = ""
+		task.Status.Resolved = resolvedSnapshot
+		if conversationClaim != "" {
+			task.Status.ConversationClaimName = conversationClaim
+		}
+		if task.Spec.RetryPolicy != nil {
+			task.Status.Attempt = currentAttempt(task)
+			task.Status.NextRetryTime = nil
+			meta.RemoveStatusCondition(&task.Status.Conditions, taskConditionRetryScheduled)
+		}
 		return r.Status().Update(ctx, task)
 	}); err != nil {
 		logger.Error(err, "Unable to update Task status")
@@ -490,9 +706,7 @@ func (r *TaskReconciler) failTaskBeforeJob(ctx context.Context, task *kelos.Task
 	}); err != nil {
 		return err
 	}
-	if task.Spec.Branch != "" && r.BranchLocker != nil {
-		r.BranchLocker.Release(branchLockKey(task), task.Name)
-	}
+	r.releaseBranchLock(ctx, task)
 	return nil
 }
 
@@ -857,9 +1071,15 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 	var podName string
 	podListSucceeded := false
 	var pods corev1.PodList
-	if err := r.List(ctx, &pods, client.InNamespace(task.Namespace), client.MatchingLabels{
+	podLabels := client.MatchingLabels{
 		"kelos.dev/task": task.Name,
-	}); err == nil {
+	}
+	// Scope to the current Job so pods of an earlier attempt that are still
+	// being garbage collected are not mistaken for the current one.
+	if job.UID != "" {
+		podLabels[batchv1.ControllerUidLabel] = string(job.UID)
+	}
+	if err := r.List(ctx, &pods, client.InNamespace(task.Namespace), podLabels); err == nil {
 		podListSucceeded = true
 		podName = latestTaskPodName(pods.Items)
 	}
@@ -868,6 +1088,9 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 	var newPhase kelos.TaskPhase
 	var newMessage string
 	var setStartTime, setCompletionTime bool
+	cancelReason, cancelled := taskCancelRequested(task)
+	spendLimitMessage, spendLimitExceeded := taskSpendLimitExceeded(task)
+	timeoutMessage, timedOut := taskTimedOut(task)
 
 	if job.Status.Active > 0 {
 		if task.Status.Phase != kelos.TaskPhaseRunning {
@@ -876,19 +1099,38 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 			r.recordEvent(task, corev1.EventTypeNormal, "TaskRunning", "Task started running")
 		}
 	} else if job.Status.Succeeded > 0 {
-		if task.Status.Phase != kelos.TaskPhaseSucceeded {
+		// A Task whose results were rejected stays Failed even though its
+		// Job succeeded.
+		if task.Status.Phase != kelos.TaskPhaseSucceeded && !meta.IsStatusConditionTrue(task.Status.Conditions, kelos.TaskConditionResultsInvalid) {
 			newPhase = kelos.TaskPhaseSucceeded
 			newMessage = "Task completed successfully"
 			setCompletionTime = true
-			r.recordEvent(task, corev1.EventTypeNormal, "TaskSucceeded", "Task completed successfully")
-			taskCompletedTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(kelos.TaskPhaseSucceeded)).Inc()
 		}
 	} else if isJobFailed(job) {
 		if task.Status.Phase != kelos.TaskPhaseFailed {
+			// A Task stopped for exceeding its spend limit or pending
+			// timeout is not retried.
+			if reason, message := jobFailureReason(job); !cancelled && !spendLimitExceeded && !timedOut && shouldRetryTask(task, reason) {
+				return r.scheduleRetry(ctx, task, job, podName, reason, message)
+			}
 			newPhase = kelos.TaskPhaseFailed
 			newMessage = "Task failed"
+			if len(task.Status.Attempts) > 0 {
+				newMessage = fmt.Sprintf("Task failed after %d attempts", currentAttempt(task))
+			}
 			setCompletionTime = true
-			r.recordEvent(task, corev1.EventTypeWarning, "TaskFailed", "Task failed")
+			if cancelled {
+				newMessage = taskCancelMessage(cancelReason)
+				r.recordEvent(task, corev1.EventTypeWarning, "TaskCancelled", "%s", newMessage)
+			} else if spendLimitExceeded {
+				newMessage = spendLimitMessage
+				r.recordEvent(task, corev1.EventTypeWarning, "TaskFailed", "%s", newMessage)
+			} else if timedOut {
+				newMessage = timeoutMessage
+				r.recordEvent(task, corev1.EventTypeWarning, "TaskFailed", "%s", newMessage)
+			} else {
+				r.recordEvent(task, corev1.EventTypeWarning, "TaskFailed", "Task failed")
+			}
 			taskCompletedTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(kelos.TaskPhaseFailed)).Inc()
 		}
 	}
@@ -896,11 +1138,14 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 	podNameChanged := podListSucceeded && task.Status.PodName != podName
 	phaseChanged := newPhase != ""
 
-	// Check if we should retry capturing outputs for an already-completed task
+	// Check if we should retry capturing outputs for an already-completed task.
+	// Tasks with a resultsSchema already waited for their outputs before
+	// their results were validated.
 	retryOutputs := !phaseChanged &&
 		len(task.Status.Outputs) == 0 && len(task.Status.Results) == 0 &&
 		task.Status.CompletionTime != nil &&
-		time.Since(task.Status.CompletionTime.Time) < outputRetryWindow
+		time.Since(task.Status.CompletionTime.Time) < outputRetryWindow &&
+		task.Spec.ResultsSchema == ""
 
 	if !phaseChanged && !podNameChanged && !retryOutputs {
 		if isTerminalTaskPhase(task.Status.Phase) && task.Status.Usage != nil {
@@ -911,17 +1156,24 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 		return ctrl.Result{}, nil
 	}
 
-	// Read outputs from Pod logs when transitioning to a terminal phase
+	// Read outputs from the results channel or Pod logs when transitioning to a terminal phase
 	// or retrying capture for an already-completed task
 	var outputs []string
 	var results map[string]string
+	var usage *kelos.TaskUsage
 	if setCompletionTime || retryOutputs {
 		effectivePodName := podName
 		if effectivePodName == "" {
 			effectivePodName = task.Status.PodName
 		}
 		containerName := kelos.AgentContainerName
-		outputs, results = r.readOutputs(ctx, task.Namespace, effectivePodName, containerName)
+		outputs, results = r.readOutputs(ctx, task, effectivePodName, containerName)
+		usage = usageFromResults(results)
+		if usage == nil && spendLimitExceeded {
+			// The stopped agent did not report its final usage, so
+			// record the last running usage instead.
+			usage = r.readUsageReport(ctx, task.Namespace, effectivePodName)
+		}
 	}
 
 	// When retrying output capture, skip the status update if we still
@@ -930,6 +1182,26 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 		return ctrl.Result{RequeueAfter: outputRetryInterval}, nil
 	}
 
+	// A Task with a resultsSchema only succeeds when its results match.
+	resultsInvalid := false
+	if newPhase == kelos.TaskPhaseSucceeded {
+		if awaitingTaskResults(task, job, outputs, r.now()) {
+			return ctrl.Result{RequeueAfter: outputRetryInterval}, nil
+		}
+		if message := validateTaskResults(task, results); message != "" {
+			if !cancelled && shouldRetryTask(task, kelos.TaskFailureReasonResultsInvalid) {
+				return r.scheduleRetry(ctx, task, job, podName, kelos.TaskFailureReasonResultsInvalid, message)
+			}
+			newPhase = kelos.TaskPhaseFailed
+			newMessage = message
+			resultsInvalid = true
+			r.recordEvent(task, corev1.EventTypeWarning, "ResultsInvalid", "%s", message)
+		} else {
+			r.recordEvent(task, corev1.EventTypeNormal, "TaskSucceeded", "Task completed successfully")
+		}
+		taskCompletedTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(newPhase)).Inc()
+	}
+
 	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
 			return getErr
@@ -948,13 +1220,25 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 				task.Status.CompletionTime = &now
 				task.Status.Outputs = outputs
 				task.Status.Results = results
-				task.Status.Usage = usageFromResults(results)
+				task.Status.Artifacts = ArtifactsFromOutputs(outputs)
+				task.Status.Usage = usageWithAttempts(task.Status.Attempts, usage)
+				if resultsInvalid {
+					setTaskResultsInvalidCondition(task, newMessage)
+				}
+				if cancelled && newPhase == kelos.TaskPhaseFailed && !resultsInvalid {
+					setTaskCancelledCondition(task, kelos.TaskReasonCancelled, newMessage)
+				} else {
+					// A cancellation that arrived after the agent finished
+					// did not stop the Task.
+					meta.RemoveStatusCondition(&task.Status.Conditions, kelos.TaskConditionCancelled)
+				}
 			}
 		}
 		if retryOutputs && (outputs != nil || results != nil) {
 			task.Status.Outputs = outputs
 			task.Status.Results = results
-			task.Status.Usage = usageFromResults(results)
+			task.Status.Artifacts = ArtifactsFromOutputs(outputs)
+			task.Status.Usage = usageWithAttempts(task.Status.Attempts, usage)
 		}
 		return r.Status().Update(ctx, task)
 	}); err != nil {
@@ -964,8 +1248,8 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 	}
 
 	// Release branch lock when task reaches a terminal phase.
-	if setCompletionTime && task.Spec.Branch != "" {
-		r.BranchLocker.Release(branchLockKey(task), task.Name)
+	if setCompletionTime {
+		r.releaseBranchLock(ctx, task)
 	}
 
 	// Record task duration when completion time is set and we have a start time
@@ -974,6 +1258,19 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *kelos.Task, job
 		taskDurationSeconds.WithLabelValues(task.Namespace, resolveTaskType(task), string(newPhase)).Observe(duration)
 	}
 
+	// Record how long the workspace clone of the final pod took
+	if setCompletionTime {
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Name != podName {
+				continue
+			}
+			if duration, ok := gitCloneDuration(pod); ok {
+				taskCloneDurationSeconds.WithLabelValues(task.Namespace, resolveTaskType(task), strconv.FormatBool(podUsesWorkspaceCache(pod))).Observe(duration)
+			}
+		}
+	}
+
 	// Record cost and token metrics when results are available
 	if (setCompletionTime || retryOutputs) && results != nil {
 		RecordCostTokenMetrics(task, results)
@@ -1050,15 +1347,20 @@ func isTerminalTaskPhase(phase kelos.TaskPhase) bool {
 	return phase == kelos.TaskPhaseSucceeded || phase == kelos.TaskPhaseFailed
 }
 
-// readOutputs reads Pod logs and extracts output markers and structured results.
-func (r *TaskReconciler) readOutputs(ctx context.Context, namespace, podName, container string) ([]string, map[string]string) {
+// readOutputs reads the outputs of the agent from the Task's results
+// channel, falling back to the output markers in the Pod logs, and extracts
+// structured results.
+func (r *TaskReconciler) readOutputs(ctx context.Context, task *kelos.Task, podName, container string) ([]string, map[string]string) {
+	if outputs := readResultsChannel(ctx, r.Client, task, podName); outputs != nil {
+		return outputs, ResultsFromOutputs(outputs)
+	}
 	if r.Clientset == nil || podName == "" {
 		return nil, nil
 	}
 	logger := log.FromContext(ctx)
 
 	var tailLines int64 = 50
-	req := r.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+	req := r.Clientset.CoreV1().Pods(task.Namespace).GetLogs(podName, &corev1.PodLogOptions{
 		Container: container,
 		TailLines: &tailLines,
 	})
@@ -1159,23 +1461,31 @@ func (r *TaskReconciler) checkDependencies(ctx context.Context, task *kelos.Task
 // detectCycle walks the dependency graph from the given task and returns an
 // error if a cycle is detected.
 func (r *TaskReconciler) detectCycle(ctx context.Context, task *kelos.Task) error {
+	return detectDependencyCycle(task.Name, func(name string) []string {
+		var t kelos.Task
+		if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: name}, &t); err != nil {
+			return nil // Cannot detect cycle if task doesn't exist yet
+		}
+		return t.Spec.DependsOn
+	})
+}
+
+// detectDependencyCycle walks a dependency graph from start and returns an
+// error if a cycle is detected. dependsOn returns the direct dependencies of
+// a node; unknown nodes have none.
+func detectDependencyCycle(start string, dependsOn func(name string) []string) error {
 	visited := make(map[string]bool)
-	return r.walkDeps(ctx, task.Namespace, task.Name, visited)
+	return walkDependencies(start, dependsOn, visited)
 }
 
-func (r *TaskReconciler) walkDeps(ctx context.Context, namespace, name string, visited map[string]bool) error {
+func walkDependencies(name string, dependsOn func(name string) []string, visited map[string]bool) error {
 	if visited[name] {
 		return fmt.Errorf("cycle involves %q", name)
 	}
 	visited[name] = true
 
-	var t kelos.Task
-	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &t); err != nil {
-		return nil // Cannot detect cycle if task doesn't exist yet
-	}
-
-	for _, dep := range t.Spec.DependsOn {
-		if err := r.walkDeps(ctx, namespace, dep, visited); err != nil {
+	for _, dep := range dependsOn(name) {
+		if err := walkDependencies(dep, dependsOn, visited); err != nil {
 			return err
 		}
 	}
@@ -1192,58 +1502,159 @@ func branchLockKey(task *kelos.Task) string {
 	if resolveTaskWorkspaceRef(task) != nil {
 		ws = resolveTaskWorkspaceRef(task).Name
 	}
-	return ws + ":" + task.Spec.Branch
+	return ws + ":" + taskBranch(task)
+}
+
+// releaseBranchLock releases the branch lock of the task if it holds one.
+// Failures are only logged: the lock is taken over once the task has
+// finished or the Lease has expired.
+func (r *TaskReconciler) releaseBranchLock(ctx context.Context, task *kelos.Task) {
+	if taskBranch(task) == "" || r.BranchLocker == nil {
+		return
+	}
+	if err := r.BranchLocker.Release(ctx, r.Client, task); err != nil {
+		log.FromContext(ctx).Error(err, "Unable to release branch lock", "branch", taskBranch(task))
+	}
+}
+
+// renewBranchLock renews the branch lock of an active task so that its
+// Lease does not expire while the agent runs. Losing the lock is only
+// possible when the Lease was not renewed in time, for example while the
+// controller was down, and is reported as a warning event.
+func (r *TaskReconciler) renewBranchLock(ctx context.Context, task *kelos.Task) {
+	if r.BranchLocker == nil {
+		return
+	}
+	logger := log.FromContext(ctx)
+	acquired, holder, err := r.BranchLocker.TryAcquire(ctx, r.Client, task)
+	if err != nil {
+		logger.Error(err, "Unable to renew branch lock", "branch", taskBranch(task))
+		return
+	}
+	if !acquired {
+		logger.Info("Branch lock was taken over by another task", "branch", taskBranch(task), "lockedBy", holder)
+		r.recordEvent(task, corev1.EventTypeWarning, "BranchLockLost", "Branch %q lock expired and is now held by %s", taskBranch(task), holder)
+	}
 }
 
 // checkBranchLock checks if another task with the same workspace and branch is
 // active. Returns (locked, result, error). locked=true means another task holds
 // the branch. A task is considered to hold the lock if it is Running, Pending,
-// or is an earlier-created Waiting task (FIFO ordering for the branch queue).
+// or is queued for the branch ahead of it in the branch's taskQueue (priority,
+// then FIFO ordering for the branch queue).
 func (r *TaskReconciler) checkBranchLock(ctx context.Context, task *kelos.Task) (bool, ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+
+	holder, ahead, err := r.branchQueue(ctx, task)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	position := int32(len(ahead) + 1)
+
+	if holder != nil {
+		logger.Info("Branch locked by another task", "branch", taskBranch(task), "lockedBy", holder.Name)
+		r.setQueuedPhase(ctx, task, fmt.Sprintf("Waiting for branch %q (locked by %s)", taskBranch(task), holder.Name), position, holder.Name)
+		return true, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	if len(ahead) > 0 {
+		logger.Info("Branch queued behind earlier task", "branch", taskBranch(task), "queuedBehind", ahead[0].Name)
+		r.setQueuedPhase(ctx, task, fmt.Sprintf("Waiting for branch %q (queued behind %s)", taskBranch(task), ahead[0].Name), position, "")
+		return true, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	return false, ctrl.Result{}, nil
+}
+
+// branchQueue returns the Running or Pending task that holds the branch of
+// the task, if any, and the tasks queued for the same workspace and branch
+// that are ahead of it in the queue, first in line first. Tasks that wait for
+// anything else, such as their dependencies or a retry backoff, are not in
+// the queue.
+func (r *TaskReconciler) branchQueue(ctx context.Context, task *kelos.Task) (*kelos.Task, []*kelos.Task, error) {
 	key := branchLockKey(task)
 
 	var taskList kelos.TaskList
 	if err := r.List(ctx, &taskList, client.InNamespace(task.Namespace)); err != nil {
-		return false, ctrl.Result{}, err
+		return nil, nil, err
 	}
 
-	for _, t := range taskList.Items {
+	var holder *kelos.Task
+	var waiting []*kelos.Task
+	for i := range taskList.Items {
+		t := &taskList.Items[i]
 		if t.Name == task.Name {
 			continue
 		}
-		if t.Spec.Branch == "" || branchLockKey(&t) != key {
+		if taskBranch(t) == "" || branchLockKey(t) != key {
 			continue
 		}
 		switch t.Status.Phase {
 		case kelos.TaskPhaseRunning, kelos.TaskPhasePending:
-			logger.Info("Branch locked by another task", "branch", task.Spec.Branch, "lockedBy", t.Name)
-			r.setWaitingPhase(ctx, task, fmt.Sprintf("Waiting for branch %q (locked by %s)", task.Spec.Branch, t.Name))
-			return true, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			holder = t
 		case kelos.TaskPhaseWaiting:
-			if t.CreationTimestamp.Before(&task.CreationTimestamp) {
-				logger.Info("Branch queued behind earlier task", "branch", task.Spec.Branch, "queuedBehind", t.Name)
-				r.setWaitingPhase(ctx, task, fmt.Sprintf("Waiting for branch %q (queued behind %s)", task.Spec.Branch, t.Name))
-				return true, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			if queuedForBranch(t) {
+				waiting = append(waiting, t)
 			}
 		}
 	}
 
-	return false, ctrl.Result{}, nil
+	// A branch is held by one task at a time. Among waiting Tasks of equal
+	// priority, those of other TaskSpawners go before the holder's.
+	var running []*kelos.Task
+	if holder != nil {
+		running = append(running, holder)
+	}
+	queue := newTaskQueue(running)
+	var ahead []*kelos.Task
+	for _, t := range waiting {
+		if queue.before(t, task) {
+			ahead = append(ahead, t)
+		}
+	}
+	sort.SliceStable(ahead, func(i, j int) bool { return queue.before(ahead[i], ahead[j]) })
+	return holder, ahead, nil
+}
+
+// queuedForBranch reports whether a Waiting task with a branch waits in the
+// queue for its branch lock. setQueuedPhase records a queue position for
+// such tasks, while a TaskBudget that holds a task back marks it with a
+// blocked condition.
+func queuedForBranch(task *kelos.Task) bool {
+	return task.Status.QueuePosition != nil && !taskAdmissionBlocked(task)
+}
+
+// branchQueuePosition returns the 1-based position of the task in the queue
+// for its branch.
+func (r *TaskReconciler) branchQueuePosition(ctx context.Context, task *kelos.Task) (int32, error) {
+	_, ahead, err := r.branchQueue(ctx, task)
+	if err != nil {
+		return 0, err
+	}
+	return int32(len(ahead) + 1), nil
 }
 
 // setWaitingPhase updates the task phase to Waiting with the given message.
 func (r *TaskReconciler) setWaitingPhase(ctx context.Context, task *kelos.Task, message string) {
+	r.setQueuedPhase(ctx, task, message, 0, "")
+}
+
+// setQueuedPhase updates the task phase to Waiting with the given message,
+// queue position, where 0 means the task is not queued, and the holder of
+// the branch lock the task waits for, if known.
+func (r *TaskReconciler) setQueuedPhase(ctx context.Context, task *kelos.Task, message string, position int32, lockHolder string) {
 	logger := log.FromContext(ctx)
 	updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
 			return getErr
 		}
-		if task.Status.Phase == kelos.TaskPhaseWaiting && task.Status.Message == message {
+		if task.Status.Phase == kelos.TaskPhaseWaiting && task.Status.Message == message &&
+			queuePositionEqual(task.Status.QueuePosition, position) && task.Status.BranchLockHolder == lockHolder {
 			return nil
 		}
 		task.Status.Phase = kelos.TaskPhaseWaiting
 		task.Status.Message = message
+		task.Status.QueuePosition = queuePosition(position)
+		task.Status.BranchLockHolder = lockHolder
 		return r.Status().Update(ctx, task)
 	})
 	if updateErr != nil {
@@ -1355,8 +1766,9 @@ func usageFromResults(results map[string]string) *kelos.TaskUsage {
 
 const (
 	// defaultTaskRecordTTL is the default retention period for TaskRecords
-	// (30 days). Records older than this are garbage-collected.
-	defaultTaskRecordTTL int32 = 30 * 24 * 60 * 60
+	// (32 days), long enough to cover the longest Monthly or Rolling budget
+	// period. Records older than this are garbage-collected.
+	defaultTaskRecordTTL int32 = 32 * 24 * 60 * 60
 )
 
 // now returns the current time, using NowFunc if set for testability.
@@ -1367,9 +1779,10 @@ func (r *TaskReconciler) now() time.Time {
 	return time.Now()
 }
 
-// budget returns a budgetEnforcer bound to this reconciler's client and clock.
+// budget returns a budgetEnforcer bound to this reconciler's client, clock and
+// event recorder.
 func (r *TaskReconciler) budget() *budgetEnforcer {
-	return &budgetEnforcer{Client: r.Client, now: r.now}
+	return &budgetEnforcer{Client: r.Client, now: r.now, recorder: r.Recorder}
 }
 
 // checkBudgetAdmission checks all matching TaskBudgets before job creation.
@@ -1412,8 +1825,9 @@ func (r *TaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 // enqueueTasksBlockedByBudget returns reconcile requests for Tasks in Waiting
-// phase that have a BudgetBlocked condition. This allows budget-blocked tasks
-// to be re-evaluated when a TaskBudget changes (e.g. period rolls over).
+// phase that have a BudgetBlocked or QuotaBlocked condition. This allows
+// budget-blocked tasks to be re-evaluated when a TaskBudget changes (e.g.
+// period rolls over or maxRunning is raised).
 func (r *TaskReconciler) enqueueTasksBlockedByBudget(ctx context.Context, obj client.Object) []reconcile.Request {
 	budget, ok := obj.(*kelos.TaskBudget)
 	if !ok {
@@ -1431,7 +1845,7 @@ func (r *TaskReconciler) enqueueTasksBlockedByBudget(ctx context.Context, obj cl
 			continue
 		}
 		for _, c := range t.Status.Conditions {
-			if c.Type == "BudgetBlocked" && c.Status == metav1.ConditionTrue {
+			if (c.Type == "BudgetBlocked" || c.Type == kelos.TaskConditionQuotaBlocked) && c.Status == metav1.ConditionTrue {
 				requests = append(requests, reconcile.Request{
 					NamespacedName: client.ObjectKeyFromObject(&t),
 				})
@@ -1468,9 +1882,9 @@ func (r *TaskReconciler) enqueueTasksForWorkspace(ctx context.Context, obj clien
 }
 
 // enqueueDependentTasks returns reconcile requests for tasks that depend on the
-// given task or are waiting for the same branch. This ensures dependent and
-// branch-queued tasks are reconciled immediately when a task reaches a terminal
-// phase, instead of waiting for a requeue timer.
+// given task, are waiting for the same branch, or are waiting for a maxRunning
+// quota. This ensures dependent and queued tasks are reconciled immediately
+// when a task reaches a terminal phase, instead of waiting for a requeue timer.
 func (r *TaskReconciler) enqueueDependentTasks(ctx context.Context, obj client.Object) []reconcile.Request {
 	task, ok := obj.(*kelos.Task)
 	if !ok {
@@ -1504,7 +1918,7 @@ func (r *TaskReconciler) enqueueDependentTasks(ctx context.Context, obj client.O
 			}
 		}
 		// Re-enqueue tasks waiting for the same workspace+branch
-		if !seen[t.Name] && task.Spec.Branch != "" && t.Spec.Branch != "" &&
+		if !seen[t.Name] && taskBranch(task) != "" && taskBranch(&t) != "" &&
 			branchLockKey(&t) == branchLockKey(task) &&
 			t.Status.Phase == kelos.TaskPhaseWaiting {
 			seen[t.Name] = true
@@ -1512,6 +1926,13 @@ func (r *TaskReconciler) enqueueDependentTasks(ctx context.Context, obj client.O
 				NamespacedName: client.ObjectKeyFromObject(&t),
 			})
 		}
+		// Re-enqueue tasks waiting for a maxRunning quota slot
+		if !seen[t.Name] && t.Spec.WorkerPoolRef == nil && taskQuotaBlocked(&t) {
+			seen[t.Name] = true
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&t),
+			})
+		}
 	}
 	return requests
 }
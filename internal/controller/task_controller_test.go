@@ -18,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -863,6 +864,38 @@ func newReconcilerWithFakeClient(objs ...runtime.Object) *TaskReconciler {
 	}
 }
 
+// newTestTask returns a claude-code Task in the default namespace that uses
+// the creds API key and workspace-1. A Running Task has a Job named after it
+// that started a minute ago.
+func newTestTask(name string, phase kelos.TaskPhase) *kelos.Task {
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name + "-uid"),
+		},
+		Spec: kelos.TaskSpec{
+			Type:   "claude-code",
+			Prompt: "test",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "creds"},
+			},
+			WorkspaceRef: &kelos.WorkspaceReference{
+				Name: "workspace-1",
+			},
+		},
+		Status: kelos.TaskStatus{Phase: phase},
+	}
+	if phase == kelos.TaskPhaseRunning {
+		startTime := metav1.NewTime(time.Now().Add(-time.Minute))
+		task.Status.JobName = name
+		task.Status.StartTime = &startTime
+		task.Status.Attempt = 1
+	}
+	return task
+}
+
 func TestValidateSkillsAuthSecrets(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -963,11 +996,6 @@ func TestFailTaskBeforeJobReleasesBranchLock(t *testing.T) {
 			},
 		},
 	}
-	locker := NewBranchLocker()
-	lockKey := branchLockKey(task)
-	if ok, holder := locker.TryAcquire(lockKey, task.Name); !ok {
-		t.Fatalf("TryAcquire() failed, held by %q", holder)
-	}
 
 	cl := fake.NewClientBuilder().
 		WithScheme(scheme).
@@ -975,6 +1003,11 @@ func TestFailTaskBeforeJobReleasesBranchLock(t *testing.T) {
 		WithObjects(task).
 		Build()
 
+	locker := NewBranchLocker()
+	if ok, holder, err := locker.TryAcquire(context.Background(), cl, task); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %q, %v; want acquired", ok, holder, err)
+	}
+
 	r := &TaskReconciler{
 		Client:       cl,
 		Scheme:       scheme,
@@ -985,8 +1018,8 @@ func TestFailTaskBeforeJobReleasesBranchLock(t *testing.T) {
 		t.Fatalf("failTaskBeforeJob() error = %v", err)
 	}
 
-	if holder := locker.Holder(lockKey); holder != "" {
-		t.Fatalf("branch lock holder = %q, want released", holder)
+	if holder, err := locker.Holder(context.Background(), cl, task); err != nil || holder != "" {
+		t.Fatalf("branch lock holder = %q, %v; want released", holder, err)
 	}
 	updated := &kelos.Task{}
 	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
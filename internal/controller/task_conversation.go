@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// conversationVolumeName is the volume of the agent container that
+	// holds the conversation state of the agent.
+	conversationVolumeName = "kelos-conversation"
+
+	// conversationMountPath is where the conversation volume is mounted.
+	conversationMountPath = "/kelos-conversation"
+
+	// defaultConversationSize is the storage requested for a conversation
+	// claim without an explicit size.
+	defaultConversationSize = "1Gi"
+)
+
+// conversationClaimName returns the name of the PersistentVolumeClaim that
+// persists the conversation of the given Task.
+func conversationClaimName(taskName string) string {
+	return taskName + "-conversation"
+}
+
+// conversationStateEnv returns the environment variable that points the
+// agent's provider state directory at the conversation volume, mirroring
+// the provider state a Session keeps on its workspace volume. It reports
+// false for agent types whose conversation cannot be continued.
+func conversationStateEnv(agentType string) (corev1.EnvVar, bool) {
+	switch agentType {
+	case "claude-code":
+		return corev1.EnvVar{Name: "CLAUDE_CONFIG_DIR", Value: conversationMountPath + "/claude-config"}, true
+	case "codex":
+		return corev1.EnvVar{Name: "CODEX_HOME", Value: conversationMountPath + "/codex-home"}, true
+	case "opencode":
+		return corev1.EnvVar{Name: "XDG_DATA_HOME", Value: conversationMountPath + "/opencode-data"}, true
+	}
+	return corev1.EnvVar{}, false
+}
+
+// validateTaskConversation rejects a Task that persists or continues a
+// conversation with an agent type that does not support it.
+func validateTaskConversation(task *kelos.Task) error {
+	if task.Spec.Conversation == nil && task.Spec.ContinueFrom == "" {
+		return nil
+	}
+	if _, ok := conversationStateEnv(resolveTaskType(task)); !ok {
+		return fmt.Errorf("agent type %q does not support persisting or continuing a conversation", resolveTaskType(task))
+	}
+	if task.Spec.ContinueFrom == task.Name {
+		return fmt.Errorf("task cannot continue its own conversation")
+	}
+	return nil
+}
+
+// checkContinuation waits for the Task named by continueFrom to finish and
+// checks that its conversation can be continued. A Task without a branch
+// inherits the branch of the continued Task in status.inheritedBranch, so
+// that the follow-up works on the same changes. It returns true once the
+// Task may start.
+func (r *TaskReconciler) checkContinuation(ctx context.Context, task *kelos.Task) (bool, ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var source kelos.Task
+	if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: task.Spec.ContinueFrom}, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("Continued Task not found yet, waiting", "continueFrom", task.Spec.ContinueFrom)
+			r.setWaitingPhase(ctx, task, fmt.Sprintf("Waiting for Task %q to be created", task.Spec.ContinueFrom))
+			return false, ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		return false, ctrl.Result{}, err
+	}
+
+	if !isTerminalTaskPhase(source.Status.Phase) {
+		logger.Info("Continued Task not finished", "continueFrom", source.Name, "phase", source.Status.Phase)
+		r.setWaitingPhase(ctx, task, fmt.Sprintf("Waiting for Task %q to finish", source.Name))
+		return false, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	message := ""
+	sourceBranch := continuedBranch(&source)
+	switch {
+	case resolveTaskType(&source) != resolveTaskType(task):
+		message = fmt.Sprintf("Task %q uses agent type %q, not %q", source.Name, resolveTaskType(&source), resolveTaskType(task))
+	case source.Status.ConversationClaimName == "":
+		message = fmt.Sprintf("Task %q did not persist its conversation", source.Name)
+	case task.Spec.Branch != "" && sourceBranch != "" && task.Spec.Branch != sourceBranch:
+		message = fmt.Sprintf("Task %q ran on branch %q, not %q", source.Name, sourceBranch, task.Spec.Branch)
+	}
+	if message == "" {
+		var claim corev1.PersistentVolumeClaim
+		err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: source.Status.ConversationClaimName}, &claim)
+		if apierrors.IsNotFound(err) {
+			message = fmt.Sprintf("Conversation of Task %q no longer exists", source.Name)
+		} else if err != nil {
+			return false, ctrl.Result{}, err
+		}
+	}
+	if message != "" {
+		logger.Info("Unable to continue Task", "continueFrom", source.Name, "reason", message)
+		r.recordEvent(task, corev1.EventTypeWarning, "ContinuationFailed", "%s", message)
+		if err := r.failTaskBeforeJob(ctx, task, message); err != nil {
+			logger.Error(err, "Unable to update Task status")
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	if task.Spec.Branch == "" && task.Status.InheritedBranch != sourceBranch {
+		// The spec of a Task is immutable, so the inherited branch is
+		// recorded in its status.
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(task), task); err != nil {
+				return err
+			}
+			task.Status.InheritedBranch = sourceBranch
+			return r.Status().Update(ctx, task)
+		}); err != nil {
+			return false, ctrl.Result{}, err
+		}
+		if sourceBranch != "" {
+			r.recordEvent(task, corev1.EventTypeNormal, "BranchInherited", "Continuing on branch %q of Task %s", sourceBranch, source.Name)
+		}
+	}
+	return true, ctrl.Result{}, nil
+}
+
+// continuedBranch returns the branch a continued Task worked on: the
+// branch it ran on, or the branch the agent reported in its results.
+func continuedBranch(task *kelos.Task) string {
+	if branch := taskBranch(task); branch != "" {
+		return branch
+	}
+	return task.Status.Results["branch"]
+}
+
+// taskBranch returns the branch a Task works on: its spec.branch, or the
+// branch it inherited from the Task it continues.
+func taskBranch(task *kelos.Task) string {
+	if task.Spec.Branch != "" {
+		return task.Spec.Branch
+	}
+	return task.Status.InheritedBranch
+}
+
+// ensureConversationClaim returns the PersistentVolumeClaim that holds the
+// conversation of the Task, or "" when it has none. A Task that persists
+// its conversation gets a claim it controls. A continuation keeps using
+// the claim of the continued Task and is added to its owners, so the
+// conversation lives as long as any Task that took part in it.
+func (r *TaskReconciler) ensureConversationClaim(ctx context.Context, task *kelos.Task) (string, error) {
+	if task.Spec.ContinueFrom != "" {
+		// A retried attempt keeps the claim of its first attempt, even if
+		// the continued Task is gone by now.
+		claimName := task.Status.ConversationClaimName
+		if claimName == "" {
+			var source kelos.Task
+			if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: task.Spec.ContinueFrom}, &source); err != nil {
+				return "", fmt.Errorf("getting continued Task %q: %w", task.Spec.ContinueFrom, err)
+			}
+			claimName = source.Status.ConversationClaimName
+		}
+		var claim corev1.PersistentVolumeClaim
+		if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: claimName}, &claim); err != nil {
+			return "", fmt.Errorf("getting conversation PersistentVolumeClaim %q: %w", claimName, err)
+		}
+		if !hasOwnerReference(&claim, task) {
+			if err := controllerutil.SetOwnerReference(task, &claim, r.Scheme); err != nil {
+				return "", fmt.Errorf("setting Task owner on conversation PersistentVolumeClaim %q: %w", claim.Name, err)
+			}
+			if err := r.Update(ctx, &claim); err != nil {
+				return "", fmt.Errorf("updating conversation PersistentVolumeClaim %q: %w", claim.Name, err)
+			}
+		}
+		return claim.Name, nil
+	}
+
+	if task.Spec.Conversation == nil {
+		return "", nil
+	}
+	size := resource.MustParse(defaultConversationSize)
+	if task.Spec.Conversation.Size != nil {
+		size = *task.Spec.Conversation.Size
+	}
+	desired := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      conversationClaimName(task.Name),
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task": task.Name,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: task.Spec.Conversation.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if _, err := ensureTaskOwnedObject(ctx, r.Client, r.Scheme, task, "conversation PersistentVolumeClaim", desired, &corev1.PersistentVolumeClaim{}); err != nil {
+		return "", err
+	}
+	return desired.Name, nil
+}
+
+func hasOwnerReference(obj metav1.Object, owner metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// configureConversation mounts the conversation claim into the agent
+// container and points the agent's provider state at it. KELOS_CONVERSATION
+// tells the agent image that its provider state persists across Tasks, and
+// a continuation also sets KELOS_CONTINUE, which tells the agent image to
+// resume the most recent conversation with the prompt as the follow-up.
+func configureConversation(task *kelos.Task, job *batchv1.Job, claimName string) {
+	env, ok := conversationStateEnv(resolveTaskType(task))
+	if !ok {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: conversationVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	})
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != kelos.AgentContainerName {
+			continue
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      conversationVolumeName,
+			MountPath: conversationMountPath,
+		})
+		container.Env = append(container.Env, env, corev1.EnvVar{Name: "KELOS_CONVERSATION", Value: "1"})
+		if task.Spec.ContinueFrom != "" {
+			container.Env = append(container.Env, corev1.EnvVar{Name: "KELOS_CONTINUE", Value: "1"})
+		}
+	}
+}
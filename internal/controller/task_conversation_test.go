@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// newContinuationTestObjects returns a finished Task that persisted its
+// conversation on feature-1, its claim, and a Task continuing it.
+func newContinuationTestObjects(t *testing.T) (*kelos.Task, *corev1.PersistentVolumeClaim, *kelos.Task) {
+	t.Helper()
+	source := newTestTask("task-1", "")
+	source.Spec.Conversation = &kelos.TaskConversation{}
+	source.Status.Phase = kelos.TaskPhaseSucceeded
+	source.Status.ConversationClaimName = "task-1-conversation"
+	source.Status.Results = map[string]string{"branch": "feature-1"}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-1-conversation", Namespace: "default"},
+	}
+	if err := controllerutil.SetControllerReference(source, claim, newTestScheme()); err != nil {
+		t.Fatalf("Setting owner reference: %v", err)
+	}
+
+	task := newTestTask("task-2", "")
+	task.Spec.ContinueFrom = "task-1"
+	return source, claim, task
+}
+
+func TestValidateTaskConversation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*kelos.Task)
+		wantErr bool
+	}{
+		{name: "no conversation", mutate: func(*kelos.Task) {}},
+		{name: "persisted conversation", mutate: func(task *kelos.Task) {
+			task.Spec.Conversation = &kelos.TaskConversation{}
+		}},
+		{name: "continuation", mutate: func(task *kelos.Task) {
+			task.Spec.ContinueFrom = "task-0"
+		}},
+		{name: "unsupported agent type", mutate: func(task *kelos.Task) {
+			task.Spec.Type = "gemini"
+			task.Spec.Conversation = &kelos.TaskConversation{}
+		}, wantErr: true},
+		{name: "continues itself", mutate: func(task *kelos.Task) {
+			task.Spec.ContinueFrom = task.Name
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask("task-1", "")
+			tt.mutate(task)
+			err := validateTaskConversation(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateTaskConversation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigureConversation(t *testing.T) {
+	tests := []struct {
+		name         string
+		agentType    string
+		continueFrom string
+		wantEnv      []corev1.EnvVar
+	}{
+		{
+			name:      "claude-code",
+			agentType: "claude-code",
+			wantEnv: []corev1.EnvVar{
+				{Name: "CLAUDE_CONFIG_DIR", Value: "/kelos-conversation/claude-config"},
+				{Name: "KELOS_CONVERSATION", Value: "1"},
+			},
+		},
+		{
+			name:         "codex continuation",
+			agentType:    "codex",
+			continueFrom: "task-0",
+			wantEnv: []corev1.EnvVar{
+				{Name: "CODEX_HOME", Value: "/kelos-conversation/codex-home"},
+				{Name: "KELOS_CONVERSATION", Value: "1"},
+				{Name: "KELOS_CONTINUE", Value: "1"},
+			},
+		},
+		{
+			name:      "opencode",
+			agentType: "opencode",
+			wantEnv: []corev1.EnvVar{
+				{Name: "XDG_DATA_HOME", Value: "/kelos-conversation/opencode-data"},
+				{Name: "KELOS_CONVERSATION", Value: "1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask("task-1", "")
+			task.Spec.Type = tt.agentType
+			task.Spec.ContinueFrom = tt.continueFrom
+			job := &batchv1.Job{}
+			job.Spec.Template.Spec.Containers = []corev1.Container{{Name: kelos.AgentContainerName}}
+
+			configureConversation(task, job, "task-0-conversation")
+
+			podSpec := job.Spec.Template.Spec
+			wantVolumes := []corev1.Volume{{
+				Name: conversationVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "task-0-conversation"},
+				},
+			}}
+			if !reflect.DeepEqual(podSpec.Volumes, wantVolumes) {
+				t.Errorf("volumes = %+v, want %+v", podSpec.Volumes, wantVolumes)
+			}
+			wantMounts := []corev1.VolumeMount{{Name: conversationVolumeName, MountPath: conversationMountPath}}
+			if !reflect.DeepEqual(podSpec.Containers[0].VolumeMounts, wantMounts) {
+				t.Errorf("volume mounts = %+v, want %+v", podSpec.Containers[0].VolumeMounts, wantMounts)
+			}
+			if !reflect.DeepEqual(podSpec.Containers[0].Env, tt.wantEnv) {
+				t.Errorf("env = %+v, want %+v", podSpec.Containers[0].Env, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestEnsureConversationClaimCreatesClaim(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", "")
+	storageClass := "fast"
+	task.Spec.Conversation = &kelos.TaskConversation{StorageClassName: &storageClass}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+	claimName, err := r.ensureConversationClaim(context.Background(), task)
+	if err != nil {
+		t.Fatalf("ensureConversationClaim() error: %v", err)
+	}
+	if claimName != "task-1-conversation" {
+		t.Fatalf("claim = %q, want task-1-conversation", claimName)
+	}
+
+	var claim corev1.PersistentVolumeClaim
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: claimName}, &claim); err != nil {
+		t.Fatalf("Getting claim: %v", err)
+	}
+	if !metav1.IsControlledBy(&claim, task) {
+		t.Error("Expected the claim to be controlled by the Task")
+	}
+	if got := claim.Spec.Resources.Requests[corev1.ResourceStorage]; got.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("storage request = %s, want 1Gi", got.String())
+	}
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName != "fast" {
+		t.Errorf("storageClassName = %v, want fast", claim.Spec.StorageClassName)
+	}
+}
+
+func TestEnsureConversationClaimAddsContinuationOwner(t *testing.T) {
+	scheme := newTestScheme()
+	source, claim, task := newContinuationTestObjects(t)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source, claim, task).Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+	claimName, err := r.ensureConversationClaim(context.Background(), task)
+	if err != nil {
+		t.Fatalf("ensureConversationClaim() error: %v", err)
+	}
+	if claimName != "task-1-conversation" {
+		t.Fatalf("claim = %q, want the claim of the continued Task", claimName)
+	}
+
+	var updated corev1.PersistentVolumeClaim
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(claim), &updated); err != nil {
+		t.Fatalf("Getting claim: %v", err)
+	}
+	if !metav1.IsControlledBy(&updated, source) {
+		t.Error("Expected the continued Task to stay the controller of the claim")
+	}
+	if !hasOwnerReference(&updated, task) {
+		t.Errorf("owner references = %+v, want the continuation among them", updated.OwnerReferences)
+	}
+}
+
+func TestCheckContinuationWaitsForContinuedTask(t *testing.T) {
+	scheme := newTestScheme()
+	source, claim, task := newContinuationTestObjects(t)
+	source.Status.Phase = kelos.TaskPhaseRunning
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(source, claim, task).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+	ready, result, err := r.checkContinuation(context.Background(), task)
+	if err != nil {
+		t.Fatalf("checkContinuation() error: %v", err)
+	}
+	if ready || result.RequeueAfter == 0 {
+		t.Errorf("checkContinuation() = %v, %+v, want a requeue while the continued Task runs", ready, result)
+	}
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting Task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseWaiting)
+	}
+}
+
+func TestCheckContinuationInheritsBranch(t *testing.T) {
+	scheme := newTestScheme()
+	source, claim, task := newContinuationTestObjects(t)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(source, claim, task).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &TaskReconciler{Client: cl, Scheme: scheme, Recorder: recorder}
+
+	ready, _, err := r.checkContinuation(context.Background(), task)
+	if err != nil {
+		t.Fatalf("checkContinuation() error: %v", err)
+	}
+	if !ready {
+		t.Error("Expected the Task to be ready once it inherited the branch of the continued Task")
+	}
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting Task: %v", err)
+	}
+	if updated.Spec.Branch != "" {
+		t.Errorf("spec.branch = %q, want the immutable spec left alone", updated.Spec.Branch)
+	}
+	if updated.Status.InheritedBranch != "feature-1" {
+		t.Errorf("inheritedBranch = %q, want the branch reported by the continued Task", updated.Status.InheritedBranch)
+	}
+	if got := branchLockKey(&updated); got != "workspace-1:feature-1" {
+		t.Errorf("branchLockKey() = %q, want the inherited branch locked", got)
+	}
+	select {
+	case event := <-recorder.Events:
+		if event != "Normal BranchInherited Continuing on branch \"feature-1\" of Task task-1" {
+			t.Errorf("event = %q", event)
+		}
+	default:
+		t.Error("Expected a BranchInherited event")
+	}
+}
+
+func TestCheckContinuationFails(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(source *kelos.Task, task *kelos.Task)
+		dropClaim   bool
+		wantMessage string
+	}{
+		{
+			name: "conversation not persisted",
+			mutate: func(source *kelos.Task, _ *kelos.Task) {
+				source.Spec.Conversation = nil
+				source.Status.ConversationClaimName = ""
+			},
+			wantMessage: `Task "task-1" did not persist its conversation`,
+		},
+		{
+			name: "different agent type",
+			mutate: func(_ *kelos.Task, task *kelos.Task) {
+				task.Spec.Type = "codex"
+			},
+			wantMessage: `Task "task-1" uses agent type "claude-code", not "codex"`,
+		},
+		{
+			name: "different branch",
+			mutate: func(_ *kelos.Task, task *kelos.Task) {
+				task.Spec.Branch = "feature-2"
+			},
+			wantMessage: `Task "task-1" ran on branch "feature-1", not "feature-2"`,
+		},
+		{
+			name:        "claim deleted",
+			mutate:      func(*kelos.Task, *kelos.Task) {},
+			dropClaim:   true,
+			wantMessage: `Conversation of Task "task-1" no longer exists`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme()
+			source, claim, task := newContinuationTestObjects(t)
+			tt.mutate(source, task)
+			objs := []client.Object{source, task}
+			if !tt.dropClaim {
+				objs = append(objs, claim)
+			}
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(task).
+				WithObjects(objs...).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &TaskReconciler{Client: cl, Scheme: scheme, Recorder: recorder}
+
+			ready, _, err := r.checkContinuation(context.Background(), task)
+			if err != nil {
+				t.Fatalf("checkContinuation() error: %v", err)
+			}
+			if ready {
+				t.Fatal("Expected the continuation to be rejected")
+			}
+
+			var updated kelos.Task
+			if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+				t.Fatalf("Getting Task: %v", err)
+			}
+			if updated.Status.Phase != kelos.TaskPhaseFailed {
+				t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+			}
+			if updated.Status.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", updated.Status.Message, tt.wantMessage)
+			}
+			select {
+			case event := <-recorder.Events:
+				if event != "Warning ContinuationFailed "+tt.wantMessage {
+					t.Errorf("event = %q", event)
+				}
+			default:
+				t.Error("Expected a ContinuationFailed event")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// taskPriority returns the queue priority of the Task. Defaults to 0.
+func taskPriority(task *kelos.Task) int32 {
+	if task.Spec.Priority == nil {
+		return 0
+	}
+	return *task.Spec.Priority
+}
+
+// taskSpawnerName returns the name of the TaskSpawner that created the Task,
+// or an empty string for Tasks created directly.
+func taskSpawnerName(task *kelos.Task) string {
+	return task.Labels["kelos.dev/taskspawner"]
+}
+
+// taskQueue orders Tasks waiting for the same branch or maxRunning quota.
+// Tasks with a higher priority are released first. Among Tasks of equal
+// priority, Tasks of the TaskSpawner with the fewest running Tasks go first,
+// so a single busy spawner cannot starve the others. Remaining ties are
+// released in creation order, with the name as the final tie-breaker.
+type taskQueue struct {
+	// running counts the running Tasks per TaskSpawner name that hold the
+	// resource the queue is for. Tasks created directly share the empty
+	// name.
+	running map[string]int
+}
+
+// newTaskQueue returns a queue whose fair sharing accounts for the given
+// running Tasks.
+func newTaskQueue(running []*kelos.Task) *taskQueue {
+	q := &taskQueue{running: make(map[string]int, len(running))}
+	for _, t := range running {
+		q.running[taskSpawnerName(t)]++
+	}
+	return q
+}
+
+// before reports whether Task a is released before Task b.
+func (q *taskQueue) before(a, b *kelos.Task) bool {
+	if pa, pb := taskPriority(a), taskPriority(b); pa != pb {
+		return pa > pb
+	}
+	if ra, rb := q.running[taskSpawnerName(a)], q.running[taskSpawnerName(b)]; ra != rb {
+		return ra < rb
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// queuePosition returns the queue position to record in the Task status, or
+// nil when the Task is not queued (position 0).
+func queuePosition(position int32) *int32 {
+	if position <= 0 {
+		return nil
+	}
+	return &position
+}
+
+// queuePositionEqual reports whether the recorded queue position matches
+// position, where 0 means the Task is not queued.
+func queuePositionEqual(recorded *int32, position int32) bool {
+	if recorded == nil {
+		return position <= 0
+	}
+	return *recorded == position
+}
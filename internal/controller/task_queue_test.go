@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestTaskQueueBefore(t *testing.T) {
+	base := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	task := func(name, spawner string, created time.Time, priority int32) *kelos.Task {
+		tk := &kelos.Task{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+		if spawner != "" {
+			tk.Labels = map[string]string{"kelos.dev/taskspawner": spawner}
+		}
+		if priority != 0 {
+			tk.Spec.Priority = &priority
+		}
+		return tk
+	}
+	queue := newTaskQueue([]*kelos.Task{
+		task("busy-1", "busy", base, 0),
+		task("busy-2", "busy", base, 0),
+		task("quiet-1", "quiet", base, 0),
+	})
+
+	tests := []struct {
+		name string
+		a, b *kelos.Task
+		want bool
+	}{
+		{
+			name: "higher priority first",
+			a:    task("a", "busy", base.Add(time.Hour), 5),
+			b:    task("b", "quiet", base, 0),
+			want: true,
+		},
+		{
+			name: "negative priority last",
+			a:    task("a", "", base, -5),
+			b:    task("b", "", base.Add(time.Hour), 0),
+			want: false,
+		},
+		{
+			name: "spawner with fewer running tasks first",
+			a:    task("a", "quiet", base.Add(time.Hour), 0),
+			b:    task("b", "busy", base, 0),
+			want: true,
+		},
+		{
+			name: "direct tasks have no running tasks",
+			a:    task("a", "", base.Add(time.Hour), 0),
+			b:    task("b", "quiet", base, 0),
+			want: true,
+		},
+		{
+			name: "earlier creation first",
+			a:    task("a", "busy", base.Add(time.Minute), 0),
+			b:    task("b", "busy", base, 0),
+			want: false,
+		},
+		{
+			name: "name breaks ties",
+			a:    task("a", "busy", base, 0),
+			b:    task("b", "busy", base, 0),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queue.before(tt.a, tt.b); got != tt.want {
+				t.Errorf("before(%s, %s) = %v, want %v", tt.a.Name, tt.b.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBranchLock_PriorityAndQueuePosition(t *testing.T) {
+	scheme := newTestScheme()
+	base := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	branchTask := func(name string, created time.Time, phase kelos.TaskPhase, priority int32) *kelos.Task {
+		return &kelos.Task{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: kelos.TaskSpec{
+				Branch:       "feature",
+				WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+				Priority:     &priority,
+			},
+			Status: kelos.TaskStatus{Phase: phase},
+		}
+	}
+	running := branchTask("running", base, kelos.TaskPhaseRunning, 0)
+	early := branchTask("early", base.Add(time.Minute), kelos.TaskPhaseWaiting, 0)
+	urgent := branchTask("urgent", base.Add(2*time.Minute), kelos.TaskPhaseWaiting, 10)
+	late := branchTask("late", base.Add(3*time.Minute), kelos.TaskPhaseWaiting, 0)
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Task{}).
+		WithObjects(running, early, urgent, late).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		task         *kelos.Task
+		wantMessage  string
+		wantPosition int32
+	}{
+		{urgent, `Waiting for branch "feature" (locked by running)`, 1},
+		{early, `Waiting for branch "feature" (locked by running)`, 2},
+		{late, `Waiting for branch "feature" (locked by running)`, 3},
+	} {
+		locked, _, err := r.checkBranchLock(ctx, tc.task)
+		if err != nil {
+			t.Fatalf("checkBranchLock(%s) error = %v", tc.task.Name, err)
+		}
+		if !locked {
+			t.Fatalf("checkBranchLock(%s) locked = false, want true", tc.task.Name)
+		}
+		var got kelos.Task
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(tc.task), &got); err != nil {
+			t.Fatalf("getting Task: %v", err)
+		}
+		if got.Status.Message != tc.wantMessage {
+			t.Errorf("%s message = %q, want %q", tc.task.Name, got.Status.Message, tc.wantMessage)
+		}
+		if got.Status.QueuePosition == nil || *got.Status.QueuePosition != tc.wantPosition {
+			t.Errorf("%s queuePosition = %v, want %d", tc.task.Name, got.Status.QueuePosition, tc.wantPosition)
+		}
+	}
+
+	// Once the branch is released, the higher priority task goes first even
+	// though it was created later.
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(running), running); err != nil {
+		t.Fatalf("getting Task: %v", err)
+	}
+	running.Status.Phase = kelos.TaskPhaseSucceeded
+	if err := cl.Status().Update(ctx, running); err != nil {
+		t.Fatalf("updating Task status: %v", err)
+	}
+	locked, _, err := r.checkBranchLock(ctx, early)
+	if err != nil {
+		t.Fatalf("checkBranchLock(early) error = %v", err)
+	}
+	if !locked {
+		t.Fatal("checkBranchLock(early) locked = false, want true")
+	}
+	if want := `Waiting for branch "feature" (queued behind urgent)`; early.Status.Message != want {
+		t.Errorf("early message = %q, want %q", early.Status.Message, want)
+	}
+	locked, _, err = r.checkBranchLock(ctx, urgent)
+	if err != nil {
+		t.Fatalf("checkBranchLock(urgent) error = %v", err)
+	}
+	if locked {
+		t.Fatal("checkBranchLock(urgent) locked = true, want false")
+	}
+}
+
+func TestCheckBranchLock_IgnoresTasksWaitingForDependencies(t *testing.T) {
+	scheme := newTestScheme()
+	base := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	// A higher-priority step that depends on a lower-priority one on the
+	// same branch must not hold its own dependency back.
+	plan := newTestTask("plan", kelos.TaskPhaseWaiting)
+	plan.CreationTimestamp = metav1.NewTime(base.Add(time.Minute))
+	plan.Spec.Branch = "feature"
+	build := newTestTask("build", kelos.TaskPhaseWaiting)
+	build.CreationTimestamp = metav1.NewTime(base)
+	build.Spec.Branch = "feature"
+	build.Spec.Priority = int32Ptr(10)
+	build.Spec.DependsOn = []string{"plan"}
+	build.Status.Message = "Waiting for dependency plan"
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Task{}).
+		WithObjects(plan, build).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+	ctx := context.Background()
+
+	locked, _, err := r.checkBranchLock(ctx, plan)
+	if err != nil {
+		t.Fatalf("checkBranchLock(plan) error = %v", err)
+	}
+	if locked {
+		t.Errorf("checkBranchLock(plan) locked = true, want the dependency to go ahead of its dependent; message %q", plan.Status.Message)
+	}
+
+	// Once the dependent waits for the branch itself, it is queued by
+	// priority.
+	build.Spec.DependsOn = nil
+	if locked, _, err := r.checkBranchLock(ctx, build); err != nil || locked {
+		t.Fatalf("checkBranchLock(build) = %v, %v; want unlocked", locked, err)
+	}
+}
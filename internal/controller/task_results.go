@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/resultschema"
+)
+
+// validateTaskResults checks results against the Task's resultsSchema and
+// returns a status message describing the mismatch, or "" when the results
+// are valid or the Task has no schema.
+func validateTaskResults(task *kelos.Task, results map[string]string) string {
+	if task.Spec.ResultsSchema == "" {
+		return ""
+	}
+	schema, err := resultschema.Compile(task.Spec.ResultsSchema)
+	if err != nil {
+		return fmt.Sprintf("Invalid resultsSchema: %v", err)
+	}
+	if err := schema.Validate(results); err != nil {
+		return fmt.Sprintf("Results do not match resultsSchema: %v", err)
+	}
+	return ""
+}
+
+// awaitingTaskResults reports whether the results of a succeeded Job should
+// be validated later because no outputs could be read yet. Pod logs may not
+// be readable right after the Job completes, so validation waits up to
+// outputRetryWindow before judging missing results.
+func awaitingTaskResults(task *kelos.Task, job *batchv1.Job, outputs []string, now time.Time) bool {
+	if task.Spec.ResultsSchema == "" || outputs != nil || job.Status.CompletionTime == nil {
+		return false
+	}
+	return now.Sub(job.Status.CompletionTime.Time) < outputRetryWindow
+}
+
+// setTaskResultsInvalidCondition records that the Task's results did not
+// match its resultsSchema.
+func setTaskResultsInvalidCondition(task *kelos.Task, message string) {
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               kelos.TaskConditionResultsInvalid,
+		Status:             metav1.ConditionTrue,
+		Reason:             kelos.TaskReasonSchemaMismatch,
+		Message:            message,
+		ObservedGeneration: task.Generation,
+	})
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/results"
+)
+
+// configureResultsChannel points kelos-capture in the agent container of a
+// Task's Job at the Task's durable results channel (see package results).
+// A pod without a ServiceAccount override runs as the Task's own results
+// ServiceAccount, so that access to the results ConfigMap is not granted to
+// every pod that runs as the namespace's default ServiceAccount.
+func configureResultsChannel(task *kelos.Task, job *batchv1.Job) {
+	podSpec := &job.Spec.Template.Spec
+	if podSpec.ServiceAccountName == "" {
+		podSpec.ServiceAccountName = results.ConfigMapName(task.UID)
+	}
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != kelos.AgentContainerName {
+			continue
+		}
+		if container.TerminationMessagePath == "" {
+			container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+		}
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: results.EnvTerminationMessagePath, Value: container.TerminationMessagePath},
+			corev1.EnvVar{Name: results.EnvConfigMap, Value: results.ConfigMapName(task.UID)},
+		)
+	}
+}
+
+// ensureTaskResultsAccess creates the results ConfigMap of a Task and a Role
+// that lets serviceAccountName read and update only that ConfigMap. The
+// ServiceAccount itself is created as well when it is the Task's own results
+// ServiceAccount. All objects are owned by the Task, and the ConfigMap is
+// emptied so that outputs of an earlier attempt are not mistaken for the
+// current one.
+func ensureTaskResultsAccess(ctx context.Context, c client.Client, scheme *runtime.Scheme, task *kelos.Task, serviceAccountName string) error {
+	name := results.ConfigMapName(task.UID)
+
+	configMap := &corev1.ConfigMap{}
+	created, err := ensureTaskOwnedObject(ctx, c, scheme, task, "results ConfigMap", &corev1.ConfigMap{ObjectMeta: taskResultsObjectMeta(task)}, configMap)
+	if err != nil {
+		return err
+	}
+	if !created && len(configMap.Data) > 0 {
+		configMap.Data = nil
+		if err := c.Update(ctx, configMap); err != nil {
+			return fmt.Errorf("clearing results ConfigMap %q: %w", name, err)
+		}
+	}
+
+	if serviceAccountName == name {
+		desired := &corev1.ServiceAccount{ObjectMeta: taskResultsObjectMeta(task)}
+		if _, err := ensureTaskOwnedObject(ctx, c, scheme, task, "results ServiceAccount", desired, &corev1.ServiceAccount{}); err != nil {
+			return err
+		}
+	}
+
+	desiredRole := &rbacv1.Role{
+		ObjectMeta: taskResultsObjectMeta(task),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{name},
+			Verbs:         []string{"get", "update"},
+		}},
+	}
+	currentRole := &rbacv1.Role{}
+	created, err = ensureTaskOwnedObject(ctx, c, scheme, task, "results Role", desiredRole, currentRole)
+	if err != nil {
+		return err
+	}
+	if !created && !reflect.DeepEqual(currentRole.Rules, desiredRole.Rules) {
+		currentRole.Rules = desiredRole.Rules
+		if err := c.Update(ctx, currentRole); err != nil {
+			return fmt.Errorf("updating results Role %q: %w", name, err)
+		}
+	}
+
+	desiredBinding := &rbacv1.RoleBinding{
+		ObjectMeta: taskResultsObjectMeta(task),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      serviceAccountName,
+			Namespace: task.Namespace,
+		}},
+	}
+	currentBinding := &rbacv1.RoleBinding{}
+	created, err = ensureTaskOwnedObject(ctx, c, scheme, task, "results RoleBinding", desiredBinding, currentBinding)
+	if err != nil {
+		return err
+	}
+	if !created && !reflect.DeepEqual(currentBinding.Subjects, desiredBinding.Subjects) {
+		currentBinding.Subjects = desiredBinding.Subjects
+		if err := c.Update(ctx, currentBinding); err != nil {
+			return fmt.Errorf("updating results RoleBinding %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ensureTaskOwnedObject creates desired with the Task as its controller,
+// or loads the existing object into current. It reports whether the object
+// was created, and refuses to adopt an object the Task does not control.
+func ensureTaskOwnedObject(ctx context.Context, c client.Client, scheme *runtime.Scheme, task *kelos.Task, kind string, desired, current client.Object) (bool, error) {
+	if err := controllerutil.SetControllerReference(task, desired, scheme); err != nil {
+		return false, fmt.Errorf("setting Task owner on %s: %w", kind, err)
+	}
+	key := client.ObjectKeyFromObject(desired)
+	if err := c.Get(ctx, key, current); apierrors.IsNotFound(err) {
+		if err := c.Create(ctx, desired); err != nil {
+			return false, fmt.Errorf("creating %s %q: %w", kind, desired.GetName(), err)
+		}
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("getting %s %q: %w", kind, desired.GetName(), err)
+	}
+	if !metav1.IsControlledBy(current, task) {
+		return false, fmt.Errorf("%s %q already exists and is not controlled by this Task", kind, current.GetName())
+	}
+	return false, nil
+}
+
+func taskResultsObjectMeta(task *kelos.Task) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      results.ConfigMapName(task.UID),
+		Namespace: task.Namespace,
+		Labels: map[string]string{
+			"kelos.dev/task": task.Name,
+		},
+	}
+}
+
+// readResultsChannel returns the outputs kelos-capture wrote to the durable
+// results channel of a Task: the termination message of the agent container
+// of podName, or the results ConfigMap. It returns nil when neither holds
+// outputs, so that callers fall back to the container logs.
+func readResultsChannel(ctx context.Context, c client.Reader, task *kelos.Task, podName string) []string {
+	if podName != "" {
+		var pod corev1.Pod
+		if err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: podName}, &pod); err == nil {
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.Name != kelos.AgentContainerName || status.State.Terminated == nil {
+					continue
+				}
+				if outputs := ParseOutputs(status.State.Terminated.Message); outputs != nil {
+					return outputs
+				}
+			}
+		}
+	}
+
+	var configMap corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: results.ConfigMapName(task.UID)}, &configMap); err != nil {
+		return nil
+	}
+	if !metav1.IsControlledBy(&configMap, task) {
+		return nil
+	}
+	return ParseOutputs(configMap.Data[results.ConfigMapKey])
+}
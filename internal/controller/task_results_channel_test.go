@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/results"
+)
+
+const resultsTestBlock = "---KELOS_OUTPUTS_START---\nbranch: feature-1\ncommit: abc123\n---KELOS_OUTPUTS_END---\n"
+
+const resultsTestConfigMapName = "kelos-results-task-1-uid"
+
+func newResultsTestConfigMap(t *testing.T, task *kelos.Task, block string) *corev1.ConfigMap {
+	t.Helper()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: results.ConfigMapName(task.UID), Namespace: task.Namespace},
+		Data:       map[string]string{results.ConfigMapKey: block},
+	}
+	if err := controllerutil.SetControllerReference(task, cm, newTestScheme()); err != nil {
+		t.Fatalf("Setting owner reference: %v", err)
+	}
+	return cm
+}
+
+func TestConfigureResultsChannel(t *testing.T) {
+	tests := []struct {
+		name               string
+		serviceAccountName string
+		wantServiceAccount string
+	}{
+		{name: "results service account", wantServiceAccount: resultsTestConfigMapName},
+		{name: "service account override", serviceAccountName: "workload-identity", wantServiceAccount: "workload-identity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask("task-1", kelos.TaskPhaseRunning)
+			job := &batchv1.Job{}
+			job.Spec.Template.Spec.ServiceAccountName = tt.serviceAccountName
+			job.Spec.Template.Spec.Containers = []corev1.Container{
+				{Name: kelos.AgentContainerName},
+				{Name: "sidecar"},
+			}
+
+			configureResultsChannel(task, job)
+
+			podSpec := job.Spec.Template.Spec
+			if podSpec.ServiceAccountName != tt.wantServiceAccount {
+				t.Errorf("serviceAccountName = %q, want %q", podSpec.ServiceAccountName, tt.wantServiceAccount)
+			}
+			agent := podSpec.Containers[0]
+			if agent.TerminationMessagePath != corev1.TerminationMessagePathDefault {
+				t.Errorf("terminationMessagePath = %q, want %q", agent.TerminationMessagePath, corev1.TerminationMessagePathDefault)
+			}
+			if agent.TerminationMessagePolicy != corev1.TerminationMessageReadFile {
+				t.Errorf("terminationMessagePolicy = %q, want %q", agent.TerminationMessagePolicy, corev1.TerminationMessageReadFile)
+			}
+			wantEnv := []corev1.EnvVar{
+				{Name: results.EnvTerminationMessagePath, Value: corev1.TerminationMessagePathDefault},
+				{Name: results.EnvConfigMap, Value: resultsTestConfigMapName},
+			}
+			if !reflect.DeepEqual(agent.Env, wantEnv) {
+				t.Errorf("agent env = %+v, want %+v", agent.Env, wantEnv)
+			}
+			if len(podSpec.Containers[1].Env) != 0 {
+				t.Errorf("sidecar env = %+v, want none", podSpec.Containers[1].Env)
+			}
+		})
+	}
+}
+
+func TestEnsureTaskResultsAccess(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+	ctx := context.Background()
+
+	if err := ensureTaskResultsAccess(ctx, cl, scheme, task, resultsTestConfigMapName); err != nil {
+		t.Fatalf("ensureTaskResultsAccess() error: %v", err)
+	}
+
+	key := client.ObjectKey{Namespace: "default", Name: resultsTestConfigMapName}
+	for _, obj := range []client.Object{&corev1.ConfigMap{}, &corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		if err := cl.Get(ctx, key, obj); err != nil {
+			t.Fatalf("Getting %T: %v", obj, err)
+		}
+		if !metav1.IsControlledBy(obj, task) {
+			t.Errorf("%T is not controlled by the Task", obj)
+		}
+	}
+
+	var role rbacv1.Role
+	if err := cl.Get(ctx, key, &role); err != nil {
+		t.Fatalf("Getting Role: %v", err)
+	}
+	wantRules := []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps"},
+		ResourceNames: []string{resultsTestConfigMapName},
+		Verbs:         []string{"get", "update"},
+	}}
+	if !reflect.DeepEqual(role.Rules, wantRules) {
+		t.Errorf("Role rules = %+v, want %+v", role.Rules, wantRules)
+	}
+
+	var binding rbacv1.RoleBinding
+	if err := cl.Get(ctx, key, &binding); err != nil {
+		t.Fatalf("Getting RoleBinding: %v", err)
+	}
+	if len(binding.Subjects) != 1 || binding.Subjects[0].Name != resultsTestConfigMapName {
+		t.Errorf("RoleBinding subjects = %+v, want the Task's results ServiceAccount", binding.Subjects)
+	}
+}
+
+func TestEnsureTaskResultsAccessBindsServiceAccountOverride(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+	ctx := context.Background()
+
+	if err := ensureTaskResultsAccess(ctx, cl, scheme, task, "workload-identity"); err != nil {
+		t.Fatalf("ensureTaskResultsAccess() error: %v", err)
+	}
+
+	key := client.ObjectKey{Namespace: "default", Name: resultsTestConfigMapName}
+	var binding rbacv1.RoleBinding
+	if err := cl.Get(ctx, key, &binding); err != nil {
+		t.Fatalf("Getting RoleBinding: %v", err)
+	}
+	if len(binding.Subjects) != 1 || binding.Subjects[0].Name != "workload-identity" {
+		t.Errorf("RoleBinding subjects = %+v, want the pod's ServiceAccount", binding.Subjects)
+	}
+	if err := cl.Get(ctx, key, &corev1.ServiceAccount{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Getting results ServiceAccount error = %v, want NotFound for a pod with its own ServiceAccount", err)
+	}
+}
+
+func TestEnsureTaskResultsAccessClearsPreviousAttempt(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(task, newResultsTestConfigMap(t, task, resultsTestBlock)).
+		Build()
+	ctx := context.Background()
+
+	if err := ensureTaskResultsAccess(ctx, cl, scheme, task, resultsTestConfigMapName); err != nil {
+		t.Fatalf("ensureTaskResultsAccess() error: %v", err)
+	}
+
+	var cm corev1.ConfigMap
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: resultsTestConfigMapName}, &cm); err != nil {
+		t.Fatalf("Getting ConfigMap: %v", err)
+	}
+	if len(cm.Data) != 0 {
+		t.Errorf("ConfigMap data = %v, want the outputs of the previous attempt cleared", cm.Data)
+	}
+}
+
+func TestEnsureTaskResultsAccessIgnoresConfigMapNamedAfterTask(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	unrelated := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-1-results", Namespace: "default"},
+		Data:       map[string]string{"config": "keep"},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task, unrelated).Build()
+	ctx := context.Background()
+
+	if err := ensureTaskResultsAccess(ctx, cl, scheme, task, resultsTestConfigMapName); err != nil {
+		t.Fatalf("ensureTaskResultsAccess() error: %v", err)
+	}
+
+	var cm corev1.ConfigMap
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(unrelated), &cm); err != nil {
+		t.Fatalf("Getting ConfigMap: %v", err)
+	}
+	if cm.Data["config"] != "keep" || len(cm.OwnerReferences) != 0 {
+		t.Errorf("ConfigMap = %+v, want it left alone", cm)
+	}
+}
+
+func TestEnsureTaskResultsAccessRefusesUnownedConfigMap(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	unowned := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: resultsTestConfigMapName, Namespace: "default"},
+		Data:       map[string]string{"config": "keep"},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task, unowned).Build()
+
+	err := ensureTaskResultsAccess(context.Background(), cl, scheme, task, resultsTestConfigMapName)
+	if err == nil || !strings.Contains(err.Error(), "not controlled by this Task") {
+		t.Fatalf("ensureTaskResultsAccess() error = %v, want a not controlled error", err)
+	}
+}
+
+func TestReadResultsChannel(t *testing.T) {
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	pod := func(message string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "task-1-abcde", Namespace: "default"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: kelos.AgentContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Message: message,
+					}},
+				}},
+			},
+		}
+	}
+	largeBlock := "---KELOS_OUTPUTS_START---\nresponse: large\n---KELOS_OUTPUTS_END---\n"
+
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		podName string
+		want    []string
+	}{
+		{
+			name:    "termination message",
+			objs:    []client.Object{pod(resultsTestBlock), newResultsTestConfigMap(t, task, "")},
+			podName: "task-1-abcde",
+			want:    []string{"branch: feature-1", "commit: abc123"},
+		},
+		{
+			name:    "results ConfigMap",
+			objs:    []client.Object{pod(""), newResultsTestConfigMap(t, task, largeBlock)},
+			podName: "task-1-abcde",
+			want:    []string{"response: large"},
+		},
+		{
+			name: "pooled worker",
+			objs: []client.Object{newResultsTestConfigMap(t, task, largeBlock)},
+			want: []string{"response: large"},
+		},
+		{
+			name:    "termination message without outputs",
+			objs:    []client.Object{pod("Error: out of memory")},
+			podName: "task-1-abcde",
+		},
+		{
+			name: "ConfigMap of another owner",
+			objs: []client.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: resultsTestConfigMapName, Namespace: "default"},
+				Data:       map[string]string{results.ConfigMapKey: resultsTestBlock},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(tt.objs...).Build()
+			got := readResultsChannel(context.Background(), cl, task, tt.podName)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readResultsChannel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusReadsOutputsFromResultsChannel(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: task.Name, Namespace: task.Namespace},
+		Status:     batchv1.JobStatus{Succeeded: 1},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job, newResultsTestConfigMap(t, task, resultsTestBlock)).
+		Build()
+
+	// Without a Clientset the logs cannot be read, so the outputs can only
+	// come from the results channel.
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting Task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseSucceeded {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseSucceeded)
+	}
+	if updated.Status.Results["branch"] != "feature-1" || updated.Status.Results["commit"] != "abc123" {
+		t.Errorf("results = %v, want the outputs from the results ConfigMap", updated.Status.Results)
+	}
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const testResultsSchema = `{"type": "object", "required": ["severity"], "properties": {"severity": {"enum": ["low", "high"]}}}`
+
+func newSucceededJob(name string, completedAt time.Time) *batchv1.Job {
+	completionTime := metav1.NewTime(completedAt)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Status: batchv1.JobStatus{
+			Succeeded:      1,
+			CompletionTime: &completionTime,
+		},
+	}
+}
+
+func TestValidateTaskResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      string
+		results     map[string]string
+		wantMessage string
+	}{
+		{
+			name:    "no schema",
+			results: map[string]string{"branch": "main"},
+		},
+		{
+			name:    "matching results",
+			schema:  testResultsSchema,
+			results: map[string]string{"severity": "low", "branch": "main"},
+		},
+		{
+			name:        "missing result",
+			schema:      testResultsSchema,
+			results:     map[string]string{"branch": "main"},
+			wantMessage: "Results do not match resultsSchema: ",
+		},
+		{
+			name:        "unexpected value",
+			schema:      testResultsSchema,
+			results:     map[string]string{"severity": "critical"},
+			wantMessage: "Results do not match resultsSchema: ",
+		},
+		{
+			name:        "invalid schema",
+			schema:      `{"type": `,
+			wantMessage: "Invalid resultsSchema: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kelos.Task{Spec: kelos.TaskSpec{ResultsSchema: tt.schema}}
+			got := validateTaskResults(task, tt.results)
+			if tt.wantMessage == "" {
+				if got != "" {
+					t.Errorf("validateTaskResults() = %q, want no message", got)
+				}
+				return
+			}
+			if !strings.HasPrefix(got, tt.wantMessage) {
+				t.Errorf("validateTaskResults() = %q, want prefix %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusFailsTaskWithInvalidResults(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.ResultsSchema = testResultsSchema
+	job := newSucceededJob("task-1", time.Now().Add(-time.Minute))
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+	}
+	if !strings.HasPrefix(updated.Status.Message, "Results do not match resultsSchema: ") {
+		t.Errorf("message = %q, want a results mismatch", updated.Status.Message)
+	}
+	if updated.Status.CompletionTime == nil {
+		t.Error("expected completionTime to be set")
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionResultsInvalid)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != kelos.TaskReasonSchemaMismatch {
+		t.Fatalf("ResultsInvalid condition = %+v, want True with reason %s", cond, kelos.TaskReasonSchemaMismatch)
+	}
+
+	// The succeeded Job must not flip the Task back to Succeeded.
+	if _, err := r.updateStatus(context.Background(), updated, job); err != nil {
+		t.Fatalf("second updateStatus() error: %v", err)
+	}
+	again := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), again); err != nil {
+		t.Fatalf("getting task: %v", err)
+	}
+	if again.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase after second update = %q, want %q", again.Status.Phase, kelos.TaskPhaseFailed)
+	}
+}
+
+func TestUpdateStatusWaitsForOutputsBeforeValidatingResults(t *testing.T) {
+	scheme := newTestScheme()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.ResultsSchema = testResultsSchema
+	job := newSucceededJob("task-1", now.Add(-time.Second))
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: NewBranchLocker(),
+		NowFunc:      func() time.Time { return now },
+	}
+	result, err := r.updateStatus(context.Background(), task, job)
+	if err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+	if result.RequeueAfter != outputRetryInterval {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, outputRetryInterval)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseRunning {
+		t.Errorf("phase = %q, want %q while waiting for outputs", updated.Status.Phase, kelos.TaskPhaseRunning)
+	}
+}
+
+func TestUpdateStatusRetriesInvalidResults(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.ResultsSchema = testResultsSchema
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{
+		MaxAttempts: 3,
+		RetryOn:     []kelos.TaskFailureReason{kelos.TaskFailureReasonResultsInvalid},
+	}
+	job := newSucceededJob("task-1", time.Now().Add(-time.Minute))
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseWaiting)
+	}
+	if len(updated.Status.Attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(updated.Status.Attempts))
+	}
+	attempt := updated.Status.Attempts[0]
+	if attempt.Reason != kelos.TaskFailureReasonResultsInvalid {
+		t.Errorf("attempt reason = %q, want %q", attempt.Reason, kelos.TaskFailureReasonResultsInvalid)
+	}
+	if !strings.HasPrefix(attempt.Message, "Results do not match resultsSchema: ") {
+		t.Errorf("attempt message = %q, want a results mismatch", attempt.Message)
+	}
+}
@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/reporting"
+)
+
+const (
+	// defaultRetryBackoff is the delay before the first retry when
+	// retryPolicy.backoffSeconds is unset.
+	defaultRetryBackoff = 30 * time.Second
+
+	// defaultMaxRetryBackoff caps the retry delay when
+	// retryPolicy.maxBackoffSeconds is unset.
+	defaultMaxRetryBackoff = 10 * time.Minute
+
+	// retryLogTailLines is the number of agent log lines read when a failed
+	// attempt is recorded. It matches reporting.DefaultProgressReader so the
+	// last assistant turn is usually within range.
+	retryLogTailLines = 300
+
+	// taskConditionRetryScheduled is set while a failed Task waits for its
+	// next attempt.
+	taskConditionRetryScheduled = "RetryScheduled"
+)
+
+// jobFailureReason classifies a failed Job using the reason of its
+// JobFailed condition and returns the condition message.
+func jobFailureReason(job *batchv1.Job) (kelos.TaskFailureReason, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Type != batchv1.JobFailed || c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Reason {
+		case batchv1.JobReasonDeadlineExceeded:
+			return kelos.TaskFailureReasonDeadlineExceeded, c.Message
+		case batchv1.JobReasonPodFailurePolicy:
+			return kelos.TaskFailureReasonPodFailurePolicy, c.Message
+		default:
+			return kelos.TaskFailureReasonAgentError, c.Message
+		}
+	}
+	return kelos.TaskFailureReasonAgentError, ""
+}
+
+// currentAttempt returns the 1-based number of the Task's current attempt.
+func currentAttempt(task *kelos.Task) int32 {
+	return int32(len(task.Status.Attempts)) + 1
+}
+
+// shouldRetryTask reports whether a Task whose current attempt failed with
+// reason has attempts left under its retryPolicy.
+func shouldRetryTask(task *kelos.Task, reason kelos.TaskFailureReason) bool {
+	policy := task.Spec.RetryPolicy
+	if policy == nil || currentAttempt(task) >= policy.MaxAttempts {
+		return false
+	}
+	if len(policy.RetryOn) == 0 {
+		return true
+	}
+	for _, r := range policy.RetryOn {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBackoff returns the delay before the given retry (1 for the first
+// retry). The delay doubles for each retry and is capped at maxBackoffSeconds.
+func retryBackoff(policy *kelos.RetryPolicy, retryNumber int32) time.Duration {
+	backoff := defaultRetryBackoff
+	maxBackoff := defaultMaxRetryBackoff
+	if policy != nil && policy.BackoffSeconds != nil {
+		backoff = time.Duration(*policy.BackoffSeconds) * time.Second
+	}
+	if policy != nil && policy.MaxBackoffSeconds != nil {
+		maxBackoff = time.Duration(*policy.MaxBackoffSeconds) * time.Second
+	}
+	for i := int32(1); i < retryNumber && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// retryWaitRemaining returns how long a Task must still wait before its next
+// attempt may start, or zero when no retry backoff is pending.
+func retryWaitRemaining(task *kelos.Task, now time.Time) time.Duration {
+	if task.Status.NextRetryTime == nil {
+		return 0
+	}
+	remaining := task.Status.NextRetryTime.Time.Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// scheduleRetry deletes the Job of the failed attempt, records the attempt
+// in the Task status, and moves the Task back to Waiting until the retry
+// backoff elapses. The next reconcile after the backoff creates a fresh Job
+// through the normal admission path (dependencies, branch lock, budget).
+//
+// Every attempt's Job has the same name, so the Job is deleted before the
+// attempt is recorded: a stale Job that is still observed afterwards is
+// recognized by its UID instead of being recorded as the next attempt.
+func (r *TaskReconciler) scheduleRetry(ctx context.Context, task *kelos.Task, job *batchv1.Job, podName string, reason kelos.TaskFailureReason, jobMessage string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if attemptRecorded(task, job) {
+		return ctrl.Result{}, r.deleteAttemptJob(ctx, job)
+	}
+
+	if podName == "" {
+		podName = task.Status.PodName
+	}
+	outputs, results, lastMessage := r.readAttemptLogs(ctx, task.Namespace, podName, kelos.AgentContainerName, resolveTaskType(task))
+	if channelOutputs := readResultsChannel(ctx, r.Client, task, podName); channelOutputs != nil {
+		outputs, results = channelOutputs, ResultsFromOutputs(channelOutputs)
+	}
+
+	if err := r.deleteAttemptJob(ctx, job); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	attempt := currentAttempt(task)
+	maxAttempts := task.Spec.RetryPolicy.MaxAttempts
+	backoff := retryBackoff(task.Spec.RetryPolicy, attempt)
+	now := metav1.NewTime(r.now())
+	nextRetry := metav1.NewTime(now.Add(backoff))
+
+	message := fmt.Sprintf("Attempt %d/%d failed (%s); retrying in %s", attempt, maxAttempts, reason, backoff)
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		// Another reconcile already recorded this attempt.
+		if currentAttempt(task) != attempt || attemptRecorded(task, job) {
+			return nil
+		}
+		task.Status.Attempts = append(task.Status.Attempts, kelos.TaskAttempt{
+			Attempt:          attempt,
+			JobName:          job.Name,
+			JobUID:           job.UID,
+			PodName:          podName,
+			StartTime:        task.Status.StartTime,
+			CompletionTime:   &now,
+			Reason:           reason,
+			Message:          jobMessage,
+			LastAgentMessage: lastMessage,
+			Outputs:          outputs,
+			Results:          results,
+			Usage:            usageFromResults(results),
+		})
+		task.Status.Phase = kelos.TaskPhaseWaiting
+		task.Status.Message = message
+		task.Status.JobName = ""
+		task.Status.PodName = ""
+		task.Status.StartTime = nil
+		task.Status.CompletionTime = nil
+		task.Status.Outputs = nil
+		task.Status.Results = nil
+		task.Status.Artifacts = nil
+		task.Status.Usage = nil
+		task.Status.NextRetryTime = &nextRetry
+		meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               taskConditionRetryScheduled,
+			Status:             metav1.ConditionTrue,
+			Reason:             string(reason),
+			Message:            message,
+			ObservedGeneration: task.Generation,
+			LastTransitionTime: now,
+		})
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		logger.Error(err, "Unable to record failed attempt")
+		reconcileErrorsTotal.WithLabelValues("task").Inc()
+		return ctrl.Result{}, err
+	}
+
+	// Release the branch lock so other Tasks on the branch are not blocked
+	// during the backoff; the retry re-acquires it before creating its Job.
+	r.releaseBranchLock(ctx, task)
+
+	if results != nil {
+		RecordCostTokenMetrics(task, results)
+	}
+	taskRetriesTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(reason)).Inc()
+	r.recordEvent(task, corev1.EventTypeWarning, "TaskRetryScheduled", "%s", message)
+	logger.Info("Scheduled Task retry", "attempt", attempt, "maxAttempts", maxAttempts, "reason", reason, "backoff", backoff)
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// attemptRecorded reports whether job ran an attempt that is already
+// recorded in the Task status.
+func attemptRecorded(task *kelos.Task, job *batchv1.Job) bool {
+	if job.UID == "" || len(task.Status.Attempts) == 0 {
+		return false
+	}
+	return task.Status.Attempts[len(task.Status.Attempts)-1].JobUID == job.UID
+}
+
+// deleteAttemptJob deletes the Job of a failed attempt so that the next
+// attempt can create a Job with the same name. The UID precondition keeps a
+// stale copy of the Job from deleting the Job of a later attempt.
+func (r *TaskReconciler) deleteAttemptJob(ctx context.Context, job *batchv1.Job) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	opts := &client.DeleteOptions{PropagationPolicy: &propagationPolicy}
+	if job.UID != "" {
+		opts.Preconditions = &metav1.Preconditions{UID: &job.UID}
+	}
+	if err := r.Delete(ctx, job, opts); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		log.FromContext(ctx).Error(err, "Unable to delete Job of failed attempt", "job", job.Name)
+		return err
+	}
+	return nil
+}
+
+// readAttemptLogs reads the tail of a failed attempt's agent logs and returns
+// its outputs, results and last assistant response. It is best-effort and
+// returns empty values when the logs cannot be read.
+func (r *TaskReconciler) readAttemptLogs(ctx context.Context, namespace, podName, container, agentType string) ([]string, map[string]string, string) {
+	if r.Clientset == nil || podName == "" {
+		return nil, nil, ""
+	}
+	logger := log.FromContext(ctx)
+
+	var tailLines int64 = retryLogTailLines
+	stream, err := r.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+	}).Stream(ctx)
+	if err != nil {
+		logger.V(1).Info("Unable to read Pod logs for failed attempt", "pod", podName, "error", err)
+		return nil, nil, ""
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		logger.V(1).Info("Unable to read Pod log stream", "pod", podName, "error", err)
+		return nil, nil, ""
+	}
+
+	outputs := ParseOutputs(string(data))
+	return outputs, ResultsFromOutputs(outputs), reporting.ExtractLatestAssistantText(bytes.NewReader(data), agentType)
+}
+
+// appendRetryContext appends a summary of the previous failed attempt to the
+// prompt of a retry attempt, unless the retryPolicy disables it.
+func appendRetryContext(task *kelos.Task, prompt string) string {
+	policy := task.Spec.RetryPolicy
+	if policy == nil || len(task.Status.Attempts) == 0 {
+		return prompt
+	}
+	if policy.IncludeFailureContext != nil && !*policy.IncludeFailureContext {
+		return prompt
+	}
+	prev := task.Status.Attempts[len(task.Status.Attempts)-1]
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n---\n")
+	fmt.Fprintf(&b, "This is attempt %d of %d. The previous attempt failed (%s).\n", currentAttempt(task), policy.MaxAttempts, prev.Reason)
+	if prev.Message != "" {
+		fmt.Fprintf(&b, "\nFailure message:\n%s\n", prev.Message)
+	}
+	if prev.LastAgentMessage != "" {
+		fmt.Fprintf(&b, "\nLast agent response from the previous attempt:\n%s\n", prev.LastAgentMessage)
+	}
+	if len(prev.Outputs) > 0 {
+		b.WriteString("\nOutputs from the previous attempt:\n")
+		for _, o := range prev.Outputs {
+			fmt.Fprintf(&b, "- %s\n", o)
+		}
+	}
+	b.WriteString("\nReview the previous attempt, avoid repeating its mistakes, and complete the task.\n")
+	return b.String()
+}
+
+// usageWithAttempts adds the usage of a Task's previous attempts to the usage
+// of its final attempt, so budget accounting covers every attempt.
+func usageWithAttempts(attempts []kelos.TaskAttempt, usage *kelos.TaskUsage) *kelos.TaskUsage {
+	total := usage
+	for _, a := range attempts {
+		total = addTaskUsage(total, a.Usage)
+	}
+	return total
+}
+
+// addTaskUsage returns the sum of a and b. Nil operands are treated as zero;
+// the result is nil only when both are nil.
+func addTaskUsage(a, b *kelos.TaskUsage) *kelos.TaskUsage {
+	if a == nil && b == nil {
+		return nil
+	}
+	if a == nil {
+		return b.DeepCopy()
+	}
+	if b == nil {
+		return a.DeepCopy()
+	}
+	out := a.DeepCopy()
+	if b.CostUSD != nil {
+		var cost resource.Quantity
+		if out.CostUSD != nil {
+			cost = out.CostUSD.DeepCopy()
+		}
+		cost.Add(*b.CostUSD)
+		out.CostUSD = &cost
+	}
+	if b.InputTokens != nil {
+		v := *b.InputTokens
+		if out.InputTokens != nil {
+			v += *out.InputTokens
+		}
+		out.InputTokens = &v
+	}
+	if b.OutputTokens != nil {
+		v := *b.OutputTokens
+		if out.OutputTokens != nil {
+			v += *out.OutputTokens
+		}
+		out.OutputTokens = &v
+	}
+	return out
+}
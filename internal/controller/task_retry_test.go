@@ -0,0 +1,459 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestShouldRetryTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *kelos.RetryPolicy
+		attempts int
+		reason   kelos.TaskFailureReason
+		want     bool
+	}{
+		{
+			name:   "no retry policy",
+			reason: kelos.TaskFailureReasonAgentError,
+			want:   false,
+		},
+		{
+			name:   "attempts left, any reason",
+			policy: &kelos.RetryPolicy{MaxAttempts: 3},
+			reason: kelos.TaskFailureReasonDeadlineExceeded,
+			want:   true,
+		},
+		{
+			name:     "attempts exhausted",
+			policy:   &kelos.RetryPolicy{MaxAttempts: 3},
+			attempts: 2,
+			reason:   kelos.TaskFailureReasonAgentError,
+			want:     false,
+		},
+		{
+			name:   "single attempt",
+			policy: &kelos.RetryPolicy{MaxAttempts: 1},
+			reason: kelos.TaskFailureReasonAgentError,
+			want:   false,
+		},
+		{
+			name: "reason listed in retryOn",
+			policy: &kelos.RetryPolicy{
+				MaxAttempts: 2,
+				RetryOn:     []kelos.TaskFailureReason{kelos.TaskFailureReasonAgentError},
+			},
+			reason: kelos.TaskFailureReasonAgentError,
+			want:   true,
+		},
+		{
+			name: "reason not listed in retryOn",
+			policy: &kelos.RetryPolicy{
+				MaxAttempts: 2,
+				RetryOn:     []kelos.TaskFailureReason{kelos.TaskFailureReasonAgentError},
+			},
+			reason: kelos.TaskFailureReasonDeadlineExceeded,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kelos.Task{Spec: kelos.TaskSpec{RetryPolicy: tt.policy}}
+			for i := 0; i < tt.attempts; i++ {
+				task.Status.Attempts = append(task.Status.Attempts, kelos.TaskAttempt{Attempt: int32(i + 1)})
+			}
+			if got := shouldRetryTask(task, tt.reason); got != tt.want {
+				t.Errorf("shouldRetryTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	int32Ptr := func(v int32) *int32 { return &v }
+
+	tests := []struct {
+		name   string
+		policy *kelos.RetryPolicy
+		retry  int32
+		want   time.Duration
+	}{
+		{name: "default first retry", policy: &kelos.RetryPolicy{MaxAttempts: 3}, retry: 1, want: 30 * time.Second},
+		{name: "default doubles", policy: &kelos.RetryPolicy{MaxAttempts: 3}, retry: 3, want: 2 * time.Minute},
+		{name: "default cap", policy: &kelos.RetryPolicy{MaxAttempts: 10}, retry: 9, want: 10 * time.Minute},
+		{name: "custom backoff", policy: &kelos.RetryPolicy{MaxAttempts: 3, BackoffSeconds: int32Ptr(5)}, retry: 2, want: 10 * time.Second},
+		{name: "custom cap", policy: &kelos.RetryPolicy{MaxAttempts: 5, BackoffSeconds: int32Ptr(60), MaxBackoffSeconds: int32Ptr(90)}, retry: 3, want: 90 * time.Second},
+		{name: "zero backoff", policy: &kelos.RetryPolicy{MaxAttempts: 3, BackoffSeconds: int32Ptr(0)}, retry: 2, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryBackoff(tt.policy, tt.retry); got != tt.want {
+				t.Errorf("retryBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFailureReason(t *testing.T) {
+	tests := []struct {
+		jobReason string
+		want      kelos.TaskFailureReason
+	}{
+		{jobReason: batchv1.JobReasonBackoffLimitExceeded, want: kelos.TaskFailureReasonAgentError},
+		{jobReason: batchv1.JobReasonDeadlineExceeded, want: kelos.TaskFailureReasonDeadlineExceeded},
+		{jobReason: batchv1.JobReasonPodFailurePolicy, want: kelos.TaskFailureReasonPodFailurePolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobReason, func(t *testing.T) {
+			job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  tt.jobReason,
+				Message: "failed",
+			}}}}
+			got, message := jobFailureReason(job)
+			if got != tt.want {
+				t.Errorf("jobFailureReason() reason = %q, want %q", got, tt.want)
+			}
+			if message != "failed" {
+				t.Errorf("jobFailureReason() message = %q, want %q", message, "failed")
+			}
+		})
+	}
+}
+
+func TestAppendRetryContext(t *testing.T) {
+	disabled := false
+	task := &kelos.Task{
+		Spec: kelos.TaskSpec{
+			RetryPolicy: &kelos.RetryPolicy{MaxAttempts: 3},
+		},
+		Status: kelos.TaskStatus{
+			Attempts: []kelos.TaskAttempt{{
+				Attempt:          1,
+				Reason:           kelos.TaskFailureReasonAgentError,
+				Message:          "Job has reached the specified backoff limit",
+				LastAgentMessage: "Tests still fail in pkg/foo",
+				Outputs:          []string{"branch: fix-foo"},
+			}},
+		},
+	}
+
+	got := appendRetryContext(task, "Fix the bug")
+	for _, want := range []string{
+		"Fix the bug\n\n---\n",
+		"This is attempt 2 of 3. The previous attempt failed (AgentError).",
+		"Job has reached the specified backoff limit",
+		"Tests still fail in pkg/foo",
+		"- branch: fix-foo",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in prompt, got:\n%s", want, got)
+		}
+	}
+
+	task.Spec.RetryPolicy.IncludeFailureContext = &disabled
+	if got := appendRetryContext(task, "Fix the bug"); got != "Fix the bug" {
+		t.Errorf("appendRetryContext() with includeFailureContext=false = %q, want unchanged prompt", got)
+	}
+
+	first := &kelos.Task{Spec: kelos.TaskSpec{RetryPolicy: &kelos.RetryPolicy{MaxAttempts: 3}}}
+	if got := appendRetryContext(first, "Fix the bug"); got != "Fix the bug" {
+		t.Errorf("appendRetryContext() on first attempt = %q, want unchanged prompt", got)
+	}
+}
+
+func TestUsageWithAttempts(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+	quantityPtr := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	attempts := []kelos.TaskAttempt{
+		{Attempt: 1, Usage: &kelos.TaskUsage{CostUSD: quantityPtr("0.50"), InputTokens: int64Ptr(100)}},
+		{Attempt: 2},
+	}
+	got := usageWithAttempts(attempts, &kelos.TaskUsage{CostUSD: quantityPtr("1.25"), OutputTokens: int64Ptr(20)})
+	if got.CostUSD == nil || got.CostUSD.Cmp(resource.MustParse("1.75")) != 0 {
+		t.Errorf("CostUSD = %v, want 1.75", got.CostUSD)
+	}
+	if got.InputTokens == nil || *got.InputTokens != 100 {
+		t.Errorf("InputTokens = %v, want 100", got.InputTokens)
+	}
+	if got.OutputTokens == nil || *got.OutputTokens != 20 {
+		t.Errorf("OutputTokens = %v, want 20", got.OutputTokens)
+	}
+
+	if got := usageWithAttempts(nil, nil); got != nil {
+		t.Errorf("usageWithAttempts(nil, nil) = %v, want nil", got)
+	}
+}
+
+func newFailedJob(name string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  batchv1.JobReasonBackoffLimitExceeded,
+				Message: "Job has reached the specified backoff limit",
+			}},
+		},
+	}
+}
+
+func TestUpdateStatusSchedulesRetryForFailedJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	job := newFailedJob("task-1")
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	locker := NewBranchLocker()
+	if ok, holder, err := locker.TryAcquire(context.Background(), cl, task); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %q, %v; want acquired", ok, holder, err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: locker,
+		NowFunc:      func() time.Time { return now },
+	}
+	result, err := r.updateStatus(context.Background(), task, job)
+	if err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+	if result.RequeueAfter != defaultRetryBackoff {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, defaultRetryBackoff)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseWaiting)
+	}
+	if len(updated.Status.Attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(updated.Status.Attempts))
+	}
+	attempt := updated.Status.Attempts[0]
+	if attempt.Attempt != 1 || attempt.Reason != kelos.TaskFailureReasonAgentError || attempt.JobName != "task-1" {
+		t.Errorf("attempt = %+v, want attempt 1 of job task-1 with reason AgentError", attempt)
+	}
+	if attempt.Message != "Job has reached the specified backoff limit" {
+		t.Errorf("attempt message = %q", attempt.Message)
+	}
+	if attempt.CompletionTime == nil || !attempt.CompletionTime.Time.Equal(now) {
+		t.Errorf("attempt completionTime = %v, want %v", attempt.CompletionTime, now)
+	}
+	if updated.Status.NextRetryTime == nil || !updated.Status.NextRetryTime.Time.Equal(now.Add(defaultRetryBackoff)) {
+		t.Errorf("nextRetryTime = %v, want %v", updated.Status.NextRetryTime, now.Add(defaultRetryBackoff))
+	}
+	if updated.Status.JobName != "" || updated.Status.StartTime != nil || updated.Status.CompletionTime != nil {
+		t.Errorf("expected per-attempt status fields to be cleared, got %+v", updated.Status)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, taskConditionRetryScheduled) {
+		t.Errorf("expected %s condition to be true", taskConditionRetryScheduled)
+	}
+	if holder, err := locker.Holder(context.Background(), cl, task); err != nil || holder != "" {
+		t.Errorf("branch lock holder = %q, %v; want released", holder, err)
+	}
+
+	var deleted batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &deleted); !apierrors.IsNotFound(err) {
+		t.Errorf("expected failed Job to be deleted, got err=%v", err)
+	}
+}
+
+func TestUpdateStatusRecordsAttemptOnceWhenJobDeleteFails(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	job := newFailedJob("task-1")
+	job.UID = "job-uid-1"
+
+	var deleteErr error = apierrors.NewServiceUnavailable("temporary Job delete failure")
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				if _, ok := obj.(*batchv1.Job); ok && deleteErr != nil {
+					return deleteErr
+				}
+				return c.Delete(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err == nil {
+		t.Fatal("updateStatus() error = nil, want the Job delete error")
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if len(updated.Status.Attempts) != 0 || updated.Status.Phase != kelos.TaskPhaseRunning {
+		t.Fatalf("status = %+v, want no attempt recorded while the Job still exists", updated.Status)
+	}
+
+	// The next reconcile deletes the Job and records the attempt.
+	deleteErr = nil
+	if _, err := r.updateStatus(context.Background(), updated, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+	// A stale copy of the deleted Job is not recorded as the next attempt.
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if _, err := r.updateStatus(context.Background(), updated, job); err != nil {
+		t.Fatalf("updateStatus() with a stale Job error: %v", err)
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if len(updated.Status.Attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(updated.Status.Attempts))
+	}
+	if got := updated.Status.Attempts[0]; got.Attempt != 1 || got.JobUID != job.UID {
+		t.Errorf("attempt = %+v, want attempt 1 of Job %s", got, job.UID)
+	}
+	if updated.Status.Attempt != 1 || updated.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("attempt = %d, phase = %q; want attempt 1 waiting for its retry", updated.Status.Attempt, updated.Status.Phase)
+	}
+}
+
+func TestUpdateStatusFailsTaskWhenAttemptsExhausted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 2}
+	task.Status.Attempts = []kelos.TaskAttempt{{Attempt: 1, Reason: kelos.TaskFailureReasonAgentError}}
+	task.Status.Attempt = 2
+	job := newFailedJob("task-1")
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+	}
+	if updated.Status.Message != "Task failed after 2 attempts" {
+		t.Errorf("message = %q, want %q", updated.Status.Message, "Task failed after 2 attempts")
+	}
+	if len(updated.Status.Attempts) != 1 {
+		t.Errorf("attempts = %d, want 1", len(updated.Status.Attempts))
+	}
+
+	var existing batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &existing); err != nil {
+		t.Errorf("expected Job of final attempt to be kept, got err=%v", err)
+	}
+}
+
+func TestReconcileWaitsForRetryBackoff(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kelos.AddToScheme(scheme))
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	nextRetry := metav1.NewTime(now.Add(20 * time.Second))
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.Branch = "feature-1"
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Status.Attempts = []kelos.TaskAttempt{{Attempt: 1, Reason: kelos.TaskFailureReasonAgentError}}
+	task.Status.Attempt = 2
+	task.Finalizers = []string{taskFinalizer}
+	task.Status.Phase = kelos.TaskPhaseWaiting
+	task.Status.JobName = ""
+	task.Status.StartTime = nil
+	task.Status.NextRetryTime = &nextRetry
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task).
+		Build()
+
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: NewBranchLocker(),
+		NowFunc:      func() time.Time { return now },
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	})
+	if err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if result.RequeueAfter != 20*time.Second {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, 20*time.Second)
+	}
+
+	var job batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &job); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Job during retry backoff, got err=%v", err)
+	}
+}
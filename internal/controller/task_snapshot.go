@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// defaultImage returns the agent image used for agentType when a Task does
+// not set one.
+func (b *JobBuilder) defaultImage(agentType string) string {
+	switch agentType {
+	case AgentTypeClaudeCode:
+		return b.ClaudeCodeImage
+	case AgentTypeCodex:
+		return b.CodexImage
+	case AgentTypeGemini:
+		return b.GeminiImage
+	case AgentTypeOpenCode:
+		return b.OpenCodeImage
+	case AgentTypeCursor:
+		return b.CursorImage
+	default:
+		return ""
+	}
+}
+
+// taskForSnapshot returns a copy of task that runs with the agent type,
+// model, effort and image of its spec.resolved snapshot. The image is only
+// set as an override when it differs from the default image, so that a
+// snapshot of a Task that ran the bundled image runs it the same way.
+func (b *JobBuilder) taskForSnapshot(task *kelos.Task) *kelos.Task {
+	snapshot := task.Spec.Resolved
+	resolved := task.DeepCopy()
+	resolved.Spec.Type = ""
+	resolved.Spec.Model = ""
+	resolved.Spec.Effort = ""
+	resolved.Spec.Image = ""
+	if resolved.Spec.Worker == nil {
+		resolved.Spec.Worker = &kelos.WorkerSpec{}
+	}
+	worker := resolved.Spec.Worker
+	worker.Type = snapshot.Type
+	worker.Model = snapshot.Model
+	worker.Effort = snapshot.Effort
+	worker.Image = ""
+	if snapshot.Image != b.defaultImage(snapshot.Type) {
+		worker.Image = snapshot.Image
+	}
+	return resolved
+}
+
+// resolvedSnapshotContent is the configuration kept in the ConfigMap of a
+// resolved snapshot. The specs are taken before any credentials were
+// resolved into them.
+type resolvedSnapshotContent struct {
+	Workspace   *kelos.WorkspaceSpec
+	AgentConfig *kelos.AgentConfigSpec
+}
+
+// resolvedSnapshotError reports a spec.resolved snapshot that the Task
+// cannot run with. Retrying does not help.
+type resolvedSnapshotError struct {
+	message string
+}
+
+func (e *resolvedSnapshotError) Error() string {
+	return e.message
+}
+
+func resolvedSnapshotErrorf(format string, args ...any) error {
+	return &resolvedSnapshotError{message: fmt.Sprintf(format, args...)}
+}
+
+// resolvedSnapshotConfigMapName returns the name of the ConfigMap that holds
+// the resolved snapshot of task.
+func resolvedSnapshotConfigMapName(task *kelos.Task) string {
+	return "kelos-resolved-" + string(task.UID)
+}
+
+// buildResolvedSnapshot returns the snapshot of the configuration job runs
+// task with.
+func buildResolvedSnapshot(task *kelos.Task, job *batchv1.Job, workspaceName string, agentConfigRefs []kelos.AgentConfigReference, content *resolvedSnapshotContent) (*kelos.TaskResolvedSnapshot, error) {
+	snapshot := &kelos.TaskResolvedSnapshot{
+		Type:          resolveTaskType(task),
+		Model:         resolveTaskModel(task),
+		Effort:        resolveTaskEffort(task),
+		WorkspaceName: workspaceName,
+		ConfigMapName: resolvedSnapshotConfigMapName(task),
+	}
+	if len(agentConfigRefs) > 0 {
+		snapshot.AgentConfigRefs = append([]kelos.AgentConfigReference(nil), agentConfigRefs...)
+	}
+	for _, container := range job.Spec.Template.Spec.Containers {
+		if container.Name == kelos.AgentContainerName {
+			snapshot.Image = container.Image
+		}
+	}
+	hash, err := resolvedSnapshotHash(snapshot, content)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Hash = hash
+	return snapshot, nil
+}
+
+// resolvedSnapshotHash returns the content hash of snapshot and the
+// configuration in its ConfigMap. It does not cover the names the snapshot
+// was resolved from.
+func resolvedSnapshotHash(snapshot *kelos.TaskResolvedSnapshot, content *resolvedSnapshotContent) (string, error) {
+	data, err := json.Marshal(struct {
+		Type        string                 `json:"type"`
+		Image       string                 `json:"image,omitempty"`
+		Model       string                 `json:"model,omitempty"`
+		Effort      string                 `json:"effort,omitempty"`
+		Workspace   *kelos.WorkspaceSpec   `json:"workspace,omitempty"`
+		AgentConfig *kelos.AgentConfigSpec `json:"agentConfig,omitempty"`
+	}{
+		Type:        snapshot.Type,
+		Image:       snapshot.Image,
+		Model:       snapshot.Model,
+		Effort:      snapshot.Effort,
+		Workspace:   content.Workspace,
+		AgentConfig: content.AgentConfig,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding resolved snapshot: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ensureResolvedSnapshotConfigMap creates or updates the ConfigMap that
+// holds content for the resolved snapshot of task. The ConfigMap is owned
+// by the Task.
+func ensureResolvedSnapshotConfigMap(ctx context.Context, c client.Client, scheme *runtime.Scheme, task *kelos.Task, content *resolvedSnapshotContent) error {
+	data := map[string]string{}
+	if content.Workspace != nil {
+		encoded, err := json.Marshal(content.Workspace)
+		if err != nil {
+			return fmt.Errorf("encoding resolved Workspace: %w", err)
+		}
+		data[kelos.ResolvedSnapshotWorkspaceKey] = string(encoded)
+	}
+	if content.AgentConfig != nil {
+		encoded, err := json.Marshal(content.AgentConfig)
+		if err != nil {
+			return fmt.Errorf("encoding resolved AgentConfig: %w", err)
+		}
+		data[kelos.ResolvedSnapshotAgentConfigKey] = string(encoded)
+	}
+
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resolvedSnapshotConfigMapName(task),
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task": task.Name,
+			},
+		},
+		Data: data,
+	}
+	current := &corev1.ConfigMap{}
+	created, err := ensureTaskOwnedObject(ctx, c, scheme, task, "resolved snapshot ConfigMap", desired, current)
+	if err != nil || created || reflect.DeepEqual(current.Data, desired.Data) {
+		return err
+	}
+	current.Data = desired.Data
+	if err := c.Update(ctx, current); err != nil {
+		return fmt.Errorf("updating resolved snapshot ConfigMap %q: %w", current.Name, err)
+	}
+	return nil
+}
+
+// loadResolvedSnapshot reads the configuration of the spec.resolved snapshot
+// of task from its ConfigMap and checks it against the snapshot's hash.
+// Problems with the snapshot are returned as a *resolvedSnapshotError.
+func loadResolvedSnapshot(ctx context.Context, c client.Reader, task *kelos.Task) (*resolvedSnapshotContent, error) {
+	snapshot := task.Spec.Resolved
+	if snapshot.ConfigMapName == "" {
+		return nil, resolvedSnapshotErrorf("spec.resolved does not name the ConfigMap of the snapshot")
+	}
+	var configMap corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: snapshot.ConfigMapName}, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, resolvedSnapshotErrorf("resolved snapshot ConfigMap %q not found", snapshot.ConfigMapName)
+		}
+		return nil, fmt.Errorf("getting resolved snapshot ConfigMap %q: %w", snapshot.ConfigMapName, err)
+	}
+
+	content := &resolvedSnapshotContent{}
+	if data, ok := configMap.Data[kelos.ResolvedSnapshotWorkspaceKey]; ok {
+		if err := json.Unmarshal([]byte(data), &content.Workspace); err != nil {
+			return nil, resolvedSnapshotErrorf("decoding the Workspace of resolved snapshot ConfigMap %q: %v", configMap.Name, err)
+		}
+	}
+	if data, ok := configMap.Data[kelos.ResolvedSnapshotAgentConfigKey]; ok {
+		if err := json.Unmarshal([]byte(data), &content.AgentConfig); err != nil {
+			return nil, resolvedSnapshotErrorf("decoding the AgentConfig of resolved snapshot ConfigMap %q: %v", configMap.Name, err)
+		}
+	}
+	hash, err := resolvedSnapshotHash(snapshot, content)
+	if err != nil {
+		return nil, err
+	}
+	if hash != snapshot.Hash {
+		return nil, resolvedSnapshotErrorf("resolved snapshot ConfigMap %q does not match hash %s", configMap.Name, snapshot.Hash)
+	}
+	return content, nil
+}
+
+// checkResolvedSnapshotRefs verifies that a Task can run with the content of
+// its spec.resolved snapshot. The Task must reference the Workspace and
+// AgentConfigs the snapshot was taken from, so that they are protected from
+// deletion while it runs, and the snapshot may only use Secrets and
+// ConfigMaps that workspace and agentConfig, their current specs, still
+// reference.
+func checkResolvedSnapshotRefs(task *kelos.Task, content *resolvedSnapshotContent, workspaceName string, workspace *kelos.WorkspaceSpec, agentConfigRefs []kelos.AgentConfigReference, agentConfig *kelos.AgentConfigSpec) error {
+	snapshot := task.Spec.Resolved
+	if workspaceName != snapshot.WorkspaceName {
+		return resolvedSnapshotErrorf("resolved snapshot was taken from Workspace %q, but the Task references %q", snapshot.WorkspaceName, workspaceName)
+	}
+	if names, snapshotNames := agentConfigRefNames(agentConfigRefs), agentConfigRefNames(snapshot.AgentConfigRefs); !reflect.DeepEqual(names, snapshotNames) {
+		return resolvedSnapshotErrorf("resolved snapshot was merged from AgentConfigs %v, but the Task references %v", snapshotNames, names)
+	}
+
+	current := configObjectRefs(workspace, agentConfig)
+	var missing []string
+	for ref := range configObjectRefs(content.Workspace, content.AgentConfig) {
+		if !current[ref] {
+			missing = append(missing, ref)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return resolvedSnapshotErrorf("resolved snapshot references %s, which its Workspace and AgentConfigs no longer reference", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func agentConfigRefNames(refs []kelos.AgentConfigReference) []string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+// configObjectRefs returns the Secrets and ConfigMaps that workspace and
+// agentConfig reference, as "Secret/<name>" and "ConfigMap/<name>".
+func configObjectRefs(workspace *kelos.WorkspaceSpec, agentConfig *kelos.AgentConfigSpec) map[string]bool {
+	refs := make(map[string]bool)
+	addSecret := func(ref *kelos.SecretReference) {
+		if ref != nil && ref.Name != "" {
+			refs["Secret/"+ref.Name] = true
+		}
+	}
+	addSkills := func(skills []kelos.SkillsShSpec) {
+		for i := range skills {
+			addSecret(skills[i].SecretRef)
+		}
+	}
+	addMCPServers := func(servers []kelos.MCPServerSpec) {
+		for i := range servers {
+			server := &servers[i]
+			if server.HeadersFrom != nil {
+				addSecret(&server.HeadersFrom.SecretRef)
+			}
+			if server.EnvFrom != nil {
+				addSecret(&server.EnvFrom.SecretRef)
+			}
+			for _, env := range server.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if ref := env.ValueFrom.SecretKeyRef; ref != nil && ref.Name != "" {
+					refs["Secret/"+ref.Name] = true
+				}
+				if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && ref.Name != "" {
+					refs["ConfigMap/"+ref.Name] = true
+				}
+			}
+		}
+	}
+
+	if workspace != nil {
+		addSecret(workspace.SecretRef)
+		for i := range workspace.Repositories {
+			addSecret(workspace.Repositories[i].SecretRef)
+		}
+	}
+	if agentConfig != nil {
+		addSkills(agentConfig.Skills)
+		addMCPServers(agentConfig.MCPServers)
+		for _, override := range agentConfig.Overrides {
+			addSkills(override.Skills)
+			addMCPServers(override.MCPServers)
+		}
+	}
+	return refs
+}
+
+// addResolvedSnapshotOwner adds record as an owner of the resolved snapshot
+// ConfigMap of its Task, so that the snapshot is kept as long as the
+// TaskRecord after the Task is deleted.
+func addResolvedSnapshotOwner(ctx context.Context, c client.Client, scheme *runtime.Scheme, record *kelos.TaskRecord) error {
+	if record.Spec.Resolved == nil || record.Spec.Resolved.ConfigMapName == "" {
+		return nil
+	}
+	var configMap corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Namespace: record.Namespace, Name: record.Spec.Resolved.ConfigMapName}, &configMap); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	for _, owner := range configMap.OwnerReferences {
+		if owner.UID == record.UID {
+			return nil
+		}
+	}
+	if err := controllerutil.SetOwnerReference(record, &configMap, scheme); err != nil {
+		return fmt.Errorf("setting TaskRecord owner on resolved snapshot ConfigMap: %w", err)
+	}
+	if err := c.Update(ctx, &configMap); err != nil {
+		return fmt.Errorf("updating resolved snapshot ConfigMap %q: %w", configMap.Name, err)
+	}
+	return nil
+}
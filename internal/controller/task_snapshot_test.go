@@ -0,0 +1,353 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func agentsMDEnv(job *batchv1.Job) string {
+	for _, container := range job.Spec.Template.Spec.Containers {
+		if container.Name != kelos.AgentContainerName {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "KELOS_AGENTS_MD" {
+				return env.Value
+			}
+		}
+	}
+	return ""
+}
+
+func TestCreateJob_RecordsAndRunsResolvedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git", Ref: "main"},
+	}
+	org := newAgentConfig("org", "", kelos.AgentConfigSpec{AgentsMD: "Org rules"})
+	org.Namespace = "default"
+	team := newAgentConfig("team", "org", kelos.AgentConfigSpec{AgentsMD: "Team rules"})
+	team.Namespace = "default"
+	task := newTestTask("original", "")
+	task.Spec.Type, task.Spec.Credentials, task.Spec.WorkspaceRef = "", nil, nil
+	task.Spec.Prompt = "Fix the bug"
+	task.Spec.Worker = &kelos.WorkerSpec{
+		Type:            AgentTypeClaudeCode,
+		Model:           "opus",
+		Credentials:     &kelos.Credentials{Type: kelos.CredentialTypeNone},
+		WorkspaceRef:    &kelos.WorkspaceReference{Name: "ws"},
+		AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(workspace, org, team, task).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme, JobBuilder: NewJobBuilder()}
+
+	if _, err := r.createJob(ctx, task); err != nil {
+		t.Fatalf("createJob error: %v", err)
+	}
+	var original kelos.Task
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(task), &original); err != nil {
+		t.Fatalf("Getting task: %v", err)
+	}
+	snapshot := original.Status.Resolved
+	if snapshot == nil {
+		t.Fatal("Expected status.resolved to be set")
+	}
+	if snapshot.Type != AgentTypeClaudeCode || snapshot.Model != "opus" || snapshot.Image != ClaudeCodeImage {
+		t.Errorf("Type, Model, Image = %q, %q, %q", snapshot.Type, snapshot.Model, snapshot.Image)
+	}
+	if snapshot.WorkspaceName != "ws" {
+		t.Errorf("WorkspaceName = %q, want ws", snapshot.WorkspaceName)
+	}
+	if len(snapshot.AgentConfigRefs) != 1 || snapshot.AgentConfigRefs[0].Name != "team" {
+		t.Errorf("AgentConfigRefs = %v, want [team]", snapshot.AgentConfigRefs)
+	}
+	if snapshot.ConfigMapName != "kelos-resolved-original-uid" {
+		t.Errorf("ConfigMapName = %q, want the Task's snapshot ConfigMap", snapshot.ConfigMapName)
+	}
+	var configMap corev1.ConfigMap
+	if err := cl.Get(ctx, client.ObjectKey{Name: snapshot.ConfigMapName, Namespace: "default"}, &configMap); err != nil {
+		t.Fatalf("Getting snapshot ConfigMap: %v", err)
+	}
+	if !metav1.IsControlledBy(&configMap, &original) {
+		t.Error("Expected the snapshot ConfigMap to be controlled by the Task")
+	}
+	rerun := newTestTask("rerun", "")
+	rerun.Spec.Type, rerun.Spec.Credentials, rerun.Spec.WorkspaceRef = "", nil, nil
+	rerun.Spec.Prompt = "Fix the bug"
+	rerun.Spec.Worker = &kelos.WorkerSpec{
+		Type:            AgentTypeClaudeCode,
+		Model:           "opus",
+		Credentials:     &kelos.Credentials{Type: kelos.CredentialTypeNone},
+		WorkspaceRef:    &kelos.WorkspaceReference{Name: "ws"},
+		AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+	}
+	rerun.Spec.Resolved = snapshot.DeepCopy()
+	content, err := loadResolvedSnapshot(ctx, cl, rerun)
+	if err != nil {
+		t.Fatalf("loadResolvedSnapshot() error: %v", err)
+	}
+	if content.Workspace == nil || content.Workspace.Repo != workspace.Spec.Repo {
+		t.Errorf("Workspace = %+v", content.Workspace)
+	}
+	if content.AgentConfig == nil || content.AgentConfig.AgentsMD != "Org rules\n\nTeam rules" {
+		t.Errorf("AgentConfig = %+v", content.AgentConfig)
+	}
+
+	// Changes to the AgentConfigs after the snapshot do not affect a Task
+	// that runs with it.
+	org.Spec.AgentsMD = "New org rules"
+	if err := cl.Update(ctx, org); err != nil {
+		t.Fatalf("Updating AgentConfig: %v", err)
+	}
+	if err := cl.Create(ctx, rerun); err != nil {
+		t.Fatalf("Creating task: %v", err)
+	}
+	if _, err := r.createJob(ctx, rerun); err != nil {
+		t.Fatalf("createJob error: %v", err)
+	}
+	var got kelos.Task
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(rerun), &got); err != nil {
+		t.Fatalf("Getting task: %v", err)
+	}
+	if got.Status.Resolved == nil || got.Status.Resolved.Hash != snapshot.Hash {
+		t.Errorf("Expected the rerun to record hash %q, got %+v", snapshot.Hash, got.Status.Resolved)
+	}
+	var job batchv1.Job
+	if err := cl.Get(ctx, client.ObjectKey{Name: got.Status.JobName, Namespace: "default"}, &job); err != nil {
+		t.Fatalf("Getting Job: %v", err)
+	}
+	if got := agentsMDEnv(&job); got != "Org rules\n\nTeam rules" {
+		t.Errorf("KELOS_AGENTS_MD = %q, want the snapshot's agentsMD", got)
+	}
+}
+
+func TestTaskForSnapshot(t *testing.T) {
+	b := NewJobBuilder()
+	task := &kelos.Task{Spec: kelos.TaskSpec{
+		Type:   AgentTypeCodex,
+		Model:  "gpt-5",
+		Image:  "example.com/codex:dev",
+		Prompt: "Fix the bug",
+		Resolved: &kelos.TaskResolvedSnapshot{
+			Type:   AgentTypeClaudeCode,
+			Model:  "opus",
+			Effort: "high",
+			Image:  ClaudeCodeImage,
+		},
+	}}
+
+	got := b.taskForSnapshot(task)
+	if resolveTaskType(got) != AgentTypeClaudeCode || resolveTaskModel(got) != "opus" || resolveTaskEffort(got) != "high" {
+		t.Errorf("Type, Model, Effort = %q, %q, %q", resolveTaskType(got), resolveTaskModel(got), resolveTaskEffort(got))
+	}
+	if image := resolveTaskImage(got); image != "" {
+		t.Errorf("Image = %q, want the default image to stay unset", image)
+	}
+	if task.Spec.Type != AgentTypeCodex {
+		t.Error("Expected the Task to be left unchanged")
+	}
+
+	task.Spec.Resolved.Image = "example.com/claude-code:dev"
+	if image := resolveTaskImage(b.taskForSnapshot(task)); image != "example.com/claude-code:dev" {
+		t.Errorf("Image = %q, want the snapshot image", image)
+	}
+}
+
+func TestResolvedSnapshotHash(t *testing.T) {
+	snapshot := &kelos.TaskResolvedSnapshot{
+		Type:            AgentTypeClaudeCode,
+		Image:           ClaudeCodeImage,
+		WorkspaceName:   "ws",
+		AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+		ConfigMapName:   "kelos-resolved-uid",
+	}
+	content := &resolvedSnapshotContent{
+		Workspace:   &kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git"},
+		AgentConfig: &kelos.AgentConfigSpec{AgentsMD: "Team rules"},
+	}
+	hash, err := resolvedSnapshotHash(snapshot, content)
+	if err != nil {
+		t.Fatalf("resolvedSnapshotHash() error: %v", err)
+	}
+
+	renamed := snapshot.DeepCopy()
+	renamed.Hash = hash
+	renamed.WorkspaceName = "other"
+	renamed.AgentConfigRefs = []kelos.AgentConfigReference{{Name: "copy"}}
+	renamed.ConfigMapName = "kelos-resolved-other"
+	if got, _ := resolvedSnapshotHash(renamed, content); got != hash {
+		t.Errorf("Expected names not to change the hash, got %q and %q", got, hash)
+	}
+
+	changed := &resolvedSnapshotContent{
+		Workspace:   content.Workspace,
+		AgentConfig: &kelos.AgentConfigSpec{AgentsMD: "Other rules"},
+	}
+	if got, _ := resolvedSnapshotHash(snapshot, changed); got == hash {
+		t.Error("Expected a different AgentConfig to change the hash")
+	}
+}
+
+func TestCreateJob_RejectsResolvedSnapshotOutsideCurrentRefs(t *testing.T) {
+	ctx := context.Background()
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git"},
+	}
+	team := newAgentConfig("team", "", kelos.AgentConfigSpec{AgentsMD: "Team rules"})
+	team.Namespace = "default"
+
+	// A hand-written snapshot that hands the agent a Secret no referenced
+	// object uses.
+	content := &resolvedSnapshotContent{
+		Workspace: &workspace.Spec,
+		AgentConfig: &kelos.AgentConfigSpec{MCPServers: []kelos.MCPServerSpec{{
+			Name:    "tools",
+			Type:    "stdio",
+			Command: "tools",
+			EnvFrom: &kelos.SecretValuesSource{SecretRef: kelos.SecretReference{Name: "other-team-credentials"}},
+		}}},
+	}
+	snapshot := &kelos.TaskResolvedSnapshot{
+		Type:            AgentTypeClaudeCode,
+		Image:           ClaudeCodeImage,
+		Model:           "opus",
+		WorkspaceName:   "ws",
+		AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+		ConfigMapName:   "forged",
+	}
+	hash, err := resolvedSnapshotHash(snapshot, content)
+	if err != nil {
+		t.Fatalf("resolvedSnapshotHash() error: %v", err)
+	}
+	snapshot.Hash = hash
+	encoded, err := json.Marshal(content.AgentConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedWorkspace, err := json.Marshal(content.Workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "forged", Namespace: "default"},
+		Data: map[string]string{
+			kelos.ResolvedSnapshotWorkspaceKey:   string(encodedWorkspace),
+			kelos.ResolvedSnapshotAgentConfigKey: string(encoded),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		workspace   string
+		wantMessage string
+	}{
+		{
+			name:        "Secret not referenced by the AgentConfigs",
+			workspace:   "ws",
+			wantMessage: "Secret/other-team-credentials",
+		},
+		{
+			name:        "another Workspace",
+			workspace:   "other",
+			wantMessage: `taken from Workspace "ws", but the Task references "other"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := workspace.DeepCopy()
+			other.Name = "other"
+			task := newTestTask("rerun", "")
+			task.Spec.Type, task.Spec.Credentials, task.Spec.WorkspaceRef = "", nil, nil
+			task.Spec.Prompt = "Fix the bug"
+			task.Spec.Worker = &kelos.WorkerSpec{
+				Type:            AgentTypeClaudeCode,
+				Model:           "opus",
+				Credentials:     &kelos.Credentials{Type: kelos.CredentialTypeNone},
+				WorkspaceRef:    &kelos.WorkspaceReference{Name: "ws"},
+				AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+			}
+			task.Spec.Worker.WorkspaceRef.Name = tt.workspace
+			task.Spec.Resolved = snapshot.DeepCopy()
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(task).
+				WithObjects(workspace, other, team, forged, task).
+				Build()
+			r := &TaskReconciler{Client: cl, Scheme: scheme, JobBuilder: NewJobBuilder()}
+
+			if _, err := r.createJob(ctx, task); err != nil {
+				t.Fatalf("createJob error: %v", err)
+			}
+			var got kelos.Task
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(task), &got); err != nil {
+				t.Fatalf("Getting task: %v", err)
+			}
+			if got.Status.Phase != kelos.TaskPhaseFailed || !strings.Contains(got.Status.Message, tt.wantMessage) {
+				t.Errorf("Phase, Message = %q, %q, want Failed with %q", got.Status.Phase, got.Status.Message, tt.wantMessage)
+			}
+			if got.Status.JobName != "" {
+				t.Errorf("JobName = %q, want no Job", got.Status.JobName)
+			}
+		})
+	}
+}
+
+func TestCreateJob_RejectsResolvedSnapshotWithWrongHash(t *testing.T) {
+	ctx := context.Background()
+	scheme := newWorkspaceControllerTestScheme()
+	task := newTestTask("rerun", "")
+	task.Spec.Type, task.Spec.Credentials, task.Spec.WorkspaceRef = "", nil, nil
+	task.Spec.Prompt = "Fix the bug"
+	task.Spec.Worker = &kelos.WorkerSpec{
+		Type:            AgentTypeClaudeCode,
+		Model:           "opus",
+		Credentials:     &kelos.Credentials{Type: kelos.CredentialTypeNone},
+		WorkspaceRef:    &kelos.WorkspaceReference{Name: "ws"},
+		AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}},
+	}
+	task.Spec.Worker.WorkspaceRef = nil
+	task.Spec.Worker.AgentConfigRefs = nil
+	task.Spec.Resolved = &kelos.TaskResolvedSnapshot{
+		Hash:          "0000",
+		Type:          AgentTypeClaudeCode,
+		ConfigMapName: "snapshot",
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "snapshot", Namespace: "default"},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(configMap, task).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme, JobBuilder: NewJobBuilder()}
+
+	if _, err := r.createJob(ctx, task); err != nil {
+		t.Fatalf("createJob error: %v", err)
+	}
+	var got kelos.Task
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(task), &got); err != nil {
+		t.Fatalf("Getting task: %v", err)
+	}
+	if got.Status.Phase != kelos.TaskPhaseFailed || !strings.Contains(got.Status.Message, "does not match hash") {
+		t.Errorf("Phase, Message = %q, %q, want a hash mismatch", got.Status.Phase, got.Status.Message)
+	}
+}
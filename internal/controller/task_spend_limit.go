@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// spendLimitPollInterval is how often the running usage of a Task with
+	// a spend limit is checked while its agent runs.
+	spendLimitPollInterval = 30 * time.Second
+
+	// spendLimitLogTailLines is the number of agent log lines searched for
+	// the latest usage report. kelos-capture repeats an unchanged report
+	// every 100 lines, so the tail always holds the latest one.
+	spendLimitLogTailLines int64 = 200
+)
+
+// spendLimitExceededMessage returns a message describing how usage exceeds
+// the spend limit, or an empty string if it does not.
+func spendLimitExceededMessage(limit *kelos.SpendLimit, usage *kelos.TaskUsage) string {
+	if limit == nil || usage == nil {
+		return ""
+	}
+	if limit.MaxCostUSD != nil && usage.CostUSD != nil && usage.CostUSD.Cmp(*limit.MaxCostUSD) > 0 {
+		return fmt.Sprintf("Spend limit exceeded: cost of %s USD is over maxCostUSD %s", usage.CostUSD.String(), limit.MaxCostUSD.String())
+	}
+	if limit.MaxTokens != nil {
+		var tokens int64
+		if usage.InputTokens != nil {
+			tokens += *usage.InputTokens
+		}
+		if usage.OutputTokens != nil {
+			tokens += *usage.OutputTokens
+		}
+		if tokens > *limit.MaxTokens {
+			return fmt.Sprintf("Spend limit exceeded: %d tokens used, maxTokens is %d", tokens, *limit.MaxTokens)
+		}
+	}
+	return ""
+}
+
+// checkSpendLimitAgentType returns an error when agentType does not report
+// the running usage a limit of limit is enforced on. Only OpenCode reports
+// its cost while running, and Gemini and Cursor report no usage until they
+// finish. A limit is never silently ignored: a Task whose agent cannot
+// enforce it fails instead.
+func checkSpendLimitAgentType(limit *kelos.SpendLimit, agentType string) error {
+	if limit.MaxCostUSD != nil && agentType != AgentTypeOpenCode {
+		return fmt.Errorf("agent type %s does not report its cost while running, so maxCostUSD cannot be enforced; use maxTokens", agentType)
+	}
+	if limit.MaxTokens != nil {
+		switch agentType {
+		case AgentTypeClaudeCode, AgentTypeCodex, AgentTypeOpenCode:
+		default:
+			return fmt.Errorf("agent type %s does not report its token usage while running, so maxTokens cannot be enforced", agentType)
+		}
+	}
+	return nil
+}
+
+// taskSpendLimitExceeded reports whether the agent of the Task was stopped
+// for exceeding its spend limit and returns the condition message.
+func taskSpendLimitExceeded(task *kelos.Task) (string, bool) {
+	cond := meta.FindStatusCondition(task.Status.Conditions, kelos.TaskConditionSpendLimitExceeded)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return "", false
+	}
+	return cond.Message, true
+}
+
+// enforceSpendLimit compares the running usage that kelos-capture reports in
+// the agent's log, plus the usage of earlier attempts, with the spend limit
+// of a Task whose Job is active, and stops the agent once the limit is
+// exceeded. It returns when the usage should be checked again.
+func (r *TaskReconciler) enforceSpendLimit(ctx context.Context, task *kelos.Task, job *batchv1.Job) (time.Duration, error) {
+	if _, exceeded := taskSpendLimitExceeded(task); exceeded {
+		// The agent is already being stopped; keep at it in case a pod
+		// was started in the meantime.
+		return taskCancelPollInterval, r.stopJobPods(ctx, task, job)
+	}
+
+	usage := usageWithAttempts(task.Status.Attempts, r.readUsageReport(ctx, task.Namespace, task.Status.PodName))
+	message := spendLimitExceededMessage(task.Spec.SpendLimit, usage)
+	if message == "" {
+		return spendLimitPollInterval, nil
+	}
+	if err := r.stopTaskOverSpendLimit(ctx, task, job, message); err != nil {
+		return 0, err
+	}
+	return taskCancelPollInterval, nil
+}
+
+// stopTaskOverSpendLimit records the SpendLimitExceeded condition and stops
+// the agent the same way a cancellation does, so kelos-capture still
+// reports the outputs and usage of the partial run. updateStatus fails the
+// Task without retrying once the Job has failed.
+func (r *TaskReconciler) stopTaskOverSpendLimit(ctx context.Context, task *kelos.Task, job *batchv1.Job, message string) error {
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		task.Status.Message = message
+		meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               kelos.TaskConditionSpendLimitExceeded,
+			Status:             metav1.ConditionTrue,
+			Reason:             kelos.TaskReasonSpendLimitExceeded,
+			Message:            message,
+			ObservedGeneration: task.Generation,
+		})
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Stopping agent of Task over its spend limit", "job", job.Name)
+	r.recordEvent(task, corev1.EventTypeWarning, "SpendLimitExceeded", "%s", message)
+	return r.stopJobPods(ctx, task, job)
+}
+
+// readUsageReport returns the latest running usage that kelos-capture
+// reported in the agent's log, or nil if there is none.
+func (r *TaskReconciler) readUsageReport(ctx context.Context, namespace, podName string) *kelos.TaskUsage {
+	if r.Clientset == nil || podName == "" {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	tailLines := spendLimitLogTailLines
+	req := r.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: kelos.AgentContainerName,
+		TailLines: &tailLines,
+	})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		logger.V(1).Info("Unable to read Pod logs for usage", "pod", podName, "error", err)
+		return nil
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		logger.V(1).Info("Unable to read Pod log stream", "pod", podName, "error", err)
+		return nil
+	}
+	return usageFromResults(ParseUsageReport(string(data)))
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestSpendLimitExceededMessage(t *testing.T) {
+	maxCost := resource.MustParse("1.50")
+	cost := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	tests := []struct {
+		name  string
+		limit *kelos.SpendLimit
+		usage *kelos.TaskUsage
+		want  string
+	}{
+		{
+			name:  "no usage",
+			limit: &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			usage: nil,
+			want:  "",
+		},
+		{
+			name:  "tokens within the limit",
+			limit: &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			usage: &kelos.TaskUsage{InputTokens: int64Ptr(600), OutputTokens: int64Ptr(400)},
+			want:  "",
+		},
+		{
+			name:  "input and output tokens are combined",
+			limit: &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			usage: &kelos.TaskUsage{InputTokens: int64Ptr(600), OutputTokens: int64Ptr(401)},
+			want:  "Spend limit exceeded: 1001 tokens used, maxTokens is 1000",
+		},
+		{
+			name:  "cost over the limit",
+			limit: &kelos.SpendLimit{MaxCostUSD: &maxCost},
+			usage: &kelos.TaskUsage{CostUSD: cost("1.75")},
+			want:  "Spend limit exceeded: cost of 1750m USD is over maxCostUSD 1500m",
+		},
+		{
+			name:  "cost is not checked without a cost limit",
+			limit: &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			usage: &kelos.TaskUsage{CostUSD: cost("100")},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spendLimitExceededMessage(tt.limit, tt.usage); got != tt.want {
+				t.Errorf("spendLimitExceededMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSpendLimitAgentType(t *testing.T) {
+	maxCost := resource.MustParse("5")
+
+	tests := []struct {
+		name      string
+		limit     *kelos.SpendLimit
+		agentType string
+		wantErr   bool
+	}{
+		{
+			name:      "cost limit for opencode",
+			limit:     &kelos.SpendLimit{MaxCostUSD: &maxCost},
+			agentType: AgentTypeOpenCode,
+		},
+		{
+			name:      "cost limit for claude-code",
+			limit:     &kelos.SpendLimit{MaxCostUSD: &maxCost, MaxTokens: int64Ptr(1000)},
+			agentType: AgentTypeClaudeCode,
+			wantErr:   true,
+		},
+		{
+			name:      "token limit for codex",
+			limit:     &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			agentType: AgentTypeCodex,
+		},
+		{
+			name:      "token limit for gemini",
+			limit:     &kelos.SpendLimit{MaxTokens: int64Ptr(1000)},
+			agentType: AgentTypeGemini,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSpendLimitAgentType(tt.limit, tt.agentType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSpendLimitAgentType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnforceSpendLimitStopsAgent(t *testing.T) {
+	scheme := newTestScheme()
+
+	// Earlier attempts already used up the limit, so the agent is stopped
+	// before it reports any usage of its own.
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Status.Attempts = []kelos.TaskAttempt{{Attempt: 1, Reason: kelos.TaskFailureReasonAgentError}}
+	task.Status.Attempt = 2
+	task.Spec.SpendLimit = &kelos.SpendLimit{MaxTokens: int64Ptr(1000)}
+	task.Status.Attempts[0].Usage = &kelos.TaskUsage{InputTokens: int64Ptr(900), OutputTokens: int64Ptr(200)}
+	backoffLimit := int32(1)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+			UID:       types.UID("job-uid"),
+		},
+		Spec:   batchv1.JobSpec{BackoffLimit: &backoffLimit},
+		Status: batchv1.JobStatus{Active: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "task-1-abcde",
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task":           task.Name,
+				batchv1.ControllerUidLabel: "job-uid",
+			},
+		},
+		Spec:   corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job, pod).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	next, err := r.enforceSpendLimit(context.Background(), task, job)
+	if err != nil {
+		t.Fatalf("enforceSpendLimit() error: %v", err)
+	}
+	if next != taskCancelPollInterval {
+		t.Errorf("next check = %v, want %v", next, taskCancelPollInterval)
+	}
+
+	var updatedPod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &updatedPod); err != nil {
+		t.Fatalf("getting pod: %v", err)
+	}
+	if d := updatedPod.Spec.ActiveDeadlineSeconds; d == nil || *d != taskCancelActiveDeadlineSeconds {
+		t.Errorf("activeDeadlineSeconds = %v, want %d", d, taskCancelActiveDeadlineSeconds)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionSpendLimitExceeded)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != kelos.TaskReasonSpendLimitExceeded {
+		t.Fatalf("SpendLimitExceeded condition = %+v, want True", cond)
+	}
+	if want := "Spend limit exceeded: 1100 tokens used, maxTokens is 1000"; cond.Message != want {
+		t.Errorf("condition message = %q, want %q", cond.Message, want)
+	}
+}
+
+func TestEnforceSpendLimitWithinLimit(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Spec.SpendLimit = &kelos.SpendLimit{MaxTokens: int64Ptr(1000)}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: task.Name, Namespace: task.Namespace},
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	next, err := r.enforceSpendLimit(context.Background(), task, job)
+	if err != nil {
+		t.Fatalf("enforceSpendLimit() error: %v", err)
+	}
+	if next != spendLimitPollInterval {
+		t.Errorf("next check = %v, want %v", next, spendLimitPollInterval)
+	}
+	if _, exceeded := taskSpendLimitExceeded(task); exceeded {
+		t.Error("Expected the Task to stay within its spend limit")
+	}
+}
+
+func TestUpdateStatusFailsTaskOverSpendLimitWithoutRetry(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Spec.SpendLimit = &kelos.SpendLimit{MaxTokens: int64Ptr(1000)}
+	message := "Spend limit exceeded: 1200 tokens used, maxTokens is 1000"
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:    kelos.TaskConditionSpendLimitExceeded,
+		Status:  metav1.ConditionTrue,
+		Reason:  kelos.TaskReasonSpendLimitExceeded,
+		Message: message,
+	})
+	job := newFailedJob("task-1")
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{Client: cl, Scheme: scheme, BranchLocker: NewBranchLocker()}
+	if _, err := r.updateStatus(context.Background(), task, job); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+	}
+	if updated.Status.Message != message {
+		t.Errorf("message = %q, want %q", updated.Status.Message, message)
+	}
+	if len(updated.Status.Attempts) != 0 {
+		t.Errorf("attempts = %d, want a Task over its spend limit not to be retried", len(updated.Status.Attempts))
+	}
+}
+
+func TestBuildJob_SpendLimitReportsUsage(t *testing.T) {
+	builder := NewJobBuilder()
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 1}
+	task.Spec.SpendLimit = &kelos.SpendLimit{MaxTokens: int64Ptr(1000)}
+	workspace := &kelos.WorkspaceSpec{Repo: "https://github.com/example/repo.git"}
+
+	job, err := builder.Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	var report string
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		if e.Name == "KELOS_REPORT_USAGE" {
+			report = e.Value
+		}
+	}
+	if report != "true" {
+		t.Errorf("KELOS_REPORT_USAGE = %q, want %q", report, "true")
+	}
+}
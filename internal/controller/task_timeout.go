@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// taskTimedOut reports whether the Task exceeded one of its timeouts and
+// returns the condition message.
+func taskTimedOut(task *kelos.Task) (string, bool) {
+	cond := meta.FindStatusCondition(task.Status.Conditions, kelos.TaskConditionTimedOut)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return "", false
+	}
+	return cond.Message, true
+}
+
+// setTaskTimedOutCondition sets the TimedOut condition on the Task status.
+func setTaskTimedOutCondition(task *kelos.Task, reason, message string) {
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               kelos.TaskConditionTimedOut,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: task.Generation,
+	})
+}
+
+// taskWaitingSince returns when the waiting timeout of a Task without a Job
+// started: when the Task was created, approved, or done with its retry
+// backoff, whichever is latest. It returns false while a Task with a
+// BeforeRun approval policy has not been approved.
+func taskWaitingSince(task *kelos.Task) (time.Time, bool) {
+	since := task.CreationTimestamp.Time
+	if taskApprovalMode(task) == kelos.ApprovalModeBeforeRun {
+		cond := meta.FindStatusCondition(task.Status.Conditions, kelos.TaskConditionApproved)
+		if cond == nil || cond.Status != metav1.ConditionTrue {
+			return time.Time{}, false
+		}
+		if cond.LastTransitionTime.Time.After(since) {
+			since = cond.LastTransitionTime.Time
+		}
+	}
+	if task.Status.NextRetryTime != nil && task.Status.NextRetryTime.Time.After(since) {
+		since = task.Status.NextRetryTime.Time
+	}
+	return since, true
+}
+
+// waitingTimeoutRemaining returns how much longer a Task without a Job may
+// wait to start. It returns false when the Task has no waiting timeout or
+// its clock has not started.
+func waitingTimeoutRemaining(task *kelos.Task, now time.Time) (time.Duration, bool) {
+	if task.Spec.WaitingTimeoutSeconds == nil {
+		return 0, false
+	}
+	since, ok := taskWaitingSince(task)
+	if !ok {
+		return 0, false
+	}
+	timeout := time.Duration(*task.Spec.WaitingTimeoutSeconds) * time.Second
+	return since.Add(timeout).Sub(now), true
+}
+
+// waitingTimeoutMessage returns the status message of a Task that waited
+// too long to start, including what it was waiting for.
+func waitingTimeoutMessage(task *kelos.Task) string {
+	message := fmt.Sprintf("Task did not start within waitingTimeoutSeconds (%ds)", *task.Spec.WaitingTimeoutSeconds)
+	if task.Status.Phase == kelos.TaskPhaseWaiting && task.Status.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, task.Status.Message)
+	}
+	return message
+}
+
+// podPendingReason returns why a pod that is not running yet is stuck, such
+// as ImagePullBackOff or Unschedulable, or an empty string if unknown.
+func podPendingReason(pod *corev1.Pod) string {
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, cs := range statuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && cs.State.Waiting.Reason != "PodInitializing" {
+				return cs.State.Waiting.Reason
+			}
+		}
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason != "" {
+			return c.Reason
+		}
+	}
+	return ""
+}
+
+// enforcePendingTimeout fails a Task whose agent pod is not running within
+// spec.pendingTimeoutSeconds of its Job being created. A Job without pods is
+// deleted right away; otherwise its pods are stopped the same way a
+// cancellation does, and updateStatus fails the Task without retrying once
+// the Job has failed. It returns when the timeout should be checked again.
+func (r *TaskReconciler) enforcePendingTimeout(ctx context.Context, task *kelos.Task, job *batchv1.Job) (time.Duration, error) {
+	if _, timedOut := taskTimedOut(task); timedOut {
+		// The agent is already being stopped; keep at it in case a pod
+		// was started in the meantime.
+		return taskCancelPollInterval, r.stopJobPods(ctx, task, job)
+	}
+	if job.Status.Succeeded > 0 || isJobFailed(job) {
+		return 0, nil
+	}
+
+	var pods corev1.PodList
+	podLabels := client.MatchingLabels{
+		"kelos.dev/task": task.Name,
+	}
+	if job.UID != "" {
+		podLabels[batchv1.ControllerUidLabel] = string(job.UID)
+	}
+	if err := r.List(ctx, &pods, client.InNamespace(task.Namespace), podLabels); err != nil {
+		return 0, fmt.Errorf("listing pods of Job %s: %w", job.Name, err)
+	}
+	var pendingReason string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodPending && pod.Status.Phase != "" {
+			// The agent pod started, so the Task is no longer pending.
+			return 0, nil
+		}
+		if reason := podPendingReason(pod); reason != "" {
+			pendingReason = reason
+		}
+	}
+
+	timeout := time.Duration(*task.Spec.PendingTimeoutSeconds) * time.Second
+	if remaining := job.CreationTimestamp.Add(timeout).Sub(r.now()); remaining > 0 {
+		return remaining, nil
+	}
+
+	message := fmt.Sprintf("Agent pod did not start running within pendingTimeoutSeconds (%ds)", *task.Spec.PendingTimeoutSeconds)
+	if pendingReason != "" {
+		message = fmt.Sprintf("%s: %s", message, pendingReason)
+	}
+
+	if len(pods.Items) == 0 && job.Status.Active == 0 && job.Status.Failed == 0 {
+		// No agent pod was created, so there is nothing to stop.
+		propagationPolicy := metav1.DeletePropagationBackground
+		if err := r.Delete(ctx, job, &client.DeleteOptions{
+			PropagationPolicy: &propagationPolicy,
+		}); err != nil && !apierrors.IsNotFound(err) {
+			return 0, fmt.Errorf("deleting Job %s: %w", job.Name, err)
+		}
+		return 0, r.failTaskTimedOut(ctx, task, kelos.TaskReasonPendingTimeout, message)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		task.Status.Message = message
+		setTaskTimedOutCondition(task, kelos.TaskReasonPendingTimeout, message)
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		return 0, err
+	}
+
+	log.FromContext(ctx).Info("Stopping agent of Task over its pending timeout", "job", job.Name)
+	r.recordEvent(task, corev1.EventTypeWarning, kelos.TaskReasonPendingTimeout, "%s", message)
+	return taskCancelPollInterval, r.stopJobPods(ctx, task, job)
+}
+
+// failTaskTimedOut moves a Task that has no running agent straight to the
+// Failed phase with a TimedOut condition, releases its branch lock, and
+// records the usage of earlier attempts.
+func (r *TaskReconciler) failTaskTimedOut(ctx context.Context, task *kelos.Task, reason, message string) error {
+	logger := log.FromContext(ctx)
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(task), task); getErr != nil {
+			return getErr
+		}
+		task.Status.Phase = kelos.TaskPhaseFailed
+		task.Status.Message = message
+		if task.Status.CompletionTime == nil {
+			now := metav1.Now()
+			task.Status.CompletionTime = &now
+		}
+		task.Status.NextRetryTime = nil
+		task.Status.QueuePosition = nil
+		task.Status.BranchLockHolder = ""
+		task.Status.Usage = usageWithAttempts(task.Status.Attempts, task.Status.Usage)
+		meta.RemoveStatusCondition(&task.Status.Conditions, taskConditionRetryScheduled)
+		setTaskTimedOutCondition(task, reason, message)
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		logger.Error(err, "Unable to update Task status")
+		reconcileErrorsTotal.WithLabelValues("task").Inc()
+		return err
+	}
+
+	r.releaseBranchLock(ctx, task)
+
+	logger.Info("Task timed out", "reason", reason)
+	r.recordEvent(task, corev1.EventTypeWarning, reason, "%s", message)
+	taskCompletedTotal.WithLabelValues(task.Namespace, resolveTaskType(task), string(kelos.TaskPhaseFailed)).Inc()
+
+	if task.Status.Usage != nil {
+		return r.createTaskRecord(ctx, task)
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+var timeoutTestCreated = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newPendingTimeoutTestJob(task *kelos.Task, active int32) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              task.Name,
+			Namespace:         task.Namespace,
+			UID:               types.UID("job-uid"),
+			CreationTimestamp: metav1.NewTime(timeoutTestCreated),
+		},
+		Status: batchv1.JobStatus{
+			Active: active,
+		},
+	}
+}
+
+func assertTaskTimedOut(t *testing.T, cl client.Client, task *kelos.Task, wantReason, wantMessagePrefix string) *kelos.Task {
+	t.Helper()
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseFailed {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseFailed)
+	}
+	if !strings.HasPrefix(updated.Status.Message, wantMessagePrefix) {
+		t.Errorf("message = %q, want prefix %q", updated.Status.Message, wantMessagePrefix)
+	}
+	if updated.Status.CompletionTime == nil {
+		t.Error("expected completionTime to be set")
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, kelos.TaskConditionTimedOut)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != wantReason {
+		t.Errorf("TimedOut condition = %+v, want True with reason %s", cond, wantReason)
+	}
+	return updated
+}
+
+func TestTaskWaitingSince(t *testing.T) {
+	approvedAt := timeoutTestCreated.Add(5 * time.Minute)
+	retryAt := timeoutTestCreated.Add(10 * time.Minute)
+
+	tests := []struct {
+		name      string
+		mutate    func(task *kelos.Task)
+		wantSince time.Time
+		wantOK    bool
+	}{
+		{
+			name:      "created",
+			mutate:    func(task *kelos.Task) {},
+			wantSince: timeoutTestCreated,
+			wantOK:    true,
+		},
+		{
+			name: "awaiting approval",
+			mutate: func(task *kelos.Task) {
+				task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforeRun}
+			},
+			wantOK: false,
+		},
+		{
+			name: "approved",
+			mutate: func(task *kelos.Task) {
+				task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforeRun}
+				task.Status.Conditions = []metav1.Condition{{
+					Type:               kelos.TaskConditionApproved,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(approvedAt),
+				}}
+			},
+			wantSince: approvedAt,
+			wantOK:    true,
+		},
+		{
+			name: "retry backoff",
+			mutate: func(task *kelos.Task) {
+				next := metav1.NewTime(retryAt)
+				task.Status.NextRetryTime = &next
+			},
+			wantSince: retryAt,
+			wantOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask("task-1", kelos.TaskPhaseWaiting)
+			task.CreationTimestamp = metav1.NewTime(timeoutTestCreated)
+			task.Finalizers = []string{taskFinalizer}
+			task.Spec.Branch = "feature-1"
+			task.Spec.WaitingTimeoutSeconds = int64Ptr(60)
+			task.Status.Message = `Waiting for branch "feature-1" (locked by task-0)`
+			tt.mutate(task)
+
+			since, ok := taskWaitingSince(task)
+			if ok != tt.wantOK {
+				t.Fatalf("taskWaitingSince() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !since.Equal(tt.wantSince) {
+				t.Errorf("taskWaitingSince() = %v, want %v", since, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestPodPendingReason(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  corev1.Pod
+		want string
+	}{
+		{
+			name: "image pull",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}},
+				}},
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			}},
+			want: "ImagePullBackOff",
+		},
+		{
+			name: "unschedulable",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:   corev1.PodScheduled,
+					Status: corev1.ConditionFalse,
+					Reason: corev1.PodReasonUnschedulable,
+				}},
+			}},
+			want: corev1.PodReasonUnschedulable,
+		},
+		{
+			name: "unknown",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podPendingReason(&tt.pod); got != tt.want {
+				t.Errorf("podPendingReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileFailsTaskOverWaitingTimeout(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseWaiting)
+	task.CreationTimestamp = metav1.NewTime(timeoutTestCreated)
+	task.Finalizers = []string{taskFinalizer}
+	task.Spec.Branch = "feature-1"
+	task.Spec.WaitingTimeoutSeconds = int64Ptr(60)
+	task.Status.Message = `Waiting for branch "feature-1" (locked by task-0)`
+	holder := newTestTask("task-0", kelos.TaskPhaseRunning)
+	holder.Spec.Branch = "feature-1"
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, holder).
+		Build()
+
+	locker := NewBranchLocker()
+	if ok, _, err := locker.TryAcquire(context.Background(), cl, holder); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %v; want acquired", ok, err)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: locker,
+		Recorder:     recorder,
+		NowFunc:      func() time.Time { return timeoutTestCreated.Add(2 * time.Minute) },
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	}); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	updated := assertTaskTimedOut(t, cl, task, kelos.TaskReasonWaitingTimeout,
+		"Task did not start within waitingTimeoutSeconds (60s)")
+	if !strings.Contains(updated.Status.Message, "locked by task-0") {
+		t.Errorf("message = %q, want it to say what the Task was waiting for", updated.Status.Message)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, kelos.TaskReasonWaitingTimeout) {
+			t.Errorf("event = %q, want reason %s", event, kelos.TaskReasonWaitingTimeout)
+		}
+	default:
+		t.Error("expected a WaitingTimeout event")
+	}
+
+	var job batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &job); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Job for a timed out Task, got err=%v", err)
+	}
+}
+
+func TestReconcileRequeuesAtWaitingTimeout(t *testing.T) {
+	scheme := newTestScheme()
+	task := newTestTask("task-1", kelos.TaskPhaseWaiting)
+	task.CreationTimestamp = metav1.NewTime(timeoutTestCreated)
+	task.Finalizers = []string{taskFinalizer}
+	task.Spec.Branch = "feature-1"
+	task.Spec.WaitingTimeoutSeconds = int64Ptr(60)
+	task.Status.Message = `Waiting for branch "feature-1" (locked by task-0)`
+	holder := newTestTask("task-0", kelos.TaskPhaseRunning)
+	holder.Spec.Branch = "feature-1"
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, holder).
+		Build()
+
+	locker := NewBranchLocker()
+	if ok, _, err := locker.TryAcquire(context.Background(), cl, holder); err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %v; want acquired", ok, err)
+	}
+
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: locker,
+		NowFunc:      func() time.Time { return timeoutTestCreated.Add(55 * time.Second) },
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	})
+	if err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if result.RequeueAfter != 5*time.Second {
+		t.Errorf("RequeueAfter = %v, want the remaining waiting timeout of 5s", result.RequeueAfter)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseWaiting {
+		t.Errorf("phase = %q, want %q", updated.Status.Phase, kelos.TaskPhaseWaiting)
+	}
+}
+
+func TestReconcilePendingTimeoutDeletesJobWithoutPods(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Finalizers = []string{taskFinalizer}
+	task.Spec.PendingTimeoutSeconds = int64Ptr(300)
+	task.Status.Phase = kelos.TaskPhasePending
+	task.Status.StartTime = nil
+	job := newPendingTimeoutTestJob(task, 0)
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job).
+		Build()
+
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: NewBranchLocker(),
+		NowFunc:      func() time.Time { return timeoutTestCreated.Add(10 * time.Minute) },
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	}); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	updated := assertTaskTimedOut(t, cl, task, kelos.TaskReasonPendingTimeout,
+		"Agent pod did not start running within pendingTimeoutSeconds (300s)")
+	if len(updated.Status.Attempts) != 0 {
+		t.Errorf("attempts = %d, want a timed out Task not to be retried", len(updated.Status.Attempts))
+	}
+
+	var deleted batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &deleted); !apierrors.IsNotFound(err) {
+		t.Errorf("expected Job without pods to be deleted, got err=%v", err)
+	}
+}
+
+func TestReconcilePendingTimeoutStopsStuckPod(t *testing.T) {
+	scheme := newTestScheme()
+
+	task := newTestTask("task-1", kelos.TaskPhaseRunning)
+	task.Spec.RetryPolicy = &kelos.RetryPolicy{MaxAttempts: 3}
+	task.Finalizers = []string{taskFinalizer}
+	task.Spec.PendingTimeoutSeconds = int64Ptr(300)
+	job := newPendingTimeoutTestJob(task, 1)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "task-1-abcde",
+			Namespace: task.Namespace,
+			Labels: map[string]string{
+				"kelos.dev/task":           task.Name,
+				batchv1.ControllerUidLabel: "job-uid",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: corev1.ConditionFalse,
+				Reason: corev1.PodReasonUnschedulable,
+			}},
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(task, job, pod).
+		Build()
+
+	recorder := record.NewFakeRecorder(10)
+	r := &TaskReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		BranchLocker: NewBranchLocker(),
+		Recorder:     recorder,
+		NowFunc:      func() time.Time { return timeoutTestCreated.Add(10 * time.Minute) },
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(task),
+	})
+	if err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > taskCancelPollInterval {
+		t.Errorf("RequeueAfter = %v, want at most %v", result.RequeueAfter, taskCancelPollInterval)
+	}
+
+	var deletedPod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &deletedPod); !apierrors.IsNotFound(err) {
+		t.Errorf("expected unscheduled pod to be deleted, got err=%v", err)
+	}
+	var updatedJob batchv1.Job
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(job), &updatedJob); err != nil {
+		t.Fatalf("getting Job: %v", err)
+	}
+	if updatedJob.Spec.BackoffLimit == nil || *updatedJob.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %v, want 0", updatedJob.Spec.BackoffLimit)
+	}
+
+	updated := &kelos.Task{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), updated); err != nil {
+		t.Fatalf("getting updated task: %v", err)
+	}
+	message, timedOut := taskTimedOut(updated)
+	if !timedOut {
+		t.Fatal("expected the TimedOut condition to be set")
+	}
+	if !strings.HasSuffix(message, ": "+corev1.PodReasonUnschedulable) {
+		t.Errorf("message = %q, want it to include the pending reason", message)
+	}
+
+	// Once the Job fails, the Task fails without being retried.
+	failedJob := newFailedJob(task.Name)
+	if _, err := r.updateStatus(context.Background(), updated, failedJob); err != nil {
+		t.Fatalf("updateStatus() error: %v", err)
+	}
+	final := assertTaskTimedOut(t, cl, task, kelos.TaskReasonPendingTimeout, message)
+	if len(final.Status.Attempts) != 0 {
+		t.Errorf("attempts = %d, want a timed out Task not to be retried", len(final.Status.Attempts))
+	}
+}
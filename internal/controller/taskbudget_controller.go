@@ -8,6 +8,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,10 +24,12 @@ import (
 // Task admission. Admission only refreshes status for budgets it evaluates, so
 // after an admitted Task completes and writes a TaskRecord, this reconciler
 // recomputes usage (triggered by the TaskRecord change) and requeues at the
-// period boundary so status resets when the period rolls over.
+// period boundary so status resets when the period rolls over. It also emits
+// the SoftLimitReached event when usage crosses spec.softLimitPercent.
 type TaskBudgetReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 
 	// NowFunc returns the current time. Defaults to time.Now.
 	// Overridable in tests for deterministic behavior.
@@ -45,7 +48,7 @@ func (r *TaskBudgetReconciler) now() time.Time {
 }
 
 func (r *TaskBudgetReconciler) budget() *budgetEnforcer {
-	return &budgetEnforcer{Client: r.Client, now: r.now}
+	return &budgetEnforcer{Client: r.Client, now: r.now, recorder: r.Recorder}
 }
 
 // Reconcile recomputes a TaskBudget's status.used for the current period.
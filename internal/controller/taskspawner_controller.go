@@ -102,6 +102,11 @@ func (r *TaskSpawnerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileWorkspaceReadyCondition(ctx, &ts); err != nil {
+		logger.Error(err, "Unable to update TaskSpawner WorkspaceReady condition")
+		return ctrl.Result{}, err
+	}
+
 	isSuspended := ts.Spec.Suspend != nil && *ts.Spec.Suspend
 
 	// Cron-based TaskSpawners use a CronJob instead of a Deployment.
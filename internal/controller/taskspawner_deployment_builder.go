@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
 )
 
 const (
@@ -59,7 +60,7 @@ func (b *DeploymentBuilder) buildPodParts(ts *kelos.TaskSpawner, workspace *kelo
 
 	var envVars []corev1.EnvVar
 
-	if workspace != nil {
+	if workspace != nil && !workspaceIsGitLab(workspace) {
 		host, owner, repo := parseGitHubRepo(workspace.Repo)
 
 		// Override with an explicit GitHub source repo if set (fork workflow).
@@ -140,6 +141,30 @@ func (b *DeploymentBuilder) buildPodParts(ts *kelos.TaskSpawner, workspace *kelo
 		}
 	}
 
+	if ts.Spec.When.GitLab != nil && workspace != nil {
+		host, project := parseGitLabProject(workspace.Repo)
+		if ts.Spec.When.GitLab.Project != "" {
+			project = ts.Spec.When.GitLab.Project
+		}
+		args = append(args,
+			"--gitlab-api-base-url="+forge.GitLabAPIBaseURL(host),
+			"--gitlab-project="+project,
+		)
+		if workspace.SecretRef != nil {
+			envVars = append(envVars, corev1.EnvVar{
+				Name: forge.EnvGitLabToken,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: workspace.SecretRef.Name,
+						},
+						Key: GitLabTokenSecretKey,
+					},
+				},
+			})
+		}
+	}
+
 	if ts.Spec.When.Jira != nil {
 		jira := ts.Spec.When.Jira
 		args = append(args,
@@ -863,6 +863,72 @@ func TestBuildDeploymentWithGitHubIssuesRepoOverride(t *testing.T) {
 	}
 }
 
+func TestDeploymentBuilder_GitLab(t *testing.T) {
+	builder := NewDeploymentBuilder()
+	ts := &kelos.TaskSpawner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-spawner",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpawnerSpec{
+			When: kelos.When{
+				GitLab: &kelos.GitLab{},
+			},
+			TaskTemplate: kelos.TaskTemplate{
+				Type: "claude-code",
+			},
+		},
+	}
+	workspace := &kelos.WorkspaceSpec{
+		Repo:      "https://gitlab.example.com/group/service.git",
+		Forge:     kelos.ForgeGitLab,
+		SecretRef: &kelos.SecretReference{Name: "gitlab-token"},
+	}
+
+	deploy := builder.Build(ts, workspace, false)
+	spawner := deploy.Spec.Template.Spec.Containers[0]
+
+	for _, want := range []string{
+		"--gitlab-api-base-url=https://gitlab.example.com/api/v4",
+		"--gitlab-project=group/service",
+	} {
+		found := false
+		for _, arg := range spawner.Args {
+			if arg == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %s arg, got args: %v", want, spawner.Args)
+		}
+	}
+	for _, arg := range spawner.Args {
+		if strings.HasPrefix(arg, "--github-") {
+			t.Errorf("unexpected GitHub arg %s for a GitLab workspace", arg)
+		}
+	}
+
+	if len(spawner.Env) != 1 || spawner.Env[0].Name != "GITLAB_TOKEN" {
+		t.Fatalf("expected only the GITLAB_TOKEN env var, got %v", spawner.Env)
+	}
+	ref := spawner.Env[0].ValueFrom.SecretKeyRef
+	if ref.Name != "gitlab-token" || ref.Key != GitLabTokenSecretKey {
+		t.Errorf("GITLAB_TOKEN references %s/%s, want gitlab-token/%s", ref.Name, ref.Key, GitLabTokenSecretKey)
+	}
+
+	ts.Spec.When.GitLab.Project = "group/other"
+	deploy = builder.Build(ts, workspace, false)
+	found := false
+	for _, arg := range deploy.Spec.Template.Spec.Containers[0].Args {
+		if arg == "--gitlab-project=group/other" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the project override, got args: %v", deploy.Spec.Template.Spec.Containers[0].Args)
+	}
+}
+
 func TestBuildDeploymentWithGitHubIssuesRepoOverrideEnterprise(t *testing.T) {
 	builder := NewDeploymentBuilder()
 	ts := &kelos.TaskSpawner{
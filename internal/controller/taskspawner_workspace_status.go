@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// taskSpawnerConditionWorkspaceReady mirrors the Ready condition of the
+// TaskSpawner's Workspace, so that a broken Workspace is visible on the
+// TaskSpawner before its Tasks wait for it.
+const taskSpawnerConditionWorkspaceReady = "WorkspaceReady"
+
+// reconcileWorkspaceReadyCondition sets the WorkspaceReady condition of ts
+// from the Ready condition of its Workspace. The condition is removed when
+// ts has no Workspace or the Workspace has not been validated.
+func (r *TaskSpawnerReconciler) reconcileWorkspaceReadyCondition(ctx context.Context, ts *kelos.TaskSpawner) error {
+	workspaceRef, result, err := r.resolveTaskSpawnerWorkspaceRef(ctx, ts)
+	if err != nil || result != (ctrl.Result{}) {
+		// The WorkerPool is not there yet; the regular reconcile requeues.
+		return err
+	}
+
+	var desired *metav1.Condition
+	if workspaceRef != nil {
+		var ws kelos.Workspace
+		err := r.Get(ctx, client.ObjectKey{Namespace: ts.Namespace, Name: workspaceRef.Name}, &ws)
+		switch {
+		case apierrors.IsNotFound(err):
+			desired = &metav1.Condition{
+				Status:  metav1.ConditionFalse,
+				Reason:  "WorkspaceNotFound",
+				Message: fmt.Sprintf("Workspace %q not found", workspaceRef.Name),
+			}
+		case err != nil:
+			return err
+		default:
+			if ready := workspaceReadyCondition(&ws); ready != nil {
+				desired = &metav1.Condition{
+					Status:  ready.Status,
+					Reason:  ready.Reason,
+					Message: fmt.Sprintf("Workspace %q: %s", ws.Name, ready.Message),
+				}
+			}
+		}
+	}
+
+	current := meta.FindStatusCondition(ts.Status.Conditions, taskSpawnerConditionWorkspaceReady)
+	if desired == nil && current == nil {
+		return nil
+	}
+	if desired != nil {
+		desired.Type = taskSpawnerConditionWorkspaceReady
+		desired.ObservedGeneration = ts.Generation
+		if current != nil && current.Status == desired.Status && current.Reason == desired.Reason &&
+			current.Message == desired.Message && current.ObservedGeneration == desired.ObservedGeneration {
+			return nil
+		}
+		if desired.Status == metav1.ConditionFalse && (current == nil || current.Status != metav1.ConditionFalse) {
+			r.recordEvent(ts, corev1.EventTypeWarning, "WorkspaceNotReady", "%s", desired.Message)
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(ts), ts); err != nil {
+			return err
+		}
+		if desired == nil {
+			meta.RemoveStatusCondition(&ts.Status.Conditions, taskSpawnerConditionWorkspaceReady)
+		} else {
+			meta.SetStatusCondition(&ts.Status.Conditions, *desired)
+		}
+		return r.Status().Update(ctx, ts)
+	})
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func TestReconcileWorkspaceReadyCondition(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kelos.AddToScheme(scheme))
+
+	workspace := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default", Generation: 2},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/owner/repo"},
+		Status: kelos.WorkspaceStatus{
+			Conditions: []metav1.Condition{{
+				Type:               kelos.WorkspaceConditionReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: 2,
+				Reason:             "Unauthorized",
+				Message:            "CredentialsValid: the token was rejected",
+				LastTransitionTime: metav1.Now(),
+			}},
+		},
+	}
+	ts := &kelos.TaskSpawner{
+		ObjectMeta: metav1.ObjectMeta{Name: "spawner", Namespace: "default", Generation: 1},
+		Spec: kelos.TaskSpawnerSpec{
+			TaskTemplate: kelos.TaskTemplate{
+				WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(ts).
+		WithObjects(workspace, ts).
+		Build()
+	r := &TaskSpawnerReconciler{Client: cl, Scheme: scheme}
+
+	require.NoError(t, r.reconcileWorkspaceReadyCondition(context.Background(), ts))
+
+	var updated kelos.TaskSpawner
+	require.NoError(t, cl.Get(context.Background(), client.ObjectKeyFromObject(ts), &updated))
+	c := meta.FindStatusCondition(updated.Status.Conditions, taskSpawnerConditionWorkspaceReady)
+	require.NotNil(t, c)
+	assert.Equal(t, metav1.ConditionFalse, c.Status)
+	assert.Equal(t, "Unauthorized", c.Reason)
+	assert.Contains(t, c.Message, `Workspace "ws"`)
+
+	// Removing the workspaceRef removes the condition.
+	updated.Spec.TaskTemplate.WorkspaceRef = nil
+	require.NoError(t, cl.Update(context.Background(), &updated))
+	require.NoError(t, r.reconcileWorkspaceReadyCondition(context.Background(), &updated))
+	require.NoError(t, cl.Get(context.Background(), client.ObjectKeyFromObject(ts), &updated))
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, taskSpawnerConditionWorkspaceReady))
+}
+
+func TestReconcileWorkspaceReadyCondition_IgnoresUnvalidatedWorkspace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kelos.AddToScheme(scheme))
+
+	workspace := &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Spec:       kelos.WorkspaceSpec{Repo: "https://github.com/owner/repo"},
+	}
+	ts := &kelos.TaskSpawner{
+		ObjectMeta: metav1.ObjectMeta{Name: "spawner", Namespace: "default"},
+		Spec: kelos.TaskSpawnerSpec{
+			TaskTemplate: kelos.TaskTemplate{
+				WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(ts).
+		WithObjects(workspace, ts).
+		Build()
+	r := &TaskSpawnerReconciler{Client: cl, Scheme: scheme}
+
+	require.NoError(t, r.reconcileWorkspaceReadyCondition(context.Background(), ts))
+
+	var updated kelos.TaskSpawner
+	require.NoError(t, cl.Get(context.Background(), client.ObjectKeyFromObject(ts), &updated))
+	assert.Empty(t, updated.Status.Conditions)
+}
@@ -21,6 +21,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -52,6 +53,7 @@ import (
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 	"github.com/kelos-dev/kelos/internal/githubapp"
+	"github.com/kelos-dev/kelos/internal/inuse"
 )
 
 const (
@@ -108,15 +110,17 @@ func (r *WorkerPoolReconciler) now() time.Time {
 	return time.Now()
 }
 
-// budget returns a budgetEnforcer bound to this reconciler's client and clock,
-// so worker-pool Tasks share the same budget admission and accounting logic as
+// budget returns a budgetEnforcer bound to this reconciler's client, clock and
+// event recorder, so worker-pool Tasks share the same budget admission and accounting logic as
 // Job-backed Tasks.
 func (r *WorkerPoolReconciler) budget() *budgetEnforcer {
-	return &budgetEnforcer{Client: r.Client, now: r.now}
+	return &budgetEnforcer{Client: r.Client, now: r.now, recorder: r.Recorder}
 }
 
 // +kubebuilder:rbac:groups=kelos.dev,resources=workerpools,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=kelos.dev,resources=workerpools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=workerpools/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kelos.dev,resources=taskspawners;pipelines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kelos.dev,resources=tasks,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=kelos.dev,resources=tasks/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kelos.dev,resources=workspaces,verbs=get;list;watch
@@ -126,6 +130,8 @@ func (r *WorkerPoolReconciler) budget() *budgetEnforcer {
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
 
 // Reconcile handles both WorkerPool infrastructure and Task assignment.
@@ -152,11 +158,20 @@ func (r *WorkerPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	var result ctrl.Result
 	if poolErr == nil {
-		poolResult, err := r.reconcilePool(ctx, &pool)
+		removed, protection, err := reconcileInUseProtection(ctx, r.Client, r.Recorder, &pool, inuse.WorkerPoolProtectionFinalizer, func() ([]inuse.User, error) {
+			return inuse.WorkerPoolUsers(ctx, r.Client, pool.Namespace, pool.Name)
+		})
 		if err != nil {
-			return poolResult, err
+			return protection, err
+		}
+		result = mergeReconcileResults(result, protection)
+		if !removed {
+			poolResult, err := r.reconcilePool(ctx, &pool)
+			if err != nil {
+				return poolResult, err
+			}
+			result = mergeReconcileResults(result, poolResult)
 		}
-		result = mergeReconcileResults(result, poolResult)
 	}
 
 	if taskErr == nil && task.Spec.WorkerPoolRef != nil {
@@ -196,6 +211,9 @@ func (r *WorkerPoolReconciler) reconcilePool(ctx context.Context, pool *kelos.Wo
 		return ctrl.Result{}, err
 	}
 	workspace = &ws.Spec
+	if len(workspace.Repositories) > 0 {
+		r.recordEvent(pool, corev1.EventTypeWarning, "RepositoriesUnsupported", "Workspace %q has additional repositories, which workers do not clone; Tasks on this pool are rejected", ws.Name)
+	}
 
 	// Resolve GitHub App-backed workspace secrets into a derived Secret
 	// holding a short-lived installation token, minting it on first use and
@@ -216,20 +234,22 @@ func (r *WorkerPoolReconciler) reconcilePool(ctx context.Context, pool *kelos.Wo
 	var agentConfig *kelos.AgentConfigSpec
 	refs := resolveWorkerPoolAgentConfigRefs(pool)
 	if len(refs) > 0 {
-		var specs []kelos.AgentConfigSpec
-		for _, ref := range refs {
-			var ac kelos.AgentConfig
-			if err := r.Get(ctx, types.NamespacedName{Namespace: pool.Namespace, Name: ref.Name}, &ac); err != nil {
-				if apierrors.IsNotFound(err) {
-					logger.Info("AgentConfig not found yet, requeuing", "agentConfig", ref.Name)
-					return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
-				}
-				logger.Error(err, "Unable to fetch AgentConfig", "agentConfig", ref.Name)
-				return ctrl.Result{}, err
-			}
-			specs = append(specs, ac.Spec)
+		resolved, err := ResolveAgentConfigs(ctx, r.Client, pool.Namespace, refs, pool.Spec.Worker.Type)
+		var notFound *AgentConfigNotFoundError
+		var cycle *AgentConfigCycleError
+		switch {
+		case errors.As(err, &notFound) && notFound.ExtendedBy == "":
+			logger.Info("AgentConfig not found yet, requeuing", "agentConfig", notFound.Name)
+			return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+		case errors.As(err, &notFound), errors.As(err, &cycle):
+			logger.Info("Unable to resolve AgentConfigs, requeuing", "error", err.Error())
+			r.recordEvent(pool, corev1.EventTypeWarning, "AgentConfigInvalid", "%v", err)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		case err != nil:
+			logger.Error(err, "Unable to resolve AgentConfigs")
+			return ctrl.Result{}, err
 		}
-		agentConfig = MergeAgentConfigs(specs)
+		agentConfig = resolved
 	}
 
 	// Resolve MCP server secrets before building the pod spec
@@ -580,7 +600,7 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 	var isEnterprise bool
 	if workspace != nil {
 		host, _, _ := parseGitHubRepo(workspace.Repo)
-		isEnterprise = host != "" && host != "github.com"
+		isEnterprise = !workspaceIsGitLab(workspace) && host != "" && host != "github.com"
 
 		if isEnterprise {
 			ghHostEnv := corev1.EnvVar{Name: "GH_HOST", Value: host}
@@ -606,7 +626,11 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 		}
 	}
 
-	if workspace != nil && workspace.SecretRef != nil {
+	if workspaceIsGitLab(workspace) {
+		agentEnv, initEnv := gitLabWorkspaceEnvVars(workspace)
+		envVars = append(envVars, agentEnv...)
+		workspaceEnvVars = append(workspaceEnvVars, initEnv...)
+	} else if workspace != nil && workspace.SecretRef != nil {
 		secretKeyRef := &corev1.SecretKeySelector{
 			LocalObjectReference: corev1.LocalObjectReference{
 				Name: workspace.SecretRef.Name,
@@ -691,18 +715,7 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 	// secret-volume updates into the running pod, so a controller-side token
 	// refresh propagates without a pod restart.
 	if workspace != nil && workspace.SecretRef != nil {
-		volumes = append(volumes, corev1.Volume{
-			Name: GitHubTokenVolumeName,
-			VolumeSource: corev1.VolumeSource{
-				Secret: &corev1.SecretVolumeSource{
-					SecretName: workspace.SecretRef.Name,
-					Items: []corev1.KeyToPath{
-						{Key: GitHubTokenSecretKey, Path: GitHubTokenSecretKey},
-					},
-					Optional: ptr.To(true),
-				},
-			},
-		})
+		volumes = append(volumes, workspaceTokenVolume(workspace))
 		mainContainer.VolumeMounts = append(mainContainer.VolumeMounts, corev1.VolumeMount{
 			Name:      GitHubTokenVolumeName,
 			MountPath: GitHubTokenMountPath,
@@ -732,11 +745,7 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 		commitRef := isFullGitCommitSHA(workspace.Ref)
 
 		// Git clone init container - wraps with exists check for pod restarts on PVCs
-		cloneArgs := []string{"clone"}
-		if workspace.Ref != "" && !commitRef {
-			cloneArgs = append(cloneArgs, "--branch", workspace.Ref)
-		}
-		cloneArgs = append(cloneArgs, "--no-single-branch", "--depth", "1", "--", workspace.Repo, targetPath)
+		cloneArgs := workspaceCloneArgs(workspace.Repo, workspace.Ref, targetPath, "", workspace.Clone)
 
 		gitClone := corev1.Container{
 			Name:         "git-clone",
@@ -752,9 +761,13 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 		credentialHelper := ""
 		credentialConfig := ""
 		if workspace.SecretRef != nil {
-			credentialHelper = gitCredentialHelper()
+			credentialHelper = workspaceCredentialHelper(workspace)
 			credentialConfig = workspaceGitCredentialConfigScript(credentialHelper)
 		}
+		finish := workspaceCloneFinishScript(targetPath, credentialHelper, workspace.Clone)
+		if finish != "" {
+			finish = " && { " + finish + "; }"
+		}
 
 		if commitRef {
 			existingRepoAction := "exit 0"
@@ -763,15 +776,15 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 			}
 			gitClone.Command = []string{"sh", "-c",
 				fmt.Sprintf("if [ -d '%s/repo/.git' ]; then echo 'Workspace exists, skipping clone'; %s; fi; %s",
-					WorkspaceMountPath, existingRepoAction, buildCommitRefCheckoutScript(credentialHelper)),
+					WorkspaceMountPath, existingRepoAction, buildCommitRefCheckoutScript(credentialHelper, "", workspace.Clone)),
 			}
 			gitClone.Args = []string{"--", workspace.Repo, targetPath, workspace.Ref}
 		} else if workspace.SecretRef != nil {
 			// Build the inner clone command with credential helper
 			innerCmd := fmt.Sprintf(
 				`git -c credential.helper= -c credential.helper='%s' -c credential.username=%s "$@" && { `+
-					`%s; }`,
-				credentialHelper, gitCredentialDefaultUsername, credentialConfig,
+					`%s; }%s`,
+				credentialHelper, gitCredentialDefaultUsername, credentialConfig, finish,
 			)
 			// Wrap with exists check so it skips if workspace already exists on PVC
 			gitClone.Command = []string{"sh", "-c",
@@ -781,9 +794,13 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 			gitClone.Args = append([]string{"--"}, cloneArgs...)
 		} else {
 			// Wrap with exists check for non-secret clones
+			cloneCmd := `exec git "$@"`
+			if finish != "" {
+				cloneCmd = `git "$@"` + finish
+			}
 			gitClone.Command = []string{"sh", "-c",
-				fmt.Sprintf("if [ -d '%s/repo/.git' ]; then echo 'Workspace exists, skipping clone'; exit 0; fi; exec git \"$@\"",
-					WorkspaceMountPath),
+				fmt.Sprintf("if [ -d '%s/repo/.git' ]; then echo 'Workspace exists, skipping clone'; exit 0; fi; %s",
+					WorkspaceMountPath, cloneCmd),
 			}
 			gitClone.Args = append([]string{"--"}, cloneArgs...)
 		}
@@ -850,7 +867,7 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 		})
 	}
 
-	// Inject AgentConfig: agentsMD, plugins, skills, MCP servers
+	// Inject AgentConfig: agentsMD, plugins, skills, MCP servers, permissions
 	if agentConfig != nil {
 		if agentConfig.AgentsMD != "" {
 			mainContainer.Env = append(mainContainer.Env, corev1.EnvVar{
@@ -930,6 +947,14 @@ func (r *WorkerPoolReconciler) buildStatefulSet(pool *kelos.WorkerPool, stsName,
 				Value: mcpJSON,
 			})
 		}
+
+		permissionsEnv, err := buildPermissionsEnvVar(agentConfig.Permissions, pool.Spec.Worker.Type)
+		if err != nil {
+			return nil, err
+		}
+		if permissionsEnv != nil {
+			mainContainer.Env = append(mainContainer.Env, *permissionsEnv)
+		}
 	}
 
 	podSecurityContext := &corev1.PodSecurityContext{
@@ -1125,6 +1150,10 @@ func (r *WorkerPoolReconciler) reconcileTask(ctx context.Context, task *kelos.Ta
 		return ctrl.Result{}, nil
 	}
 
+	if reason, ok := taskCancelRequested(task); ok && !isTerminalTaskPhase(task.Status.Phase) {
+		return r.cancelTask(ctx, task, reason)
+	}
+
 	switch task.Status.Phase {
 	case kelos.TaskPhaseSucceeded, kelos.TaskPhaseFailed:
 		// completeTask writes the terminal phase before creating the TaskRecord, so
@@ -1154,6 +1183,10 @@ func (r *WorkerPoolReconciler) reconcileTask(ctx context.Context, task *kelos.Ta
 func (r *WorkerPoolReconciler) assignTask(ctx context.Context, task *kelos.Task, poolName string) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
+	if rejected, err := r.rejectTaskWithRepositories(ctx, task, poolName); err != nil || rejected {
+		return ctrl.Result{}, err
+	}
+
 	// Enforce TaskBudgets before claiming a worker pod, so worker-pool Tasks are
 	// gated by the same admission policy as Job-backed Tasks. A blocked Task is
 	// left in Waiting phase and requeued for re-evaluation when the period rolls.
@@ -1174,20 +1207,31 @@ func (r *WorkerPoolReconciler) assignTask(ctx context.Context, task *kelos.Task,
 		return ctrl.Result{}, fmt.Errorf("workerpool %s: listing worker pods: %w", poolName, err)
 	}
 
-	var availablePod *corev1.Pod
+	var available []*corev1.Pod
 	for i := range podList.Items {
 		pod := &podList.Items[i]
-		if !isPodAvailable(pod) {
-			continue
+		if isPodAvailable(pod) {
+			available = append(available, pod)
 		}
-		availablePod = pod
-		break
 	}
 
-	if availablePod == nil {
-		logger.Info("No available worker pods, requeuing", "workerpool", poolName, "task", task.Name)
+	// Tasks waiting for the pool take the available workers in queue order.
+	ahead, err := r.workerQueue(ctx, task, poolName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("workerpool %s: listing queued tasks: %w", poolName, err)
+	}
+	if len(ahead) >= len(available) {
+		logger.Info("No available worker pods, requeuing", "workerpool", poolName, "task", task.Name, "queuedAhead", len(ahead))
+		r.setWorkerQueuedPhase(ctx, task, fmt.Sprintf("Waiting for a worker of WorkerPool %q", poolName), int32(len(ahead)+1))
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
+	availablePod := available[len(ahead)]
+
+	// The worker writes the Task's outputs to its results ConfigMap, which
+	// must be writable before the Task is assigned.
+	if err := ensureTaskResultsAccess(ctx, r.Client, r.Scheme, task, WorkerRunnerServiceAccount); err != nil {
+		return ctrl.Result{}, fmt.Errorf("workerpool %s: ensuring results channel of task %s: %w", poolName, task.Name, err)
+	}
 
 	// Annotate the pod first to atomically claim it via optimistic lock.
 	// This ordering ensures that if the controller crashes between the two
@@ -1218,6 +1262,8 @@ func (r *WorkerPoolReconciler) assignTask(ctx context.Context, task *kelos.Task,
 		}
 		task.Status.Phase = kelos.TaskPhasePending
 		task.Status.PodName = availablePod.Name
+		task.Status.Message = ""
+		task.Status.QueuePosition = nil
 		now := metav1.Now()
 		task.Status.StartTime = &now
 		return r.Status().Update(ctx, task)
@@ -1234,6 +1280,105 @@ func (r *WorkerPoolReconciler) assignTask(ctx context.Context, task *kelos.Task,
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 }
 
+// rejectTaskWithRepositories fails a Task whose WorkerPool runs on a
+// Workspace with additional repositories. Workers clone only the primary
+// repository, so the Task would silently run without the others. It reports
+// whether the Task was rejected.
+func (r *WorkerPoolReconciler) rejectTaskWithRepositories(ctx context.Context, task *kelos.Task, poolName string) (bool, error) {
+	var pool kelos.WorkerPool
+	if err := r.Get(ctx, types.NamespacedName{Namespace: task.Namespace, Name: poolName}, &pool); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if pool.Spec.Worker.WorkspaceRef == nil {
+		return false, nil
+	}
+	var ws kelos.Workspace
+	if err := r.Get(ctx, types.NamespacedName{Namespace: task.Namespace, Name: pool.Spec.Worker.WorkspaceRef.Name}, &ws); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if len(ws.Spec.Repositories) == 0 {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("WorkerPool %q does not support Workspace %q with additional repositories; run the Task without workerPoolRef", poolName, ws.Name)
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(task), task); err != nil {
+			return err
+		}
+		task.Status.Phase = kelos.TaskPhaseFailed
+		task.Status.Message = message
+		task.Status.QueuePosition = nil
+		now := metav1.Now()
+		task.Status.CompletionTime = &now
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		return false, fmt.Errorf("workerpool %s: rejecting task %s: %w", poolName, task.Name, err)
+	}
+	log.FromContext(ctx).Info("Rejected task on a Workspace with additional repositories", "task", task.Name, "workerpool", poolName, "workspace", ws.Name)
+	r.recordEvent(task, corev1.EventTypeWarning, "RepositoriesUnsupported", "%s", message)
+	return true, nil
+}
+
+// workerQueue returns the Tasks of the pool that wait for a worker ahead of
+// task in its taskQueue. Fair sharing accounts for the Tasks the workers of
+// the pool are running. Tasks held back by a TaskBudget do not wait for a
+// worker.
+func (r *WorkerPoolReconciler) workerQueue(ctx context.Context, task *kelos.Task, poolName string) ([]*kelos.Task, error) {
+	var taskList kelos.TaskList
+	if err := r.List(ctx, &taskList, client.InNamespace(task.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var running, waiting []*kelos.Task
+	for i := range taskList.Items {
+		t := &taskList.Items[i]
+		if t.Name == task.Name || t.Spec.WorkerPoolRef == nil || t.Spec.WorkerPoolRef.Name != poolName {
+			continue
+		}
+		if isTerminalTaskPhase(t.Status.Phase) || !t.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if t.Status.PodName != "" {
+			running = append(running, t)
+			continue
+		}
+		if taskAdmissionBlocked(t) {
+			continue
+		}
+		waiting = append(waiting, t)
+	}
+
+	queue := newTaskQueue(running)
+	var ahead []*kelos.Task
+	for _, t := range waiting {
+		if queue.before(t, task) {
+			ahead = append(ahead, t)
+		}
+	}
+	return ahead, nil
+}
+
+// setWorkerQueuedPhase sets a Task that waits for a worker of its pool to
+// the Waiting phase with its queue position. It skips the status write when
+// nothing changed.
+func (r *WorkerPoolReconciler) setWorkerQueuedPhase(ctx context.Context, task *kelos.Task, message string, position int32) {
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(task), task); err != nil {
+			return err
+		}
+		if task.Status.Phase == kelos.TaskPhaseWaiting && task.Status.Message == message &&
+			queuePositionEqual(task.Status.QueuePosition, position) {
+			return nil
+		}
+		task.Status.Phase = kelos.TaskPhaseWaiting
+		task.Status.Message = message
+		task.Status.QueuePosition = queuePosition(position)
+		return r.Status().Update(ctx, task)
+	}); err != nil {
+		log.FromContext(ctx).Error(err, "Unable to update Task status to Waiting", "task", task.Name)
+	}
+}
+
 func (r *WorkerPoolReconciler) monitorTaskCompletion(ctx context.Context, task *kelos.Task) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -1294,13 +1439,17 @@ func (r *WorkerPoolReconciler) monitorTaskCompletion(ctx context.Context, task *
 }
 
 func (r *WorkerPoolReconciler) completeTask(ctx context.Context, task *kelos.Task, phase kelos.TaskPhase, message string) error {
-	outputs, results := r.readPodOutputs(ctx, task.Namespace, task.Status.PodName, task.Name)
+	outputs, results := r.readPodOutputs(ctx, task)
 	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if err := r.Get(ctx, client.ObjectKeyFromObject(task), task); err != nil {
 			return err
 		}
 		task.Status.Phase = phase
 		task.Status.Message = message
+		if reason, ok := taskCancelRequested(task); ok && phase == kelos.TaskPhaseFailed {
+			task.Status.Message = taskCancelMessage(reason)
+			setTaskCancelledCondition(task, kelos.TaskReasonCancelled, task.Status.Message)
+		}
 		now := metav1.Now()
 		task.Status.CompletionTime = &now
 		if outputs != nil {
@@ -1348,7 +1497,16 @@ func (r *WorkerPoolReconciler) clearTaskPodAssignmentIfStillAssigned(ctx context
 	return r.clearPodAssignment(ctx, &pod)
 }
 
-func (r *WorkerPoolReconciler) readPodOutputs(ctx context.Context, namespace, podName, taskName string) ([]string, map[string]string) {
+// readPodOutputs reads the outputs of a Task run by a worker pod from the
+// Task's results ConfigMap, falling back to the Task's segment of the worker
+// logs for workers that do not write one.
+func (r *WorkerPoolReconciler) readPodOutputs(ctx context.Context, task *kelos.Task) ([]string, map[string]string) {
+	// The worker container outlives the Task, so its termination message
+	// never holds the Task's outputs.
+	if outputs := readResultsChannel(ctx, r.Client, task, ""); outputs != nil {
+		return outputs, ResultsFromOutputs(outputs)
+	}
+	namespace, podName, taskName := task.Namespace, task.Status.PodName, task.Name
 	if r.Clientset == nil || podName == "" {
 		return nil, nil
 	}
@@ -1635,17 +1793,7 @@ func (r *WorkerPoolReconciler) findWorkerPoolTasksForBudget(ctx context.Context,
 	var requests []reconcile.Request
 	for i := range taskList.Items {
 		t := &taskList.Items[i]
-		if t.Spec.WorkerPoolRef == nil || t.Status.Phase != kelos.TaskPhaseWaiting {
-			continue
-		}
-		blocked := false
-		for _, c := range t.Status.Conditions {
-			if c.Type == "BudgetBlocked" && c.Status == metav1.ConditionTrue {
-				blocked = true
-				break
-			}
-		}
-		if !blocked {
+		if t.Spec.WorkerPoolRef == nil || !taskAdmissionBlocked(t) {
 			continue
 		}
 		requests = append(requests, reconcile.Request{
@@ -1681,6 +1829,10 @@ func (r *WorkerPoolReconciler) findPoolsForAgentConfig(ctx context.Context, obj
 		return nil
 	}
 
+	dependents, err := agentConfigDependents(ctx, r.Client, agentConfig.Namespace, agentConfig.Name)
+	if err != nil {
+		return nil
+	}
 	var poolList kelos.WorkerPoolList
 	if err := r.List(ctx, &poolList, client.InNamespace(agentConfig.Namespace)); err != nil {
 		return nil
@@ -1689,7 +1841,7 @@ func (r *WorkerPoolReconciler) findPoolsForAgentConfig(ctx context.Context, obj
 	var requests []reconcile.Request
 	for _, pool := range poolList.Items {
 		for _, ref := range pool.Spec.Worker.AgentConfigRefs {
-			if ref.Name == agentConfig.Name {
+			if dependents[ref.Name] {
 				requests = appendWorkerPoolRequest(requests, pool.Namespace, pool.Name)
 				break
 			}
@@ -26,6 +26,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -245,6 +246,49 @@ func TestWorkerPoolReconciler_CommitRefWorkspaceUsesCheckoutScript(t *testing.T)
 	}
 }
 
+func TestWorkerPoolReconciler_WorkspaceCloneOptions(t *testing.T) {
+	scheme := newWorkerPoolTestScheme()
+	pool := newTestWorkerPool("my-pool", "default", 1)
+	ws := newTestWorkspace("default")
+	ws.Spec.Clone = &kelos.WorkspaceCloneOptions{
+		Depth:          ptr.To[int32](0),
+		SparseCheckout: []string{"services/api"},
+		Submodules:     true,
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.WorkerPool{}).
+		WithObjects(pool, ws).
+		Build()
+
+	r := newWorkerPoolReconciler(cl, scheme)
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-pool", Namespace: "default"},
+	})
+	require.NoError(t, err)
+
+	var sts appsv1.StatefulSet
+	err = cl.Get(context.Background(), types.NamespacedName{Name: "wp-my-pool", Namespace: "default"}, &sts)
+	require.NoError(t, err)
+
+	var gitClone *corev1.Container
+	for i := range sts.Spec.Template.Spec.InitContainers {
+		if sts.Spec.Template.Spec.InitContainers[i].Name == "git-clone" {
+			gitClone = &sts.Spec.Template.Spec.InitContainers[i]
+			break
+		}
+	}
+	require.NotNil(t, gitClone)
+	require.Len(t, gitClone.Command, 3)
+	assert.NotContains(t, gitClone.Args, "--depth")
+	assert.Contains(t, gitClone.Args, "--sparse")
+	assert.Contains(t, gitClone.Command[2], "Workspace exists, skipping clone")
+	assert.Contains(t, gitClone.Command[2], "sparse-checkout set --cone -- 'services/api'")
+	assert.Contains(t, gitClone.Command[2], "submodule update --init --recursive")
+}
+
 func TestWorkerPoolReconciler_ExistingWorkspaceRefreshesCredentialConfig(t *testing.T) {
 	refs := map[string]string{
 		"branch": "main",
@@ -553,6 +597,143 @@ func TestWorkerPoolReconciler_AssignsTask(t *testing.T) {
 	assert.Empty(t, updatedPod.Annotations[kelos.AnnotationWorkerTaskStatus])
 }
 
+func TestWorkerPoolReconciler_RejectsTaskOnWorkspaceWithRepositories(t *testing.T) {
+	scheme := newWorkerPoolTestScheme()
+	pool := newTestWorkerPool("my-pool", "default", 1)
+	ws := newTestWorkspace("default")
+	ws.Spec.Repositories = []kelos.WorkspaceRepository{
+		{Name: "proto", Repo: "https://github.com/example/proto.git"},
+	}
+
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-task",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Do something",
+			WorkerPoolRef: &kelos.WorkerPoolReference{
+				Name: "my-pool",
+			},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wp-my-pool-0",
+			Namespace: "default",
+			Labels:    workerPoolLabelsForTest("my-pool"),
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Task{}, &kelos.WorkerPool{}).
+		WithObjects(pool, ws, task, pod).
+		Build()
+
+	r := newWorkerPoolReconciler(cl, scheme)
+	recorder := r.Recorder.(*record.FakeRecorder)
+
+	_, err := r.reconcileTask(context.Background(), task)
+	require.NoError(t, err)
+
+	var updatedTask kelos.Task
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "test-task", Namespace: "default"}, &updatedTask))
+	assert.Equal(t, kelos.TaskPhaseFailed, updatedTask.Status.Phase)
+	assert.Contains(t, updatedTask.Status.Message, "additional repositories")
+	assert.NotNil(t, updatedTask.Status.CompletionTime)
+	assert.Empty(t, updatedTask.Status.PodName)
+
+	var updatedPod corev1.Pod
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "wp-my-pool-0", Namespace: "default"}, &updatedPod))
+	assert.Empty(t, updatedPod.Annotations[kelos.AnnotationWorkerAssignedTask])
+
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, "Warning RepositoriesUnsupported")
+	default:
+		t.Error("Expected a RepositoriesUnsupported event")
+	}
+}
+
+func TestWorkerPoolReconciler_AssignsTasksInQueueOrder(t *testing.T) {
+	scheme := newWorkerPoolTestScheme()
+	pool := newTestWorkerPool("my-pool", "default", 1)
+
+	low := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "low",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+		Spec: kelos.TaskSpec{
+			Type:          AgentTypeClaudeCode,
+			Prompt:        "Do something",
+			WorkerPoolRef: &kelos.WorkerPoolReference{Name: "my-pool"},
+		},
+	}
+	high := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "high",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+		Spec: kelos.TaskSpec{
+			Type:          AgentTypeClaudeCode,
+			Prompt:        "Do something",
+			WorkerPoolRef: &kelos.WorkerPoolReference{Name: "my-pool"},
+			Priority:      int32Ptr(10),
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wp-my-pool-0",
+			Namespace: "default",
+			Labels:    workerPoolLabelsForTest("my-pool"),
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&kelos.Task{}, &kelos.WorkerPool{}).
+		WithObjects(pool, low, high, pod).
+		Build()
+
+	r := newWorkerPoolReconciler(cl, scheme)
+
+	// The older Task yields the only worker to the higher-priority one.
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "low", Namespace: "default"},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter)
+
+	var updated kelos.Task
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "low", Namespace: "default"}, &updated))
+	assert.Empty(t, updated.Status.PodName)
+	assert.Equal(t, kelos.TaskPhaseWaiting, updated.Status.Phase)
+	require.NotNil(t, updated.Status.QueuePosition)
+	assert.Equal(t, int32(2), *updated.Status.QueuePosition)
+
+	_, err = r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "high", Namespace: "default"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "high", Namespace: "default"}, &updated))
+	assert.Equal(t, "wp-my-pool-0", updated.Status.PodName)
+	assert.Equal(t, kelos.TaskPhasePending, updated.Status.Phase)
+	assert.Nil(t, updated.Status.QueuePosition)
+}
+
 func TestWorkerPoolReconciler_TaskCompletionSucceeded(t *testing.T) {
 	scheme := newWorkerPoolTestScheme()
 	pool := newTestWorkerPool("my-pool", "default", 1)
@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// DefaultWorkspaceCacheStorage is the default size of a Workspace's
+	// git mirror PersistentVolumeClaim.
+	DefaultWorkspaceCacheStorage = "10Gi"
+
+	// DefaultWorkspaceCacheRefreshSchedule is the default cron schedule on
+	// which a Workspace's git mirror is fetched from the remote.
+	DefaultWorkspaceCacheRefreshSchedule = "*/15 * * * *"
+
+	// WorkspaceCacheVolumeName is the name of the volume that holds the
+	// Workspace's git mirror in Task pods and refresh Jobs.
+	WorkspaceCacheVolumeName = kelos.ReservedVolumeNamePrefix + "git-cache"
+
+	// WorkspaceCacheMountPath is where the git mirror volume is mounted.
+	WorkspaceCacheMountPath = "/kelos/git-cache"
+
+	// workspaceCacheMirrorPath is the bare mirror repository inside the
+	// git mirror volume.
+	workspaceCacheMirrorPath = WorkspaceCacheMountPath + "/mirror.git"
+
+	workspaceCacheNamePrefix    = "git-cache-"
+	workspaceCacheNameMaxLength = 52
+)
+
+// WorkspaceCacheName returns the deterministic name of the PersistentVolumeClaim
+// and refresh CronJob of a Workspace's git mirror. It is short enough for a
+// CronJob name.
+func WorkspaceCacheName(workspaceName string) string {
+	name := workspaceCacheNamePrefix + workspaceName
+	if len(name) <= workspaceCacheNameMaxLength {
+		return name
+	}
+
+	sum := sha1.Sum([]byte(name))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	maxPrefixLen := workspaceCacheNameMaxLength - len(suffix) - 1
+	return name[:maxPrefixLen] + "-" + suffix
+}
+
+func workspaceCacheLabels(workspaceName string) map[string]string {
+	return map[string]string{
+		"kelos.dev/name":       "kelos",
+		"kelos.dev/component":  "git-cache",
+		"kelos.dev/managed-by": "kelos-controller",
+		"kelos.dev/workspace":  workspaceName,
+	}
+}
+
+// buildWorkspaceCachePVC returns the PersistentVolumeClaim that holds the
+// git mirror of workspace.
+func buildWorkspaceCachePVC(workspace *kelos.Workspace) *corev1.PersistentVolumeClaim {
+	cache := workspace.Spec.Cache
+	storage := resource.MustParse(DefaultWorkspaceCacheStorage)
+	if cache.Storage != nil {
+		storage = cache.Storage.DeepCopy()
+	}
+	accessModes := cache.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      WorkspaceCacheName(workspace.Name),
+			Namespace: workspace.Namespace,
+			Labels:    workspaceCacheLabels(workspace.Name),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      append([]corev1.PersistentVolumeAccessMode(nil), accessModes...),
+			StorageClassName: cache.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: storage},
+			},
+		},
+	}
+}
+
+// buildWorkspaceCacheCronJob returns the CronJob that creates and refreshes
+// the git mirror of workspace. The mirror tracks the remote's branches and
+// tags; it is created in a temporary directory and moved into place so Task
+// pods never see a partially initialized repository. Automatic gc is turned
+// off in the mirror so that no git command, including a fetch, repacks it
+// in the background; the refresh runs gc itself once the fetch is done,
+// and the CronJob never runs two refreshes at once.
+func buildWorkspaceCacheCronJob(workspace *kelos.Workspace) *batchv1.CronJob {
+	schedule := workspace.Spec.Cache.RefreshSchedule
+	if schedule == "" {
+		schedule = DefaultWorkspaceCacheRefreshSchedule
+	}
+	agentUID := AgentUID
+	labels := workspaceCacheLabels(workspace.Name)
+
+	credentialHelper := ""
+	volumes := []corev1.Volume{{
+		Name: WorkspaceCacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: WorkspaceCacheName(workspace.Name),
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{Name: WorkspaceCacheVolumeName, MountPath: WorkspaceCacheMountPath}}
+	if workspace.Spec.SecretRef != nil {
+		credentialHelper = workspaceCredentialHelper(&workspace.Spec)
+		volumes = append(volumes, workspaceTokenVolume(&workspace.Spec))
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      GitHubTokenVolumeName,
+			MountPath: GitHubTokenMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	remoteGit := remoteGitCommand(credentialHelper)
+	script := fmt.Sprintf(`set -eu
+repo=$1
+mirror=%s
+if [ ! -d "$mirror" ]; then
+  rm -rf "$mirror.tmp"
+  git init --bare "$mirror.tmp"
+  git -C "$mirror.tmp" remote add origin "$repo"
+  git -C "$mirror.tmp" config remote.origin.fetch '+refs/heads/*:refs/heads/*'
+  mv "$mirror.tmp" "$mirror"
+fi
+git -C "$mirror" config gc.auto 0
+git -C "$mirror" remote set-url origin "$repo"
+%s -C "$mirror" fetch --prune --tags origin
+git -C "$mirror" -c gc.auto=6700 -c gc.autoDetach=false gc --auto --quiet`, workspaceCacheMirrorPath, remoteGit)
+
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      WorkspaceCacheName(workspace.Name),
+			Namespace: workspace.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: ptr.To[int32](1),
+			FailedJobsHistoryLimit:     ptr.To[int32](1),
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: ptr.To[int32](2),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyOnFailure,
+							SecurityContext: &corev1.PodSecurityContext{
+								FSGroup: &agentUID,
+							},
+							Containers: []corev1.Container{{
+								Name:         "git-cache-refresh",
+								Image:        GitCloneImage,
+								Command:      []string{"sh", "-c", script},
+								Args:         []string{"--", workspace.Spec.Repo},
+								VolumeMounts: mounts,
+								SecurityContext: &corev1.SecurityContext{
+									RunAsUser: &agentUID,
+								},
+							}},
+							Volumes: volumes,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// reconcileCache creates the git mirror PersistentVolumeClaim and refresh
+// CronJob of a Workspace with a cache, and deletes them when the cache is
+// disabled. An existing PersistentVolumeClaim is left unchanged because its
+// storage class and access modes are immutable.
+func (r *WorkspaceReconciler) reconcileCache(ctx context.Context, workspace *kelos.Workspace) error {
+	if workspace.Spec.Cache == nil {
+		return r.deleteCacheResources(ctx, workspace)
+	}
+
+	pvc := buildWorkspaceCachePVC(workspace)
+	if err := controllerutil.SetControllerReference(workspace, pvc, r.Scheme); err != nil {
+		return err
+	}
+	var currentPVC corev1.PersistentVolumeClaim
+	err := r.Get(ctx, client.ObjectKeyFromObject(pvc), &currentPVC)
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, pvc); err != nil {
+			return err
+		}
+		r.recordEvent(workspace, corev1.EventTypeNormal, "WorkspaceCacheVolumeCreated", "Created git mirror PersistentVolumeClaim %s", pvc.Name)
+	} else if err != nil {
+		return err
+	}
+
+	desired := buildWorkspaceCacheCronJob(workspace)
+	if err := controllerutil.SetControllerReference(workspace, desired, r.Scheme); err != nil {
+		return err
+	}
+	var current batchv1.CronJob
+	err = r.Get(ctx, client.ObjectKeyFromObject(desired), &current)
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		r.recordEvent(workspace, corev1.EventTypeNormal, "WorkspaceCacheCronJobCreated", "Created git mirror refresh CronJob %s", desired.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if current.Spec.Schedule == desired.Spec.Schedule &&
+		reflect.DeepEqual(current.Labels, desired.Labels) &&
+		containersEqual(current.Spec.JobTemplate.Spec.Template.Spec.Containers, desired.Spec.JobTemplate.Spec.Template.Spec.Containers) &&
+		reflect.DeepEqual(current.Spec.JobTemplate.Spec.Template.Spec.Volumes, desired.Spec.JobTemplate.Spec.Template.Spec.Volumes) {
+		return nil
+	}
+	current.Labels = desired.Labels
+	current.Spec.Schedule = desired.Spec.Schedule
+	current.Spec.JobTemplate = desired.Spec.JobTemplate
+	if err := r.Update(ctx, &current); err != nil {
+		return err
+	}
+	r.recordEvent(workspace, corev1.EventTypeNormal, "WorkspaceCacheCronJobUpdated", "Updated git mirror refresh CronJob %s", desired.Name)
+	return nil
+}
+
+func (r *WorkspaceReconciler) deleteCacheResources(ctx context.Context, workspace *kelos.Workspace) error {
+	name := WorkspaceCacheName(workspace.Name)
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workspace.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workspace.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// workspaceCacheReference returns the git mirror a Task pod clones with as a
+// reference, or "" when the Task's Workspace has no cache.
+func workspaceCacheReference(task *kelos.Task, workspace *kelos.WorkspaceSpec) string {
+	if workspace == nil || workspace.Cache == nil || resolveTaskWorkspaceRef(task) == nil {
+		return ""
+	}
+	return workspaceCacheMirrorPath
+}
+
+// workspaceCacheVolume returns the read-only volume of the git mirror of the
+// named Workspace.
+func workspaceCacheVolume(workspaceName string) corev1.Volume {
+	return corev1.Volume{
+		Name: WorkspaceCacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: WorkspaceCacheName(workspaceName),
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// gitCloneDuration returns how long the git-clone init container of pod ran,
+// if it completed successfully.
+func gitCloneDuration(pod *corev1.Pod) (float64, bool) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != "git-clone" {
+			continue
+		}
+		terminated := status.State.Terminated
+		if terminated == nil || terminated.ExitCode != 0 || terminated.StartedAt.IsZero() || terminated.FinishedAt.IsZero() {
+			return 0, false
+		}
+		return terminated.FinishedAt.Sub(terminated.StartedAt.Time).Seconds(), true
+	}
+	return 0, false
+}
+
+// podUsesWorkspaceCache reports whether pod mounts a Workspace git mirror.
+func podUsesWorkspaceCache(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == WorkspaceCacheVolumeName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newWorkspaceCacheTestWorkspace() *kelos.Workspace {
+	storage := resource.MustParse("20Gi")
+	return &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "monorepo", Namespace: "default", UID: "ws-uid"},
+		Spec: kelos.WorkspaceSpec{
+			Repo:      "https://github.com/org/monorepo.git",
+			SecretRef: &kelos.SecretReference{Name: "github-token"},
+			Cache:     &kelos.WorkspaceCache{Storage: &storage, RefreshSchedule: "*/5 * * * *"},
+		},
+	}
+}
+
+func TestWorkspaceReconciler_CreatesCacheResources(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := newWorkspaceCacheTestWorkspace()
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace).Build()
+	r := &WorkspaceReconciler{Client: cl, Scheme: scheme, ProxyBuilder: NewWorkspaceGHProxyBuilder()}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "monorepo"},
+	}); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	key := client.ObjectKey{Namespace: "default", Name: WorkspaceCacheName("monorepo")}
+	var pvc corev1.PersistentVolumeClaim
+	if err := cl.Get(context.Background(), key, &pvc); err != nil {
+		t.Fatalf("getting cache PVC: %v", err)
+	}
+	if got := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; got.String() != "20Gi" {
+		t.Errorf("PVC storage = %s, want 20Gi", got.String())
+	}
+	if len(pvc.Spec.AccessModes) != 1 || pvc.Spec.AccessModes[0] != corev1.ReadWriteMany {
+		t.Errorf("PVC access modes = %v, want [ReadWriteMany]", pvc.Spec.AccessModes)
+	}
+	if len(pvc.OwnerReferences) != 1 || pvc.OwnerReferences[0].UID != workspace.UID {
+		t.Errorf("PVC owner references = %v, want the Workspace", pvc.OwnerReferences)
+	}
+
+	var cronJob batchv1.CronJob
+	if err := cl.Get(context.Background(), key, &cronJob); err != nil {
+		t.Fatalf("getting cache CronJob: %v", err)
+	}
+	if cronJob.Spec.Schedule != "*/5 * * * *" {
+		t.Errorf("CronJob schedule = %q, want */5 * * * *", cronJob.Spec.Schedule)
+	}
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	if got := strings.Join(container.Args, " "); got != "-- https://github.com/org/monorepo.git" {
+		t.Errorf("refresh args = %q, want the Workspace repo", got)
+	}
+	for _, want := range []string{
+		"mirror=" + workspaceCacheMirrorPath,
+		`mv "$mirror.tmp" "$mirror"`,
+		"-c credential.username=" + gitCredentialDefaultUsername + ` -C "$mirror" fetch --prune --tags origin`,
+		`git -C "$mirror" config gc.auto 0`,
+		`git -C "$mirror" -c gc.auto=6700 -c gc.autoDetach=false gc --auto --quiet`,
+	} {
+		if !strings.Contains(container.Command[2], want) {
+			t.Errorf("refresh script should contain %q, got %q", want, container.Command[2])
+		}
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(workspace), workspace); err != nil {
+		t.Fatal(err)
+	}
+	workspace.Spec.Cache = nil
+	if err := cl.Update(context.Background(), workspace); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "monorepo"},
+	}); err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if err := cl.Get(context.Background(), key, &corev1.PersistentVolumeClaim{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the cache PVC to be deleted, got %v", err)
+	}
+	if err := cl.Get(context.Background(), key, &batchv1.CronJob{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the cache CronJob to be deleted, got %v", err)
+	}
+}
+
+func TestBuildJob_WorkspaceCache(t *testing.T) {
+	workspace := newWorkspaceCacheTestWorkspace()
+	task := newWorkspaceRepositoriesTestTask()
+	task.Spec.WorkspaceRef = &kelos.WorkspaceReference{Name: workspace.Name}
+
+	job, err := NewJobBuilder().Build(task, &workspace.Spec, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	podSpec := job.Spec.Template.Spec
+
+	clone := initContainerByName(podSpec.InitContainers, "git-clone")
+	if !strings.Contains(strings.Join(clone.Args, " "), "--reference-if-able "+workspaceCacheMirrorPath+" --dissociate --") {
+		t.Errorf("git-clone args = %v, want the mirror as a reference", clone.Args)
+	}
+	var mounted bool
+	for _, m := range clone.VolumeMounts {
+		if m.Name == WorkspaceCacheVolumeName && m.MountPath == WorkspaceCacheMountPath && m.ReadOnly {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("git-clone volume mounts = %v, want the mirror read-only", clone.VolumeMounts)
+	}
+	for _, m := range podSpec.Containers[0].VolumeMounts {
+		if m.Name == WorkspaceCacheVolumeName {
+			t.Error("the agent container should not mount the mirror")
+		}
+	}
+	var volume *corev1.Volume
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == WorkspaceCacheVolumeName {
+			volume = &podSpec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != WorkspaceCacheName(workspace.Name) {
+		t.Fatalf("expected a volume for the cache PVC, got %+v", volume)
+	}
+}
+
+func TestBuildJob_WorkspaceCacheCommitRef(t *testing.T) {
+	workspace := newWorkspaceCacheTestWorkspace()
+	workspace.Spec.Ref = "c44211cb54d861d9445de16a0e8ce96d7f29637d"
+	task := newWorkspaceRepositoriesTestTask()
+	task.Spec.WorkspaceRef = &kelos.WorkspaceReference{Name: workspace.Name}
+
+	job, err := NewJobBuilder().Build(task, &workspace.Spec, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	script := initContainerByName(job.Spec.Template.Spec.InitContainers, "git-clone").Command[2]
+	alternates := strings.Index(script, `>> "$target/.git/objects/info/alternates"`)
+	fetch := strings.Index(script, `fetch --depth 1 origin "$ref"`)
+	dissociate := strings.Index(script, `repack -a -d -q && rm -f "$target/.git/objects/info/alternates"`)
+	if alternates < 0 || fetch < 0 || dissociate < 0 || !(alternates < fetch && fetch < dissociate) {
+		t.Errorf("commit checkout script should borrow from the mirror before fetching and dissociate after, got %q", script)
+	}
+}
+
+func TestBuildJob_WorkspaceCacheWithoutWorkspaceRef(t *testing.T) {
+	workspace := newWorkspaceCacheTestWorkspace()
+	task := newWorkspaceRepositoriesTestTask()
+
+	job, err := NewJobBuilder().Build(task, &workspace.Spec, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	for _, v := range job.Spec.Template.Spec.Volumes {
+		if v.Name == WorkspaceCacheVolumeName {
+			t.Fatal("a Task without a Workspace reference cannot locate the mirror")
+		}
+	}
+}
+
+func TestGitCloneDuration(t *testing.T) {
+	start := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	pod := &corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{{
+		Name: "git-clone",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode:   0,
+			StartedAt:  start,
+			FinishedAt: metav1.NewTime(start.Add(12 * time.Second)),
+		}},
+	}}}}
+	if got, ok := gitCloneDuration(pod); !ok || got != 12 {
+		t.Errorf("gitCloneDuration() = %v, %v, want 12, true", got, ok)
+	}
+
+	pod.Status.InitContainerStatuses[0].State.Terminated.ExitCode = 128
+	if _, ok := gitCloneDuration(pod); ok {
+		t.Error("a failed clone should not report a duration")
+	}
+}
+
+func TestWorkspaceCacheName_TruncatesLongWorkspaceNames(t *testing.T) {
+	name := WorkspaceCacheName(strings.Repeat("a", 70))
+	if len(name) > 52 {
+		t.Fatalf("expected truncated name length <= 52, got %d", len(name))
+	}
+	if !strings.HasPrefix(name, "git-cache-") {
+		t.Fatalf("expected git-cache prefix, got %q", name)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+// workspaceCloneDepth returns the history depth to fetch for clone, or 0 for
+// the full history.
+func workspaceCloneDepth(clone *kelos.WorkspaceCloneOptions) int32 {
+	if clone == nil || clone.Depth == nil {
+		return 1
+	}
+	return *clone.Depth
+}
+
+// workspaceCloneArgs returns the git arguments that clone repoURL into
+// targetPath. ref is passed as --branch unless it is a commit SHA, which is
+// fetched separately by buildCommitRefCheckoutScript. When reference is set,
+// objects are borrowed from that local repository if it exists and copied
+// into the clone so it does not depend on the reference afterwards.
+func workspaceCloneArgs(repoURL, ref, targetPath, reference string, clone *kelos.WorkspaceCloneOptions) []string {
+	args := []string{"clone"}
+	if ref != "" && !isFullGitCommitSHA(ref) {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--no-single-branch")
+	if depth := workspaceCloneDepth(clone); depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(int(depth)))
+	}
+	if clone != nil && clone.Filter != "" {
+		args = append(args, "--filter="+clone.Filter)
+	}
+	if clone != nil && len(clone.SparseCheckout) > 0 {
+		args = append(args, "--sparse")
+	}
+	if reference != "" {
+		args = append(args, "--reference-if-able", reference, "--dissociate")
+	}
+	return append(args, "--", repoURL, targetPath)
+}
+
+// workspaceFetchDepthFlag returns the --depth flag for fetches that extend
+// an existing clone. It is empty unless a depth was configured, so those
+// fetches keep git's default of deepening to the existing shallow boundary.
+func workspaceFetchDepthFlag(clone *kelos.WorkspaceCloneOptions) string {
+	if clone == nil || clone.Depth == nil || *clone.Depth == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" --depth %d", *clone.Depth)
+}
+
+// remoteGitCommand returns the git command used for operations that contact
+// the remote, authenticating with credentialHelper when it is set.
+func remoteGitCommand(credentialHelper string) string {
+	if credentialHelper == "" {
+		return "git"
+	}
+	return fmt.Sprintf(
+		`git -c credential.helper= -c credential.helper='%s' -c credential.username=%s`,
+		credentialHelper, gitCredentialDefaultUsername,
+	)
+}
+
+// sparseCheckoutCommand returns the command that restricts the work tree of
+// the repository in dir to the cone-mode paths of clone, or "" when sparse
+// checkout is not configured.
+func sparseCheckoutCommand(dir string, clone *kelos.WorkspaceCloneOptions) string {
+	if clone == nil || len(clone.SparseCheckout) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(clone.SparseCheckout))
+	for _, p := range clone.SparseCheckout {
+		quoted = append(quoted, shellQuote(p))
+	}
+	return fmt.Sprintf("git -C %s sparse-checkout set --cone -- %s", dir, strings.Join(quoted, " "))
+}
+
+// workspaceCheckoutCommands returns the commands that complete a checkout of
+// the repository in dir: updating submodules and fetching Git LFS objects as
+// configured by clone. remoteGit is the git command returned by
+// remoteGitCommand.
+func workspaceCheckoutCommands(dir, remoteGit string, clone *kelos.WorkspaceCloneOptions) []string {
+	if clone == nil {
+		return nil
+	}
+	var cmds []string
+	if clone.Submodules {
+		submodule := fmt.Sprintf("%s -C %s submodule update --init --recursive", remoteGit, dir)
+		if depth := workspaceCloneDepth(clone); depth > 0 {
+			submodule += fmt.Sprintf(" --depth %d", depth)
+		}
+		cmds = append(cmds, submodule)
+	}
+	if clone.LFS != nil {
+		cmds = append(cmds, fmt.Sprintf("git -C %s lfs install --local", dir))
+		if len(clone.LFS.Include) > 0 {
+			cmds = append(cmds, fmt.Sprintf("git -C %s config lfs.fetchinclude %s", dir, shellQuote(strings.Join(clone.LFS.Include, ","))))
+		}
+		if len(clone.LFS.Exclude) > 0 {
+			cmds = append(cmds, fmt.Sprintf("git -C %s config lfs.fetchexclude %s", dir, shellQuote(strings.Join(clone.LFS.Exclude, ","))))
+		}
+		cmds = append(cmds, fmt.Sprintf("%s -C %s lfs pull", remoteGit, dir))
+	}
+	return cmds
+}
+
+// workspaceCloneFinishScript returns the commands that run after git clone
+// of the repository in dir: the sparse checkout followed by
+// workspaceCheckoutCommands, joined with &&. It is empty when clone needs no
+// post-clone steps.
+func workspaceCloneFinishScript(dir, credentialHelper string, clone *kelos.WorkspaceCloneOptions) string {
+	var cmds []string
+	if sparse := sparseCheckoutCommand(dir, clone); sparse != "" {
+		cmds = append(cmds, sparse)
+	}
+	cmds = append(cmds, workspaceCheckoutCommands(dir, remoteGitCommand(credentialHelper), clone)...)
+	return strings.Join(cmds, " && ")
+}
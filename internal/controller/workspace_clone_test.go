@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newWorkspaceCloneTestWorkspace(ref string) *kelos.WorkspaceSpec {
+	return &kelos.WorkspaceSpec{
+		Repo:      "https://github.com/org/monorepo.git",
+		Ref:       ref,
+		SecretRef: &kelos.SecretReference{Name: "github-token"},
+		Clone: &kelos.WorkspaceCloneOptions{
+			Depth:          ptr.To[int32](10),
+			SparseCheckout: []string{"services/api", "libs/common"},
+			Submodules:     true,
+			LFS:            &kelos.WorkspaceCloneLFS{Include: []string{"assets/**"}, Exclude: []string{"*.psd"}},
+			Filter:         "blob:none",
+		},
+	}
+}
+
+func TestBuildJob_WorkspaceCloneOptions(t *testing.T) {
+	task := newWorkspaceRepositoriesTestTask()
+	job, err := NewJobBuilder().Build(task, newWorkspaceCloneTestWorkspace("main"), nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	containers := job.Spec.Template.Spec.InitContainers
+	target := WorkspaceMountPath + "/repo"
+
+	clone := initContainerByName(containers, "git-clone")
+	wantArgs := "-- clone --branch main --no-single-branch --depth 10 --filter=blob:none --sparse -- https://github.com/org/monorepo.git " + target
+	if got := strings.Join(clone.Args, " "); got != wantArgs {
+		t.Errorf("git-clone args = %q, want %q", got, wantArgs)
+	}
+	script := clone.Command[2]
+	for _, want := range []string{
+		"git -C " + target + " sparse-checkout set --cone -- 'services/api' 'libs/common'",
+		"-C " + target + " submodule update --init --recursive --depth 10",
+		"git -C " + target + " lfs install --local",
+		"git -C " + target + " config lfs.fetchinclude 'assets/**'",
+		"git -C " + target + " config lfs.fetchexclude '*.psd'",
+		"-c credential.username=" + gitCredentialDefaultUsername + " -C " + target + " lfs pull",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("git-clone script should contain %q, got %q", want, script)
+		}
+	}
+	if strings.Index(script, "sparse-checkout") > strings.Index(script, "submodule update") {
+		t.Errorf("sparse checkout should be configured before submodules are updated, got %q", script)
+	}
+
+	branch := initContainerByName(containers, "branch-setup")
+	for _, want := range []string{
+		`fetch --depth 10 origin "refs/heads/$KELOS_BRANCH"`,
+		"submodule update --init --recursive --depth 10",
+		"lfs pull",
+	} {
+		if !strings.Contains(branch.Command[2], want) {
+			t.Errorf("branch-setup script should contain %q, got %q", want, branch.Command[2])
+		}
+	}
+}
+
+func TestBuildJob_WorkspaceCloneOptionsCommitRef(t *testing.T) {
+	task := newWorkspaceRepositoriesTestTask()
+	task.Spec.Branch = ""
+	workspace := newWorkspaceCloneTestWorkspace("c44211cb54d861d9445de16a0e8ce96d7f29637d")
+	workspace.Clone.Depth = ptr.To[int32](0)
+	job, err := NewJobBuilder().Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	script := initContainerByName(job.Spec.Template.Spec.InitContainers, "git-clone").Command[2]
+	wantOrder := []string{
+		`git -C "$target" config extensions.partialClone origin`,
+		`git -C "$target" config remote.origin.partialclonefilter 'blob:none'`,
+		`git -C "$target" sparse-checkout set --cone -- 'services/api' 'libs/common'`,
+		` fetch --filter=blob:none origin "$ref"`,
+		`git -C "$target" checkout --detach FETCH_HEAD`,
+		`-C "$target" submodule update --init --recursive`,
+		`-C "$target" lfs pull`,
+	}
+	last := -1
+	for _, want := range wantOrder {
+		i := strings.Index(script, want)
+		if i < 0 {
+			t.Fatalf("commit checkout script should contain %q, got %q", want, script)
+		}
+		if i < last {
+			t.Errorf("commit checkout script runs %q out of order: %q", want, script)
+		}
+		last = i
+	}
+	if strings.Contains(script, "--depth") {
+		t.Errorf("a depth of 0 should fetch the full history, got %q", script)
+	}
+}
+
+func TestBuildJob_WorkspaceDefaultCloneUnchanged(t *testing.T) {
+	task := newWorkspaceRepositoriesTestTask()
+	workspace := &kelos.WorkspaceSpec{Repo: "https://github.com/org/repo.git"}
+	job, err := NewJobBuilder().Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	containers := job.Spec.Template.Spec.InitContainers
+
+	clone := initContainerByName(containers, "git-clone")
+	if len(clone.Command) != 0 {
+		t.Errorf("git-clone command = %v, want the image entrypoint", clone.Command)
+	}
+	branch := initContainerByName(containers, "branch-setup")
+	if strings.Contains(branch.Command[2], "--depth") || strings.Contains(branch.Command[2], "submodule") {
+		t.Errorf("branch-setup script should not be tuned by default, got %q", branch.Command[2])
+	}
+}
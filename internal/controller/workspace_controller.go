@@ -6,6 +6,7 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,23 +22,35 @@ import (
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 	"github.com/kelos-dev/kelos/internal/githubapp"
+	"github.com/kelos-dev/kelos/internal/inuse"
 )
 
-// WorkspaceReconciler reconciles workspace-scoped ghproxy resources.
+// WorkspaceReconciler reconciles workspace-scoped ghproxy and git mirror
+// resources and validates the Workspace repository.
 type WorkspaceReconciler struct {
 	client.Client
 	Scheme       *runtime.Scheme
 	ProxyBuilder *WorkspaceGHProxyBuilder
 	Recorder     record.EventRecorder
+	// Validator maintains the status conditions of Workspaces. When nil,
+	// Workspaces are not validated.
+	Validator *WorkspaceValidator
 }
 
-// +kubebuilder:rbac:groups=kelos.dev,resources=workspaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kelos.dev,resources=workspaces,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=workspaces/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kelos.dev,resources=workspaces/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kelos.dev,resources=tasks;sessions;workerpools;taskspawners;sessionspawners;pipelines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
-// Reconcile ensures each Workspace has the requested ghproxy resources.
+// Reconcile ensures each Workspace has the requested ghproxy and git mirror
+// resources and up-to-date status conditions, and keeps deleted Workspaces
+// while they are in use.
 func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -51,12 +64,31 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	removed, protection, err := reconcileInUseProtection(ctx, r.Client, r.Recorder, &workspace, inuse.WorkspaceProtectionFinalizer, func() ([]inuse.User, error) {
+		return inuse.WorkspaceUsers(ctx, r.Client, workspace.Namespace, workspace.Name)
+	})
+	if removed || err != nil {
+		return protection, err
+	}
+
+	if err := r.reconcileCache(ctx, &workspace); err != nil {
+		logger.Error(err, "Unable to reconcile workspace git mirror", "workspace", workspace.Name)
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileStatus(ctx, &workspace)
+	if err != nil {
+		logger.Error(err, "Unable to update Workspace status", "workspace", workspace.Name)
+		return ctrl.Result{}, err
+	}
+	result = mergeReconcileResults(result, protection)
+
 	if !workspaceUsesGHProxy(&workspace.Spec) {
 		if err := r.deleteProxyResources(ctx, &workspace); err != nil {
 			logger.Error(err, "Unable to delete disabled workspace proxy resources", "workspace", workspace.Name)
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		return result, nil
 	}
 
 	isGitHubApp := false
@@ -83,7 +115,34 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
+}
+
+// reconcileStatus validates the Workspace when it is due and requeues it for
+// the next validation.
+func (r *WorkspaceReconciler) reconcileStatus(ctx context.Context, workspace *kelos.Workspace) (ctrl.Result, error) {
+	if r.Validator == nil {
+		return ctrl.Result{}, nil
+	}
+	if wait := r.Validator.nextValidation(workspace, time.Now()); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	previous := workspaceReadyCondition(workspace)
+	workspace.Status = r.Validator.Validate(ctx, r.Client, workspace)
+	if err := r.Status().Update(ctx, workspace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if ready := workspaceReadyCondition(workspace); ready != nil && (previous == nil || previous.Status != ready.Status) {
+		switch ready.Status {
+		case metav1.ConditionFalse:
+			r.recordEvent(workspace, corev1.EventTypeWarning, "WorkspaceNotReady", "Workspace is not ready: %s", ready.Message)
+		case metav1.ConditionTrue:
+			r.recordEvent(workspace, corev1.EventTypeNormal, "WorkspaceReady", "Workspace is ready")
+		}
+	}
+	return ctrl.Result{RequeueAfter: r.Validator.revalidateAfter(&workspace.Status)}, nil
 }
 
 func (r *WorkspaceReconciler) deleteProxyResources(ctx context.Context, workspace *kelos.Workspace) error {
@@ -216,6 +275,8 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&kelos.Workspace{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&batchv1.CronJob{}).
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findWorkspacesForSecret)).
 		Complete(r)
 }
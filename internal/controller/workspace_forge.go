@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
+)
+
+// GitLabTokenSecretKey is the Secret key under which the access token of a
+// GitLab Workspace is stored. Mounted as a file at
+// GitHubTokenMountPath + "/" + GitLabTokenSecretKey.
+const GitLabTokenSecretKey = "GITLAB_TOKEN"
+
+// workspaceIsGitLab reports whether the repositories of workspace are hosted
+// on GitLab.
+func workspaceIsGitLab(workspace *kelos.WorkspaceSpec) bool {
+	return workspace != nil && workspace.Forge == kelos.ForgeGitLab
+}
+
+// workspaceTokenSecretKey returns the key of the Workspace's SecretRef that
+// holds the token for its forge.
+func workspaceTokenSecretKey(workspace *kelos.WorkspaceSpec) string {
+	if workspaceIsGitLab(workspace) {
+		return GitLabTokenSecretKey
+	}
+	return GitHubTokenSecretKey
+}
+
+// workspaceCredentialHelper returns the inline git credential helper that
+// authenticates with the token of the Workspace's forge. See
+// gitCredentialHelper.
+func workspaceCredentialHelper(workspace *kelos.WorkspaceSpec) string {
+	if workspaceIsGitLab(workspace) {
+		return gitCredentialHelperForToken(GitHubTokenMountPath+"/"+GitLabTokenSecretKey, GitLabTokenSecretKey)
+	}
+	return gitCredentialHelper()
+}
+
+// workspaceTokenVolume returns the volume that mounts the token of the
+// Workspace's SecretRef at GitHubTokenMountPath. The Secret is optional so
+// that pods start while a GitHub App token Secret is still being created.
+func workspaceTokenVolume(workspace *kelos.WorkspaceSpec) corev1.Volume {
+	key := workspaceTokenSecretKey(workspace)
+	return corev1.Volume{
+		Name: GitHubTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: workspace.SecretRef.Name,
+				Items:      []corev1.KeyToPath{{Key: key, Path: key}},
+				Optional:   ptr.To(true),
+			},
+		},
+	}
+}
+
+// parseGitLabProject returns the host and the project path with namespace of
+// a GitLab repository URL. GitLab projects may be nested in subgroups, so
+// the whole path names the project, e.g. "group/subgroup/project".
+func parseGitLabProject(repoURL string) (host, project string) {
+	repoURL = strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+	if rest, ok := strings.CutPrefix(repoURL, "git@"); ok {
+		host, project, _ = strings.Cut(rest, ":")
+		return host, project
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", ""
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/")
+}
+
+// gitLabWorkspaceEnvVars returns the env vars of a GitLab Workspace: agentEnv
+// points glab and kelos-capture at the project and, with workspaceEnv,
+// exposes the token to the agent and the init containers.
+func gitLabWorkspaceEnvVars(workspace *kelos.WorkspaceSpec) (agentEnv, workspaceEnv []corev1.EnvVar) {
+	host, project := parseGitLabProject(workspace.Repo)
+	agentEnv = []corev1.EnvVar{
+		{Name: "GITLAB_HOST", Value: host},
+		{Name: forge.EnvForge, Value: forge.TypeGitLab},
+		{Name: forge.EnvAPIURL, Value: forge.GitLabAPIBaseURL(host)},
+		{Name: forge.EnvProject, Value: project},
+	}
+	if workspace.SecretRef != nil {
+		tokenEnv := corev1.EnvVar{
+			Name: forge.EnvGitLabToken,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: workspace.SecretRef.Name},
+					Key:                  GitLabTokenSecretKey,
+				},
+			},
+		}
+		agentEnv = append(agentEnv, tokenEnv)
+		workspaceEnv = append(workspaceEnv, tokenEnv)
+	}
+	return agentEnv, workspaceEnv
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseGitLabProject(t *testing.T) {
+	tests := []struct {
+		repoURL     string
+		wantHost    string
+		wantProject string
+	}{
+		{"https://gitlab.com/group/project.git", "gitlab.com", "group/project"},
+		{"https://gitlab.example.com/group/sub/project", "gitlab.example.com", "group/sub/project"},
+		{"git@gitlab.com:group/sub/project.git", "gitlab.com", "group/sub/project"},
+		{"https://gitlab.com/group/project/", "gitlab.com", "group/project"},
+	}
+	for _, tt := range tests {
+		host, project := parseGitLabProject(tt.repoURL)
+		if host != tt.wantHost || project != tt.wantProject {
+			t.Errorf("parseGitLabProject(%q) = %q, %q, want %q, %q", tt.repoURL, host, project, tt.wantHost, tt.wantProject)
+		}
+	}
+}
+
+func TestBuildClaudeCodeJob_GitLabWorkspace(t *testing.T) {
+	builder := NewJobBuilder()
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-gitlab",
+			Namespace: "default",
+		},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Fix the bug",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+		},
+	}
+	workspace := &kelos.WorkspaceSpec{
+		Repo:      "https://gitlab.example.com/group/sub/service.git",
+		Forge:     kelos.ForgeGitLab,
+		SecretRef: &kelos.SecretReference{Name: "gitlab-token"},
+	}
+
+	job, err := builder.Build(task, workspace, nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	container := job.Spec.Template.Spec.Containers[0]
+	envMap := map[string]string{}
+	for _, env := range container.Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			envMap[env.Name] = env.ValueFrom.SecretKeyRef.Name + "/" + env.ValueFrom.SecretKeyRef.Key
+		} else {
+			envMap[env.Name] = env.Value
+		}
+	}
+	for name, want := range map[string]string{
+		"GITLAB_HOST":         "gitlab.example.com",
+		"GITLAB_TOKEN":        "gitlab-token/GITLAB_TOKEN",
+		"KELOS_FORGE":         "gitlab",
+		"KELOS_FORGE_API_URL": "https://gitlab.example.com/api/v4",
+		"KELOS_FORGE_PROJECT": "group/sub/service",
+	} {
+		if envMap[name] != want {
+			t.Errorf("%s = %q, want %q", name, envMap[name], want)
+		}
+	}
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN", "GH_ENTERPRISE_TOKEN", "GH_HOST"} {
+		if _, ok := envMap[name]; ok {
+			t.Errorf("%s should not be set for a GitLab workspace", name)
+		}
+	}
+
+	var tokenItems []string
+	for _, v := range job.Spec.Template.Spec.Volumes {
+		if v.Name == GitHubTokenVolumeName && v.Secret != nil {
+			for _, item := range v.Secret.Items {
+				tokenItems = append(tokenItems, item.Key)
+			}
+		}
+	}
+	if len(tokenItems) != 1 || tokenItems[0] != GitLabTokenSecretKey {
+		t.Errorf("Token volume items = %v, want [%s]", tokenItems, GitLabTokenSecretKey)
+	}
+
+	script := strings.Join(job.Spec.Template.Spec.InitContainers[0].Command, " ")
+	if !strings.Contains(script, GitHubTokenMountPath+"/"+GitLabTokenSecretKey) || !strings.Contains(script, "$GITLAB_TOKEN") {
+		t.Errorf("Expected the clone credential helper to read the GitLab token, got %q", script)
+	}
+}
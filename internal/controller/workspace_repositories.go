@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/capture"
+)
+
+// WorkspaceRepositoryTokenMountDir is the directory under which the token
+// Secrets of additional workspace repositories are mounted, one
+// subdirectory per repository name.
+const WorkspaceRepositoryTokenMountDir = "/kelos/repository-tokens"
+
+// workspaceRepositoryDir returns the absolute path of the clone of an
+// additional workspace repository.
+func workspaceRepositoryDir(repo kelos.WorkspaceRepository) string {
+	path := repo.Path
+	if path == "" {
+		path = repo.Name
+	}
+	return WorkspaceMountPath + "/" + path
+}
+
+// workspaceRepositoryPod holds the parts of a Task pod that prepare the
+// additional repositories of its Workspace.
+type workspaceRepositoryPod struct {
+	initContainers    []corev1.Container
+	volumes           []corev1.Volume
+	agentVolumeMounts []corev1.VolumeMount
+	agentEnv          []corev1.EnvVar
+}
+
+// buildWorkspaceRepositories returns the init containers that clone each
+// additional repository of workspace, configure its remotes and check out
+// the Task branch, together with the token volumes and the agent env that
+// tells kelos-capture where the repositories are. workspaceEnv and
+// workspaceVolumeMounts are those of the primary repository's init
+// containers.
+func buildWorkspaceRepositories(task *kelos.Task, workspace *kelos.WorkspaceSpec, workspaceEnv []corev1.EnvVar, workspaceVolumeMounts []corev1.VolumeMount, agentUID int64) (*workspaceRepositoryPod, error) {
+	pod := &workspaceRepositoryPod{}
+	tokenKey := workspaceTokenSecretKey(workspace)
+	captureRepos := make([]capture.Repository, 0, len(workspace.Repositories))
+
+	for _, repo := range workspace.Repositories {
+		dir := workspaceRepositoryDir(repo)
+		mounts := append([]corev1.VolumeMount(nil), workspaceVolumeMounts...)
+
+		credentialHelper := ""
+		switch {
+		case repo.SecretRef != nil:
+			mountDir := WorkspaceRepositoryTokenMountDir + "/" + repo.Name
+			volumeName := kelos.ReservedVolumeNamePrefix + "repo-token-" + repo.Name
+			pod.volumes = append(pod.volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: repo.SecretRef.Name,
+						Items: []corev1.KeyToPath{
+							{Key: tokenKey, Path: tokenKey},
+						},
+						Optional: ptr.To(true),
+					},
+				},
+			})
+			mount := corev1.VolumeMount{Name: volumeName, MountPath: mountDir, ReadOnly: true}
+			mounts = append(mounts, mount)
+			pod.agentVolumeMounts = append(pod.agentVolumeMounts, mount)
+			credentialHelper = gitCredentialHelperForToken(mountDir+"/"+tokenKey, tokenKey)
+		case workspace.SecretRef != nil:
+			credentialHelper = workspaceCredentialHelper(workspace)
+		}
+
+		pod.initContainers = append(pod.initContainers,
+			gitCloneContainer(kelos.ReservedContainerNamePrefix+"git-clone-"+repo.Name, repo.Repo, repo.Ref, dir, credentialHelper, "", nil, workspaceEnv, mounts, agentUID))
+
+		if len(repo.Remotes) > 0 {
+			pod.initContainers = append(pod.initContainers, corev1.Container{
+				Name:         kelos.ReservedContainerNamePrefix + "remote-setup-" + repo.Name,
+				Image:        GitCloneImage,
+				Command:      []string{"sh", "-c", remoteSetupScript(dir, repo.Remotes)},
+				VolumeMounts: append([]corev1.VolumeMount(nil), mounts...),
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser: ptr.To(agentUID),
+				},
+			})
+		}
+
+		if taskBranch(task) != "" {
+			branchEnv := append(append([]corev1.EnvVar(nil), workspaceEnv...), corev1.EnvVar{
+				Name:  "KELOS_BRANCH",
+				Value: taskBranch(task),
+			})
+			pod.initContainers = append(pod.initContainers, corev1.Container{
+				Name:         kelos.ReservedContainerNamePrefix + "branch-setup-" + repo.Name,
+				Image:        GitCloneImage,
+				Command:      []string{"sh", "-c", branchSetupScript(dir, credentialHelper, nil)},
+				Env:          branchEnv,
+				VolumeMounts: mounts,
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser: ptr.To(agentUID),
+				},
+			})
+		}
+
+		captureRepo := capture.Repository{Name: repo.Name, Path: dir, BaseBranch: repo.Ref}
+		if host, owner, name := parseGitHubRepo(repo.Repo); !workspaceIsGitLab(workspace) && owner != "" && name != "" {
+			captureRepo.GitHubRepo = owner + "/" + name
+			if host != "" && host != "github.com" {
+				captureRepo.GitHubRepo = host + "/" + captureRepo.GitHubRepo
+			}
+		}
+		captureRepos = append(captureRepos, captureRepo)
+	}
+
+	reposJSON, err := json.Marshal(captureRepos)
+	if err != nil {
+		return nil, fmt.Errorf("encoding workspace repositories: %w", err)
+	}
+	pod.agentEnv = []corev1.EnvVar{{Name: capture.EnvRepositories, Value: string(reposJSON)}}
+	return pod, nil
+}
+
+// gitCloneContainer returns an init container that clones repoURL into
+// targetPath and checks out ref, tuned by clone and borrowing objects from
+// the local repository reference when it is set. When credentialHelper is
+// set, inherited credential helpers are cleared with an empty
+// -c credential.helper= before it is used, and it is persisted into the
+// repository's git config so the agent container is independent from
+// global/system helpers.
+func gitCloneContainer(name, repoURL, ref, targetPath, credentialHelper, reference string, clone *kelos.WorkspaceCloneOptions, env []corev1.EnvVar, mounts []corev1.VolumeMount, agentUID int64) corev1.Container {
+	commitRef := isFullGitCommitSHA(ref)
+	cloneArgs := workspaceCloneArgs(repoURL, ref, targetPath, reference, clone)
+	finish := workspaceCloneFinishScript(targetPath, credentialHelper, clone)
+	if finish != "" {
+		finish = " && { " + finish + "; }"
+	}
+
+	container := corev1.Container{
+		Name:         name,
+		Image:        GitCloneImage,
+		Args:         cloneArgs,
+		Env:          env,
+		VolumeMounts: append([]corev1.VolumeMount(nil), mounts...),
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: ptr.To(agentUID),
+		},
+	}
+	switch {
+	case commitRef:
+		container.Command = []string{"sh", "-c", buildCommitRefCheckoutScript(credentialHelper, reference, clone)}
+		container.Args = []string{"--", repoURL, targetPath, ref}
+	case credentialHelper != "":
+		container.Command = []string{"sh", "-c",
+			fmt.Sprintf(
+				`git -c credential.helper= -c credential.helper='%s' -c credential.username=%s "$@" && { `+
+					`%s; }%s`,
+				credentialHelper, gitCredentialDefaultUsername, gitCredentialConfigScript(targetPath, credentialHelper), finish,
+			),
+		}
+		container.Args = append([]string{"--"}, cloneArgs...)
+	case finish != "":
+		container.Command = []string{"sh", "-c", `git "$@"` + finish}
+		container.Args = append([]string{"--"}, cloneArgs...)
+	}
+	return container
+}
+
+// workspaceRepositoriesInstructions returns the agent instructions that
+// describe the additional repositories of a Workspace.
+func workspaceRepositoriesInstructions(repos []kelos.WorkspaceRepository) string {
+	var b strings.Builder
+	b.WriteString("## Workspace repositories\n\n")
+	fmt.Fprintf(&b, "The primary repository is cloned in %s/repo. The workspace also contains these repositories:\n\n", WorkspaceMountPath)
+	for _, repo := range repos {
+		fmt.Fprintf(&b, "- %s: %s (%s)\n", repo.Name, workspaceRepositoryDir(repo), repo.Repo)
+	}
+	b.WriteString("\nEach repository is a separate git repository with its own remotes; commit and push changes in each repository you modify.")
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/capture"
+)
+
+func newWorkspaceRepositoriesTestTask() *kelos.Task {
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "multi-repo", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:   AgentTypeClaudeCode,
+			Prompt: "Change the API",
+			Credentials: &kelos.Credentials{
+				Type:      kelos.CredentialTypeAPIKey,
+				SecretRef: &kelos.SecretReference{Name: "my-secret"},
+			},
+			Branch: "kelos/api-change",
+		},
+	}
+}
+
+func newWorkspaceRepositoriesTestWorkspace() *kelos.WorkspaceSpec {
+	return &kelos.WorkspaceSpec{
+		Repo:      "https://github.com/org/service.git",
+		SecretRef: &kelos.SecretReference{Name: "service-token"},
+		Repositories: []kelos.WorkspaceRepository{
+			{
+				Name:      "proto",
+				Repo:      "https://github.com/org/proto.git",
+				Ref:       "main",
+				SecretRef: &kelos.SecretReference{Name: "proto-token"},
+				Remotes:   []kelos.GitRemote{{Name: "upstream", URL: "https://github.com/upstream/proto.git"}},
+			},
+			{
+				Name: "deploy",
+				Repo: "https://github.com/org/deploy.git",
+				Path: "ops",
+			},
+		},
+	}
+}
+
+func initContainerByName(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+func TestBuildJob_WorkspaceRepositories(t *testing.T) {
+	task := newWorkspaceRepositoriesTestTask()
+	job, err := NewJobBuilder().Build(task, newWorkspaceRepositoriesTestWorkspace(), nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	podSpec := job.Spec.Template.Spec
+
+	var names []string
+	for _, c := range podSpec.InitContainers {
+		names = append(names, c.Name)
+	}
+	wantNames := []string{
+		"git-clone",
+		"branch-setup",
+		"kelos-git-clone-proto",
+		"kelos-remote-setup-proto",
+		"kelos-branch-setup-proto",
+		"kelos-git-clone-deploy",
+		"kelos-branch-setup-deploy",
+	}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("init containers = %v, want %v", names, wantNames)
+	}
+
+	protoClone := initContainerByName(podSpec.InitContainers, "kelos-git-clone-proto")
+	protoArgs := strings.Join(protoClone.Args, " ")
+	if !strings.Contains(protoArgs, "--branch main") || !strings.HasSuffix(protoArgs, "https://github.com/org/proto.git /workspace/proto") {
+		t.Errorf("proto clone args = %q, want a clone of main into /workspace/proto", protoArgs)
+	}
+	if !strings.Contains(protoClone.Command[2], WorkspaceRepositoryTokenMountDir+"/proto/"+GitHubTokenSecretKey) {
+		t.Errorf("proto clone should authenticate with its own token, got %q", protoClone.Command[2])
+	}
+	if !strings.Contains(protoClone.Command[2], "git -C /workspace/proto config --add credential.helper") {
+		t.Errorf("proto clone should persist the credential helper in /workspace/proto, got %q", protoClone.Command[2])
+	}
+
+	deployClone := initContainerByName(podSpec.InitContainers, "kelos-git-clone-deploy")
+	if !strings.Contains(deployClone.Command[2], GitHubTokenMountPath+"/"+GitHubTokenSecretKey) {
+		t.Errorf("deploy clone should fall back to the Workspace token, got %q", deployClone.Command[2])
+	}
+	deployBranch := initContainerByName(podSpec.InitContainers, "kelos-branch-setup-deploy")
+	if !strings.Contains(deployBranch.Command[2], "cd /workspace/ops\n") {
+		t.Errorf("deploy branch setup should run in /workspace/ops, got %q", deployBranch.Command[2])
+	}
+	if !hasEnvVar(deployBranch.Env, "KELOS_BRANCH", "kelos/api-change") {
+		t.Errorf("deploy branch setup env = %v, want KELOS_BRANCH", deployBranch.Env)
+	}
+
+	remoteSetup := initContainerByName(podSpec.InitContainers, "kelos-remote-setup-proto")
+	if !strings.Contains(remoteSetup.Command[2], "cd /workspace/proto && ") {
+		t.Errorf("proto remote setup should run in /workspace/proto, got %q", remoteSetup.Command[2])
+	}
+
+	var tokenVolume bool
+	for _, v := range podSpec.Volumes {
+		if v.Name == "kelos-repo-token-proto" && v.Secret != nil && v.Secret.SecretName == "proto-token" {
+			tokenVolume = true
+		}
+	}
+	if !tokenVolume {
+		t.Error("expected a token volume for the proto repository")
+	}
+
+	agent := podSpec.Containers[0]
+	var tokenMount bool
+	for _, m := range agent.VolumeMounts {
+		if m.Name == "kelos-repo-token-proto" && m.MountPath == WorkspaceRepositoryTokenMountDir+"/proto" {
+			tokenMount = true
+		}
+	}
+	if !tokenMount {
+		t.Errorf("agent volume mounts = %v, want the proto token", agent.VolumeMounts)
+	}
+
+	var repos []capture.Repository
+	for _, e := range agent.Env {
+		if e.Name == capture.EnvRepositories {
+			if err := json.Unmarshal([]byte(e.Value), &repos); err != nil {
+				t.Fatalf("decoding %s: %v", capture.EnvRepositories, err)
+			}
+		}
+	}
+	wantRepos := []capture.Repository{
+		{Name: "proto", Path: "/workspace/proto", BaseBranch: "main", GitHubRepo: "org/proto"},
+		{Name: "deploy", Path: "/workspace/ops", GitHubRepo: "org/deploy"},
+	}
+	if len(repos) != len(wantRepos) || repos[0] != wantRepos[0] || repos[1] != wantRepos[1] {
+		t.Errorf("%s = %+v, want %+v", capture.EnvRepositories, repos, wantRepos)
+	}
+
+	var agentsMD string
+	for _, e := range agent.Env {
+		if e.Name == "KELOS_AGENTS_MD" {
+			agentsMD = e.Value
+		}
+	}
+	if !strings.Contains(agentsMD, "- deploy: /workspace/ops (https://github.com/org/deploy.git)") {
+		t.Errorf("KELOS_AGENTS_MD should describe the repositories, got %q", agentsMD)
+	}
+}
+
+func TestBuildJob_WorkspaceRepositoriesPushApproval(t *testing.T) {
+	task := newWorkspaceRepositoriesTestTask()
+	task.Spec.Approval = &kelos.ApprovalPolicy{Mode: kelos.ApprovalModeBeforePush}
+	job, err := NewJobBuilder().Build(task, newWorkspaceRepositoriesTestWorkspace(), nil, task.Spec.Prompt)
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	hook := initContainerByName(job.Spec.Template.Spec.InitContainers, "kelos-push-approval")
+	if hook == nil {
+		t.Fatal("expected the push approval init container")
+	}
+	for _, dir := range []string{"/workspace/repo", "/workspace/proto", "/workspace/ops"} {
+		if !strings.Contains(hook.Command[2], dir) {
+			t.Errorf("push approval hook should be installed in %s, got %q", dir, hook.Command[2])
+		}
+	}
+}
+
+func hasEnvVar(env []corev1.EnvVar, name, value string) bool {
+	for _, e := range env {
+		if e.Name == name && e.Value == value {
+			return true
+		}
+	}
+	return false
+}
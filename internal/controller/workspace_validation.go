@@ -0,0 +1,377 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
+	"github.com/kelos-dev/kelos/internal/githubapp"
+	"github.com/kelos-dev/kelos/internal/gitremote"
+)
+
+const (
+	// DefaultWorkspaceValidationInterval is how often a ready Workspace is
+	// revalidated, so that expired tokens and deleted refs are noticed
+	// before the next Task starts.
+	DefaultWorkspaceValidationInterval = 10 * time.Minute
+
+	// workspaceValidationRetryInterval is how often a Workspace that is not
+	// ready is revalidated.
+	workspaceValidationRetryInterval = time.Minute
+
+	// gitLabOAuthUsername is the basic auth username GitLab expects with an
+	// access token.
+	gitLabOAuthUsername = "oauth2"
+)
+
+// WorkspaceValidator probes the primary repository of a Workspace with a git
+// ls-remote and reports the result as status conditions. Additional
+// repositories are not validated.
+type WorkspaceValidator struct {
+	// Client sends the git and API requests. Defaults to a client with a
+	// 30 second timeout.
+	Client *http.Client
+	// TokenClient mints installation tokens for GitHub App secrets. When
+	// nil, Workspaces with GitHub App secrets are not probed.
+	TokenClient *githubapp.TokenClient
+	// Interval is how often a ready Workspace is revalidated. Defaults to
+	// DefaultWorkspaceValidationInterval.
+	Interval time.Duration
+}
+
+func (v *WorkspaceValidator) httpClient() *http.Client {
+	if v.Client != nil {
+		return v.Client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// nextValidation returns how long until workspace is due for validation, or
+// zero if it is due now. A Workspace is due when its spec changed since the
+// last validation, and otherwise after Interval if it is ready or after
+// workspaceValidationRetryInterval if it is not.
+func (v *WorkspaceValidator) nextValidation(workspace *kelos.Workspace, now time.Time) time.Duration {
+	status := &workspace.Status
+	if status.ObservedGeneration != workspace.Generation || status.LastValidationTime == nil {
+		return 0
+	}
+	wait := status.LastValidationTime.Add(v.revalidateAfter(status)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// revalidateAfter returns how long after a validation with the result status
+// the Workspace is validated again.
+func (v *WorkspaceValidator) revalidateAfter(status *kelos.WorkspaceStatus) time.Duration {
+	if !meta.IsStatusConditionTrue(status.Conditions, kelos.WorkspaceConditionReady) {
+		return workspaceValidationRetryInterval
+	}
+	if v.Interval > 0 {
+		return v.Interval
+	}
+	return DefaultWorkspaceValidationInterval
+}
+
+// workspaceCredentials is the token used to probe a Workspace repository.
+type workspaceCredentials struct {
+	token string
+	// scopes are the GitHub App permissions of an installation token.
+	scopes []string
+	// appToken is true for GitHub App installation tokens, whose scopes are
+	// known from the token exchange.
+	appToken bool
+}
+
+// Validate probes the repository of workspace and returns its updated
+// status. Conditions keep their last transition time when their status does
+// not change.
+func (v *WorkspaceValidator) Validate(ctx context.Context, cl client.Client, workspace *kelos.Workspace) kelos.WorkspaceStatus {
+	status := *workspace.Status.DeepCopy()
+	now := metav1.Now()
+	status.ObservedGeneration = workspace.Generation
+	status.LastValidationTime = &now
+	status.CredentialScopes = nil
+	status.ResolvedCommit = ""
+
+	set := func(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: workspace.Generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+	unknown := func(reason, message string, conditionTypes ...string) {
+		for _, t := range conditionTypes {
+			set(t, metav1.ConditionUnknown, reason, message)
+		}
+	}
+
+	finish := func() kelos.WorkspaceStatus {
+		setWorkspaceReadyCondition(&status, workspace.Generation)
+		return status
+	}
+
+	creds, reason, err := v.credentials(ctx, cl, workspace)
+	switch {
+	case err != nil:
+		set(kelos.WorkspaceConditionCredentialsValid, metav1.ConditionFalse, reason, err.Error())
+		unknown("CredentialsInvalid", "The repository was not probed because the credentials are invalid",
+			kelos.WorkspaceConditionRepoReachable, kelos.WorkspaceConditionRefResolved, kelos.WorkspaceConditionDefaultBranchResolved)
+		return finish()
+	case reason != "":
+		// The credentials cannot be checked, e.g. because the GitHub App
+		// TokenClient is not configured.
+		unknown(reason, "The credentials could not be checked",
+			kelos.WorkspaceConditionCredentialsValid, kelos.WorkspaceConditionRepoReachable,
+			kelos.WorkspaceConditionRefResolved, kelos.WorkspaceConditionDefaultBranchResolved)
+		return finish()
+	}
+
+	if creds.token == "" {
+		set(kelos.WorkspaceConditionCredentialsValid, metav1.ConditionTrue, "NoSecret", "No secretRef is configured; the repository is cloned anonymously")
+	}
+
+	repoURL := workspace.Spec.Repo
+	if workspaceIsGitLab(&workspace.Spec) && creds.token != "" {
+		repoURL = withURLUsername(repoURL, gitLabOAuthUsername)
+	}
+	refs, err := gitremote.ListRefs(ctx, v.httpClient(), repoURL, creds.token)
+	switch {
+	case errors.Is(err, gitremote.ErrUnsupportedURL):
+		message := fmt.Sprintf("Repository %s is not an http(s) URL and cannot be probed", workspace.Spec.Repo)
+		if creds.token != "" {
+			unknown("UnsupportedURL", message, kelos.WorkspaceConditionCredentialsValid)
+		}
+		unknown("UnsupportedURL", message, kelos.WorkspaceConditionRepoReachable,
+			kelos.WorkspaceConditionRefResolved, kelos.WorkspaceConditionDefaultBranchResolved)
+		return finish()
+	case errors.Is(err, gitremote.ErrUnauthorized) && creds.token == "":
+		set(kelos.WorkspaceConditionRepoReachable, metav1.ConditionFalse, "AuthenticationRequired",
+			fmt.Sprintf("Repository %s requires credentials; set spec.secretRef", workspace.Spec.Repo))
+	case errors.Is(err, gitremote.ErrUnauthorized):
+		set(kelos.WorkspaceConditionCredentialsValid, metav1.ConditionFalse, "Unauthorized",
+			fmt.Sprintf("The token in secret %q was rejected by the repository host; it may be expired or revoked", workspace.Spec.SecretRef.Name))
+		set(kelos.WorkspaceConditionRepoReachable, metav1.ConditionUnknown, "CredentialsInvalid", "The repository cannot be read with the rejected token")
+	case errors.Is(err, gitremote.ErrNotFound):
+		if creds.token != "" {
+			unknown("RepoNotFound", "The credentials cannot be checked against a missing repository", kelos.WorkspaceConditionCredentialsValid)
+		}
+		set(kelos.WorkspaceConditionRepoReachable, metav1.ConditionFalse, "RepoNotFound",
+			fmt.Sprintf("Repository %s does not exist or the credentials cannot read it", workspace.Spec.Repo))
+	case err != nil:
+		// Network errors, timeouts and server errors may be transient and
+		// say nothing definite about the repository, so they do not hold
+		// back Tasks.
+		if creds.token != "" {
+			unknown("RepoUnreachable", "The repository could not be reached", kelos.WorkspaceConditionCredentialsValid)
+		}
+		unknown("RepoUnreachable", err.Error(), kelos.WorkspaceConditionRepoReachable)
+	}
+	if err != nil {
+		unknown("RepoUnreachable", "The repository could not be listed",
+			kelos.WorkspaceConditionRefResolved, kelos.WorkspaceConditionDefaultBranchResolved)
+		return finish()
+	}
+
+	set(kelos.WorkspaceConditionRepoReachable, metav1.ConditionTrue, "Reachable", fmt.Sprintf("Repository %s answered ls-remote", workspace.Spec.Repo))
+	if creds.token != "" {
+		status.CredentialScopes = creds.scopes
+		if !creds.appToken {
+			status.CredentialScopes = v.tokenScopes(ctx, workspace, creds.token)
+		}
+		message := "The token was accepted by the repository host"
+		if len(status.CredentialScopes) > 0 {
+			message += "; scopes: " + strings.Join(status.CredentialScopes, ", ")
+		}
+		set(kelos.WorkspaceConditionCredentialsValid, metav1.ConditionTrue, "Valid", message)
+	}
+
+	switch {
+	case refs.Head != "":
+		status.DefaultBranch = refs.DefaultBranch()
+		set(kelos.WorkspaceConditionDefaultBranchResolved, metav1.ConditionTrue, "Resolved", fmt.Sprintf("The default branch is %q", status.DefaultBranch))
+	case len(refs.Refs) == 0:
+		status.DefaultBranch = ""
+		set(kelos.WorkspaceConditionDefaultBranchResolved, metav1.ConditionFalse, "EmptyRepository", "The repository has no commits")
+	default:
+		status.DefaultBranch = ""
+		set(kelos.WorkspaceConditionDefaultBranchResolved, metav1.ConditionUnknown, "HeadNotAdvertised", "The repository host did not report where HEAD points")
+	}
+
+	ref := workspace.Spec.Ref
+	switch sha, ok := refs.Resolve(ref); {
+	case ref == "" && refs.Head != "":
+		status.ResolvedCommit = refs.Refs[refs.Head]
+		set(kelos.WorkspaceConditionRefResolved, metav1.ConditionTrue, "DefaultBranch", fmt.Sprintf("Ref is not set; Tasks check out the default branch %q", status.DefaultBranch))
+	case ref == "":
+		set(kelos.WorkspaceConditionRefResolved, metav1.ConditionUnknown, "DefaultBranchUnknown", "Ref is not set and the default branch is unknown")
+	case ok:
+		status.ResolvedCommit = sha
+		set(kelos.WorkspaceConditionRefResolved, metav1.ConditionTrue, "Resolved", fmt.Sprintf("Ref %q resolves to %s", ref, sha))
+	case gitremote.IsCommitSHA(ref):
+		set(kelos.WorkspaceConditionRefResolved, metav1.ConditionUnknown, "CommitNotAdvertised",
+			fmt.Sprintf("Ref %q looks like a commit that no branch or tag points to; it can only be verified by fetching it", ref))
+	default:
+		set(kelos.WorkspaceConditionRefResolved, metav1.ConditionFalse, "RefNotFound",
+			fmt.Sprintf("Ref %q is not a branch or tag of %s", ref, workspace.Spec.Repo))
+	}
+
+	return finish()
+}
+
+// setWorkspaceReadyCondition sets the Ready condition from the other
+// conditions: False if any of them is False, True if all of them are True
+// and Unknown otherwise.
+func setWorkspaceReadyCondition(status *kelos.WorkspaceStatus, generation int64) {
+	ready := metav1.Condition{
+		Type:               kelos.WorkspaceConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Validated",
+		Message:            "The repository, credentials and ref were validated",
+	}
+	for _, t := range []string{
+		kelos.WorkspaceConditionCredentialsValid,
+		kelos.WorkspaceConditionRepoReachable,
+		kelos.WorkspaceConditionRefResolved,
+		kelos.WorkspaceConditionDefaultBranchResolved,
+	} {
+		c := meta.FindStatusCondition(status.Conditions, t)
+		if c != nil && c.Status == metav1.ConditionFalse {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = c.Reason
+			ready.Message = fmt.Sprintf("%s: %s", t, c.Message)
+			break
+		}
+		if c == nil || c.Status != metav1.ConditionTrue {
+			ready.Status = metav1.ConditionUnknown
+			ready.Reason = "ValidationIncomplete"
+			ready.Message = "Some checks could not be completed; Tasks are not blocked"
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+}
+
+// credentials returns the token in the Workspace's SecretRef, minting an
+// installation token for GitHub App secrets. A non-empty reason with a nil
+// error means the credentials cannot be checked; with an error it is the
+// reason the credentials are invalid.
+func (v *WorkspaceValidator) credentials(ctx context.Context, cl client.Client, workspace *kelos.Workspace) (workspaceCredentials, string, error) {
+	if workspace.Spec.SecretRef == nil {
+		return workspaceCredentials{}, "", nil
+	}
+
+	var secret corev1.Secret
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: workspace.Namespace, Name: workspace.Spec.SecretRef.Name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return workspaceCredentials{}, "SecretNotFound", fmt.Errorf("secret %q not found", workspace.Spec.SecretRef.Name)
+		}
+		return workspaceCredentials{}, "SecretUnreadable", fmt.Errorf("reading secret %q: %w", workspace.Spec.SecretRef.Name, err)
+	}
+
+	if githubapp.IsGitHubApp(secret.Data) {
+		if v.TokenClient == nil {
+			return workspaceCredentials{}, "TokenClientNotConfigured", nil
+		}
+		appCreds, err := githubapp.ParseCredentials(secret.Data)
+		if err != nil {
+			return workspaceCredentials{}, "InvalidGitHubApp", fmt.Errorf("parsing GitHub App credentials in secret %q: %w", secret.Name, err)
+		}
+		resp, err := tokenClientForRepo(v.TokenClient, workspace.Spec.Repo).GenerateInstallationToken(ctx, appCreds)
+		if err != nil {
+			return workspaceCredentials{}, "TokenExchangeFailed", fmt.Errorf("generating installation token: %w", err)
+		}
+		var scopes []string
+		for name, access := range resp.Permissions {
+			scopes = append(scopes, name+":"+access)
+		}
+		sort.Strings(scopes)
+		return workspaceCredentials{token: resp.Token, scopes: scopes, appToken: true}, "", nil
+	}
+
+	key := workspaceTokenSecretKey(&workspace.Spec)
+	token := strings.TrimSpace(string(secret.Data[key]))
+	if token == "" {
+		return workspaceCredentials{}, "TokenMissing", fmt.Errorf("secret %q has no %s key", secret.Name, key)
+	}
+	return workspaceCredentials{token: token}, "", nil
+}
+
+// tokenScopes returns the scopes of an access token when the repository
+// host reports them: the OAuth scopes of GitHub personal access tokens
+// (classic) and the scopes of GitLab access tokens. Lookup errors are
+// ignored; scopes are informational.
+func (v *WorkspaceValidator) tokenScopes(ctx context.Context, workspace *kelos.Workspace, token string) []string {
+	if workspaceIsGitLab(&workspace.Spec) {
+		host, _ := parseGitLabProject(workspace.Spec.Repo)
+		gl := &forge.GitLab{BaseURL: forge.GitLabAPIBaseURL(host), Token: token, Client: v.httpClient()}
+		scopes, err := gl.TokenScopes(ctx)
+		if err != nil {
+			return nil
+		}
+		return scopes
+	}
+
+	host, owner, repo := parseGitHubRepo(workspace.Spec.Repo)
+	apiBaseURL := gitHubAPIBaseURL(host)
+	if apiBaseURL == "" {
+		apiBaseURL = "https://api.github.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/%s", apiBaseURL, owner, repo), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := v.httpClient().Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	// Fine-grained tokens do not report scopes.
+	var scopes []string
+	for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// withURLUsername returns repoURL with username set, leaving URLs that are
+// not http(s) or already carry a username unchanged.
+func withURLUsername(repoURL, username string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User != nil {
+		return repoURL
+	}
+	u.User = url.User(username)
+	return u.String()
+}
+
+// workspaceReadyCondition returns the Ready condition of workspace if it is
+// current, i.e. it was computed for the Workspace's current generation.
+func workspaceReadyCondition(workspace *kelos.Workspace) *metav1.Condition {
+	c := meta.FindStatusCondition(workspace.Status.Conditions, kelos.WorkspaceConditionReady)
+	if c == nil || c.ObservedGeneration != workspace.Generation {
+		return nil
+	}
+	return c
+}
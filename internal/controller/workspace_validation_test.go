@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const validationMainSHA = "1111111111111111111111111111111111111111"
+
+func gitPktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+// newGitHostServer serves the ref advertisement of owner/repo and the GitHub
+// repository API for the token ghp_valid. requests counts ls-remote calls.
+func newGitHostServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/owner/repo/info/refs":
+			if requests != nil {
+				requests.Add(1)
+			}
+			if _, pass, _ := r.BasicAuth(); pass != "ghp_valid" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+			fmt.Fprint(w, gitPktLine("# service=git-upload-pack\n")+"0000"+
+				gitPktLine(validationMainSHA+" HEAD\x00symref=HEAD:refs/heads/main\n")+
+				gitPktLine(validationMainSHA+" refs/heads/main\n")+"0000")
+		case "/owner/flaky/info/refs":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/api/v3/repos/owner/repo":
+			w.Header().Set("X-OAuth-Scopes", "repo, workflow")
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newValidationWorkspace(repo, ref string) *kelos.Workspace {
+	return &kelos.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default", Generation: 1},
+		Spec: kelos.WorkspaceSpec{
+			Repo:      repo,
+			Ref:       ref,
+			SecretRef: &kelos.SecretReference{Name: "github-token"},
+		},
+	}
+}
+
+func newTokenSecret(token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"},
+		Data:       map[string][]byte{GitHubTokenSecretKey: []byte(token)},
+	}
+}
+
+func TestWorkspaceValidator_Ready(t *testing.T) {
+	server := newGitHostServer(t, nil)
+	workspace := newValidationWorkspace(server.URL+"/owner/repo", "")
+	cl := fake.NewClientBuilder().WithScheme(newWorkspaceControllerTestScheme()).WithObjects(newTokenSecret("ghp_valid")).Build()
+	v := &WorkspaceValidator{Client: server.Client()}
+
+	status := v.Validate(context.Background(), cl, workspace)
+
+	for _, conditionType := range []string{
+		kelos.WorkspaceConditionReady,
+		kelos.WorkspaceConditionRepoReachable,
+		kelos.WorkspaceConditionCredentialsValid,
+		kelos.WorkspaceConditionRefResolved,
+		kelos.WorkspaceConditionDefaultBranchResolved,
+	} {
+		if !meta.IsStatusConditionTrue(status.Conditions, conditionType) {
+			t.Errorf("Condition %s = %+v, want True", conditionType, meta.FindStatusCondition(status.Conditions, conditionType))
+		}
+	}
+	if status.DefaultBranch != "main" || status.ResolvedCommit != validationMainSHA {
+		t.Errorf("DefaultBranch, ResolvedCommit = %q, %q", status.DefaultBranch, status.ResolvedCommit)
+	}
+	if want := []string{"repo", "workflow"}; !reflect.DeepEqual(status.CredentialScopes, want) {
+		t.Errorf("CredentialScopes = %v, want %v", status.CredentialScopes, want)
+	}
+	if status.ObservedGeneration != 1 || status.LastValidationTime == nil {
+		t.Errorf("ObservedGeneration = %d, LastValidationTime = %v", status.ObservedGeneration, status.LastValidationTime)
+	}
+}
+
+func TestWorkspaceValidator_NotReady(t *testing.T) {
+	server := newGitHostServer(t, nil)
+
+	tests := []struct {
+		name          string
+		repo          string
+		ref           string
+		secret        *corev1.Secret
+		conditionType string
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:          "expired token",
+			repo:          server.URL + "/owner/repo",
+			secret:        newTokenSecret("ghp_expired"),
+			conditionType: kelos.WorkspaceConditionCredentialsValid,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    "Unauthorized",
+		},
+		{
+			name:          "missing secret",
+			repo:          server.URL + "/owner/repo",
+			conditionType: kelos.WorkspaceConditionCredentialsValid,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    "SecretNotFound",
+		},
+		{
+			name:          "repo typo",
+			repo:          server.URL + "/owner/rpeo",
+			secret:        newTokenSecret("ghp_valid"),
+			conditionType: kelos.WorkspaceConditionRepoReachable,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    "RepoNotFound",
+		},
+		{
+			name:          "host unavailable",
+			repo:          server.URL + "/owner/flaky",
+			secret:        newTokenSecret("ghp_valid"),
+			conditionType: kelos.WorkspaceConditionRepoReachable,
+			wantStatus:    metav1.ConditionUnknown,
+			wantReason:    "RepoUnreachable",
+		},
+		{
+			name:          "missing ref",
+			repo:          server.URL + "/owner/repo",
+			ref:           "release-9",
+			secret:        newTokenSecret("ghp_valid"),
+			conditionType: kelos.WorkspaceConditionRefResolved,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    "RefNotFound",
+		},
+		{
+			name:          "unadvertised commit",
+			repo:          server.URL + "/owner/repo",
+			ref:           "abcdef1234567",
+			secret:        newTokenSecret("ghp_valid"),
+			conditionType: kelos.WorkspaceConditionRefResolved,
+			wantStatus:    metav1.ConditionUnknown,
+			wantReason:    "CommitNotAdvertised",
+		},
+		{
+			name:          "ssh repo",
+			repo:          "git@github.com:owner/repo.git",
+			secret:        newTokenSecret("ghp_valid"),
+			conditionType: kelos.WorkspaceConditionRepoReachable,
+			wantStatus:    metav1.ConditionUnknown,
+			wantReason:    "UnsupportedURL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(newWorkspaceControllerTestScheme())
+			if tt.secret != nil {
+				builder = builder.WithObjects(tt.secret)
+			}
+			v := &WorkspaceValidator{Client: server.Client()}
+
+			status := v.Validate(context.Background(), builder.Build(), newValidationWorkspace(tt.repo, tt.ref))
+
+			c := meta.FindStatusCondition(status.Conditions, tt.conditionType)
+			if c == nil || c.Status != tt.wantStatus || c.Reason != tt.wantReason {
+				t.Fatalf("Condition %s = %+v, want %s/%s", tt.conditionType, c, tt.wantStatus, tt.wantReason)
+			}
+			ready := meta.FindStatusCondition(status.Conditions, kelos.WorkspaceConditionReady)
+			wantReady := tt.wantStatus
+			if ready == nil || ready.Status != wantReady {
+				t.Fatalf("Ready = %+v, want %s", ready, wantReady)
+			}
+			if wantReady == metav1.ConditionFalse && ready.Reason != tt.wantReason {
+				t.Errorf("Ready reason = %q, want %q", ready.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestWorkspaceReconciler_UpdatesStatus(t *testing.T) {
+	var requests atomic.Int32
+	server := newGitHostServer(t, &requests)
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := newValidationWorkspace(server.URL+"/owner/repo", "main")
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(workspace).
+		WithObjects(workspace, newTokenSecret("ghp_valid")).
+		Build()
+	r := &WorkspaceReconciler{
+		Client:       cl,
+		Scheme:       scheme,
+		ProxyBuilder: NewWorkspaceGHProxyBuilder(),
+		Validator:    &WorkspaceValidator{Client: server.Client(), Interval: 5 * time.Minute},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(workspace)}
+
+	result, err := r.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if result.RequeueAfter != 5*time.Minute {
+		t.Errorf("RequeueAfter = %v, want the validation interval", result.RequeueAfter)
+	}
+
+	var updated kelos.Workspace
+	if err := cl.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("Getting workspace: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, kelos.WorkspaceConditionReady) {
+		t.Errorf("Expected Ready condition, got %+v", updated.Status.Conditions)
+	}
+
+	// A reconcile triggered before the interval elapses, e.g. by the status
+	// update itself, does not probe the repository again.
+	result, err = r.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 ls-remote, got %d", got)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > 5*time.Minute {
+		t.Errorf("RequeueAfter = %v, want the remaining interval", result.RequeueAfter)
+	}
+}
+
+func TestCreateJob_WaitsForNotReadyWorkspace(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := newValidationWorkspace("https://github.com/owner/repo", "release-9")
+	workspace.Status.Conditions = []metav1.Condition{{
+		Type:               kelos.WorkspaceConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: 1,
+		Reason:             "RefNotFound",
+		Message:            `RefResolved: Ref "release-9" is not a branch or tag`,
+		LastTransitionTime: metav1.Now(),
+	}}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-task", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:         AgentTypeClaudeCode,
+			Prompt:       "test",
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(workspace, task).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+	result, err := r.createJob(context.Background(), task)
+	if err != nil {
+		t.Fatalf("createJob error: %v", err)
+	}
+	if result.RequeueAfter <= 0 {
+		t.Errorf("Expected a requeue, got %+v", result)
+	}
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting task: %v", err)
+	}
+	if updated.Status.Phase != kelos.TaskPhaseWaiting || !strings.Contains(updated.Status.Message, `Waiting for Workspace "ws" to become ready`) {
+		t.Errorf("Phase, Message = %q, %q", updated.Status.Phase, updated.Status.Message)
+	}
+	if updated.Status.JobName != "" {
+		t.Errorf("Expected no Job, got %q", updated.Status.JobName)
+	}
+}
+
+func TestCreateJob_DoesNotWaitForUnvalidatedWorkspace(t *testing.T) {
+	scheme := newWorkspaceControllerTestScheme()
+	workspace := newValidationWorkspace("https://github.com/owner/repo", "")
+	workspace.Status.Conditions = []metav1.Condition{{
+		Type:               kelos.WorkspaceConditionReady,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: 1,
+		Reason:             "ValidationIncomplete",
+		Message:            "Some checks could not be completed; Tasks are not blocked",
+		LastTransitionTime: metav1.Now(),
+	}}
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-task", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:         AgentTypeClaudeCode,
+			Prompt:       "test",
+			WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(task).
+		WithObjects(workspace, task).
+		Build()
+	r := &TaskReconciler{Client: cl, Scheme: scheme}
+
+	// The Task goes past the Workspace check; whatever happens later, it is
+	// not held for the Workspace.
+	_, _ = r.createJob(context.Background(), task)
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting task: %v", err)
+	}
+	if strings.Contains(updated.Status.Message, "Waiting for Workspace") {
+		t.Errorf("Message = %q, want the Task not held while the Workspace is not known to be broken", updated.Status.Message)
+	}
+}
@@ -14,6 +14,8 @@ import (
 const (
 	preservedMCPValueFromEnvAnnotation = "kelos.dev/v1alpha2-mcp-value-from-env"
 	preservedSkillsSecretRefAnnotation = "kelos.dev/v1alpha2-skills-secret-ref"
+	preservedCompositionAnnotation     = "kelos.dev/v1alpha2-composition"
+	preservedPermissionsAnnotation     = "kelos.dev/v1alpha2-permissions"
 )
 
 type preservedMCPValueFromEnv struct {
@@ -29,6 +31,11 @@ type preservedSkillsSecretRef struct {
 	SecretRef v1alpha2.SecretReference `json:"secretRef"`
 }
 
+type preservedComposition struct {
+	Extends   *v1alpha2.AgentConfigReference          `json:"extends,omitempty"`
+	Overrides map[string]v1alpha2.AgentConfigOverride `json:"overrides,omitempty"`
+}
+
 func AgentConfigToV1alpha2(ctx context.Context, src *v1alpha1.AgentConfig, dst *v1alpha2.AgentConfig) error {
 	return agentConfigToHub(ctx, src, dst)
 }
@@ -49,8 +56,12 @@ func agentConfigToHub(_ context.Context, src *v1alpha1.AgentConfig, dst *v1alpha
 	if err := restorePreservedSkillsSecretRefs(src.Annotations, dst.Spec.Skills); err != nil {
 		return err
 	}
+	restorePreservedComposition(src.Annotations, &dst.Spec)
+	restorePreservedPermissions(src.Annotations, &dst.Spec)
 	deleteAnnotation(dst.Annotations, preservedMCPValueFromEnvAnnotation)
 	deleteAnnotation(dst.Annotations, preservedSkillsSecretRefAnnotation)
+	deleteAnnotation(dst.Annotations, preservedCompositionAnnotation)
+	deleteAnnotation(dst.Annotations, preservedPermissionsAnnotation)
 	return nil
 }
 
@@ -63,7 +74,13 @@ func agentConfigFromHub(_ context.Context, src *v1alpha2.AgentConfig, dst *v1alp
 	if err := setPreservedMCPValueFromEnvAnnotation(dst, src.Spec.MCPServers); err != nil {
 		return err
 	}
-	return setPreservedSkillsSecretRefAnnotation(dst, src.Spec.Skills)
+	if err := setPreservedSkillsSecretRefAnnotation(dst, src.Spec.Skills); err != nil {
+		return err
+	}
+	if err := setPreservedCompositionAnnotation(dst, &src.Spec); err != nil {
+		return err
+	}
+	return setPreservedPermissionsAnnotation(dst, src.Spec.Permissions)
 }
 
 func mcpServersToV1alpha2(in []v1alpha1.MCPServerSpec) []v1alpha2.MCPServerSpec {
@@ -319,6 +336,67 @@ func preservedSkillIndex(skills []v1alpha2.SkillsShSpec, item preservedSkillsSec
 	return found, true
 }
 
+// setPreservedCompositionAnnotation stores extends and overrides, which
+// v1alpha1 cannot represent, in an annotation.
+func setPreservedCompositionAnnotation(dst *v1alpha1.AgentConfig, spec *v1alpha2.AgentConfigSpec) error {
+	if spec.Extends == nil && len(spec.Overrides) == 0 {
+		deleteAnnotation(dst.Annotations, preservedCompositionAnnotation)
+		return nil
+	}
+	data, err := json.Marshal(preservedComposition{Extends: spec.Extends, Overrides: spec.Overrides})
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[preservedCompositionAnnotation] = string(data)
+	return nil
+}
+
+func restorePreservedComposition(annotations map[string]string, spec *v1alpha2.AgentConfigSpec) {
+	raw := annotations[preservedCompositionAnnotation]
+	if raw == "" {
+		return
+	}
+	var preserved preservedComposition
+	if err := json.Unmarshal([]byte(raw), &preserved); err != nil {
+		return
+	}
+	spec.Extends = preserved.Extends
+	spec.Overrides = preserved.Overrides
+}
+
+// setPreservedPermissionsAnnotation stores the permission policy, which
+// v1alpha1 cannot represent, in an annotation.
+func setPreservedPermissionsAnnotation(dst *v1alpha1.AgentConfig, permissions *v1alpha2.AgentPermissions) error {
+	if permissions == nil {
+		deleteAnnotation(dst.Annotations, preservedPermissionsAnnotation)
+		return nil
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[preservedPermissionsAnnotation] = string(data)
+	return nil
+}
+
+func restorePreservedPermissions(annotations map[string]string, spec *v1alpha2.AgentConfigSpec) {
+	raw := annotations[preservedPermissionsAnnotation]
+	if raw == "" {
+		return
+	}
+	var permissions v1alpha2.AgentPermissions
+	if err := json.Unmarshal([]byte(raw), &permissions); err != nil {
+		return
+	}
+	spec.Permissions = &permissions
+}
+
 func deleteAnnotation(annotations map[string]string, key string) {
 	if annotations == nil {
 		return
@@ -489,3 +489,71 @@ func TestAgentConfigToHub_PreservesNonEnvFields(t *testing.T) {
 		t.Errorf("EnvFrom not copied: %#v", s.EnvFrom)
 	}
 }
+
+func TestAgentConfigRoundTrip_PreservesComposition(t *testing.T) {
+	src := &v1alpha2.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "cfg", Namespace: "default"},
+		Spec: v1alpha2.AgentConfigSpec{
+			Extends:  &v1alpha2.AgentConfigReference{Name: "base"},
+			AgentsMD: "# Shared",
+			Overrides: map[string]v1alpha2.AgentConfigOverride{
+				"codex": {
+					AgentsMD: "# Codex",
+					Skills:   []v1alpha2.SkillsShSpec{{Source: "owner/skills"}},
+				},
+			},
+		},
+	}
+
+	spoke := &v1alpha1.AgentConfig{}
+	if err := agentConfigFromHub(context.Background(), src, spoke); err != nil {
+		t.Fatalf("agentConfigFromHub() error = %v", err)
+	}
+	if spoke.Annotations[preservedCompositionAnnotation] == "" {
+		t.Fatalf("spoke annotation %q not set", preservedCompositionAnnotation)
+	}
+	hub := &v1alpha2.AgentConfig{}
+	if err := agentConfigToHub(context.Background(), spoke, hub); err != nil {
+		t.Fatalf("agentConfigToHub() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(hub.Spec, src.Spec) {
+		t.Errorf("round-tripped spec = %#v, want %#v", hub.Spec, src.Spec)
+	}
+	if _, ok := hub.Annotations[preservedCompositionAnnotation]; ok {
+		t.Errorf("hub annotation %q should be removed after restore", preservedCompositionAnnotation)
+	}
+}
+
+func TestAgentConfigRoundTrip_PreservesPermissions(t *testing.T) {
+	src := &v1alpha2.AgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "cfg", Namespace: "default"},
+		Spec: v1alpha2.AgentConfigSpec{
+			AgentsMD: "# Shared",
+			Permissions: &v1alpha2.AgentPermissions{
+				AllowedTools:   []string{"mcp:github/get_issue"},
+				DeniedCommands: []string{"rm -rf *", "kubectl *"},
+				DeniedPaths:    []string{".github/workflows/**"},
+			},
+		},
+	}
+
+	spoke := &v1alpha1.AgentConfig{}
+	if err := agentConfigFromHub(context.Background(), src, spoke); err != nil {
+		t.Fatalf("agentConfigFromHub() error = %v", err)
+	}
+	if spoke.Annotations[preservedPermissionsAnnotation] == "" {
+		t.Fatalf("spoke annotation %q not set", preservedPermissionsAnnotation)
+	}
+	hub := &v1alpha2.AgentConfig{}
+	if err := agentConfigToHub(context.Background(), spoke, hub); err != nil {
+		t.Fatalf("agentConfigToHub() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(hub.Spec, src.Spec) {
+		t.Errorf("round-tripped spec = %#v, want %#v", hub.Spec, src.Spec)
+	}
+	if _, ok := hub.Annotations[preservedPermissionsAnnotation]; ok {
+		t.Errorf("hub annotation %q should be removed after restore", preservedPermissionsAnnotation)
+	}
+}
@@ -0,0 +1,103 @@
+// Package forge abstracts the git hosting services ("forges") that Kelos
+// reports to, so that capturing change requests and reporting Task status do
+// not depend on a particular service. GitHub is still served by the gh CLI
+// and the reporting package; GitLab is the first forge implemented here.
+package forge
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// EnvForge is the environment variable through which the controller
+	// tells kelos-capture which forge hosts the Workspace repository. It is
+	// unset for GitHub.
+	EnvForge = "KELOS_FORGE"
+
+	// EnvAPIURL is the environment variable holding the forge API base URL,
+	// for example "https://gitlab.com/api/v4".
+	EnvAPIURL = "KELOS_FORGE_API_URL"
+
+	// EnvProject is the environment variable holding the project the
+	// Workspace repository belongs to, for example "group/subgroup/project".
+	EnvProject = "KELOS_FORGE_PROJECT"
+
+	// EnvGitLabToken is the environment variable holding the GitLab access
+	// token.
+	EnvGitLabToken = "GITLAB_TOKEN"
+)
+
+// TypeGitLab identifies GitLab in EnvForge.
+const TypeGitLab = "gitlab"
+
+// Forge is a git hosting service that Task status is reported to.
+type Forge interface {
+	// ChangeRequestURLs returns the web URLs of the open change requests
+	// (pull requests or merge requests) whose source branch is branch.
+	ChangeRequestURLs(ctx context.Context, branch string) ([]string, error)
+
+	// CreateComment comments body on item and returns the comment ID.
+	CreateComment(ctx context.Context, item Item, body string) (int64, error)
+
+	// UpdateComment replaces the body of the comment id on item.
+	UpdateComment(ctx context.Context, item Item, id int64, body string) error
+
+	// SetCommitStatus sets status on the commit sha.
+	SetCommitStatus(ctx context.Context, sha string, status CommitStatus) error
+}
+
+// ItemKind is the kind of a forge item that can be commented on.
+type ItemKind string
+
+const (
+	// ItemIssue is an issue.
+	ItemIssue ItemKind = "issue"
+	// ItemChangeRequest is a pull request or merge request.
+	ItemChangeRequest ItemKind = "change-request"
+)
+
+// Item identifies an issue or change request of the project by its
+// project-scoped number.
+type Item struct {
+	Kind   ItemKind
+	Number int
+}
+
+// CommitState is the state of a commit status.
+type CommitState string
+
+const (
+	CommitStatePending CommitState = "pending"
+	CommitStateRunning CommitState = "running"
+	CommitStateSuccess CommitState = "success"
+	CommitStateFailed  CommitState = "failed"
+)
+
+// CommitStatus is a named status attached to a commit.
+type CommitStatus struct {
+	Name        string
+	State       CommitState
+	Description string
+	TargetURL   string
+}
+
+// FromEnv returns the forge configured through EnvForge, EnvAPIURL and
+// EnvProject, or nil when EnvForge is unset.
+func FromEnv(getenv func(string) string) (Forge, error) {
+	switch kind := getenv(EnvForge); kind {
+	case "":
+		return nil, nil
+	case TypeGitLab:
+		if getenv(EnvAPIURL) == "" || getenv(EnvProject) == "" {
+			return nil, fmt.Errorf("%s and %s are required for forge %q", EnvAPIURL, EnvProject, kind)
+		}
+		return &GitLab{
+			BaseURL: getenv(EnvAPIURL),
+			Project: getenv(EnvProject),
+			Token:   getenv(EnvGitLabToken),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported forge %q", kind)
+	}
+}
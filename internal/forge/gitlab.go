@@ -0,0 +1,300 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// gitLabPerPage is the page size requested from list endpoints.
+	gitLabPerPage = 100
+
+	// maxGitLabPages limits the number of pages fetched from a list endpoint.
+	maxGitLabPages = 10
+)
+
+// GitLab is a Forge backed by the GitLab REST API v4. It also lists the
+// issues and merge requests of the project for the GitLab TaskSpawner source.
+type GitLab struct {
+	// BaseURL is the API base URL, for example "https://gitlab.com/api/v4".
+	BaseURL string
+	// Project is the project path with namespace ("group/project") or the
+	// numeric project ID.
+	Project string
+	// Token is a personal, group or project access token.
+	Token  string
+	Client *http.Client
+}
+
+var _ Forge = (*GitLab)(nil)
+
+// GitLabIssue is an issue returned by the GitLab API.
+type GitLabIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	WebURL      string   `json:"web_url"`
+	Labels      []string `json:"labels"`
+}
+
+// GitLabMergeRequest is a merge request returned by the GitLab API.
+type GitLabMergeRequest struct {
+	IID          int      `json:"iid"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	WebURL       string   `json:"web_url"`
+	Labels       []string `json:"labels"`
+	SourceBranch string   `json:"source_branch"`
+	SHA          string   `json:"sha"`
+	Draft        bool     `json:"draft"`
+}
+
+// GitLabNote is a comment on an issue or merge request.
+type GitLabNote struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+}
+
+// GitLabListOptions filters the issues and merge requests that are listed.
+type GitLabListOptions struct {
+	// State is "opened", "closed", "merged" (merge requests only) or
+	// "all". Empty lists all states.
+	State string
+	// Labels only lists items that have all of these labels.
+	Labels []string
+}
+
+type gitLabNoteRequest struct {
+	Body string `json:"body"`
+}
+
+type gitLabCommitStatusRequest struct {
+	State       string `json:"state"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// GitLabAPIBaseURL returns the API base URL of the GitLab instance at host.
+func GitLabAPIBaseURL(host string) string {
+	return "https://" + host + "/api/v4"
+}
+
+func (g *GitLab) httpClient() *http.Client {
+	if g.Client != nil {
+		return g.Client
+	}
+	return http.DefaultClient
+}
+
+// projectURL returns the API URL of path under the project.
+func (g *GitLab) projectURL(path string) string {
+	return fmt.Sprintf("%s/projects/%s%s", strings.TrimRight(g.BaseURL, "/"), url.PathEscape(g.Project), path)
+}
+
+// itemPath returns the API path of item under the project.
+func itemPath(item Item) string {
+	if item.Kind == ItemChangeRequest {
+		return "/merge_requests/" + strconv.Itoa(item.Number)
+	}
+	return "/issues/" + strconv.Itoa(item.Number)
+}
+
+// ChangeRequestURLs returns the web URLs of the open merge requests whose
+// source branch is branch.
+func (g *GitLab) ChangeRequestURLs(ctx context.Context, branch string) ([]string, error) {
+	params := url.Values{}
+	params.Set("state", "opened")
+	params.Set("source_branch", branch)
+	var mrs []GitLabMergeRequest
+	if err := g.get(ctx, g.projectURL("/merge_requests")+"?"+params.Encode(), &mrs); err != nil {
+		return nil, fmt.Errorf("listing merge requests for branch %s: %w", branch, err)
+	}
+	var urls []string
+	for _, mr := range mrs {
+		if mr.WebURL != "" {
+			urls = append(urls, mr.WebURL)
+		}
+	}
+	return urls, nil
+}
+
+// CreateComment creates a note on item and returns the note ID.
+func (g *GitLab) CreateComment(ctx context.Context, item Item, body string) (int64, error) {
+	var note GitLabNote
+	if err := g.send(ctx, http.MethodPost, g.projectURL(itemPath(item)+"/notes"), gitLabNoteRequest{Body: body}, &note); err != nil {
+		return 0, fmt.Errorf("creating note: %w", err)
+	}
+	return note.ID, nil
+}
+
+// UpdateComment replaces the body of the note id on item.
+func (g *GitLab) UpdateComment(ctx context.Context, item Item, id int64, body string) error {
+	path := itemPath(item) + "/notes/" + strconv.FormatInt(id, 10)
+	if err := g.send(ctx, http.MethodPut, g.projectURL(path), gitLabNoteRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("updating note %d: %w", id, err)
+	}
+	return nil
+}
+
+// SetCommitStatus sets a commit status on sha.
+func (g *GitLab) SetCommitStatus(ctx context.Context, sha string, status CommitStatus) error {
+	req := gitLabCommitStatusRequest{
+		State:       string(status.State),
+		Name:        status.Name,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+	}
+	if err := g.send(ctx, http.MethodPost, g.projectURL("/statuses/"+url.PathEscape(sha)), req, nil); err != nil {
+		return fmt.Errorf("setting commit status on %s: %w", sha, err)
+	}
+	return nil
+}
+
+// ListIssues returns the issues of the project matching opts.
+func (g *GitLab) ListIssues(ctx context.Context, opts GitLabListOptions) ([]GitLabIssue, error) {
+	var issues []GitLabIssue
+	if err := listPages(ctx, g, g.projectURL("/issues"), opts.values(), &issues); err != nil {
+		return nil, fmt.Errorf("listing issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListMergeRequests returns the merge requests of the project matching opts.
+func (g *GitLab) ListMergeRequests(ctx context.Context, opts GitLabListOptions) ([]GitLabMergeRequest, error) {
+	var mrs []GitLabMergeRequest
+	if err := listPages(ctx, g, g.projectURL("/merge_requests"), opts.values(), &mrs); err != nil {
+		return nil, fmt.Errorf("listing merge requests: %w", err)
+	}
+	return mrs, nil
+}
+
+// ListNotes returns the notes on item, oldest first.
+func (g *GitLab) ListNotes(ctx context.Context, item Item) ([]GitLabNote, error) {
+	params := url.Values{}
+	params.Set("sort", "asc")
+	params.Set("order_by", "created_at")
+	var notes []GitLabNote
+	if err := listPages(ctx, g, g.projectURL(itemPath(item)+"/notes"), params, &notes); err != nil {
+		return nil, fmt.Errorf("listing notes: %w", err)
+	}
+	return notes, nil
+}
+
+// TokenScopes returns the scopes of the token, e.g. "api" or
+// "read_repository". It is only supported for personal, group and project
+// access tokens.
+func (g *GitLab) TokenScopes(ctx context.Context) ([]string, error) {
+	var token struct {
+		Scopes []string `json:"scopes"`
+	}
+	if err := g.get(ctx, strings.TrimRight(g.BaseURL, "/")+"/personal_access_tokens/self", &token); err != nil {
+		return nil, fmt.Errorf("fetching token scopes: %w", err)
+	}
+	return token.Scopes, nil
+}
+
+func (o GitLabListOptions) values() url.Values {
+	params := url.Values{}
+	if o.State != "" && o.State != "all" {
+		params.Set("state", o.State)
+	}
+	if len(o.Labels) > 0 {
+		params.Set("labels", strings.Join(o.Labels, ","))
+	}
+	return params
+}
+
+// listPages fetches every page of the list endpoint u, following the
+// X-Next-Page header, and appends the items to out.
+func listPages[T any](ctx context.Context, g *GitLab, u string, params url.Values, out *[]T) error {
+	params.Set("per_page", strconv.Itoa(gitLabPerPage))
+	page := "1"
+	for i := 0; i < maxGitLabPages && page != ""; i++ {
+		params.Set("page", page)
+		var items []T
+		next, err := g.getPage(ctx, u+"?"+params.Encode(), &items)
+		if err != nil {
+			return err
+		}
+		*out = append(*out, items...)
+		page = next
+	}
+	return nil
+}
+
+func (g *GitLab) get(ctx context.Context, u string, out any) error {
+	_, err := g.getPage(ctx, u, out)
+	return err
+}
+
+// getPage decodes the response to a GET of u into out and returns the
+// X-Next-Page header.
+func (g *GitLab) getPage(ctx context.Context, u string, out any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := g.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	return resp.Header.Get("X-Next-Page"), nil
+}
+
+// send issues a request with the JSON encoding of payload as the body and
+// decodes the response into out unless it is nil.
+func (g *GitLab) send(ctx context.Context, method, u string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// do authenticates and sends req. Responses other than 2xx are returned as
+// errors.
+func (g *GitLab) do(req *http.Request) (*http.Response, error) {
+	if g.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(errBody))
+	}
+	return resp, nil
+}
@@ -0,0 +1,197 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitLabChangeRequestURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.EscapedPath(); got != "/api/v4/projects/group%2Fsub%2Fservice/merge_requests" {
+			t.Errorf("Unexpected path: %s", got)
+		}
+		if got := r.URL.Query().Get("source_branch"); got != "kelos/fix" {
+			t.Errorf("source_branch = %q, want kelos/fix", got)
+		}
+		if got := r.URL.Query().Get("state"); got != "opened" {
+			t.Errorf("state = %q, want opened", got)
+		}
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "glpat-test" {
+			t.Errorf("PRIVATE-TOKEN = %q, want glpat-test", got)
+		}
+		json.NewEncoder(w).Encode([]GitLabMergeRequest{{IID: 7, WebURL: "https://gitlab.example.com/group/sub/service/-/merge_requests/7"}})
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL + "/api/v4", Project: "group/sub/service", Token: "glpat-test"}
+	urls, err := g.ChangeRequestURLs(context.Background(), "kelos/fix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"https://gitlab.example.com/group/sub/service/-/merge_requests/7"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("ChangeRequestURLs() = %v, want %v", urls, want)
+	}
+}
+
+func TestGitLabComments(t *testing.T) {
+	var requests []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		var req gitLabNoteRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		bodies = append(bodies, req.Body)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(GitLabNote{ID: 501, Body: req.Body})
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL, Project: "42"}
+	id, err := g.CreateComment(context.Background(), Item{Kind: ItemChangeRequest, Number: 3}, "accepted")
+	if err != nil {
+		t.Fatalf("CreateComment() error: %v", err)
+	}
+	if id != 501 {
+		t.Errorf("CreateComment() = %d, want 501", id)
+	}
+	if err := g.UpdateComment(context.Background(), Item{Kind: ItemIssue, Number: 9}, id, "succeeded"); err != nil {
+		t.Fatalf("UpdateComment() error: %v", err)
+	}
+
+	wantRequests := []string{
+		"POST /projects/42/merge_requests/3/notes",
+		"PUT /projects/42/issues/9/notes/501",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	if !reflect.DeepEqual(bodies, []string{"accepted", "succeeded"}) {
+		t.Errorf("bodies = %v", bodies)
+	}
+}
+
+func TestGitLabSetCommitStatus(t *testing.T) {
+	var got gitLabCommitStatusRequest
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL, Project: "group/service"}
+	err := g.SetCommitStatus(context.Background(), "abc123", CommitStatus{Name: "kelos", State: CommitStateRunning, Description: "running"})
+	if err != nil {
+		t.Fatalf("SetCommitStatus() error: %v", err)
+	}
+	if path != "/projects/group%2Fservice/statuses/abc123" {
+		t.Errorf("Unexpected path: %s", path)
+	}
+	want := gitLabCommitStatusRequest{State: "running", Name: "kelos", Description: "running"}
+	if got != want {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+}
+
+func TestGitLabListIssuesFollowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("labels"); got != "bug,kelos" {
+			t.Errorf("labels = %q, want bug,kelos", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			json.NewEncoder(w).Encode([]GitLabIssue{{IID: 1}})
+		case "2":
+			json.NewEncoder(w).Encode([]GitLabIssue{{IID: 2}})
+		default:
+			t.Errorf("Unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL, Project: "group/service"}
+	issues, err := g.ListIssues(context.Background(), GitLabListOptions{State: "opened", Labels: []string{"bug", "kelos"}})
+	if err != nil {
+		t.Fatalf("ListIssues() error: %v", err)
+	}
+	if len(issues) != 2 || issues[0].IID != 1 || issues[1].IID != 2 {
+		t.Errorf("ListIssues() = %+v, want issues 1 and 2", issues)
+	}
+}
+
+func TestGitLabTokenScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/personal_access_tokens/self" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "glpat-test" {
+			t.Errorf("PRIVATE-TOKEN = %q, want glpat-test", got)
+		}
+		w.Write([]byte(`{"id":1,"scopes":["api","read_repository"]}`))
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL, Token: "glpat-test"}
+	scopes, err := g.TokenScopes(context.Background())
+	if err != nil {
+		t.Fatalf("TokenScopes() error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0] != "api" || scopes[1] != "read_repository" {
+		t.Errorf("TokenScopes() = %v, want [api read_repository]", scopes)
+	}
+}
+
+func TestGitLabReturnsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"403 Forbidden"}`))
+	}))
+	defer server.Close()
+
+	g := &GitLab{BaseURL: server.URL, Project: "group/service"}
+	if _, err := g.CreateComment(context.Background(), Item{Kind: ItemIssue, Number: 1}, "x"); err == nil {
+		t.Fatal("expected an error for a 403 response")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(k string) string { return env[k] }
+
+	f, err := FromEnv(getenv)
+	if err != nil || f != nil {
+		t.Fatalf("FromEnv() without %s = %v, %v, want nil, nil", EnvForge, f, err)
+	}
+
+	env[EnvForge] = TypeGitLab
+	if _, err := FromEnv(getenv); err == nil {
+		t.Fatal("expected an error without an API URL and project")
+	}
+
+	env[EnvAPIURL] = "https://gitlab.example.com/api/v4"
+	env[EnvProject] = "group/service"
+	env[EnvGitLabToken] = "glpat-test"
+	f, err = FromEnv(getenv)
+	if err != nil {
+		t.Fatalf("FromEnv() error: %v", err)
+	}
+	want := &GitLab{BaseURL: "https://gitlab.example.com/api/v4", Project: "group/service", Token: "glpat-test"}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("FromEnv() = %+v, want %+v", f, want)
+	}
+
+	env[EnvForge] = "bitbucket"
+	if _, err := FromEnv(getenv); err == nil {
+		t.Fatal("expected an error for an unsupported forge")
+	}
+}
@@ -49,6 +49,9 @@ type Credentials struct {
 type TokenResponse struct {
 	Token     string
 	ExpiresAt time.Time
+	// Permissions maps the permissions granted to the token to their access
+	// level, e.g. "contents": "write".
+	Permissions map[string]string
 }
 
 // TokenClient generates GitHub App installation tokens.
@@ -151,16 +154,18 @@ func (tc *TokenClient) GenerateInstallationToken(ctx context.Context, creds *Cre
 	}
 
 	var result struct {
-		Token     string    `json:"token"`
-		ExpiresAt time.Time `json:"expires_at"`
+		Token       string            `json:"token"`
+		ExpiresAt   time.Time         `json:"expires_at"`
+		Permissions map[string]string `json:"permissions"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("decoding response: %w", err)
 	}
 
 	return &TokenResponse{
-		Token:     result.Token,
-		ExpiresAt: result.ExpiresAt,
+		Token:       result.Token,
+		ExpiresAt:   result.ExpiresAt,
+		Permissions: result.Permissions,
 	}, nil
 }
 
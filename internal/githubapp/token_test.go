@@ -210,8 +210,9 @@ func TestGenerateInstallationToken(t *testing.T) {
 
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"token":      "ghs_test_token_123",
-			"expires_at": expiresAt.Format(time.RFC3339),
+			"token":       "ghs_test_token_123",
+			"expires_at":  expiresAt.Format(time.RFC3339),
+			"permissions": map[string]string{"contents": "write", "metadata": "read"},
 		})
 	}))
 	defer server.Close()
@@ -241,6 +242,9 @@ func TestGenerateInstallationToken(t *testing.T) {
 	if !resp.ExpiresAt.Equal(expiresAt) {
 		t.Errorf("ExpiresAt = %v, want %v", resp.ExpiresAt, expiresAt)
 	}
+	if resp.Permissions["contents"] != "write" || resp.Permissions["metadata"] != "read" {
+		t.Errorf("Permissions = %v, want contents:write and metadata:read", resp.Permissions)
+	}
 }
 
 func TestTokenProvider_CachesToken(t *testing.T) {
@@ -0,0 +1,192 @@
+// Package gitremote lists the refs of a remote git repository over the smart
+// HTTP protocol, the equivalent of git ls-remote, without a git binary.
+package gitremote
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedURL is returned for repository URLs that are not http(s),
+	// such as SSH (git@host:owner/repo) and git:// URLs.
+	ErrUnsupportedURL = errors.New("only http and https repository URLs can be probed")
+	// ErrUnauthorized is returned when the server rejects the credentials.
+	ErrUnauthorized = errors.New("credentials were rejected")
+	// ErrNotFound is returned when the repository does not exist or the
+	// credentials cannot read it.
+	ErrNotFound = errors.New("repository not found")
+)
+
+// DefaultUsername is the basic auth username sent with a token when the
+// repository URL does not carry one. GitHub accepts any username with a
+// token; this one is also the username of GitHub App installation tokens.
+const DefaultUsername = "x-access-token"
+
+// Refs are the refs advertised by a remote repository.
+type Refs struct {
+	// Head is the ref HEAD points to, e.g. "refs/heads/main". It is empty
+	// when the server does not advertise the symref capability.
+	Head string
+	// Refs maps full ref names to commit SHAs. Annotated tags are stored
+	// under "<tag>^{}" for the commit they point to, as in git ls-remote.
+	Refs map[string]string
+}
+
+// DefaultBranch returns the short name of the branch HEAD points to.
+func (r *Refs) DefaultBranch() string {
+	return strings.TrimPrefix(r.Head, "refs/heads/")
+}
+
+// Resolve returns the commit that ref names. ref may be a full ref name, a
+// branch or tag name, or a commit SHA that one of the refs points to.
+func (r *Refs) Resolve(ref string) (string, bool) {
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref} {
+		if sha, ok := r.Refs[name+"^{}"]; ok {
+			return sha, true
+		}
+		if sha, ok := r.Refs[name]; ok {
+			return sha, true
+		}
+	}
+	if IsCommitSHA(ref) {
+		for _, sha := range r.Refs {
+			if strings.HasPrefix(sha, strings.ToLower(ref)) {
+				return sha, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsCommitSHA reports whether ref looks like an abbreviated or full commit
+// SHA. Servers only advertise refs, so a commit that no ref points to can
+// only be verified by fetching it.
+func IsCommitSHA(ref string) bool {
+	if len(ref) < 7 || len(ref) > 64 {
+		return false
+	}
+	return strings.Trim(strings.ToLower(ref), "0123456789abcdef") == ""
+}
+
+// ListRefs fetches the refs of the repository at repoURL. When password is
+// set it is sent with basic auth, with the username from the URL or
+// DefaultUsername.
+func ListRefs(ctx context.Context, client *http.Client, repoURL, password string) (*Refs, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrUnsupportedURL
+	}
+	username := DefaultUsername
+	if u.User != nil {
+		username = u.User.Username()
+		u.User = nil
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/info/refs"
+	u.RawQuery = "service=git-upload-pack"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if password != "" {
+		req.SetBasicAuth(username, password)
+	}
+	req.Header.Set("User-Agent", "git/2.0 (kelos)")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("%s returned status %d", u.Redacted(), resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-git-upload-pack-advertisement" {
+		return nil, fmt.Errorf("%s is not a git smart HTTP endpoint (content type %q)", u.Redacted(), ct)
+	}
+	return parseAdvertisement(resp.Body)
+}
+
+// parseAdvertisement parses a git-upload-pack ref advertisement (protocol
+// version 0/1) made of pkt-lines.
+func parseAdvertisement(r io.Reader) (*Refs, error) {
+	br := bufio.NewReader(r)
+	refs := &Refs{Refs: map[string]string{}}
+	first := true
+	for {
+		line, flush, err := readPktLine(br)
+		if err == io.EOF {
+			return refs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if flush || strings.HasPrefix(line, "# service=") {
+			continue
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if first {
+			first = false
+			var caps string
+			line, caps, _ = strings.Cut(line, "\x00")
+			for _, c := range strings.Fields(caps) {
+				if target, ok := strings.CutPrefix(c, "symref=HEAD:"); ok {
+					refs.Head = target
+				}
+			}
+		}
+		sha, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed ref advertisement line %q", line)
+		}
+		// An empty repository advertises only its capabilities.
+		if name == "capabilities^{}" {
+			continue
+		}
+		refs.Refs[name] = sha
+	}
+}
+
+// readPktLine reads one pkt-line. flush is true for a flush-pkt ("0000").
+func readPktLine(r *bufio.Reader) (line string, flush bool, err error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", false, fmt.Errorf("truncated pkt-line length")
+		}
+		return "", false, err
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid pkt-line length %q", size[:])
+	}
+	if n == 0 {
+		return "", true, nil
+	}
+	if n < 4 {
+		return "", false, fmt.Errorf("invalid pkt-line length %d", n)
+	}
+	buf := make([]byte, n-4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", false, fmt.Errorf("truncated pkt-line: %w", err)
+	}
+	return string(buf), false, nil
+}
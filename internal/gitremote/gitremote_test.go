@@ -0,0 +1,128 @@
+package gitremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	mainSHA = "1111111111111111111111111111111111111111"
+	tagSHA  = "2222222222222222222222222222222222222222"
+	peelSHA = "3333333333333333333333333333333333333333"
+)
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func advertisement() string {
+	var b strings.Builder
+	b.WriteString(pktLine("# service=git-upload-pack\n"))
+	b.WriteString("0000")
+	b.WriteString(pktLine(mainSHA + " HEAD\x00multi_ack symref=HEAD:refs/heads/main agent=git/2.45\n"))
+	b.WriteString(pktLine(mainSHA + " refs/heads/main\n"))
+	b.WriteString(pktLine(tagSHA + " refs/tags/v1.0.0\n"))
+	b.WriteString(pktLine(peelSHA + " refs/tags/v1.0.0^{}\n"))
+	b.WriteString("0000")
+	return b.String()
+}
+
+func newTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/owner/repo.git/info/refs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query().Get("service"); got != "git-upload-pack" {
+			t.Errorf("service = %q, want git-upload-pack", got)
+		}
+		if token != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || pass != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if user != DefaultUsername {
+				t.Errorf("username = %q, want %q", user, DefaultUsername)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		fmt.Fprint(w, advertisement())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListRefs(t *testing.T) {
+	server := newTestServer(t, "ghp_test")
+
+	refs, err := ListRefs(context.Background(), server.Client(), server.URL+"/owner/repo.git", "ghp_test")
+	if err != nil {
+		t.Fatalf("ListRefs() error: %v", err)
+	}
+	if refs.Head != "refs/heads/main" || refs.DefaultBranch() != "main" {
+		t.Errorf("Head = %q, DefaultBranch() = %q", refs.Head, refs.DefaultBranch())
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+		ok   bool
+	}{
+		{ref: "main", want: mainSHA, ok: true},
+		{ref: "refs/heads/main", want: mainSHA, ok: true},
+		{ref: "HEAD", want: mainSHA, ok: true},
+		{ref: "v1.0.0", want: peelSHA, ok: true},
+		{ref: "1111111", want: mainSHA, ok: true},
+		{ref: "missing"},
+		{ref: "abcdef0"},
+	}
+	for _, tt := range tests {
+		got, ok := refs.Resolve(tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestListRefs_Errors(t *testing.T) {
+	server := newTestServer(t, "ghp_test")
+
+	tests := []struct {
+		name    string
+		repoURL string
+		token   string
+		want    error
+	}{
+		{name: "bad token", repoURL: server.URL + "/owner/repo.git", token: "expired", want: ErrUnauthorized},
+		{name: "missing repo", repoURL: server.URL + "/owner/missing.git", token: "ghp_test", want: ErrNotFound},
+		{name: "ssh", repoURL: "git@github.com:owner/repo.git", want: ErrUnsupportedURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ListRefs(context.Background(), server.Client(), tt.repoURL, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ListRefs() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAdvertisement_EmptyRepository(t *testing.T) {
+	body := pktLine("# service=git-upload-pack\n") + "0000" +
+		pktLine(strings.Repeat("0", 40)+" capabilities^{}\x00agent=git/2.45\n") + "0000"
+
+	refs, err := parseAdvertisement(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseAdvertisement() error: %v", err)
+	}
+	if len(refs.Refs) != 0 || refs.Head != "" {
+		t.Errorf("Expected no refs, got %+v", refs)
+	}
+}
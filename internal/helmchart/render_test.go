@@ -376,7 +376,9 @@ func TestRender_TaskSpawnerTemplatePlaceholdersRemainLiteral(t *testing.T) {
 	}
 	// Each placeholder appears in the Branch and PromptTemplate godoc of
 	// TaskTemplate across both served TaskSpawner CRD schemas (4), plus the
-	// NameTemplate godoc that exists only in the latest version (1).
+	// NameTemplate godoc that exists only in the latest version (1). Pipeline
+	// steps use their own template type, which documents the Pipeline
+	// variables instead.
 	for _, expected := range []string{
 		"Available variables (all sources): {{.ID}}, {{.Title}}, {{.Kind}}",
 		"GitHub issue/Jira sources: {{.Number}}, {{.Body}}, {{.URL}}, {{.Labels}}, {{.Comments}}",
@@ -387,6 +389,9 @@ func TestRender_TaskSpawnerTemplatePlaceholdersRemainLiteral(t *testing.T) {
 			t.Errorf("expected %q to appear five times in TaskSpawner CRD descriptions, got %d", expected, count)
 		}
 	}
+	if count := strings.Count(output, "Available variables: {{.Pipeline}} is the Pipeline name"); count != 1 {
+		t.Errorf("expected the Pipeline promptTemplate variables to appear once in the Pipeline CRD description, got %d", count)
+	}
 }
 
 func TestRender_CRDKeepAnnotation(t *testing.T) {
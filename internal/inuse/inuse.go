@@ -0,0 +1,252 @@
+// Package inuse finds the objects that reference a Workspace, AgentConfig or
+// WorkerPool. The controller keeps a protection finalizer on those objects
+// and only removes it once they are no longer in use, in the spirit of the
+// Kubernetes PVC protection.
+package inuse
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// WorkspaceProtectionFinalizer keeps a deleted Workspace until no
+	// object uses it.
+	WorkspaceProtectionFinalizer = "kelos.dev/workspace-protection"
+	// AgentConfigProtectionFinalizer keeps a deleted AgentConfig until no
+	// object uses it.
+	AgentConfigProtectionFinalizer = "kelos.dev/agentconfig-protection"
+	// WorkerPoolProtectionFinalizer keeps a deleted WorkerPool until no
+	// object uses it.
+	WorkerPoolProtectionFinalizer = "kelos.dev/workerpool-protection"
+)
+
+// User is an object that uses a Workspace, AgentConfig or WorkerPool.
+type User struct {
+	Kind string
+	Name string
+}
+
+// String returns the user in kind/name form, e.g. "task/fix-bug".
+func (u User) String() string {
+	return strings.ToLower(u.Kind) + "/" + u.Name
+}
+
+// Refs are the names of the Workspaces, AgentConfigs and WorkerPools an
+// object uses.
+type Refs struct {
+	Workspaces   []string
+	AgentConfigs []string
+	WorkerPools  []string
+}
+
+func (r *Refs) addWorker(worker *kelos.WorkerSpec) {
+	if worker == nil {
+		return
+	}
+	if worker.WorkspaceRef != nil {
+		r.Workspaces = append(r.Workspaces, worker.WorkspaceRef.Name)
+	}
+	r.addAgentConfigs(worker.AgentConfigRefs)
+}
+
+func (r *Refs) addTaskTemplate(template *kelos.TaskTemplate) {
+	r.addWorker(template.Worker)
+	if template.WorkspaceRef != nil {
+		r.Workspaces = append(r.Workspaces, template.WorkspaceRef.Name)
+	}
+	r.addAgentConfigs(template.AgentConfigRefs)
+	if template.WorkerPoolRef != nil {
+		r.WorkerPools = append(r.WorkerPools, template.WorkerPoolRef.Name)
+	}
+}
+
+func (r *Refs) addPipelineTaskTemplate(template *kelos.PipelineTaskTemplate) {
+	r.addWorker(template.Worker)
+	if template.WorkerPoolRef != nil {
+		r.WorkerPools = append(r.WorkerPools, template.WorkerPoolRef.Name)
+	}
+}
+
+func (r *Refs) addAgentConfigs(refs []kelos.AgentConfigReference) {
+	for _, ref := range refs {
+		r.AgentConfigs = append(r.AgentConfigs, ref.Name)
+	}
+}
+
+// References returns the kind of a Task, Session, WorkerPool, TaskSpawner,
+// SessionSpawner or Pipeline and the objects it uses. Finished Tasks and
+// Pipelines use no objects, and neither do objects being deleted, except
+// Tasks, which may still need their WorkerPool to stop.
+func References(obj client.Object) (string, Refs) {
+	var refs Refs
+	deleting := obj.GetDeletionTimestamp() != nil
+	switch o := obj.(type) {
+	case *kelos.Task:
+		if o.Status.Phase == kelos.TaskPhaseSucceeded || o.Status.Phase == kelos.TaskPhaseFailed {
+			return "Task", refs
+		}
+		refs.addWorker(o.Spec.Worker)
+		if o.Spec.WorkspaceRef != nil {
+			refs.Workspaces = append(refs.Workspaces, o.Spec.WorkspaceRef.Name)
+		}
+		refs.addAgentConfigs(o.Spec.AgentConfigRefs)
+		if o.Spec.WorkerPoolRef != nil {
+			refs.WorkerPools = append(refs.WorkerPools, o.Spec.WorkerPoolRef.Name)
+		}
+		return "Task", refs
+	case *kelos.Session:
+		if !deleting {
+			refs.addWorker(&o.Spec.Worker)
+		}
+		return "Session", refs
+	case *kelos.WorkerPool:
+		if !deleting {
+			refs.addWorker(&o.Spec.Worker)
+		}
+		return "WorkerPool", refs
+	case *kelos.TaskSpawner:
+		if !deleting {
+			refs.addTaskTemplate(&o.Spec.TaskTemplate)
+		}
+		return "TaskSpawner", refs
+	case *kelos.SessionSpawner:
+		if !deleting {
+			refs.addWorker(&o.Spec.SessionTemplate.Worker)
+		}
+		return "SessionSpawner", refs
+	case *kelos.Pipeline:
+		if deleting || o.Status.Phase == kelos.PipelinePhaseSucceeded || o.Status.Phase == kelos.PipelinePhaseFailed {
+			return "Pipeline", refs
+		}
+		for i := range o.Spec.Steps {
+			refs.addPipelineTaskTemplate(&o.Spec.Steps[i].TaskTemplate)
+		}
+		return "Pipeline", refs
+	}
+	return "", refs
+}
+
+// WorkspaceUsers returns the objects in namespace that use the Workspace
+// name, sorted by kind and name.
+func WorkspaceUsers(ctx context.Context, c client.Reader, namespace, name string) ([]User, error) {
+	return users(ctx, c, namespace, func(refs Refs) bool {
+		return slices.Contains(refs.Workspaces, name)
+	})
+}
+
+// WorkerPoolUsers returns the objects in namespace that use the WorkerPool
+// name, sorted by kind and name.
+func WorkerPoolUsers(ctx context.Context, c client.Reader, namespace, name string) ([]User, error) {
+	return users(ctx, c, namespace, func(refs Refs) bool {
+		return slices.Contains(refs.WorkerPools, name)
+	})
+}
+
+// AgentConfigUsers returns the objects in namespace that use the
+// AgentConfig name, directly or through an AgentConfig that extends it,
+// and the AgentConfigs that extend it, sorted by kind and name.
+// AgentConfigs being deleted are not listed, but their users are.
+func AgentConfigUsers(ctx context.Context, c client.Reader, namespace, name string) ([]User, error) {
+	var list kelos.AgentConfigList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	bases := make(map[string]string, len(list.Items))
+	for _, ac := range list.Items {
+		if ac.Spec.Extends != nil {
+			bases[ac.Name] = ac.Spec.Extends.Name
+		}
+	}
+
+	dependents := map[string]bool{name: true}
+	var result []User
+	for _, ac := range list.Items {
+		seen := map[string]bool{ac.Name: true}
+		for base := bases[ac.Name]; base != "" && !seen[base]; base = bases[base] {
+			seen[base] = true
+			if base == name {
+				dependents[ac.Name] = true
+				if ac.DeletionTimestamp == nil {
+					result = append(result, User{Kind: "AgentConfig", Name: ac.Name})
+				}
+				break
+			}
+		}
+	}
+
+	others, err := users(ctx, c, namespace, func(refs Refs) bool {
+		for _, ref := range refs.AgentConfigs {
+			if dependents[ref] {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, others...)
+	sortUsers(result)
+	return result, nil
+}
+
+// users returns the objects in namespace whose references match.
+func users(ctx context.Context, c client.Reader, namespace string, match func(Refs) bool) ([]User, error) {
+	var objects []client.Object
+	var tasks kelos.TaskList
+	var sessions kelos.SessionList
+	var pools kelos.WorkerPoolList
+	var taskSpawners kelos.TaskSpawnerList
+	var sessionSpawners kelos.SessionSpawnerList
+	var pipelines kelos.PipelineList
+	for _, list := range []client.ObjectList{&tasks, &sessions, &pools, &taskSpawners, &sessionSpawners, &pipelines} {
+		// Older installations may lack some of the kinds.
+		if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil && !meta.IsNoMatchError(err) {
+			return nil, err
+		}
+	}
+	for i := range tasks.Items {
+		objects = append(objects, &tasks.Items[i])
+	}
+	for i := range sessions.Items {
+		objects = append(objects, &sessions.Items[i])
+	}
+	for i := range pools.Items {
+		objects = append(objects, &pools.Items[i])
+	}
+	for i := range taskSpawners.Items {
+		objects = append(objects, &taskSpawners.Items[i])
+	}
+	for i := range sessionSpawners.Items {
+		objects = append(objects, &sessionSpawners.Items[i])
+	}
+	for i := range pipelines.Items {
+		objects = append(objects, &pipelines.Items[i])
+	}
+
+	var result []User
+	for _, obj := range objects {
+		if kind, refs := References(obj); match(refs) {
+			result = append(result, User{Kind: kind, Name: obj.GetName()})
+		}
+	}
+	sortUsers(result)
+	return result, nil
+}
+
+func sortUsers(users []User) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Kind != users[j].Kind {
+			return users[i].Kind < users[j].Kind
+		}
+		return users[i].Name < users[j].Name
+	})
+}
@@ -0,0 +1,170 @@
+package inuse
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := kelos.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func objectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: "default"}
+}
+
+// deleting returns metadata of an object that is being deleted.
+func deleting(name string) metav1.ObjectMeta {
+	m := objectMeta(name)
+	now := metav1.Now()
+	m.DeletionTimestamp = &now
+	m.Finalizers = []string{"test"}
+	return m
+}
+
+func TestWorkspaceUsers(t *testing.T) {
+	ws := &kelos.WorkerSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"}}
+	cl := newClient(t,
+		&kelos.Task{ObjectMeta: objectMeta("running"), Spec: kelos.TaskSpec{Worker: ws}},
+		&kelos.Task{ObjectMeta: objectMeta("legacy"), Spec: kelos.TaskSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"}}},
+		&kelos.Task{
+			ObjectMeta: objectMeta("done"),
+			Spec:       kelos.TaskSpec{Worker: ws},
+			Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseSucceeded},
+		},
+		&kelos.Task{ObjectMeta: deleting("stopping"), Spec: kelos.TaskSpec{Worker: ws}},
+		&kelos.Task{ObjectMeta: objectMeta("other"), Spec: kelos.TaskSpec{WorkspaceRef: &kelos.WorkspaceReference{Name: "other"}}},
+		&kelos.Session{ObjectMeta: objectMeta("chat"), Spec: kelos.SessionSpec{Worker: *ws}},
+		&kelos.Session{ObjectMeta: deleting("closing"), Spec: kelos.SessionSpec{Worker: *ws}},
+		&kelos.WorkerPool{ObjectMeta: objectMeta("pool"), Spec: kelos.WorkerPoolSpec{Worker: *ws}},
+		&kelos.TaskSpawner{ObjectMeta: objectMeta("spawner"), Spec: kelos.TaskSpawnerSpec{
+			TaskTemplate: kelos.TaskTemplate{WorkspaceRef: &kelos.WorkspaceReference{Name: "ws"}},
+		}},
+		&kelos.SessionSpawner{ObjectMeta: objectMeta("sessions"), Spec: kelos.SessionSpawnerSpec{
+			SessionTemplate: kelos.SessionTemplate{SessionSpec: kelos.SessionSpec{Worker: *ws}},
+		}},
+		&kelos.Pipeline{ObjectMeta: objectMeta("pipeline"), Spec: kelos.PipelineSpec{Steps: []kelos.PipelineStep{
+			{Name: "plan", TaskTemplate: kelos.PipelineTaskTemplate{Worker: ws}},
+		}}},
+		&kelos.Pipeline{
+			ObjectMeta: objectMeta("finished"),
+			Spec: kelos.PipelineSpec{Steps: []kelos.PipelineStep{
+				{Name: "plan", TaskTemplate: kelos.PipelineTaskTemplate{Worker: ws}},
+			}},
+			Status: kelos.PipelineStatus{Phase: kelos.PipelinePhaseFailed},
+		},
+	)
+
+	got, err := WorkspaceUsers(context.Background(), cl, "default", "ws")
+	if err != nil {
+		t.Fatalf("WorkspaceUsers() error: %v", err)
+	}
+	want := []User{
+		{Kind: "Pipeline", Name: "pipeline"},
+		{Kind: "Session", Name: "chat"},
+		{Kind: "SessionSpawner", Name: "sessions"},
+		{Kind: "Task", Name: "legacy"},
+		{Kind: "Task", Name: "running"},
+		{Kind: "Task", Name: "stopping"},
+		{Kind: "TaskSpawner", Name: "spawner"},
+		{Kind: "WorkerPool", Name: "pool"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WorkspaceUsers() = %v, want %v", got, want)
+	}
+}
+
+func TestWorkerPoolUsers(t *testing.T) {
+	pool := &kelos.WorkerPoolReference{Name: "pool"}
+	cl := newClient(t,
+		&kelos.Task{ObjectMeta: objectMeta("queued"), Spec: kelos.TaskSpec{WorkerPoolRef: pool}},
+		&kelos.Task{
+			ObjectMeta: objectMeta("failed"),
+			Spec:       kelos.TaskSpec{WorkerPoolRef: pool},
+			Status:     kelos.TaskStatus{Phase: kelos.TaskPhaseFailed},
+		},
+		&kelos.TaskSpawner{ObjectMeta: objectMeta("spawner"), Spec: kelos.TaskSpawnerSpec{
+			TaskTemplate: kelos.TaskTemplate{WorkerPoolRef: pool},
+		}},
+		&kelos.TaskSpawner{ObjectMeta: deleting("removed"), Spec: kelos.TaskSpawnerSpec{
+			TaskTemplate: kelos.TaskTemplate{WorkerPoolRef: pool},
+		}},
+	)
+
+	got, err := WorkerPoolUsers(context.Background(), cl, "default", "pool")
+	if err != nil {
+		t.Fatalf("WorkerPoolUsers() error: %v", err)
+	}
+	want := []User{{Kind: "Task", Name: "queued"}, {Kind: "TaskSpawner", Name: "spawner"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WorkerPoolUsers() = %v, want %v", got, want)
+	}
+}
+
+func TestAgentConfigUsers(t *testing.T) {
+	extends := func(base string) *kelos.AgentConfigReference {
+		return &kelos.AgentConfigReference{Name: base}
+	}
+	cl := newClient(t,
+		&kelos.AgentConfig{ObjectMeta: objectMeta("org")},
+		&kelos.AgentConfig{ObjectMeta: objectMeta("team"), Spec: kelos.AgentConfigSpec{Extends: extends("org")}},
+		&kelos.AgentConfig{ObjectMeta: deleting("old-team"), Spec: kelos.AgentConfigSpec{Extends: extends("org")}},
+		&kelos.AgentConfig{ObjectMeta: objectMeta("a"), Spec: kelos.AgentConfigSpec{Extends: extends("b")}},
+		&kelos.AgentConfig{ObjectMeta: objectMeta("b"), Spec: kelos.AgentConfigSpec{Extends: extends("a")}},
+		&kelos.Task{ObjectMeta: objectMeta("direct"), Spec: kelos.TaskSpec{
+			AgentConfigRefs: []kelos.AgentConfigReference{{Name: "org"}},
+		}},
+		&kelos.Task{ObjectMeta: objectMeta("via-team"), Spec: kelos.TaskSpec{
+			Worker: &kelos.WorkerSpec{AgentConfigRefs: []kelos.AgentConfigReference{{Name: "team"}}},
+		}},
+		&kelos.WorkerPool{ObjectMeta: objectMeta("via-old-team"), Spec: kelos.WorkerPoolSpec{
+			Worker: kelos.WorkerSpec{AgentConfigRefs: []kelos.AgentConfigReference{{Name: "old-team"}}},
+		}},
+		&kelos.Session{ObjectMeta: objectMeta("unrelated"), Spec: kelos.SessionSpec{
+			Worker: kelos.WorkerSpec{AgentConfigRefs: []kelos.AgentConfigReference{{Name: "a"}}},
+		}},
+	)
+
+	got, err := AgentConfigUsers(context.Background(), cl, "default", "org")
+	if err != nil {
+		t.Fatalf("AgentConfigUsers() error: %v", err)
+	}
+	want := []User{
+		{Kind: "AgentConfig", Name: "team"},
+		{Kind: "Task", Name: "direct"},
+		{Kind: "Task", Name: "via-team"},
+		{Kind: "WorkerPool", Name: "via-old-team"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AgentConfigUsers(org) = %v, want %v", got, want)
+	}
+
+	// An extends cycle terminates.
+	got, err = AgentConfigUsers(context.Background(), cl, "default", "a")
+	if err != nil {
+		t.Fatalf("AgentConfigUsers() error: %v", err)
+	}
+	want = []User{{Kind: "AgentConfig", Name: "b"}, {Kind: "Session", Name: "unrelated"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AgentConfigUsers(a) = %v, want %v", got, want)
+	}
+}
+
+func TestUserString(t *testing.T) {
+	if got := (User{Kind: "TaskSpawner", Name: "issues"}).String(); got != "taskspawner/issues" {
+		t.Errorf("String() = %q, want taskspawner/issues", got)
+	}
+}
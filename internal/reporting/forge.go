@@ -0,0 +1,205 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
+)
+
+const (
+	// AnnotationForgeNotes indicates that status notes are posted to the
+	// originating issue or merge request on a non-GitHub forge.
+	AnnotationForgeNotes = "kelos.dev/forge-notes"
+
+	// AnnotationForgeCommentID stores the ID of the status note so
+	// subsequent updates edit the same note.
+	AnnotationForgeCommentID = "kelos.dev/forge-comment-id"
+
+	// AnnotationForgeReportPhase records the last Task phase that was
+	// reported in the status note.
+	AnnotationForgeReportPhase = "kelos.dev/forge-report-phase"
+
+	// AnnotationForgeCommitStatus indicates that a commit status is set on
+	// the head commit (AnnotationSourceSHA) of the originating merge request.
+	AnnotationForgeCommitStatus = "kelos.dev/forge-commit-status"
+
+	// AnnotationForgeCommitStatusName stores the commit status name
+	// configured on the TaskSpawner.
+	AnnotationForgeCommitStatusName = "kelos.dev/forge-commit-status-name"
+
+	// AnnotationForgeCommitStatusPhase records the last Task phase that was
+	// reported as a commit status.
+	AnnotationForgeCommitStatusPhase = "kelos.dev/forge-commit-status-phase"
+)
+
+// ForgeTaskReporter reports Task status changes to a non-GitHub forge as
+// notes on the originating issue or merge request and as commit statuses.
+type ForgeTaskReporter struct {
+	Client client.Client
+	Forge  forge.Forge
+}
+
+// ReportTaskStatus checks a Task's current phase against its last reported
+// phases and updates the status note and/or commit status as needed.
+func (tr *ForgeTaskReporter) ReportTaskStatus(ctx context.Context, task *kelos.Task) error {
+	annotations := task.Annotations
+	if annotations == nil {
+		return nil
+	}
+	if annotations[AnnotationForgeNotes] == "enabled" {
+		if err := tr.reportViaNote(ctx, task); err != nil {
+			return err
+		}
+	}
+	if annotations[AnnotationForgeCommitStatus] == "enabled" {
+		if err := tr.reportViaCommitStatus(ctx, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportViaNote creates or updates the status note on the source item.
+func (tr *ForgeTaskReporter) reportViaNote(ctx context.Context, task *kelos.Task) error {
+	log := ctrl.Log.WithName("reporter")
+
+	annotations := task.Annotations
+	numberStr, ok := annotations[AnnotationSourceNumber]
+	if !ok {
+		return nil
+	}
+	number, err := strconv.Atoi(numberStr)
+	if err != nil {
+		return fmt.Errorf("parsing source number %q: %w", numberStr, err)
+	}
+
+	desiredPhase := statusCommentPhase(task)
+	if desiredPhase == "" || annotations[AnnotationForgeReportPhase] == desiredPhase {
+		return nil
+	}
+
+	var commentID int64
+	if idStr, ok := annotations[AnnotationForgeCommentID]; ok {
+		commentID, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing %s annotation %q: %w", AnnotationForgeCommentID, idStr, err)
+		}
+	}
+
+	item := forge.Item{Kind: forge.ItemIssue, Number: number}
+	if annotations[AnnotationSourceKind] == "pull-request" {
+		item.Kind = forge.ItemChangeRequest
+	}
+	body := statusCommentBody(task, desiredPhase)
+
+	if commentID == 0 {
+		log.Info("Creating status note", "task", task.Name, "number", number, "phase", desiredPhase)
+		commentID, err = tr.Forge.CreateComment(ctx, item, body)
+		if err != nil {
+			return fmt.Errorf("creating status note for task %s: %w", task.Name, err)
+		}
+	} else {
+		log.Info("Updating status note", "task", task.Name, "number", number, "phase", desiredPhase, "commentID", commentID)
+		if err := tr.Forge.UpdateComment(ctx, item, commentID, body); err != nil {
+			return fmt.Errorf("updating status note %d for task %s: %w", commentID, task.Name, err)
+		}
+	}
+
+	return tr.persistAnnotations(ctx, task, map[string]string{
+		AnnotationForgeCommentID:   strconv.FormatInt(commentID, 10),
+		AnnotationForgeReportPhase: desiredPhase,
+	})
+}
+
+// reportViaCommitStatus sets a commit status on the head commit of the
+// source merge request.
+func (tr *ForgeTaskReporter) reportViaCommitStatus(ctx context.Context, task *kelos.Task) error {
+	log := ctrl.Log.WithName("reporter")
+
+	annotations := task.Annotations
+	sha := annotations[AnnotationSourceSHA]
+	if sha == "" {
+		log.Info("Skipping commit status: source SHA annotation is not set", "task", task.Name)
+		return nil
+	}
+
+	var status forge.CommitStatus
+	switch task.Status.Phase {
+	case kelos.TaskPhasePending, kelos.TaskPhaseWaiting, kelos.TaskPhaseAwaitingApproval:
+		status.State = forge.CommitStatePending
+		status.Description = fmt.Sprintf("Agent task %s is pending", task.Name)
+	case kelos.TaskPhaseRunning:
+		status.State = forge.CommitStateRunning
+		status.Description = fmt.Sprintf("Agent task %s is running", task.Name)
+	case kelos.TaskPhaseSucceeded:
+		status.State = forge.CommitStateSuccess
+		status.Description = fmt.Sprintf("Agent task %s has succeeded", task.Name)
+	case kelos.TaskPhaseFailed:
+		status.State = forge.CommitStateFailed
+		status.Description = fmt.Sprintf("Agent task %s has failed", task.Name)
+		if message, ok := taskTimeoutMessage(task); ok {
+			status.Description = fmt.Sprintf("Agent task %s has timed out: %s", task.Name, message)
+		}
+	default:
+		return nil
+	}
+	if annotations[AnnotationForgeCommitStatusPhase] == string(status.State) {
+		return nil
+	}
+
+	status.Name = annotations[AnnotationForgeCommitStatusName]
+	if status.Name == "" {
+		spawnerName := task.Labels["kelos.dev/taskspawner"]
+		if spawnerName == "" {
+			spawnerName = task.Name
+		}
+		status.Name = "kelos/" + spawnerName
+	}
+
+	log.Info("Setting commit status", "task", task.Name, "name", status.Name, "sha", sha, "state", status.State)
+	if err := tr.Forge.SetCommitStatus(ctx, sha, status); err != nil {
+		return fmt.Errorf("setting commit status for task %s: %w", task.Name, err)
+	}
+
+	return tr.persistAnnotations(ctx, task, map[string]string{
+		AnnotationForgeCommitStatusPhase: string(status.State),
+	})
+}
+
+func (tr *ForgeTaskReporter) persistAnnotations(ctx context.Context, task *kelos.Task, annotations map[string]string) error {
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var current kelos.Task
+		if err := tr.Client.Get(ctx, client.ObjectKeyFromObject(task), &current); err != nil {
+			return err
+		}
+
+		if current.Annotations == nil {
+			current.Annotations = make(map[string]string)
+		}
+		for k, v := range annotations {
+			current.Annotations[k] = v
+		}
+
+		if err := tr.Client.Update(ctx, &current); err != nil {
+			return err
+		}
+
+		task.Annotations = current.Annotations
+		return nil
+	}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("persisting annotations on task %s: task no longer exists", task.Name)
+		}
+		return fmt.Errorf("persisting annotations on task %s: %w", task.Name, err)
+	}
+
+	return nil
+}
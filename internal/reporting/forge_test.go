@@ -0,0 +1,117 @@
+package reporting
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/forge"
+)
+
+type fakeForge struct {
+	created  []forge.Item
+	updated  []int64
+	statuses []forge.CommitStatus
+	bodies   []string
+}
+
+func (f *fakeForge) ChangeRequestURLs(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeForge) CreateComment(_ context.Context, item forge.Item, body string) (int64, error) {
+	f.created = append(f.created, item)
+	f.bodies = append(f.bodies, body)
+	return 77, nil
+}
+
+func (f *fakeForge) UpdateComment(_ context.Context, _ forge.Item, id int64, body string) error {
+	f.updated = append(f.updated, id)
+	f.bodies = append(f.bodies, body)
+	return nil
+}
+
+func (f *fakeForge) SetCommitStatus(_ context.Context, _ string, status forge.CommitStatus) error {
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+func TestForgeTaskReporter_Notes(t *testing.T) {
+	task := newTaskWithAnnotations("test-task", "default", kelos.TaskPhasePending, map[string]string{
+		AnnotationForgeNotes:   "enabled",
+		AnnotationSourceNumber: "5",
+		AnnotationSourceKind:   "pull-request",
+	})
+	cl := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(task).Build()
+	f := &fakeForge{}
+	tr := &ForgeTaskReporter{Client: cl, Forge: f}
+
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.created) != 1 || f.created[0] != (forge.Item{Kind: forge.ItemChangeRequest, Number: 5}) {
+		t.Fatalf("Expected one note on merge request 5, got %+v", f.created)
+	}
+
+	// Reporting the same phase again is a no-op.
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.created) != 1 || len(f.updated) != 0 {
+		t.Fatalf("Expected no further calls, got created=%v updated=%v", f.created, f.updated)
+	}
+
+	task.Status.Phase = kelos.TaskPhaseSucceeded
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.updated) != 1 || f.updated[0] != 77 {
+		t.Fatalf("Expected note 77 to be updated, got %v", f.updated)
+	}
+	if f.bodies[1] != FormatSucceededComment("test-task") {
+		t.Errorf("Unexpected body %q", f.bodies[1])
+	}
+
+	var updated kelos.Task
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), &updated); err != nil {
+		t.Fatalf("Getting updated task: %v", err)
+	}
+	if updated.Annotations[AnnotationForgeReportPhase] != "succeeded" || updated.Annotations[AnnotationForgeCommentID] != "77" {
+		t.Errorf("Unexpected annotations %v", updated.Annotations)
+	}
+}
+
+func TestForgeTaskReporter_CommitStatus(t *testing.T) {
+	task := newTaskWithAnnotations("test-task", "default", kelos.TaskPhaseRunning, map[string]string{
+		AnnotationForgeCommitStatus: "enabled",
+		AnnotationSourceSHA:         "abc123",
+	})
+	task.Labels = map[string]string{"kelos.dev/taskspawner": "reviewer"}
+	cl := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(task).Build()
+	f := &fakeForge{}
+	tr := &ForgeTaskReporter{Client: cl, Forge: f}
+
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	task.Status.Phase = kelos.TaskPhaseFailed
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(f.statuses) != 2 {
+		t.Fatalf("Expected 2 commit statuses, got %+v", f.statuses)
+	}
+	if f.statuses[0].State != forge.CommitStateRunning || f.statuses[1].State != forge.CommitStateFailed {
+		t.Errorf("Unexpected states %+v", f.statuses)
+	}
+	if f.statuses[0].Name != "kelos/reviewer" {
+		t.Errorf("Name = %q, want kelos/reviewer", f.statuses[0].Name)
+	}
+}
@@ -241,6 +241,17 @@ func FormatAcceptedComment(taskName string) string {
 	return fmt.Sprintf("🤖 **Kelos Task Status**\n\nTask `%s` has been **accepted** and is being processed.", taskName)
 }
 
+// FormatAwaitingApprovalComment returns the comment body for a task that
+// waits for approval. When approveComment is set, the body tells authorized
+// users which comment approves the task.
+func FormatAwaitingApprovalComment(taskName, approveComment string) string {
+	body := fmt.Sprintf("🤖 **Kelos Task Status**\n\nTask `%s` is **waiting for approval**.", taskName)
+	if approveComment != "" {
+		body += fmt.Sprintf(" Comment `%s` to approve it.", approveComment)
+	}
+	return body
+}
+
 // FormatSucceededComment returns the comment body for a succeeded task.
 func FormatSucceededComment(taskName string) string {
 	return fmt.Sprintf("🤖 **Kelos Task Status**\n\nTask `%s` has **succeeded**. ✅", taskName)
@@ -250,3 +261,9 @@ func FormatSucceededComment(taskName string) string {
 func FormatFailedComment(taskName string) string {
 	return fmt.Sprintf("🤖 **Kelos Task Status**\n\nTask `%s` has **failed**. ❌", taskName)
 }
+
+// FormatTimedOutComment returns the comment body for a task that failed
+// because it exceeded its waiting or pending timeout.
+func FormatTimedOutComment(taskName, message string) string {
+	return fmt.Sprintf("🤖 **Kelos Task Status**\n\nTask `%s` has **timed out**. ⏱️\n\n%s", taskName, message)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -331,4 +332,9 @@ func TestFormatComments(t *testing.T) {
 	if failed == "" {
 		t.Error("Expected non-empty failed comment")
 	}
+
+	timedOut := FormatTimedOutComment("test-task", "Task did not start within waitingTimeoutSeconds (60s)")
+	if !strings.Contains(timedOut, "timed out") || !strings.Contains(timedOut, "waitingTimeoutSeconds (60s)") {
+		t.Errorf("Expected timed out comment to include the timeout message, got %q", timedOut)
+	}
 }
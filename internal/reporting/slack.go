@@ -85,6 +85,48 @@ func contextBlock(taskName string) *slack.ContextBlock {
 	)
 }
 
+// SlackApproveActionID is the action ID of the Approve button posted while a
+// Task waits for approval. The button value is "<namespace>/<name>".
+const SlackApproveActionID = "kelos_approve_task"
+
+// FormatSlackApprovalRequest returns the message posted while a Task waits
+// for approval. When withButton is true, the message carries an Approve
+// button for the Task's Slack approvers.
+func FormatSlackApprovalRequest(namespace, taskName string, withButton bool) SlackMessage {
+	blocks := []slack.Block{
+		slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, ":raised_hand: *Waiting for approval*", false, false),
+			nil, nil,
+		),
+	}
+	if withButton {
+		button := slack.NewButtonBlockElement(SlackApproveActionID, namespace+"/"+taskName,
+			slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false))
+		button.WithStyle(slack.StylePrimary)
+		blocks = append(blocks, slack.NewActionBlock("", button))
+	}
+	blocks = append(blocks, contextBlock(taskName))
+	return SlackMessage{
+		Text:   fmt.Sprintf("Waiting for approval (Task: %s)", taskName),
+		Blocks: blocks,
+	}
+}
+
+// FormatSlackApprovedMessage returns the message that replaces an approval
+// request once the Task has been approved by the given Slack user.
+func FormatSlackApprovedMessage(taskName, userID string) SlackMessage {
+	return SlackMessage{
+		Text: fmt.Sprintf("Approved by <@%s> (Task: %s)", userID, taskName),
+		Blocks: []slack.Block{
+			slack.NewSectionBlock(
+				slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(":white_check_mark: *Approved by <@%s>*", userID), false, false),
+				nil, nil,
+			),
+			contextBlock(taskName),
+		},
+	}
+}
+
 // phaseHeaderText maps each phase to its leading Block Kit section text.
 // Phases without an entry (e.g. "succeeded") get no header block.
 var phaseHeaderText = map[string]string{
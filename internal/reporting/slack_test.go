@@ -636,3 +636,31 @@ func assertContextContains(t *testing.T, block slack.Block, substr string) {
 	}
 	t.Errorf("context block does not contain %q", substr)
 }
+
+func TestFormatSlackApprovalRequest(t *testing.T) {
+	t.Run("with approve button", func(t *testing.T) {
+		got := FormatSlackApprovalRequest("default", "spawner-123", true)
+		if got.Text != "Waiting for approval (Task: spawner-123)" {
+			t.Errorf("fallback text = %q", got.Text)
+		}
+		assertBlockCount(t, got.Blocks, 3) // section + actions + context
+		assertSectionText(t, got.Blocks[0], ":raised_hand: *Waiting for approval*")
+		actions, ok := got.Blocks[1].(*slack.ActionBlock)
+		if !ok {
+			t.Fatalf("Expected *slack.ActionBlock, got %T", got.Blocks[1])
+		}
+		button, ok := actions.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+		if !ok {
+			t.Fatalf("Expected *slack.ButtonBlockElement, got %T", actions.Elements.ElementSet[0])
+		}
+		if button.ActionID != SlackApproveActionID || button.Value != "default/spawner-123" {
+			t.Errorf("button = %q/%q, want %q/%q", button.ActionID, button.Value, SlackApproveActionID, "default/spawner-123")
+		}
+		assertContextContains(t, got.Blocks[2], "spawner-123")
+	})
+
+	t.Run("without approve button", func(t *testing.T) {
+		got := FormatSlackApprovalRequest("default", "spawner-123", false)
+		assertBlockCount(t, got.Blocks, 2) // section + context
+	})
+}
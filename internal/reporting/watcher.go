@@ -8,6 +8,8 @@ import (
 
 	"github.com/slack-go/slack"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -211,15 +213,8 @@ func (tr *TaskReporter) reportViaComment(ctx context.Context, task *kelos.Task)
 		return fmt.Errorf("parsing source number %q: %w", numberStr, err)
 	}
 
-	var desiredPhase string
-	switch task.Status.Phase {
-	case kelos.TaskPhasePending, kelos.TaskPhaseRunning, kelos.TaskPhaseWaiting:
-		desiredPhase = "accepted"
-	case kelos.TaskPhaseSucceeded:
-		desiredPhase = "succeeded"
-	case kelos.TaskPhaseFailed:
-		desiredPhase = "failed"
-	default:
+	desiredPhase := statusCommentPhase(task)
+	if desiredPhase == "" {
 		return nil
 	}
 
@@ -262,15 +257,7 @@ func (tr *TaskReporter) reportViaComment(ctx context.Context, task *kelos.Task)
 		return tr.persistReportingState(ctx, task, commentID, desiredPhase)
 	}
 
-	var body string
-	switch desiredPhase {
-	case "accepted":
-		body = FormatAcceptedComment(task.Name)
-	case "succeeded":
-		body = FormatSucceededComment(task.Name)
-	case "failed":
-		body = FormatFailedComment(task.Name)
-	}
+	body := statusCommentBody(task, desiredPhase)
 
 	if annotations[AnnotationGitHubCommentMode] == string(kelos.GitHubCommentModeSticky) {
 		marker, err := stickyCommentMarker(task)
@@ -308,6 +295,56 @@ func (tr *TaskReporter) reportViaComment(ctx context.Context, task *kelos.Task)
 	return tr.persistReportingState(ctx, task, commentID, desiredPhase)
 }
 
+// statusCommentPhase returns the phase a status comment reports for task,
+// or "" when the task's phase is not reported.
+func statusCommentPhase(task *kelos.Task) string {
+	switch task.Status.Phase {
+	case kelos.TaskPhasePending, kelos.TaskPhaseRunning, kelos.TaskPhaseWaiting:
+		return "accepted"
+	case kelos.TaskPhaseAwaitingApproval:
+		return "awaiting-approval"
+	case kelos.TaskPhaseSucceeded:
+		return "succeeded"
+	case kelos.TaskPhaseFailed:
+		return "failed"
+	default:
+		return ""
+	}
+}
+
+// statusCommentBody returns the status comment body for task in phase, as
+// returned by statusCommentPhase.
+func statusCommentBody(task *kelos.Task, phase string) string {
+	switch phase {
+	case "accepted":
+		return FormatAcceptedComment(task.Name)
+	case "awaiting-approval":
+		var approveComment string
+		if task.Spec.Approval != nil {
+			approveComment = task.Spec.Approval.GitHubComment
+		}
+		return FormatAwaitingApprovalComment(task.Name, approveComment)
+	case "succeeded":
+		return FormatSucceededComment(task.Name)
+	case "failed":
+		if message, ok := taskTimeoutMessage(task); ok {
+			return FormatTimedOutComment(task.Name, message)
+		}
+		return FormatFailedComment(task.Name)
+	}
+	return ""
+}
+
+// taskTimeoutMessage returns the message of a Task that failed because it
+// exceeded its waiting or pending timeout.
+func taskTimeoutMessage(task *kelos.Task) (string, bool) {
+	cond := meta.FindStatusCondition(task.Status.Conditions, kelos.TaskConditionTimedOut)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return "", false
+	}
+	return cond.Message, true
+}
+
 func stickyCommentMarker(task *kelos.Task) (string, error) {
 	spawnerName := task.Labels["kelos.dev/taskspawner"]
 	if spawnerName == "" {
@@ -340,7 +377,7 @@ func (tr *TaskReporter) reportViaCheckRun(ctx context.Context, task *kelos.Task)
 	var status, conclusion string
 	var output *checkRunOutput
 	switch task.Status.Phase {
-	case kelos.TaskPhasePending, kelos.TaskPhaseRunning, kelos.TaskPhaseWaiting:
+	case kelos.TaskPhasePending, kelos.TaskPhaseRunning, kelos.TaskPhaseWaiting, kelos.TaskPhaseAwaitingApproval:
 		desiredPhase = "in_progress"
 		status = "in_progress"
 		output = &checkRunOutput{
@@ -363,6 +400,10 @@ func (tr *TaskReporter) reportViaCheckRun(ctx context.Context, task *kelos.Task)
 			Title:   checkName + " — Failed",
 			Summary: fmt.Sprintf("Agent task `%s` has failed", task.Name),
 		}
+		if message, ok := taskTimeoutMessage(task); ok {
+			output.Title = checkName + " — Timed Out"
+			output.Summary = fmt.Sprintf("Agent task `%s` has timed out: %s", task.Name, message)
+		}
 	default:
 		return nil
 	}
@@ -527,15 +568,8 @@ func (tr *SlackTaskReporter) ReportTaskStatus(ctx context.Context, task *kelos.T
 		return nil
 	}
 
-	var desiredPhase string
-	switch task.Status.Phase {
-	case kelos.TaskPhasePending, kelos.TaskPhaseRunning, kelos.TaskPhaseWaiting:
-		desiredPhase = "accepted"
-	case kelos.TaskPhaseSucceeded:
-		desiredPhase = "succeeded"
-	case kelos.TaskPhaseFailed:
-		desiredPhase = "failed"
-	default:
+	desiredPhase := statusCommentPhase(task)
+	if desiredPhase == "" {
 		return nil
 	}
 
@@ -548,7 +582,13 @@ func (tr *SlackTaskReporter) ReportTaskStatus(ctx context.Context, task *kelos.T
 		return nil
 	}
 
-	msgs := FormatSlackTransitionMessage(desiredPhase, task.Name, task.Status.Message, task.Status.Results)
+	var msgs []SlackMessage
+	if desiredPhase == "awaiting-approval" {
+		withButton := task.Spec.Approval != nil && len(task.Spec.Approval.SlackApprovers) > 0
+		msgs = []SlackMessage{FormatSlackApprovalRequest(task.Namespace, task.Name, withButton)}
+	} else {
+		msgs = FormatSlackTransitionMessage(desiredPhase, task.Name, task.Status.Message, task.Status.Results)
+	}
 
 	// For terminal phases, try to edit the existing progress message
 	// in-place. When the response is a single message, this keeps the
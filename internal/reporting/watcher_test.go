@@ -411,6 +411,52 @@ func TestReportTaskStatus_UpdatesCommentOnFailed(t *testing.T) {
 	}
 }
 
+func TestReportTaskStatus_ReportsTimeoutOnFailed(t *testing.T) {
+	server, records := newTestServer(t)
+	defer server.Close()
+
+	task := newTaskWithAnnotations("test-task", "default", kelos.TaskPhaseFailed, map[string]string{
+		AnnotationGitHubReporting:   "enabled",
+		AnnotationSourceNumber:      "42",
+		AnnotationSourceKind:        "issue",
+		AnnotationGitHubCommentID:   "5555",
+		AnnotationGitHubReportPhase: "accepted",
+	})
+	task.Status.Conditions = []metav1.Condition{{
+		Type:    kelos.TaskConditionTimedOut,
+		Status:  metav1.ConditionTrue,
+		Reason:  kelos.TaskReasonPendingTimeout,
+		Message: "Agent pod did not start running within pendingTimeoutSeconds (300s): ImagePullBackOff",
+	}}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(task).
+		Build()
+
+	tr := &TaskReporter{
+		Client: cl,
+		Reporter: &GitHubReporter{
+			Owner:   "owner",
+			Repo:    "repo",
+			Token:   "token",
+			BaseURL: server.URL,
+		},
+	}
+
+	if err := tr.ReportTaskStatus(context.Background(), task); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(*records) != 1 {
+		t.Fatalf("Expected 1 API call, got %d", len(*records))
+	}
+	body := (*records)[0].body
+	if !strings.Contains(body, "timed out") || !strings.Contains(body, "ImagePullBackOff") {
+		t.Errorf("Expected comment to report the timeout, got %q", body)
+	}
+}
+
 func TestReportTaskStatus_SkipsDuplicateReport(t *testing.T) {
 	server, records := newTestServer(t)
 	defer server.Close()
@@ -0,0 +1,39 @@
+// Package results defines the durable channel through which kelos-capture
+// hands the outputs of an agent run to the controller, so that capturing
+// them does not depend on container logs that may be rotated or truncated.
+//
+// Small payloads are written to the agent container's termination message.
+// Payloads that do not fit, and the outputs of Tasks run by pooled workers
+// whose container outlives the Task, are written to a per-Task ConfigMap
+// that the agent may only read and update. Both carry the same
+// marker-delimited block kelos-capture prints on stdout.
+package results
+
+import "k8s.io/apimachinery/pkg/types"
+
+// Environment variables through which the controller configures where
+// kelos-capture writes the outputs.
+const (
+	// EnvTerminationMessagePath is the termination message file of the
+	// agent container. It is only set for Job-backed Tasks.
+	EnvTerminationMessagePath = "KELOS_TERMINATION_MESSAGE_PATH"
+	// EnvConfigMap is the name of the Task's results ConfigMap in the
+	// namespace of the pod.
+	EnvConfigMap = "KELOS_RESULTS_CONFIGMAP"
+)
+
+// ConfigMapKey is the key of the results ConfigMap holding the outputs.
+const ConfigMapKey = "outputs"
+
+// MaxTerminationMessageBytes is the size limit the kubelet enforces on a
+// container's termination message.
+const MaxTerminationMessageBytes = 4096
+
+// ConfigMapName returns the name of the results ConfigMap of the Task with
+// the given UID. The Role and RoleBinding that grant the agent access to it
+// share the name. Naming it after the UID rather than the Task name keeps
+// it from colliding with unrelated objects and with those of an earlier
+// Task of the same name.
+func ConfigMapName(taskUID types.UID) string {
+	return "kelos-results-" + string(taskUID)
+}
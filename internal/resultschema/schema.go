@@ -0,0 +1,122 @@
+package resultschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// FilePath is where the agent writes its structured results as a JSON
+	// object. kelos-capture reads the file after the agent exits and reports
+	// each property as a result.
+	FilePath = "/tmp/kelos-results.json"
+
+	// resourceURL identifies the schema document inside the compiler.
+	resourceURL = "results-schema.json"
+)
+
+// Schema is a compiled results schema.
+type Schema struct {
+	schema *jsonschema.Schema
+	json   string
+}
+
+// Compile parses a JSON Schema written as JSON or YAML. References to
+// other documents are not resolved.
+func Compile(source string) (*Schema, error) {
+	raw, err := yaml.YAMLToJSON([]byte(source))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return nil, errors.New("schema must be an object")
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(noLoader{})
+	if err := c.AddResource(resourceURL, doc); err != nil {
+		return nil, err
+	}
+	compiled, err := c.Compile(resourceURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: compiled, json: string(raw)}, nil
+}
+
+// JSON returns the schema as compact JSON.
+func (s *Schema) JSON() string {
+	return s.json
+}
+
+// Validate checks results against the schema. Result values are always
+// strings, so the schema sees an object with string properties.
+func (s *Schema) Validate(results map[string]string) error {
+	instance := make(map[string]any, len(results))
+	for k, v := range results {
+		instance[k] = v
+	}
+	err := s.schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	return errors.New(validationMessage(verr.Error()))
+}
+
+// validationMessage flattens the multi-line validation error into a single
+// line and drops the header naming the schema location.
+func validationMessage(msg string) string {
+	var parts []string
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "jsonschema validation failed") {
+			continue
+		}
+		parts = append(parts, strings.TrimPrefix(line, "- "))
+	}
+	if len(parts) == 0 {
+		return msg
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Instructions tells the agent how to report results matching the schema.
+func (s *Schema) Instructions() string {
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(s.json), "", "  "); err != nil {
+		pretty.Reset()
+		pretty.WriteString(s.json)
+	}
+	var b strings.Builder
+	b.WriteString("## Structured results\n\n")
+	b.WriteString("Before you finish, write your results as a single JSON object to ")
+	b.WriteString(FilePath)
+	b.WriteString(". Each property is recorded as a Task result. Use string values on a single line. ")
+	b.WriteString("The results must match this JSON Schema, or the Task fails:\n\n")
+	b.WriteString("```json\n")
+	b.WriteString(pretty.String())
+	b.WriteString("\n```\n")
+	return b.String()
+}
+
+// noLoader refuses to load referenced documents so that a schema cannot
+// make the controller read local files or the network.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("loading %q: external references are not supported", url)
+}
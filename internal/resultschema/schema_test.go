@@ -0,0 +1,157 @@
+package resultschema
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["summary", "risk"],
+  "properties": {
+    "summary": {"type": "string", "minLength": 1},
+    "risk": {"enum": ["low", "medium", "high"]}
+  }
+}`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{
+			name:   "json",
+			source: testSchema,
+		},
+		{
+			name: "yaml",
+			source: `type: object
+required: [summary]
+properties:
+  summary:
+    type: string
+`,
+		},
+		{
+			name:    "not an object",
+			source:  `["summary"]`,
+			wantErr: "schema must be an object",
+		},
+		{
+			name:    "malformed",
+			source:  `{"type": `,
+			wantErr: "parsing schema",
+		},
+		{
+			name:    "invalid keyword value",
+			source:  `{"type": "object", "required": "summary"}`,
+			wantErr: "required",
+		},
+		{
+			name:    "external reference",
+			source:  `{"$ref": "file:///etc/passwd"}`,
+			wantErr: "external references are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile(tt.source)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Compile() error = %v", err)
+				}
+				if s.JSON() == "" {
+					t.Error("JSON() is empty")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Compile() error = nil, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Compile(testSchema)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		results map[string]string
+		wantErr []string
+	}{
+		{
+			name:    "valid with extra keys",
+			results: map[string]string{"summary": "Fixed the bug", "risk": "low", "branch": "main"},
+		},
+		{
+			name:    "missing key",
+			results: map[string]string{"summary": "Fixed the bug", "branch": "main"},
+			wantErr: []string{"risk"},
+		},
+		{
+			name:    "value not in enum",
+			results: map[string]string{"summary": "Fixed the bug", "risk": "none"},
+			wantErr: []string{"risk"},
+		},
+		{
+			name:    "no results",
+			wantErr: []string{"summary", "risk"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate(tt.results)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() error = nil, want error")
+			}
+			msg := err.Error()
+			if strings.Contains(msg, "\n") || strings.Contains(msg, "jsonschema validation failed") {
+				t.Errorf("Validate() error = %q, want a single line without the schema header", msg)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(msg, want) {
+					t.Errorf("Validate() error = %q, want it to contain %q", msg, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationMessage(t *testing.T) {
+	msg := "jsonschema validation failed with 'file:///results-schema.json#'\n" +
+		"- at '': missing property 'risk'\n" +
+		"- at '/summary': minLength: got 0, want 1\n"
+	want := "at '': missing property 'risk'; at '/summary': minLength: got 0, want 1"
+	if got := validationMessage(msg); got != want {
+		t.Errorf("validationMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestInstructions(t *testing.T) {
+	s, err := Compile(testSchema)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	got := s.Instructions()
+	for _, want := range []string{FilePath, `"required": [`, `"summary"`, "```json"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Instructions() = %q, want it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sessionbuilder
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+const (
+	// AnnotationFromTask records the Task a Session was opened from.
+	AnnotationFromTask = "kelos.dev/from-task"
+
+	// MaxTaskTranscriptBytes bounds the part of a Task's transcript that is
+	// included in the initial prompt of a Session opened from the Task.
+	MaxTaskTranscriptBytes = 32 * 1024
+
+	// DefaultTaskSessionStorage is the workspace storage requested by a
+	// Session opened from a Task.
+	DefaultTaskSessionStorage = "10Gi"
+
+	transcriptTruncatedMarker = "[earlier transcript truncated]\n"
+)
+
+// TaskWorker returns the effective worker of a Task: fields of spec.worker
+// take precedence over the legacy top-level fields, and a pooled Task runs
+// with the worker of its WorkerPool.
+func TaskWorker(task *kelos.Task, pool *kelos.WorkerPool) kelos.WorkerSpec {
+	if task.Spec.WorkerPoolRef != nil && pool != nil {
+		return *pool.Spec.Worker.DeepCopy()
+	}
+	worker := kelos.WorkerSpec{
+		Type:            task.Spec.Type,
+		Credentials:     task.Spec.Credentials,
+		Model:           task.Spec.Model,
+		Effort:          task.Spec.Effort,
+		Image:           task.Spec.Image,
+		WorkspaceRef:    task.Spec.WorkspaceRef,
+		AgentConfigRefs: task.Spec.AgentConfigRefs,
+		PodOverrides:    task.Spec.PodOverrides,
+	}
+	if w := task.Spec.Worker; w != nil {
+		if w.Type != "" {
+			worker.Type = w.Type
+		}
+		if w.Credentials != nil {
+			worker.Credentials = w.Credentials
+		}
+		if w.Model != "" {
+			worker.Model = w.Model
+		}
+		if w.Effort != "" {
+			worker.Effort = w.Effort
+		}
+		if w.Image != "" {
+			worker.Image = w.Image
+		}
+		if w.WorkspaceRef != nil {
+			worker.WorkspaceRef = w.WorkspaceRef
+		}
+		if len(w.AgentConfigRefs) > 0 {
+			worker.AgentConfigRefs = w.AgentConfigRefs
+		}
+		if w.PodOverrides != nil {
+			worker.PodOverrides = w.PodOverrides
+		}
+	}
+	return *worker.DeepCopy()
+}
+
+// TaskBranch returns the branch a Task worked on: its spec.branch, the
+// branch it inherited from the Task it continued, or the branch it reported
+// in its results.
+func TaskBranch(task *kelos.Task) string {
+	if task.Spec.Branch != "" {
+		return task.Spec.Branch
+	}
+	if task.Status.InheritedBranch != "" {
+		return task.Status.InheritedBranch
+	}
+	return task.Status.Results["branch"]
+}
+
+// TaskSessionName returns the default name of a Session opened from the
+// named Task.
+func TaskSessionName(taskName string) string {
+	const suffix = "-session"
+	prefix := taskName
+	if maxPrefix := 63 - len(suffix); len(prefix) > maxPrefix {
+		prefix = strings.TrimRight(prefix[:maxPrefix], "-.")
+	}
+	return prefix + suffix
+}
+
+// FromTask creates a Session that lets a human take over from a Task. The
+// Session runs the Task's worker on the Task's branch, and its initial
+// prompt carries the Task's prompt, outcome and the end of its transcript.
+// Workspace changes the Task did not push are not carried over.
+func FromTask(name, namespace string, task *kelos.Task, worker kelos.WorkerSpec, transcript string) (*kelos.Session, error) {
+	switch worker.Type {
+	case "claude-code", "codex", "opencode":
+	default:
+		return nil, fmt.Errorf("task %s uses agent type %q, which Sessions do not support", task.Name, worker.Type)
+	}
+	worker = *worker.DeepCopy()
+	// A deadline meant to bound a headless run would end the Session.
+	if worker.PodOverrides != nil {
+		worker.PodOverrides.ActiveDeadlineSeconds = nil
+	}
+
+	spec := kelos.SessionSpec{
+		Worker:        worker,
+		InitialPrompt: TaskInitialPrompt(task, transcript),
+		VolumeClaimTemplate: &corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(DefaultTaskSessionStorage),
+				},
+			},
+		},
+	}
+	if worker.WorkspaceRef != nil {
+		spec.InitialBranch = TaskBranch(task)
+	}
+
+	session := &kelos.Session{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				AnnotationFromTask: task.Name,
+			},
+		},
+		Spec: spec,
+	}
+	session.SetGroupVersionKind(kelos.GroupVersion.WithKind("Session"))
+	return session, nil
+}
+
+// TaskInitialPrompt returns the initial prompt of a Session opened from a
+// Task. Only the last MaxTaskTranscriptBytes of the transcript are kept.
+func TaskInitialPrompt(task *kelos.Task, transcript string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are taking over from the Kelos Task %q", task.Name)
+	if task.Status.Phase != "" {
+		fmt.Fprintf(&b, ", which ended in phase %s", task.Status.Phase)
+	}
+	b.WriteString(".")
+	if task.Status.Message != "" {
+		fmt.Fprintf(&b, " Its status message was: %s", task.Status.Message)
+	}
+	if branch := TaskBranch(task); branch != "" {
+		fmt.Fprintf(&b, "\nThe Task worked on branch %q; only the changes it pushed to that branch are available.", branch)
+	}
+	fmt.Fprintf(&b, "\n\nThe Task's prompt was:\n\n%s\n", task.Spec.Prompt)
+	if transcript = strings.TrimSpace(transcript); transcript != "" {
+		fmt.Fprintf(&b, "\nThe end of the Task's agent transcript:\n\n%s\n", truncateTranscript(transcript))
+	}
+	b.WriteString("\nSummarize where the Task stopped and what remains to be done, then wait for instructions.")
+	return b.String()
+}
+
+// truncateTranscript keeps the end of transcript, cut at a line boundary.
+func truncateTranscript(transcript string) string {
+	if len(transcript) <= MaxTaskTranscriptBytes {
+		return transcript
+	}
+	tail := transcript[len(transcript)-MaxTaskTranscriptBytes+len(transcriptTruncatedMarker):]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return transcriptTruncatedMarker + tail
+}
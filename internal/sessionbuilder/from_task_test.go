@@ -0,0 +1,147 @@
+package sessionbuilder
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+)
+
+func newFromTaskTestTask() *kelos.Task {
+	deadline := int64(3600)
+	return &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "fix-bug", Namespace: "default"},
+		Spec: kelos.TaskSpec{
+			Type:        "claude-code",
+			Prompt:      "Fix the flaky scheduler test",
+			Credentials: &kelos.Credentials{Type: kelos.CredentialTypeOAuth, SecretRef: &kelos.SecretReference{Name: "creds"}},
+			Model:       "sonnet",
+			Worker: &kelos.WorkerSpec{
+				Model:           "opus",
+				WorkspaceRef:    &kelos.WorkspaceReference{Name: "kelos"},
+				AgentConfigRefs: []kelos.AgentConfigReference{{Name: "reviewer"}},
+				PodOverrides:    &kelos.PodOverrides{ActiveDeadlineSeconds: &deadline},
+			},
+		},
+		Status: kelos.TaskStatus{
+			Phase:   kelos.TaskPhaseFailed,
+			Message: "Agent exited with code 1",
+			Results: map[string]string{"branch": "kelos/fix-bug"},
+		},
+	}
+}
+
+func TestTaskWorkerPrefersWorkerFields(t *testing.T) {
+	task := newFromTaskTestTask()
+
+	worker := TaskWorker(task, nil)
+	if worker.Type != "claude-code" || worker.Model != "opus" {
+		t.Errorf("type = %q, model = %q; want claude-code and the worker model", worker.Type, worker.Model)
+	}
+	if worker.Credentials == nil || worker.Credentials.SecretRef.Name != "creds" {
+		t.Errorf("credentials = %+v, want the legacy credentials", worker.Credentials)
+	}
+	if worker.WorkspaceRef == nil || worker.WorkspaceRef.Name != "kelos" {
+		t.Errorf("workspaceRef = %+v, want kelos", worker.WorkspaceRef)
+	}
+
+	worker.Model = "changed"
+	worker.WorkspaceRef.Name = "changed"
+	if task.Spec.Worker.Model != "opus" || task.Spec.Worker.WorkspaceRef.Name != "kelos" {
+		t.Error("TaskWorker shares state with the Task")
+	}
+}
+
+func TestTaskWorkerUsesWorkerPool(t *testing.T) {
+	task := &kelos.Task{Spec: kelos.TaskSpec{WorkerPoolRef: &kelos.WorkerPoolReference{Name: "pool"}}}
+	pool := &kelos.WorkerPool{Spec: kelos.WorkerPoolSpec{Worker: kelos.WorkerSpec{Type: "codex", Model: "gpt-5"}}}
+
+	worker := TaskWorker(task, pool)
+	if worker.Type != "codex" || worker.Model != "gpt-5" {
+		t.Errorf("worker = %+v, want the WorkerPool worker", worker)
+	}
+}
+
+func TestFromTask(t *testing.T) {
+	task := newFromTaskTestTask()
+
+	session, err := FromTask("fix-bug-session", "default", task, TaskWorker(task, nil), "Running the tests\nThe test still fails\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Annotations[AnnotationFromTask] != "fix-bug" {
+		t.Errorf("annotations = %v, want the Task name", session.Annotations)
+	}
+	if session.Spec.InitialBranch != "kelos/fix-bug" {
+		t.Errorf("initialBranch = %q, want the branch of the Task", session.Spec.InitialBranch)
+	}
+	if session.Spec.Worker.PodOverrides.ActiveDeadlineSeconds != nil {
+		t.Error("expected the Task deadline to be dropped")
+	}
+	if task.Spec.Worker.PodOverrides.ActiveDeadlineSeconds == nil {
+		t.Error("FromTask modified the Task")
+	}
+	if session.Spec.VolumeClaimTemplate == nil {
+		t.Error("expected a persistent workspace")
+	}
+	for _, want := range []string{
+		`"fix-bug", which ended in phase Failed`,
+		"Agent exited with code 1",
+		"Fix the flaky scheduler test",
+		"The test still fails",
+	} {
+		if !strings.Contains(session.Spec.InitialPrompt, want) {
+			t.Errorf("initialPrompt does not contain %q:\n%s", want, session.Spec.InitialPrompt)
+		}
+	}
+}
+
+func TestFromTaskWithoutWorkspaceHasNoBranch(t *testing.T) {
+	task := newFromTaskTestTask()
+	task.Spec.Worker.WorkspaceRef = nil
+
+	session, err := FromTask("fix-bug-session", "default", task, TaskWorker(task, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Spec.InitialBranch != "" {
+		t.Errorf("initialBranch = %q, want none without a workspace", session.Spec.InitialBranch)
+	}
+	if strings.Contains(session.Spec.InitialPrompt, "transcript") {
+		t.Errorf("initialPrompt mentions a transcript without one:\n%s", session.Spec.InitialPrompt)
+	}
+}
+
+func TestFromTaskRejectsUnsupportedAgentType(t *testing.T) {
+	task := newFromTaskTestTask()
+	task.Spec.Type = "gemini"
+
+	if _, err := FromTask("fix-bug-session", "default", task, TaskWorker(task, nil), ""); err == nil {
+		t.Fatal("expected an unsupported agent type error")
+	}
+}
+
+func TestTaskInitialPromptTruncatesTranscript(t *testing.T) {
+	task := newFromTaskTestTask()
+	transcript := strings.Repeat("early line\n", MaxTaskTranscriptBytes/10) + "last line"
+
+	prompt := TaskInitialPrompt(task, transcript)
+	if !strings.Contains(prompt, transcriptTruncatedMarker+"early line\n") {
+		t.Error("expected the transcript to be truncated at a line boundary")
+	}
+	if !strings.Contains(prompt, "last line") {
+		t.Error("expected the end of the transcript to be kept")
+	}
+	if len(prompt) > MaxTaskTranscriptBytes+len(task.Spec.Prompt)+1024 {
+		t.Errorf("prompt length = %d, want the transcript bounded", len(prompt))
+	}
+}
+
+func TestTaskSessionNameFitsDNSLabel(t *testing.T) {
+	name := TaskSessionName(strings.Repeat("a", 63))
+	if len(name) > 63 || !strings.HasSuffix(name, "-session") {
+		t.Errorf("name = %q, want at most 63 characters with a session suffix", name)
+	}
+}
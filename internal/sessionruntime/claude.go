@@ -386,6 +386,9 @@ func (p *ClaudeProvider) handleClaudeLine(line []byte, sink EventSink) (*claudeT
 		ModelUsage     map[string]claudeModelUsage `json:"modelUsage"`
 		Event          json.RawMessage             `json:"event"`
 		Message        json.RawMessage             `json:"message"`
+		// PermissionDenials lists the tool calls of the turn that Claude Code
+		// denied, including those denied by the permission policy.
+		PermissionDenials []claudePermissionDenial `json:"permission_denials"`
 	}
 	if err := json.Unmarshal(line, &envelope); err != nil {
 		return nil, fmt.Errorf("decoding Claude Code event: %w", err)
@@ -415,6 +418,11 @@ func (p *ClaudeProvider) handleClaudeLine(line []byte, sink EventSink) (*claudeT
 	case "assistant", "user":
 		p.emitClaudeMessage(envelope.Type, envelope.Message, sink)
 	case "result":
+		if sink != nil {
+			for _, denial := range envelope.PermissionDenials {
+				sink.Emit(denial.event())
+			}
+		}
 		result := &claudeTurnResult{}
 		completion := claudecode.Result{
 			Subtype:        envelope.Subtype,
@@ -436,6 +444,26 @@ func (p *ClaudeProvider) handleClaudeLine(line []byte, sink EventSink) (*claudeT
 	return nil, nil
 }
 
+type claudePermissionDenial struct {
+	ToolName  string `json:"tool_name"`
+	ToolUseID string `json:"tool_use_id"`
+	ToolInput struct {
+		Command  string `json:"command"`
+		FilePath string `json:"file_path"`
+	} `json:"tool_input"`
+}
+
+func (d claudePermissionDenial) event() Event {
+	text := fmt.Sprintf("tool %q was denied", d.ToolName)
+	switch {
+	case d.ToolInput.Command != "":
+		text = fmt.Sprintf("command %q was denied", d.ToolInput.Command)
+	case d.ToolInput.FilePath != "":
+		text = fmt.Sprintf("%s of %q was denied", d.ToolName, d.ToolInput.FilePath)
+	}
+	return Event{Type: EventPermissionDenied, ToolID: d.ToolUseID, ToolName: d.ToolName, Text: text}
+}
+
 type claudeModelUsage struct {
 	ContextWindow int64 `json:"contextWindow"`
 }
@@ -15,6 +15,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
 )
 
 type codexResponse struct {
@@ -1051,6 +1053,17 @@ func (p *CodexProvider) emitCodexItem(method string, params json.RawMessage, sin
 		}
 	}
 	sink.Emit(Event{Type: eventType, ToolID: item.ID, ToolName: name, Output: output, Status: status})
+	if item.Type == "commandExecution" && method == "item/completed" && status != "completed" {
+		// Codex does not run commands forbidden by the exec policy that the
+		// entrypoint wrote from the permission policy.
+		reason, denied := agentpermissions.Denied(p.config.Permissions, agentpermissions.Action{Tool: agentpermissions.ToolShell, Command: item.Command})
+		if item.Status == "declined" && !denied {
+			reason, denied = fmt.Sprintf("command %q was declined", item.Command), true
+		}
+		if denied {
+			sink.Emit(Event{Type: EventPermissionDenied, ToolID: item.ID, ToolName: name, Text: reason})
+		}
+	}
 }
 
 func (p *CodexProvider) appendCodexCommandOutput(itemID, delta string) {
@@ -273,7 +273,7 @@ func projectHistory(source []Event) ([]historyItem, HistoryState, []Event) {
 				event.Goal.Objective = boundedHistoryText(event.Goal.Objective, maxHistoryMessageBytes)
 			}
 			addItem(event.ID, event.ID, normalizedHistoryEvent(event))
-		case EventRuntimeRecovered, EventError, EventTurnInterrupting:
+		case EventRuntimeRecovered, EventError, EventTurnInterrupting, EventPermissionDenied:
 			event.Text = boundedHistoryText(event.Text, maxHistoryNoticeBytes)
 			addItem(event.ID, event.ID, normalizedHistoryEvent(event))
 		case EventTurnCompleted:
@@ -12,9 +12,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/agentpermissions"
 )
 
 const (
@@ -315,13 +320,14 @@ func (p *OpenCodeProvider) openSession(ctx context.Context) error {
 		return fmt.Errorf("reading saved OpenCode session ID: %w", err)
 	}
 
+	rules := []map[string]string{{
+		"permission": "*",
+		"pattern":    "*",
+		"action":     "allow",
+	}}
 	request := map[string]any{
-		"agent": "build",
-		"permission": []map[string]string{{
-			"permission": "*",
-			"pattern":    "*",
-			"action":     "allow",
-		}},
+		"agent":      "build",
+		"permission": append(rules, openCodePermissionRules(p.config.Permissions)...),
 	}
 	model, err := openCodeModel(p.config.Model, p.config.Effort)
 	if err != nil {
@@ -936,9 +942,11 @@ func (p *OpenCodeProvider) questionPath(eventType, requestID, action string) (st
 
 func (p *OpenCodeProvider) handlePermission(eventType string, raw json.RawMessage) {
 	var properties struct {
-		ID        string `json:"id"`
-		RequestID string `json:"requestID"`
-		SessionID string `json:"sessionID"`
+		ID         string   `json:"id"`
+		RequestID  string   `json:"requestID"`
+		SessionID  string   `json:"sessionID"`
+		Permission string   `json:"permission"`
+		Patterns   []string `json:"patterns"`
 	}
 	if json.Unmarshal(raw, &properties) != nil || properties.SessionID != p.currentSessionID() {
 		return
@@ -960,6 +968,7 @@ func (p *OpenCodeProvider) handlePermission(eventType string, raw json.RawMessag
 		return
 	}
 	p.permissions[requestID] = struct{}{}
+	sink := p.activeSink
 	p.activeMu.Unlock()
 
 	path := "/permission/" + url.PathEscape(requestID) + "/reply"
@@ -968,9 +977,109 @@ func (p *OpenCodeProvider) handlePermission(eventType string, raw json.RawMessag
 		path = "/api/session/" + url.PathEscape(p.currentSessionID()) + "/permission/" + url.PathEscape(requestID) + "/reply"
 		includeDirectory = false
 	}
-	if err := p.client.doJSON(p.ctx, http.MethodPost, path, includeDirectory, map[string]string{"reply": "once"}, nil); err != nil {
-		p.failActiveTurn(fmt.Errorf("approving OpenCode permission: %w", err))
+	reason, denied := openCodePermissionDenied(p.config.Permissions, properties.Permission, properties.Patterns)
+	if !denied {
+		if err := p.client.doJSON(p.ctx, http.MethodPost, path, includeDirectory, map[string]string{"reply": "once"}, nil); err != nil {
+			p.failActiveTurn(fmt.Errorf("approving OpenCode permission: %w", err))
+		}
+		return
+	}
+	reply := map[string]string{"reply": "reject", "message": "Denied by the permission policy: " + reason}
+	if err := p.client.doJSON(p.ctx, http.MethodPost, path, includeDirectory, reply, nil); err != nil {
+		p.failActiveTurn(fmt.Errorf("rejecting OpenCode permission: %w", err))
+		return
+	}
+	sink.Emit(Event{Type: EventPermissionDenied, ToolName: properties.Permission, Text: reason})
+}
+
+// openCodePermissionRules translates policy into OpenCode session rules.
+// OpenCode applies the last matching rule, so the rules follow the
+// allow-all rule. Commands and edits that the policy may deny are asked
+// about, and handlePermission rejects the ones it denies so that the
+// Session can report them.
+func openCodePermissionRules(policy *kelos.AgentPermissions) []map[string]string {
+	if agentpermissions.Empty(policy) {
+		return nil
+	}
+	var rules []map[string]string
+	add := func(permission, pattern, action string) {
+		rules = append(rules, map[string]string{"permission": permission, "pattern": pattern, "action": action})
+	}
+	for _, tool := range agentpermissions.BuiltinTools {
+		if _, denied := agentpermissions.Denied(policy, agentpermissions.Action{Tool: tool}); denied {
+			for _, permission := range openCodeBuiltinPermissions[tool] {
+				add(permission, "*", "deny")
+			}
+		}
+	}
+	limited := agentpermissions.LimitedMCPServers(policy.AllowedTools)
+	servers := make([]string, 0, len(limited))
+	for server := range limited {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+	for _, server := range servers {
+		add(openCodeToolName(server, "*"), "*", "deny")
+		for _, tool := range limited[server] {
+			add(openCodeToolName(server, tool), "*", "allow")
+		}
+	}
+	for _, entry := range policy.DeniedTools {
+		server, tool, ok := agentpermissions.ParseMCPTool(entry)
+		if !ok {
+			continue
+		}
+		if tool == nil {
+			add(openCodeToolName(server, "*"), "*", "deny")
+		} else {
+			add(openCodeToolName(server, *tool), "*", "deny")
+		}
+	}
+	if len(policy.DeniedCommands) > 0 {
+		add("bash", "*", "ask")
+	}
+	if len(policy.DeniedPaths) > 0 {
+		add("edit", "*", "ask")
+	}
+	return rules
+}
+
+// openCodeBuiltinPermissions maps built-in tools to OpenCode permissions.
+var openCodeBuiltinPermissions = map[string][]string{
+	agentpermissions.ToolShell:     {"bash"},
+	agentpermissions.ToolRead:      {"read", "glob", "grep", "list"},
+	agentpermissions.ToolEdit:      {"edit"},
+	agentpermissions.ToolWebFetch:  {"webfetch"},
+	agentpermissions.ToolWebSearch: {"websearch"},
+}
+
+var openCodeToolNameRe = regexp.MustCompile(`[^a-zA-Z0-9_*-]`)
+
+// openCodeToolName returns the name OpenCode gives the tool of an MCP
+// server. Wildcards are kept so that the name can be used as a pattern.
+func openCodeToolName(server, tool string) string {
+	return openCodeToolNameRe.ReplaceAllString(server, "_") + "_" + openCodeToolNameRe.ReplaceAllString(tool, "_")
+}
+
+// openCodePermissionDenied evaluates an OpenCode permission request against
+// policy. Bash requests carry the commands and edit requests the files as
+// patterns.
+func openCodePermissionDenied(policy *kelos.AgentPermissions, permission string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		var action agentpermissions.Action
+		switch permission {
+		case "bash":
+			action = agentpermissions.Action{Tool: agentpermissions.ToolShell, Command: pattern}
+		case "edit":
+			action = agentpermissions.Action{Tool: agentpermissions.ToolEdit, Path: pattern}
+		default:
+			return "", false
+		}
+		if reason, denied := agentpermissions.Denied(policy, action); denied {
+			return reason, true
+		}
 	}
+	return "", false
 }
 
 func (p *OpenCodeProvider) failActiveTurn(err error) {
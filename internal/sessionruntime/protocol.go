@@ -25,6 +25,7 @@ const (
 	EventInputRequested     = "input.requested"
 	EventInputResolved      = "input.resolved"
 	EventFileDiff           = "file.diff"
+	EventPermissionDenied   = "permission.denied"
 	EventTurnCompleted      = "turn.completed"
 	EventError              = "error"
 
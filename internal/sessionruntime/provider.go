@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 )
 
 var (
@@ -24,6 +26,10 @@ type ProviderConfig struct {
 	Effort      string
 	PluginDir   string
 	Environment []string
+	// Permissions is the tool permission policy of the agent. The entrypoint
+	// already wrote it into the provider's native configuration; providers
+	// use it to answer approval requests and to report denied actions.
+	Permissions *kelos.AgentPermissions
 }
 
 // Provider runs turns against one provider-owned conversation.
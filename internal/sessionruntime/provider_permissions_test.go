@@ -1,8 +1,13 @@
 package sessionruntime
 
 import (
+	"encoding/json"
+	"reflect"
 	"slices"
 	"testing"
+	"time"
+
+	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 )
 
 func TestClaudeCommandArgsBypassPermissions(t *testing.T) {
@@ -31,3 +36,116 @@ func TestCodexThreadParamsBypassApprovalsAndSandbox(t *testing.T) {
 		t.Fatalf("approvalsReviewer = %v, want unset", reviewer)
 	}
 }
+
+func TestOpenCodePermissionRules(t *testing.T) {
+	if rules := openCodePermissionRules(nil); rules != nil {
+		t.Fatalf("openCodePermissionRules(nil) = %v, want none", rules)
+	}
+	rules := openCodePermissionRules(&kelos.AgentPermissions{
+		AllowedTools:   []string{"shell", "read", "edit", "mcp:github/get_*"},
+		DeniedTools:    []string{"mcp:slack", "mcp:docs/delete.page"},
+		DeniedCommands: []string{"rm -rf *"},
+		DeniedPaths:    []string{".github/workflows/**"},
+	})
+	want := []map[string]string{
+		{"permission": "webfetch", "pattern": "*", "action": "deny"},
+		{"permission": "websearch", "pattern": "*", "action": "deny"},
+		{"permission": "github_*", "pattern": "*", "action": "deny"},
+		{"permission": "github_get_*", "pattern": "*", "action": "allow"},
+		{"permission": "slack_*", "pattern": "*", "action": "deny"},
+		{"permission": "docs_delete_page", "pattern": "*", "action": "deny"},
+		{"permission": "bash", "pattern": "*", "action": "ask"},
+		{"permission": "edit", "pattern": "*", "action": "ask"},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Fatalf("openCodePermissionRules() = %v, want %v", rules, want)
+	}
+}
+
+func TestOpenCodeProviderRejectsDeniedPermission(t *testing.T) {
+	fake := newFakeOpenCodeServer(t)
+	provider := newTestOpenCodeProvider(t, fake, ProviderConfig{
+		WorkingDir:  t.TempDir(),
+		StateDir:    t.TempDir(),
+		Permissions: &kelos.AgentPermissions{DeniedCommands: []string{"kubectl *"}},
+	})
+	if permissions, ok := fake.createdSessionRequest()["permission"].([]any); !ok || len(permissions) != 2 {
+		t.Fatalf("OpenCode session permissions = %#v", fake.createdSessionRequest()["permission"])
+	}
+
+	sink := newOpenCodeTestSink(nil)
+	turnDone := make(chan error, 1)
+	go func() { turnDone <- provider.RunTurn(t.Context(), TurnInput{Text: "deploy"}, sink) }()
+	receiveOpenCodePrompt(t, fake.prompts)
+
+	fake.emit("session.status", map[string]any{"sessionID": fake.sessionID, "status": map[string]string{"type": "busy"}})
+	fake.emit("permission.asked", map[string]any{"id": "permission-1", "sessionID": fake.sessionID, "permission": "bash", "patterns": []string{"go build ./..."}})
+	fake.emit("permission.asked", map[string]any{"id": "permission-2", "sessionID": fake.sessionID, "permission": "bash", "patterns": []string{"kubectl apply -f deploy.yaml"}})
+	for _, want := range []string{"once", "reject"} {
+		select {
+		case reply := <-fake.permissionReplies:
+			if reply != want {
+				t.Fatalf("OpenCode permission reply = %q, want %q", reply, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("OpenCode permission was not answered")
+		}
+	}
+	fake.emit("session.idle", map[string]string{"sessionID": fake.sessionID})
+	if err := receiveOpenCodeResult(t, turnDone); err != nil {
+		t.Fatalf("RunTurn() error = %v", err)
+	}
+	want := []Event{{
+		Type:     EventPermissionDenied,
+		ToolName: "bash",
+		Text:     `command "kubectl apply -f deploy.yaml" matches denied pattern "kubectl *"`,
+	}}
+	if got := sink.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("OpenCode events = %#v, want %#v", got, want)
+	}
+}
+
+func TestClaudeResultReportsPermissionDenials(t *testing.T) {
+	provider := &ClaudeProvider{}
+	sink := newOpenCodeTestSink(nil)
+	line := `{"type":"result","subtype":"success","permission_denials":[` +
+		`{"tool_name":"Bash","tool_use_id":"toolu-1","tool_input":{"command":"rm -rf build"}},` +
+		`{"tool_name":"Edit","tool_use_id":"toolu-2","tool_input":{"file_path":"/workspace/repo/.github/workflows/ci.yaml"}},` +
+		`{"tool_name":"mcp__github__create_issue","tool_use_id":"toolu-3","tool_input":{"title":"x"}}]}`
+	if _, err := provider.handleClaudeLine([]byte(line), sink); err != nil {
+		t.Fatalf("handleClaudeLine() error = %v", err)
+	}
+	want := []Event{
+		{Type: EventPermissionDenied, ToolID: "toolu-1", ToolName: "Bash", Text: `command "rm -rf build" was denied`},
+		{Type: EventPermissionDenied, ToolID: "toolu-2", ToolName: "Edit", Text: `Edit of "/workspace/repo/.github/workflows/ci.yaml" was denied`},
+		{Type: EventPermissionDenied, ToolID: "toolu-3", ToolName: "mcp__github__create_issue", Text: `tool "mcp__github__create_issue" was denied`},
+	}
+	if got := sink.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Claude events = %#v, want %#v", got, want)
+	}
+}
+
+func TestCodexDeclinedCommandReportsPermissionDenial(t *testing.T) {
+	provider := &CodexProvider{config: ProviderConfig{Permissions: &kelos.AgentPermissions{DeniedCommands: []string{"kubectl *"}}}}
+	sink := newOpenCodeTestSink(nil)
+	for _, item := range []string{
+		`{"item":{"type":"commandExecution","id":"cmd-1","command":"go test ./...","status":"completed"}}`,
+		`{"item":{"type":"commandExecution","id":"cmd-2","command":"kubectl get pods","status":"declined"}}`,
+		`{"item":{"type":"commandExecution","id":"cmd-3","command":"git push","status":"declined"}}`,
+	} {
+		provider.emitCodexItem("item/completed", json.RawMessage(item), sink)
+	}
+	var denials []Event
+	for _, event := range sink.snapshot() {
+		if event.Type == EventPermissionDenied {
+			denials = append(denials, event)
+		}
+	}
+	want := []Event{
+		{Type: EventPermissionDenied, ToolID: "cmd-2", ToolName: "kubectl get pods", Text: `command "kubectl get pods" matches denied pattern "kubectl *"`},
+		{Type: EventPermissionDenied, ToolID: "cmd-3", ToolName: "git push", Text: `command "git push" was declined`},
+	}
+	if !reflect.DeepEqual(denials, want) {
+		t.Fatalf("Codex permission events = %#v, want %#v", denials, want)
+	}
+}
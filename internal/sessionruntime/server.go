@@ -56,6 +56,7 @@ type Config struct {
 	PluginDir            string
 	InitialPrompt        string
 	Environment          []string
+	Permissions          *kelos.AgentPermissions
 	PublishSessionStatus SessionStatusPublisher
 	SessionName          string
 	PodUID               types.UID
@@ -225,6 +226,7 @@ func Run(ctx context.Context, config Config) error {
 		Effort:      config.Effort,
 		PluginDir:   config.PluginDir,
 		Environment: config.Environment,
+		Permissions: config.Permissions,
 	})
 	if err != nil {
 		journal.Close()
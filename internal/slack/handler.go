@@ -115,6 +115,8 @@ func (h *SlackHandler) Start(ctx context.Context) error {
 				h.handleEventsAPI(bgCtx, evt)
 			case socketmode.EventTypeSlashCommand:
 				h.handleSlashCommand(bgCtx, evt)
+			case socketmode.EventTypeInteractive:
+				h.handleInteractive(bgCtx, evt)
 			default:
 				h.log.V(1).Info("Unhandled Socket Mode event type", "type", evt.Type)
 			}
@@ -279,6 +281,95 @@ func (h *SlackHandler) handleSlashCommand(ctx context.Context, evt socketmode.Ev
 	h.routeMessage(ctx, msg)
 }
 
+func (h *SlackHandler) handleInteractive(ctx context.Context, evt socketmode.Event) {
+	callback, ok := evt.Data.(goslack.InteractionCallback)
+	if !ok {
+		h.sm.Ack(*evt.Request)
+		return
+	}
+	h.sm.Ack(*evt.Request)
+
+	if callback.Type != goslack.InteractionTypeBlockActions {
+		return
+	}
+	for _, action := range callback.ActionCallback.BlockActions {
+		if action.ActionID == reporting.SlackApproveActionID {
+			h.handleApproveAction(ctx, &callback, action.Value)
+		}
+	}
+}
+
+// handleApproveAction approves the Task referenced by an Approve button
+// press. Only users listed in the Task's approval.slackApprovers may
+// approve; anyone else gets an ephemeral notice and the Task is left alone.
+func (h *SlackHandler) handleApproveAction(ctx context.Context, callback *goslack.InteractionCallback, value string) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" {
+		h.log.Info("Ignoring approve action with malformed task reference", "value", value)
+		return
+	}
+	userID := callback.User.ID
+	taskLog := h.log.WithValues("task", name, "namespace", namespace, "user", userID)
+
+	task := &kelos.Task{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, task); err != nil {
+		if apierrors.IsNotFound(err) {
+			h.postEphemeral(ctx, callback, fmt.Sprintf("Task %s no longer exists.", name))
+			return
+		}
+		taskLog.Error(err, "Failed to get Task for approval")
+		return
+	}
+	if !slackUserCanApprove(task, userID) {
+		taskLog.Info("Rejected approval from user not listed in slackApprovers")
+		h.postEphemeral(ctx, callback, fmt.Sprintf("You are not allowed to approve Task %s.", name))
+		return
+	}
+
+	if _, approved := task.Annotations[kelos.AnnotationTaskApprove]; !approved {
+		patch := client.MergeFrom(task.DeepCopy())
+		if task.Annotations == nil {
+			task.Annotations = make(map[string]string)
+		}
+		task.Annotations[kelos.AnnotationTaskApprove] = "slack:" + userID
+		if err := h.client.Patch(ctx, task, patch); err != nil {
+			taskLog.Error(err, "Failed to approve Task")
+			return
+		}
+		taskLog.Info("Approved Task from Slack")
+	}
+
+	msg := reporting.FormatSlackApprovedMessage(name, userID)
+	updateCtx, cancel := context.WithTimeout(ctx, postMessageTimeout)
+	defer cancel()
+	if _, _, _, err := h.api.UpdateMessageContext(updateCtx, callback.Channel.ID, callback.Message.Timestamp,
+		goslack.MsgOptionText(msg.Text, false), goslack.MsgOptionBlocks(msg.Blocks...)); err != nil {
+		taskLog.Error(err, "Failed to update approval message")
+	}
+}
+
+// slackUserCanApprove reports whether the Slack user is one of the Task's
+// approvers.
+func slackUserCanApprove(task *kelos.Task, userID string) bool {
+	if task.Spec.Approval == nil || userID == "" {
+		return false
+	}
+	for _, approver := range task.Spec.Approval.SlackApprovers {
+		if approver == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *SlackHandler) postEphemeral(ctx context.Context, callback *goslack.InteractionCallback, text string) {
+	postCtx, cancel := context.WithTimeout(ctx, postMessageTimeout)
+	defer cancel()
+	if _, err := h.api.PostEphemeralContext(postCtx, callback.Channel.ID, callback.User.ID, goslack.MsgOptionText(text, false)); err != nil {
+		h.log.Error(err, "Failed to post ephemeral message", "channel", callback.Channel.ID)
+	}
+}
+
 // routeMessage finds all matching TaskSpawners and creates tasks for each.
 func (h *SlackHandler) routeMessage(ctx context.Context, msg *SlackMessageData) {
 	spawners, err := h.getMatchingSpawners(ctx)
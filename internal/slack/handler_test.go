@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
@@ -766,3 +767,89 @@ func TestDenySlackConnectLeaveFailureDoesNotPostJoinMessage(t *testing.T) {
 		t.Error("Join message should not be posted when channel is external, even if leave fails")
 	}
 }
+
+func TestHandleApproveAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		wantApproved  string
+		wantUpdate    bool
+		wantEphemeral bool
+	}{
+		{
+			name:         "listed approver approves the task",
+			userID:       "U123",
+			wantApproved: "slack:U123",
+			wantUpdate:   true,
+		},
+		{
+			name:          "unlisted user is rejected",
+			userID:        "U999",
+			wantEphemeral: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+			utilruntime.Must(kelos.AddToScheme(scheme))
+
+			task := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-task", Namespace: "default"},
+				Spec: kelos.TaskSpec{
+					Type:   "claude-code",
+					Prompt: "Fix the bug",
+					Approval: &kelos.ApprovalPolicy{
+						SlackApprovers: []string{"U123"},
+					},
+				},
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(task).Build()
+
+			var updateCalled, ephemeralCalled bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.Contains(r.URL.Path, "chat.update"):
+					updateCalled = true
+					w.Write([]byte(`{"ok":true,"channel":"C456","ts":"1.1"}`))
+				case strings.Contains(r.URL.Path, "chat.postEphemeral"):
+					ephemeralCalled = true
+					w.Write([]byte(`{"ok":true,"message_ts":"1.2"}`))
+				default:
+					w.Write([]byte(`{"ok":true}`))
+				}
+			}))
+			defer srv.Close()
+
+			h := &SlackHandler{
+				client: cl,
+				log:    logr.Discard(),
+				api:    goslack.New("xoxb-test", goslack.OptionAPIURL(srv.URL+"/")),
+			}
+
+			callback := &goslack.InteractionCallback{
+				Type: goslack.InteractionTypeBlockActions,
+				User: goslack.User{ID: tt.userID},
+			}
+			callback.Channel.ID = "C456"
+			callback.Message.Timestamp = "1.1"
+
+			h.handleApproveAction(context.Background(), callback, "default/my-task")
+
+			got := &kelos.Task{}
+			if err := cl.Get(context.Background(), client.ObjectKeyFromObject(task), got); err != nil {
+				t.Fatalf("Get task: %v", err)
+			}
+			if approver := got.Annotations[kelos.AnnotationTaskApprove]; approver != tt.wantApproved {
+				t.Errorf("approve annotation = %q, want %q", approver, tt.wantApproved)
+			}
+			if updateCalled != tt.wantUpdate {
+				t.Errorf("chat.update called = %v, want %v", updateCalled, tt.wantUpdate)
+			}
+			if ephemeralCalled != tt.wantEphemeral {
+				t.Errorf("chat.postEphemeral called = %v, want %v", ephemeralCalled, tt.wantEphemeral)
+			}
+		})
+	}
+}
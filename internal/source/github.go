@@ -44,6 +44,10 @@ type GitHubSource struct {
 	AllowedTeams      []string
 	MinimumPermission string
 	PriorityLabels    []string
+	// ApprovalComment is the command that approves the Tasks spawned for
+	// an issue. When set, Discover reports the latest authorized approval
+	// on each work item.
+	ApprovalComment string
 }
 
 type githubIssue struct {
@@ -104,6 +108,13 @@ func (s *GitHubSource) Discover(ctx context.Context) ([]WorkItem, error) {
 			return nil, err
 		}
 	}
+	var approvalAuthorizer *githubCommentAuthorizer
+	if s.ApprovalComment != "" {
+		approvalAuthorizer, err = newGitHubApprovalAuthorizer(s.Owner, s.Repo, s.baseURL(), s.Token, s.httpClient(), policy)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var items []WorkItem
 	for _, issue := range issues {
@@ -153,6 +164,13 @@ func (s *GitHubSource) Discover(ctx context.Context) ([]WorkItem, error) {
 			item.TriggerTime = triggerTime
 		}
 
+		if s.ApprovalComment != "" {
+			item.ApprovedBy, item.ApprovalTime, err = latestGitHubApproval(ctx, rawComments, s.ApprovalComment, approvalAuthorizer)
+			if err != nil {
+				return nil, fmt.Errorf("evaluating approval comments for issue #%d: %w", issue.Number, err)
+			}
+		}
+
 		items = append(items, item)
 	}
 
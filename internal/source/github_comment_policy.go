@@ -109,6 +109,17 @@ func newGitHubCommentAuthorizer(owner, repo, baseURL, token string, client *http
 	}, nil
 }
 
+// newGitHubApprovalAuthorizer returns the authorizer for approval comments.
+// It applies the same author policy as trigger comments but requires write
+// access to the repository when the policy does not restrict authors, so that
+// an arbitrary commenter cannot approve a Task.
+func newGitHubApprovalAuthorizer(owner, repo, baseURL, token string, client *http.Client, policy githubCommentPolicy) (*githubCommentAuthorizer, error) {
+	if len(policy.AllowedUsers) == 0 && len(policy.AllowedTeams) == 0 && policy.MinimumPermission == "" {
+		policy.MinimumPermission = "write"
+	}
+	return newGitHubCommentAuthorizer(owner, repo, baseURL, token, client, policy)
+}
+
 func (a *githubCommentAuthorizer) authorizationConfigured() bool {
 	return len(a.allowedUsers) > 0 || len(a.allowedTeams) > 0 || a.minimumPermission != ""
 }
@@ -357,6 +368,34 @@ func latestAuthorizedCommentMatch(ctx context.Context, comments []githubComment,
 	return match, nil
 }
 
+// latestGitHubApproval returns the author and creation time of the most
+// recent authorized comment containing the approval command. Comments without
+// a parseable creation time are ignored because approvals are only honored
+// when they are newer than the Task they approve.
+func latestGitHubApproval(ctx context.Context, comments []githubComment, command string, authorizer *githubCommentAuthorizer) (string, time.Time, error) {
+	var approvedBy string
+	var approvalTime time.Time
+	for _, comment := range comments {
+		if !containsCommand(comment.Body, command) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, comment.CreatedAt)
+		if err != nil || !createdAt.After(approvalTime) {
+			continue
+		}
+		authorized, err := authorizer.isAuthorized(ctx, comment.User)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if !authorized {
+			continue
+		}
+		approvedBy = comment.User.Login
+		approvalTime = createdAt
+	}
+	return approvedBy, approvalTime, nil
+}
+
 func compareGitHubCommentMatches(left, right githubCommentMatch) int {
 	switch {
 	case left.found && right.found:
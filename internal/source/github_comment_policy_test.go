@@ -502,3 +502,59 @@ func TestDiscoverPullRequests_CommentPolicyIgnoresUnauthorizedReviewBody(t *test
 		t.Fatalf("Number = %d, want %d", items[0].Number, 1)
 	}
 }
+
+func TestLatestGitHubApproval_RequiresWriteByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/collaborators/alice/permission":
+			json.NewEncoder(w).Encode(githubPermissionResponse{Permission: "write"})
+		case "/repos/owner/repo/collaborators/mallory/permission":
+			json.NewEncoder(w).Encode(githubPermissionResponse{Permission: "read"})
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	authorizer, err := newGitHubApprovalAuthorizer("owner", "repo", server.URL, "", server.Client(), githubCommentPolicy{})
+	if err != nil {
+		t.Fatalf("newGitHubApprovalAuthorizer() error = %v", err)
+	}
+
+	comments := []githubComment{
+		{Body: "/kelos approve", CreatedAt: "2026-01-02T12:00:00Z", User: githubUser{Login: "alice"}},
+		{Body: "Looks good to me\n/kelos approve", CreatedAt: "2026-01-03T12:00:00Z", User: githubUser{Login: "alice"}},
+		{Body: "/kelos approve", CreatedAt: "2026-01-04T12:00:00Z", User: githubUser{Login: "mallory"}},
+		{Body: "/kelos approve", CreatedAt: "not-a-time", User: githubUser{Login: "alice"}},
+		{Body: "please /kelos approve", CreatedAt: "2026-01-05T12:00:00Z", User: githubUser{Login: "alice"}},
+	}
+
+	approvedBy, approvalTime, err := latestGitHubApproval(context.Background(), comments, "/kelos approve", authorizer)
+	if err != nil {
+		t.Fatalf("latestGitHubApproval() error = %v", err)
+	}
+	if approvedBy != "alice" {
+		t.Errorf("approvedBy = %q, want %q", approvedBy, "alice")
+	}
+	if want := time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC); !approvalTime.Equal(want) {
+		t.Errorf("approvalTime = %v, want %v", approvalTime, want)
+	}
+}
+
+func TestLatestGitHubApproval_HonorsAllowedUsers(t *testing.T) {
+	authorizer, err := newGitHubApprovalAuthorizer("owner", "repo", "http://127.0.0.1:0", "", nil, githubCommentPolicy{AllowedUsers: []string{"bob"}})
+	if err != nil {
+		t.Fatalf("newGitHubApprovalAuthorizer() error = %v", err)
+	}
+
+	comments := []githubComment{
+		{Body: "/kelos approve", CreatedAt: "2026-01-02T12:00:00Z", User: githubUser{Login: "Bob"}},
+	}
+	approvedBy, _, err := latestGitHubApproval(context.Background(), comments, "/kelos approve", authorizer)
+	if err != nil {
+		t.Fatalf("latestGitHubApproval() error = %v", err)
+	}
+	if approvedBy != "Bob" {
+		t.Errorf("approvedBy = %q, want %q", approvedBy, "Bob")
+	}
+}
@@ -92,6 +92,9 @@ type GitHubPullRequestSource struct {
 	PriorityLabels    []string
 	FileInclude       []string
 	FileExclude       []string
+	// ApprovalComment is the command that approves the Tasks spawned for
+	// a pull request. Only conversation comments are considered.
+	ApprovalComment string
 }
 
 type githubUser struct {
@@ -171,6 +174,13 @@ func (s *GitHubPullRequestSource) Discover(ctx context.Context) ([]WorkItem, err
 			return nil, err
 		}
 	}
+	var approvalAuthorizer *githubCommentAuthorizer
+	if s.ApprovalComment != "" {
+		approvalAuthorizer, err = newGitHubApprovalAuthorizer(s.Owner, s.Repo, s.baseURL(), s.Token, s.httpClient(), policy)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	issueSource := &GitHubSource{
 		Owner:   s.Owner,
@@ -240,6 +250,13 @@ func (s *GitHubPullRequestSource) Discover(ctx context.Context) ([]WorkItem, err
 
 		item.TriggerTime = s.resolveTriggerTime(triggerTime, commentTriggerTime)
 
+		if s.ApprovalComment != "" {
+			item.ApprovedBy, item.ApprovalTime, err = latestGitHubApproval(ctx, conversationComments, s.ApprovalComment, approvalAuthorizer)
+			if err != nil {
+				return nil, fmt.Errorf("evaluating approval comments for pull request #%d: %w", pr.Number, err)
+			}
+		}
+
 		items = append(items, item)
 	}
 
@@ -0,0 +1,134 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/kelos-dev/kelos/internal/forge"
+)
+
+// GitLabSource discovers issues and merge requests from a GitLab project.
+type GitLabSource struct {
+	// BaseURL is the API base URL, for example "https://gitlab.com/api/v4".
+	BaseURL string
+	// Project is the project path with namespace or the numeric project ID.
+	Project string
+	// Types lists the item types to discover: "issues" and/or
+	// "merge_requests". Defaults to issues.
+	Types         []string
+	Labels        []string
+	ExcludeLabels []string
+	// State is "opened", "closed" or "all". Defaults to opened.
+	State  string
+	Token  string
+	Client *http.Client
+}
+
+func (s *GitLabSource) client() *forge.GitLab {
+	return &forge.GitLab{BaseURL: s.BaseURL, Project: s.Project, Token: s.Token, Client: s.Client}
+}
+
+func (s *GitLabSource) listOptions() forge.GitLabListOptions {
+	state := s.State
+	if state == "" {
+		state = "opened"
+	}
+	return forge.GitLabListOptions{State: state, Labels: s.Labels}
+}
+
+// Discover fetches issues and merge requests from GitLab and returns them as
+// WorkItems. Issues are identified by their IID and merge requests by
+// "mr-<iid>", because both are numbered from 1 in each project.
+func (s *GitLabSource) Discover(ctx context.Context) ([]WorkItem, error) {
+	gl := s.client()
+	types := s.Types
+	if len(types) == 0 {
+		types = []string{"issues"}
+	}
+
+	var items []WorkItem
+	for _, t := range types {
+		switch t {
+		case "issues":
+			issues, err := gl.ListIssues(ctx, s.listOptions())
+			if err != nil {
+				return nil, err
+			}
+			for _, issue := range issues {
+				if s.excluded(issue.Labels) {
+					continue
+				}
+				comments, err := s.comments(ctx, gl, forge.Item{Kind: forge.ItemIssue, Number: issue.IID})
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, WorkItem{
+					ID:       strconv.Itoa(issue.IID),
+					Number:   issue.IID,
+					Title:    issue.Title,
+					Body:     issue.Description,
+					URL:      issue.WebURL,
+					Labels:   issue.Labels,
+					Comments: comments,
+					Kind:     "Issue",
+				})
+			}
+		case "merge_requests":
+			mrs, err := gl.ListMergeRequests(ctx, s.listOptions())
+			if err != nil {
+				return nil, err
+			}
+			for _, mr := range mrs {
+				if s.excluded(mr.Labels) {
+					continue
+				}
+				comments, err := s.comments(ctx, gl, forge.Item{Kind: forge.ItemChangeRequest, Number: mr.IID})
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, WorkItem{
+					ID:       "mr-" + strconv.Itoa(mr.IID),
+					Number:   mr.IID,
+					Title:    mr.Title,
+					Body:     mr.Description,
+					URL:      mr.WebURL,
+					Labels:   mr.Labels,
+					Comments: comments,
+					Kind:     "PR",
+					Branch:   mr.SourceBranch,
+					HeadSHA:  mr.SHA,
+				})
+			}
+		}
+	}
+	return items, nil
+}
+
+// excluded reports whether labels contains any of the ExcludeLabels.
+func (s *GitLabSource) excluded(labels []string) bool {
+	for _, l := range labels {
+		for _, e := range s.ExcludeLabels {
+			if l == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// comments returns the user notes on item concatenated like GitHub
+// comments. System notes (label changes, mentions, ...) are skipped.
+func (s *GitLabSource) comments(ctx context.Context, gl *forge.GitLab, item forge.Item) (string, error) {
+	notes, err := gl.ListNotes(ctx, item)
+	if err != nil {
+		return "", err
+	}
+	var bodies []string
+	for _, n := range notes {
+		if !n.System {
+			bodies = append(bodies, n.Body)
+		}
+	}
+	return concatBodies(bodies), nil
+}
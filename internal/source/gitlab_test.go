@@ -0,0 +1,104 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kelos-dev/kelos/internal/forge"
+)
+
+func newGitLabTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "glpat-test" {
+			t.Errorf("PRIVATE-TOKEN = %q, want glpat-test", got)
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fservice/issues":
+			if got := r.URL.Query().Get("state"); got != "opened" {
+				t.Errorf("state = %q, want opened", got)
+			}
+			if got := r.URL.Query().Get("labels"); got != "kelos" {
+				t.Errorf("labels = %q, want kelos", got)
+			}
+			json.NewEncoder(w).Encode([]forge.GitLabIssue{
+				{IID: 1, Title: "Fix login", Description: "Login fails", WebURL: "https://gitlab.example.com/group/service/-/issues/1", Labels: []string{"kelos"}},
+				{IID: 2, Title: "Skip me", Labels: []string{"kelos", "wontfix"}},
+			})
+		case "/api/v4/projects/group%2Fservice/merge_requests":
+			json.NewEncoder(w).Encode([]forge.GitLabMergeRequest{
+				{IID: 1, Title: "Add cache", WebURL: "https://gitlab.example.com/group/service/-/merge_requests/1", Labels: []string{"kelos"}, SourceBranch: "feature/cache", SHA: "abc123"},
+			})
+		case "/api/v4/projects/group%2Fservice/issues/1/notes":
+			json.NewEncoder(w).Encode([]forge.GitLabNote{
+				{ID: 1, Body: "added ~kelos label", System: true},
+				{ID: 2, Body: "Reproduced on main"},
+			})
+		case "/api/v4/projects/group%2Fservice/merge_requests/1/notes":
+			json.NewEncoder(w).Encode([]forge.GitLabNote{})
+		default:
+			t.Errorf("Unexpected request %s", r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitLabSourceDiscoverIssues(t *testing.T) {
+	server := newGitLabTestServer(t)
+	s := &GitLabSource{
+		BaseURL:       server.URL + "/api/v4",
+		Project:       "group/service",
+		Token:         "glpat-test",
+		Labels:        []string{"kelos"},
+		ExcludeLabels: []string{"wontfix"},
+	}
+
+	items, err := s.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d: %+v", len(items), items)
+	}
+	item := items[0]
+	if item.ID != "1" || item.Number != 1 || item.Kind != "Issue" {
+		t.Errorf("Unexpected item identity: %+v", item)
+	}
+	if item.Title != "Fix login" || item.Body != "Login fails" || item.URL != "https://gitlab.example.com/group/service/-/issues/1" {
+		t.Errorf("Unexpected item content: %+v", item)
+	}
+	if item.Comments != "Reproduced on main" {
+		t.Errorf("Comments = %q, want only the user note", item.Comments)
+	}
+}
+
+func TestGitLabSourceDiscoverMergeRequests(t *testing.T) {
+	server := newGitLabTestServer(t)
+	s := &GitLabSource{
+		BaseURL: server.URL + "/api/v4",
+		Project: "group/service",
+		Token:   "glpat-test",
+		Types:   []string{"merge_requests"},
+		Labels:  []string{"kelos"},
+	}
+
+	items, err := s.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d: %+v", len(items), items)
+	}
+	item := items[0]
+	if item.ID != "mr-1" || item.Number != 1 || item.Kind != "PR" {
+		t.Errorf("Unexpected item identity: %+v", item)
+	}
+	if item.Branch != "feature/cache" || item.HeadSHA != "abc123" {
+		t.Errorf("Branch, HeadSHA = %q, %q, want feature/cache, abc123", item.Branch, item.HeadSHA)
+	}
+}
@@ -33,6 +33,13 @@ type WorkItem struct {
 	// The spawner uses this to retrigger completed tasks when the trigger time
 	// is newer than the task's completion time.
 	TriggerTime time.Time
+
+	// ApprovedBy is the GitHub login of the author of the most recent
+	// authorized approval comment, when the source is configured with an
+	// approval comment. ApprovalTime is when that comment was posted. The
+	// spawner uses them to approve Tasks that wait for approval.
+	ApprovedBy   string
+	ApprovalTime time.Time
 }
 
 // Source discovers work items from an external system.
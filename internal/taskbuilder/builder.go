@@ -13,6 +13,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/resultschema"
 	"github.com/kelos-dev/kelos/internal/spawnercredentials"
 )
 
@@ -79,6 +80,9 @@ func (tb *TaskBuilder) AssignSpawnerCredential(spawner *kelos.TaskSpawner, task
 // When taskTemplate.NameTemplate is set it is rendered, sanitized into a valid
 // Kubernetes resource name, and used as the Task name; otherwise the passed-in
 // name is used unchanged.
+//
+// When taskTemplate.ResultsSchema is set, the prompt template can also use
+// {{.ResultsSchema}} and {{.ResultsInstructions}}.
 func (tb *TaskBuilder) BuildTask(
 	name, namespace string,
 	taskTemplate *kelos.TaskTemplate,
@@ -97,7 +101,21 @@ func (tb *TaskBuilder) BuildTask(
 		promptTemplate = "{{.Title}}" // Default template
 	}
 
-	prompt, err := renderTemplate("prompt", promptTemplate, templateVars)
+	promptVars := templateVars
+	if taskTemplate.ResultsSchema != "" {
+		schema, err := resultschema.Compile(taskTemplate.ResultsSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resultsSchema: %w", err)
+		}
+		promptVars = make(map[string]interface{}, len(templateVars)+2)
+		for k, v := range templateVars {
+			promptVars[k] = v
+		}
+		promptVars["ResultsSchema"] = schema.JSON()
+		promptVars["ResultsInstructions"] = schema.Instructions()
+	}
+
+	prompt, err := renderTemplate("prompt", promptTemplate, promptVars)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render prompt template: %w", err)
 	}
@@ -155,6 +173,27 @@ func (tb *TaskBuilder) BuildTask(
 	if taskTemplate.PodFailurePolicy != nil {
 		task.Spec.PodFailurePolicy = taskTemplate.PodFailurePolicy
 	}
+	if taskTemplate.RetryPolicy != nil {
+		task.Spec.RetryPolicy = taskTemplate.RetryPolicy
+	}
+	if taskTemplate.ResultsSchema != "" {
+		task.Spec.ResultsSchema = taskTemplate.ResultsSchema
+	}
+	if taskTemplate.Approval != nil {
+		task.Spec.Approval = taskTemplate.Approval
+	}
+	if taskTemplate.SpendLimit != nil {
+		task.Spec.SpendLimit = taskTemplate.SpendLimit
+	}
+	if taskTemplate.Priority != nil {
+		task.Spec.Priority = taskTemplate.Priority
+	}
+	if taskTemplate.WaitingTimeoutSeconds != nil {
+		task.Spec.WaitingTimeoutSeconds = taskTemplate.WaitingTimeoutSeconds
+	}
+	if taskTemplate.PendingTimeoutSeconds != nil {
+		task.Spec.PendingTimeoutSeconds = taskTemplate.PendingTimeoutSeconds
+	}
 	if taskTemplate.UpstreamRepo != "" {
 		task.Spec.UpstreamRepo = taskTemplate.UpstreamRepo
 	}
@@ -179,6 +179,157 @@ func TestBuildTask_ForwardsPodFailurePolicy(t *testing.T) {
 	}
 }
 
+func TestBuildTask_ResultsSchema(t *testing.T) {
+	tb := &TaskBuilder{}
+	template := &kelos.TaskTemplate{
+		Type: "codex",
+		Credentials: &kelos.Credentials{
+			Type:      kelos.CredentialTypeAPIKey,
+			SecretRef: &kelos.SecretReference{Name: "credentials"},
+		},
+		PromptTemplate: "Triage {{.Title}}\n{{.ResultsInstructions}}Schema: {{.ResultsSchema}}",
+		ResultsSchema:  "type: object\nrequired: [severity]\n",
+	}
+	vars := map[string]interface{}{"Title": "the bug"}
+
+	task, err := tb.BuildTask("task-1", "default", template, vars, nil)
+	if err != nil {
+		t.Fatalf("BuildTask() returned error: %v", err)
+	}
+	if task.Spec.ResultsSchema != template.ResultsSchema {
+		t.Errorf("task.Spec.ResultsSchema = %q, want %q", task.Spec.ResultsSchema, template.ResultsSchema)
+	}
+	for _, want := range []string{"Triage the bug", "## Structured results", `Schema: {"required":["severity"],"type":"object"}`} {
+		if !strings.Contains(task.Spec.Prompt, want) {
+			t.Errorf("task.Spec.Prompt = %q, want it to contain %q", task.Spec.Prompt, want)
+		}
+	}
+	if _, ok := vars["ResultsSchema"]; ok {
+		t.Error("BuildTask() modified the caller's template variables")
+	}
+}
+
+func TestBuildTask_InvalidResultsSchema(t *testing.T) {
+	tb := &TaskBuilder{}
+	template := &kelos.TaskTemplate{
+		Type:           "codex",
+		PromptTemplate: "{{.Title}}",
+		ResultsSchema:  `{"type": "object", "required": "severity"}`,
+	}
+
+	_, err := tb.BuildTask("task-1", "default", template, map[string]interface{}{"Title": "the bug"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid resultsSchema") {
+		t.Fatalf("BuildTask() error = %v, want invalid resultsSchema error", err)
+	}
+}
+
+func TestBuildTask_ForwardsApproval(t *testing.T) {
+	tb := &TaskBuilder{}
+	template := &kelos.TaskTemplate{
+		Type: "claude-code",
+		Credentials: &kelos.Credentials{
+			Type:      kelos.CredentialTypeOAuth,
+			SecretRef: &kelos.SecretReference{Name: "credentials"},
+		},
+		Approval: &kelos.ApprovalPolicy{
+			Mode:          kelos.ApprovalModeBeforePush,
+			GitHubComment: "/kelos approve",
+		},
+		PromptTemplate: "Fix {{.Title}}",
+	}
+
+	task, err := tb.BuildTask("task-1", "default", template, map[string]interface{}{
+		"Title": "the bug",
+	}, nil)
+	if err != nil {
+		t.Fatalf("BuildTask() returned error: %v", err)
+	}
+
+	if task.Spec.Approval == nil || task.Spec.Approval.Mode != kelos.ApprovalModeBeforePush {
+		t.Fatalf("task.Spec.Approval = %+v, want BeforePush approval", task.Spec.Approval)
+	}
+}
+
+func TestBuildTask_ForwardsSpendLimit(t *testing.T) {
+	tb := &TaskBuilder{}
+	maxTokens := int64(200000)
+	template := &kelos.TaskTemplate{
+		Type: "codex",
+		Credentials: &kelos.Credentials{
+			Type:      kelos.CredentialTypeAPIKey,
+			SecretRef: &kelos.SecretReference{Name: "credentials"},
+		},
+		SpendLimit:     &kelos.SpendLimit{MaxTokens: &maxTokens},
+		PromptTemplate: "Fix {{.Title}}",
+	}
+
+	task, err := tb.BuildTask("task-1", "default", template, map[string]interface{}{
+		"Title": "the bug",
+	}, nil)
+	if err != nil {
+		t.Fatalf("BuildTask() returned error: %v", err)
+	}
+
+	if task.Spec.SpendLimit == nil || task.Spec.SpendLimit.MaxTokens == nil || *task.Spec.SpendLimit.MaxTokens != maxTokens {
+		t.Fatalf("task.Spec.SpendLimit = %+v, want maxTokens %d", task.Spec.SpendLimit, maxTokens)
+	}
+}
+
+func TestBuildTask_ForwardsPriority(t *testing.T) {
+	tb := &TaskBuilder{}
+	priority := int32(100)
+	template := &kelos.TaskTemplate{
+		Type: "codex",
+		Credentials: &kelos.Credentials{
+			Type:      kelos.CredentialTypeAPIKey,
+			SecretRef: &kelos.SecretReference{Name: "credentials"},
+		},
+		Priority:       &priority,
+		PromptTemplate: "Fix {{.Title}}",
+	}
+
+	task, err := tb.BuildTask("task-1", "default", template, map[string]interface{}{
+		"Title": "the bug",
+	}, nil)
+	if err != nil {
+		t.Fatalf("BuildTask() returned error: %v", err)
+	}
+
+	if task.Spec.Priority == nil || *task.Spec.Priority != priority {
+		t.Fatalf("task.Spec.Priority = %v, want %d", task.Spec.Priority, priority)
+	}
+}
+
+func TestBuildTask_ForwardsTimeouts(t *testing.T) {
+	tb := &TaskBuilder{}
+	waitingTimeout := int64(3600)
+	pendingTimeout := int64(600)
+	template := &kelos.TaskTemplate{
+		Type: "codex",
+		Credentials: &kelos.Credentials{
+			Type:      kelos.CredentialTypeAPIKey,
+			SecretRef: &kelos.SecretReference{Name: "credentials"},
+		},
+		WaitingTimeoutSeconds: &waitingTimeout,
+		PendingTimeoutSeconds: &pendingTimeout,
+		PromptTemplate:        "Fix {{.Title}}",
+	}
+
+	task, err := tb.BuildTask("task-1", "default", template, map[string]interface{}{
+		"Title": "the bug",
+	}, nil)
+	if err != nil {
+		t.Fatalf("BuildTask() returned error: %v", err)
+	}
+
+	if task.Spec.WaitingTimeoutSeconds == nil || *task.Spec.WaitingTimeoutSeconds != waitingTimeout {
+		t.Errorf("task.Spec.WaitingTimeoutSeconds = %v, want %d", task.Spec.WaitingTimeoutSeconds, waitingTimeout)
+	}
+	if task.Spec.PendingTimeoutSeconds == nil || *task.Spec.PendingTimeoutSeconds != pendingTimeout {
+		t.Errorf("task.Spec.PendingTimeoutSeconds = %v, want %d", task.Spec.PendingTimeoutSeconds, pendingTimeout)
+	}
+}
+
 func TestBuildTask_NameTemplate(t *testing.T) {
 	tb := &TaskBuilder{}
 	template := &kelos.TaskTemplate{
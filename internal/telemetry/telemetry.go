@@ -276,6 +276,9 @@ func collect(ctx context.Context, c client.Client, clientset kubernetes.Interfac
 		if s.Spec.When.Jira != nil {
 			sourceTypes["jira"] = struct{}{}
 		}
+		if s.Spec.When.GitLab != nil {
+			sourceTypes["gitlab"] = struct{}{}
+		}
 	}
 	for st := range sourceTypes {
 		report.Features.SourceTypes = append(report.Features.SourceTypes, st)
@@ -344,7 +347,8 @@ func normalizeTaskPhase(phase kelos.TaskPhase) string {
 		kelos.TaskPhaseRunning,
 		kelos.TaskPhaseSucceeded,
 		kelos.TaskPhaseFailed,
-		kelos.TaskPhaseWaiting:
+		kelos.TaskPhaseWaiting,
+		kelos.TaskPhaseAwaitingApproval:
 		return string(phase)
 	default:
 		return "Unknown"
@@ -391,6 +395,8 @@ func taskSpawnerSource(when kelos.When) string {
 		return "cron"
 	case when.Jira != nil:
 		return "jira"
+	case when.GitLab != nil:
+		return "gitlab"
 	case when.Slack != nil:
 		return "slack"
 	default:
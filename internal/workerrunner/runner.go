@@ -30,6 +30,7 @@ import (
 	"time"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/results"
 	kelosclientset "github.com/kelos-dev/kelos/pkg/generated/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -284,9 +285,9 @@ func (r *Runner) runAgent(ctx context.Context, task *kelos.Task) error {
 
 	err := cmd.Run()
 	if ctx.Err() != nil {
-		// The process group is gone by now, but agent tooling that put itself
-		// in a new session or process group survives that signal. Sweep them
-		// before the runner picks up the next task.
+		// The entrypoint has exited or been killed by now, but descendants
+		// that ignored SIGTERM or put themselves in a new session or process
+		// group survive. Sweep them before the runner picks up the next task.
 		killSurvivingTaskProcesses()
 	}
 	return err
@@ -297,13 +298,18 @@ func (r *Runner) runAgent(ctx context.Context, task *kelos.Task) error {
 // separate process group, CommandContext kills only /kelos_entrypoint.sh;
 // session drivers and agent CLIs are re-parented to PID 1 and keep consuming
 // resources while the runner starts the next task.
+//
+// The group first receives SIGTERM so the agent can stop and kelos-capture
+// can still emit the outputs of the partial run. The entrypoint is killed
+// once taskCancelGracePeriod elapses, and runAgent sweeps whatever remains.
 func configureTaskProcessGroupCancellation(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.WaitDelay = taskCancelGracePeriod
 	cmd.Cancel = func() error {
 		if cmd.Process == nil {
 			return os.ErrProcessDone
 		}
-		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM); err != nil {
 			if errors.Is(err, syscall.ESRCH) {
 				return os.ErrProcessDone
 			}
@@ -318,6 +324,10 @@ func configureTaskProcessGroupCancellation(cmd *exec.Cmd) {
 var procRoot = "/proc"
 
 const (
+	// taskCancelGracePeriod is how long a cancelled task's entrypoint may
+	// take to stop after SIGTERM before it is killed.
+	taskCancelGracePeriod = 30 * time.Second
+
 	// taskSweepTimeout bounds how long the runner keeps re-sweeping after a
 	// cancelled task before giving up and starting the next task.
 	taskSweepTimeout      = 10 * time.Second
@@ -449,6 +459,10 @@ func taskAgentEnv(base []string, task *kelos.Task) []string {
 	// per-Task value. Export it here, where the per-Task env is assembled.
 	env = append(env, "KELOS_TASK_NAME="+task.Name)
 
+	// kelos-capture writes the Task's outputs to its results ConfigMap, so
+	// the controller does not have to find them in the worker's logs.
+	env = append(env, results.EnvConfigMap+"="+results.ConfigMapName(task.UID))
+
 	env = append(env, "KELOS_PROMPT="+task.Spec.Prompt)
 	if task.Spec.Model != "" {
 		env = append(env, "KELOS_MODEL="+task.Spec.Model)
@@ -32,6 +32,7 @@ import (
 	"time"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
+	"github.com/kelos-dev/kelos/internal/results"
 	kelosfake "github.com/kelos-dev/kelos/pkg/generated/clientset/versioned/fake"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -209,6 +210,24 @@ func TestTaskAgentEnvIncludesTaskName(t *testing.T) {
 	}
 }
 
+// Pooled workers serve many Tasks from one container, so the outputs of each
+// Task go to that Task's results ConfigMap rather than the termination message.
+func TestTaskAgentEnvIncludesResultsConfigMap(t *testing.T) {
+	task := &kelos.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-abc123", UID: "uid-abc123"},
+		Spec:       kelos.TaskSpec{Prompt: "Fix the bug"},
+	}
+
+	env := taskAgentEnv([]string{"OTHER=value"}, task)
+
+	if got := lastEnvValue(env, results.EnvConfigMap); got != "kelos-results-uid-abc123" {
+		t.Errorf("%s = %q, want the task's results ConfigMap", results.EnvConfigMap, got)
+	}
+	if got := lastEnvValue(env, results.EnvTerminationMessagePath); got != "" {
+		t.Errorf("%s = %q, want it unset for pooled workers", results.EnvTerminationMessagePath, got)
+	}
+}
+
 // Each Task must see its own name, not a value left over from the previous Task
 // this worker ran. taskAgentEnv is always called with the pod's pristine
 // os.Environ(), but appending last also makes it win over any inherited value.
@@ -754,6 +773,53 @@ func TestConfigureTaskProcessGroupCancellationKillsDescendants(t *testing.T) {
 	waitForDescendantToStop(t, pid, heartbeat)
 }
 
+// gracefulStopScript records that it received SIGTERM before exiting, the
+// way the agent entrypoint lets kelos-capture finish after a cancellation.
+const gracefulStopScript = `
+trap 'printf stopped >"$STOPPED"; exit 0' TERM
+printf ready >"$READY"
+while :; do sleep 0.05; done
+`
+
+func TestConfigureTaskProcessGroupCancellationStopsGracefully(t *testing.T) {
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	stopped := filepath.Join(dir, "stopped")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", gracefulStopScript)
+	cmd.Env = append(os.Environ(), "READY="+ready, "STOPPED="+stopped)
+	configureTaskProcessGroupCancellation(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Starting task shell: %v", err)
+	}
+
+	deadline := time.Now().Add(descendantTimeout)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Task shell did not become ready within %s", descendantTimeout)
+		}
+		time.Sleep(descendantPollInterval)
+	}
+
+	cancel()
+	_ = cmd.Wait()
+
+	data, err := os.ReadFile(stopped)
+	if err != nil {
+		t.Fatalf("Expected the task shell to handle SIGTERM before exiting: %v", err)
+	}
+	if string(data) != "stopped" {
+		t.Errorf("Stop marker = %q, want %q", data, "stopped")
+	}
+}
+
 // waitForDescendantExit polls until the descendant PID no longer exists,
 // failing at the deadline if it is still alive. A killed child is reaped by
 // the system reaper once orphaned, so its PID does disappear.
@@ -5,9 +5,11 @@
 # Interface contract:
 #   - First argument ($1): the task prompt
 #   - KELOS_MODEL env var: model name (optional, provider/model format)
+#   - KELOS_CONTINUE=1: continue the most recent conversation with the prompt
 #   - OPENCODE_API_KEY env var: API key forwarded to the provider
 #   - KELOS_AGENTS_MD env var: user-level instructions (optional)
 #   - KELOS_PLUGIN_DIR env var: plugin directory with skills/agents (optional)
+#   - KELOS_PERMISSIONS env var: JSON tool permission policy (optional)
 #   - UID 61100: shared between git-clone init container and agent
 #   - Working directory: /workspace/repo when a workspace is configured
 
@@ -124,6 +126,60 @@ if [ -n "${KELOS_PLUGIN_DIR:-}" ] && [ -d "${KELOS_PLUGIN_DIR}" ]; then
   done
 fi
 
+# Translate the tool permission policy into OpenCode permission rules.
+# OpenCode applies the last matching rule, so every rule is moved to the end
+# of the existing configuration.
+if [ -n "${KELOS_PERMISSIONS:-}" ]; then
+  node -e '
+const fs = require("fs");
+const path = require("path");
+const cfgPath = path.join(process.env.OPENCODE_CONFIG_DIR, "opencode.json");
+let existing = {};
+try { existing = JSON.parse(fs.readFileSync(cfgPath, "utf8")); } catch {}
+const policy = JSON.parse(process.env.KELOS_PERMISSIONS);
+const permission = existing.permission && typeof existing.permission === "object" ? existing.permission : {};
+if (typeof existing.permission === "string") permission["*"] = existing.permission;
+const set = (key, action) => { delete permission[key]; permission[key] = action; };
+const setPattern = (key, pattern, action) => {
+  let rules = permission[key];
+  if (typeof rules !== "object" || rules === null) rules = rules ? { "*": rules } : {};
+  delete rules[pattern];
+  rules[pattern] = action;
+  delete permission[key];
+  permission[key] = rules;
+};
+const toolName = (server, tool) => `${server}_${tool}`.replace(/[^a-zA-Z0-9_*-]/g, "_");
+
+const builtins = {
+  "shell": ["bash"],
+  "read": ["read", "glob", "grep", "list"],
+  "edit": ["edit"],
+  "web-fetch": ["webfetch"],
+  "web-search": ["websearch"],
+};
+const allowed = policy.allowedTools || [];
+const denied = policy.deniedTools || [];
+const limited = allowed.some((tool) => tool in builtins);
+for (const [tool, keys] of Object.entries(builtins)) {
+  if (denied.includes(tool) || (limited && !allowed.includes(tool))) keys.forEach((key) => set(key, "deny"));
+}
+const mcpTools = (list) => list.filter((tool) => tool.startsWith("mcp:")).map((tool) => tool.slice(4).split("/"));
+const servers = new Set(mcpTools(allowed).map(([server]) => server));
+for (const server of servers) {
+  const tools = mcpTools(allowed).filter(([s]) => s === server);
+  if (tools.some(([, tool]) => tool === undefined)) continue;
+  set(toolName(server, "*"), "deny");
+  tools.forEach(([, tool]) => set(toolName(server, tool), "allow"));
+}
+for (const [server, tool] of mcpTools(denied)) set(toolName(server, tool === undefined ? "*" : tool), "deny");
+for (const command of policy.deniedCommands || []) setPattern("bash", command, "deny");
+for (const glob of policy.deniedPaths || []) setPattern("edit", glob, "deny");
+
+existing.permission = permission;
+fs.writeFileSync(cfgPath, JSON.stringify(existing, null, 2));
+'
+fi
+
 # Run pre-agent setup command if configured. KELOS_SETUP_COMMAND is the
 # JSON-encoded exec-form array from Workspace.spec.setupCommand. A non-zero
 # exit aborts the task before the agent starts.
@@ -163,6 +219,15 @@ if [ -z "${KELOS_EFFORT:-}" ] && [ -n "${KELOS_MODEL:-}" ]; then
   ARGS+=("--model" "$KELOS_MODEL")
 fi
 
+# Resume the conversation restored from the continued Task.
+if [ "${KELOS_CONTINUE:-}" = "1" ]; then
+  ARGS+=("--continue")
+fi
+
+# Keep the shell alive when the agent is stopped with SIGTERM (for example,
+# when the Task is cancelled) so kelos-capture can still report outputs.
+trap 'true' TERM
+
 opencode "${ARGS[@]}" | /kelos/kelos-capture
 AGENT_EXIT_CODE=${PIPESTATUS[0]}
 
@@ -39,6 +39,8 @@ type AgentConfigsGetter interface {
 type AgentConfigInterface interface {
 	Create(ctx context.Context, agentConfig *apiv1alpha2.AgentConfig, opts v1.CreateOptions) (*apiv1alpha2.AgentConfig, error)
 	Update(ctx context.Context, agentConfig *apiv1alpha2.AgentConfig, opts v1.UpdateOptions) (*apiv1alpha2.AgentConfig, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, agentConfig *apiv1alpha2.AgentConfig, opts v1.UpdateOptions) (*apiv1alpha2.AgentConfig, error)
 	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
 	Get(ctx context.Context, name string, opts v1.GetOptions) (*apiv1alpha2.AgentConfig, error)
@@ -29,6 +29,7 @@ import (
 type ApiV1alpha2Interface interface {
 	RESTClient() rest.Interface
 	AgentConfigsGetter
+	PipelinesGetter
 	SessionsGetter
 	SessionSpawnersGetter
 	TasksGetter
@@ -48,6 +49,10 @@ func (c *ApiV1alpha2Client) AgentConfigs(namespace string) AgentConfigInterface
 	return newAgentConfigs(c, namespace)
 }
 
+func (c *ApiV1alpha2Client) Pipelines(namespace string) PipelineInterface {
+	return newPipelines(c, namespace)
+}
+
 func (c *ApiV1alpha2Client) Sessions(namespace string) SessionInterface {
 	return newSessions(c, namespace)
 }
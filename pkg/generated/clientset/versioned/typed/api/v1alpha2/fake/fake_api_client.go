@@ -32,6 +32,10 @@ func (c *FakeApiV1alpha2) AgentConfigs(namespace string) v1alpha2.AgentConfigInt
 	return newFakeAgentConfigs(c, namespace)
 }
 
+func (c *FakeApiV1alpha2) Pipelines(namespace string) v1alpha2.PipelineInterface {
+	return newFakePipelines(c, namespace)
+}
+
 func (c *FakeApiV1alpha2) Sessions(namespace string) v1alpha2.SessionInterface {
 	return newFakeSessions(c, namespace)
 }
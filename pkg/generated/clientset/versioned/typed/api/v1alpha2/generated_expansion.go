@@ -20,6 +20,8 @@ package v1alpha2
 
 type AgentConfigExpansion interface{}
 
+type PipelineExpansion interface{}
+
 type SessionExpansion interface{}
 
 type SessionSpawnerExpansion interface{}
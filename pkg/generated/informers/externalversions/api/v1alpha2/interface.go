@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// AgentConfigs returns a AgentConfigInformer.
 	AgentConfigs() AgentConfigInformer
+	// Pipelines returns a PipelineInformer.
+	Pipelines() PipelineInformer
 	// Sessions returns a SessionInformer.
 	Sessions() SessionInformer
 	// SessionSpawners returns a SessionSpawnerInformer.
@@ -60,6 +62,11 @@ func (v *version) AgentConfigs() AgentConfigInformer {
 	return &agentConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// Pipelines returns a PipelineInformer.
+func (v *version) Pipelines() PipelineInformer {
+	return &pipelineInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Sessions returns a SessionInformer.
 func (v *version) Sessions() SessionInformer {
 	return &sessionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
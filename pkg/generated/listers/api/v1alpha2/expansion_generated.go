@@ -26,6 +26,14 @@ type AgentConfigListerExpansion interface{}
 // AgentConfigNamespaceLister.
 type AgentConfigNamespaceListerExpansion interface{}
 
+// PipelineListerExpansion allows custom methods to be added to
+// PipelineLister.
+type PipelineListerExpansion interface{}
+
+// PipelineNamespaceListerExpansion allows custom methods to be added to
+// PipelineNamespaceLister.
+type PipelineNamespaceListerExpansion interface{}
+
 // SessionListerExpansion allows custom methods to be added to
 // SessionLister.
 type SessionListerExpansion interface{}
@@ -77,7 +77,7 @@ var _ = Describe("TaskRecord", func() {
 		Expect(record.Spec.Usage.InputTokens).NotTo(BeNil())
 		Expect(record.Spec.Usage.OutputTokens).NotTo(BeNil())
 		Expect(record.Spec.TTLSecondsAfterCompletion).NotTo(BeNil())
-		Expect(*record.Spec.TTLSecondsAfterCompletion).To(Equal(int32(30 * 24 * 60 * 60)))
+		Expect(*record.Spec.TTLSecondsAfterCompletion).To(Equal(int32(32 * 24 * 60 * 60)))
 
 		By("verifying TaskRecord labels match the Task labels")
 		Expect(record.Labels).To(HaveKeyWithValue("test", "taskrecord"))
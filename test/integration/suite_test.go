@@ -14,6 +14,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -125,6 +126,11 @@ var _ = BeforeSuite(func() {
 			Port:    webhookOpts.LocalServingPort,
 			CertDir: webhookOpts.LocalServingCertDir,
 		}),
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&coordinationv1.Lease{}},
+			},
+		},
 	})
 	Expect(err).NotTo(HaveOccurred())
 
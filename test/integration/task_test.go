@@ -13,11 +13,13 @@ import (
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	kelos "github.com/kelos-dev/kelos/api/v1alpha2"
 	"github.com/kelos-dev/kelos/internal/controller"
+	"github.com/kelos-dev/kelos/internal/results"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -125,7 +127,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(container.Args).To(Equal([]string{"Create a hello world program"}))
 
 			By("Verifying the Job has KELOS_MODEL, KELOS_AGENT_TYPE, and API key env vars")
-			Expect(container.Env).To(HaveLen(3))
+			Expect(container.Env).To(HaveLen(5))
 			Expect(container.Env[0].Name).To(Equal("KELOS_MODEL"))
 			Expect(container.Env[0].Value).To(Equal("claude-sonnet-4-20250514"))
 			Expect(container.Env[1].Name).To(Equal("KELOS_AGENT_TYPE"))
@@ -133,6 +135,25 @@ var _ = Describe("Task Controller", func() {
 			Expect(container.Env[2].Name).To(Equal("ANTHROPIC_API_KEY"))
 			Expect(container.Env[2].ValueFrom.SecretKeyRef.Name).To(Equal("anthropic-api-key"))
 
+			By("Verifying the Job writes its outputs to the results channel")
+			resultsName := results.ConfigMapName(createdTask.UID)
+			Expect(container.Env[3].Name).To(Equal(results.EnvTerminationMessagePath))
+			Expect(container.Env[3].Value).To(Equal(corev1.TerminationMessagePathDefault))
+			Expect(container.Env[4].Name).To(Equal(results.EnvConfigMap))
+			Expect(container.Env[4].Value).To(Equal(resultsName))
+			Expect(createdJob.Spec.Template.Spec.ServiceAccountName).To(Equal(resultsName))
+
+			resultsKey := types.NamespacedName{Name: resultsName, Namespace: ns.Name}
+			Expect(k8sClient.Get(ctx, resultsKey, &corev1.ConfigMap{})).To(Succeed())
+			Expect(k8sClient.Get(ctx, resultsKey, &corev1.ServiceAccount{})).To(Succeed())
+			resultsRole := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, resultsKey, resultsRole)).To(Succeed())
+			Expect(resultsRole.Rules).To(HaveLen(1))
+			Expect(resultsRole.Rules[0].ResourceNames).To(Equal([]string{resultsName}))
+			resultsBinding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, resultsKey, resultsBinding)).To(Succeed())
+			Expect(resultsBinding.Subjects[0].Name).To(Equal(resultsName))
+
 			By("Verifying the Job has owner reference")
 			Expect(createdJob.OwnerReferences).To(HaveLen(1))
 			Expect(createdJob.OwnerReferences[0].Name).To(Equal(task.Name))
@@ -260,7 +281,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(container.Args).To(Equal([]string{"Create a hello world program"}))
 
 			By("Verifying the Job has KELOS_AGENT_TYPE and OAuth token env vars")
-			Expect(container.Env).To(HaveLen(2))
+			Expect(container.Env).To(HaveLen(4))
 			Expect(container.Env[0].Name).To(Equal("KELOS_AGENT_TYPE"))
 			Expect(container.Env[0].Value).To(Equal("claude-code"))
 			Expect(container.Env[1].Name).To(Equal("CLAUDE_CODE_OAUTH_TOKEN"))
@@ -1405,7 +1426,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(mainContainer.Args).To(Equal([]string{"Create a PR"}))
 
 			By("Verifying the main container has KELOS_AGENT_TYPE, ANTHROPIC_API_KEY, KELOS_BASE_BRANCH, GITHUB_TOKEN, GH_TOKEN, GH_CONFIG_DIR, and KELOS_GITHUB_TOKEN_FILE env vars")
-			Expect(mainContainer.Env).To(HaveLen(7))
+			Expect(mainContainer.Env).To(HaveLen(9))
 			mainEnv := map[string]corev1.EnvVar{}
 			for _, envVar := range mainContainer.Env {
 				mainEnv[envVar.Name] = envVar
@@ -1886,7 +1907,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(container.Args).To(Equal([]string{"Fix the bug"}))
 
 			By("Verifying KELOS_MODEL and KELOS_AGENT_TYPE are set")
-			Expect(container.Env).To(HaveLen(4))
+			Expect(container.Env).To(HaveLen(6))
 			Expect(container.Env[0].Name).To(Equal("KELOS_MODEL"))
 			Expect(container.Env[0].Value).To(Equal("gpt-4"))
 			Expect(container.Env[1].Name).To(Equal("KELOS_AGENT_TYPE"))
@@ -2108,7 +2129,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(container.Args).To(Equal([]string{"Fix the bug"}))
 
 			By("Verifying the Job has KELOS_MODEL, KELOS_AGENT_TYPE, and CODEX_API_KEY env vars")
-			Expect(container.Env).To(HaveLen(3))
+			Expect(container.Env).To(HaveLen(5))
 			Expect(container.Env[0].Name).To(Equal("KELOS_MODEL"))
 			Expect(container.Env[0].Value).To(Equal("gpt-4.1"))
 			Expect(container.Env[1].Name).To(Equal("KELOS_AGENT_TYPE"))
@@ -2200,7 +2221,7 @@ var _ = Describe("Task Controller", func() {
 			Expect(mainContainer.Args).To(Equal([]string{"Refactor the module"}))
 
 			By("Verifying the main container has KELOS_AGENT_TYPE, CODEX_API_KEY, and KELOS_BASE_BRANCH env vars")
-			Expect(mainContainer.Env).To(HaveLen(3))
+			Expect(mainContainer.Env).To(HaveLen(5))
 			Expect(mainContainer.Env[0].Name).To(Equal("KELOS_AGENT_TYPE"))
 			Expect(mainContainer.Env[0].Value).To(Equal("codex"))
 			Expect(mainContainer.Env[1].Name).To(Equal("CODEX_API_KEY"))
@@ -2277,7 +2298,7 @@ var _ = Describe("Task Controller", func() {
 			By("Verifying the Job has CODEX_AUTH_JSON env var")
 			container := createdJob.Spec.Template.Spec.Containers[0]
 			Expect(container.Name).To(Equal(kelos.AgentContainerName))
-			Expect(container.Env).To(HaveLen(2))
+			Expect(container.Env).To(HaveLen(4))
 			Expect(container.Env[0].Name).To(Equal("KELOS_AGENT_TYPE"))
 			Expect(container.Env[0].Value).To(Equal("codex"))
 			Expect(container.Env[1].Name).To(Equal("CODEX_AUTH_JSON"))
@@ -4180,4 +4201,137 @@ var _ = Describe("Task Controller", func() {
 			Expect(strings.Index(agentsMD, "## Environment")).To(BeNumerically("<", strings.Index(agentsMD, "## Identity")))
 		})
 	})
+
+	Context("When continuing a Task without a branch", func() {
+		It("Should run the follow-up on the branch of the continued Task", func() {
+			By("Creating a namespace")
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-task-continue-branch",
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+			By("Creating a Secret with API key")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "anthropic-api-key",
+					Namespace: ns.Name,
+				},
+				StringData: map[string]string{
+					"ANTHROPIC_API_KEY": "test-api-key",
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			By("Creating a Workspace resource")
+			ws := &kelos.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-workspace",
+					Namespace: ns.Name,
+				},
+				Spec: kelos.WorkspaceSpec{
+					Repo: "https://github.com/example/repo.git",
+					Ref:  "main",
+				},
+			}
+			Expect(k8sClient.Create(ctx, ws)).Should(Succeed())
+
+			By("Creating a Task that persists its conversation on feature-1")
+			source := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "continue-source",
+					Namespace: ns.Name,
+				},
+				Spec: kelos.TaskSpec{
+					Type:         "claude-code",
+					Prompt:       "Fix the bug",
+					Branch:       "feature-1",
+					Conversation: &kelos.TaskConversation{},
+					Credentials: &kelos.Credentials{
+						Type:      kelos.CredentialTypeAPIKey,
+						SecretRef: &kelos.SecretReference{Name: "anthropic-api-key"},
+					},
+					WorkspaceRef: &kelos.WorkspaceReference{
+						Name: "test-workspace",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, source)).Should(Succeed())
+
+			By("Simulating completion of the continued Task's Job")
+			sourceJobKey := types.NamespacedName{Name: source.Name, Namespace: ns.Name}
+			sourceJob := &batchv1.Job{}
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sourceJobKey, sourceJob); err != nil {
+					return err
+				}
+				sourceJob.Status.Succeeded = 1
+				return k8sClient.Status().Update(ctx, sourceJob)
+			}, timeout, interval).Should(Succeed())
+
+			sourceKey := types.NamespacedName{Name: source.Name, Namespace: ns.Name}
+			Eventually(func() kelos.TaskPhase {
+				if err := k8sClient.Get(ctx, sourceKey, source); err != nil {
+					return ""
+				}
+				return source.Status.Phase
+			}, timeout, interval).Should(Equal(kelos.TaskPhaseSucceeded))
+
+			By("Creating a follow-up Task without a branch")
+			followUp := &kelos.Task{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "continue-follow-up",
+					Namespace: ns.Name,
+				},
+				Spec: kelos.TaskSpec{
+					Type:         "claude-code",
+					Prompt:       "Also add a regression test",
+					ContinueFrom: source.Name,
+					Credentials: &kelos.Credentials{
+						Type:      kelos.CredentialTypeAPIKey,
+						SecretRef: &kelos.SecretReference{Name: "anthropic-api-key"},
+					},
+					WorkspaceRef: &kelos.WorkspaceReference{
+						Name: "test-workspace",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, followUp)).Should(Succeed())
+
+			By("Verifying the follow-up gets a Job")
+			jobKey := types.NamespacedName{Name: followUp.Name, Namespace: ns.Name}
+			createdJob := &batchv1.Job{}
+			Eventually(func() bool {
+				return k8sClient.Get(ctx, jobKey, createdJob) == nil
+			}, timeout, interval).Should(BeTrue())
+
+			By("Verifying the inherited branch is recorded in status, not spec")
+			followUpKey := types.NamespacedName{Name: followUp.Name, Namespace: ns.Name}
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, followUpKey, followUp); err != nil {
+					return ""
+				}
+				return followUp.Status.InheritedBranch
+			}, timeout, interval).Should(Equal("feature-1"))
+			Expect(followUp.Spec.Branch).To(BeEmpty())
+
+			By("Verifying the API server rejects setting the branch in the spec")
+			followUp.Spec.Branch = "feature-1"
+			err := k8sClient.Update(ctx, followUp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("immutable"))
+
+			By("Verifying the Job checks out the inherited branch")
+			var branchSetup *corev1.Container
+			for i := range createdJob.Spec.Template.Spec.InitContainers {
+				if createdJob.Spec.Template.Spec.InitContainers[i].Name == "branch-setup" {
+					branchSetup = &createdJob.Spec.Template.Spec.InitContainers[i]
+					break
+				}
+			}
+			Expect(branchSetup).NotTo(BeNil(), "Expected branch-setup init container")
+			Expect(branchSetup.Env).To(ContainElement(corev1.EnvVar{Name: "KELOS_BRANCH", Value: "feature-1"}))
+		})
+	})
 })
@@ -144,6 +144,168 @@ var _ = Describe("TaskBudget API validation", func() {
 		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
 	})
 
+	It("accepts a Weekly period with a week start day", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "weekly", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodWeekly, WeekStart: "Sunday"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).Should(Succeed())
+	})
+
+	It("rejects weekStart on a non-Weekly period", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "daily-week-start", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily, WeekStart: "Monday"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("rejects an unknown weekStart day", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-week-start", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodWeekly, WeekStart: "Someday"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("accepts a Monthly period", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "monthly", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodMonthly, Timezone: "Europe/Berlin"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).Should(Succeed())
+	})
+
+	It("accepts a Rolling period with a window", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "rolling", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodRolling, Window: "7d"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).Should(Succeed())
+	})
+
+	It("rejects a Rolling period without a window", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "rolling-no-window", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodRolling},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("rejects a window on a non-Rolling period", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "daily-window", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily, Window: "24h"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("rejects a malformed window", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-window", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodRolling, Window: "1w"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("rejects a window longer than 31 days", func() {
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "long-window", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodRolling, Window: "745h"},
+				MaxCostUSD:   quantity("50"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("accepts a softLimitPercent", func() {
+		softLimit := int32(80)
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "soft-limit", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:           kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily},
+				MaxCostUSD:       quantity("50"),
+				SoftLimitPercent: &softLimit,
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).Should(Succeed())
+	})
+
+	It("rejects a softLimitPercent of 100", func() {
+		softLimit := int32(100)
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "soft-limit-100", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:           kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily},
+				MaxCostUSD:       quantity("50"),
+				SoftLimitPercent: &softLimit,
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
+	It("accepts a TaskBudget with only maxRunning", func() {
+		maxRunning := int32(5)
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "max-running", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily},
+				MaxRunning:   &maxRunning,
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).Should(Succeed())
+	})
+
+	It("rejects a negative maxRunning", func() {
+		maxRunning := int32(-1)
+		budget := &kelos.TaskBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "negative-max-running", Namespace: ns},
+			Spec: kelos.TaskBudgetSpec{
+				TaskSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Period:       kelos.BudgetPeriod{Type: kelos.BudgetPeriodDaily},
+				MaxRunning:   &maxRunning,
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).ShouldNot(Succeed())
+	})
+
 	It("rejects a negative maxCostUSD", func() {
 		budget := &kelos.TaskBudget{
 			ObjectMeta: metav1.ObjectMeta{Name: "negative-cost", Namespace: ns},